@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"sort"
+
+	"why-pptx/internal/xmlcancel"
 )
 
 type RangeKind string
@@ -14,14 +16,21 @@ const (
 	KindCategories RangeKind = "categories"
 	KindValues     RangeKind = "values"
 	KindSeriesName RangeKind = "seriesName"
+	// KindDataLabels is a c15:datalabelsRange; its cache is the
+	// c15:dlblRangeCache next to the formula.
+	KindDataLabels RangeKind = "dataLabels"
 )
 
 type Range struct {
 	Kind        RangeKind
 	SeriesIndex int
-	Sheet       string
-	StartCell   string
-	EndCell     string
+	// UnionIndex is the position of the range within a union formula. Ranges
+	// with UnionIndex > 0 follow the segment before them, and their values are
+	// appended to it.
+	UnionIndex int
+	Sheet      string
+	StartCell  string
+	EndCell    string
 }
 
 type Dependencies struct {
@@ -31,8 +40,43 @@ type Dependencies struct {
 
 type ValueProvider func(kind RangeKind, sheet, start, end string) ([]string, error)
 
+// SyncCaches rewrites every cache referenced by deps from provider values,
+// keeping the formatCode, extLst, and other children of each cache besides
+// its points. The multiLvlStrCache of multi-level categories is left as it
+// is.
 func SyncCaches(chartXML []byte, deps Dependencies, provider ValueProvider) ([]byte, error) {
-	if deps.ChartType != "bar" && deps.ChartType != "line" && deps.ChartType != "pie" && deps.ChartType != "area" {
+	return SyncCachesWithCancel(chartXML, deps, provider, nil)
+}
+
+// SyncCachesWithCancel stops with xmlcancel.ErrCanceled as soon as cancel is
+// set, checking it between tokens of the chart XML.
+func SyncCachesWithCancel(chartXML []byte, deps Dependencies, provider ValueProvider, cancel *xmlcancel.Flag) ([]byte, error) {
+	return syncCaches(chartXML, deps, provider, cancel, nil)
+}
+
+// SyncCachesReport is SyncCachesWithCancel that also reports, per cache, how
+// the rewrite differs from the cache it replaced, so a caller can leave a
+// chart whose caches are already current untouched. Like RepairCaches it
+// carries the children of a cache other than its points over.
+func SyncCachesReport(chartXML []byte, deps Dependencies, provider ValueProvider, cancel *xmlcancel.Flag) ([]byte, []CacheRepair, error) {
+	return RepairCaches(chartXML, deps, provider, cancel)
+}
+
+// RepairCaches rewrites every cache referenced by deps from provider values.
+// Existing points are read only to report what changed; their ptCount and idx
+// are never trusted, so corrupt caches are replaced wholesale. The other
+// children, formatCode and extLst among them, are carried over unchanged.
+func RepairCaches(chartXML []byte, deps Dependencies, provider ValueProvider, cancel *xmlcancel.Flag) ([]byte, []CacheRepair, error) {
+	repairs := make([]CacheRepair, 0)
+	out, err := syncCaches(chartXML, deps, provider, cancel, &repairs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, repairs, nil
+}
+
+func syncCaches(chartXML []byte, deps Dependencies, provider ValueProvider, cancel *xmlcancel.Flag, repairs *[]CacheRepair) ([]byte, error) {
+	if deps.ChartType != "bar" && deps.ChartType != "line" && deps.ChartType != "pie" && deps.ChartType != "doughnut" && deps.ChartType != "area" && deps.ChartType != "stock" {
 		return nil, fmt.Errorf("unsupported chart type %q", deps.ChartType)
 	}
 
@@ -64,8 +108,12 @@ func SyncCaches(chartXML []byte, deps Dependencies, provider ValueProvider) ([]b
 		targetChart = "lineChart"
 	} else if deps.ChartType == "pie" {
 		targetChart = "pieChart"
+	} else if deps.ChartType == "doughnut" {
+		targetChart = "doughnutChart"
 	} else if deps.ChartType == "area" {
 		targetChart = "areaChart"
+	} else if deps.ChartType == "stock" {
+		targetChart = "stockChart"
 	}
 
 	decoder := xml.NewDecoder(bytes.NewReader(chartXML))
@@ -91,6 +139,9 @@ func SyncCaches(chartXML []byte, deps Dependencies, provider ValueProvider) ([]b
 	refHasCache := false
 
 	for {
+		if err := cancel.Err(); err != nil {
+			return nil, err
+		}
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
@@ -122,7 +173,12 @@ func SyncCaches(chartXML []byte, deps Dependencies, provider ValueProvider) ([]b
 					txDepth++
 				}
 
-				if tok.Name.Local == "strRef" || tok.Name.Local == "numRef" {
+				if tok.Name.Local == "multiLvlStrRef" && catDepth > 0 && seriesHasData(seriesData, currentSeries, KindCategories) {
+					// Flat values cannot rebuild its levels, so the
+					// multiLvlStrCache is copied through as it is.
+					markRefSeen(seriesData, currentSeries, KindCategories)
+				}
+				if tok.Name.Local == "strRef" || tok.Name.Local == "numRef" || tok.Name.Local == "datalabelsRange" {
 					kind := refKindFor(tok.Name.Local, catDepth, valDepth, txDepth)
 					if kind != "" && seriesHasData(seriesData, currentSeries, kind) {
 						markRefSeen(seriesData, currentSeries, kind)
@@ -134,17 +190,21 @@ func SyncCaches(chartXML []byte, deps Dependencies, provider ValueProvider) ([]b
 				}
 			}
 
-			if inTarget && inRef && (tok.Name.Local == "strCache" || tok.Name.Local == "numCache") {
+			if inTarget && inRef && (tok.Name.Local == "strCache" || tok.Name.Local == "numCache" || tok.Name.Local == "dlblRangeCache") {
 				if cacheMatchesRef(refKind, tok.Name.Local) {
 					values := seriesValues(seriesData, currentSeries, refKind)
-					if err := writeCache(encoder, tok.Name, tok.Attr, values, chartNS); err != nil {
+					old, err := scanCache(decoder)
+					if err != nil {
 						return nil, err
 					}
-					refHasCache = true
-					markCacheUpdated(seriesData, currentSeries, refKind)
-					if err := skipElement(decoder); err != nil {
+					if repairs != nil {
+						*repairs = append(*repairs, old.repair(currentSeries, refKind, values))
+					}
+					if err := writeCache(encoder, tok.Name, tok.Attr, old, values, chartNS); err != nil {
 						return nil, err
 					}
+					refHasCache = true
+					markCacheUpdated(seriesData, currentSeries, refKind)
 					continue
 				}
 			}
@@ -173,11 +233,20 @@ func SyncCaches(chartXML []byte, deps Dependencies, provider ValueProvider) ([]b
 					if !refHasCache {
 						values := seriesValues(seriesData, currentSeries, refKind)
 						if len(values) > 0 || seriesHasData(seriesData, currentSeries, refKind) {
-							cacheName := cacheNameFor(refKind, chartNS)
-							if err := writeCache(encoder, cacheName, nil, values, chartNS); err != nil {
+							cacheName := cacheNameFor(refKind, refName, chartNS)
+							if err := writeCache(encoder, cacheName, nil, cacheScan{}, values, chartNS); err != nil {
 								return nil, err
 							}
 							markCacheUpdated(seriesData, currentSeries, refKind)
+							if repairs != nil {
+								*repairs = append(*repairs, CacheRepair{
+									SeriesIndex: currentSeries,
+									Kind:        refKind,
+									OldPtCount:  -1,
+									NewPoints:   len(values),
+									Added:       true,
+								})
+							}
 						}
 					}
 					inRef = false
@@ -233,14 +302,17 @@ type seriesCache struct {
 	categories []string
 	values     []string
 	name       []string
+	labels     []string
 
-	catRefSeen bool
-	valRefSeen bool
-	txRefSeen  bool
+	catRefSeen   bool
+	valRefSeen   bool
+	txRefSeen    bool
+	labelRefSeen bool
 
-	catUpdated bool
-	valUpdated bool
-	txUpdated  bool
+	catUpdated   bool
+	valUpdated   bool
+	txUpdated    bool
+	labelUpdated bool
 }
 
 func buildSeriesData(deps Dependencies, provider ValueProvider) (map[int]*seriesCache, error) {
@@ -260,6 +332,15 @@ func buildSeriesData(deps Dependencies, provider ValueProvider) (map[int]*series
 			series[r.SeriesIndex] = entry
 		}
 
+		if r.UnionIndex > 0 {
+			target := seriesSlot(entry, r.Kind)
+			if target == nil || *target == nil {
+				return nil, fmt.Errorf("union range %d of series %d has no first range", r.UnionIndex, r.SeriesIndex)
+			}
+			*target = append(*target, values...)
+			continue
+		}
+
 		switch r.Kind {
 		case KindCategories:
 			if entry.categories != nil {
@@ -276,6 +357,11 @@ func buildSeriesData(deps Dependencies, provider ValueProvider) (map[int]*series
 				return nil, fmt.Errorf("duplicate series name range for series %d", r.SeriesIndex)
 			}
 			entry.name = values
+		case KindDataLabels:
+			if entry.labels != nil {
+				return nil, fmt.Errorf("duplicate data label range for series %d", r.SeriesIndex)
+			}
+			entry.labels = values
 		default:
 			return nil, fmt.Errorf("unsupported range kind %q", r.Kind)
 		}
@@ -284,6 +370,22 @@ func buildSeriesData(deps Dependencies, provider ValueProvider) (map[int]*series
 	return series, nil
 }
 
+// seriesSlot returns the values entry holds for kind, or nil for an unknown
+// kind.
+func seriesSlot(entry *seriesCache, kind RangeKind) *[]string {
+	switch kind {
+	case KindCategories:
+		return &entry.categories
+	case KindValues:
+		return &entry.values
+	case KindSeriesName:
+		return &entry.name
+	case KindDataLabels:
+		return &entry.labels
+	}
+	return nil
+}
+
 func seriesHasData(series map[int]*seriesCache, index int, kind RangeKind) bool {
 	entry := series[index]
 	if entry == nil {
@@ -296,6 +398,8 @@ func seriesHasData(series map[int]*seriesCache, index int, kind RangeKind) bool
 		return entry.values != nil
 	case KindSeriesName:
 		return entry.name != nil
+	case KindDataLabels:
+		return entry.labels != nil
 	default:
 		return false
 	}
@@ -313,6 +417,8 @@ func seriesValues(series map[int]*seriesCache, index int, kind RangeKind) []stri
 		return entry.values
 	case KindSeriesName:
 		return entry.name
+	case KindDataLabels:
+		return entry.labels
 	default:
 		return nil
 	}
@@ -330,6 +436,8 @@ func markRefSeen(series map[int]*seriesCache, index int, kind RangeKind) {
 		entry.valRefSeen = true
 	case KindSeriesName:
 		entry.txRefSeen = true
+	case KindDataLabels:
+		entry.labelRefSeen = true
 	}
 }
 
@@ -345,6 +453,8 @@ func markCacheUpdated(series map[int]*seriesCache, index int, kind RangeKind) {
 		entry.valUpdated = true
 	case KindSeriesName:
 		entry.txUpdated = true
+	case KindDataLabels:
+		entry.labelUpdated = true
 	}
 }
 
@@ -362,6 +472,9 @@ func validateSeries(series map[int]*seriesCache, index int) error {
 	if entry.name != nil && !entry.txRefSeen {
 		return fmt.Errorf("missing series name reference for series %d", index)
 	}
+	if entry.labels != nil && !entry.labelRefSeen {
+		return fmt.Errorf("missing data label range for series %d", index)
+	}
 	return nil
 }
 
@@ -421,6 +534,8 @@ func refKindFor(refName string, catDepth, valDepth, txDepth int) RangeKind {
 		if valDepth > 0 {
 			return KindValues
 		}
+	case "datalabelsRange":
+		return KindDataLabels
 	}
 	return ""
 }
@@ -431,24 +546,37 @@ func cacheMatchesRef(kind RangeKind, cacheName string) bool {
 		return cacheName == "strCache"
 	case KindValues:
 		return cacheName == "numCache"
+	case KindDataLabels:
+		return cacheName == "dlblRangeCache"
 	default:
 		return false
 	}
 }
 
-func cacheNameFor(kind RangeKind, space string) xml.Name {
-	local := "strCache"
-	if kind == KindValues {
-		local = "numCache"
+// cacheNameFor names the cache created for a ref that has none. A data label
+// cache takes the namespace of its c15:datalabelsRange; the others belong to
+// the chart namespace.
+func cacheNameFor(kind RangeKind, ref xml.Name, space string) xml.Name {
+	switch kind {
+	case KindValues:
+		return xml.Name{Space: space, Local: "numCache"}
+	case KindDataLabels:
+		return xml.Name{Space: ref.Space, Local: "dlblRangeCache"}
 	}
-	return xml.Name{Space: space, Local: local}
+	return xml.Name{Space: space, Local: "strCache"}
 }
 
-func writeCache(encoder *xml.Encoder, name xml.Name, attrs []xml.Attr, values []string, space string) error {
+// writeCache writes a cache holding values. The children of the old cache
+// other than ptCount and pt, formatCode and extLst among them, are written
+// back unchanged on the same side of the points.
+func writeCache(encoder *xml.Encoder, name xml.Name, attrs []xml.Attr, old cacheScan, values []string, space string) error {
 	start := xml.StartElement{Name: name, Attr: attrs}
 	if err := encoder.EncodeToken(start); err != nil {
 		return err
 	}
+	if err := encodeTokens(encoder, old.leading); err != nil {
+		return err
+	}
 
 	countAttr := xml.Attr{Name: xml.Name{Local: "val"}, Value: fmt.Sprintf("%d", len(values))}
 	ptCount := xml.StartElement{Name: xml.Name{Space: space, Local: "ptCount"}, Attr: []xml.Attr{countAttr}}
@@ -484,25 +612,20 @@ func writeCache(encoder *xml.Encoder, name xml.Name, attrs []xml.Attr, values []
 		}
 	}
 
+	if err := encodeTokens(encoder, old.trailing); err != nil {
+		return err
+	}
 	if err := encoder.EncodeToken(xml.EndElement{Name: name}); err != nil {
 		return err
 	}
 	return nil
 }
 
-func skipElement(decoder *xml.Decoder) error {
-	depth := 1
-	for depth > 0 {
-		token, err := decoder.Token()
-		if err != nil {
+func encodeTokens(encoder *xml.Encoder, tokens []xml.Token) error {
+	for _, token := range tokens {
+		if err := encoder.EncodeToken(token); err != nil {
 			return err
 		}
-		switch token.(type) {
-		case xml.StartElement:
-			depth++
-		case xml.EndElement:
-			depth--
-		}
 	}
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"io"
+	"strings"
 	"testing"
 )
 
@@ -315,6 +316,201 @@ func TestSyncCachesAreaMismatchedCategoriesErrors(t *testing.T) {
 	}
 }
 
+func TestSyncCachesConcatenatesUnionRanges(t *testing.T) {
+	xml := `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart><c:plotArea><c:barChart><c:ser>` +
+		`<c:cat><c:strRef><c:f>Sheet1!$A$2:$A$4</c:f><c:strCache><c:ptCount val="1"/><c:pt idx="0"><c:v>Old</c:v></c:pt></c:strCache></c:strRef></c:cat>` +
+		`<c:val><c:numRef><c:f>(Sheet1!$B$2:$B$3,Sheet1!$B$9)</c:f><c:numCache><c:ptCount val="1"/><c:pt idx="0"><c:v>0</c:v></c:pt></c:numCache></c:numRef></c:val>` +
+		`</c:ser></c:barChart></c:plotArea></c:chart></c:chartSpace>`
+	deps := Dependencies{
+		ChartType: "bar",
+		Ranges: []Range{
+			{Kind: KindCategories, Sheet: "Sheet1", StartCell: "A2", EndCell: "A4"},
+			{Kind: KindValues, Sheet: "Sheet1", StartCell: "B2", EndCell: "B3"},
+			{Kind: KindValues, UnionIndex: 1, Sheet: "Sheet1", StartCell: "B9", EndCell: "B9"},
+		},
+	}
+	values := map[string][]string{"A2": {"a", "b", "c"}, "B2": {"1", "2"}, "B9": {"9"}}
+	out, err := SyncCaches([]byte(xml), deps, func(_ RangeKind, _, start, _ string) ([]string, error) {
+		return values[start], nil
+	})
+	if err != nil {
+		t.Fatalf("SyncCaches: %v", err)
+	}
+	if _, nums := extractCacheValues(t, out); len(nums) != 3 || nums[0] != "1" || nums[2] != "9" {
+		t.Fatalf("unexpected values cache: %v", nums)
+	}
+
+	deps.Ranges = []Range{deps.Ranges[0], deps.Ranges[2]}
+	if _, err := SyncCaches([]byte(xml), deps, func(_ RangeKind, _, start, _ string) ([]string, error) {
+		return values[start], nil
+	}); err == nil {
+		t.Fatalf("expected an error for a union segment without its first range")
+	}
+}
+
+func TestSyncCachesKeepsMultiLevelCategoryCache(t *testing.T) {
+	xml := `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart><c:plotArea><c:barChart><c:ser>` +
+		`<c:cat><c:multiLvlStrRef><c:f>Sheet1!$A$2:$B$3</c:f><c:multiLvlStrCache><c:ptCount val="2"/>` +
+		`<c:lvl><c:pt idx="0"><c:v>H1</c:v></c:pt><c:pt idx="1"><c:v>H2</c:v></c:pt></c:lvl>` +
+		`<c:lvl><c:pt idx="0"><c:v>2024</c:v></c:pt></c:lvl>` +
+		`</c:multiLvlStrCache></c:multiLvlStrRef></c:cat>` +
+		`<c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f><c:numCache><c:ptCount val="2"/><c:pt idx="0"><c:v>0</c:v></c:pt><c:pt idx="1"><c:v>0</c:v></c:pt></c:numCache></c:numRef></c:val>` +
+		`</c:ser></c:barChart></c:plotArea></c:chart></c:chartSpace>`
+	deps := Dependencies{
+		ChartType: "bar",
+		Ranges: []Range{
+			{Kind: KindCategories, Sheet: "Sheet1", StartCell: "A2", EndCell: "B3"},
+			{Kind: KindValues, Sheet: "Sheet1", StartCell: "C2", EndCell: "C3"},
+		},
+	}
+	values := map[string][]string{"A2": {"2024 H1", "H2"}, "C2": {"10", "20"}}
+	out, err := SyncCaches([]byte(xml), deps, func(_ RangeKind, _, start, _ string) ([]string, error) {
+		return values[start], nil
+	})
+	if err != nil {
+		t.Fatalf("SyncCaches: %v", err)
+	}
+	cats, nums := extractCacheValues(t, out)
+	if len(cats) != 0 || len(nums) != 2 || nums[0] != "10" || nums[1] != "20" {
+		t.Fatalf("expected only the values cache to be rewritten, got cats=%v nums=%v", cats, nums)
+	}
+	for _, want := range []string{"multiLvlStrCache", "lvl", ">H2<", ">2024<"} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("expected the multi-level cache to be kept, missing %q in %s", want, out)
+		}
+	}
+}
+
+func TestSyncCachesWritesDataLabelRangeCache(t *testing.T) {
+	const c15 = "http://schemas.microsoft.com/office/drawing/2012/chart"
+	xml := `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:c15="` + c15 + `"><c:chart><c:plotArea><c:barChart><c:ser>` +
+		`<c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:ptCount val="2"/><c:pt idx="0"><c:v>1</c:v></c:pt><c:pt idx="1"><c:v>2</c:v></c:pt></c:numCache></c:numRef></c:val>` +
+		`<c:extLst><c:ext uri="{02D57815-91ED-43cb-92C2-25804820EDAC}"><c15:datalabelsRange><c15:f>Sheet1!$C$2:$C$3</c15:f>%s</c15:datalabelsRange></c:ext></c:extLst>` +
+		`</c:ser></c:barChart></c:plotArea></c:chart></c:chartSpace>`
+	deps := Dependencies{
+		ChartType: "bar",
+		Ranges: []Range{
+			{Kind: KindValues, Sheet: "Sheet1", StartCell: "B2", EndCell: "B3"},
+			{Kind: KindDataLabels, Sheet: "Sheet1", StartCell: "C2", EndCell: "C3"},
+		},
+	}
+	values := map[string][]string{"B2": {"1", "2"}, "C2": {"low", "high"}}
+	provider := func(_ RangeKind, _, start, _ string) ([]string, error) {
+		return values[start], nil
+	}
+
+	stale := `<c15:dlblRangeCache><c:ptCount val="1"/><c:pt idx="0"><c:v>old</c:v></c:pt></c15:dlblRangeCache>`
+	for name, cache := range map[string]string{"stale": stale, "missing": ""} {
+		out, err := SyncCaches([]byte(strings.Replace(xml, "%s", cache, 1)), deps, provider)
+		if err != nil {
+			t.Fatalf("%s: SyncCaches: %v", name, err)
+		}
+		cacheName, labels := readDataLabelCache(t, out)
+		if cacheName.Space != c15 {
+			t.Fatalf("%s: expected the cache in the c15 namespace, got %#v", name, cacheName)
+		}
+		if len(labels) != 2 || labels[0] != "low" || labels[1] != "high" {
+			t.Fatalf("%s: unexpected label cache: %v", name, labels)
+		}
+	}
+}
+
+// readDataLabelCache returns the name and point values of the first
+// dlblRangeCache in data.
+func readDataLabelCache(t *testing.T, data []byte) (xml.Name, []string) {
+	t.Helper()
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var name xml.Name
+	var values []string
+	inCache := false
+	inValue := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if tok.Name.Local == "dlblRangeCache" {
+				name = tok.Name
+				inCache = true
+			} else if inCache && tok.Name.Local == "v" {
+				inValue = true
+				values = append(values, "")
+			}
+		case xml.CharData:
+			if inValue {
+				values[len(values)-1] += string(tok)
+			}
+		case xml.EndElement:
+			if tok.Name.Local == "dlblRangeCache" {
+				inCache = false
+			} else if tok.Name.Local == "v" {
+				inValue = false
+			}
+		}
+	}
+	return name, values
+}
+
+func TestRepairCachesReportsAndKeepsFormatCode(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:lineChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:formatCode>#,##0</c:formatCode><c:ptCount val="x"/><c:pt idx="3"><c:v>1</c:v></c:pt><c:pt idx="3"><c:v>2</c:v></c:pt></c:numCache></c:numRef></c:val>
+        </c:ser>
+      </c:lineChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	deps := Dependencies{
+		ChartType: "line",
+		Ranges: []Range{
+			{Kind: KindCategories, SeriesIndex: 0, Sheet: "Sheet1", StartCell: "A2", EndCell: "A3"},
+			{Kind: KindValues, SeriesIndex: 0, Sheet: "Sheet1", StartCell: "B2", EndCell: "B3"},
+		},
+	}
+	provider := func(kind RangeKind, sheet, start, end string) ([]string, error) {
+		if kind == KindCategories {
+			return []string{"Cat1", "Cat2"}, nil
+		}
+		return []string{"1", "2"}, nil
+	}
+
+	out, repairs, err := RepairCaches([]byte(xml), deps, provider, nil)
+	if err != nil {
+		t.Fatalf("RepairCaches: %v", err)
+	}
+	if len(repairs) != 2 {
+		t.Fatalf("expected 2 repairs, got %#v", repairs)
+	}
+	val := repairs[1]
+	if val.Kind != KindValues || val.OldPtCount != -1 || !val.IdxRenumbered || val.ValuesChanged || val.FormatCode != "#,##0" || !val.Changed() {
+		t.Fatalf("unexpected value repair: %#v", val)
+	}
+	cat := repairs[0]
+	if cat.Kind != KindCategories || !cat.Added || cat.NewPoints != 2 {
+		t.Fatalf("unexpected category repair: %#v", cat)
+	}
+	if !bytes.Contains(out, []byte(">#,##0</formatCode>")) {
+		t.Fatalf("expected formatCode to be kept:\n%s", out)
+	}
+
+	cats, nums := extractCacheValues(t, out)
+	if len(cats) != 2 || len(nums) != 2 || nums[1] != "2" {
+		t.Fatalf("unexpected caches: cats=%v nums=%v", cats, nums)
+	}
+}
+
 func extractCacheValues(t *testing.T, data []byte) ([]string, []string) {
 	t.Helper()
 
@@ -373,3 +569,154 @@ func extractCacheValues(t *testing.T, data []byte) ([]string, []string) {
 
 	return cats, nums
 }
+
+func TestSyncCachesKeepsFormatCode(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="1"/></c:strCache></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:formatCode>0.0%</c:formatCode><c:ptCount val="1"/><c:pt idx="0"><c:v>0.1</c:v></c:pt></c:numCache></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	deps := Dependencies{
+		ChartType: "bar",
+		Ranges: []Range{
+			{Kind: KindCategories, SeriesIndex: 0, Sheet: "Sheet1", StartCell: "A2", EndCell: "A3"},
+			{Kind: KindValues, SeriesIndex: 0, Sheet: "Sheet1", StartCell: "B2", EndCell: "B3"},
+		},
+	}
+	provider := func(kind RangeKind, sheet, start, end string) ([]string, error) {
+		if kind == KindCategories {
+			return []string{"Cat1", "Cat2"}, nil
+		}
+		return []string{"0.25", "0.5"}, nil
+	}
+
+	out, err := SyncCaches([]byte(xml), deps, provider)
+	if err != nil {
+		t.Fatalf("SyncCaches: %v", err)
+	}
+	if !bytes.Contains(out, []byte(">0.0%</formatCode>")) {
+		t.Fatalf("expected formatCode to be kept:\n%s", out)
+	}
+	if !bytes.Contains(out, []byte(">0.5</v>")) {
+		t.Fatalf("expected the values to be rewritten:\n%s", out)
+	}
+}
+
+func TestSyncCachesKeepsCacheChildrenInPlace(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Old</c:v></c:pt><c:extLst><c:ext uri="{STR}"/></c:extLst></c:strCache></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:formatCode>0.0%;[Red]-0.0% </c:formatCode><c:ptCount val="1"/><c:pt idx="0"><c:v>0.1</c:v></c:pt><c:extLst><c:ext uri="{NUM}"><c:note>kept</c:note></c:ext></c:extLst></c:numCache></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	deps := Dependencies{
+		ChartType: "bar",
+		Ranges: []Range{
+			{Kind: KindCategories, SeriesIndex: 0, Sheet: "Sheet1", StartCell: "A2", EndCell: "A3"},
+			{Kind: KindValues, SeriesIndex: 0, Sheet: "Sheet1", StartCell: "B2", EndCell: "B3"},
+		},
+	}
+	provider := func(kind RangeKind, sheet, start, end string) ([]string, error) {
+		if kind == KindCategories {
+			return []string{"Cat1", "Cat2"}, nil
+		}
+		return []string{"0.25", "0.5"}, nil
+	}
+
+	out, err := SyncCaches([]byte(xml), deps, provider)
+	if err != nil {
+		t.Fatalf("SyncCaches: %v", err)
+	}
+	got := cacheChildren(t, out)
+	want := map[string]string{
+		"strCache": "ptCount pt=Cat1 pt=Cat2 extLst=ext[{STR}]",
+		"numCache": "formatCode=0.0%;[Red]-0.0%  ptCount pt=0.25 pt=0.5 extLst=ext[{NUM}]=kept",
+	}
+	for cache, children := range want {
+		if got[cache] != children {
+			t.Fatalf("%s children = %q, want %q\n%s", cache, got[cache], children, out)
+		}
+	}
+
+	// A second sync finds the kept children where the first put them.
+	again, err := SyncCaches(out, deps, provider)
+	if err != nil {
+		t.Fatalf("SyncCaches: %v", err)
+	}
+	if children := cacheChildren(t, again); children["strCache"] != want["strCache"] || children["numCache"] != want["numCache"] {
+		t.Fatalf("second sync moved the kept children: %q", children)
+	}
+}
+
+// cacheChildren describes the children of each strCache and numCache in
+// order, as "name", "name=text", or "name[attr]" for the ext uri.
+func cacheChildren(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	out := make(map[string]string)
+	cache := ""
+	depth := 0
+	var parts []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if tok.Name.Local == "strCache" || tok.Name.Local == "numCache" {
+				cache, depth, parts = tok.Name.Local, 0, nil
+				continue
+			}
+			if cache == "" {
+				continue
+			}
+			depth++
+			switch {
+			case depth == 1:
+				parts = append(parts, tok.Name.Local)
+			case tok.Name.Local == "ext":
+				for _, attr := range tok.Attr {
+					if attr.Name.Local == "uri" {
+						parts[len(parts)-1] += "=ext[" + attr.Value + "]"
+					}
+				}
+			}
+		case xml.CharData:
+			if cache != "" && depth > 0 && (depth > 1 || len(bytes.TrimSpace(tok)) > 0) {
+				parts[len(parts)-1] += "=" + string(tok)
+			}
+		case xml.EndElement:
+			if cache == "" {
+				continue
+			}
+			if depth == 0 {
+				out[cache] = strings.Join(parts, " ")
+				cache = ""
+				continue
+			}
+			depth--
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package chartcache
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// CacheRepair describes one cache rewritten by RepairCaches.
+type CacheRepair struct {
+	SeriesIndex int
+	Kind        RangeKind
+	// OldPtCount is the ptCount found in the old cache, or -1 when it was
+	// missing or not an integer.
+	OldPtCount int
+	OldPoints  int
+	NewPoints  int
+	// IdxRenumbered reports that the old pt idx values were not 0..n-1.
+	IdxRenumbered bool
+	// IdxOutOfRange reports that an old pt idx was unreadable, repeated, or
+	// not below NewPoints, so the old points do not fit the range.
+	IdxOutOfRange bool
+	ValuesChanged bool
+	// Added reports that the ref had no cache and one was created.
+	Added      bool
+	FormatCode string
+}
+
+// Changed reports whether the rewrite differs from the old cache.
+func (r CacheRepair) Changed() bool {
+	return r.Added || r.IdxRenumbered || r.ValuesChanged || r.OldPtCount != r.NewPoints || r.OldPoints != r.NewPoints
+}
+
+type cacheScan struct {
+	ptCount    int
+	idxInOrder bool
+	idx        []int
+	values     []string
+	formatCode string
+	// leading and trailing hold the tokens of the children other than
+	// ptCount and pt, such as formatCode and extLst, found before and after
+	// the points, for the rewrite to carry over in place.
+	leading  []xml.Token
+	trailing []xml.Token
+}
+
+// scanCache consumes a strCache/numCache element after its start token and
+// records its contents without validating them.
+func scanCache(decoder *xml.Decoder) (cacheScan, error) {
+	scan := cacheScan{ptCount: -1, idxInOrder: true}
+	depth := 1
+	inValue := false
+	inFormatCode := false
+	var buf strings.Builder
+	// kept is the list the child being read is copied to, if any.
+	var kept *[]xml.Token
+	pointsSeen := false
+
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return cacheScan{}, err
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				if tok.Name.Local == "ptCount" || tok.Name.Local == "pt" {
+					pointsSeen = true
+				} else if pointsSeen {
+					kept = &scan.trailing
+				} else {
+					kept = &scan.leading
+				}
+			}
+			switch {
+			case depth == 2 && tok.Name.Local == "ptCount":
+				for _, attr := range tok.Attr {
+					if attr.Name.Local != "val" {
+						continue
+					}
+					if count, err := strconv.Atoi(strings.TrimSpace(attr.Value)); err == nil {
+						scan.ptCount = count
+					}
+				}
+			case depth == 2 && tok.Name.Local == "formatCode":
+				inFormatCode = true
+				buf.Reset()
+			case depth == 2 && tok.Name.Local == "pt":
+				idx := -1
+				for _, attr := range tok.Attr {
+					if attr.Name.Local == "idx" {
+						if value, err := strconv.Atoi(strings.TrimSpace(attr.Value)); err == nil {
+							idx = value
+						}
+					}
+				}
+				if idx != len(scan.values) {
+					scan.idxInOrder = false
+				}
+				scan.idx = append(scan.idx, idx)
+				scan.values = append(scan.values, "")
+			case depth == 3 && tok.Name.Local == "v" && len(scan.values) > 0:
+				inValue = true
+				buf.Reset()
+			default:
+				if inFormatCode {
+					// Nested markup inside formatCode is not a readable code.
+					inFormatCode = false
+					buf.Reset()
+				}
+			}
+		case xml.CharData:
+			if inValue || inFormatCode {
+				buf.Write(tok)
+			}
+		case xml.EndElement:
+			if inValue && tok.Name.Local == "v" {
+				scan.values[len(scan.values)-1] = buf.String()
+				inValue = false
+			}
+			if inFormatCode && tok.Name.Local == "formatCode" {
+				scan.formatCode = strings.TrimSpace(buf.String())
+				inFormatCode = false
+			}
+			depth--
+		}
+		if kept != nil {
+			*kept = append(*kept, xml.CopyToken(token))
+			if _, ok := token.(xml.EndElement); ok && depth == 1 {
+				kept = nil
+			}
+		}
+	}
+	return scan, nil
+}
+
+func (s cacheScan) repair(seriesIndex int, kind RangeKind, values []string) CacheRepair {
+	return CacheRepair{
+		SeriesIndex:   seriesIndex,
+		Kind:          kind,
+		OldPtCount:    s.ptCount,
+		OldPoints:     len(s.values),
+		NewPoints:     len(values),
+		IdxRenumbered: !s.idxInOrder,
+		IdxOutOfRange: !s.idxFits(len(values)),
+		ValuesChanged: !equalStrings(s.values, values),
+		FormatCode:    s.formatCode,
+	}
+}
+
+// idxFits reports whether every scanned pt idx is distinct and in [0, n).
+func (s cacheScan) idxFits(n int) bool {
+	seen := make(map[int]struct{}, len(s.idx))
+	for _, idx := range s.idx {
+		if idx < 0 || idx >= n {
+			return false
+		}
+		if _, ok := seen[idx]; ok {
+			return false
+		}
+		seen[idx] = struct{}{}
+	}
+	return true
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"errors"
 	"path"
-	"sort"
 	"strings"
 
 	"why-pptx/internal/ooxmlpkg"
@@ -12,22 +11,32 @@ import (
 )
 
 type ChartRef struct {
+	// SlidePath is the part that shows the chart: a slide, or the layout,
+	// master, or notes slide Source names.
 	SlidePath string
-	ChartPath string
+	Source    string
+	// SlideIndex is the 1-based position of the slide in the presentation,
+	// or 0 for a slide part p:sldIdLst leaves out.
+	SlideIndex int
+	ChartPath  string
 }
 
 type EmbeddedChart struct {
 	SlidePath    string
+	Source       string
+	SlideIndex   int
 	ChartPath    string
 	WorkbookPath string
 }
 
 type SkippedChart struct {
-	SlidePath string
-	ChartPath string
-	Reason    string
-	Target    string
-	RelsPath  string
+	SlidePath  string
+	Source     string
+	SlideIndex int
+	ChartPath  string
+	Reason     string
+	Target     string
+	RelsPath   string
 }
 
 type PartReader interface {
@@ -35,60 +44,78 @@ type PartReader interface {
 	ReadPart(name string) ([]byte, error)
 }
 
+// DiscoverChartRefs lists the chart references of every slide in
+// presentation order, followed by those of the slide layouts, slide
+// masters, and notes slides.
 func DiscoverChartRefs(pkg PartReader) ([]ChartRef, error) {
-	parts, err := pkg.ListParts()
-	if err != nil {
-		return nil, err
-	}
+	return DiscoverChartRefsOrder(pkg, OrderPresentation)
+}
 
-	slides := make([]string, 0)
-	for _, part := range parts {
-		match, _ := path.Match("ppt/slides/slide*.xml", part)
-		if match {
-			slides = append(slides, part)
-		}
+// DiscoverChartRefsOrder is DiscoverChartRefs listing the charts in order.
+func DiscoverChartRefsOrder(pkg PartReader, order Order) ([]ChartRef, error) {
+	refs, _, err := DiscoverChartRefsLimit(pkg, order, 0)
+	return refs, err
+}
+
+// DiscoverChartRefsLimit is DiscoverChartRefsOrder stopping after max
+// charts, without reading the rels of the slides that follow; truncated
+// reports that it stopped. Zero or less lists every chart.
+func DiscoverChartRefsLimit(pkg PartReader, order Order, max int) (refs []ChartRef, truncated bool, err error) {
+	owners, err := chartOwners(pkg, order)
+	if err != nil {
+		return nil, false, err
 	}
-	sort.Strings(slides)
 
-	var refs []ChartRef
-	for _, slide := range slides {
+	for _, owner := range owners {
+		slide := owner.path
 		relsPath := slideRelsPath(slide)
 		data, err := pkg.ReadPart(relsPath)
 		if err != nil {
 			if errors.Is(err, ooxmlpkg.ErrPartNotFound) {
 				continue
 			}
-			return nil, err
+			return nil, false, err
 		}
 
 		parsed, err := rels.Parse(bytes.NewReader(data))
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 
 		ids := make([]string, 0, len(parsed.ByID))
-		for id := range parsed.ByID {
-			ids = append(ids, id)
-		}
-		sort.Strings(ids)
-
-		for _, id := range ids {
-			rel := parsed.ByID[id]
+		for id, rel := range parsed.ByID {
 			if !strings.HasSuffix(rel.Type, "/chart") {
 				continue
 			}
 			if rel.TargetMode == "External" {
 				continue
 			}
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		ids, err = orderChartRels(pkg, slide, ids, order)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, id := range ids {
+			if max > 0 && len(refs) == max {
+				return refs, true, nil
+			}
+			rel := parsed.ByID[id]
 			target := rels.ResolveTarget(slide, rel.Target)
 			refs = append(refs, ChartRef{
-				SlidePath: slide,
-				ChartPath: target,
+				SlidePath:  slide,
+				Source:     owner.source,
+				SlideIndex: owner.slideIndex,
+				ChartPath:  target,
 			})
 		}
 	}
 
-	return refs, nil
+	return refs, false, nil
 }
 
 const (
@@ -98,10 +125,24 @@ const (
 	ReasonUnsupported      = "unsupported_target"
 )
 
+// DiscoverEmbeddedCharts sorts the charts DiscoverChartRefs finds into those backed by an embedded workbook and those skipped.
 func DiscoverEmbeddedCharts(pkg PartReader) ([]EmbeddedChart, []SkippedChart, error) {
-	refs, err := DiscoverChartRefs(pkg)
+	return DiscoverEmbeddedChartsOrder(pkg, OrderPresentation)
+}
+
+// DiscoverEmbeddedChartsOrder is DiscoverEmbeddedCharts listing the charts
+// in order.
+func DiscoverEmbeddedChartsOrder(pkg PartReader, order Order) ([]EmbeddedChart, []SkippedChart, error) {
+	embedded, skipped, _, err := DiscoverEmbeddedChartsLimit(pkg, order, 0)
+	return embedded, skipped, err
+}
+
+// DiscoverEmbeddedChartsLimit is DiscoverEmbeddedChartsOrder over the first
+// max charts DiscoverChartRefsLimit finds, skipped ones included.
+func DiscoverEmbeddedChartsLimit(pkg PartReader, order Order, max int) ([]EmbeddedChart, []SkippedChart, bool, error) {
+	refs, truncated, err := DiscoverChartRefsLimit(pkg, order, max)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, false, err
 	}
 
 	embedded := make([]EmbeddedChart, 0, len(refs))
@@ -113,19 +154,21 @@ func DiscoverEmbeddedCharts(pkg PartReader) ([]EmbeddedChart, []SkippedChart, er
 		if err != nil {
 			if errors.Is(err, ooxmlpkg.ErrPartNotFound) {
 				skipped = append(skipped, SkippedChart{
-					SlidePath: ref.SlidePath,
-					ChartPath: ref.ChartPath,
-					Reason:    ReasonRelsMissing,
-					RelsPath:  relsPath,
+					SlidePath:  ref.SlidePath,
+					Source:     ref.Source,
+					SlideIndex: ref.SlideIndex,
+					ChartPath:  ref.ChartPath,
+					Reason:     ReasonRelsMissing,
+					RelsPath:   relsPath,
 				})
 				continue
 			}
-			return nil, nil, err
+			return nil, nil, false, err
 		}
 
 		parsed, err := rels.Parse(bytes.NewReader(data))
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, false, err
 		}
 
 		embeddedPath := ""
@@ -160,25 +203,31 @@ func DiscoverEmbeddedCharts(pkg PartReader) ([]EmbeddedChart, []SkippedChart, er
 
 		if linkedTarget != "" {
 			skipped = append(skipped, SkippedChart{
-				SlidePath: ref.SlidePath,
-				ChartPath: ref.ChartPath,
-				Reason:    ReasonLinked,
-				Target:    linkedTarget,
+				SlidePath:  ref.SlidePath,
+				Source:     ref.Source,
+				SlideIndex: ref.SlideIndex,
+				ChartPath:  ref.ChartPath,
+				Reason:     ReasonLinked,
+				Target:     linkedTarget,
 			})
 			continue
 		}
 		if unsupportedTarget != "" {
 			skipped = append(skipped, SkippedChart{
-				SlidePath: ref.SlidePath,
-				ChartPath: ref.ChartPath,
-				Reason:    ReasonUnsupported,
-				Target:    unsupportedTarget,
+				SlidePath:  ref.SlidePath,
+				Source:     ref.Source,
+				SlideIndex: ref.SlideIndex,
+				ChartPath:  ref.ChartPath,
+				Reason:     ReasonUnsupported,
+				Target:     unsupportedTarget,
 			})
 			continue
 		}
 		if embeddedPath != "" {
 			embedded = append(embedded, EmbeddedChart{
 				SlidePath:    ref.SlidePath,
+				Source:       ref.Source,
+				SlideIndex:   ref.SlideIndex,
 				ChartPath:    ref.ChartPath,
 				WorkbookPath: embeddedPath,
 			})
@@ -186,14 +235,16 @@ func DiscoverEmbeddedCharts(pkg PartReader) ([]EmbeddedChart, []SkippedChart, er
 		}
 		if !foundWorkbookRel {
 			skipped = append(skipped, SkippedChart{
-				SlidePath: ref.SlidePath,
-				ChartPath: ref.ChartPath,
-				Reason:    ReasonWorkbookNotFound,
+				SlidePath:  ref.SlidePath,
+				Source:     ref.Source,
+				SlideIndex: ref.SlideIndex,
+				ChartPath:  ref.ChartPath,
+				Reason:     ReasonWorkbookNotFound,
 			})
 		}
 	}
 
-	return embedded, skipped, nil
+	return embedded, skipped, truncated, nil
 }
 
 func slideRelsPath(slidePath string) string {
@@ -0,0 +1,312 @@
+package chartdiscover
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/rels"
+)
+
+// Order is the order discovery lists slides, and charts within a slide, in.
+type Order int
+
+const (
+	// OrderPresentation lists slides as the presentation shows them, the
+	// order of p:sldIdLst, and the charts of a slide in the order their
+	// graphic frames appear in the slide XML.
+	OrderPresentation Order = iota
+	// OrderPartName lists slides by part name and the charts of a slide by
+	// relationship id, both compared as strings, so slide10.xml comes
+	// before slide2.xml. It is the order of earlier releases.
+	OrderPartName
+)
+
+// Sources of a chart: the kind of part that shows it.
+const (
+	SourceSlide  = "slide"
+	SourceLayout = "layout"
+	SourceMaster = "master"
+	SourceNotes  = "notes"
+)
+
+// chartOwner is a part whose rels can name charts.
+type chartOwner struct {
+	path       string
+	source     string
+	slideIndex int
+}
+
+// ownerPatterns are the parts besides slides that can show charts, in the
+// order discovery scans them.
+var ownerPatterns = []struct {
+	pattern string
+	source  string
+}{
+	{"ppt/slideLayouts/slideLayout*.xml", SourceLayout},
+	{"ppt/slideMasters/slideMaster*.xml", SourceMaster},
+	{"ppt/notesSlides/notesSlide*.xml", SourceNotes},
+}
+
+const (
+	presentationPart      = "ppt/presentation.xml"
+	presentationRelsPart  = "ppt/_rels/presentation.xml.rels"
+	officeRelationshipsNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+// SlideOrder lists the slides of the presentation in the order it shows
+// them, from p:sldIdLst in ppt/presentation.xml resolved through its rels.
+// Without a slide list every slide part is listed, ordered by the number in
+// its name.
+func SlideOrder(pkg PartReader) ([]string, error) {
+	parts, err := pkg.ListParts()
+	if err != nil {
+		return nil, err
+	}
+	return slideOrder(pkg, parts)
+}
+
+func slideOrder(pkg PartReader, parts []string) ([]string, error) {
+	listed, ok, err := presentationSlides(pkg, parts)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return listed, nil
+	}
+	slides := slideParts(parts)
+	sortByNumber(slides)
+	return slides, nil
+}
+
+// orderedSlides returns the slide parts to scan in order, with the 1-based
+// position of each in the presentation; slides left out of p:sldIdLst have
+// position 0 and, in presentation order, come last.
+func orderedSlides(pkg PartReader, order Order) ([]string, map[string]int, error) {
+	parts, err := pkg.ListParts()
+	if err != nil {
+		return nil, nil, err
+	}
+	slides := slideParts(parts)
+	shown, err := slideOrder(pkg, parts)
+	if err != nil {
+		return nil, nil, err
+	}
+	positions := make(map[string]int, len(shown))
+	for i, slide := range shown {
+		positions[slide] = i + 1
+	}
+
+	if order == OrderPartName {
+		sort.Strings(slides)
+		return slides, positions, nil
+	}
+
+	present := make(map[string]struct{}, len(slides))
+	for _, slide := range slides {
+		present[slide] = struct{}{}
+	}
+	out := make([]string, 0, len(slides))
+	for _, slide := range shown {
+		if _, ok := present[slide]; ok {
+			out = append(out, slide)
+		}
+	}
+	var hidden []string
+	for _, slide := range slides {
+		if positions[slide] == 0 {
+			hidden = append(hidden, slide)
+		}
+	}
+	sortByNumber(hidden)
+	return append(out, hidden...), positions, nil
+}
+
+// chartOwners lists the slides in order, then the layouts, masters, and
+// notes slides, each group by the number in the part name (by name in
+// OrderPartName).
+func chartOwners(pkg PartReader, order Order) ([]chartOwner, error) {
+	slides, positions, err := orderedSlides(pkg, order)
+	if err != nil {
+		return nil, err
+	}
+	owners := make([]chartOwner, 0, len(slides))
+	for _, slide := range slides {
+		owners = append(owners, chartOwner{path: slide, source: SourceSlide, slideIndex: positions[slide]})
+	}
+
+	parts, err := pkg.ListParts()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range ownerPatterns {
+		matched := matchParts(parts, group.pattern)
+		if order == OrderPartName {
+			sort.Strings(matched)
+		} else {
+			sortByNumber(matched)
+		}
+		for _, part := range matched {
+			owners = append(owners, chartOwner{path: part, source: group.source})
+		}
+	}
+	return owners, nil
+}
+
+func slideParts(parts []string) []string {
+	return matchParts(parts, "ppt/slides/slide*.xml")
+}
+
+func matchParts(parts []string, pattern string) []string {
+	out := make([]string, 0)
+	for _, part := range parts {
+		if match, _ := path.Match(pattern, part); match {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// presentationSlides resolves p:sldIdLst. ok is false when the package has
+// no presentation part or it lists no slides.
+func presentationSlides(pkg PartReader, parts []string) ([]string, bool, error) {
+	if !containsPart(parts, presentationPart) {
+		return nil, false, nil
+	}
+	data, err := pkg.ReadPart(presentationPart)
+	if err != nil {
+		return nil, false, err
+	}
+	ids, err := elementRelIDs(data, "sldId")
+	if err != nil {
+		return nil, false, fmt.Errorf("parse %s: %w", presentationPart, err)
+	}
+	if len(ids) == 0 {
+		return nil, false, nil
+	}
+
+	relsData, err := pkg.ReadPart(presentationRelsPart)
+	if err != nil {
+		return nil, false, fmt.Errorf("read %s: %w", presentationRelsPart, err)
+	}
+	parsed, err := rels.Parse(bytes.NewReader(relsData))
+	if err != nil {
+		return nil, false, fmt.Errorf("parse %s: %w", presentationRelsPart, err)
+	}
+
+	slides := make([]string, 0, len(ids))
+	for _, id := range ids {
+		rel, ok := parsed.Resolve(id)
+		if !ok {
+			return nil, false, fmt.Errorf("slide relationship %q missing from %s", id, presentationRelsPart)
+		}
+		slides = append(slides, rels.ResolveTarget(presentationPart, rel.Target))
+	}
+	return slides, true, nil
+}
+
+// elementRelIDs returns the r:id of every element named local, in document
+// order and without repeats (mc:AlternateContent can name a chart twice).
+func elementRelIDs(data []byte, local string) ([]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var ids []string
+	seen := make(map[string]struct{})
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != local {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local != "id" || attr.Name.Space != officeRelationshipsNS {
+				continue
+			}
+			if _, ok := seen[attr.Value]; !ok {
+				seen[attr.Value] = struct{}{}
+				ids = append(ids, attr.Value)
+			}
+		}
+	}
+}
+
+// orderChartRels orders the chart relationship ids of a slide. In
+// presentation order the ids the slide XML names come first, in shape
+// order, followed by any others by id.
+func orderChartRels(pkg PartReader, slide string, ids []string, order Order) ([]string, error) {
+	sort.Strings(ids)
+	if order == OrderPartName {
+		return ids, nil
+	}
+	data, err := pkg.ReadPart(slide)
+	if err != nil {
+		return nil, err
+	}
+	shapes, err := elementRelIDs(data, "chart")
+	if err != nil {
+		// The slide XML is not needed to find the charts; fall back to the
+		// relationship order.
+		return ids, nil
+	}
+
+	known := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		known[id] = struct{}{}
+	}
+	out := make([]string, 0, len(ids))
+	placed := make(map[string]struct{}, len(ids))
+	for _, id := range shapes {
+		if _, ok := known[id]; ok {
+			out = append(out, id)
+			placed[id] = struct{}{}
+		}
+	}
+	for _, id := range ids {
+		if _, ok := placed[id]; !ok {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func containsPart(parts []string, name string) bool {
+	for _, part := range parts {
+		if part == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortByNumber(parts []string) {
+	sort.SliceStable(parts, func(i, j int) bool {
+		a, b := partNumber(parts[i]), partNumber(parts[j])
+		if a != b {
+			return a < b
+		}
+		return parts[i] < parts[j]
+	})
+}
+
+// partNumber returns the number ending a part name such as
+// "ppt/slides/slide12.xml" or "ppt/slideLayouts/slideLayout3.xml", or 0.
+func partNumber(partPath string) int {
+	name := strings.TrimSuffix(path.Base(partPath), ".xml")
+	digits := strings.TrimLeftFunc(name, func(r rune) bool { return r < '0' || r > '9' })
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0
+	}
+	return n
+}
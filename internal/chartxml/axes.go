@@ -0,0 +1,180 @@
+package chartxml
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// axisTracker collects catAx/dateAx/valAx definitions from a chart token
+// stream. Feed it every start and end element; it ignores tokens outside
+// axes. A dateAx is tracked as a category axis.
+type axisTracker struct {
+	depth   int
+	current axisInfo
+	axes    []axisInfo
+	// titleDepth and inTitleText follow the axis c:title, whose a:t runs (or
+	// c:v, for a title linked to a cell) are joined into current.title.
+	titleDepth  int
+	inTitleText bool
+	title       strings.Builder
+}
+
+func (a *axisTracker) start(tok xml.StartElement) {
+	switch tok.Name.Local {
+	case "catAx", "dateAx", "valAx":
+		a.depth++
+		if a.depth == 1 {
+			kind := "cat"
+			if tok.Name.Local == "valAx" {
+				kind = "val"
+			}
+			a.current = axisInfo{kind: kind}
+		}
+		return
+	}
+	if a.depth == 0 {
+		return
+	}
+
+	switch tok.Name.Local {
+	case "title":
+		a.titleDepth++
+	case "t", "v":
+		a.inTitleText = a.titleDepth > 0
+	case "axId":
+		if a.current.id == "" {
+			a.current.id = attrVal(tok)
+		}
+	case "crossAx":
+		a.current.cross = attrVal(tok)
+	case "orientation":
+		a.current.reversed = attrVal(tok) == "maxMin"
+	case "numFmt":
+		for _, attr := range tok.Attr {
+			switch attr.Name.Local {
+			case "formatCode":
+				a.current.formatCode = attr.Value
+			case "sourceLinked":
+				a.current.sourceLinked = attr.Value == "1" || attr.Value == "true"
+			}
+		}
+	case "axPos", "axisPos":
+		a.current.axisPos = attrVal(tok)
+	case "majorGridlines":
+		if a.current.kind == "val" {
+			a.current.hasMajorGridlines = true
+		}
+	case "minorGridlines":
+		if a.current.kind == "val" {
+			a.current.hasMinorGridlines = true
+		}
+	}
+}
+
+func (a *axisTracker) end(name string) {
+	if a.depth > 0 {
+		switch name {
+		case "t", "v":
+			a.inTitleText = false
+		case "title":
+			if a.titleDepth > 0 {
+				a.titleDepth--
+			}
+			if a.titleDepth == 0 {
+				a.current.title = strings.TrimSpace(a.title.String())
+				a.title.Reset()
+			}
+		}
+	}
+	if name != "catAx" && name != "dateAx" && name != "valAx" || a.depth == 0 {
+		return
+	}
+	a.depth--
+	if a.depth == 0 {
+		if a.current.id != "" {
+			a.axes = append(a.axes, a.current)
+		}
+		a.current = axisInfo{}
+		a.titleDepth = 0
+		a.inTitleText = false
+		a.title.Reset()
+	}
+}
+
+// text takes the character data of the token stream.
+func (a *axisTracker) text(data xml.CharData) {
+	if a.inTitleText {
+		a.title.Write(data)
+	}
+}
+
+func (a *axisTracker) inAxis() bool {
+	return a.depth > 0
+}
+
+func (a *axisTracker) valueAxisCount() int {
+	seen := make(map[string]struct{})
+	for _, axis := range a.axes {
+		if axis.kind == "val" {
+			seen[axis.id] = struct{}{}
+		}
+	}
+	return len(seen)
+}
+
+// plotAxisRoles names each plot's axis: plots sharing the first plot's axis
+// IDs are "primary", the rest "secondary". A plot without axis IDs counts as
+// primary.
+func plotAxisRoles(plots []*plotState) []string {
+	roles := make([]string, len(plots))
+	if len(plots) == 0 {
+		return roles
+	}
+	primaryIDs := plots[0].axisIDs
+	for i, plot := range plots {
+		roles[i] = "primary"
+		if len(primaryIDs) == 0 || len(plot.axisIDs) == 0 {
+			continue
+		}
+		if !equalStringSets(primaryIDs, plot.axisIDs) {
+			roles[i] = "secondary"
+		}
+	}
+	return roles
+}
+
+// AxisGroupRoles names each axis group "primary" when the first plot draws
+// on it and "secondary" otherwise. Without plot axis IDs, the first group is
+// primary.
+func AxisGroupRoles(plots []MixedPlot, groups []AxisGroup) []string {
+	roles := make([]string, len(groups))
+	var primaryIDs []string
+	if len(plots) > 0 {
+		primaryIDs = plots[0].AxisIDs
+	}
+	for i, group := range groups {
+		roles[i] = "secondary"
+		if len(primaryIDs) == 0 {
+			if i == 0 {
+				roles[i] = "primary"
+			}
+			continue
+		}
+		for _, id := range primaryIDs {
+			if id == group.CatAxID || id == group.ValAxID {
+				roles[i] = "primary"
+				break
+			}
+		}
+	}
+	return roles
+}
+
+func attrVal(tok xml.StartElement) string {
+	for _, attr := range tok.Attr {
+		if attr.Name.Local == "val" {
+			return attr.Value
+		}
+	}
+	return ""
+}
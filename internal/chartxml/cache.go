@@ -0,0 +1,235 @@
+package chartxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/xmlcancel"
+)
+
+// SeriesCache is what a chart caches of one series: the points PowerPoint
+// renders when the workbook cannot be reached.
+type SeriesCache struct {
+	// Index numbers the series by c:order, as ParsedChart does.
+	Index int
+	// Name is the c:tx strCache text, or its literal c:v; HasName is false
+	// for a series without a c:tx.
+	Name    string
+	HasName bool
+	// Categories holds the c:cat points, or the c:xVal points of a scatter
+	// series; Values the c:val or c:yVal points. A point the cache leaves
+	// out is "". Multi-level categories are not read.
+	Categories []string
+	Values     []string
+	// CategoriesFormatCode and FormatCode are the formatCodes of the
+	// numCache or numLit of the categories and values.
+	CategoriesFormatCode string
+	FormatCode           string
+}
+
+// pointCache collects one strCache, numCache, strLit, or numLit.
+type pointCache struct {
+	count      int
+	hasCount   bool
+	points     map[int]string
+	formatCode string
+}
+
+// maxCachePoints bounds the ptCount and pt idx a cache is trusted with: a
+// worksheet has no more rows than this, so a longer range cannot be cached.
+const maxCachePoints = 1 << 20
+
+func (c *pointCache) list() []string {
+	n := 0
+	for idx := range c.points {
+		if idx+1 > n {
+			n = idx + 1
+		}
+	}
+	if c.hasCount && c.count <= maxCachePoints {
+		n = c.count
+	}
+	out := make([]string, n)
+	for idx, value := range c.points {
+		if idx < n {
+			out[idx] = value
+		}
+	}
+	return out
+}
+
+// ParseCaches reads the cached points of every series of a chart, in index
+// order. It checks cancel between tokens like ParseWithCancel.
+func ParseCaches(r io.Reader, cancel *xmlcancel.Flag) ([]SeriesCache, error) {
+	decoder := xml.NewDecoder(r)
+
+	var series []SeriesCache
+	order := map[int]int{}
+	plotDepth := 0
+	inSeries := false
+	serChildDepth := 0
+	// part is the series child being read: "cat", "val", "tx", "xVal", or
+	// "yVal".
+	part := ""
+	var cache *pointCache
+	point := -1
+	inValue := false
+	inFormatCode := false
+	var buf strings.Builder
+
+	for {
+		if err := cancel.Err(); err != nil {
+			return nil, err
+		}
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse chart xml: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if isBasicPlot(tok.Name.Local) {
+				plotDepth++
+				continue
+			}
+			if !inSeries {
+				if tok.Name.Local == "ser" && plotDepth > 0 {
+					inSeries = true
+					serChildDepth = 0
+					series = append(series, SeriesCache{})
+				}
+				continue
+			}
+			serChildDepth++
+			name := tok.Name.Local
+			if serChildDepth == 1 {
+				switch name {
+				case "cat", "val", "tx", "xVal", "yVal":
+					part = name
+				case "order":
+					if val, ok := idxVal(tok); ok {
+						order[len(series)-1] = val
+					}
+				}
+				continue
+			}
+			if part == "" {
+				continue
+			}
+			switch name {
+			case "strCache", "numCache", "strLit", "numLit":
+				cache = &pointCache{points: map[int]string{}}
+			case "ptCount":
+				if cache != nil {
+					if count, err := strconv.Atoi(attrVal(tok)); err == nil && count >= 0 {
+						cache.count, cache.hasCount = count, true
+					}
+				}
+			case "pt":
+				point = -1
+				if cache != nil {
+					for _, attr := range tok.Attr {
+						if attr.Name.Local == "idx" {
+							if idx, err := strconv.Atoi(attr.Value); err == nil && idx >= 0 && idx < maxCachePoints {
+								point = idx
+							}
+						}
+					}
+				}
+			case "v":
+				// A c:tx may hold its name as a literal c:v.
+				if (cache != nil && point >= 0) || (part == "tx" && serChildDepth == 2) {
+					inValue = true
+					buf.Reset()
+				}
+			case "formatCode":
+				if cache != nil {
+					inFormatCode = true
+					buf.Reset()
+				}
+			}
+		case xml.EndElement:
+			if isBasicPlot(tok.Name.Local) {
+				if plotDepth > 0 {
+					plotDepth--
+				}
+				continue
+			}
+			if !inSeries {
+				continue
+			}
+			if tok.Name.Local == "ser" && serChildDepth == 0 {
+				inSeries = false
+				part = ""
+				cache = nil
+				continue
+			}
+			serChildDepth--
+			current := &series[len(series)-1]
+			switch tok.Name.Local {
+			case "v":
+				if inValue {
+					if cache != nil && point >= 0 {
+						cache.points[point] = buf.String()
+					} else {
+						current.Name, current.HasName = buf.String(), true
+					}
+					inValue = false
+				}
+			case "formatCode":
+				if inFormatCode {
+					cache.formatCode = strings.TrimSpace(buf.String())
+					inFormatCode = false
+				}
+			case "pt":
+				point = -1
+			case "strCache", "numCache", "strLit", "numLit":
+				if cache != nil {
+					current.setCache(part, cache)
+					cache = nil
+				}
+			}
+			if serChildDepth == 0 {
+				part = ""
+			}
+		case xml.CharData:
+			if inValue || inFormatCode {
+				buf.Write([]byte(tok))
+			}
+		}
+	}
+
+	ranks := seriesRanks(len(series), order)
+	for position := range series {
+		series[position].Index = position
+		if ranks != nil {
+			series[position].Index = ranks[position]
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].Index < series[j].Index
+	})
+	return series, nil
+}
+
+func (s *SeriesCache) setCache(part string, cache *pointCache) {
+	switch part {
+	case "cat", "xVal":
+		s.Categories = cache.list()
+		s.CategoriesFormatCode = cache.formatCode
+	case "val", "yVal":
+		s.Values = cache.list()
+		s.FormatCode = cache.formatCode
+	case "tx":
+		if points := cache.list(); len(points) > 0 {
+			s.Name, s.HasName = points[0], true
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package chartxml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCaches(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:lineChart>
+        <c:ser>
+          <c:idx val="0"/><c:order val="1"/>
+          <c:tx><c:v>Literal</c:v></c:tx>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$4</c:f><c:strCache><c:ptCount val="3"/><c:pt idx="0"><c:v>Jan</c:v></c:pt><c:pt idx="2"><c:v>Mar</c:v></c:pt></c:strCache></c:strRef></c:cat>
+          <c:val><c:numLit><c:formatCode>0.0%</c:formatCode><c:ptCount val="3"/><c:pt idx="0"><c:v>0.1</c:v></c:pt><c:pt idx="1"><c:v>0.2</c:v></c:pt><c:pt idx="2"><c:v>0.3</c:v></c:pt></c:numLit></c:val>
+        </c:ser>
+        <c:ser>
+          <c:idx val="1"/><c:order val="0"/>
+          <c:tx><c:strRef><c:f>Sheet1!$C$1</c:f><c:strCache><c:ptCount val="1"/><c:pt idx="0"><c:v>Cached</c:v></c:pt></c:strCache></c:strRef></c:tx>
+          <c:dPt><c:idx val="9"/></c:dPt>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$4</c:f><c:numCache><c:ptCount val="3"/><c:pt idx="1"><c:v>5</c:v></c:pt></c:numCache></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:val><c:numRef><c:f>Sheet1!$D$2:$D$4</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:lineChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	caches, err := ParseCaches(strings.NewReader(xml), nil)
+	if err != nil {
+		t.Fatalf("ParseCaches: %v", err)
+	}
+	want := []SeriesCache{
+		{Index: 0, Name: "Cached", HasName: true, Values: []string{"", "5", ""}},
+		{Index: 1, Name: "Literal", HasName: true, Categories: []string{"Jan", "", "Mar"}, Values: []string{"0.1", "0.2", "0.3"}, FormatCode: "0.0%"},
+		{Index: 2},
+	}
+	if !reflect.DeepEqual(caches, want) {
+		t.Fatalf("unexpected caches:\n got %#v\nwant %#v", caches, want)
+	}
+}
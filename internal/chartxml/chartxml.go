@@ -4,47 +4,108 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+
+	"why-pptx/internal/xmlcancel"
 )
 
 const (
 	KindCategories = "categories"
 	KindValues     = "values"
 	KindSeriesName = "seriesName"
+	// KindDataLabels is the c15:datalabelsRange extension of a series: the
+	// worksheet range its data labels show instead of the values.
+	KindDataLabels = "dataLabels"
+	// KindXValues and KindYValues are the c:xVal and c:yVal of a scatter
+	// series, which has them in place of c:cat and c:val.
+	KindXValues = "xValues"
+	KindYValues = "yValues"
 )
 
 type Formula struct {
 	Kind        string
 	SeriesIndex int
 	Formula     string
+	// FormatCode is the formatCode of the numCache that follows the
+	// formula, such as "0.0%"; empty for a strRef or a cache without one.
+	FormatCode string
+	// PtCount is the ptCount of the cache that follows the formula, or 0
+	// without one.
+	PtCount int
+	// MultiLevel is set for the formula of a c:multiLvlStrRef, whose
+	// categories are grouped under one or more outer levels of labels.
+	MultiLevel bool
 }
 
 type ParsedChart struct {
-	ChartType string
-	Formulas  []Formula
+	ChartType  string
+	Formulas   []Formula
+	Plots      []MixedPlot
+	AxisGroups []AxisGroup
+	// SeriesAxes maps a series index to "primary" or "secondary". It is only
+	// set when the chart defines more than one value axis.
+	SeriesAxes map[int]string
+	// SeriesIdx maps a series index to the val of its c:idx, for series
+	// that have one. PowerPoint leaves gaps
+	// in c:idx when a series is deleted, so the two differ.
+	SeriesIdx map[int]int
+	// SeriesPositions maps a series index to the series' position in the
+	// chart XML. Series are indexed by c:order, which PowerPoint changes
+	// rather than moving the c:ser when series are reordered; the map is nil
+	// when every series index is its position.
+	SeriesPositions map[int]int
+	// Date1904 is the chartSpace c:date1904 flag: the chart's date serials
+	// count days from 1904-01-01 rather than from 1900.
+	Date1904 bool
 }
 
 func Parse(r io.Reader) (*ParsedChart, error) {
+	return ParseWithCancel(r, nil)
+}
+
+// ParseWithCancel checks cancel between tokens and returns
+// xmlcancel.ErrCanceled once it is set. The Info and Mixed parsers have
+// matching WithCancel variants.
+func ParseWithCancel(r io.Reader, cancel *xmlcancel.Flag) (*ParsedChart, error) {
 	decoder := xml.NewDecoder(r)
-	out := &ParsedChart{ChartType: "unknown"}
+	out := &ParsedChart{ChartType: "unknown", SeriesIdx: map[int]int{}}
 
 	seriesIndex := -1
+	seriesOrder := map[int]int{}
 	inSeries := false
+	// serChildDepth is the element depth below the current ser, so only its
+	// own c:idx is read and not those of its dPt or dLbl children.
+	serChildDepth := 0
 	catDepth := 0
 	valDepth := 0
 	txDepth := 0
+	labelsDepth := 0
+	xValDepth := 0
+	yValDepth := 0
 	barDepth := 0
 	lineDepth := 0
 	pieDepth := 0
 	areaDepth := 0
+	stockDepth := 0
+	scatterDepth := 0
+	radarDepth := 0
 	otherDepth := 0
+	plots := make([]*plotState, 0)
+	var axes axisTracker
 
 	inFormula := false
+	inMultiLevel := false
 	formulaKind := ""
 	formulaSeries := -1
 	var buf strings.Builder
+	inFormatCode := false
+	var formatBuf strings.Builder
 
 	for {
+		if err := cancel.Err(); err != nil {
+			return nil, err
+		}
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
@@ -55,7 +116,27 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 
 		switch tok := token.(type) {
 		case xml.StartElement:
+			axes.start(tok)
+			if inSeries {
+				serChildDepth++
+				if serChildDepth == 1 && tok.Name.Local == "idx" {
+					if idx, ok := idxVal(tok); ok {
+						out.SeriesIdx[seriesIndex] = idx
+					}
+				}
+				if serChildDepth == 1 && tok.Name.Local == "order" {
+					if order, ok := idxVal(tok); ok {
+						seriesOrder[seriesIndex] = order
+					}
+				}
+			}
+			if isBasicPlot(tok.Name.Local) && barDepth+lineDepth+pieDepth+areaDepth+stockDepth+scatterDepth+radarDepth == 0 {
+				plots = append(plots, newPlotState(strings.TrimSuffix(tok.Name.Local, "Chart")))
+			}
 			switch tok.Name.Local {
+			case "date1904":
+				val := attrVal(tok)
+				out.Date1904 = val == "" || val == "1" || val == "true"
 			case "barChart":
 				barDepth++
 				out.ChartType = updateChartType(out.ChartType, "bar")
@@ -65,18 +146,40 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 			case "pieChart":
 				pieDepth++
 				out.ChartType = updateChartType(out.ChartType, "pie")
+			case "doughnutChart":
+				// A doughnut plot is laid out like a pie plot, one ring per
+				// series.
+				pieDepth++
+				out.ChartType = updateChartType(out.ChartType, "doughnut")
 			case "areaChart":
 				areaDepth++
 				out.ChartType = updateChartType(out.ChartType, "area")
+			case "stockChart":
+				stockDepth++
+				out.ChartType = updateChartType(out.ChartType, "stock")
+			case "scatterChart":
+				scatterDepth++
+				out.ChartType = updateChartType(out.ChartType, "scatter")
+			case "radarChart":
+				radarDepth++
+				out.ChartType = updateChartType(out.ChartType, "radar")
 			default:
 				if isOtherChart(tok.Name.Local) {
 					otherDepth++
-					out.ChartType = updateChartType(out.ChartType, "other")
+					out.ChartType = updateChartType(out.ChartType, otherChartType(tok.Name.Local))
 				}
 			case "ser":
-				if barDepth+lineDepth+pieDepth+areaDepth > 0 {
+				if barDepth+lineDepth+pieDepth+areaDepth+stockDepth+scatterDepth+radarDepth > 0 {
 					seriesIndex++
 					inSeries = true
+					plot := plots[len(plots)-1]
+					plot.seriesIndices = append(plot.seriesIndices, seriesIndex)
+				}
+			case "axId":
+				if barDepth+lineDepth+pieDepth+areaDepth+stockDepth+scatterDepth+radarDepth > 0 && !axes.inAxis() {
+					if id := attrVal(tok); id != "" {
+						plots[len(plots)-1].axisIDs[id] = struct{}{}
+					}
 				}
 			case "cat":
 				if inSeries {
@@ -90,16 +193,37 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 				if inSeries {
 					txDepth++
 				}
-			case "f":
+			case "datalabelsRange":
 				if inSeries {
-					kind := ""
-					if catDepth > 0 {
-						kind = KindCategories
-					} else if valDepth > 0 {
-						kind = KindValues
-					} else if txDepth > 0 {
-						kind = KindSeriesName
+					labelsDepth++
+				}
+			case "xVal":
+				if inSeries {
+					xValDepth++
+				}
+			case "yVal":
+				if inSeries {
+					yValDepth++
+				}
+			case "multiLvlStrRef":
+				inMultiLevel = inSeries
+			case "formatCode":
+				if inSeries && catDepth+valDepth+xValDepth+yValDepth > 0 {
+					inFormatCode = true
+					formatBuf.Reset()
+				}
+			case "ptCount":
+				if inSeries {
+					kind := seriesRangeKind(catDepth, valDepth, txDepth, labelsDepth, xValDepth, yValDepth)
+					if n := len(out.Formulas); kind != "" && n > 0 && out.Formulas[n-1].SeriesIndex == seriesIndex && out.Formulas[n-1].Kind == kind {
+						if count, err := strconv.Atoi(attrVal(tok)); err == nil {
+							out.Formulas[n-1].PtCount = count
+						}
 					}
+				}
+			case "f":
+				if inSeries {
+					kind := seriesRangeKind(catDepth, valDepth, txDepth, labelsDepth, xValDepth, yValDepth)
 					if kind != "" {
 						inFormula = true
 						formulaKind = kind
@@ -109,6 +233,10 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 				}
 			}
 		case xml.EndElement:
+			axes.end(tok.Name.Local)
+			if inSeries && tok.Name.Local != "ser" {
+				serChildDepth--
+			}
 			switch tok.Name.Local {
 			case "barChart":
 				if barDepth > 0 {
@@ -118,7 +246,7 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 				if lineDepth > 0 {
 					lineDepth--
 				}
-			case "pieChart":
+			case "pieChart", "doughnutChart":
 				if pieDepth > 0 {
 					pieDepth--
 				}
@@ -126,19 +254,49 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 				if areaDepth > 0 {
 					areaDepth--
 				}
+			case "stockChart":
+				if stockDepth > 0 {
+					stockDepth--
+				}
+			case "scatterChart":
+				if scatterDepth > 0 {
+					scatterDepth--
+				}
+			case "radarChart":
+				if radarDepth > 0 {
+					radarDepth--
+				}
 			default:
 				if isOtherChart(tok.Name.Local) && otherDepth > 0 {
 					otherDepth--
 				}
 			case "ser":
 				inSeries = false
+				serChildDepth = 0
 				catDepth = 0
 				valDepth = 0
 				txDepth = 0
+				labelsDepth = 0
+				xValDepth = 0
+				yValDepth = 0
 				inFormula = false
+				inMultiLevel = false
 				formulaKind = ""
 				formulaSeries = -1
 				buf.Reset()
+				inFormatCode = false
+			case "multiLvlStrRef":
+				inMultiLevel = false
+			case "formatCode":
+				if inFormatCode {
+					kind := seriesRangeKind(catDepth, valDepth, txDepth, labelsDepth, xValDepth, yValDepth)
+					// The numCache follows the f of its numRef, so the formula
+					// it caches is the last one of the series.
+					if n := len(out.Formulas); n > 0 && out.Formulas[n-1].SeriesIndex == seriesIndex && out.Formulas[n-1].Kind == kind {
+						out.Formulas[n-1].FormatCode = strings.TrimSpace(formatBuf.String())
+					}
+					inFormatCode = false
+				}
 			case "cat":
 				if catDepth > 0 {
 					catDepth--
@@ -151,6 +309,18 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 				if txDepth > 0 {
 					txDepth--
 				}
+			case "datalabelsRange":
+				if labelsDepth > 0 {
+					labelsDepth--
+				}
+			case "xVal":
+				if xValDepth > 0 {
+					xValDepth--
+				}
+			case "yVal":
+				if yValDepth > 0 {
+					yValDepth--
+				}
 			case "f":
 				if inFormula {
 					text := strings.TrimSpace(buf.String())
@@ -159,6 +329,7 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 							Kind:        formulaKind,
 							SeriesIndex: formulaSeries,
 							Formula:     text,
+							MultiLevel:  inMultiLevel,
 						})
 					}
 					inFormula = false
@@ -168,15 +339,76 @@ func Parse(r io.Reader) (*ParsedChart, error) {
 				}
 			}
 		case xml.CharData:
+			axes.text(tok)
 			if inFormula {
 				buf.Write([]byte(tok))
 			}
+			if inFormatCode {
+				formatBuf.Write([]byte(tok))
+			}
+		}
+	}
+
+	out.Plots = plotsToMixed(plots)
+	out.AxisGroups = buildAxisGroups(axes.axes)
+	valueAxes := axes.valueAxisCount()
+	for _, plot := range plots {
+		// A scatter plot draws its x values on a valAx as well.
+		if plot.plotType == "scatter" {
+			valueAxes--
+		}
+	}
+	if valueAxes > 1 {
+		roles := plotAxisRoles(plots)
+		out.SeriesAxes = make(map[int]string)
+		for i, plot := range plots {
+			for _, idx := range plot.seriesIndices {
+				out.SeriesAxes[idx] = roles[i]
+			}
 		}
 	}
+	out.reorderSeries(seriesRanks(seriesIndex+1, seriesOrder))
 
 	return out, nil
 }
 
+// seriesRangeKind names the series child being read from the depths of the
+// elements that hold formulas, or returns "" outside all of them.
+func seriesRangeKind(catDepth, valDepth, txDepth, labelsDepth, xValDepth, yValDepth int) string {
+	switch {
+	case catDepth > 0:
+		return KindCategories
+	case valDepth > 0:
+		return KindValues
+	case txDepth > 0:
+		return KindSeriesName
+	case labelsDepth > 0:
+		return KindDataLabels
+	case xValDepth > 0:
+		return KindXValues
+	case yValDepth > 0:
+		return KindYValues
+	}
+	return ""
+}
+
+// idxVal reads the non-negative integer val of a c:idx or c:order element.
+func idxVal(tok xml.StartElement) (int, bool) {
+	idx, err := strconv.Atoi(attrVal(tok))
+	if err != nil || idx < 0 {
+		return 0, false
+	}
+	return idx, true
+}
+
+func isBasicPlot(name string) bool {
+	switch name {
+	case "barChart", "lineChart", "pieChart", "doughnutChart", "areaChart", "stockChart", "scatterChart", "radarChart":
+		return true
+	}
+	return false
+}
+
 func updateChartType(current, next string) string {
 	if current == "unknown" {
 		return next
@@ -188,8 +420,21 @@ func updateChartType(current, next string) string {
 }
 
 func isOtherChart(name string) bool {
-	if name == "barChart" || name == "lineChart" || name == "pieChart" || name == "areaChart" {
+	if isBasicPlot(name) {
 		return false
 	}
 	return strings.HasSuffix(name, "Chart")
 }
+
+// otherChartType names the chart type of a plot element the parsers do not
+// read series from. Bubble and surface plots are named so unsupported-type
+// alerts say what the chart is; everything else is "other".
+func otherChartType(name string) string {
+	switch name {
+	case "bubbleChart":
+		return "bubble"
+	case "surfaceChart", "surface3DChart":
+		return "surface"
+	}
+	return "other"
+}
@@ -1,10 +1,13 @@
 package chartxml
 
 import (
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 
 	"why-pptx/internal/xlref"
+	"why-pptx/internal/xmlcancel"
 )
 
 func TestParseBarChartFormulas(t *testing.T) {
@@ -176,6 +179,207 @@ func TestParseAreaChartFormulas(t *testing.T) {
 	}
 }
 
+func TestParseStockChartFormulas(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:stockChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$4</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$4</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$4</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$4</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:hiLowLines/>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:stockChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if parsed.ChartType != "stock" {
+		t.Fatalf("expected stock chart type, got %q", parsed.ChartType)
+	}
+	if len(parsed.Formulas) != 4 || parsed.Formulas[3].SeriesIndex != 1 {
+		t.Fatalf("unexpected formulas: %#v", parsed.Formulas)
+	}
+
+	info, err := ParseInfo(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if info.ChartType != "stock" || info.SeriesCount != 2 {
+		t.Fatalf("unexpected info: %#v", info)
+	}
+}
+
+func TestParseScatterChartFormulas(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:scatterChart>
+        <c:ser>
+          <c:tx><c:strRef><c:f>Sheet1!$B$1</c:f></c:strRef></c:tx>
+          <c:xVal><c:numRef><c:f>Sheet1!$A$2:$A$5</c:f></c:numRef></c:xVal>
+          <c:yVal><c:numRef><c:f>Sheet1!$B$2:$B$5</c:f></c:numRef></c:yVal>
+        </c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:scatterChart>
+      <c:valAx><c:axId val="1"/><c:axPos val="b"/><c:crossAx val="2"/></c:valAx>
+      <c:valAx><c:axId val="2"/><c:axPos val="l"/><c:crossAx val="1"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if parsed.ChartType != "scatter" {
+		t.Fatalf("expected scatter chart type, got %q", parsed.ChartType)
+	}
+	want := []Formula{
+		{Kind: KindSeriesName, SeriesIndex: 0, Formula: "Sheet1!$B$1"},
+		{Kind: KindXValues, SeriesIndex: 0, Formula: "Sheet1!$A$2:$A$5"},
+		{Kind: KindYValues, SeriesIndex: 0, Formula: "Sheet1!$B$2:$B$5"},
+	}
+	if !reflect.DeepEqual(parsed.Formulas, want) {
+		t.Fatalf("unexpected formulas: %#v", parsed.Formulas)
+	}
+	// The x axis is a valAx too, which must not read as a secondary axis.
+	if parsed.SeriesAxes != nil {
+		t.Fatalf("expected no series axes, got %#v", parsed.SeriesAxes)
+	}
+
+	info, err := ParseInfo(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if info.ChartType != "scatter" || info.SeriesCount != 1 {
+		t.Fatalf("unexpected info: %#v", info)
+	}
+}
+
+func TestParseRadarChartFormulas(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:radarChart>
+        <c:radarStyle val="marker"/>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$4</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$4</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:radarChart>
+      <c:bubbleChart>
+        <c:ser>
+          <c:xVal><c:numRef><c:f>Sheet1!$D$2:$D$4</c:f></c:numRef></c:xVal>
+        </c:ser>
+      </c:bubbleChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	// The bubble plot makes the chart mixed, and its series is not read.
+	if parsed.ChartType != "mixed" {
+		t.Fatalf("expected mixed chart type, got %q", parsed.ChartType)
+	}
+	want := []Formula{
+		{Kind: KindCategories, SeriesIndex: 0, Formula: "Sheet1!$A$2:$A$4"},
+		{Kind: KindValues, SeriesIndex: 0, Formula: "Sheet1!$B$2:$B$4"},
+	}
+	if !reflect.DeepEqual(parsed.Formulas, want) {
+		t.Fatalf("unexpected formulas: %#v", parsed.Formulas)
+	}
+}
+
+func TestParseDoughnutChart(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:doughnutChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$4</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$4</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$4</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$4</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:holeSize val="50"/>
+      </c:doughnutChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if parsed.ChartType != "doughnut" || len(parsed.Formulas) != 4 || parsed.Formulas[3].SeriesIndex != 1 {
+		t.Fatalf("unexpected parse: %#v", parsed)
+	}
+
+	info, err := ParseInfo(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if info.ChartType != "doughnut" || info.SeriesCount != 2 {
+		t.Fatalf("unexpected info: %#v", info)
+	}
+}
+
+func TestParseDataLabelsRangeFormula(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:c15="http://schemas.microsoft.com/office/drawing/2012/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+          <c:extLst><c:ext uri="{02D57815-91ED-43cb-92C2-25804820EDAC}"><c15:datalabelsRange><c15:f>Sheet1!$C$2:$C$3</c15:f></c15:datalabelsRange></c:ext></c:extLst>
+        </c:ser>
+        <c:ser>
+          <c:val><c:numRef><c:f>Sheet1!$D$2:$D$3</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(parsed.Formulas) != 4 {
+		t.Fatalf("expected 4 formulas, got %#v", parsed.Formulas)
+	}
+	labels := parsed.Formulas[2]
+	if labels.Kind != KindDataLabels || labels.SeriesIndex != 0 || labels.Formula != "Sheet1!$C$2:$C$3" {
+		t.Fatalf("unexpected data label formula: %#v", labels)
+	}
+	if next := parsed.Formulas[3]; next.Kind != KindValues || next.SeriesIndex != 1 {
+		t.Fatalf("the next series must not inherit the label range: %#v", next)
+	}
+}
+
 func TestParseMixedChartType(t *testing.T) {
 	xml := `<?xml version="1.0" encoding="UTF-8"?>
 <c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
@@ -203,3 +407,295 @@ func TestParseMixedChartType(t *testing.T) {
 		t.Fatalf("expected mixed chart type, got %q", parsed.ChartType)
 	}
 }
+
+func TestParseLineChartSecondaryAxis(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:lineChart>
+        <c:ser><c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val></c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:lineChart>
+      <c:lineChart>
+        <c:ser><c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f></c:numRef></c:val></c:ser>
+        <c:axId val="3"/>
+        <c:axId val="4"/>
+      </c:lineChart>
+      <c:catAx><c:axId val="1"/><c:crossAx val="2"/></c:catAx>
+      <c:valAx><c:axId val="2"/><c:axPos val="l"/><c:crossAx val="1"/></c:valAx>
+      <c:catAx><c:axId val="3"/><c:crossAx val="4"/></c:catAx>
+      <c:valAx><c:axId val="4"/><c:axPos val="r"/><c:crossAx val="3"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if parsed.ChartType != "line" {
+		t.Fatalf("expected line chart type, got %q", parsed.ChartType)
+	}
+	if len(parsed.Plots) != 2 || strings.Join(parsed.Plots[1].AxisIDs, ",") != "3,4" || len(parsed.Plots[1].SeriesIndices) != 1 {
+		t.Fatalf("unexpected plots: %#v", parsed.Plots)
+	}
+	if len(parsed.AxisGroups) != 2 || parsed.AxisGroups[1].ValAxID != "4" || parsed.AxisGroups[1].ValAxisPos != "r" {
+		t.Fatalf("unexpected axis groups: %#v", parsed.AxisGroups)
+	}
+	if parsed.SeriesAxes[0] != "primary" || parsed.SeriesAxes[1] != "secondary" {
+		t.Fatalf("unexpected series axes: %#v", parsed.SeriesAxes)
+	}
+}
+
+func TestParseSingleValueAxisLeavesSeriesAxesUnset(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser><c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val></c:ser>
+        <c:ser><c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f></c:numRef></c:val></c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:barChart>
+      <c:catAx><c:axId val="1"/><c:crossAx val="2"/></c:catAx>
+      <c:valAx><c:axId val="2"/><c:crossAx val="1"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(parsed.Plots) != 1 || len(parsed.Plots[0].SeriesIndices) != 2 || len(parsed.AxisGroups) != 1 {
+		t.Fatalf("unexpected structure: plots=%#v groups=%#v", parsed.Plots, parsed.AxisGroups)
+	}
+	if parsed.SeriesAxes != nil {
+		t.Fatalf("expected no series axes for a single value axis, got %#v", parsed.SeriesAxes)
+	}
+}
+
+func TestParseSeriesIdxWithGaps(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:idx val="0"/><c:order val="0"/>
+          <c:dPt><c:idx val="1"/></c:dPt>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:idx val="2"/><c:order val="1"/>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:idx val="5"/><c:order val="2"/>
+          <c:dLbls><c:dLbl><c:idx val="0"/></c:dLbl></c:dLbls>
+          <c:val><c:numRef><c:f>Sheet1!$D$2:$D$3</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := map[int]int{0: 0, 1: 2, 2: 5}
+	if len(parsed.SeriesIdx) != len(want) {
+		t.Fatalf("unexpected series idx: %#v", parsed.SeriesIdx)
+	}
+	for index, idx := range want {
+		if parsed.SeriesIdx[index] != idx {
+			t.Fatalf("series %d: expected idx %d, got %#v", index, idx, parsed.SeriesIdx)
+		}
+	}
+	for i, formula := range parsed.Formulas {
+		if formula.SeriesIndex != i {
+			t.Fatalf("formula %d: expected positional series index, got %d", i, formula.SeriesIndex)
+		}
+	}
+}
+
+func TestParseSeriesIndexFollowsOrder(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:lineChart>
+        <c:ser>
+          <c:idx val="0"/><c:order val="0"/>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:idx val="2"/><c:order val="2"/>
+          <c:tx><c:strRef><c:f>Sheet1!$C$1</c:f></c:strRef></c:tx>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:idx val="3"/><c:order val="1"/>
+          <c:dPt><c:idx val="1"/></c:dPt>
+          <c:val><c:numRef><c:f>Sheet1!$D$2:$D$3</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:lineChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Formula{
+		{Kind: KindValues, SeriesIndex: 0, Formula: "Sheet1!$B$2:$B$3"},
+		{Kind: KindValues, SeriesIndex: 1, Formula: "Sheet1!$D$2:$D$3"},
+		{Kind: KindSeriesName, SeriesIndex: 2, Formula: "Sheet1!$C$1"},
+		{Kind: KindValues, SeriesIndex: 2, Formula: "Sheet1!$C$2:$C$3"},
+	}
+	if !reflect.DeepEqual(parsed.Formulas, want) {
+		t.Fatalf("unexpected formulas: %#v", parsed.Formulas)
+	}
+	if !reflect.DeepEqual(parsed.SeriesIdx, map[int]int{0: 0, 1: 3, 2: 2}) {
+		t.Fatalf("unexpected series idx: %#v", parsed.SeriesIdx)
+	}
+	if !reflect.DeepEqual(parsed.SeriesPositions, map[int]int{0: 0, 1: 2, 2: 1}) {
+		t.Fatalf("unexpected series positions: %#v", parsed.SeriesPositions)
+	}
+	if !reflect.DeepEqual(parsed.Plots[0].SeriesIndices, []int{0, 2, 1}) {
+		t.Fatalf("unexpected plot series: %#v", parsed.Plots)
+	}
+
+	inOrder, err := Parse(strings.NewReader(strings.Replace(xml, `<c:order val="2"/>`, `<c:order val="1"/>`, 1)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if inOrder.SeriesPositions != nil || inOrder.Formulas[1].SeriesIndex != 1 || inOrder.Formulas[3].SeriesIndex != 2 {
+		t.Fatalf("expected a tie to keep positions, got %#v", inOrder)
+	}
+}
+
+func TestParseWithCancelStopsWhenCanceled(t *testing.T) {
+	flag := xmlcancel.New()
+	flag.Cancel()
+
+	xml := `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart/></c:chartSpace>`
+	if _, err := ParseWithCancel(strings.NewReader(xml), flag); !errors.Is(err, xmlcancel.ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	if _, err := ParseInfoWithCancel(strings.NewReader(xml), flag); !errors.Is(err, xmlcancel.ErrCanceled) {
+		t.Fatalf("expected ErrCanceled from ParseInfo, got %v", err)
+	}
+	if _, err := ParseWithCancel(strings.NewReader(xml), nil); err != nil {
+		t.Fatalf("expected nil flag to never cancel, got %v", err)
+	}
+}
+
+func TestRewriteSeriesFormulas(t *testing.T) {
+	xml := `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:tx><c:strRef><c:f>Sheet1!$B$1</c:f></c:strRef></c:tx>
+          <c:cat><c:strRef><c:f> Sheet1!$A$2:$A$3 </c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	out, err := RewriteSeriesFormulas([]byte(xml), map[string]string{
+		"Sheet1!$A$2:$A$3": "Sheet1!$A$2:$A$5",
+		"Sheet1!$B$2:$B$3": "'R&D'!$B$2:$B$5",
+		"Sheet1!$B$1":      "Sheet1!$C$1",
+	})
+	if err != nil {
+		t.Fatalf("RewriteSeriesFormulas: %v", err)
+	}
+	want := strings.NewReplacer(
+		"<c:f> Sheet1!$A$2:$A$3 </c:f>", "<c:f>Sheet1!$A$2:$A$5</c:f>",
+		"<c:f>Sheet1!$B$2:$B$3</c:f>", "<c:f>&#39;R&amp;D&#39;!$B$2:$B$5</c:f>",
+	).Replace(xml)
+	if string(out) != want {
+		t.Fatalf("unexpected rewrite:\n%s", out)
+	}
+}
+
+func TestParseNumCacheFormatCodes(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:lineChart>
+        <c:ser>
+          <c:tx><c:strRef><c:f>Sheet1!$B$1</c:f></c:strRef></c:tx>
+          <c:cat><c:numRef><c:f>Sheet1!$A$2:$A$3</c:f><c:numCache><c:formatCode>mmm yy</c:formatCode><c:ptCount val="2"/></c:numCache></c:numRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:formatCode> 0.0% </c:formatCode><c:ptCount val="2"/></c:numCache></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f><c:numCache><c:ptCount val="2"/></c:numCache></c:numRef></c:val>
+        </c:ser>
+      </c:lineChart>
+      <c:valAx><c:axId val="2"/><c:numFmt formatCode="#,##0" sourceLinked="0"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Formula{
+		{Kind: KindSeriesName, SeriesIndex: 0, Formula: "Sheet1!$B$1"},
+		{Kind: KindCategories, SeriesIndex: 0, Formula: "Sheet1!$A$2:$A$3", FormatCode: "mmm yy", PtCount: 2},
+		{Kind: KindValues, SeriesIndex: 0, Formula: "Sheet1!$B$2:$B$3", FormatCode: "0.0%", PtCount: 2},
+		{Kind: KindValues, SeriesIndex: 1, Formula: "Sheet1!$C$2:$C$3", PtCount: 2},
+	}
+	if !reflect.DeepEqual(parsed.Formulas, want) {
+		t.Fatalf("unexpected formulas: %#v", parsed.Formulas)
+	}
+}
+
+func TestParseMultiLevelCategories(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat>
+            <c:multiLvlStrRef>
+              <c:f>Sheet1!$A$2:$B$5</c:f>
+              <c:multiLvlStrCache>
+                <c:ptCount val="4"/>
+                <c:lvl><c:pt idx="0"><c:v>H1</c:v></c:pt><c:pt idx="1"><c:v>H2</c:v></c:pt><c:pt idx="2"><c:v>H1</c:v></c:pt><c:pt idx="3"><c:v>H2</c:v></c:pt></c:lvl>
+                <c:lvl><c:pt idx="0"><c:v>2024</c:v></c:pt><c:pt idx="2"><c:v>2025</c:v></c:pt></c:lvl>
+              </c:multiLvlStrCache>
+            </c:multiLvlStrRef>
+          </c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$5</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	parsed, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Formula{
+		{Kind: KindCategories, SeriesIndex: 0, Formula: "Sheet1!$A$2:$B$5", PtCount: 4, MultiLevel: true},
+		{Kind: KindValues, SeriesIndex: 0, Formula: "Sheet1!$C$2:$C$5"},
+	}
+	if !reflect.DeepEqual(parsed.Formulas, want) {
+		t.Fatalf("unexpected formulas: %#v", parsed.Formulas)
+	}
+}
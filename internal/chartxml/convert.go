@@ -0,0 +1,311 @@
+package chartxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ErrUnsupportedConversion is wrapped by ConvertPlot errors naming why a
+// chart cannot be converted.
+var ErrUnsupportedConversion = errors.New("unsupported chart conversion")
+
+// plotSchema lists the children of a plot element and of its series in
+// schema order (CT_BarChart/CT_BarSer, CT_LineChart/CT_LineSer).
+type plotSchema struct {
+	plot   []string
+	series []string
+	// labelPositions are the dLblPos values the plot type accepts.
+	labelPositions []string
+}
+
+var plotSchemas = map[string]plotSchema{
+	"bar": {
+		plot:           []string{"barDir", "grouping", "varyColors", "ser", "dLbls", "gapWidth", "overlap", "serLines", "axId", "extLst"},
+		series:         []string{"idx", "order", "tx", "spPr", "invertIfNegative", "pictureOptions", "dPt", "dLbls", "trendline", "errBars", "cat", "val", "shape", "extLst"},
+		labelPositions: []string{"ctr", "inBase", "inEnd", "outEnd"},
+	},
+	"line": {
+		plot:           []string{"grouping", "varyColors", "ser", "dLbls", "dropLines", "hiLowLines", "upDownBars", "marker", "smooth", "axId", "extLst"},
+		series:         []string{"idx", "order", "tx", "spPr", "marker", "dPt", "dLbls", "trendline", "errBars", "cat", "val", "smooth", "extLst"},
+		labelPositions: []string{"b", "ctr", "l", "r", "t"},
+	},
+}
+
+// generated are the defaults ConvertPlot adds for children the target type
+// expects. Values are element local names and val attributes.
+var generated = map[string]struct {
+	plot   [][2]string
+	series [][2]string
+}{
+	"bar":  {plot: [][2]string{{"barDir", "col"}, {"grouping", "clustered"}, {"gapWidth", "150"}}},
+	"line": {plot: [][2]string{{"grouping", "standard"}, {"marker", "1"}}, series: [][2]string{{"smooth", "0"}}},
+}
+
+type convertNode struct {
+	name       string
+	start, end int64
+	val        string
+	children   []*convertNode
+}
+
+// ConvertPlot rewrites the single bar or line plot of a chart as the other
+// type ("bar" or "line"). The plot element is renamed, children the target
+// type does not allow are dropped, and the children it expects are added at
+// their schema position with PowerPoint's defaults. Series keep idx, order,
+// tx, spPr, cat, val, and their caches; axIds are kept. Everything outside
+// the dropped and added elements is copied byte for byte.
+//
+// Charts with more than one plot, 3-D plots, pie or other plot types,
+// stacked groupings, horizontal bars, and data label positions the target
+// type lacks are rejected with an error wrapping ErrUnsupportedConversion.
+func ConvertPlot(chartXML []byte, to string) ([]byte, error) {
+	target, ok := plotSchemas[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot convert to %q; supported types are bar and line", ErrUnsupportedConversion, to)
+	}
+
+	plot, plotCount, labelPositions, err := findConvertiblePlot(chartXML)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case plotCount == 0:
+		return nil, fmt.Errorf("%w: chart has no plot", ErrUnsupportedConversion)
+	case plotCount > 1:
+		return nil, fmt.Errorf("%w: chart has %d plots; only single-plot charts can be converted", ErrUnsupportedConversion, plotCount)
+	}
+	if bytes.HasSuffix(chartXML[:plot.end], []byte("/>")) {
+		return nil, fmt.Errorf("%w: %s has no series", ErrUnsupportedConversion, plot.name)
+	}
+	from := strings.TrimSuffix(plot.name, "Chart")
+	if strings.HasSuffix(from, "3D") {
+		return nil, fmt.Errorf("%w: 3-D charts (%s) are not supported", ErrUnsupportedConversion, plot.name)
+	}
+	source, ok := plotSchemas[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s charts cannot be converted", ErrUnsupportedConversion, plot.name)
+	}
+	if from == to {
+		return nil, fmt.Errorf("%w: chart is already a %s chart", ErrUnsupportedConversion, to)
+	}
+
+	for _, child := range plot.children {
+		switch child.name {
+		case "grouping":
+			if child.val == "stacked" || child.val == "percentStacked" {
+				return nil, fmt.Errorf("%w: %s grouping is not supported", ErrUnsupportedConversion, child.val)
+			}
+		case "barDir":
+			if child.val == "bar" {
+				return nil, fmt.Errorf("%w: horizontal bar charts (barDir=bar) cannot be converted", ErrUnsupportedConversion)
+			}
+		}
+	}
+	for _, pos := range labelPositions {
+		if !contains(target.labelPositions, pos) {
+			return nil, fmt.Errorf("%w: data label position %q is not valid for %s charts", ErrUnsupportedConversion, pos, to)
+		}
+	}
+
+	var splices []convertSplice
+	prefix, rawName := plotPrefix(chartXML, plot.start)
+	newName := prefix + to + "Chart"
+	splices = append(splices,
+		convertSplice{start: plot.start + 1, end: plot.start + 1 + int64(len(rawName)), with: []byte(newName)},
+		convertSplice{start: closeTagName(chartXML, plot), end: closeTagName(chartXML, plot) + int64(len(rawName)), with: []byte(newName)},
+	)
+
+	splices = append(splices, convertChildren(chartXML, plot, source.plot, target.plot, generated[to].plot, prefix)...)
+	for _, child := range plot.children {
+		if child.name == "ser" {
+			splices = append(splices, convertChildren(chartXML, child, source.series, target.series, generated[to].series, prefix)...)
+		}
+	}
+	for _, child := range plot.children {
+		if child.name == "grouping" && child.val == "clustered" && to == "line" {
+			splices = append(splices, valSplice(chartXML, child, "standard"))
+		}
+		if child.name == "grouping" && child.val == "standard" && to == "bar" {
+			splices = append(splices, valSplice(chartXML, child, "clustered"))
+		}
+	}
+
+	return applyConvertSplices(chartXML, splices), nil
+}
+
+// findConvertiblePlot returns the first chart element under plotArea with
+// its direct children and those of its series, the number of plot elements,
+// and every dLblPos value inside the first plot.
+func findConvertiblePlot(chartXML []byte) (*convertNode, int, []string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(chartXML))
+	var stack []*convertNode
+	plotAreaDepth := -1
+	plotAreaDone := false
+	plotCount := 0
+	var plot *convertNode
+	var labelPositions []string
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("parse chart xml: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			node := &convertNode{name: tok.Name.Local, start: offset, val: attrVal(tok)}
+			depth := len(stack)
+			switch {
+			case tok.Name.Local == "plotArea" && plotAreaDepth < 0 && !plotAreaDone:
+				plotAreaDepth = depth
+			case plotAreaDepth >= 0 && depth == plotAreaDepth+1 && strings.HasSuffix(tok.Name.Local, "Chart"):
+				plotCount++
+				if plot == nil {
+					plot = node
+				}
+			case plot != nil && plot.end == 0 && depth > plotAreaDepth+1:
+				parent := stack[depth-1]
+				if parent == plot || (parent.name == "ser" && depth == plotAreaDepth+3) {
+					parent.children = append(parent.children, node)
+				}
+				if tok.Name.Local == "dLblPos" {
+					labelPositions = append(labelPositions, node.val)
+				}
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			node.end = decoder.InputOffset()
+			if len(stack) == plotAreaDepth {
+				plotAreaDepth, plotAreaDone = -1, true
+			}
+		}
+	}
+	return plot, plotCount, labelPositions, nil
+}
+
+type convertSplice struct {
+	start, end int64
+	with       []byte
+}
+
+// convertChildren drops the children of parent that the target schema does
+// not allow and inserts the defaults it is missing, each before the first
+// kept child that follows it in target order.
+func convertChildren(data []byte, parent *convertNode, sourceOrder, targetOrder []string, defaults [][2]string, prefix string) []convertSplice {
+	var splices []convertSplice
+	var kept []*convertNode
+	present := make(map[string]bool)
+	for _, child := range parent.children {
+		if !contains(targetOrder, child.name) {
+			if contains(sourceOrder, child.name) {
+				splices = append(splices, convertSplice{start: lineStart(data, child.start), end: child.end})
+			}
+			continue
+		}
+		kept = append(kept, child)
+		present[child.name] = true
+	}
+
+	for _, def := range defaults {
+		name, val := def[0], def[1]
+		if present[name] {
+			continue
+		}
+		element := []byte(fmt.Sprintf(`<%s%s val="%s"/>`, prefix, name, val))
+		rank := indexOf(targetOrder, name)
+		var next *convertNode
+		for _, child := range kept {
+			if indexOf(targetOrder, child.name) > rank {
+				next = child
+				break
+			}
+		}
+		switch {
+		case next != nil:
+			indent := data[lineStart(data, next.start):next.start]
+			splices = append(splices, convertSplice{start: next.start, end: next.start, with: append(element, indent...)})
+		case len(kept) > 0:
+			last := kept[len(kept)-1]
+			indent := data[lineStart(data, last.start):last.start]
+			splices = append(splices, convertSplice{start: last.end, end: last.end, with: append(append([]byte(nil), indent...), element...)})
+		default:
+			closeStart := closeTagName(data, parent) - 2
+			splices = append(splices, convertSplice{start: closeStart, end: closeStart, with: element})
+		}
+	}
+	return splices
+}
+
+// closeTagName returns the offset of the name in node's end tag.
+func closeTagName(data []byte, node *convertNode) int64 {
+	return int64(bytes.LastIndex(data[:node.end], []byte("</"))) + 2
+}
+
+func valSplice(data []byte, node *convertNode, val string) convertSplice {
+	raw := data[node.start:]
+	loc := valAttr.FindSubmatchIndex(raw[:bytes.IndexByte(raw, '>')+1])
+	quote := raw[loc[2]]
+	return convertSplice{
+		start: node.start + int64(loc[2]),
+		end:   node.start + int64(loc[3]),
+		with:  []byte(string(quote) + val + string(quote)),
+	}
+}
+
+func applyConvertSplices(data []byte, splices []convertSplice) []byte {
+	// Insertions sort before a removal starting at the same offset, and keep
+	// their generation order among themselves.
+	sort.SliceStable(splices, func(i, j int) bool {
+		if splices[i].start != splices[j].start {
+			return splices[i].start < splices[j].start
+		}
+		return splices[i].end == splices[i].start && splices[j].end != splices[j].start
+	})
+	out := make([]byte, 0, len(data)+128)
+	last := int64(0)
+	for _, s := range splices {
+		out = append(out, data[last:s.start]...)
+		out = append(out, s.with...)
+		last = s.end
+	}
+	return append(out, data[last:]...)
+}
+
+// plotPrefix returns the namespace prefix, colon included, and the raw
+// name of the start tag at offset.
+func plotPrefix(data []byte, offset int64) (string, string) {
+	raw := data[offset+1:]
+	end := bytes.IndexAny(raw, " \t\r\n/>")
+	name := string(raw[:end])
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[:i+1], name
+	}
+	return "", name
+}
+
+func contains(values []string, value string) bool {
+	return indexOf(values, value) >= 0
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
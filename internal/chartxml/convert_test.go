@@ -0,0 +1,132 @@
+package chartxml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const convertBarChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:barDir val="col"/>
+        <c:grouping val="clustered"/>
+        <c:varyColors val="0"/>
+        <c:ser>
+          <c:idx val="0"/>
+          <c:order val="0"/>
+          <c:tx><c:v>Sales</c:v></c:tx>
+          <c:spPr><a:ln xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"/></c:spPr>
+          <c:invertIfNegative val="0"/>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:gapWidth val="219"/>
+        <c:overlap val="-27"/>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:barChart>
+      <c:catAx><c:axId val="1"/></c:catAx>
+      <c:valAx><c:axId val="2"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+const convertLineChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:lineChart>
+        <c:grouping val="standard"/>
+        <c:varyColors val="0"/>
+        <c:ser>
+          <c:idx val="0"/>
+          <c:order val="0"/>
+          <c:tx><c:v>Sales</c:v></c:tx>
+          <c:spPr><a:ln xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"/></c:spPr>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+          <c:smooth val="0"/>
+        </c:ser>
+        <c:marker val="1"/>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:lineChart>
+      <c:catAx><c:axId val="1"/></c:catAx>
+      <c:valAx><c:axId val="2"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+func TestConvertPlotBarToLine(t *testing.T) {
+	out, err := ConvertPlot([]byte(convertBarChart), "line")
+	if err != nil {
+		t.Fatalf("ConvertPlot: %v", err)
+	}
+	if string(out) != convertLineChart {
+		t.Fatalf("unexpected line chart:\n%s", out)
+	}
+
+	info, err := ParseInfo(strings.NewReader(string(out)))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if info.ChartType != "line" || info.SeriesCount != 1 {
+		t.Fatalf("unexpected info: %#v", info)
+	}
+}
+
+func TestConvertPlotLineToBar(t *testing.T) {
+	out, err := ConvertPlot([]byte(convertLineChart), "bar")
+	if err != nil {
+		t.Fatalf("ConvertPlot: %v", err)
+	}
+	want := strings.NewReplacer(
+		`<c:gapWidth val="219"/>`, `<c:gapWidth val="150"/>`,
+		"\n        <c:overlap val=\"-27\"/>", "",
+		"\n          <c:invertIfNegative val=\"0\"/>", "",
+	).Replace(convertBarChart)
+	if string(out) != want {
+		t.Fatalf("unexpected bar chart:\n%s", out)
+	}
+}
+
+func TestConvertPlotAddsDefaultsToBareChart(t *testing.T) {
+	in := `<c:chartSpace xmlns:c="c"><c:chart><c:plotArea><c:barChart><c:ser><c:idx val="0"/><c:order val="0"/><c:val><c:numRef><c:f>Sheet1!$B$2</c:f></c:numRef></c:val></c:ser></c:barChart></c:plotArea></c:chart></c:chartSpace>`
+	out, err := ConvertPlot([]byte(in), "line")
+	if err != nil {
+		t.Fatalf("ConvertPlot: %v", err)
+	}
+	want := `<c:chartSpace xmlns:c="c"><c:chart><c:plotArea><c:lineChart><c:grouping val="standard"/><c:ser><c:idx val="0"/><c:order val="0"/><c:val><c:numRef><c:f>Sheet1!$B$2</c:f></c:numRef></c:val><c:smooth val="0"/></c:ser><c:marker val="1"/></c:lineChart></c:plotArea></c:chart></c:chartSpace>`
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestConvertPlotRejections(t *testing.T) {
+	cases := map[string]struct {
+		chart  string
+		to     string
+		reason string
+	}{
+		"area target": {convertBarChart, "area", `cannot convert to "area"`},
+		"same type":   {convertBarChart, "bar", "already a bar chart"},
+		"stacked":     {strings.Replace(convertBarChart, `val="clustered"`, `val="stacked"`, 1), "line", "stacked grouping"},
+		"horizontal":  {strings.Replace(convertBarChart, `<c:barDir val="col"/>`, `<c:barDir val="bar"/>`, 1), "line", "barDir=bar"},
+		"3-D":         {strings.ReplaceAll(convertBarChart, "c:barChart>", "c:bar3DChart>"), "line", "3-D charts"},
+		"pie":         {strings.ReplaceAll(convertBarChart, "c:barChart>", "c:pieChart>"), "line", "pieChart charts cannot be converted"},
+		"label pos":   {strings.Replace(convertBarChart, `<c:gapWidth`, `<c:dLbls><c:dLblPos val="inEnd"/></c:dLbls><c:gapWidth`, 1), "line", `"inEnd" is not valid for line`},
+		"two plots":   {strings.Replace(convertBarChart, `<c:catAx>`, `<c:lineChart><c:ser/></c:lineChart><c:catAx>`, 1), "line", "2 plots"},
+		"no series":   {`<c:chartSpace xmlns:c="c"><c:chart><c:plotArea><c:barChart/></c:plotArea></c:chart></c:chartSpace>`, "line", "has no series"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ConvertPlot([]byte(tc.chart), tc.to)
+			if !errors.Is(err, ErrUnsupportedConversion) || !strings.Contains(err.Error(), tc.reason) {
+				t.Fatalf("expected %q rejection, got %v", tc.reason, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package chartxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrDataLabelsUnsupported is wrapped by SetDataLabels errors for a chart
+// with a plot whose data labels are not modeled, such as a scatter plot.
+var ErrDataLabelsUnsupported = errors.New("data labels not supported for chart type")
+
+// AllSeries selects every series of the chart in SetDataLabels.
+const AllSeries = -1
+
+// DataLabels is what a c:dLbls element shows. A dLbls holding c:delete
+// shows nothing.
+type DataLabels struct {
+	ShowVal     bool
+	ShowCatName bool
+	ShowPercent bool
+	// FormatCode is the formatCode of the labels' c:numFmt, empty without
+	// one; SourceLinked is its sourceLinked flag.
+	FormatCode   string
+	SourceLinked bool
+}
+
+// dLblsChildren lists the children of CT_DLbls in schema order, with the
+// Group_DLbls elements in place of the delete they are an alternative to.
+var dLblsChildren = []string{
+	"dLbl", "delete", "numFmt", "spPr", "txPr", "dLblPos", "showLegendKey", "showVal", "showCatName",
+	"showSerName", "showPercent", "showBubbleSize", "separator", "showLeaderLines", "leaderLines", "extLst",
+}
+
+// serAfterDLbls lists the CT_Ser children, across the plot types
+// SetDataLabels accepts, that come after dLbls.
+var serAfterDLbls = map[string]bool{
+	"trendline": true, "errBars": true, "cat": true, "val": true, "smooth": true,
+	"shape": true, "bubble3D": true, "extLst": true,
+}
+
+// dataLabelsPlots lists the plot elements whose series SetDataLabels
+// writes.
+var dataLabelsPlots = map[string]bool{
+	"barChart": true, "lineChart": true, "pieChart": true, "doughnutChart": true, "areaChart": true,
+}
+
+// dataLabelsTracker collects the c:dLbls that are direct children of a
+// plot element or of one of its series. Those of single points (c:dLbl)
+// are not read.
+type dataLabelsTracker struct {
+	stack   []string
+	idx     int
+	hasIdx  bool
+	current *DataLabels
+	inSer   bool
+	plot    *DataLabels
+	series  map[int]DataLabels
+}
+
+func (t *dataLabelsTracker) start(tok xml.StartElement) {
+	name := tok.Name.Local
+	parent, grandparent := t.parent(1), t.parent(2)
+	_, parentIsPlot := infoPlotType(parent)
+	_, grandparentIsPlot := infoPlotType(grandparent)
+	switch {
+	case name == "ser" && parentIsPlot:
+		t.hasIdx = false
+	case name == "idx" && parent == "ser" && grandparentIsPlot:
+		if idx, err := strconv.Atoi(attrVal(tok)); err == nil {
+			t.idx, t.hasIdx = idx, true
+		}
+	case name == "dLbls" && parentIsPlot:
+		t.current, t.inSer = &DataLabels{}, false
+	case name == "dLbls" && parent == "ser" && grandparentIsPlot:
+		t.current, t.inSer = &DataLabels{}, true
+	case t.current != nil && parent == "dLbls":
+		switch name {
+		case "showVal":
+			t.current.ShowVal = isTrueVal(attrVal(tok))
+		case "showCatName":
+			t.current.ShowCatName = isTrueVal(attrVal(tok))
+		case "showPercent":
+			t.current.ShowPercent = isTrueVal(attrVal(tok))
+		case "numFmt":
+			for _, attr := range tok.Attr {
+				switch attr.Name.Local {
+				case "formatCode":
+					t.current.FormatCode = attr.Value
+				case "sourceLinked":
+					t.current.SourceLinked = attr.Value == "1" || attr.Value == "true"
+				}
+			}
+		case "delete":
+			if isTrueVal(attrVal(tok)) {
+				*t.current = DataLabels{}
+			}
+		}
+	}
+	t.stack = append(t.stack, name)
+}
+
+func (t *dataLabelsTracker) end(name string) {
+	if len(t.stack) > 0 {
+		t.stack = t.stack[:len(t.stack)-1]
+	}
+	if name != "dLbls" || t.current == nil {
+		return
+	}
+	switch {
+	case t.inSer && t.hasIdx:
+		if t.series == nil {
+			t.series = make(map[int]DataLabels)
+		}
+		t.series[t.idx] = *t.current
+	case !t.inSer && t.plot == nil:
+		t.plot = t.current
+	}
+	t.current = nil
+}
+
+// parent returns the name of the element n levels above the next one.
+func (t *dataLabelsTracker) parent(n int) string {
+	if len(t.stack) < n {
+		return ""
+	}
+	return t.stack[len(t.stack)-n]
+}
+
+// SetDataLabels shows or hides the data labels of the series whose c:idx is
+// seriesIdx, or of every series for AllSeries, as labels says. A series
+// dLbls is updated in place: showVal, showCatName, and showPercent take the
+// new values, the other Group_DLbls flags PowerPoint requires are added as
+// "0" where missing, a c:delete is dropped, and numFmt is replaced when
+// labels.FormatCode is set. Point labels, shape and text properties, and
+// anything else in it are kept. A series without one gets a new dLbls.
+// Everything outside the changed elements is copied byte for byte.
+//
+// A chart with a plot other than bar, line, pie, doughnut, or area is
+// rejected with an error wrapping ErrDataLabelsUnsupported.
+func SetDataLabels(chartXML []byte, labels DataLabels, seriesIdx int) ([]byte, error) {
+	root, _, err := parseElementTree(chartXML)
+	if err != nil {
+		return nil, err
+	}
+	plotArea := childElement(childElement(root, "chart"), "plotArea")
+	if root == nil || root.name != "chartSpace" || plotArea == nil {
+		return nil, fmt.Errorf("plot area not found")
+	}
+
+	var series []*convertNode
+	for _, plot := range plotArea.children {
+		if _, ok := infoPlotType(plot.name); !ok {
+			continue
+		}
+		if !dataLabelsPlots[plot.name] {
+			return nil, fmt.Errorf("%w: %s", ErrDataLabelsUnsupported, plot.name)
+		}
+		for _, ser := range plot.children {
+			if ser.name != "ser" {
+				continue
+			}
+			if seriesIdx != AllSeries {
+				idx := childElement(ser, "idx")
+				if idx == nil || idx.val != strconv.Itoa(seriesIdx) {
+					continue
+				}
+			}
+			series = append(series, ser)
+		}
+	}
+	if len(series) == 0 {
+		if seriesIdx != AllSeries {
+			return nil, fmt.Errorf("series %d not found", seriesIdx)
+		}
+		return chartXML, nil
+	}
+
+	var splices []convertSplice
+	for _, ser := range series {
+		prefix, _ := plotPrefix(chartXML, ser.start)
+		dLbls := childElement(ser, "dLbls")
+		switch {
+		case dLbls == nil:
+			splices = append(splices, insertSeriesDLbls(chartXML, ser, newDLbls(prefix, labels)))
+		case isEmptyElement(chartXML, dLbls):
+			splices = append(splices, convertSplice{start: dLbls.start, end: dLbls.end, with: newDLbls(prefix, labels)})
+		default:
+			splices = append(splices, updateDLbls(chartXML, dLbls, prefix, labels)...)
+		}
+	}
+	return applyConvertSplices(chartXML, splices), nil
+}
+
+// dLblsFlags are the Group_DLbls flags in schema order, with the value
+// labels gives each.
+func dLblsFlags(labels DataLabels) [][2]string {
+	flag := func(on bool) string {
+		if on {
+			return "1"
+		}
+		return "0"
+	}
+	return [][2]string{
+		{"showLegendKey", "0"},
+		{"showVal", flag(labels.ShowVal)},
+		{"showCatName", flag(labels.ShowCatName)},
+		{"showSerName", "0"},
+		{"showPercent", flag(labels.ShowPercent)},
+		{"showBubbleSize", "0"},
+	}
+}
+
+func dLblsNumFmt(prefix string, labels DataLabels) []byte {
+	var escaped bytes.Buffer
+	_ = xml.EscapeText(&escaped, []byte(labels.FormatCode))
+	linked := "0"
+	if labels.SourceLinked {
+		linked = "1"
+	}
+	return []byte(fmt.Sprintf(`<%snumFmt formatCode="%s" sourceLinked="%s"/>`, prefix, escaped.String(), linked))
+}
+
+func newDLbls(prefix string, labels DataLabels) []byte {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "<%sdLbls>", prefix)
+	if labels.FormatCode != "" {
+		out.Write(dLblsNumFmt(prefix, labels))
+	}
+	for _, flag := range dLblsFlags(labels) {
+		fmt.Fprintf(&out, `<%s%s val="%s"/>`, prefix, flag[0], flag[1])
+	}
+	fmt.Fprintf(&out, "</%sdLbls>", prefix)
+	return out.Bytes()
+}
+
+// insertSeriesDLbls inserts element before the first child of ser that
+// comes after dLbls, or at the end of ser.
+func insertSeriesDLbls(data []byte, ser *convertNode, element []byte) convertSplice {
+	for _, child := range ser.children {
+		if serAfterDLbls[child.name] {
+			indent := data[lineStart(data, child.start):child.start]
+			return convertSplice{start: child.start, end: child.start, with: append(append([]byte(nil), element...), indent...)}
+		}
+	}
+	if len(ser.children) > 0 {
+		last := ser.children[len(ser.children)-1]
+		indent := data[lineStart(data, last.start):last.start]
+		return convertSplice{start: last.end, end: last.end, with: append(append([]byte(nil), indent...), element...)}
+	}
+	closeStart := closeTagName(data, ser) - 2
+	return convertSplice{start: closeStart, end: closeStart, with: element}
+}
+
+// updateDLbls rewrites the flags and number format of an existing dLbls.
+func updateDLbls(data []byte, dLbls *convertNode, prefix string, labels DataLabels) []convertSplice {
+	var splices []convertSplice
+	var kept []*convertNode
+	for _, child := range dLbls.children {
+		if child.name == "delete" {
+			splices = append(splices, convertSplice{start: lineStart(data, child.start), end: child.end})
+			continue
+		}
+		kept = append(kept, child)
+	}
+
+	set := make(map[string][]byte)
+	if labels.FormatCode != "" {
+		set["numFmt"] = dLblsNumFmt(prefix, labels)
+	}
+	var missing [][]byte
+	var missingNames []string
+	for _, flag := range dLblsFlags(labels) {
+		element := []byte(fmt.Sprintf(`<%s%s val="%s"/>`, prefix, flag[0], flag[1]))
+		if flag[0] == "showVal" || flag[0] == "showCatName" || flag[0] == "showPercent" {
+			set[flag[0]] = element
+		}
+		if childElement(dLbls, flag[0]) == nil {
+			missing = append(missing, element)
+			missingNames = append(missingNames, flag[0])
+		}
+	}
+	if _, ok := set["numFmt"]; ok && childElement(dLbls, "numFmt") == nil {
+		missing = append([][]byte{set["numFmt"]}, missing...)
+		missingNames = append([]string{"numFmt"}, missingNames...)
+	}
+
+	for _, child := range kept {
+		if element, ok := set[child.name]; ok {
+			splices = append(splices, convertSplice{start: child.start, end: child.end, with: element})
+		}
+	}
+	for i, element := range missing {
+		splices = append(splices, insertByRank(data, dLbls, kept, dLblsChildren, missingNames[i], element))
+	}
+	return splices
+}
+
+// insertByRank inserts element as a child of parent at the schema position
+// of name in order: after the last of kept that comes before it, before the
+// first that comes after it, or at the end of parent.
+func insertByRank(data []byte, parent *convertNode, kept []*convertNode, order []string, name string, element []byte) convertSplice {
+	rank := indexOf(order, name)
+	var after, before *convertNode
+	for _, child := range kept {
+		switch childRank := indexOf(order, child.name); {
+		case childRank >= 0 && childRank < rank:
+			after = child
+		case childRank > rank && before == nil:
+			before = child
+		}
+	}
+	switch {
+	case after != nil:
+		indent := data[lineStart(data, after.start):after.start]
+		return convertSplice{start: after.end, end: after.end, with: append(append([]byte(nil), indent...), element...)}
+	case before != nil:
+		indent := data[lineStart(data, before.start):before.start]
+		return convertSplice{start: before.start, end: before.start, with: append(append([]byte(nil), element...), indent...)}
+	}
+	closeStart := closeTagName(data, parent) - 2
+	return convertSplice{start: closeStart, end: closeStart, with: element}
+}
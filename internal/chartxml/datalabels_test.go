@@ -0,0 +1,163 @@
+package chartxml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const dataLabelsChartHead = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">
+  <c:chart>
+    <c:plotArea>
+`
+
+const dataLabelsChartTail = `    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+func TestParseInfoDataLabels(t *testing.T) {
+	plot := `      <c:barChart>
+        <c:ser>
+          <c:idx val="0"/>
+          <c:dLbls><c:numFmt formatCode="0.0%" sourceLinked="0"/><c:showLegendKey val="0"/><c:showVal val="1"/><c:showCatName val="0"/><c:showSerName val="0"/><c:showPercent val="0"/><c:showBubbleSize val="0"/></c:dLbls>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:idx val="1"/>
+          <c:dLbls><c:dLbl><c:idx val="0"/><c:showVal val="1"/></c:dLbl><c:delete val="1"/></c:dLbls>
+        </c:ser>
+        <c:dLbls><c:showCatName val="1"/></c:dLbls>
+      </c:barChart>
+`
+	info, err := ParseInfo(strings.NewReader(dataLabelsChartHead + plot + dataLabelsChartTail))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if info.DataLabels == nil || !info.DataLabels.ShowCatName || info.DataLabels.ShowVal {
+		t.Fatalf("unexpected plot labels %+v", info.DataLabels)
+	}
+	if got := info.SeriesDataLabels[0]; !got.ShowVal || got.ShowCatName || got.FormatCode != "0.0%" || got.SourceLinked {
+		t.Fatalf("unexpected series 0 labels %+v", got)
+	}
+	if got, ok := info.SeriesDataLabels[1]; !ok || got != (DataLabels{}) {
+		t.Fatalf("unexpected series 1 labels %+v (present %t)", got, ok)
+	}
+}
+
+func TestSetDataLabelsInsertsBeforeCategories(t *testing.T) {
+	plot := `      <c:barChart>
+        <c:ser>
+          <c:idx val="0"/>
+          <c:tx><c:v>A</c:v></c:tx>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+        </c:ser>
+        <c:ser>
+          <c:idx val="1"/>
+        </c:ser>
+      </c:barChart>
+`
+	out, err := SetDataLabels([]byte(dataLabelsChartHead+plot+dataLabelsChartTail), DataLabels{ShowVal: true, FormatCode: `0 "pts"`}, AllSeries)
+	if err != nil {
+		t.Fatalf("SetDataLabels: %v", err)
+	}
+	dLbls := `<c:dLbls><c:numFmt formatCode="0 &#34;pts&#34;" sourceLinked="0"/><c:showLegendKey val="0"/><c:showVal val="1"/><c:showCatName val="0"/><c:showSerName val="0"/><c:showPercent val="0"/><c:showBubbleSize val="0"/></c:dLbls>`
+	want := `      <c:barChart>
+        <c:ser>
+          <c:idx val="0"/>
+          <c:tx><c:v>A</c:v></c:tx>
+          ` + dLbls + `
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+        </c:ser>
+        <c:ser>
+          <c:idx val="1"/>
+          ` + dLbls + `
+        </c:ser>
+      </c:barChart>
+`
+	if string(out) != dataLabelsChartHead+want+dataLabelsChartTail {
+		t.Fatalf("unexpected chart:\n%s", out)
+	}
+	info, err := ParseInfo(strings.NewReader(string(out)))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if got := info.SeriesDataLabels[1]; !got.ShowVal || got.FormatCode != `0 "pts"` {
+		t.Fatalf("unexpected series 1 labels %+v", got)
+	}
+}
+
+func TestSetDataLabelsUpdatesExisting(t *testing.T) {
+	plot := `      <c:lineChart>
+        <c:ser>
+          <c:idx val="0"/>
+          <c:dLbls>
+            <c:dLbl><c:idx val="2"/><c:delete val="1"/></c:dLbl>
+            <c:delete val="1"/>
+            <c:spPr><a:noFill/></c:spPr>
+            <c:txPr><a:bodyPr/><a:p><a:pPr><a:defRPr sz="900"/></a:pPr></a:p></c:txPr>
+            <c:showVal val="0"/>
+          </c:dLbls>
+        </c:ser>
+        <c:ser>
+          <c:idx val="3"/>
+          <c:dLbls/>
+        </c:ser>
+      </c:lineChart>
+`
+	out, err := SetDataLabels([]byte(dataLabelsChartHead+plot+dataLabelsChartTail), DataLabels{ShowVal: true, ShowCatName: true}, 0)
+	if err != nil {
+		t.Fatalf("SetDataLabels: %v", err)
+	}
+	want := `      <c:lineChart>
+        <c:ser>
+          <c:idx val="0"/>
+          <c:dLbls>
+            <c:dLbl><c:idx val="2"/><c:delete val="1"/></c:dLbl>
+            <c:spPr><a:noFill/></c:spPr>
+            <c:txPr><a:bodyPr/><a:p><a:pPr><a:defRPr sz="900"/></a:pPr></a:p></c:txPr>
+            <c:showLegendKey val="0"/>
+            <c:showVal val="1"/>
+            <c:showCatName val="1"/>
+            <c:showSerName val="0"/>
+            <c:showPercent val="0"/>
+            <c:showBubbleSize val="0"/>
+          </c:dLbls>
+        </c:ser>
+        <c:ser>
+          <c:idx val="3"/>
+          <c:dLbls/>
+        </c:ser>
+      </c:lineChart>
+`
+	if string(out) != dataLabelsChartHead+want+dataLabelsChartTail {
+		t.Fatalf("unexpected chart:\n%s", out)
+	}
+
+	out, err = SetDataLabels(out, DataLabels{ShowPercent: true}, 3)
+	if err != nil {
+		t.Fatalf("SetDataLabels series 3: %v", err)
+	}
+	info, err := ParseInfo(strings.NewReader(string(out)))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if got := info.SeriesDataLabels[3]; got != (DataLabels{ShowPercent: true}) {
+		t.Fatalf("unexpected series 3 labels %+v", got)
+	}
+	if got := info.SeriesDataLabels[0]; got != (DataLabels{ShowVal: true, ShowCatName: true}) {
+		t.Fatalf("unexpected series 0 labels %+v", got)
+	}
+
+	if _, err := SetDataLabels(out, DataLabels{}, 7); err == nil || !strings.Contains(err.Error(), "series 7 not found") {
+		t.Fatalf("expected missing series error, got %v", err)
+	}
+}
+
+func TestSetDataLabelsRejectsScatter(t *testing.T) {
+	plot := `      <c:scatterChart><c:ser><c:idx val="0"/></c:ser></c:scatterChart>
+`
+	if _, err := SetDataLabels([]byte(dataLabelsChartHead+plot+dataLabelsChartTail), DataLabels{ShowVal: true}, AllSeries); !errors.Is(err, ErrDataLabelsUnsupported) {
+		t.Fatalf("expected ErrDataLabelsUnsupported, got %v", err)
+	}
+}
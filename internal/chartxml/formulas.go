@@ -0,0 +1,89 @@
+package chartxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// RewriteSeriesFormulas replaces the c:f of every series c:cat and c:val
+// whose trimmed text is a key of formulas with the matching value. Series
+// names, data label ranges, and everything else are copied byte for byte;
+// the caches under a rewritten c:f are left for a cache sync to refresh.
+func RewriteSeriesFormulas(chartXML []byte, formulas map[string]string) ([]byte, error) {
+	type splice struct {
+		start, end int64
+		with       []byte
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(chartXML))
+	var splices []splice
+	serDepth := 0
+	// refDepth counts the c:cat or c:val elements open inside the current
+	// series; c:tx and extension ranges never raise it.
+	refDepth := 0
+	textStart := int64(-1)
+	var text strings.Builder
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse chart xml: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			switch {
+			case tok.Name.Local == "ser":
+				serDepth++
+			case serDepth == 0:
+			case tok.Name.Local == "cat" || tok.Name.Local == "val":
+				refDepth++
+			case tok.Name.Local == "f" && refDepth > 0:
+				textStart = decoder.InputOffset()
+				text.Reset()
+			}
+		case xml.CharData:
+			if textStart >= 0 {
+				text.Write(tok)
+			}
+		case xml.EndElement:
+			switch {
+			case tok.Name.Local == "ser" && serDepth > 0:
+				serDepth--
+				refDepth = 0
+			case (tok.Name.Local == "cat" || tok.Name.Local == "val") && refDepth > 0:
+				refDepth--
+			case tok.Name.Local == "f" && textStart >= 0:
+				if next, ok := formulas[strings.TrimSpace(text.String())]; ok {
+					var escaped bytes.Buffer
+					if err := xml.EscapeText(&escaped, []byte(next)); err != nil {
+						return nil, err
+					}
+					splices = append(splices, splice{start: textStart, end: offset, with: escaped.Bytes()})
+				}
+				textStart = -1
+			}
+		}
+	}
+
+	if len(splices) == 0 {
+		return chartXML, nil
+	}
+	sort.Slice(splices, func(i, j int) bool { return splices[i].start < splices[j].start })
+	out := make([]byte, 0, len(chartXML))
+	last := int64(0)
+	for _, s := range splices {
+		out = append(out, chartXML[last:s.start]...)
+		out = append(out, s.with...)
+		last = s.end
+	}
+	return append(out, chartXML[last:]...), nil
+}
@@ -5,15 +5,34 @@ import (
 	"fmt"
 	"io"
 	"strings"
+
+	"why-pptx/internal/xmlcancel"
 )
 
 type Info struct {
 	ChartType   string
 	SeriesCount int
 	Title       string
+	// HiddenLegendEntries holds the legendEntry indices marked deleted.
+	HiddenLegendEntries map[int]bool
+	// AxisGroups are the chart's axis pairs; a scatter chart's pair is its
+	// x and y value axes, the horizontal one as the category axis. Plots
+	// carries the type and axis IDs of each plot (no series indices), for
+	// AxisGroupRoles.
+	AxisGroups []AxisGroup
+	Plots      []MixedPlot
+	// DataLabels is the c:dLbls of the first plot that has one, nil when
+	// none does. SeriesDataLabels maps the c:idx of each series with its own
+	// dLbls, which overrides the plot's, to what it shows.
+	DataLabels       *DataLabels
+	SeriesDataLabels map[int]DataLabels
 }
 
 func ParseInfo(r io.Reader) (*Info, error) {
+	return ParseInfoWithCancel(r, nil)
+}
+
+func ParseInfoWithCancel(r io.Reader, cancel *xmlcancel.Flag) (*Info, error) {
 	decoder := xml.NewDecoder(r)
 	info := &Info{ChartType: "unknown"}
 
@@ -21,13 +40,24 @@ func ParseInfo(r io.Reader) (*Info, error) {
 	lineDepth := 0
 	pieDepth := 0
 	areaDepth := 0
+	stockDepth := 0
+	scatterDepth := 0
+	radarDepth := 0
 	otherDepth := 0
 	titleDepth := 0
 	inTitleText := false
 	titleSet := false
 	var buf strings.Builder
+	var legend legendTracker
+	var labels dataLabelsTracker
+	var axes axisTracker
+	var plots []MixedPlot
+	plotDepth := 0
 
 	for {
+		if err := cancel.Err(); err != nil {
+			return nil, err
+		}
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
@@ -38,6 +68,17 @@ func ParseInfo(r io.Reader) (*Info, error) {
 
 		switch tok := token.(type) {
 		case xml.StartElement:
+			legend.start(tok)
+			labels.start(tok)
+			axes.start(tok)
+			if plotType, ok := infoPlotType(tok.Name.Local); ok {
+				if plotDepth == 0 {
+					plots = append(plots, MixedPlot{PlotType: plotType})
+				}
+				plotDepth++
+			} else if tok.Name.Local == "axId" && plotDepth > 0 && !axes.inAxis() {
+				plots[len(plots)-1].AxisIDs = append(plots[len(plots)-1].AxisIDs, attrVal(tok))
+			}
 			switch tok.Name.Local {
 			case "barChart":
 				barDepth++
@@ -48,20 +89,34 @@ func ParseInfo(r io.Reader) (*Info, error) {
 			case "pieChart":
 				pieDepth++
 				info.ChartType = updateChartType(info.ChartType, "pie")
+			case "doughnutChart":
+				pieDepth++
+				info.ChartType = updateChartType(info.ChartType, "doughnut")
 			case "areaChart":
 				areaDepth++
 				info.ChartType = updateChartType(info.ChartType, "area")
+			case "stockChart":
+				stockDepth++
+				info.ChartType = updateChartType(info.ChartType, "stock")
+			case "scatterChart":
+				scatterDepth++
+				info.ChartType = updateChartType(info.ChartType, "scatter")
+			case "radarChart":
+				radarDepth++
+				info.ChartType = updateChartType(info.ChartType, "radar")
 			default:
 				if isOtherChart(tok.Name.Local) {
 					otherDepth++
-					info.ChartType = updateChartType(info.ChartType, "other")
+					info.ChartType = updateChartType(info.ChartType, otherChartType(tok.Name.Local))
 				}
 			case "ser":
-				if barDepth > 0 || lineDepth > 0 || pieDepth > 0 || areaDepth > 0 {
+				if barDepth > 0 || lineDepth > 0 || pieDepth > 0 || areaDepth > 0 || stockDepth > 0 || scatterDepth > 0 || radarDepth > 0 {
 					info.SeriesCount++
 				}
 			case "title":
-				titleDepth++
+				if !axes.inAxis() {
+					titleDepth++
+				}
 			case "t", "v":
 				if titleDepth > 0 && !titleSet {
 					inTitleText = true
@@ -69,6 +124,12 @@ func ParseInfo(r io.Reader) (*Info, error) {
 				}
 			}
 		case xml.EndElement:
+			legend.end(tok.Name.Local)
+			labels.end(tok.Name.Local)
+			axes.end(tok.Name.Local)
+			if _, ok := infoPlotType(tok.Name.Local); ok && plotDepth > 0 {
+				plotDepth--
+			}
 			switch tok.Name.Local {
 			case "barChart":
 				if barDepth > 0 {
@@ -78,7 +139,7 @@ func ParseInfo(r io.Reader) (*Info, error) {
 				if lineDepth > 0 {
 					lineDepth--
 				}
-			case "pieChart":
+			case "pieChart", "doughnutChart":
 				if pieDepth > 0 {
 					pieDepth--
 				}
@@ -86,12 +147,24 @@ func ParseInfo(r io.Reader) (*Info, error) {
 				if areaDepth > 0 {
 					areaDepth--
 				}
+			case "stockChart":
+				if stockDepth > 0 {
+					stockDepth--
+				}
+			case "scatterChart":
+				if scatterDepth > 0 {
+					scatterDepth--
+				}
+			case "radarChart":
+				if radarDepth > 0 {
+					radarDepth--
+				}
 			default:
 				if isOtherChart(tok.Name.Local) && otherDepth > 0 {
 					otherDepth--
 				}
 			case "title":
-				if titleDepth > 0 {
+				if titleDepth > 0 && !axes.inAxis() {
 					titleDepth--
 				}
 			case "t", "v":
@@ -106,11 +179,75 @@ func ParseInfo(r io.Reader) (*Info, error) {
 				}
 			}
 		case xml.CharData:
+			axes.text(tok)
 			if inTitleText {
 				buf.Write([]byte(tok))
 			}
 		}
 	}
 
+	info.HiddenLegendEntries = legend.hidden
+	info.DataLabels = labels.plot
+	info.SeriesDataLabels = labels.series
+	info.Plots = plots
+	info.AxisGroups = buildAxisGroups(axes.axes)
+	if len(info.AxisGroups) == 0 {
+		info.AxisGroups = buildValueAxisGroups(axes.axes)
+	}
 	return info, nil
 }
+
+// infoPlotType returns the plot type of a plot element, such as "bar" for
+// c:barChart, naming other plots as otherChartType does.
+func infoPlotType(name string) (string, bool) {
+	if isBasicPlot(name) {
+		return strings.TrimSuffix(name, "Chart"), true
+	}
+	if isOtherChart(name) {
+		return otherChartType(name), true
+	}
+	return "", false
+}
+
+// buildValueAxisGroups pairs value axes that cross each other, as a scatter
+// or bubble chart's x and y axes do. The horizontal axis (axPos "b" or "t")
+// takes the category side of the group.
+func buildValueAxisGroups(axes []axisInfo) []AxisGroup {
+	byID := make(map[string]axisInfo)
+	for _, axis := range axes {
+		if axis.kind == "val" && axis.id != "" {
+			byID[axis.id] = axis
+		}
+	}
+	var out []AxisGroup
+	seen := make(map[string]bool)
+	for _, axis := range axes {
+		if axis.kind != "val" || axis.id == "" || seen[axis.id] {
+			continue
+		}
+		other, ok := byID[axis.cross]
+		if !ok || other.cross != axis.id || seen[other.id] {
+			continue
+		}
+		x, y := axis, other
+		if y.axisPos == "b" || y.axisPos == "t" {
+			x, y = y, x
+		}
+		seen[x.id], seen[y.id] = true, true
+		out = append(out, AxisGroup{
+			CatAxID:              x.id,
+			ValAxID:              y.id,
+			ValAxisPos:           y.axisPos,
+			ValHasMajorGridlines: y.hasMajorGridlines,
+			ValHasMinorGridlines: y.hasMinorGridlines,
+			CatReversed:          x.reversed,
+			ValReversed:          y.reversed,
+			ValFormatCode:        y.formatCode,
+			ValSourceLinked:      y.sourceLinked,
+			CatTitle:             x.title,
+			ValTitle:             y.title,
+			CatAxisPos:           x.axisPos,
+		})
+	}
+	return out
+}
@@ -76,3 +76,86 @@ func TestParseInfoMixedChart(t *testing.T) {
 		t.Fatalf("expected series count 2, got %d", info.SeriesCount)
 	}
 }
+
+func TestParseInfoNamesRadarBubbleSurface(t *testing.T) {
+	for _, tc := range []struct {
+		plot       string
+		chartType  string
+		seriesSeen int
+	}{
+		{plot: "radarChart", chartType: "radar", seriesSeen: 2},
+		{plot: "stockChart", chartType: "stock", seriesSeen: 2},
+		{plot: "bubbleChart", chartType: "bubble"},
+		{plot: "surfaceChart", chartType: "surface"},
+		{plot: "surface3DChart", chartType: "surface"},
+		{plot: "bar3DChart", chartType: "other"},
+	} {
+		xml := `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart><c:plotArea><c:` + tc.plot + `><c:ser></c:ser><c:ser></c:ser></c:` + tc.plot + `></c:plotArea></c:chart></c:chartSpace>`
+		info, err := ParseInfo(strings.NewReader(xml))
+		if err != nil {
+			t.Fatalf("%s: ParseInfo: %v", tc.plot, err)
+		}
+		if info.ChartType != tc.chartType || info.SeriesCount != tc.seriesSeen {
+			t.Fatalf("%s: got type %q with %d series, want %q with %d", tc.plot, info.ChartType, info.SeriesCount, tc.chartType, tc.seriesSeen)
+		}
+	}
+}
+
+func TestParseInfoAxisGroups(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart><c:ser></c:ser><c:axId val="10"/><c:axId val="20"/></c:barChart>
+      <c:lineChart><c:ser></c:ser><c:axId val="30"/><c:axId val="40"/></c:lineChart>
+      <c:catAx><c:axId val="10"/><c:title><c:tx><c:rich><a:p><a:r><a:t>Fiscal </a:t></a:r><a:r><a:t>quarter</a:t></a:r></a:p></c:rich></c:tx></c:title><c:axPos val="b"/><c:crossAx val="20"/></c:catAx>
+      <c:valAx><c:axId val="20"/><c:title><c:tx><c:strRef><c:f>Sheet1!$B$1</c:f><c:strCache><c:pt idx="0"><c:v>Revenue</c:v></c:pt></c:strCache></c:strRef></c:tx></c:title><c:numFmt formatCode="#,##0" sourceLinked="0"/><c:axPos val="l"/><c:crossAx val="10"/></c:valAx>
+      <c:catAx><c:axId val="30"/><c:delete val="1"/><c:axPos val="b"/><c:crossAx val="40"/></c:catAx>
+      <c:valAx><c:axId val="40"/><c:axPos val="r"/><c:crossAx val="30"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	info, err := ParseInfo(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if info.Title != "" {
+		t.Fatalf("axis titles must not become the chart title, got %q", info.Title)
+	}
+	if len(info.AxisGroups) != 2 {
+		t.Fatalf("expected 2 axis groups, got %#v", info.AxisGroups)
+	}
+	primary := info.AxisGroups[0]
+	if primary.CatTitle != "Fiscal quarter" || primary.ValTitle != "Revenue" || primary.ValFormatCode != "#,##0" || primary.ValAxisPos != "l" || primary.CatAxisPos != "b" {
+		t.Fatalf("unexpected primary group: %#v", primary)
+	}
+	if roles := AxisGroupRoles(info.Plots, info.AxisGroups); roles[0] != "primary" || roles[1] != "secondary" {
+		t.Fatalf("unexpected roles %v for plots %#v", roles, info.Plots)
+	}
+}
+
+func TestParseInfoScatterAxisGroup(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">
+  <c:chart>
+    <c:title><c:tx><c:rich><a:p><a:r><a:t>Spread</a:t></a:r></a:p></c:rich></c:tx></c:title>
+    <c:plotArea>
+      <c:scatterChart><c:ser></c:ser><c:axId val="1"/><c:axId val="2"/></c:scatterChart>
+      <c:valAx><c:axId val="2"/><c:title><c:tx><c:rich><a:p><a:r><a:t>Y</a:t></a:r></a:p></c:rich></c:tx></c:title><c:axPos val="l"/><c:crossAx val="1"/></c:valAx>
+      <c:valAx><c:axId val="1"/><c:title><c:tx><c:rich><a:p><a:r><a:t>X</a:t></a:r></a:p></c:rich></c:tx></c:title><c:axPos val="b"/><c:crossAx val="2"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	info, err := ParseInfo(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if info.Title != "Spread" {
+		t.Fatalf("unexpected title %q", info.Title)
+	}
+	if len(info.AxisGroups) != 1 || info.AxisGroups[0].CatTitle != "X" || info.AxisGroups[0].ValTitle != "Y" || info.AxisGroups[0].ValAxisPos != "l" {
+		t.Fatalf("unexpected scatter axis group: %#v", info.AxisGroups)
+	}
+}
@@ -0,0 +1,91 @@
+package chartxml
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strconv"
+)
+
+// legendTracker collects the legendEntry elements of c:legend that carry
+// c:delete val="1". On series charts an entry's idx is the series index;
+// on varyColors charts such as pie it is the point index.
+type legendTracker struct {
+	legendDepth int
+	entryDepth  int
+	idx         int
+	hasIdx      bool
+	deleted     bool
+	hidden      map[int]bool
+}
+
+func (l *legendTracker) start(tok xml.StartElement) {
+	switch {
+	case tok.Name.Local == "legend":
+		l.legendDepth++
+		return
+	case l.legendDepth == 0:
+		return
+	case tok.Name.Local == "legendEntry" && l.entryDepth == 0:
+		l.entryDepth = 1
+		l.hasIdx, l.deleted = false, false
+		return
+	case l.entryDepth == 0:
+		return
+	}
+
+	l.entryDepth++
+	if l.entryDepth != 2 {
+		return
+	}
+	switch tok.Name.Local {
+	case "idx":
+		if idx, err := strconv.Atoi(attrVal(tok)); err == nil {
+			l.idx, l.hasIdx = idx, true
+		}
+	case "delete":
+		l.deleted = isTrueVal(attrVal(tok))
+	}
+}
+
+func (l *legendTracker) end(name string) {
+	switch {
+	case l.entryDepth > 1:
+		l.entryDepth--
+	case l.entryDepth == 1:
+		l.entryDepth = 0
+		if l.hasIdx && l.deleted {
+			if l.hidden == nil {
+				l.hidden = make(map[int]bool)
+			}
+			l.hidden[l.idx] = true
+		}
+	case name == "legend" && l.legendDepth > 0:
+		l.legendDepth--
+	}
+}
+
+// isTrueVal reads an xsd:boolean val attribute. An empty value is the
+// CT_Boolean default, which is true.
+func isTrueVal(val string) bool {
+	return val == "" || val == "1" || val == "true"
+}
+
+// valAttr matches a val attribute with its quoted value.
+var valAttr = regexp.MustCompile(`val\s*=\s*("[^"]*"|'[^']*')`)
+
+// lineStart widens a removal starting at offset to take the indentation and
+// line break before it, so dropping an element leaves no blank line.
+func lineStart(data []byte, offset int64) int64 {
+	i := offset
+	for i > 0 && (data[i-1] == ' ' || data[i-1] == '\t') {
+		i--
+	}
+	if i > 0 && data[i-1] == '\n' {
+		i--
+		if i > 0 && data[i-1] == '\r' {
+			i--
+		}
+		return i
+	}
+	return offset
+}
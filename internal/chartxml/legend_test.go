@@ -0,0 +1,46 @@
+package chartxml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const legendChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser><c:idx val="0"/><c:order val="0"/></c:ser>
+        <c:ser><c:idx val="1"/><c:order val="1"/></c:ser>
+        <c:ser><c:idx val="2"/><c:order val="2"/></c:ser>
+      </c:barChart>
+    </c:plotArea>
+    <c:legend>
+      <c:legendPos val="r"/>
+      <c:legendEntry><c:idx val='1'/><c:delete val="1"/></c:legendEntry>
+      <c:legendEntry><c:idx val="2"/><c:delete val="0"/><c:txPr/></c:legendEntry>
+    </c:legend>
+  </c:chart>
+</c:chartSpace>`
+
+func TestParseInfoHiddenLegendEntries(t *testing.T) {
+	info, err := ParseInfo(strings.NewReader(legendChart))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if !reflect.DeepEqual(info.HiddenLegendEntries, map[int]bool{1: true}) {
+		t.Fatalf("unexpected hidden entries: %v", info.HiddenLegendEntries)
+	}
+	if info.SeriesCount != 3 {
+		t.Fatalf("series idx must not be read as legend entries, got %d series", info.SeriesCount)
+	}
+
+	info, err = ParseInfo(strings.NewReader(strings.Replace(legendChart, `<c:delete val="1"/>`, `<c:delete/>`, 1)))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if !info.HiddenLegendEntries[1] {
+		t.Fatalf("delete without val defaults to true, got %v", info.HiddenLegendEntries)
+	}
+}
@@ -0,0 +1,154 @@
+package chartxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Lint rules. Each names a structural assumption the write path makes about
+// chart XML.
+const (
+	LintNoSeries         = "NO_SERIES"
+	LintPlotUnrecognized = "PLOT_UNRECOGNIZED"
+	LintSeriesIDMissing  = "SERIES_ID_MISSING"
+	LintRefCount         = "REF_COUNT"
+	LintFormulaMissing   = "FORMULA_MISSING"
+	LintPtCountMissing   = "PTCOUNT_MISSING"
+)
+
+// LintFinding is one violated assumption. Pointer is an element path rooted
+// at plotArea, e.g. "plotArea/barChart/ser[2]/val"; series and repeated plot
+// elements carry a 1-based position.
+type LintFinding struct {
+	Rule    string
+	Pointer string
+	Message string
+}
+
+type lintNode struct {
+	XMLName  xml.Name
+	Children []lintNode `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// Lint runs a cheap structural check over a chart part. It only fails when
+// the XML cannot be decoded; everything else is reported as a finding.
+func Lint(data []byte) ([]LintFinding, error) {
+	var root lintNode
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		return nil, fmt.Errorf("parse chart xml: %w", err)
+	}
+
+	var findings []LintFinding
+	plotArea := root.child("chart").child("plotArea")
+	if plotArea == nil {
+		return append(findings, LintFinding{Rule: LintNoSeries, Pointer: "plotArea", Message: "chart has no plotArea"}), nil
+	}
+
+	seriesTotal := 0
+	plotSeen := make(map[string]int)
+	for _, plot := range plotArea.Children {
+		name := plot.XMLName.Local
+		if !strings.HasSuffix(name, "Chart") {
+			continue
+		}
+		plotSeen[name]++
+		pointer := "plotArea/" + name
+		if plotSeen[name] > 1 {
+			pointer += "[" + strconv.Itoa(plotSeen[name]) + "]"
+		}
+		if isOtherChart(name) {
+			findings = append(findings, LintFinding{Rule: LintPlotUnrecognized, Pointer: pointer, Message: "plot type " + name + " is not recognized"})
+		}
+
+		seriesPos := 0
+		for i := range plot.Children {
+			ser := &plot.Children[i]
+			if ser.XMLName.Local != "ser" {
+				continue
+			}
+			seriesPos++
+			seriesTotal++
+			findings = append(findings, lintSeries(ser, pointer+"/ser["+strconv.Itoa(seriesPos)+"]")...)
+		}
+	}
+
+	if seriesTotal == 0 {
+		findings = append(findings, LintFinding{Rule: LintNoSeries, Pointer: "plotArea", Message: "plotArea has no series"})
+	}
+	return findings, nil
+}
+
+func lintSeries(ser *lintNode, pointer string) []LintFinding {
+	var findings []LintFinding
+	for _, name := range []string{"idx", "order"} {
+		if ser.child(name) == nil {
+			findings = append(findings, LintFinding{Rule: LintSeriesIDMissing, Pointer: pointer + "/" + name, Message: "series has no " + name})
+		}
+	}
+
+	if tx := ser.child("tx"); tx != nil {
+		for i := range tx.Children {
+			if isLintRef(tx.Children[i].XMLName.Local) {
+				findings = append(findings, lintRef(&tx.Children[i], pointer+"/tx/"+tx.Children[i].XMLName.Local)...)
+			}
+		}
+	}
+
+	for _, name := range []string{"cat", "val", "xVal", "yVal"} {
+		data := ser.child(name)
+		if data == nil {
+			continue
+		}
+		dataPointer := pointer + "/" + name
+		var refs []*lintNode
+		for i := range data.Children {
+			if isLintRef(data.Children[i].XMLName.Local) {
+				refs = append(refs, &data.Children[i])
+			}
+		}
+		if len(refs) != 1 {
+			findings = append(findings, LintFinding{Rule: LintRefCount, Pointer: dataPointer, Message: fmt.Sprintf("%s has %d ref children, want 1", name, len(refs))})
+		}
+		for _, ref := range refs {
+			findings = append(findings, lintRef(ref, dataPointer+"/"+ref.XMLName.Local)...)
+		}
+	}
+	return findings
+}
+
+func lintRef(ref *lintNode, pointer string) []LintFinding {
+	var findings []LintFinding
+	if f := ref.child("f"); f == nil || strings.TrimSpace(f.Text) == "" {
+		findings = append(findings, LintFinding{Rule: LintFormulaMissing, Pointer: pointer + "/f", Message: ref.XMLName.Local + " has no formula"})
+	}
+	for i := range ref.Children {
+		cache := &ref.Children[i]
+		if !strings.HasSuffix(cache.XMLName.Local, "Cache") {
+			continue
+		}
+		if cache.child("ptCount") == nil {
+			findings = append(findings, LintFinding{Rule: LintPtCountMissing, Pointer: pointer + "/" + cache.XMLName.Local, Message: cache.XMLName.Local + " has no ptCount"})
+		}
+	}
+	return findings
+}
+
+func (n *lintNode) child(local string) *lintNode {
+	if n == nil {
+		return nil
+	}
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == local {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+func isLintRef(name string) bool {
+	return name == "numRef" || name == "strRef" || name == "multiLvlStrRef"
+}
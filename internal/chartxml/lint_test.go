@@ -0,0 +1,60 @@
+package chartxml
+
+import "testing"
+
+func TestLintCleanChart(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:idx val="0"/><c:order val="0"/>
+          <c:tx><c:strRef><c:f>Sheet1!$B$1</c:f></c:strRef></c:tx>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/></c:strCache></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:ptCount val="2"/></c:numCache></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+      <c:lineChart>
+        <c:ser>
+          <c:idx val="1"/><c:order val="1"/>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:lineChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	findings, err := Lint([]byte(xml))
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Fatalf("expected no findings, got %#v", findings)
+	}
+}
+
+func TestLintRepeatedPlotPointer(t *testing.T) {
+	xml := `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart><c:ser><c:idx val="0"/><c:order val="0"/></c:ser></c:barChart>
+      <c:barChart><c:ser><c:idx val="1"/><c:order val="1"/><c:val><c:numRef/></c:val></c:ser></c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	findings, err := Lint([]byte(xml))
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Rule != LintFormulaMissing || findings[0].Pointer != "plotArea/barChart[2]/ser[1]/val/numRef/f" {
+		t.Fatalf("unexpected findings: %#v", findings)
+	}
+}
+
+func TestLintMalformedXML(t *testing.T) {
+	if _, err := Lint([]byte("<c:chartSpace><c:chart>")); err == nil {
+		t.Fatalf("expected malformed chart XML to fail")
+	}
+}
@@ -2,14 +2,22 @@ package chartxml
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
+
+	"why-pptx/internal/xmlcancel"
 )
 
 type MixedSeries struct {
-	Index     int
+	// Index is the series index across the chart, by c:order as in
+	// ParsedChart, and PlotIndex the series' position within its plot.
+	Index int
+	// Idx is the val of the series' c:idx, or Index when it has none.
+	Idx       int
 	PlotType  string
 	PlotIndex int
 	Axis      string
@@ -22,6 +30,22 @@ type AxisGroup struct {
 	ValAxisPos           string
 	ValHasMajorGridlines bool
 	ValHasMinorGridlines bool
+	// CatReversed and ValReversed are set when the axis scaling has
+	// orientation maxMin: categories run last to first, or values top down.
+	CatReversed bool
+	ValReversed bool
+	// ValFormatCode and ValSourceLinked come from the valAx numFmt; both
+	// are zero when the axis has none.
+	ValFormatCode   string
+	ValSourceLinked bool
+	// CatFormatCode is the catAx or dateAx numFmt formatCode, empty when
+	// the axis has none.
+	CatFormatCode string
+	// CatTitle and ValTitle are the axis titles, empty when an axis has
+	// none. CatAxisPos is the category axis c:axPos.
+	CatTitle   string
+	ValTitle   string
+	CatAxisPos string
 }
 
 type MixedPlot struct {
@@ -36,7 +60,15 @@ type MixedChart struct {
 	AxisGroups []AxisGroup
 }
 
+// ErrMixedChartSyntax is wrapped, with the decoder's error, by the error
+// ParseMixed returns for chart XML that does not parse.
+var ErrMixedChartSyntax = errors.New("parse mixed chart")
+
 func ParseMixed(r io.Reader) (*MixedChart, error) {
+	return ParseMixedWithCancel(r, nil)
+}
+
+func ParseMixedWithCancel(r io.Reader, cancel *xmlcancel.Flag) (*MixedChart, error) {
 	decoder := xml.NewDecoder(r)
 	out := &MixedChart{}
 
@@ -46,13 +78,13 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 	barDepth := 0
 	lineDepth := 0
 
-	axisDepth := 0
-	axisKind := ""
-	currentAxis := axisInfo{}
-	axes := make([]axisInfo, 0)
+	var axes axisTracker
 
 	seriesIndex := -1
+	seriesOrder := map[int]int{}
+	hasIdx := map[int]bool{}
 	serDepth := 0
+	serChildDepth := 0
 	currentSeries := -1
 	catDepth := 0
 	valDepth := 0
@@ -63,16 +95,33 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 	var buf strings.Builder
 
 	for {
+		if err := cancel.Err(); err != nil {
+			return nil, err
+		}
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("parse mixed chart: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrMixedChartSyntax, err)
 		}
 
 		switch tok := token.(type) {
 		case xml.StartElement:
+			if serDepth > 0 {
+				serChildDepth++
+				if serChildDepth == 1 && tok.Name.Local == "idx" && currentSeries >= 0 {
+					if idx, ok := idxVal(tok); ok {
+						out.Series[currentSeries].Idx = idx
+						hasIdx[currentSeries] = true
+					}
+				}
+				if serChildDepth == 1 && tok.Name.Local == "order" && currentSeries >= 0 {
+					if order, ok := idxVal(tok); ok {
+						seriesOrder[currentSeries] = order
+					}
+				}
+			}
 			switch tok.Name.Local {
 			case "barChart":
 				barDepth++
@@ -88,56 +137,13 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 					currentPlot = len(plots) - 1
 					plotTypes["line"] = struct{}{}
 				}
-			case "catAx":
-				axisDepth++
-				if axisDepth == 1 {
-					axisKind = "cat"
-					currentAxis = axisInfo{kind: "cat"}
-				}
-			case "valAx":
-				axisDepth++
-				if axisDepth == 1 {
-					axisKind = "val"
-					currentAxis = axisInfo{kind: "val"}
-				}
 			default:
 				if isUnsupportedPlot(tok.Name.Local) {
 					return nil, fmt.Errorf("unsupported plot type %q", tok.Name.Local)
 				}
 			}
 
-			if axisDepth > 0 {
-				switch tok.Name.Local {
-				case "axId":
-					for _, attr := range tok.Attr {
-						if attr.Name.Local == "val" && currentAxis.id == "" {
-							currentAxis.id = attr.Value
-						}
-					}
-				case "crossAx":
-					for _, attr := range tok.Attr {
-						if attr.Name.Local == "val" {
-							currentAxis.cross = attr.Value
-						}
-					}
-				case "axisPos":
-					if axisKind == "val" {
-						for _, attr := range tok.Attr {
-							if attr.Name.Local == "val" {
-								currentAxis.axisPos = attr.Value
-							}
-						}
-					}
-				case "majorGridlines":
-					if axisKind == "val" {
-						currentAxis.hasMajorGridlines = true
-					}
-				case "minorGridlines":
-					if axisKind == "val" {
-						currentAxis.hasMinorGridlines = true
-					}
-				}
-			}
+			axes.start(tok)
 
 			if currentPlot >= 0 {
 				switch tok.Name.Local {
@@ -162,6 +168,7 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 						plots[currentPlot].seriesCount++
 						out.Series = append(out.Series, MixedSeries{
 							Index:     seriesIndex,
+							Idx:       seriesIndex,
 							PlotType:  plots[currentPlot].plotType,
 							PlotIndex: plotSeriesIndex,
 						})
@@ -181,16 +188,18 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 					if serDepth > 0 {
 						txDepth++
 					}
+				case "ptCount":
+					if serDepth > 0 && currentSeries >= 0 {
+						formulas := out.Series[currentSeries].Formulas
+						if n := len(formulas); n > 0 && formulas[n-1].Kind == mixedRangeKind(catDepth, valDepth, txDepth) {
+							if count, err := strconv.Atoi(attrVal(tok)); err == nil {
+								formulas[n-1].PtCount = count
+							}
+						}
+					}
 				case "f":
 					if serDepth > 0 {
-						kind := ""
-						if catDepth > 0 {
-							kind = KindCategories
-						} else if valDepth > 0 {
-							kind = KindValues
-						} else if txDepth > 0 {
-							kind = KindSeriesName
-						}
+						kind := mixedRangeKind(catDepth, valDepth, txDepth)
 						if kind != "" && currentSeries >= 0 {
 							inFormula = true
 							formulaKind = kind
@@ -200,6 +209,14 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 				}
 			}
 		case xml.EndElement:
+			axes.end(tok.Name.Local)
+			if serDepth > 0 {
+				if tok.Name.Local == "ser" && serDepth == 1 {
+					serChildDepth = 0
+				} else {
+					serChildDepth--
+				}
+			}
 			switch tok.Name.Local {
 			case "barChart":
 				if barDepth > 0 {
@@ -215,17 +232,6 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 				if barDepth == 0 && lineDepth == 0 {
 					currentPlot = -1
 				}
-			case "catAx", "valAx":
-				if axisDepth > 0 {
-					axisDepth--
-				}
-				if axisDepth == 0 {
-					if currentAxis.id != "" {
-						axes = append(axes, currentAxis)
-					}
-					axisKind = ""
-					currentAxis = axisInfo{}
-				}
 			case "ser":
 				if serDepth > 0 {
 					serDepth--
@@ -267,6 +273,7 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 				}
 			}
 		case xml.CharData:
+			axes.text(tok)
 			if inFormula {
 				buf.Write([]byte(tok))
 			}
@@ -282,7 +289,8 @@ func ParseMixed(r io.Reader) (*MixedChart, error) {
 
 	assignMixedAxes(out.Series, plots)
 	out.Plots = plotsToMixed(plots)
-	out.AxisGroups = buildAxisGroups(axes)
+	reorderMixedSeries(out, seriesRanks(len(out.Series), seriesOrder), hasIdx)
+	out.AxisGroups = buildAxisGroups(axes.axes)
 
 	return out, nil
 }
@@ -301,6 +309,10 @@ type axisInfo struct {
 	axisPos           string
 	hasMajorGridlines bool
 	hasMinorGridlines bool
+	reversed          bool
+	formatCode        string
+	sourceLinked      bool
+	title             string
 }
 
 func newPlotState(plotType string) *plotState {
@@ -315,18 +327,7 @@ func assignMixedAxes(series []MixedSeries, plots []*plotState) {
 		return
 	}
 
-	primaryIDs := plots[0].axisIDs
-	plotAxis := make([]string, len(plots))
-	for i, plot := range plots {
-		plotAxis[i] = "primary"
-		if len(primaryIDs) == 0 || len(plot.axisIDs) == 0 {
-			continue
-		}
-		if !equalStringSets(primaryIDs, plot.axisIDs) {
-			plotAxis[i] = "secondary"
-		}
-	}
-
+	plotAxis := plotAxisRoles(plots)
 	for i := range plots {
 		for _, idx := range plots[i].seriesIndices {
 			if idx >= 0 && idx < len(series) {
@@ -409,6 +410,14 @@ func buildAxisGroups(axes []axisInfo) []AxisGroup {
 			ValAxisPos:           val.axisPos,
 			ValHasMajorGridlines: val.hasMajorGridlines,
 			ValHasMinorGridlines: val.hasMinorGridlines,
+			CatReversed:          cat.reversed,
+			ValReversed:          val.reversed,
+			ValFormatCode:        val.formatCode,
+			ValSourceLinked:      val.sourceLinked,
+			CatFormatCode:        cat.formatCode,
+			CatTitle:             cat.title,
+			ValTitle:             val.title,
+			CatAxisPos:           cat.axisPos,
 		})
 	}
 
@@ -430,3 +439,17 @@ func isUnsupportedPlot(name string) bool {
 	}
 	return false
 }
+
+// mixedRangeKind is the formula kind of the cat, val, or tx element the
+// parser is in, or "" outside them.
+func mixedRangeKind(catDepth, valDepth, txDepth int) string {
+	switch {
+	case catDepth > 0:
+		return KindCategories
+	case valDepth > 0:
+		return KindValues
+	case txDepth > 0:
+		return KindSeriesName
+	}
+	return ""
+}
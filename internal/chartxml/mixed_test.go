@@ -48,6 +48,89 @@ func TestParseMixedBarLine(t *testing.T) {
 	}
 }
 
+func TestParseMixedSeriesIdx(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:idx val="1"/>
+          <c:dPt><c:idx val="0"/></c:dPt>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:barChart>
+      <c:lineChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:lineChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	mixed, err := ParseMixed(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseMixed: %v", err)
+	}
+	if len(mixed.Series) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(mixed.Series))
+	}
+	if mixed.Series[0].Idx != 1 || mixed.Series[1].Idx != 1 {
+		t.Fatalf("expected idx 1 and the default 1, got %+v", mixed.Series)
+	}
+}
+
+func TestParseMixedSeriesOrder(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:idx val="0"/><c:order val="1"/>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:barChart>
+      <c:lineChart>
+        <c:ser>
+          <c:order val="0"/>
+          <c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:lineChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	mixed, err := ParseMixed(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseMixed: %v", err)
+	}
+	if len(mixed.Series) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(mixed.Series))
+	}
+	line, bar := mixed.Series[0], mixed.Series[1]
+	if line.PlotType != "line" || line.Index != 0 || line.Idx != 0 || line.PlotIndex != 0 || line.Formulas[0].SeriesIndex != 0 {
+		t.Fatalf("expected the line series first, got %+v", mixed.Series)
+	}
+	if bar.PlotType != "bar" || bar.Index != 1 || bar.Idx != 0 || bar.PlotIndex != 0 || bar.Formulas[0].SeriesIndex != 1 {
+		t.Fatalf("expected the bar series second, got %+v", mixed.Series)
+	}
+	if mixed.Plots[0].SeriesIndices[0] != 1 || mixed.Plots[1].SeriesIndices[0] != 0 {
+		t.Fatalf("unexpected plot series: %+v", mixed.Plots)
+	}
+}
+
 func TestParseMixedSecondaryAxis(t *testing.T) {
 	xml := `<?xml version="1.0" encoding="UTF-8"?>
 <c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
@@ -127,6 +210,58 @@ func TestParseMixedAxisGroups(t *testing.T) {
 	}
 }
 
+func TestParseMixedAxisOrientation(t *testing.T) {
+	// The secondary pair comes first in the plot area; roles follow the
+	// plots' axis IDs, not axis order. A dateAx counts as a category axis.
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser><c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat></c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:barChart>
+      <c:lineChart>
+        <c:ser><c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat></c:ser>
+        <c:axId val="3"/>
+        <c:axId val="4"/>
+      </c:lineChart>
+      <c:dateAx><c:axId val="3"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:crossAx val="4"/></c:dateAx>
+      <c:valAx><c:axId val="4"/><c:scaling><c:orientation val="maxMin"/></c:scaling><c:crossAx val="3"/><c:axPos val="r"/></c:valAx>
+      <c:catAx><c:axId val="1"/><c:scaling><c:orientation val="maxMin"/></c:scaling><c:crossAx val="2"/></c:catAx>
+      <c:valAx><c:axId val="2"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:crossAx val="1"/><c:axPos val="l"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+	mixed, err := ParseMixed(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseMixed: %v", err)
+	}
+	if len(mixed.AxisGroups) != 2 {
+		t.Fatalf("expected 2 axis groups, got %#v", mixed.AxisGroups)
+	}
+	got := map[string][2]bool{}
+	for _, group := range mixed.AxisGroups {
+		got[group.CatAxID] = [2]bool{group.CatReversed, group.ValReversed}
+	}
+	if got["1"] != [2]bool{true, false} || got["3"] != [2]bool{false, true} {
+		t.Fatalf("unexpected orientation: %#v", mixed.AxisGroups)
+	}
+
+	roles := AxisGroupRoles(mixed.Plots, mixed.AxisGroups)
+	for i, group := range mixed.AxisGroups {
+		want := "secondary"
+		if group.CatAxID == "1" {
+			want = "primary"
+		}
+		if roles[i] != want {
+			t.Fatalf("group %s: role %q, want %q", group.CatAxID, roles[i], want)
+		}
+	}
+}
+
 func TestParseMixedStackedUnsupported(t *testing.T) {
 	xml := `<?xml version="1.0" encoding="UTF-8"?>
 <c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
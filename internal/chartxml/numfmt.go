@@ -0,0 +1,116 @@
+package chartxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// valAxChildren lists the children of CT_ValAx in schema order.
+var valAxChildren = []string{
+	"axId", "scaling", "delete", "axPos", "majorGridlines", "minorGridlines", "title", "numFmt",
+	"majorTickMark", "minorTickMark", "tickLblPos", "spPr", "txPr", "crossAx", "crosses", "crossesAt",
+	"crossBetween", "majorUnit", "minorUnit", "dispUnits", "extLst",
+}
+
+// SetValueAxisNumFmt sets the tick label format of the valAx whose axId is
+// valAxID to formatCode with sourceLinked="0", so the labels stop following
+// the workbook cells' format. An existing numFmt is replaced; otherwise one
+// is inserted at its schema position, after title and before majorTickMark.
+// Everything else is copied byte for byte.
+func SetValueAxisNumFmt(chartXML []byte, valAxID, formatCode string) ([]byte, error) {
+	axis, err := findValueAxis(chartXML, valAxID)
+	if err != nil {
+		return nil, err
+	}
+	if axis == nil {
+		return nil, fmt.Errorf("value axis %q not found", valAxID)
+	}
+
+	prefix, _ := plotPrefix(chartXML, axis.start)
+	var escaped bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(formatCode)); err != nil {
+		return nil, err
+	}
+	element := []byte(fmt.Sprintf(`<%snumFmt formatCode="%s" sourceLinked="0"/>`, prefix, escaped.String()))
+
+	rank := indexOf(valAxChildren, "numFmt")
+	var before, after *convertNode
+	for _, child := range axis.children {
+		if child.name == "numFmt" {
+			return applyConvertSplices(chartXML, []convertSplice{{start: child.start, end: child.end, with: element}}), nil
+		}
+		switch childRank := indexOf(valAxChildren, child.name); {
+		case childRank >= 0 && childRank < rank:
+			after = child
+		case childRank > rank && before == nil:
+			before = child
+		}
+	}
+
+	var splice convertSplice
+	switch {
+	case after != nil:
+		indent := chartXML[lineStart(chartXML, after.start):after.start]
+		splice = convertSplice{start: after.end, end: after.end, with: append(append([]byte(nil), indent...), element...)}
+	case before != nil:
+		indent := chartXML[lineStart(chartXML, before.start):before.start]
+		splice = convertSplice{start: before.start, end: before.start, with: append(element, indent...)}
+	default:
+		if bytes.HasSuffix(chartXML[:axis.end], []byte("/>")) {
+			return nil, fmt.Errorf("value axis %q is empty", valAxID)
+		}
+		closeStart := closeTagName(chartXML, axis) - 2
+		splice = convertSplice{start: closeStart, end: closeStart, with: element}
+	}
+	return applyConvertSplices(chartXML, []convertSplice{splice}), nil
+}
+
+// findValueAxis returns the valAx under plotArea whose axId is id, with its
+// direct children, or nil when there is none.
+func findValueAxis(chartXML []byte, id string) (*convertNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(chartXML))
+	var stack []*convertNode
+	var axis *convertNode
+	axisDepth := -1
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse chart xml: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			node := &convertNode{name: tok.Name.Local, start: offset, val: attrVal(tok)}
+			depth := len(stack)
+			if axis == nil && tok.Name.Local == "valAx" && depth > 0 && stack[depth-1].name == "plotArea" {
+				axis, axisDepth = node, depth
+			} else if axis != nil && depth == axisDepth+1 {
+				axis.children = append(axis.children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			node.end = decoder.InputOffset()
+			if node != axis {
+				continue
+			}
+			for _, child := range axis.children {
+				if child.name == "axId" && child.val == id {
+					return axis, nil
+				}
+			}
+			axis, axisDepth = nil, -1
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package chartxml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const numFmtChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart><c:ser/><c:axId val="1"/><c:axId val="2"/></c:barChart>
+      <c:catAx><c:axId val="1"/><c:crossAx val="2"/></c:catAx>
+      <c:valAx>
+        <c:axId val="2"/>
+        <c:scaling><c:orientation val="minMax"/></c:scaling>
+        <c:axPos val="l"/>
+        <c:majorGridlines/>
+        <c:majorTickMark val="out"/>
+        <c:crossAx val="1"/>
+      </c:valAx>
+      <c:catAx><c:axId val="3"/><c:delete val="1"/><c:crossAx val="4"/></c:catAx>
+      <c:valAx><c:axId val="4"/><c:numFmt formatCode="General" sourceLinked="1"/><c:crossAx val="3"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+func TestSetValueAxisNumFmtInsertsInSchemaOrder(t *testing.T) {
+	out, err := SetValueAxisNumFmt([]byte(numFmtChart), "2", `#,##0,,"M"`)
+	if err != nil {
+		t.Fatalf("SetValueAxisNumFmt: %v", err)
+	}
+	want := "<c:majorGridlines/>\n        <c:numFmt formatCode=\"#,##0,,&#34;M&#34;\" sourceLinked=\"0\"/>\n        <c:majorTickMark val=\"out\"/>"
+	if !strings.Contains(string(out), want) {
+		t.Fatalf("numFmt not inserted after majorGridlines:\n%s", out)
+	}
+	if strings.Replace(string(out), "\n        <c:numFmt formatCode=\"#,##0,,&#34;M&#34;\" sourceLinked=\"0\"/>", "", 1) != numFmtChart {
+		t.Fatalf("other bytes changed:\n%s", out)
+	}
+
+	parsed, err := Parse(strings.NewReader(string(out)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for _, group := range parsed.AxisGroups {
+		if group.ValAxID == "2" && (group.ValFormatCode != `#,##0,,"M"` || group.ValSourceLinked) {
+			t.Fatalf("unexpected parsed format: %#v", group)
+		}
+	}
+}
+
+func TestSetValueAxisNumFmtReplacesExisting(t *testing.T) {
+	before, err := Parse(strings.NewReader(numFmtChart))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var linked bool
+	for _, group := range before.AxisGroups {
+		if group.ValAxID == "4" {
+			linked = group.ValSourceLinked && group.ValFormatCode == "General"
+		}
+	}
+	if !linked {
+		t.Fatalf("expected the linked General format on axis 4: %#v", before.AxisGroups)
+	}
+
+	out, err := SetValueAxisNumFmt([]byte(numFmtChart), "4", "0.0%")
+	if err != nil {
+		t.Fatalf("SetValueAxisNumFmt: %v", err)
+	}
+	want := `<c:valAx><c:axId val="4"/><c:numFmt formatCode="0.0%" sourceLinked="0"/><c:crossAx val="3"/></c:valAx>`
+	if !strings.Contains(string(out), want) || strings.Count(string(out), "numFmt") != 1 {
+		t.Fatalf("numFmt not replaced:\n%s", out)
+	}
+}
+
+func TestSetValueAxisNumFmtUnknownAxis(t *testing.T) {
+	if _, err := SetValueAxisNumFmt([]byte(numFmtChart), "1", "0"); err == nil || !strings.Contains(err.Error(), `value axis "1" not found`) {
+		t.Fatalf("a category axis id must not match, got %v", err)
+	}
+	if _, err := SetValueAxisNumFmt([]byte("<c:chartSpace"), "2", "0"); err == nil || errors.Is(err, ErrUnsupportedConversion) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+}
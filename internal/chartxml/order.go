@@ -0,0 +1,108 @@
+package chartxml
+
+import "sort"
+
+// seriesRanks returns the series index of each of count series positions:
+// its rank by c:order, where order maps a position to its c:order val. A
+// series without one, or that ties with another, keeps its place by
+// position. ranks is nil when every series is already at its position.
+func seriesRanks(count int, order map[int]int) []int {
+	if len(order) == 0 {
+		return nil
+	}
+	key := func(position int) int {
+		if val, ok := order[position]; ok {
+			return val
+		}
+		return position
+	}
+	positions := make([]int, count)
+	for i := range positions {
+		positions[i] = i
+	}
+	sort.SliceStable(positions, func(i, j int) bool {
+		return key(positions[i]) < key(positions[j])
+	})
+
+	ranks := make([]int, count)
+	moved := false
+	for rank, position := range positions {
+		ranks[position] = rank
+		if rank != position {
+			moved = true
+		}
+	}
+	if !moved {
+		return nil
+	}
+	return ranks
+}
+
+// reorderSeries renumbers the series of out from chart positions to ranks,
+// returned by seriesRanks, and records the positions in SeriesPositions.
+func (out *ParsedChart) reorderSeries(ranks []int) {
+	if ranks == nil {
+		return
+	}
+	rank := func(position int) int {
+		if position >= 0 && position < len(ranks) {
+			return ranks[position]
+		}
+		return position
+	}
+
+	for i := range out.Formulas {
+		out.Formulas[i].SeriesIndex = rank(out.Formulas[i].SeriesIndex)
+	}
+	sort.SliceStable(out.Formulas, func(i, j int) bool {
+		return out.Formulas[i].SeriesIndex < out.Formulas[j].SeriesIndex
+	})
+	for i := range out.Plots {
+		for j, position := range out.Plots[i].SeriesIndices {
+			out.Plots[i].SeriesIndices[j] = rank(position)
+		}
+	}
+	idx := make(map[int]int, len(out.SeriesIdx))
+	for position, val := range out.SeriesIdx {
+		idx[rank(position)] = val
+	}
+	out.SeriesIdx = idx
+	if out.SeriesAxes != nil {
+		axes := make(map[int]string, len(out.SeriesAxes))
+		for position, axis := range out.SeriesAxes {
+			axes[rank(position)] = axis
+		}
+		out.SeriesAxes = axes
+	}
+	out.SeriesPositions = make(map[int]int, len(ranks))
+	for position, index := range ranks {
+		out.SeriesPositions[index] = position
+	}
+}
+
+// reorderMixedSeries is reorderSeries for a MixedChart, whose series are
+// listed in index order afterwards. Idx follows the new Index of a series
+// without a c:idx, which hasIdx marks by position.
+func reorderMixedSeries(out *MixedChart, ranks []int, hasIdx map[int]bool) {
+	if ranks == nil {
+		return
+	}
+	for position := range out.Series {
+		series := &out.Series[position]
+		series.Index = ranks[position]
+		if !hasIdx[position] {
+			series.Idx = series.Index
+		}
+		for i := range series.Formulas {
+			series.Formulas[i].SeriesIndex = series.Index
+		}
+	}
+	sort.SliceStable(out.Series, func(i, j int) bool {
+		return out.Series[i].Index < out.Series[j].Index
+	})
+	for i := range out.Plots {
+		for j, position := range out.Plots[i].SeriesIndices {
+			out.Plots[i].SeriesIndices[j] = ranks[position]
+		}
+	}
+}
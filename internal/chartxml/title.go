@@ -0,0 +1,211 @@
+package chartxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const drawingMLNamespace = "http://schemas.openxmlformats.org/drawingml/2006/main"
+
+// ErrTitleNotEditable is wrapped by SetTitle errors for a title whose text
+// cannot be replaced without losing what it means.
+var ErrTitleNotEditable = errors.New("chart title is not editable")
+
+// SetTitle sets the text of the chart title (c:chart/c:title). A rich text
+// title keeps its first paragraph and that paragraph's first run, pPr and
+// rPr included: the run's a:t takes title, and the other runs, line breaks,
+// and paragraphs are dropped. A title without c:tx, as PowerPoint writes
+// for an automatic title, gets one; a chart without a title gets a minimal
+// c:title, and a set c:autoTitleDeleted is cleared so it shows. Everything
+// else is copied byte for byte.
+//
+// A title linked to a cell (c:tx/c:strRef) or holding a field (a:fld) is
+// rejected with an error wrapping ErrTitleNotEditable.
+func SetTitle(chartXML []byte, title string) ([]byte, error) {
+	root, drawingPrefix, err := parseElementTree(chartXML)
+	if err != nil {
+		return nil, err
+	}
+	chart := childElement(root, "chart")
+	if root == nil || root.name != "chartSpace" || chart == nil || isEmptyElement(chartXML, chart) {
+		return nil, fmt.Errorf("chart element not found")
+	}
+
+	var text bytes.Buffer
+	if err := xml.EscapeText(&text, []byte(title)); err != nil {
+		return nil, err
+	}
+	prefix, _ := plotPrefix(chartXML, chart.start)
+	declare := ""
+	if drawingPrefix == "" {
+		drawingPrefix = "a:"
+		declare = ` xmlns:a="` + drawingMLNamespace + `"`
+	}
+	run := fmt.Sprintf(`<%[1]sr><%[1]st>%[2]s</%[1]st></%[1]sr>`, drawingPrefix, text.String())
+	tx := fmt.Sprintf(`<%[1]stx><%[1]srich%[3]s><%[2]sbodyPr/><%[2]slstStyle/><%[2]sp>%[4]s</%[2]sp></%[1]srich></%[1]stx>`,
+		prefix, drawingPrefix, declare, run)
+
+	var splices []convertSplice
+	if deleted := childElement(chart, "autoTitleDeleted"); deleted != nil && isTrueVal(deleted.val) {
+		splices = append(splices, convertSplice{start: deleted.start, end: deleted.end, with: []byte(fmt.Sprintf(`<%sautoTitleDeleted val="0"/>`, prefix))})
+	}
+
+	titleNode := childElement(chart, "title")
+	switch {
+	case titleNode == nil:
+		element := fmt.Sprintf(`<%[1]stitle>%[2]s<%[1]soverlay val="0"/></%[1]stitle>`, prefix, tx)
+		splices = append(splices, insertFirstChild(chartXML, chart, []byte(element)))
+		return applyConvertSplices(chartXML, splices), nil
+	case isEmptyElement(chartXML, titleNode):
+		element := fmt.Sprintf(`<%[1]stitle>%[2]s<%[1]soverlay val="0"/></%[1]stitle>`, prefix, tx)
+		splices = append(splices, convertSplice{start: titleNode.start, end: titleNode.end, with: []byte(element)})
+		return applyConvertSplices(chartXML, splices), nil
+	}
+
+	txNode := childElement(titleNode, "tx")
+	if txNode == nil {
+		splices = append(splices, insertFirstChild(chartXML, titleNode, []byte(tx)))
+		return applyConvertSplices(chartXML, splices), nil
+	}
+	if childElement(txNode, "strRef") != nil {
+		return nil, fmt.Errorf("%w: the title is linked to a cell", ErrTitleNotEditable)
+	}
+	rich := childElement(txNode, "rich")
+	if rich == nil || isEmptyElement(chartXML, rich) {
+		return nil, fmt.Errorf("%w: the title has no rich text", ErrTitleNotEditable)
+	}
+
+	var paragraphs []*convertNode
+	for _, child := range rich.children {
+		if child.name != "p" {
+			continue
+		}
+		if childElement(child, "fld") != nil {
+			return nil, fmt.Errorf("%w: the title holds a field", ErrTitleNotEditable)
+		}
+		paragraphs = append(paragraphs, child)
+	}
+	if len(paragraphs) == 0 {
+		paragraph := fmt.Sprintf(`<%[1]sp>%[2]s</%[1]sp>`, drawingPrefix, run)
+		closeStart := closeTagName(chartXML, rich) - 2
+		splices = append(splices, convertSplice{start: closeStart, end: closeStart, with: []byte(paragraph)})
+		return applyConvertSplices(chartXML, splices), nil
+	}
+	for _, paragraph := range paragraphs[1:] {
+		splices = append(splices, convertSplice{start: lineStart(chartXML, paragraph.start), end: paragraph.end})
+	}
+
+	paragraph := paragraphs[0]
+	pPrefix, _ := plotPrefix(chartXML, paragraph.start)
+	run = fmt.Sprintf(`<%[1]sr><%[1]st>%[2]s</%[1]st></%[1]sr>`, pPrefix, text.String())
+	if isEmptyElement(chartXML, paragraph) {
+		splices = append(splices, convertSplice{start: paragraph.start, end: paragraph.end, with: []byte(fmt.Sprintf(`<%[1]sp>%[2]s</%[1]sp>`, pPrefix, run))})
+		return applyConvertSplices(chartXML, splices), nil
+	}
+	var first *convertNode
+	for _, child := range paragraph.children {
+		switch {
+		case child.name == "r" && first == nil:
+			first = child
+		case child.name == "r" || child.name == "br":
+			splices = append(splices, convertSplice{start: child.start, end: child.end})
+		}
+	}
+	if first == nil {
+		at := closeTagName(chartXML, paragraph) - 2
+		if end := childElement(paragraph, "endParaRPr"); end != nil {
+			at = end.start
+		}
+		splices = append(splices, convertSplice{start: at, end: at, with: []byte(run)})
+		return applyConvertSplices(chartXML, splices), nil
+	}
+
+	textNode := childElement(first, "t")
+	switch {
+	case textNode != nil:
+		_, raw := plotPrefix(chartXML, textNode.start)
+		splices = append(splices, convertSplice{start: textNode.start, end: textNode.end, with: []byte(fmt.Sprintf(`<%[1]s>%[2]s</%[1]s>`, raw, text.String()))})
+	case isEmptyElement(chartXML, first):
+		splices = append(splices, convertSplice{start: first.start, end: first.end, with: []byte(run)})
+	default:
+		closeStart := closeTagName(chartXML, first) - 2
+		splices = append(splices, convertSplice{start: closeStart, end: closeStart, with: []byte(fmt.Sprintf(`<%[1]st>%[2]s</%[1]st>`, pPrefix, text.String()))})
+	}
+	return applyConvertSplices(chartXML, splices), nil
+}
+
+// parseElementTree returns the root element of data with every descendant
+// and their offsets, and the prefix, colon included, the root binds to the
+// DrawingML namespace.
+func parseElementTree(data []byte) (*convertNode, string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root *convertNode
+	var stack []*convertNode
+	drawingPrefix := ""
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return root, drawingPrefix, nil
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("parse chart xml: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			node := &convertNode{name: tok.Name.Local, start: offset, val: attrVal(tok)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+				for _, attr := range tok.Attr {
+					if attr.Name.Space == "xmlns" && attr.Value == drawingMLNamespace {
+						drawingPrefix = attr.Name.Local + ":"
+					}
+				}
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			stack[len(stack)-1].end = decoder.InputOffset()
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// childElement returns the first child of node named name, or nil.
+func childElement(node *convertNode, name string) *convertNode {
+	if node == nil {
+		return nil
+	}
+	for _, child := range node.children {
+		if child.name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+func isEmptyElement(data []byte, node *convertNode) bool {
+	return bytes.HasSuffix(data[:node.end], []byte("/>"))
+}
+
+// insertFirstChild inserts element as the first child of parent, on its own
+// line when the first existing child is indented.
+func insertFirstChild(data []byte, parent *convertNode, element []byte) convertSplice {
+	if len(parent.children) == 0 {
+		closeStart := closeTagName(data, parent) - 2
+		return convertSplice{start: closeStart, end: closeStart, with: element}
+	}
+	first := parent.children[0]
+	indent := data[lineStart(data, first.start):first.start]
+	return convertSplice{start: first.start, end: first.start, with: append(append([]byte(nil), element...), indent...)}
+}
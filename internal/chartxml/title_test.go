@@ -0,0 +1,95 @@
+package chartxml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const titleChartHead = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">
+  <c:chart>
+`
+
+const titleChartTail = `    <c:plotArea><c:barChart><c:ser/></c:barChart></c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+func TestSetTitleKeepsRunProperties(t *testing.T) {
+	title := `    <c:title><c:tx><c:rich><a:bodyPr/><a:p><a:pPr><a:defRPr sz="1400"/></a:pPr><a:r><a:rPr lang="en-US" b="1"/><a:t>Old</a:t></a:r><a:br/><a:r><a:t>second</a:t></a:r><a:endParaRPr lang="en-US"/></a:p>
+    <a:p><a:r><a:t>more</a:t></a:r></a:p></c:rich></c:tx><c:overlay val="0"/></c:title>
+    <c:autoTitleDeleted val="0"/>
+`
+	out, err := SetTitle([]byte(titleChartHead+title+titleChartTail), "Revenue & Cost")
+	if err != nil {
+		t.Fatalf("SetTitle: %v", err)
+	}
+	want := `    <c:title><c:tx><c:rich><a:bodyPr/><a:p><a:pPr><a:defRPr sz="1400"/></a:pPr><a:r><a:rPr lang="en-US" b="1"/><a:t>Revenue &amp; Cost</a:t></a:r><a:endParaRPr lang="en-US"/></a:p></c:rich></c:tx><c:overlay val="0"/></c:title>
+    <c:autoTitleDeleted val="0"/>
+`
+	if string(out) != titleChartHead+want+titleChartTail {
+		t.Fatalf("unexpected chart:\n%s", out)
+	}
+	info, err := ParseInfo(strings.NewReader(string(out)))
+	if err != nil {
+		t.Fatalf("ParseInfo: %v", err)
+	}
+	if info.Title != "Revenue & Cost" {
+		t.Fatalf("unexpected title %q", info.Title)
+	}
+}
+
+func TestSetTitleCreatesTitle(t *testing.T) {
+	cases := map[string]struct {
+		body string
+		want string
+	}{
+		"no title": {
+			body: "    <c:autoTitleDeleted val=\"1\"/>\n",
+			want: "    <c:title><c:tx><c:rich><a:bodyPr/><a:lstStyle/><a:p><a:r><a:t>Sales</a:t></a:r></a:p></c:rich></c:tx><c:overlay val=\"0\"/></c:title>\n    <c:autoTitleDeleted val=\"0\"/>\n",
+		},
+		"automatic title": {
+			body: "    <c:title><c:overlay val=\"0\"/></c:title>\n",
+			want: "    <c:title><c:tx><c:rich><a:bodyPr/><a:lstStyle/><a:p><a:r><a:t>Sales</a:t></a:r></a:p></c:rich></c:tx><c:overlay val=\"0\"/></c:title>\n",
+		},
+		"run without text": {
+			body: "    <c:title><c:tx><c:rich><a:bodyPr/><a:p><a:endParaRPr lang=\"en-US\"/></a:p></c:rich></c:tx></c:title>\n",
+			want: "    <c:title><c:tx><c:rich><a:bodyPr/><a:p><a:r><a:t>Sales</a:t></a:r><a:endParaRPr lang=\"en-US\"/></a:p></c:rich></c:tx></c:title>\n",
+		},
+	}
+	for name, tc := range cases {
+		out, err := SetTitle([]byte(titleChartHead+tc.body+titleChartTail), "Sales")
+		if err != nil {
+			t.Fatalf("%s: SetTitle: %v", name, err)
+		}
+		if string(out) != titleChartHead+tc.want+titleChartTail {
+			t.Fatalf("%s: unexpected chart:\n%s", name, out)
+		}
+	}
+
+	// Without a DrawingML prefix on the root, the rich text declares one.
+	chart := `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart><c:plotArea/></c:chart></c:chartSpace>`
+	out, err := SetTitle([]byte(chart), "Sales")
+	if err != nil {
+		t.Fatalf("SetTitle: %v", err)
+	}
+	if !strings.Contains(string(out), `<c:rich xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><a:bodyPr/>`) {
+		t.Fatalf("expected a namespace declaration:\n%s", out)
+	}
+	if info, err := ParseInfo(strings.NewReader(string(out))); err != nil || info.Title != "Sales" {
+		t.Fatalf("ParseInfo: %v %#v", err, info)
+	}
+}
+
+func TestSetTitleRejectsLinkedTitles(t *testing.T) {
+	cases := map[string]string{
+		"cell":  `    <c:title><c:tx><c:strRef><c:f>Sheet1!$B$1</c:f></c:strRef></c:tx></c:title>` + "\n",
+		"field": `    <c:title><c:tx><c:rich><a:bodyPr/><a:p><a:fld id="{1}" type="cell"><a:t>Old</a:t></a:fld></a:p></c:rich></c:tx></c:title>` + "\n",
+	}
+	for name, body := range cases {
+		_, err := SetTitle([]byte(titleChartHead+body+titleChartTail), "Sales")
+		if !errors.Is(err, ErrTitleNotEditable) {
+			t.Fatalf("%s: expected ErrTitleNotEditable, got %v", name, err)
+		}
+	}
+}
@@ -1,9 +1,29 @@
 package ooxmlpkg
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrOpenFailed   = errors.New("ooxmlpkg: open failed")
 	ErrPartNotFound = errors.New("ooxmlpkg: part not found")
 	ErrSaveFailed   = errors.New("ooxmlpkg: save failed")
+	ErrPartTooLarge = errors.New("ooxmlpkg: part too large")
 )
+
+// CanceledError is returned by the Context saves when the context is done
+// before every part is written. Part is the part that was to be written
+// next, and Err the context's error.
+type CanceledError struct {
+	Part string
+	Err  error
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("save canceled before part %q: %v", e.Part, e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,151 @@
+package ooxmlpkg
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Limits bounds how much reading the entries of a zip may inflate. Zero or
+// less disables a limit.
+type Limits struct {
+	// MaxPartSize bounds the uncompressed size of a single entry.
+	MaxPartSize int64
+	// MaxTotalSize bounds the uncompressed size of all entries read, each
+	// counted once at the size its header declares. archive/zip fails an
+	// entry that inflates past its declared size, so the count holds even
+	// for lying headers.
+	MaxTotalSize int64
+}
+
+// PartTooLargeError is the error of a read that Limits refuses. It wraps
+// ErrPartTooLarge.
+type PartTooLargeError struct {
+	Part string
+	// Size is the size the entry declares, or, with Inflated, the bytes it
+	// had inflated to when it was cut off. With Total it is the size the
+	// entries read would have come to.
+	Size  int64
+	Limit int64
+	// Total is set when MaxTotalSize was exceeded, and Inflated when the
+	// entry inflated past MaxPartSize although its header claimed less.
+	Total    bool
+	Inflated bool
+}
+
+func (e *PartTooLargeError) Error() string {
+	switch {
+	case e.Total:
+		return fmt.Sprintf("%v: %s: parts read would total %d bytes, limit %d", ErrPartTooLarge, e.Part, e.Size, e.Limit)
+	case e.Inflated:
+		return fmt.Sprintf("%v: %s: inflates past limit %d", ErrPartTooLarge, e.Part, e.Limit)
+	default:
+		return fmt.Sprintf("%v: %s: declares %d bytes, limit %d", ErrPartTooLarge, e.Part, e.Size, e.Limit)
+	}
+}
+
+func (e *PartTooLargeError) Unwrap() error {
+	return ErrPartTooLarge
+}
+
+// Budget applies Limits to the entries of one zip, counting the bytes read
+// across entries. onExceeded, when set, sees every PartTooLargeError
+// before it is returned. A Budget is safe for concurrent use; a nil Budget
+// opens entries without limits.
+type Budget struct {
+	limits     Limits
+	onExceeded func(*PartTooLargeError)
+
+	mu      sync.Mutex
+	counted map[string]bool
+	total   int64
+}
+
+func NewBudget(limits Limits, onExceeded func(*PartTooLargeError)) *Budget {
+	return &Budget{limits: limits, onExceeded: onExceeded, counted: make(map[string]bool)}
+}
+
+// Open opens part for reading within the limits. An entry whose header
+// already claims too much, alone or with the entries read before it, is
+// refused without being inflated.
+func (b *Budget) Open(part *zip.File) (io.ReadCloser, error) {
+	var limits Limits
+	if b != nil {
+		limits = b.limits
+	}
+	size := int64(part.UncompressedSize64)
+	if max := limits.MaxPartSize; max > 0 && part.UncompressedSize64 > uint64(max) {
+		return nil, b.exceeded(&PartTooLargeError{Part: part.Name, Size: size, Limit: max})
+	}
+	if err := b.count(part.Name, size); err != nil {
+		return nil, err
+	}
+
+	reader, err := part.Open()
+	if err != nil {
+		return nil, fmt.Errorf("read part %q: %w", part.Name, err)
+	}
+	if limits.MaxPartSize <= 0 {
+		return reader, nil
+	}
+	return &limitedPart{ReadCloser: reader, budget: b, name: part.Name, limit: limits.MaxPartSize, left: limits.MaxPartSize}, nil
+}
+
+// count adds an entry to the bytes read the first time it is opened.
+func (b *Budget) count(name string, size int64) error {
+	if b == nil || b.limits.MaxTotalSize <= 0 {
+		return nil
+	}
+	max := b.limits.MaxTotalSize
+	b.mu.Lock()
+	if b.counted[name] {
+		b.mu.Unlock()
+		return nil
+	}
+	total := b.total + size
+	if total > max || total < 0 {
+		b.mu.Unlock()
+		return b.exceeded(&PartTooLargeError{Part: name, Size: total, Limit: max, Total: true})
+	}
+	b.counted[name] = true
+	b.total = total
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *Budget) exceeded(err *PartTooLargeError) error {
+	if b.onExceeded != nil {
+		b.onExceeded(err)
+	}
+	return err
+}
+
+// limitedPart fails a read once a zip entry inflates past the part size
+// limit, whatever its header declared.
+type limitedPart struct {
+	io.ReadCloser
+	budget *Budget
+	name   string
+	limit  int64
+	left   int64
+}
+
+func (l *limitedPart) Read(b []byte) (int, error) {
+	if l.left < 0 {
+		return 0, l.tooLarge()
+	}
+	if int64(len(b)) > l.left+1 {
+		b = b[:l.left+1]
+	}
+	n, err := l.ReadCloser.Read(b)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return n, l.tooLarge()
+	}
+	return n, err
+}
+
+func (l *limitedPart) tooLarge() error {
+	return l.budget.exceeded(&PartTooLargeError{Part: l.name, Size: l.limit - l.left, Limit: l.limit, Inflated: true})
+}
@@ -4,6 +4,8 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/flate"
+	"context"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
@@ -11,25 +13,187 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
 type Package struct {
 	data    []byte
+	file    *os.File
 	reader  *zip.Reader
 	index   map[string]*zip.File
 	overlay map[string][]byte
+	budget  *Budget
+	output  Output
 }
 
+// Output controls how a save writes the entries it compresses itself, the
+// parts written in the session; untouched parts are copied as they are. The
+// zero value keeps the method of each rewritten part and deflates at the
+// default level.
+type Output struct {
+	// Store writes every written part uncompressed.
+	Store bool
+	// Level is the compress/flate level of deflated parts; 0 means
+	// flate.DefaultCompression.
+	Level int
+	// Deterministic writes every written part with a 1980-01-01
+	// modification time, no extra fields or comment, and no flags beyond the UTF-8 one,
+	// so the same input and writes save to the same bytes.
+	Deterministic bool
+}
+
+// dosEpochDate is 1980-01-01, the earliest MS-DOS date a zip entry holds.
+const dosEpochDate = 1<<5 | 1
+
+// Header returns header, the old header of a rewritten part or the zero
+// header of a new one, adjusted to o.
+func (o Output) Header(header zip.FileHeader) zip.FileHeader {
+	if o.Store {
+		header.Method = zip.Store
+	}
+	if o.Deterministic {
+		header.Modified = time.Time{}
+		header.ModifiedDate = dosEpochDate
+		header.ModifiedTime = 0
+		header.Extra = nil
+		header.Comment = ""
+		header.Flags &= 0x800
+	}
+	return header
+}
+
+// NewWriter returns a zip writer for w that deflates at o.Level.
+func (o Output) NewWriter(w io.Writer) *zip.Writer {
+	writer := zip.NewWriter(w)
+	if o.Level != 0 {
+		writer.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, o.Level)
+		})
+	}
+	return writer
+}
+
+// CompressData compresses data for method, deflating at o.Level.
+func (o Output) CompressData(method uint16, data []byte) ([]byte, error) {
+	switch method {
+	case zip.Store:
+		return data, nil
+	case zip.Deflate:
+		level := o.Level
+		if level == 0 {
+			level = flate.DefaultCompression
+		}
+		var buf bytes.Buffer
+		zw, err := flate.NewWriter(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if _, err := zw.Write(data); err != nil {
+				_ = zw.Close()
+				return nil, err
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression method: %d", method)
+	}
+}
+
+// SetOutput sets how later saves write parts.
+func (p *Package) SetOutput(out Output) {
+	if p == nil {
+		return
+	}
+	p.output = out
+}
+
+// PartInfo describes a part without reading its content.
+type PartInfo struct {
+	Name string
+	// Size is the uncompressed size. For a zip entry it comes from the
+	// central directory and is only a hint: a crafted archive can claim
+	// any size, so reads still count the bytes they inflate.
+	Size           int64
+	CompressedSize int64
+	CRC32          uint32
+	Method         uint16
+	// Overwritten reports that the part was written in this session, either
+	// replacing a zip entry or as a new part. Size and CRC32 then describe
+	// the pending content, and CompressedSize is unknown (0) until save.
+	Overwritten bool
+	// InputSize is the uncompressed size of the zip entry the package was
+	// opened with: equal to Size for untouched parts and 0 for new ones.
+	InputSize int64
+}
+
+// OpenFile opens the package at path. Only the central directory is read up
+// front: parts are inflated from the file when they are read, and a save
+// copies untouched parts entry to entry without inflating them, so memory
+// holds the parts written in the session rather than the whole file. The
+// file stays open until Close.
 func OpenFile(path string) (*Package, error) {
-	data, err := os.ReadFile(path)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrOpenFailed, path, err)
+	}
+	info, err := file.Stat()
 	if err != nil {
+		_ = file.Close()
 		return nil, fmt.Errorf("%w: %s: %v", ErrOpenFailed, path, err)
 	}
 
-	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	pkg, err := newPackage(file, info.Size())
 	if err != nil {
+		_ = file.Close()
 		return nil, fmt.Errorf("%w: %s: %v", ErrOpenFailed, path, err)
 	}
+	pkg.file = file
+	return pkg, nil
+}
+
+// Close releases the file OpenFile reads parts from. Untouched parts can no
+// longer be read or saved afterwards. It is a no-op for packages opened
+// from memory or a reader.
+func (p *Package) Close() error {
+	if p == nil || p.file == nil {
+		return nil
+	}
+	err := p.file.Close()
+	p.file = nil
+	return err
+}
+
+// Open reads a package held in memory. The package reads its parts from
+// data until it is discarded, so data must not be modified meanwhile.
+func Open(data []byte) (*Package, error) {
+	pkg, err := newPackage(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOpenFailed, err)
+	}
+	pkg.data = data
+	return pkg, nil
+}
+
+// OpenReader reads a package of size bytes from r. Parts are inflated from r
+// when they are read, so r must stay readable for as long as the package is
+// used.
+func OpenReader(r io.ReaderAt, size int64) (*Package, error) {
+	pkg, err := newPackage(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOpenFailed, err)
+	}
+	return pkg, nil
+}
+
+func newPackage(r io.ReaderAt, size int64) (*Package, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
 
 	index := make(map[string]*zip.File, len(reader.File))
 	for _, part := range reader.File {
@@ -37,7 +201,6 @@ func OpenFile(path string) (*Package, error) {
 	}
 
 	return &Package{
-		data:    data,
 		reader:  reader,
 		index:   index,
 		overlay: make(map[string][]byte),
@@ -72,34 +235,133 @@ func (p *Package) ListParts() ([]string, error) {
 	return names, nil
 }
 
-func (p *Package) ReadPart(name string) ([]byte, error) {
+// PartInfo returns the metadata of one part.
+func (p *Package) PartInfo(name string) (PartInfo, error) {
 	if p == nil {
-		return nil, fmt.Errorf("%w: package not initialized", ErrOpenFailed)
+		return PartInfo{}, fmt.Errorf("%w: package not initialized", ErrOpenFailed)
+	}
+	part := p.index[name]
+	data, overwritten := p.overlay[name]
+	if part == nil && !overwritten {
+		return PartInfo{}, fmt.Errorf("%w: %s", ErrPartNotFound, name)
 	}
+	return p.partInfo(name, part, data, overwritten), nil
+}
 
-	if data, ok := p.overlay[name]; ok {
-		return append([]byte(nil), data...), nil
+// ListPartsWithInfo returns PartInfo for every part, in ListParts order.
+func (p *Package) ListPartsWithInfo() ([]PartInfo, error) {
+	names, err := p.ListParts()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]PartInfo, 0, len(names))
+	for _, name := range names {
+		data, overwritten := p.overlay[name]
+		infos = append(infos, p.partInfo(name, p.index[name], data, overwritten))
 	}
+	return infos, nil
+}
 
-	part, ok := p.index[name]
-	if !ok {
-		return nil, fmt.Errorf("%w: %s", ErrPartNotFound, name)
+func (p *Package) partInfo(name string, part *zip.File, data []byte, overwritten bool) PartInfo {
+	if !overwritten {
+		return PartInfo{
+			Name:           name,
+			Size:           int64(part.UncompressedSize64),
+			CompressedSize: int64(part.CompressedSize64),
+			CRC32:          part.CRC32,
+			Method:         part.Method,
+			InputSize:      int64(part.UncompressedSize64),
+		}
+	}
+	// Mirror the method SaveFile will use for the pending content.
+	method := zip.Deflate
+	var inputSize int64
+	if part != nil {
+		method = part.Method
+		inputSize = int64(part.UncompressedSize64)
+	} else if strings.HasSuffix(name, "/") {
+		method = zip.Store
 	}
+	return PartInfo{
+		Name:        name,
+		Size:        int64(len(data)),
+		CRC32:       crc32.ChecksumIEEE(data),
+		Method:      method,
+		Overwritten: true,
+		InputSize:   inputSize,
+	}
+}
 
-	reader, err := part.Open()
+// SetMaxPartSize bounds the uncompressed size ReadPart accepts for a zip
+// entry. An entry whose central directory already claims more is rejected
+// without being read; one that inflates past the limit anyway is cut off.
+// Both fail with ErrPartTooLarge. Zero or less disables the limit. Parts
+// written in this session are not limited.
+func (p *Package) SetMaxPartSize(n int64) {
+	if p == nil {
+		return
+	}
+	limits := Limits{MaxPartSize: n}
+	var onExceeded func(*PartTooLargeError)
+	if p.budget != nil {
+		limits.MaxTotalSize = p.budget.limits.MaxTotalSize
+		onExceeded = p.budget.onExceeded
+	}
+	p.SetLimits(limits, onExceeded)
+}
+
+// SetLimits applies limits to the entries ReadPart and OpenPart read from
+// then on, as SetMaxPartSize does for MaxPartSize. The bytes counted
+// against MaxTotalSize start over. onExceeded, when set, is called with
+// each PartTooLargeError before it is returned.
+func (p *Package) SetLimits(limits Limits, onExceeded func(*PartTooLargeError)) {
+	if p == nil {
+		return
+	}
+	if limits.MaxPartSize <= 0 && limits.MaxTotalSize <= 0 {
+		p.budget = nil
+		return
+	}
+	p.budget = NewBudget(limits, onExceeded)
+}
+
+func (p *Package) ReadPart(name string) ([]byte, error) {
+	reader, err := p.OpenPart(name)
 	if err != nil {
-		return nil, fmt.Errorf("read part %q: %w", name, err)
+		return nil, err
 	}
 	defer reader.Close()
 
 	data, err := io.ReadAll(reader)
+	if errors.Is(err, ErrPartTooLarge) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("read part %q: %w", name, err)
 	}
-
 	return data, nil
 }
 
+// OpenPart streams a part instead of reading it into memory: a zip entry is
+// inflated as it is read, and a part written in this session is read from
+// its pending content. The size limit applies as for ReadPart, with a read
+// past it failing with ErrPartTooLarge. The reader must be closed.
+func (p *Package) OpenPart(name string) (io.ReadCloser, error) {
+	if p == nil {
+		return nil, fmt.Errorf("%w: package not initialized", ErrOpenFailed)
+	}
+
+	if data, ok := p.overlay[name]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	part, ok := p.index[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPartNotFound, name)
+	}
+	return p.budget.Open(part)
+}
+
 func (p *Package) WritePart(name string, data []byte) {
 	if p == nil {
 		return
@@ -114,7 +376,59 @@ func (p *Package) WritePart(name string, data []byte) {
 	p.overlay[name] = copied
 }
 
+// Snapshot is the set of parts written in the session at one point, as
+// returned by Package.Snapshot.
+type Snapshot struct {
+	written map[string][]byte
+}
+
+// Snapshot captures the parts written so far. Only the map is copied:
+// WritePart stores its own copy of the data and never changes it, so the
+// snapshot shares the contents with the package.
+func (p *Package) Snapshot() Snapshot {
+	if p == nil {
+		return Snapshot{}
+	}
+	written := make(map[string][]byte, len(p.overlay))
+	for name, data := range p.overlay {
+		written[name] = data
+	}
+	return Snapshot{written: written}
+}
+
+// Restore puts the written parts back as they were at snapshot: later
+// writes are dropped, and parts first written after it no longer exist.
+// The snapshot stays usable.
+func (p *Package) Restore(snapshot Snapshot) {
+	if p == nil {
+		return
+	}
+	p.overlay = make(map[string][]byte, len(snapshot.written))
+	for name, data := range snapshot.written {
+		p.overlay[name] = data
+	}
+}
+
+// WrittenParts lists the parts written in the session, sorted by name.
+func (p *Package) WrittenParts() []string {
+	if p == nil {
+		return []string{}
+	}
+	names := make([]string, 0, len(p.overlay))
+	for name := range p.overlay {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (p *Package) SaveFile(path string) error {
+	return p.SaveFileContext(context.Background(), path)
+}
+
+// SaveFileContext is SaveFile stopping between parts once ctx is done, with
+// a *CanceledError naming the next part. path is left as it was.
+func (p *Package) SaveFileContext(ctx context.Context, path string) error {
 	if p == nil || p.reader == nil {
 		return fmt.Errorf("%w: package not initialized", ErrSaveFailed)
 	}
@@ -133,67 +447,102 @@ func (p *Package) SaveFile(path string) error {
 		}
 	}()
 
-	writer := zip.NewWriter(tmpFile)
+	if err := p.writeZip(ctx, tmpFile); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("%w: %s: %w", ErrSaveFailed, path, err)
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("%w: %s: %v", ErrSaveFailed, path, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrSaveFailed, path, err)
+	}
+
+	if err := replaceFile(tmpName, path); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrSaveFailed, path, err)
+	}
+
+	cleanup = false
+	return nil
+}
+
+// SaveTo writes the package, with every part written in this session, to w
+// as a zip archive. Untouched parts are copied without being recompressed.
+// The package is only read, so after a failed write, with w holding a
+// partial archive, it can be saved again to another writer.
+func (p *Package) SaveTo(w io.Writer) error {
+	return p.SaveToContext(context.Background(), w)
+}
+
+// SaveToContext is SaveTo stopping between parts once ctx is done, with a
+// *CanceledError naming the next part.
+func (p *Package) SaveToContext(ctx context.Context, w io.Writer) error {
+	if p == nil || p.reader == nil {
+		return fmt.Errorf("%w: package not initialized", ErrSaveFailed)
+	}
+	if err := p.writeZip(ctx, w); err != nil {
+		return fmt.Errorf("%w: %w", ErrSaveFailed, err)
+	}
+	return nil
+}
+
+func (p *Package) writeZip(ctx context.Context, w io.Writer) error {
+	writer := p.output.NewWriter(w)
 	written := make(map[string]struct{}, len(p.reader.File)+len(p.overlay))
 
 	for _, part := range p.reader.File {
 		name := part.Name
+		if err := ctx.Err(); err != nil {
+			_ = writer.Close()
+			return &CanceledError{Part: name, Err: err}
+		}
 		if data, ok := p.overlay[name]; ok {
-			if err := writeOverrideEntry(writer, part, data); err != nil {
+			if err := writeOverrideEntry(writer, p.output, part, data); err != nil {
 				_ = writer.Close()
-				_ = tmpFile.Close()
-				return fmt.Errorf("%w: write part %q: %v", ErrSaveFailed, name, err)
+				return fmt.Errorf("write part %q: %w", name, err)
 			}
 		} else {
 			if err := writer.Copy(part); err != nil {
 				_ = writer.Close()
-				_ = tmpFile.Close()
-				return fmt.Errorf("%w: copy part %q: %v", ErrSaveFailed, name, err)
+				return fmt.Errorf("copy part %q: %w", name, err)
 			}
 		}
 		written[name] = struct{}{}
 	}
 
-	for name, data := range p.overlay {
-		if _, ok := written[name]; ok {
-			continue
+	// New parts follow the existing ones in name order, so the same writes
+	// always save the same entry order.
+	added := make([]string, 0, len(p.overlay))
+	for name := range p.overlay {
+		if _, ok := written[name]; !ok {
+			added = append(added, name)
 		}
-		if err := writeNewEntry(writer, name, data); err != nil {
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		if err := ctx.Err(); err != nil {
 			_ = writer.Close()
-			_ = tmpFile.Close()
-			return fmt.Errorf("%w: write part %q: %v", ErrSaveFailed, name, err)
+			return &CanceledError{Part: name, Err: err}
+		}
+		if err := writeNewEntry(writer, p.output, name, p.overlay[name]); err != nil {
+			_ = writer.Close()
+			return fmt.Errorf("write part %q: %w", name, err)
 		}
 	}
 
-	if err := writer.Close(); err != nil {
-		_ = tmpFile.Close()
-		return fmt.Errorf("%w: %s: %v", ErrSaveFailed, path, err)
-	}
-
-	if err := tmpFile.Sync(); err != nil {
-		_ = tmpFile.Close()
-		return fmt.Errorf("%w: %s: %v", ErrSaveFailed, path, err)
-	}
-	if err := tmpFile.Close(); err != nil {
-		return fmt.Errorf("%w: %s: %v", ErrSaveFailed, path, err)
-	}
-
-	if err := replaceFile(tmpName, path); err != nil {
-		return fmt.Errorf("%w: %s: %v", ErrSaveFailed, path, err)
-	}
-
-	cleanup = false
-	return nil
+	return writer.Close()
 }
 
-func writeNewEntry(writer *zip.Writer, name string, data []byte) error {
+func writeNewEntry(writer *zip.Writer, output Output, name string, data []byte) error {
 	if strings.HasSuffix(name, "/") {
-		header := zip.FileHeader{Name: name, Method: zip.Store}
+		header := output.Header(zip.FileHeader{Name: name, Method: zip.Store})
 		return writeDirectoryEntry(writer, &header)
 	}
 
-	header := zip.FileHeader{Name: name, Method: zip.Deflate}
-	return writeRawEntry(writer, &header, data)
+	header := output.Header(zip.FileHeader{Name: name, Method: zip.Deflate})
+	return writeRawEntry(writer, output, &header, data)
 }
 
 func replaceFile(src, dst string) error {
@@ -210,17 +559,17 @@ func replaceFile(src, dst string) error {
 	}
 }
 
-func writeOverrideEntry(writer *zip.Writer, part *zip.File, data []byte) error {
+func writeOverrideEntry(writer *zip.Writer, output Output, part *zip.File, data []byte) error {
 	if part.FileInfo().IsDir() {
 		return writer.Copy(part)
 	}
 
-	header := part.FileHeader
+	header := output.Header(part.FileHeader)
 	if header.Flags&0x8 != 0 {
 		return writeEntryWithDescriptor(writer, &header, data)
 	}
 
-	return writeRawEntry(writer, &header, data)
+	return writeRawEntry(writer, output, &header, data)
 }
 
 func writeEntryWithDescriptor(writer *zip.Writer, header *zip.FileHeader, data []byte) error {
@@ -239,9 +588,9 @@ func writeEntryWithDescriptor(writer *zip.Writer, header *zip.FileHeader, data [
 	return err
 }
 
-func writeRawEntry(writer *zip.Writer, header *zip.FileHeader, data []byte) error {
+func writeRawEntry(writer *zip.Writer, output Output, header *zip.FileHeader, data []byte) error {
 	// Precompute sizes/CRC and use CreateRaw so we don't introduce data descriptors.
-	compressed, err := compressData(header.Method, data)
+	compressed, err := output.CompressData(header.Method, data)
 	if err != nil {
 		return err
 	}
@@ -277,28 +626,3 @@ func writeDirectoryEntry(writer *zip.Writer, header *zip.FileHeader) error {
 	_, err := writer.CreateHeader(header)
 	return err
 }
-
-func compressData(method uint16, data []byte) ([]byte, error) {
-	switch method {
-	case zip.Store:
-		return data, nil
-	case zip.Deflate:
-		var buf bytes.Buffer
-		zw, err := flate.NewWriter(&buf, flate.DefaultCompression)
-		if err != nil {
-			return nil, err
-		}
-		if len(data) > 0 {
-			if _, err := zw.Write(data); err != nil {
-				_ = zw.Close()
-				return nil, err
-			}
-		}
-		if err := zw.Close(); err != nil {
-			return nil, err
-		}
-		return buf.Bytes(), nil
-	default:
-		return nil, fmt.Errorf("unsupported compression method: %d", method)
-	}
-}
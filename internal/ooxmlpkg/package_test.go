@@ -2,11 +2,16 @@ package ooxmlpkg
 
 import (
 	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"hash/crc32"
 	"io"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 )
 
@@ -95,6 +100,62 @@ func TestOpenFileMissing(t *testing.T) {
 	}
 }
 
+func TestOpenInvalidData(t *testing.T) {
+	if _, err := Open([]byte("not a zip")); !errors.Is(err, ErrOpenFailed) {
+		t.Fatalf("expected ErrOpenFailed, got %v", err)
+	}
+}
+
+type failingWriter struct {
+	remaining int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		n := w.remaining
+		w.remaining = 0
+		return n, errors.New("disk full")
+	}
+	w.remaining -= len(p)
+	return len(p), nil
+}
+
+func TestSaveToRetriesAfterFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	outputPath := filepath.Join(dir, "output.pptx")
+	if err := writeZip(inputPath, map[string][]byte{
+		"[Content_Types].xml":  []byte("types"),
+		"ppt/presentation.xml": []byte("original"),
+	}); err != nil {
+		t.Fatalf("writeZip: %v", err)
+	}
+	pkg, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	pkg.WritePart("ppt/presentation.xml", []byte("updated"))
+	pkg.WritePart("ppt/new.xml", []byte("new"))
+
+	if err := pkg.SaveTo(&failingWriter{remaining: 40}); !errors.Is(err, ErrSaveFailed) {
+		t.Fatalf("expected ErrSaveFailed, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := pkg.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo after a failed write: %v", err)
+	}
+	if err := pkg.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	want, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("SaveTo output differs from SaveFile output")
+	}
+}
+
 func TestSaveFileReplacesExisting(t *testing.T) {
 	dir := t.TempDir()
 	inputPath := filepath.Join(dir, "input.pptx")
@@ -130,6 +191,41 @@ func TestSaveFileReplacesExisting(t *testing.T) {
 	}
 }
 
+func TestSaveFileContextCanceled(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	outputPath := filepath.Join(dir, "output.pptx")
+
+	if err := writeZip(inputPath, map[string][]byte{"ppt/presentation.xml": []byte("original")}); err != nil {
+		t.Fatalf("writeZip: %v", err)
+	}
+	if err := os.WriteFile(outputPath, []byte("stale"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	pkg, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = pkg.SaveFileContext(ctx, outputPath)
+	var canceled *CanceledError
+	if !errors.As(err, &canceled) || canceled.Part != "ppt/presentation.xml" {
+		t.Fatalf("expected a CanceledError at ppt/presentation.xml, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrSaveFailed) {
+		t.Fatalf("expected context.Canceled and ErrSaveFailed, got %v", err)
+	}
+	if data, err := os.ReadFile(outputPath); err != nil || string(data) != "stale" {
+		t.Fatalf("output must be left as it was: %q %v", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected no temporary file left, got %v %v", entries, err)
+	}
+}
+
 func TestSaveFileDoesNotAddDataDescriptorFlag(t *testing.T) {
 	dir := t.TempDir()
 	inputPath := filepath.Join(dir, "input.pptx")
@@ -164,6 +260,363 @@ func TestSaveFileDoesNotAddDataDescriptorFlag(t *testing.T) {
 	}
 }
 
+func TestPartInfoFromCentralDirectory(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	slide := bytes.Repeat([]byte("<p:sp/>"), 100)
+	if err := writeRawZip(inputPath, []rawEntry{
+		{name: "ppt/presentation.xml", data: []byte("presentation"), method: zip.Store},
+		{name: "ppt/slides/slide1.xml", data: slide, method: zip.Deflate},
+	}); err != nil {
+		t.Fatalf("writeRawZip: %v", err)
+	}
+
+	pkg, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	info, err := pkg.PartInfo("ppt/slides/slide1.xml")
+	if err != nil {
+		t.Fatalf("PartInfo: %v", err)
+	}
+	if info.Size != 700 || info.Method != zip.Deflate || info.CRC32 != crc32.ChecksumIEEE(slide) || info.Overwritten {
+		t.Fatalf("unexpected slide info: %#v", info)
+	}
+	if info.CompressedSize <= 0 || info.CompressedSize >= info.Size {
+		t.Fatalf("expected a compressed size below 700, got %d", info.CompressedSize)
+	}
+
+	pkg.WritePart("ppt/presentation.xml", []byte("updated!"))
+	pkg.WritePart("ppt/new.xml", []byte("new"))
+
+	infos, err := pkg.ListPartsWithInfo()
+	if err != nil {
+		t.Fatalf("ListPartsWithInfo: %v", err)
+	}
+	want := []PartInfo{
+		{Name: "ppt/presentation.xml", Size: 8, CRC32: crc32.ChecksumIEEE([]byte("updated!")), Method: zip.Store, Overwritten: true, InputSize: 12},
+		{Name: "ppt/slides/slide1.xml", Size: 700, CompressedSize: info.CompressedSize, CRC32: info.CRC32, Method: zip.Deflate, InputSize: 700},
+		{Name: "ppt/new.xml", Size: 3, CRC32: crc32.ChecksumIEEE([]byte("new")), Method: zip.Deflate, Overwritten: true},
+	}
+	if len(infos) != len(want) {
+		t.Fatalf("expected %d parts, got %#v", len(want), infos)
+	}
+	for i := range want {
+		if infos[i] != want[i] {
+			t.Fatalf("part %d: expected %#v, got %#v", i, want[i], infos[i])
+		}
+	}
+
+	if _, err := pkg.PartInfo("missing.xml"); !errors.Is(err, ErrPartNotFound) {
+		t.Fatalf("expected ErrPartNotFound, got %v", err)
+	}
+}
+
+func TestReadPartLimitUsesDeclaredSize(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	if err := writeRawZip(inputPath, []rawEntry{
+		{name: "ppt/small.xml", data: []byte("small"), method: zip.Store},
+		{name: "ppt/large.xml", data: bytes.Repeat([]byte("x"), 4096), method: zip.Deflate},
+	}); err != nil {
+		t.Fatalf("writeRawZip: %v", err)
+	}
+
+	pkg, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	pkg.SetMaxPartSize(1024)
+
+	if _, err := pkg.ReadPart("ppt/large.xml"); !errors.Is(err, ErrPartTooLarge) {
+		t.Fatalf("expected ErrPartTooLarge, got %v", err)
+	}
+	if data, err := pkg.ReadPart("ppt/small.xml"); err != nil || string(data) != "small" {
+		t.Fatalf("expected small part to read, got %q, %v", data, err)
+	}
+
+	pkg.WritePart("ppt/large.xml", bytes.Repeat([]byte("y"), 2048))
+	if _, err := pkg.ReadPart("ppt/large.xml"); err != nil {
+		t.Fatalf("expected session writes to bypass the limit, got %v", err)
+	}
+}
+
+func TestReadPartRejectsLyingHeader(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	payload := bytes.Repeat([]byte("a"), 64*1024)
+	if err := writeRawZip(inputPath, []rawEntry{
+		{name: "ppt/bomb.xml", data: payload, method: zip.Deflate, declaredSize: 16},
+	}); err != nil {
+		t.Fatalf("writeRawZip: %v", err)
+	}
+
+	pkg, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	info, err := pkg.PartInfo("ppt/bomb.xml")
+	if err != nil {
+		t.Fatalf("PartInfo: %v", err)
+	}
+	if info.Size != 16 {
+		t.Fatalf("expected PartInfo to report the declared size, got %d", info.Size)
+	}
+
+	for _, limit := range []int64{0, 1024} {
+		pkg.SetMaxPartSize(limit)
+		data, err := pkg.ReadPart("ppt/bomb.xml")
+		if err == nil {
+			t.Fatalf("limit %d: expected lying header to fail the read, got %d bytes", limit, len(data))
+		}
+	}
+}
+
+func TestReadPartTotalLimitCountsEachPartOnce(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	if err := writeRawZip(inputPath, []rawEntry{
+		{name: "ppt/a.xml", data: bytes.Repeat([]byte("a"), 600), method: zip.Deflate},
+		{name: "ppt/b.xml", data: bytes.Repeat([]byte("b"), 600), method: zip.Deflate},
+	}); err != nil {
+		t.Fatalf("writeRawZip: %v", err)
+	}
+
+	pkg, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer pkg.Close()
+	var exceeded []*PartTooLargeError
+	pkg.SetLimits(Limits{MaxTotalSize: 1000}, func(err *PartTooLargeError) {
+		exceeded = append(exceeded, err)
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := pkg.ReadPart("ppt/a.xml"); err != nil {
+			t.Fatalf("read %d: expected a reread to count once, got %v", i, err)
+		}
+	}
+	_, err = pkg.ReadPart("ppt/b.xml")
+	var tooLarge *PartTooLargeError
+	if !errors.As(err, &tooLarge) || !errors.Is(err, ErrPartTooLarge) || !tooLarge.Total || tooLarge.Size != 1200 || tooLarge.Limit != 1000 {
+		t.Fatalf("expected the total to be exceeded, got %v", err)
+	}
+	if len(exceeded) != 1 || exceeded[0] != tooLarge {
+		t.Fatalf("expected the handler to see the error, got %v", exceeded)
+	}
+
+	pkg.WritePart("ppt/b.xml", []byte("small"))
+	if _, err := pkg.ReadPart("ppt/b.xml"); err != nil {
+		t.Fatalf("expected session writes to bypass the limit, got %v", err)
+	}
+}
+
+type rawEntry struct {
+	name   string
+	data   []byte
+	method uint16
+	// declaredSize, when set, replaces the uncompressed size recorded in
+	// the headers while the real compressed payload is kept.
+	declaredSize uint64
+}
+
+// largeMediaSize is the size of the synthetic video in the streaming tests,
+// large enough that reading it into memory would dwarf everything else a
+// save allocates.
+const largeMediaSize = 32 << 20
+
+func TestSaveFileStreamsUntouchedMedia(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	outputPath := filepath.Join(dir, "output.pptx")
+	if err := writeLargeMediaZip(inputPath, largeMediaSize); err != nil {
+		t.Fatalf("writeLargeMediaZip: %v", err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	pkg, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer pkg.Close()
+	pkg.WritePart("ppt/slides/slide1.xml", []byte("updated"))
+	if err := pkg.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > largeMediaSize/8 {
+		t.Fatalf("open and save allocated %d bytes for a %d byte media part", allocated, largeMediaSize)
+	}
+
+	in, err := zip.OpenReader(inputPath)
+	if err != nil {
+		t.Fatalf("zip.OpenReader: %v", err)
+	}
+	defer in.Close()
+	out, err := zip.OpenReader(outputPath)
+	if err != nil {
+		t.Fatalf("zip.OpenReader: %v", err)
+	}
+	defer out.Close()
+	if got, want := rawEntryHash(t, &out.Reader, "ppt/media/media1.mp4"), rawEntryHash(t, &in.Reader, "ppt/media/media1.mp4"); got != want {
+		t.Fatalf("media bytes changed by save: %x != %x", got, want)
+	}
+}
+
+func TestOpenPartStreamsAndClose(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	if err := writeZip(inputPath, map[string][]byte{"ppt/media/image1.png": bytes.Repeat([]byte("x"), 100)}); err != nil {
+		t.Fatalf("writeZip: %v", err)
+	}
+	pkg, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	pkg.SetMaxPartSize(10)
+	if _, err := pkg.OpenPart("ppt/media/image1.png"); !errors.Is(err, ErrPartTooLarge) {
+		t.Fatalf("expected ErrPartTooLarge, got %v", err)
+	}
+
+	pkg.SetMaxPartSize(0)
+	reader, err := pkg.OpenPart("ppt/media/image1.png")
+	if err != nil {
+		t.Fatalf("OpenPart: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || len(data) != 100 {
+		t.Fatalf("OpenPart read %d bytes: %v", len(data), err)
+	}
+
+	if err := pkg.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := pkg.ReadPart("ppt/media/image1.png"); err == nil {
+		t.Fatalf("expected reads to fail after Close")
+	}
+	if err := pkg.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func BenchmarkSaveFileLargeMedia(b *testing.B) {
+	dir := b.TempDir()
+	inputPath := filepath.Join(dir, "input.pptx")
+	if err := writeLargeMediaZip(inputPath, largeMediaSize); err != nil {
+		b.Fatalf("writeLargeMediaZip: %v", err)
+	}
+	b.ReportAllocs()
+	b.SetBytes(largeMediaSize)
+	for i := 0; i < b.N; i++ {
+		pkg, err := OpenFile(inputPath)
+		if err != nil {
+			b.Fatalf("OpenFile: %v", err)
+		}
+		pkg.WritePart("ppt/slides/slide1.xml", []byte("updated"))
+		if err := pkg.SaveFile(filepath.Join(dir, "output.pptx")); err != nil {
+			b.Fatalf("SaveFile: %v", err)
+		}
+		_ = pkg.Close()
+	}
+}
+
+// writeLargeMediaZip writes a deck with a slide and a stored, incompressible
+// media part of size bytes, streamed so the test itself stays small.
+func writeLargeMediaZip(path string, size int64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	slide, err := writer.Create("ppt/slides/slide1.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := slide.Write([]byte("slide1")); err != nil {
+		return err
+	}
+	media, err := writer.CreateHeader(&zip.FileHeader{Name: "ppt/media/media1.mp4", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(media, rand.New(rand.NewSource(1)), size); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// rawEntryHash hashes the stored bytes of an entry without inflating them.
+func rawEntryHash(t *testing.T, reader *zip.Reader, name string) [sha256.Size]byte {
+	t.Helper()
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+		raw, err := file.OpenRaw()
+		if err != nil {
+			t.Fatalf("OpenRaw %s: %v", name, err)
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(hash, raw); err != nil {
+			t.Fatalf("hash %s: %v", name, err)
+		}
+		var sum [sha256.Size]byte
+		copy(sum[:], hash.Sum(nil))
+		return sum
+	}
+	t.Fatalf("entry %s missing", name)
+	return [sha256.Size]byte{}
+}
+
+func writeRawZip(path string, entries []rawEntry) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, entry := range entries {
+		compressed, err := Output{}.CompressData(entry.method, entry.data)
+		if err != nil {
+			_ = writer.Close()
+			return err
+		}
+		size := uint64(len(entry.data))
+		if entry.declaredSize != 0 {
+			size = entry.declaredSize
+		}
+		header := zip.FileHeader{
+			Name:               entry.name,
+			Method:             entry.method,
+			CRC32:              crc32.ChecksumIEEE(entry.data),
+			UncompressedSize64: size,
+			CompressedSize64:   uint64(len(compressed)),
+		}
+		w, err := writer.CreateRaw(&header)
+		if err != nil {
+			_ = writer.Close()
+			return err
+		}
+		if _, err := w.Write(compressed); err != nil {
+			_ = writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
 func writeZip(path string, parts map[string][]byte) error {
 	file, err := os.Create(path)
 	if err != nil {
@@ -284,3 +737,38 @@ func readZipFlags(path, name string) (uint16, error) {
 
 	return 0, os.ErrNotExist
 }
+
+func TestSnapshotRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZip(path, map[string][]byte{"a.xml": []byte("<a/>")}); err != nil {
+		t.Fatalf("writeZip: %v", err)
+	}
+	pkg, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer pkg.Close()
+
+	pkg.WritePart("a.xml", []byte("<a>1</a>"))
+	snapshot := pkg.Snapshot()
+	pkg.WritePart("a.xml", []byte("<a>2</a>"))
+	pkg.WritePart("b.xml", []byte("<b/>"))
+	if got := pkg.WrittenParts(); len(got) != 2 || got[0] != "a.xml" || got[1] != "b.xml" {
+		t.Fatalf("unexpected written parts: %v", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		pkg.Restore(snapshot)
+		got, err := pkg.ReadPart("a.xml")
+		if err != nil || string(got) != "<a>1</a>" {
+			t.Fatalf("restore %d: expected a.xml as snapshotted, got %q, %v", i, got, err)
+		}
+		if _, err := pkg.ReadPart("b.xml"); !errors.Is(err, ErrPartNotFound) {
+			t.Fatalf("restore %d: expected b.xml to be gone, got %v", i, err)
+		}
+		if parts, _ := pkg.ListParts(); len(parts) != 1 {
+			t.Fatalf("restore %d: unexpected parts %v", i, parts)
+		}
+		pkg.WritePart("b.xml", []byte("<b/>"))
+	}
+}
@@ -10,3 +10,9 @@ type Overlay interface {
 type BaselineChecker interface {
 	HasBaseline(path string) (bool, error)
 }
+
+// BaselineAdopter is implemented by overlays whose baseline can grow: parts
+// created by a committed stage are adopted so later stages may rewrite them.
+type BaselineAdopter interface {
+	AdoptBaseline(paths ...string)
+}
@@ -1,6 +1,7 @@
 package overlaystage
 
 import (
+	"errors"
 	"fmt"
 
 	"why-pptx/internal/ooxmlpkg"
@@ -9,6 +10,16 @@ import (
 type PackageOverlay struct {
 	pkg      *ooxmlpkg.Package
 	baseline map[string]struct{}
+	// adopted holds the parts AdoptBaseline added to the baseline, kept
+	// apart so a checkpoint copies them and not the whole baseline.
+	adopted map[string]struct{}
+}
+
+// PackageCheckpoint is the written state of a PackageOverlay at one point,
+// as returned by Checkpoint.
+type PackageCheckpoint struct {
+	parts   ooxmlpkg.Snapshot
+	adopted map[string]struct{}
 }
 
 func NewPackageOverlay(pkg *ooxmlpkg.Package) (*PackageOverlay, error) {
@@ -51,20 +62,17 @@ func (o *PackageOverlay) Has(path string) (bool, error) {
 		return false, fmt.Errorf("overlay not initialized")
 	}
 
-	if _, ok := o.baseline[path]; ok {
+	if o.inBaseline(path) {
 		return true, nil
 	}
 
-	parts, err := o.pkg.ListParts()
-	if err != nil {
-		return false, err
-	}
-	for _, name := range parts {
-		if name == path {
-			return true, nil
+	if _, err := o.pkg.PartInfo(path); err != nil {
+		if errors.Is(err, ooxmlpkg.ErrPartNotFound) {
+			return false, nil
 		}
+		return false, err
 	}
-	return false, nil
+	return true, nil
 }
 
 func (o *PackageOverlay) ListEntries() ([]string, error) {
@@ -78,6 +86,52 @@ func (o *PackageOverlay) HasBaseline(path string) (bool, error) {
 	if o == nil || o.pkg == nil {
 		return false, fmt.Errorf("overlay not initialized")
 	}
-	_, ok := o.baseline[path]
-	return ok, nil
+	return o.inBaseline(path), nil
+}
+
+func (o *PackageOverlay) inBaseline(path string) bool {
+	if _, ok := o.baseline[path]; ok {
+		return true
+	}
+	_, ok := o.adopted[path]
+	return ok
+}
+
+// AdoptBaseline adds paths to the baseline.
+func (o *PackageOverlay) AdoptBaseline(paths ...string) {
+	if o == nil {
+		return
+	}
+	if o.adopted == nil {
+		o.adopted = make(map[string]struct{}, len(paths))
+	}
+	for _, path := range paths {
+		o.adopted[path] = struct{}{}
+	}
+}
+
+// Checkpoint captures the parts written so far and the parts adopted into
+// the baseline, for Restore.
+func (o *PackageOverlay) Checkpoint() PackageCheckpoint {
+	if o == nil {
+		return PackageCheckpoint{}
+	}
+	adopted := make(map[string]struct{}, len(o.adopted))
+	for path := range o.adopted {
+		adopted[path] = struct{}{}
+	}
+	return PackageCheckpoint{parts: o.pkg.Snapshot(), adopted: adopted}
+}
+
+// Restore returns the overlay to checkpoint. Parts created after it are
+// gone, from the package and from the baseline alike.
+func (o *PackageOverlay) Restore(checkpoint PackageCheckpoint) {
+	if o == nil {
+		return
+	}
+	o.pkg.Restore(checkpoint.parts)
+	o.adopted = make(map[string]struct{}, len(checkpoint.adopted))
+	for path := range checkpoint.adopted {
+		o.adopted[path] = struct{}{}
+	}
 }
@@ -8,6 +8,9 @@ import (
 type StagingOverlay struct {
 	parent Overlay
 	staged map[string][]byte
+	// allowNew holds parts Commit may create even though the baseline
+	// lacks them.
+	allowNew map[string]struct{}
 }
 
 func NewStagingOverlay(parent Overlay) *StagingOverlay {
@@ -66,6 +69,20 @@ func (s *StagingOverlay) ListTouched() []string {
 	return names
 }
 
+// AllowNewParts lets Commit create the given parts. Every other staged part
+// must already exist in the baseline.
+func (s *StagingOverlay) AllowNewParts(paths ...string) {
+	if s == nil || len(paths) == 0 {
+		return
+	}
+	if s.allowNew == nil {
+		s.allowNew = make(map[string]struct{}, len(paths))
+	}
+	for _, path := range paths {
+		s.allowNew[path] = struct{}{}
+	}
+}
+
 func (s *StagingOverlay) Commit() error {
 	if s == nil || s.parent == nil {
 		return fmt.Errorf("stage not initialized")
@@ -76,6 +93,9 @@ func (s *StagingOverlay) Commit() error {
 
 	paths := s.ListTouched()
 	for _, path := range paths {
+		if _, ok := s.allowNew[path]; ok {
+			continue
+		}
 		exists, err := s.hasBaseline(path)
 		if err != nil {
 			return fmt.Errorf("check baseline for %q: %w", path, err)
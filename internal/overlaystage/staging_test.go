@@ -195,3 +195,26 @@ func TestStagingOverlayCommitNoNewEntries(t *testing.T) {
 		t.Fatalf("parent data changed: %q", parentData)
 	}
 }
+
+func TestStagingOverlayCommitAllowedNewEntry(t *testing.T) {
+	parent := newMemOverlay(map[string][]byte{
+		"ppt/charts/chart1.xml": []byte("orig"),
+	})
+	stage := NewStagingOverlay(parent)
+	stage.AllowNewParts("docProps/custom.json")
+
+	if err := stage.Set("docProps/custom.json", []byte("{}")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := stage.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	data, err := parent.Get("docProps/custom.json")
+	if err != nil {
+		t.Fatalf("parent.Get: %v", err)
+	}
+	if !bytes.Equal(data, []byte("{}")) {
+		t.Fatalf("unexpected committed data: %q", data)
+	}
+}
@@ -0,0 +1,75 @@
+package postflight
+
+import (
+	"archive/zip"
+	"bytes"
+
+	"why-pptx/internal/overlaystage"
+)
+
+// ValidateChart runs the chart checks of ValidateChartStage on ctx.ChartPath
+// as stage reads it, whether or not the stage touched it: well-formed XML,
+// the caches when ctx.CacheSyncEnabled, and relationship targets. Unlike
+// ValidateChartStage it carries on past a failed check and returns every
+// failure, each an *Error with its alert emitted; a check still stops at its
+// first finding. Any other error, such as a cancellation, is returned as err.
+func (v *PostflightValidator) ValidateChart(ctx ValidateContext, stage *overlaystage.StagingOverlay) ([]error, error) {
+	failures, err := collectFailures(func() error {
+		return v.checkWellFormedXML(ctx, stage, ctx.ChartPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	checks := []func() error{}
+	// The cache check parses the chart again, so it is skipped when the
+	// chart is not XML at all.
+	if ctx.CacheSyncEnabled && len(failures) == 0 {
+		checks = append(checks, func() error { return v.checkChartCaches(ctx, stage, ctx.ChartPath) })
+	}
+	checks = append(checks, func() error { return v.checkRelationshipTargets(ctx, stage, ctx.ChartPath) })
+	more, err := collectFailures(checks...)
+	if err != nil {
+		return nil, err
+	}
+	return append(failures, more...), nil
+}
+
+// ValidateWorkbook runs the workbook checks of ValidateChartStage on
+// ctx.WorkbookPath as stage reads it, returning every failure as
+// ValidateChart does. A workbook that is not a zip fails once, as
+// POSTFLIGHT_XML_MALFORMED.
+func (v *PostflightValidator) ValidateWorkbook(ctx ValidateContext, stage *overlaystage.StagingOverlay) ([]error, error) {
+	workbookPath := ctx.WorkbookPath
+	data, err := stage.Get(workbookPath)
+	if err == nil {
+		_, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	}
+	if err != nil {
+		return collectFailures(func() error { return v.checkWorkbookXML(ctx, stage, workbookPath) })
+	}
+
+	checks := []func() error{}
+	if ctx.SharedStringCells == nil {
+		checks = append(checks, func() error { return v.checkSharedStrings(ctx, stage, workbookPath) })
+	}
+	checks = append(checks,
+		func() error { return v.checkWorkbookXML(ctx, stage, workbookPath) },
+		func() error { return v.checkWorksheetCellTypes(ctx, stage, workbookPath) },
+	)
+	return collectFailures(checks...)
+}
+
+// collectFailures runs every check, gathering the postflight errors. It
+// stops at the first error of another kind.
+func collectFailures(checks ...func() error) ([]error, error) {
+	var failures []error
+	for _, check := range checks {
+		if err := check(); err != nil {
+			if !IsPostflightError(err) {
+				return nil, err
+			}
+			failures = append(failures, err)
+		}
+	}
+	return failures, nil
+}
@@ -16,6 +16,9 @@ import (
 	"why-pptx/internal/errwrap"
 	"why-pptx/internal/overlaystage"
 	"why-pptx/internal/rels"
+	"why-pptx/internal/xlref"
+	"why-pptx/internal/xlsxembed"
+	"why-pptx/internal/xmlcancel"
 )
 
 type Mode string
@@ -32,11 +35,26 @@ type ValidateContext struct {
 	Mode                 Mode
 	CacheSyncEnabled     bool
 	MissingNumericPolicy int
+	// Cancel, when set, aborts validation between XML tokens. A canceled
+	// check returns xmlcancel.ErrCanceled unwrapped and emits no alert.
+	Cancel *xmlcancel.Flag
+	// AllowedNewParts lists parts the stage may create without tripping the
+	// unexpected-part check.
+	AllowedNewParts []string
+	// SharedStringCells, when non-nil, narrows the shared strings checks of
+	// touched workbooks to the cells the stage wrote, keyed by sheet name
+	// and then normalized cell reference: sharedStrings.xml may stay, and
+	// only a listed cell still typed t="s" fails. Nil rejects any shared
+	// string table or cell.
+	SharedStringCells map[string]map[string]struct{}
 }
 
 type Document struct {
 	Overlay   overlaystage.Overlay
 	EmitAlert func(code, message string, ctx map[string]string)
+	// EmitWarning, if set, receives the alerts of findings that do not fail
+	// validation, such as POSTFLIGHT_REL_TARGET_CASE_MISMATCH.
+	EmitWarning func(code, message string, ctx map[string]string)
 }
 
 type PostflightValidator struct {
@@ -107,11 +125,21 @@ func (v *PostflightValidator) ValidateChartStage(ctx ValidateContext, stage *ove
 			}
 			touchedCharts = append(touchedCharts, part)
 		}
+		if strings.HasPrefix(part, "docProps/") && strings.HasSuffix(part, ".xml") {
+			if err := v.checkWellFormedXML(ctx, stage, part); err != nil {
+				return err
+			}
+		}
 	}
 
 	for _, part := range touched {
 		if strings.HasPrefix(part, "ppt/embeddings/") && strings.HasSuffix(strings.ToLower(part), ".xlsx") {
-			if err := v.checkSharedStrings(ctx, stage, part); err != nil {
+			if ctx.SharedStringCells == nil {
+				if err := v.checkSharedStrings(ctx, stage, part); err != nil {
+					return err
+				}
+			}
+			if err := v.checkWorkbookXML(ctx, stage, part); err != nil {
 				return err
 			}
 			if err := v.checkWorksheetCellTypes(ctx, stage, part); err != nil {
@@ -139,6 +167,9 @@ func (v *PostflightValidator) ValidateChartStage(ctx ValidateContext, stage *ove
 
 func (v *PostflightValidator) checkUnexpectedParts(ctx ValidateContext, touched []string) error {
 	for _, part := range touched {
+		if containsString(ctx.AllowedNewParts, part) {
+			continue
+		}
 		exists, err := v.hasBaseline(part)
 		if err != nil {
 			return v.wrapError("POSTFLIGHT_UNEXPECTED_PART_ADDED", fmt.Errorf("check baseline for %q: %w", part, err), ctx, map[string]string{
@@ -161,7 +192,7 @@ func (v *PostflightValidator) checkWellFormedXML(ctx ValidateContext, stage *ove
 			"partPath": part,
 		})
 	}
-	if err := validateXML(data); err != nil {
+	if err := validateXML(data, ctx.Cancel); err != nil {
 		return v.wrapError("POSTFLIGHT_XML_MALFORMED", fmt.Errorf("malformed xml %q: %w", part, err), ctx, map[string]string{
 			"partPath": part,
 		})
@@ -198,6 +229,47 @@ func (v *PostflightValidator) checkSharedStrings(ctx ValidateContext, stage *ove
 	return nil
 }
 
+// checkWorkbookXML parses every XML part of a touched workbook other than the
+// worksheets, which checkWorksheetCellTypes reads in full; between them a
+// workbook holding a part Excel would refuse to open fails the stage.
+func (v *PostflightValidator) checkWorkbookXML(ctx ValidateContext, stage *overlaystage.StagingOverlay, workbookPath string) error {
+	data, err := stage.Get(workbookPath)
+	if err != nil {
+		return v.wrapError("POSTFLIGHT_XML_MALFORMED", fmt.Errorf("read workbook %q: %w", workbookPath, err), ctx, map[string]string{
+			"partPath":     workbookPath,
+			"workbookPath": workbookPath,
+		})
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return v.wrapError("POSTFLIGHT_XML_MALFORMED", fmt.Errorf("open workbook %q: %w", workbookPath, err), ctx, map[string]string{
+			"partPath":     workbookPath,
+			"workbookPath": workbookPath,
+		})
+	}
+
+	for _, part := range reader.File {
+		if strings.HasPrefix(part.Name, "xl/worksheets/") && strings.HasSuffix(part.Name, ".xml") {
+			continue
+		}
+		if !strings.HasSuffix(part.Name, ".xml") && !strings.HasSuffix(part.Name, ".rels") {
+			continue
+		}
+		partData, err := readZipPart(part)
+		if err == nil {
+			err = validateXML(partData, ctx.Cancel)
+		}
+		if err != nil {
+			return v.wrapError("POSTFLIGHT_XML_MALFORMED", fmt.Errorf("malformed xml %q in workbook %q: %w", part.Name, workbookPath, err), ctx, map[string]string{
+				"partPath":     part.Name,
+				"workbookPath": workbookPath,
+			})
+		}
+	}
+	return nil
+}
+
 func (v *PostflightValidator) checkWorksheetCellTypes(ctx ValidateContext, stage *overlaystage.StagingOverlay, workbookPath string) error {
 	data, err := stage.Get(workbookPath)
 	if err != nil {
@@ -215,10 +287,24 @@ func (v *PostflightValidator) checkWorksheetCellTypes(ctx ValidateContext, stage
 		})
 	}
 
+	var written map[string]map[string]struct{}
+	if ctx.SharedStringCells != nil {
+		written, err = sharedStringCellsBySheetPath(data, ctx.SharedStringCells)
+		if err != nil {
+			return v.wrapError("POSTFLIGHT_XML_MALFORMED", fmt.Errorf("open workbook %q: %w", workbookPath, err), ctx, map[string]string{
+				"partPath":     workbookPath,
+				"workbookPath": workbookPath,
+			})
+		}
+	}
+
 	for _, part := range reader.File {
 		if !strings.HasPrefix(part.Name, "xl/worksheets/") || !strings.HasSuffix(part.Name, ".xml") {
 			continue
 		}
+		if written != nil && len(written[part.Name]) == 0 {
+			continue
+		}
 		rc, err := part.Open()
 		if err != nil {
 			return v.wrapError("POSTFLIGHT_XML_MALFORMED", fmt.Errorf("read worksheet %q: %w", part.Name, err), ctx, map[string]string{
@@ -227,7 +313,7 @@ func (v *PostflightValidator) checkWorksheetCellTypes(ctx ValidateContext, stage
 				"sheetPath":    part.Name,
 			})
 		}
-		if err := v.scanWorksheetForSharedStrings(ctx, workbookPath, part.Name, rc); err != nil {
+		if err := v.scanWorksheetForSharedStrings(ctx, workbookPath, part.Name, rc, written[part.Name]); err != nil {
 			_ = rc.Close()
 			return err
 		}
@@ -237,9 +323,30 @@ func (v *PostflightValidator) checkWorksheetCellTypes(ctx ValidateContext, stage
 	return nil
 }
 
-func (v *PostflightValidator) scanWorksheetForSharedStrings(ctx ValidateContext, workbookPath, sheetPath string, r io.Reader) error {
+// sharedStringCellsBySheetPath rekeys cells, listed by sheet name, by the
+// worksheet part each sheet lives in.
+func sharedStringCellsBySheetPath(data []byte, cells map[string]map[string]struct{}) (map[string]map[string]struct{}, error) {
+	wb, err := xlsxembed.Open(data)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]map[string]struct{}, len(cells))
+	for sheet, refs := range cells {
+		if sheetPath, ok := wb.SheetPath(sheet); ok {
+			out[sheetPath] = refs
+		}
+	}
+	return out, nil
+}
+
+// scanWorksheetForSharedStrings fails on the first t="s" cell of the sheet,
+// or, when written is non-nil, on the first one among written.
+func (v *PostflightValidator) scanWorksheetForSharedStrings(ctx ValidateContext, workbookPath, sheetPath string, r io.Reader, written map[string]struct{}) error {
 	decoder := xml.NewDecoder(r)
 	for {
+		if err := ctx.Cancel.Err(); err != nil {
+			return err
+		}
 		token, err := decoder.Token()
 		if err == io.EOF {
 			return nil
@@ -267,6 +374,12 @@ func (v *PostflightValidator) scanWorksheetForSharedStrings(ctx ValidateContext,
 				cellRef = attr.Value
 			}
 		}
+		if cellType == "s" && written != nil {
+			normalized, err := xlref.NormalizeCellRef(cellRef)
+			if _, ok := written[normalized]; err != nil || !ok {
+				continue
+			}
+		}
 		if cellType == "s" {
 			return v.wrapError("POSTFLIGHT_XLSX_CELL_TYPE_MISMATCH", fmt.Errorf("worksheet %q contains shared string cell", sheetPath), ctx, map[string]string{
 				"partPath":     sheetPath,
@@ -278,57 +391,126 @@ func (v *PostflightValidator) scanWorksheetForSharedStrings(ctx ValidateContext,
 	}
 }
 
+// userShapesRelType links a chart to the drawing holding shapes drawn over
+// it (callouts, arrows), which can carry relationships of its own.
+const userShapesRelType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chartUserShapes"
+
 func (v *PostflightValidator) checkRelationshipTargets(ctx ValidateContext, stage *overlaystage.StagingOverlay, chartPath string) error {
-	relPath := chartRelsPath(chartPath)
+	drawings, err := v.checkPartRelTargets(ctx, stage, chartPath)
+	if err != nil {
+		return err
+	}
+	for _, drawing := range drawings {
+		if _, err := v.checkPartRelTargets(ctx, stage, drawing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPartRelTargets checks that every internal target in the rels of
+// partPath exists and returns the userShapes drawings among them.
+func (v *PostflightValidator) checkPartRelTargets(ctx ValidateContext, stage *overlaystage.StagingOverlay, partPath string) ([]string, error) {
+	relPath := chartRelsPath(partPath)
 	hasRel, err := stage.Has(relPath)
 	if err != nil {
-		return v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("check rels %q: %w", relPath, err), ctx, map[string]string{
+		return nil, v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("check rels %q: %w", relPath, err), ctx, map[string]string{
 			"partPath": relPath,
 		})
 	}
 	if !hasRel {
-		return nil
+		return nil, nil
 	}
 
 	data, err := stage.Get(relPath)
 	if err != nil {
-		return v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("read rels %q: %w", relPath, err), ctx, map[string]string{
+		return nil, v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("read rels %q: %w", relPath, err), ctx, map[string]string{
 			"partPath": relPath,
 		})
 	}
 
 	parsed, err := rels.Parse(bytes.NewReader(data))
 	if err != nil {
-		return v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("parse rels %q: %w", relPath, err), ctx, map[string]string{
+		return nil, v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("parse rels %q: %w", relPath, err), ctx, map[string]string{
 			"partPath": relPath,
 		})
 	}
 
-	for _, rel := range parsed.ByID {
+	ids := make([]string, 0, len(parsed.ByID))
+	for id := range parsed.ByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var entries []string
+	var drawings []string
+	for _, id := range ids {
+		rel := parsed.ByID[id]
 		if rel.TargetMode == "External" {
 			continue
 		}
-		target := resolveRelTarget(chartPath, rel.Target)
+		target := rels.ResolveTarget(partPath, rel.Target)
 		if target == "" {
 			continue
 		}
 		// stage.Has uses the merged view (stage overrides + parent overlay + baseline).
 		exists, err := stage.Has(target)
+		if err == nil && !exists {
+			if entries == nil {
+				entries, err = stage.ListEntries()
+				entries = append(entries, stage.ListTouched()...)
+			}
+			if err == nil {
+				var matched string
+				matched, exists = matchRelTarget(entries, target)
+				if exists && matched != target {
+					v.emitWarning("POSTFLIGHT_REL_TARGET_CASE_MISMATCH", ctx, map[string]string{
+						"partPath":    relPath,
+						"target":      target,
+						"matchedPart": matched,
+					})
+					target = matched
+				}
+			}
+		}
 		if err != nil {
-			return v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("check rel target %q: %w", target, err), ctx, map[string]string{
+			return nil, v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("check rel target %q: %w", target, err), ctx, map[string]string{
 				"partPath": relPath,
 				"target":   target,
 			})
 		}
 		if !exists {
-			return v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("missing rel target %q", target), ctx, map[string]string{
+			return nil, v.wrapError("POSTFLIGHT_REL_TARGET_MISSING", fmt.Errorf("missing rel target %q", target), ctx, map[string]string{
 				"partPath": relPath,
 				"target":   target,
 			})
 		}
+		if rel.Type == userShapesRelType {
+			drawings = append(drawings, target)
+		}
 	}
+	sort.Strings(drawings)
 
-	return nil
+	return drawings, nil
+}
+
+// matchRelTarget looks for target among entries when no part has its exact
+// name. A directory holding parts matches as itself; otherwise the first
+// part whose name differs from target only in case is returned.
+func matchRelTarget(entries []string, target string) (string, bool) {
+	sorted := append([]string(nil), entries...)
+	sort.Strings(sorted)
+	for _, entry := range sorted {
+		if strings.HasPrefix(entry, target+"/") {
+			return target, true
+		}
+	}
+	for _, entry := range sorted {
+		if strings.EqualFold(entry, target) {
+			return entry, true
+		}
+	}
+	return "", false
 }
 
 const (
@@ -353,7 +535,12 @@ type cacheState struct {
 	valueBuf      strings.Builder
 	hasValueError bool
 	inArea        bool
+	inDoughnut    bool
 	values        []string
+	// level counts the c:lvl elements of a multiLvlStrCache read so far.
+	// The first holds the innermost labels, one per point; the outer ones
+	// only have a point where a group starts.
+	level int
 }
 
 func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overlaystage.StagingOverlay, chartPath string) error {
@@ -379,6 +566,10 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 	areaSeries := make(map[int]struct{})
 	areaCategories := make(map[int][]string)
 	areaValueCounts := make(map[int]int)
+	doughnutDepth := 0
+	doughnutSeries := make(map[int]struct{})
+	doughnutCategoryCounts := make(map[int]int)
+	doughnutValueCounts := make(map[int]int)
 	hasBarSeries := false
 	hasLineSeries := false
 	mixedSeries := make(map[int]struct{})
@@ -390,6 +581,9 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 	var cache *cacheState
 
 	for {
+		if err := ctx.Cancel.Err(); err != nil {
+			return err
+		}
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
@@ -405,10 +599,13 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 			switch tok.Name.Local {
 			case "barChart":
 				barDepth++
-			case "lineChart":
+			case "lineChart", "stockChart":
 				lineDepth++
-			case "pieChart":
+			case "pieChart", "doughnutChart":
 				pieDepth++
+				if tok.Name.Local == "doughnutChart" {
+					doughnutDepth++
+				}
 			case "areaChart":
 				areaDepth++
 			case "ser":
@@ -432,6 +629,9 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 					if areaDepth > 0 {
 						areaSeries[currentSeries] = struct{}{}
 					}
+					if doughnutDepth > 0 {
+						doughnutSeries[currentSeries] = struct{}{}
+					}
 				}
 				serDepth++
 			case "cat":
@@ -446,9 +646,9 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 				if serDepth > 0 {
 					txDepth++
 				}
-			case "strCache", "numCache":
+			case "strCache", "numCache", "dlblRangeCache", "multiLvlStrCache":
 				role := ""
-				if tok.Name.Local == "strCache" {
+				if tok.Name.Local == "strCache" || tok.Name.Local == "multiLvlStrCache" {
 					if catDepth > 0 {
 						role = "categories"
 					} else if txDepth > 0 {
@@ -458,6 +658,8 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 					if valDepth > 0 {
 						role = "values"
 					}
+				} else if serDepth > 0 {
+					role = "dataLabels"
 				}
 				cache = &cacheState{
 					kind:        tok.Name.Local,
@@ -466,6 +668,7 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 					plotType:    currentPlotType,
 					ptIdx:       make(map[int]struct{}),
 					inArea:      areaDepth > 0,
+					inDoughnut:  doughnutDepth > 0,
 				}
 			case "ptCount":
 				if cache != nil {
@@ -483,14 +686,24 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 						return v.cacheError(ctx, chartPath, cache, fmt.Errorf("missing ptCount"))
 					}
 				}
+			case "lvl":
+				if cache != nil && cache.kind == "multiLvlStrCache" {
+					cache.level++
+				}
 			case "pt":
 				if cache != nil {
 					idx, err := readPtIndex(tok.Attr)
 					if err != nil {
 						return v.cacheError(ctx, chartPath, cache, err)
 					}
-					cache.ptIdx[idx] = struct{}{}
-					cache.ptTotal++
+					if cache.level > 1 {
+						if idx >= cache.ptCount {
+							return v.cacheError(ctx, chartPath, cache, fmt.Errorf("pt idx %d out of range for ptCount %d", idx, cache.ptCount))
+						}
+					} else {
+						cache.ptIdx[idx] = struct{}{}
+						cache.ptTotal++
+					}
 					cache.inPt = true
 					cache.ptHasValue = false
 					cache.ptValue = ""
@@ -507,14 +720,17 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 				if barDepth > 0 {
 					barDepth--
 				}
-			case "lineChart":
+			case "lineChart", "stockChart":
 				if lineDepth > 0 {
 					lineDepth--
 				}
-			case "pieChart":
+			case "pieChart", "doughnutChart":
 				if pieDepth > 0 {
 					pieDepth--
 				}
+				if tok.Name.Local == "doughnutChart" && doughnutDepth > 0 {
+					doughnutDepth--
+				}
 			case "areaChart":
 				if areaDepth > 0 {
 					areaDepth--
@@ -550,11 +766,11 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 				}
 			case "pt":
 				if cache != nil && cache.inPt {
-					if cache.kind == "strCache" {
+					if cache.kind == "strCache" || cache.kind == "multiLvlStrCache" {
 						if !cache.ptHasValue {
-							return v.cacheError(ctx, chartPath, cache, fmt.Errorf("missing strCache value"))
+							return v.cacheError(ctx, chartPath, cache, fmt.Errorf("missing %s value", cache.kind))
 						}
-						if cache.role == "categories" && (cache.inArea || cache.plotType != "") {
+						if cache.role == "categories" && cache.level <= 1 && (cache.inArea || cache.plotType != "") {
 							cache.values = append(cache.values, cache.ptValue)
 						}
 					} else if cache.kind == "numCache" {
@@ -566,7 +782,7 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 					cache.ptHasValue = false
 					cache.ptValue = ""
 				}
-			case "strCache", "numCache":
+			case "strCache", "numCache", "dlblRangeCache", "multiLvlStrCache":
 				if cache != nil {
 					if !cache.ptCountSeen {
 						return v.cacheError(ctx, chartPath, cache, fmt.Errorf("missing ptCount"))
@@ -584,6 +800,13 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 							areaValueCounts[cache.seriesIndex] = cache.ptCount
 						}
 					}
+					if cache.inDoughnut {
+						if cache.role == "categories" {
+							doughnutCategoryCounts[cache.seriesIndex] = cache.ptCount
+						} else if cache.role == "values" {
+							doughnutValueCounts[cache.seriesIndex] = cache.ptCount
+						}
+					}
 					if cache.plotType != "" {
 						if cache.role == "categories" {
 							mixedCategories[cache.seriesIndex] = append([]string(nil), cache.values...)
@@ -636,6 +859,20 @@ func (v *PostflightValidator) checkChartCaches(ctx ValidateContext, stage *overl
 		}
 	}
 
+	// The rings of a doughnut chart share its categories, so a ring caching
+	// another number of points than the first was left stale by a write.
+	// Labels may differ: a partial update syncs only the rings it wrote.
+	if len(doughnutSeries) > 1 {
+		seriesKeys := sortedSeries(doughnutSeries)
+		baseCats := doughnutCategoryCounts[seriesKeys[0]]
+		baseCount := doughnutValueCounts[seriesKeys[0]]
+		for _, idx := range seriesKeys[1:] {
+			if doughnutCategoryCounts[idx] != baseCats || doughnutValueCounts[idx] != baseCount {
+				return v.cacheError(ctx, chartPath, &cacheState{seriesIndex: idx}, fmt.Errorf("doughnut chart ptCount mismatch across rings"))
+			}
+		}
+	}
+
 	if hasBarSeries && hasLineSeries {
 		if len(mixedCategories) != len(mixedSeries) {
 			return v.mixedCacheError(ctx, chartPath, -1, fmt.Errorf("missing categories cache for mixed chart"))
@@ -713,7 +950,7 @@ func (v *PostflightValidator) mixedCacheError(ctx ValidateContext, chartPath str
 }
 
 func (v *PostflightValidator) checkMixedAxisGroups(ctx ValidateContext, chartPath string, data []byte) error {
-	parsed, err := chartxml.ParseMixed(bytes.NewReader(data))
+	parsed, err := chartxml.ParseMixedWithCancel(bytes.NewReader(data), ctx.Cancel)
 	if err != nil {
 		return v.wrapError("POSTFLIGHT_MIX_SECONDARY_AXIS_INVALID", errwrap.WrapOp("postflight: mixed-axis", err), ctx, map[string]string{
 			"partPath": chartPath,
@@ -853,9 +1090,21 @@ func validateNumericValue(value string, policy int) error {
 	return fmt.Errorf("invalid numeric cache value %q", trimmed)
 }
 
-func validateXML(data []byte) error {
+func readZipPart(part *zip.File) ([]byte, error) {
+	rc, err := part.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func validateXML(data []byte, cancel *xmlcancel.Flag) error {
 	decoder := xml.NewDecoder(bytes.NewReader(data))
 	for {
+		if err := cancel.Err(); err != nil {
+			return err
+		}
 		_, err := decoder.Token()
 		if err == io.EOF {
 			return nil
@@ -866,16 +1115,6 @@ func validateXML(data []byte) error {
 	}
 }
 
-func resolveRelTarget(basePart, relTarget string) string {
-	if relTarget == "" {
-		return ""
-	}
-	if strings.HasPrefix(relTarget, "/") {
-		return strings.TrimLeft(relTarget, "/")
-	}
-	return rels.ResolveTarget(basePart, relTarget)
-}
-
 func chartRelsPath(chartPath string) string {
 	return path.Join(path.Dir(chartPath), "_rels", path.Base(chartPath)+".rels")
 }
@@ -901,6 +1140,15 @@ func equalStrings(a, b []string) bool {
 	return true
 }
 
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *PostflightValidator) hasBaseline(path string) (bool, error) {
 	if checker, ok := v.overlay.(overlaystage.BaselineChecker); ok {
 		return checker.HasBaseline(path)
@@ -909,14 +1157,28 @@ func (v *PostflightValidator) hasBaseline(path string) (bool, error) {
 }
 
 func (v *PostflightValidator) wrapError(code string, err error, ctx ValidateContext, extra map[string]string) error {
+	if errors.Is(err, xmlcancel.ErrCanceled) {
+		return err
+	}
 	v.emitAlert(code, messageForCode(code), ctx, extra)
 	return &Error{Code: code, Err: err}
 }
 
 func (v *PostflightValidator) emitAlert(code, message string, ctx ValidateContext, extra map[string]string) {
-	if v.doc == nil {
+	if v.doc == nil || v.doc.EmitAlert == nil {
 		return
 	}
+	v.doc.EmitAlert(code, message, alertContext(ctx, extra))
+}
+
+func (v *PostflightValidator) emitWarning(code string, ctx ValidateContext, extra map[string]string) {
+	if v.doc == nil || v.doc.EmitWarning == nil {
+		return
+	}
+	v.doc.EmitWarning(code, messageForCode(code), alertContext(ctx, extra))
+}
+
+func alertContext(ctx ValidateContext, extra map[string]string) map[string]string {
 	out := make(map[string]string, 6+len(extra))
 	if ctx.ChartPath != "" {
 		out["chartPath"] = ctx.ChartPath
@@ -934,10 +1196,7 @@ func (v *PostflightValidator) emitAlert(code, message string, ctx ValidateContex
 	for key, value := range extra {
 		out[key] = value
 	}
-	if v.doc.EmitAlert == nil {
-		return
-	}
-	v.doc.EmitAlert(code, message, out)
+	return out
 }
 
 func messageForCode(code string) string {
@@ -950,6 +1209,8 @@ func messageForCode(code string) string {
 		return "Embedded workbook contains sharedStrings.xml"
 	case "POSTFLIGHT_REL_TARGET_MISSING":
 		return "Relationship target missing after chart update"
+	case "POSTFLIGHT_REL_TARGET_CASE_MISMATCH":
+		return "Relationship target matches a part only when case is ignored"
 	case "POSTFLIGHT_CHART_CACHE_INVALID":
 		return "Chart cache validation failed"
 	case "POSTFLIGHT_XLSX_CELL_TYPE_MISMATCH":
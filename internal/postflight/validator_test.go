@@ -3,6 +3,9 @@ package postflight
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"why-pptx/internal/overlaystage"
@@ -22,6 +25,7 @@ func newValidator(parent overlaystage.Overlay, alerts *[]alertRecord) *Postfligh
 			}
 		},
 	}
+	doc.EmitWarning = doc.EmitAlert
 	return NewPostflightValidator(doc)
 }
 
@@ -167,6 +171,42 @@ func TestPostflightRelTargetMissing(t *testing.T) {
 	}
 }
 
+func TestValidateChartUntouchedCollectsFailures(t *testing.T) {
+	parent := newMemOverlay(map[string][]byte{
+		"ppt/charts/chart1.xml": []byte("<c:chartSpace><broken"),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="missing.png"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildXLSXWithSharedStringCell(t),
+	})
+	var alerts []alertRecord
+	validator := newValidator(parent, &alerts)
+	stage := overlaystage.NewStagingOverlay(parent)
+
+	ctx := ValidateContext{ChartPath: "ppt/charts/chart1.xml", WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Mode: ModeBestEffort, CacheSyncEnabled: true}
+	failures, err := validator.ValidateChart(ctx, stage)
+	if err != nil {
+		t.Fatalf("ValidateChart: %v", err)
+	}
+	workbookFailures, err := validator.ValidateWorkbook(ctx, stage)
+	if err != nil {
+		t.Fatalf("ValidateWorkbook: %v", err)
+	}
+	failures = append(failures, workbookFailures...)
+
+	want := []string{"POSTFLIGHT_XML_MALFORMED", "POSTFLIGHT_REL_TARGET_MISSING", "POSTFLIGHT_XLSX_CELL_TYPE_MISMATCH"}
+	if len(failures) != len(want) || len(alerts) != len(want) {
+		t.Fatalf("expected %d failures, got %v and alerts %#v", len(want), failures, alerts)
+	}
+	for i, code := range want {
+		var failure *Error
+		if !errors.As(failures[i], &failure) || failure.Code != code || alerts[i].code != code {
+			t.Fatalf("failure %d: expected %s, got %v and alert %#v", i, code, failures[i], alerts[i])
+		}
+	}
+}
+
 func TestPostflightRelTargetUsesStageView(t *testing.T) {
 	parent := newMemOverlay(map[string][]byte{
 		"ppt/charts/chart1.xml": []byte("<c:chartSpace></c:chartSpace>"),
@@ -192,6 +232,65 @@ func TestPostflightRelTargetUsesStageView(t *testing.T) {
 	}
 }
 
+func TestPostflightRelTargetNormalized(t *testing.T) {
+	parent := newMemOverlay(map[string][]byte{
+		"ppt/charts/chart1.xml": []byte("<c:chartSpace></c:chartSpace>"),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/oleObject" Target="../embeddings/OleObject1.bin"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="../media/image%201.png"/>
+  <Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="../media/"/>
+  <Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="#slide3"/>
+  <Relationship Id="rId5" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target=""/>
+</Relationships>`),
+		"ppt/embeddings/oleObject1.bin": []byte("data"),
+		"ppt/media/image 1.png":         []byte("data"),
+	})
+	var alerts []alertRecord
+	validator := newValidator(parent, &alerts)
+	stage := overlaystage.NewStagingOverlay(parent)
+
+	ctx := ValidateContext{ChartPath: "ppt/charts/chart1.xml", Mode: ModeStrict}
+	if err := validator.checkRelationshipTargets(ctx, stage, "ppt/charts/chart1.xml"); err != nil {
+		t.Fatalf("expected rel target check to pass, got %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].code != "POSTFLIGHT_REL_TARGET_CASE_MISMATCH" {
+		t.Fatalf("expected one POSTFLIGHT_REL_TARGET_CASE_MISMATCH alert, got %#v", alerts)
+	}
+	if alerts[0].ctx["target"] != "ppt/embeddings/OleObject1.bin" || alerts[0].ctx["matchedPart"] != "ppt/embeddings/oleObject1.bin" {
+		t.Fatalf("unexpected alert context: %#v", alerts[0].ctx)
+	}
+}
+
+func TestPostflightRelTargetMissingUnderUserShapes(t *testing.T) {
+	parent := newMemOverlay(map[string][]byte{
+		"ppt/charts/chart1.xml": []byte("<c:chartSpace></c:chartSpace>"),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chartUserShapes" Target="../drawings/drawing1.xml"/>
+</Relationships>`),
+		"ppt/drawings/drawing1.xml": []byte("<c:userShapes/>"),
+		"ppt/drawings/_rels/drawing1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="../media/image9.png"/>
+</Relationships>`),
+	})
+	var alerts []alertRecord
+	validator := newValidator(parent, &alerts)
+	stage := overlaystage.NewStagingOverlay(parent)
+
+	ctx := ValidateContext{ChartPath: "ppt/charts/chart1.xml", Mode: ModeStrict}
+	if err := validator.checkRelationshipTargets(ctx, stage, "ppt/charts/chart1.xml"); err == nil {
+		t.Fatalf("expected missing drawing rel target to fail")
+	}
+	if len(alerts) != 1 || alerts[0].code != "POSTFLIGHT_REL_TARGET_MISSING" {
+		t.Fatalf("expected POSTFLIGHT_REL_TARGET_MISSING alert, got %#v", alerts)
+	}
+	if alerts[0].ctx["target"] != "ppt/media/image9.png" {
+		t.Fatalf("expected drawing target in context, got %#v", alerts[0].ctx)
+	}
+}
+
 func TestPostflightUnexpectedPartAdded(t *testing.T) {
 	parent := newMemOverlay(map[string][]byte{
 		"ppt/charts/chart1.xml": []byte("<c:chartSpace></c:chartSpace>"),
@@ -214,6 +313,27 @@ func TestPostflightUnexpectedPartAdded(t *testing.T) {
 	}
 }
 
+func TestPostflightAllowedNewPart(t *testing.T) {
+	parent := newMemOverlay(map[string][]byte{
+		"ppt/charts/chart1.xml": []byte("<c:chartSpace></c:chartSpace>"),
+	})
+	var alerts []alertRecord
+	validator := newValidator(parent, &alerts)
+	stage := overlaystage.NewStagingOverlay(parent)
+
+	if err := stage.Set("docProps/custom.json", []byte("{}")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	ctx := ValidateContext{Mode: ModeStrict, AllowedNewParts: []string{"docProps/custom.json"}}
+	if err := validator.ValidateChartStage(ctx, stage); err != nil {
+		t.Fatalf("expected allowed part to pass, got %v", err)
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("unexpected alerts: %#v", alerts)
+	}
+}
+
 func TestPostflightChartCachePtCountMismatch(t *testing.T) {
 	chartXML := []byte(`<?xml version="1.0" encoding="UTF-8"?>
 <c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
@@ -254,6 +374,113 @@ func TestPostflightChartCachePtCountMismatch(t *testing.T) {
 	}
 }
 
+func TestPostflightDataLabelRangeCachePtCountMismatch(t *testing.T) {
+	chartXML := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:c15="http://schemas.microsoft.com/office/drawing/2012/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:val>
+            <c:numRef>
+              <c:numCache>
+                <c:ptCount val="2"/>
+                <c:pt idx="0"><c:v>1</c:v></c:pt>
+                <c:pt idx="1"><c:v>2</c:v></c:pt>
+              </c:numCache>
+            </c:numRef>
+          </c:val>
+          <c:extLst>
+            <c:ext uri="{02D57815-91ED-43cb-92C2-25804820EDAC}">
+              <c15:datalabelsRange>
+                <c15:dlblRangeCache>
+                  <c:ptCount val="2"/>
+                  <c:pt idx="0"><c:v>low</c:v></c:pt>
+                </c15:dlblRangeCache>
+              </c15:datalabelsRange>
+            </c:ext>
+          </c:extLst>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`)
+
+	parent := newMemOverlay(map[string][]byte{
+		"ppt/charts/chart1.xml": chartXML,
+	})
+	var alerts []alertRecord
+	validator := newValidator(parent, &alerts)
+	stage := overlaystage.NewStagingOverlay(parent)
+	if err := stage.Set("ppt/charts/chart1.xml", chartXML); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	ctx := ValidateContext{ChartPath: "ppt/charts/chart1.xml", Mode: ModeStrict, CacheSyncEnabled: true}
+	if err := validator.ValidateChartStage(ctx, stage); err == nil {
+		t.Fatalf("expected data label cache error")
+	}
+	if len(alerts) != 1 || alerts[0].code != "POSTFLIGHT_CHART_CACHE_INVALID" || alerts[0].ctx["seriesIndex"] != "0" {
+		t.Fatalf("expected POSTFLIGHT_CHART_CACHE_INVALID alert, got %#v", alerts)
+	}
+}
+
+func TestPostflightMultiLevelCategoryCache(t *testing.T) {
+	chart := func(outerIdx string) []byte {
+		return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat>
+            <c:multiLvlStrRef>
+              <c:multiLvlStrCache>
+                <c:ptCount val="2"/>
+                <c:lvl><c:pt idx="0"><c:v>H1</c:v></c:pt><c:pt idx="1"><c:v>H2</c:v></c:pt></c:lvl>
+                <c:lvl><c:pt idx="` + outerIdx + `"><c:v>2024</c:v></c:pt></c:lvl>
+              </c:multiLvlStrCache>
+            </c:multiLvlStrRef>
+          </c:cat>
+          <c:val>
+            <c:numRef>
+              <c:numCache>
+                <c:ptCount val="2"/>
+                <c:pt idx="0"><c:v>1</c:v></c:pt>
+                <c:pt idx="1"><c:v>2</c:v></c:pt>
+              </c:numCache>
+            </c:numRef>
+          </c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`)
+	}
+
+	for _, tc := range []struct {
+		outerIdx string
+		ok       bool
+	}{{"0", true}, {"2", false}} {
+		chartXML := chart(tc.outerIdx)
+		parent := newMemOverlay(map[string][]byte{
+			"ppt/charts/chart1.xml": chartXML,
+		})
+		var alerts []alertRecord
+		validator := newValidator(parent, &alerts)
+		stage := overlaystage.NewStagingOverlay(parent)
+		if err := stage.Set("ppt/charts/chart1.xml", chartXML); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+
+		ctx := ValidateContext{ChartPath: "ppt/charts/chart1.xml", Mode: ModeStrict, CacheSyncEnabled: true}
+		err := validator.ValidateChartStage(ctx, stage)
+		if (err == nil) != tc.ok {
+			t.Fatalf("outer idx %s: expected ok=%v, got %v (alerts %#v)", tc.outerIdx, tc.ok, err, alerts)
+		}
+	}
+}
+
 func TestPostflightChartCacheIdxGap(t *testing.T) {
 	chartXML := []byte(`<?xml version="1.0" encoding="UTF-8"?>
 <c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
@@ -481,6 +708,56 @@ func TestPostflightAreaCachePtCountMismatch(t *testing.T) {
 	}
 }
 
+func TestPostflightDoughnutCacheRingsMismatch(t *testing.T) {
+	ring := func(cats ...string) string {
+		var catPts, valPts strings.Builder
+		for i, cat := range cats {
+			fmt.Fprintf(&catPts, `<c:pt idx="%d"><c:v>%s</c:v></c:pt>`, i, cat)
+			fmt.Fprintf(&valPts, `<c:pt idx="%d"><c:v>%d</c:v></c:pt>`, i, i+1)
+		}
+		return fmt.Sprintf(`<c:ser><c:cat><c:strRef><c:strCache><c:ptCount val="%d"/>%s</c:strCache></c:strRef></c:cat><c:val><c:numRef><c:numCache><c:ptCount val="%d"/>%s</c:numCache></c:numRef></c:val></c:ser>`, len(cats), catPts.String(), len(cats), valPts.String())
+	}
+	cases := []struct {
+		name  string
+		rings []string
+		valid bool
+	}{
+		{name: "matching", rings: []string{ring("A", "B"), ring("A", "B")}, valid: true},
+		{name: "labels", rings: []string{ring("A", "B"), ring("A", "C")}, valid: true},
+		{name: "ptCount", rings: []string{ring("A", "B", "C"), ring("A", "B")}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			chartXML := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart><c:plotArea><c:doughnutChart>` + strings.Join(tc.rings, "") + `<c:holeSize val="50"/></c:doughnutChart></c:plotArea></c:chart></c:chartSpace>`)
+			parent := newMemOverlay(map[string][]byte{
+				"ppt/charts/chart1.xml": chartXML,
+			})
+			var alerts []alertRecord
+			validator := newValidator(parent, &alerts)
+			stage := overlaystage.NewStagingOverlay(parent)
+			if err := stage.Set("ppt/charts/chart1.xml", chartXML); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+
+			ctx := ValidateContext{ChartPath: "ppt/charts/chart1.xml", Mode: ModeStrict, CacheSyncEnabled: true}
+			err := validator.ValidateChartStage(ctx, stage)
+			if tc.valid {
+				if err != nil {
+					t.Fatalf("expected matching rings to pass, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected chart cache error")
+			}
+			if len(alerts) != 1 || alerts[0].code != "POSTFLIGHT_CHART_CACHE_INVALID" || alerts[0].ctx["seriesIndex"] != "1" {
+				t.Fatalf("expected POSTFLIGHT_CHART_CACHE_INVALID for ring 1, got %#v", alerts)
+			}
+		})
+	}
+}
+
 func TestPostflightWorksheetSharedStringCellType(t *testing.T) {
 	xlsx := buildXLSXWithSharedStringCell(t)
 	parent := newMemOverlay(map[string][]byte{
@@ -502,6 +779,146 @@ func TestPostflightWorksheetSharedStringCellType(t *testing.T) {
 	}
 }
 
+func TestPostflightSharedStringCellsChecksWrittenCellsOnly(t *testing.T) {
+	xlsx := buildXLSXFiles(t, map[string][]byte{
+		"xl/workbook.xml":            []byte(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Data" sheetId="1" r:id="rId1"/></sheets></workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`),
+		"xl/sharedStrings.xml":       []byte(`<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>Name</t></si></sst>`),
+		"xl/worksheets/sheet1.xml":   []byte(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>New</t></is></c></row></sheetData></worksheet>`),
+	})
+	cases := []struct {
+		name    string
+		written map[string]map[string]struct{}
+		code    string
+	}{
+		{"untouched shared string", map[string]map[string]struct{}{"Data": {"B1": {}}}, ""},
+		{"written cell still shared", map[string]map[string]struct{}{"Data": {"A1": {}}}, "POSTFLIGHT_XLSX_CELL_TYPE_MISMATCH"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parent := newMemOverlay(map[string][]byte{
+				"ppt/embeddings/embeddedWorkbook1.xlsx": xlsx,
+			})
+			var alerts []alertRecord
+			validator := newValidator(parent, &alerts)
+			stage := overlaystage.NewStagingOverlay(parent)
+			if err := stage.Set("ppt/embeddings/embeddedWorkbook1.xlsx", xlsx); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+
+			ctx := ValidateContext{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Mode: ModeStrict, SharedStringCells: tc.written}
+			err := validator.ValidateChartStage(ctx, stage)
+			if tc.code == "" {
+				if err != nil || len(alerts) != 0 {
+					t.Fatalf("expected the stage to pass, got %v %#v", err, alerts)
+				}
+				return
+			}
+			if err == nil || len(alerts) != 1 || alerts[0].code != tc.code {
+				t.Fatalf("expected %s, got %v %#v", tc.code, err, alerts)
+			}
+			if alerts[0].ctx["cellRef"] != "A1" {
+				t.Fatalf("unexpected context: %#v", alerts[0].ctx)
+			}
+		})
+	}
+}
+
+func TestPostflightBoolAndErrorCellsPass(t *testing.T) {
+	xlsx := buildXLSXFiles(t, map[string][]byte{
+		"xl/workbook.xml":            []byte(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Data" sheetId="1" r:id="rId1"/></sheets></workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`),
+		"xl/worksheets/sheet1.xml":   []byte(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1"><c r="A1" t="b"><v>1</v></c><c r="B1" t="e"><f>1/0</f><v>#DIV/0!</v></c><c r="C1"><v>3</v></c></row></sheetData></worksheet>`),
+	})
+	parent := newMemOverlay(map[string][]byte{
+		"ppt/embeddings/embeddedWorkbook1.xlsx": xlsx,
+	})
+	var alerts []alertRecord
+	validator := newValidator(parent, &alerts)
+	stage := overlaystage.NewStagingOverlay(parent)
+	if err := stage.Set("ppt/embeddings/embeddedWorkbook1.xlsx", xlsx); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	ctx := ValidateContext{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Mode: ModeStrict}
+	if err := validator.ValidateChartStage(ctx, stage); err != nil || len(alerts) != 0 {
+		t.Fatalf("expected boolean and error cells to pass, got %v %#v", err, alerts)
+	}
+}
+
+func TestPostflightMalformedDocProps(t *testing.T) {
+	parent := newMemOverlay(map[string][]byte{
+		"docProps/core.xml": []byte(`<cp:coreProperties xmlns:cp="urn:cp"/>`),
+	})
+	var alerts []alertRecord
+	validator := newValidator(parent, &alerts)
+	stage := overlaystage.NewStagingOverlay(parent)
+	if err := stage.Set("docProps/core.xml", []byte(`<cp:coreProperties xmlns:cp="urn:cp"><cp:lastModifiedBy></cp:coreProperties>`)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := validator.ValidateChartStage(ValidateContext{Mode: ModeStrict}, stage); err == nil {
+		t.Fatalf("expected malformed core properties to fail")
+	}
+	if len(alerts) != 1 || alerts[0].code != "POSTFLIGHT_XML_MALFORMED" || alerts[0].ctx["partPath"] != "docProps/core.xml" {
+		t.Fatalf("expected POSTFLIGHT_XML_MALFORMED, got %#v", alerts)
+	}
+}
+
+func TestPostflightMalformedWorkbookXML(t *testing.T) {
+	cases := map[string]map[string][]byte{
+		"worksheet control character": {
+			"xl/worksheets/sheet1.xml": []byte("<worksheet><sheetData><row r=\"1\"><c r=\"A1\" t=\"inlineStr\"><is><t>a\x0Bb</t></is></c></row></sheetData></worksheet>"),
+		},
+		"unclosed workbook part": {
+			"xl/workbook.xml":          []byte(`<workbook><sheets><sheet name="Sheet1" sheetId="1"/></workbook>`),
+			"xl/worksheets/sheet1.xml": []byte(`<worksheet><sheetData/></worksheet>`),
+		},
+	}
+	for name, files := range cases {
+		t.Run(name, func(t *testing.T) {
+			xlsx := buildXLSXFiles(t, files)
+			parent := newMemOverlay(map[string][]byte{
+				"ppt/embeddings/embeddedWorkbook1.xlsx": xlsx,
+			})
+			var alerts []alertRecord
+			validator := newValidator(parent, &alerts)
+			stage := overlaystage.NewStagingOverlay(parent)
+			if err := stage.Set("ppt/embeddings/embeddedWorkbook1.xlsx", xlsx); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+
+			ctx := ValidateContext{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Mode: ModeStrict}
+			if err := validator.ValidateChartStage(ctx, stage); err == nil {
+				t.Fatalf("expected malformed xml error")
+			}
+			if len(alerts) != 1 || alerts[0].code != "POSTFLIGHT_XML_MALFORMED" {
+				t.Fatalf("expected POSTFLIGHT_XML_MALFORMED alert, got %#v", alerts)
+			}
+		})
+	}
+}
+
+func buildXLSXFiles(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, data := range files {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := entry.Write(data); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
 func buildXLSXWithSharedStrings(t *testing.T) []byte {
 	t.Helper()
 
@@ -0,0 +1,258 @@
+package rels
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Editor is an editable view of one rels part. Serialize copies every byte it
+// was not asked to change, so the declaration, the namespace bindings, and the
+// order, attributes, and formatting of untouched relationships come through
+// exactly as loaded.
+type Editor struct {
+	data    []byte
+	entries []relEntry
+	added   []Relationship
+	// retired holds ids removed through this Editor. AllocateID never hands
+	// them out again, so a part edited in one session does not reuse an rId
+	// for a different target.
+	retired map[string]struct{}
+
+	// elemName is the element name for added relationships, carrying the
+	// root's prefix if it has one.
+	elemName string
+	// insertAt is where added relationships go: after the last loaded
+	// relationship, or before the root's closing tag.
+	insertAt int64
+	indent   []byte
+	// selfClosingAt is the offset of the "/>" ending an empty root written as
+	// <Relationships .../>, or -1.
+	selfClosingAt int64
+	closeTag      string
+}
+
+type relEntry struct {
+	rel     Relationship
+	start   int64
+	end     int64
+	removed bool
+}
+
+// Load parses a rels part for editing.
+func Load(data []byte) (*Editor, error) {
+	e := &Editor{data: data, retired: map[string]struct{}{}, selfClosingAt: -1}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	var current *relEntry
+	rootSeen := false
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse rels: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				if tok.Name.Local != "Relationships" {
+					return nil, fmt.Errorf("parse rels: unexpected root element %q", tok.Name.Local)
+				}
+				rootSeen = true
+				raw := rawName(data[offset:])
+				e.closeTag = "</" + raw + ">"
+				e.elemName = "Relationship"
+				if i := bytes.IndexByte([]byte(raw), ':'); i >= 0 {
+					e.elemName = raw[:i+1] + "Relationship"
+				}
+				end := decoder.InputOffset()
+				if bytes.HasSuffix(data[:end], []byte("/>")) {
+					e.selfClosingAt = end - 2
+				}
+				continue
+			}
+			if depth == 2 && tok.Name.Local == "Relationship" {
+				e.entries = append(e.entries, relEntry{rel: relationshipFrom(tok), start: offset})
+				current = &e.entries[len(e.entries)-1]
+			}
+		case xml.EndElement:
+			depth--
+			switch {
+			case depth == 1 && current != nil:
+				current.end = decoder.InputOffset()
+				current = nil
+			case depth == 0 && e.selfClosingAt < 0:
+				e.insertAt = offset
+			}
+		}
+	}
+	if !rootSeen {
+		return nil, fmt.Errorf("parse rels: missing Relationships element")
+	}
+
+	if n := len(e.entries); n > 0 {
+		last := e.entries[n-1]
+		e.insertAt = last.end
+		e.indent = data[leadingSpace(data, last.start):last.start]
+	}
+	return e, nil
+}
+
+// Relationships returns the live relationships: the loaded ones in document
+// order, then the added ones in the order they were added.
+func (e *Editor) Relationships() []Relationship {
+	out := make([]Relationship, 0, len(e.entries)+len(e.added))
+	for _, entry := range e.entries {
+		if !entry.removed {
+			out = append(out, entry.rel)
+		}
+	}
+	return append(out, e.added...)
+}
+
+// AddRelationship appends a relationship and returns the rId allocated for it.
+// targetMode is empty for internal targets.
+func (e *Editor) AddRelationship(relType, target, targetMode string) string {
+	id := e.AllocateID()
+	e.added = append(e.added, Relationship{ID: id, Type: relType, Target: target, TargetMode: targetMode})
+	return id
+}
+
+// AllocateID returns the smallest rIdN that is neither live nor removed
+// through this Editor.
+func (e *Editor) AllocateID() string {
+	taken := make(map[string]struct{}, len(e.entries)+len(e.added)+len(e.retired))
+	for _, rel := range e.Relationships() {
+		taken[rel.ID] = struct{}{}
+	}
+	for id := range e.retired {
+		taken[id] = struct{}{}
+	}
+	for n := 1; ; n++ {
+		candidate := "rId" + strconv.Itoa(n)
+		if _, ok := taken[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// RemoveRelationship drops the relationship with the given id. The id is
+// retired for the rest of the Editor's life.
+func (e *Editor) RemoveRelationship(id string) error {
+	for i := range e.entries {
+		if e.entries[i].rel.ID == id && !e.entries[i].removed {
+			e.entries[i].removed = true
+			e.retired[id] = struct{}{}
+			return nil
+		}
+	}
+	for i, rel := range e.added {
+		if rel.ID == id {
+			e.added = append(e.added[:i], e.added[i+1:]...)
+			e.retired[id] = struct{}{}
+			return nil
+		}
+	}
+	return fmt.Errorf("relationship %q not found", id)
+}
+
+// Serialize returns the edited part. With no edits it returns the loaded
+// bytes unchanged.
+func (e *Editor) Serialize() []byte {
+	var out bytes.Buffer
+	out.Grow(len(e.data) + 160*len(e.added))
+	last := int64(0)
+	for _, entry := range e.entries {
+		if !entry.removed {
+			continue
+		}
+		out.Write(e.data[last:lineStart(e.data, entry.start)])
+		last = entry.end
+	}
+
+	switch {
+	case len(e.added) == 0:
+	case e.selfClosingAt >= 0:
+		out.Write(e.data[last:e.selfClosingAt])
+		out.WriteByte('>')
+		for _, rel := range e.added {
+			e.writeRelationship(&out, rel)
+		}
+		out.WriteString(e.closeTag)
+		last = e.selfClosingAt + 2
+	default:
+		out.Write(e.data[last:e.insertAt])
+		for _, rel := range e.added {
+			out.Write(e.indent)
+			e.writeRelationship(&out, rel)
+		}
+		last = e.insertAt
+	}
+	out.Write(e.data[last:])
+	return out.Bytes()
+}
+
+func (e *Editor) writeRelationship(b *bytes.Buffer, rel Relationship) {
+	b.WriteString("<" + e.elemName + ` Id="`)
+	xml.EscapeText(b, []byte(rel.ID))
+	b.WriteString(`" Type="`)
+	xml.EscapeText(b, []byte(rel.Type))
+	b.WriteString(`" Target="`)
+	xml.EscapeText(b, []byte(rel.Target))
+	b.WriteString(`"`)
+	if rel.TargetMode != "" {
+		b.WriteString(` TargetMode="`)
+		xml.EscapeText(b, []byte(rel.TargetMode))
+		b.WriteString(`"`)
+	}
+	b.WriteString("/>")
+}
+
+// rawName returns the element name, prefix included, of the start tag at the
+// beginning of data.
+func rawName(data []byte) string {
+	end := bytes.IndexAny(data, " \t\r\n/>")
+	if end < 1 {
+		return "Relationships"
+	}
+	return string(data[1:end])
+}
+
+// leadingSpace returns the offset where the whitespace run ending at offset
+// starts.
+func leadingSpace(data []byte, offset int64) int64 {
+	i := offset
+	for i > 0 && isSpace(data[i-1]) {
+		i--
+	}
+	return i
+}
+
+// lineStart widens a removal starting at offset to take the indentation and
+// line break before it, so dropping a relationship leaves no blank line.
+func lineStart(data []byte, offset int64) int64 {
+	i := offset
+	for i > 0 && (data[i-1] == ' ' || data[i-1] == '\t') {
+		i--
+	}
+	if i > 0 && data[i-1] == '\n' {
+		i--
+		if i > 0 && data[i-1] == '\r' {
+			i--
+		}
+		return i
+	}
+	return offset
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
@@ -0,0 +1,154 @@
+package rels
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	chartRelType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+	imageRelType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+)
+
+func TestEditorAddIntoEmptyRels(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		want string
+	}{
+		"open and close": {
+			in:   `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"></Relationships>`,
+			want: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="` + chartRelType + `" Target="../charts/chart1.xml"/></Relationships>`,
+		},
+		"self-closing": {
+			in:   `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"/>`,
+			want: `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="` + chartRelType + `" Target="../charts/chart1.xml"/></Relationships>`,
+		},
+		"prefixed": {
+			in:   `<pr:Relationships xmlns:pr="http://schemas.openxmlformats.org/package/2006/relationships"></pr:Relationships>`,
+			want: `<pr:Relationships xmlns:pr="http://schemas.openxmlformats.org/package/2006/relationships"><pr:Relationship Id="rId1" Type="` + chartRelType + `" Target="../charts/chart1.xml"/></pr:Relationships>`,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			editor, err := Load([]byte(tc.in))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if id := editor.AddRelationship(chartRelType, "../charts/chart1.xml", ""); id != "rId1" {
+				t.Fatalf("unexpected id %q", id)
+			}
+			if got := string(editor.Serialize()); got != tc.want {
+				t.Fatalf("unexpected output:\n%s", got)
+			}
+			if _, err := Parse(strings.NewReader(tc.want)); err != nil {
+				t.Fatalf("output does not parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestEditorAllocatesIntoGaps(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="` + chartRelType + `" Target="../charts/chart1.xml"/>
+  <Relationship Id="rId5" Type="` + imageRelType + `" Target="https://example.com/a.png" TargetMode="External"/>
+</Relationships>`
+
+	editor, err := Load([]byte(in))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	first := editor.AddRelationship(chartRelType, "../charts/chart2.xml", "")
+	second := editor.AddRelationship(imageRelType, "https://example.com/b&c.png", "External")
+	if first != "rId2" || second != "rId3" {
+		t.Fatalf("unexpected ids %q, %q", first, second)
+	}
+
+	want := strings.Replace(in, `TargetMode="External"/>`, `TargetMode="External"/>
+  <Relationship Id="rId2" Type="`+chartRelType+`" Target="../charts/chart2.xml"/>
+  <Relationship Id="rId3" Type="`+imageRelType+`" Target="https://example.com/b&amp;c.png" TargetMode="External"/>`, 1)
+	if got := string(editor.Serialize()); got != want {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+}
+
+func TestEditorRemoveRetiresID(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="` + chartRelType + `" Target="../charts/chart1.xml"/>
+  <Relationship Id="rId2" Type="` + chartRelType + `" Target="../charts/chart2.xml"/>
+</Relationships>`
+
+	editor, err := Load([]byte(in))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := editor.RemoveRelationship("rId1"); err != nil {
+		t.Fatalf("RemoveRelationship: %v", err)
+	}
+	if err := editor.RemoveRelationship("rId1"); err == nil {
+		t.Fatalf("expected removing a missing relationship to fail")
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId2" Type="` + chartRelType + `" Target="../charts/chart2.xml"/>
+</Relationships>`
+	if got := string(editor.Serialize()); got != want {
+		t.Fatalf("unexpected output after remove:\n%s", got)
+	}
+
+	if id := editor.AddRelationship(chartRelType, "../charts/chart3.xml", ""); id != "rId3" {
+		t.Fatalf("removed id must not be reused, got %q", id)
+	}
+	if err := editor.RemoveRelationship("rId3"); err != nil {
+		t.Fatalf("RemoveRelationship added: %v", err)
+	}
+	if id := editor.AddRelationship(chartRelType, "../charts/chart3.xml", ""); id != "rId4" {
+		t.Fatalf("removed added id must not be reused, got %q", id)
+	}
+	rels := editor.Relationships()
+	if len(rels) != 2 || rels[0].ID != "rId2" || rels[1].ID != "rId4" {
+		t.Fatalf("unexpected live relationships: %#v", rels)
+	}
+}
+
+func TestEditorRoundTripKeepsUntouchedEntries(t *testing.T) {
+	// Attribute order, quoting, an unknown attribute, and a non-empty element
+	// must all survive, along with the declaration and namespace.
+	in := "<?xml version='1.0' encoding='UTF-8' standalone='yes'?>\r\n" +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships" xmlns:x="urn:x">` +
+		`<Relationship Target='../charts/chart1.xml' Id='rId7' Type='` + chartRelType + `' x:extra="1"/>` +
+		`<Relationship Id="rId2" Type="` + imageRelType + `" Target="../media/image1.png"></Relationship>` +
+		`</Relationships>`
+
+	editor, err := Load([]byte(in))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := string(editor.Serialize()); got != in {
+		t.Fatalf("unedited output differs:\n%s", got)
+	}
+
+	if err := editor.RemoveRelationship("rId2"); err != nil {
+		t.Fatalf("RemoveRelationship: %v", err)
+	}
+	id := editor.AddRelationship(imageRelType, "../media/image2.png", "")
+	if id != "rId1" {
+		t.Fatalf("unexpected id %q", id)
+	}
+	want := "<?xml version='1.0' encoding='UTF-8' standalone='yes'?>\r\n" +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships" xmlns:x="urn:x">` +
+		`<Relationship Target='../charts/chart1.xml' Id='rId7' Type='` + chartRelType + `' x:extra="1"/>` +
+		`<Relationship Id="rId1" Type="` + imageRelType + `" Target="../media/image2.png"/>` +
+		`</Relationships>`
+	if got := string(editor.Serialize()); got != want {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+}
+
+func TestLoadRejectsOtherRoots(t *testing.T) {
+	if _, err := Load([]byte(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`)); err == nil {
+		t.Fatalf("expected a non-rels root to be rejected")
+	}
+}
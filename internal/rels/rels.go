@@ -36,20 +36,7 @@ func Parse(r io.Reader) (*Rels, error) {
 			continue
 		}
 
-		rel := Relationship{}
-		for _, attr := range start.Attr {
-			switch strings.ToLower(attr.Name.Local) {
-			case "id":
-				rel.ID = attr.Value
-			case "type":
-				rel.Type = attr.Value
-			case "target":
-				rel.Target = attr.Value
-			case "targetmode":
-				rel.TargetMode = attr.Value
-			}
-		}
-
+		rel := relationshipFrom(start)
 		if rel.ID != "" {
 			out.ByID[rel.ID] = rel
 		}
@@ -58,6 +45,23 @@ func Parse(r io.Reader) (*Rels, error) {
 	return out, nil
 }
 
+func relationshipFrom(start xml.StartElement) Relationship {
+	rel := Relationship{}
+	for _, attr := range start.Attr {
+		switch strings.ToLower(attr.Name.Local) {
+		case "id":
+			rel.ID = attr.Value
+		case "type":
+			rel.Type = attr.Value
+		case "target":
+			rel.Target = attr.Value
+		case "targetmode":
+			rel.TargetMode = attr.Value
+		}
+	}
+	return rel
+}
+
 func (r *Rels) Resolve(id string) (Relationship, bool) {
 	if r == nil || r.ByID == nil {
 		return Relationship{}, false
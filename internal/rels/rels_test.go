@@ -51,3 +51,25 @@ func TestResolveTarget(t *testing.T) {
 		t.Fatalf("unexpected resolved path: %q", got)
 	}
 }
+
+func TestResolveTargetEncodedAndDotted(t *testing.T) {
+	cases := []struct {
+		base, target, want string
+	}{
+		{"ppt/slides/slide1.xml", "../media/image%201.png", "ppt/media/image 1.png"},
+		{"ppt/slides/slide1.xml", "./../charts/./chart1.xml", "ppt/charts/chart1.xml"},
+		{"ppt/slides/slide1.xml", "../charts/../embeddings/oleObject1.bin", "ppt/embeddings/oleObject1.bin"},
+		{"ppt/slides/slide1.xml", "/ppt/media/image1.png", "ppt/media/image1.png"},
+		{"ppt/slides/slide1.xml", "../media/", "ppt/media"},
+		{"ppt/slides/slide1.xml", "slide2.xml#rId3", "ppt/slides/slide2.xml"},
+		{"ppt/slides/slide1.xml", "#slide3", ""},
+		{"ppt/slides/slide1.xml", "", ""},
+		{"ppt/slides/slide1.xml", "../media/100%.png", "ppt/media/100%.png"},
+		{"", "ppt/presentation.xml", "ppt/presentation.xml"},
+	}
+	for _, tc := range cases {
+		if got := ResolveTarget(tc.base, tc.target); got != tc.want {
+			t.Errorf("ResolveTarget(%q, %q) = %q, want %q", tc.base, tc.target, got, tc.want)
+		}
+	}
+}
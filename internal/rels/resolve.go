@@ -1,19 +1,32 @@
 package rels
 
 import (
+	"net/url"
 	"path"
 	"strings"
 )
 
 // ResolveTarget resolves a relationship target against the base part path.
+// The target is percent-decoded ("image%201.png" names "image 1.png") and
+// any fragment is dropped; a target starting with "/" is relative to the
+// package root. An empty or fragment-only target resolves to "".
 // External targets (TargetMode="External") should be handled by the caller.
 func ResolveTarget(basePart string, relTarget string) string {
-	if relTarget == "" {
+	target := relTarget
+	if i := strings.IndexByte(target, '#'); i >= 0 {
+		target = target[:i]
+	}
+	if decoded, err := url.PathUnescape(target); err == nil {
+		target = decoded
+	}
+	if strings.TrimSpace(target) == "" {
 		return ""
 	}
 
-	cleanTarget := strings.TrimLeft(relTarget, "/")
-	joined := path.Join(path.Dir(basePart), cleanTarget)
+	joined := target
+	if !strings.HasPrefix(target, "/") {
+		joined = path.Join(path.Dir(basePart), target)
+	}
 	joined = path.Clean(joined)
 	return strings.TrimLeft(joined, "/")
 }
@@ -14,6 +14,7 @@ import (
 	"strings"
 	"testing"
 
+	"why-pptx/internal/chartxml"
 	"why-pptx/internal/rels"
 	"why-pptx/internal/xlref"
 )
@@ -32,6 +33,9 @@ type CacheSeries struct {
 
 type CacheSnapshot struct {
 	Series []CacheSeries
+	// HiddenLegendEntries lists, in order, the idx of each legendEntry
+	// marked deleted.
+	HiddenLegendEntries []int
 }
 
 type ExpectedCacheSeries struct {
@@ -299,6 +303,15 @@ func ExtractChartCacheSnapshot(chartXML []byte) (CacheSnapshot, error) {
 		}
 	}
 
+	info, err := chartxml.ParseInfo(bytes.NewReader(chartXML))
+	if err != nil {
+		return CacheSnapshot{}, err
+	}
+	for idx := range info.HiddenLegendEntries {
+		snapshot.HiddenLegendEntries = append(snapshot.HiddenLegendEntries, idx)
+	}
+	sort.Ints(snapshot.HiddenLegendEntries)
+
 	return snapshot, nil
 }
 
@@ -18,9 +18,15 @@ import (
 )
 
 type Snapshot struct {
-	Entries   []string           `json:"entries"`
-	Charts    []ChartSnapshot    `json:"charts"`
-	Workbooks []WorkbookSnapshot `json:"workbooks"`
+	SchemaVersion int                `json:"schemaVersion"`
+	Entries       []string           `json:"entries"`
+	Charts        []ChartSnapshot    `json:"charts"`
+	Workbooks     []WorkbookSnapshot `json:"workbooks"`
+
+	// StoredVersion is the schema version found on disk before migration.
+	StoredVersion int `json:"-"`
+	// Migrations lists the migrations LoadSnapshot applied, in order.
+	Migrations []string `json:"-"`
 }
 
 type ChartSnapshot struct {
@@ -29,6 +35,7 @@ type ChartSnapshot struct {
 	WorkbookPath string                `json:"workbookPath,omitempty"`
 	Plots        []PlotSnapshot        `json:"plots,omitempty"`
 	AxisGroups   []AxisGroupSnapshot   `json:"axisGroups,omitempty"`
+	SeriesCount  int                   `json:"seriesCount"`
 	Series       []ChartSeriesSnapshot `json:"series"`
 	Cache        CacheSnapshot         `json:"cache"`
 }
@@ -129,13 +136,24 @@ func BuildSnapshot(pptxPath string) (Snapshot, error) {
 				return Snapshot{}, err
 			}
 
+			axisBySeries := make(map[int]string, len(mixed.Series))
+			for _, series := range mixed.Series {
+				axisBySeries[series.Index] = series.Axis
+			}
 			snap.AxisGroups = axisGroupSnapshots(mixed.AxisGroups)
-			snap.Plots = plotSnapshots(mixed)
+			snap.Plots = plotSnapshots(mixed.Plots, axisBySeries)
 			snap.Series = mixedSeriesSnapshots(mixed.Series)
 		} else {
+			snap.AxisGroups = axisGroupSnapshots(parsed.AxisGroups)
+			snap.Plots = plotSnapshots(parsed.Plots, parsed.SeriesAxes)
 			snap.Series = seriesSnapshotsFromFormulas(parsed.Formulas)
+			for i := range snap.Series {
+				snap.Series[i].Axis = parsed.SeriesAxes[snap.Series[i].Index]
+			}
 		}
 
+		snap.SeriesCount = len(snap.Series)
+
 		cacheSnap, err := ExtractChartCacheSnapshot(chartXML)
 		if err != nil {
 			return Snapshot{}, err
@@ -151,20 +169,29 @@ func BuildSnapshot(pptxPath string) (Snapshot, error) {
 	}
 
 	return Snapshot{
-		Entries:   entries,
-		Charts:    charts,
-		Workbooks: workbooks,
+		SchemaVersion: CurrentSchemaVersion,
+		Entries:       entries,
+		Charts:        charts,
+		Workbooks:     workbooks,
 	}, nil
 }
 
+// WriteSnapshot stores snap in canonical form: current schema version,
+// stable ordering of every list, two-space indentation and a trailing newline,
+// so regenerating on different machines yields identical files.
 func WriteSnapshot(path string, snap Snapshot) error {
+	snap = canonicalSnapshot(snap)
+	snap.SchemaVersion = CurrentSchemaVersion
 	data, err := json.MarshalIndent(snap, "", "  ")
 	if err != nil {
 		return err
 	}
+	data = append(data, '\n')
 	return os.WriteFile(path, data, 0o644)
 }
 
+// LoadSnapshot reads a stored snapshot and upgrades it to CurrentSchemaVersion.
+// Snapshots without a schemaVersion field are treated as version 1.
 func LoadSnapshot(path string) (Snapshot, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -174,13 +201,33 @@ func LoadSnapshot(path string) (Snapshot, error) {
 	if err := json.Unmarshal(data, &snap); err != nil {
 		return Snapshot{}, err
 	}
+	if err := migrateSnapshot(&snap); err != nil {
+		return Snapshot{}, fmt.Errorf("%s: %w", path, err)
+	}
 	return snap, nil
 }
 
-func AssertSnapshotEqual(t *testing.T, got, want Snapshot) {
+type SnapshotOpt func(*snapshotOptions)
+
+type snapshotOptions struct {
+	ignoreNewerFields bool
+}
+
+// IgnoreFieldsNewerThanStored compares only the fields known to the schema
+// version the expected snapshot was stored with. Fields added by later schema
+// versions are cleared on both sides before comparison, so goldens keep
+// passing until they are regenerated.
+func IgnoreFieldsNewerThanStored() SnapshotOpt {
+	return func(opts *snapshotOptions) {
+		opts.ignoreNewerFields = true
+	}
+}
+
+func AssertSnapshotEqual(t *testing.T, got, want Snapshot, opts ...SnapshotOpt) {
 	t.Helper()
 
-	if snapshotsEqual(got, want) {
+	got, want, ok := compareSnapshots(got, want, opts...)
+	if ok {
 		return
 	}
 
@@ -189,6 +236,29 @@ func AssertSnapshotEqual(t *testing.T, got, want Snapshot) {
 	t.Fatalf("snapshot mismatch\n--- got ---\n%s\n--- want ---\n%s", string(gotJSON), string(wantJSON))
 }
 
+// compareSnapshots returns the normalized snapshots that were compared and
+// whether they are equal.
+func compareSnapshots(got, want Snapshot, opts ...SnapshotOpt) (Snapshot, Snapshot, bool) {
+	var options snapshotOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+
+	got = canonicalSnapshot(got)
+	want = canonicalSnapshot(want)
+	if options.ignoreNewerFields {
+		stored := want.StoredVersion
+		if stored == 0 {
+			stored = want.SchemaVersion
+		}
+		got = stripFieldsNewerThan(got, stored)
+		want = stripFieldsNewerThan(want, stored)
+	}
+	return got, want, snapshotsEqual(got, want)
+}
+
 func snapshotsEqual(a, b Snapshot) bool {
 	return jsonEqual(a, b)
 }
@@ -236,18 +306,15 @@ func axisGroupSnapshots(groups []chartxml.AxisGroup) []AxisGroupSnapshot {
 	return out
 }
 
-func plotSnapshots(mixed *chartxml.MixedChart) []PlotSnapshot {
-	out := make([]PlotSnapshot, 0, len(mixed.Plots))
-	for _, plot := range mixed.Plots {
+// plotSnapshots takes each plot's axis role from the first of its series
+// with an entry in axisBySeries.
+func plotSnapshots(plots []chartxml.MixedPlot, axisBySeries map[int]string) []PlotSnapshot {
+	out := make([]PlotSnapshot, 0, len(plots))
+	for _, plot := range plots {
 		role := ""
 		for _, seriesIdx := range plot.SeriesIndices {
-			for _, series := range mixed.Series {
-				if series.Index == seriesIdx && series.Axis != "" {
-					role = series.Axis
-					break
-				}
-			}
-			if role != "" {
+			if axis := axisBySeries[seriesIdx]; axis != "" {
+				role = axis
 				break
 			}
 		}
@@ -328,6 +395,11 @@ func buildWorkbookSnapshots(reader *zip.Reader, charts []ChartSnapshot) ([]Workb
 				if err != nil {
 					return nil, fmt.Errorf("parse formula %q: %w", formula, err)
 				}
+				if ref.Whole != xlref.NotWhole {
+					// Sized by the sheet's used range, which the snapshot
+					// does not model.
+					continue
+				}
 				cells, err := cellsFromRange(ref.StartCell, ref.EndCell)
 				if err != nil {
 					return nil, fmt.Errorf("expand range %s:%s: %w", ref.StartCell, ref.EndCell, err)
@@ -0,0 +1,132 @@
+package pptxassert
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func v1GoldenPath() string {
+	return filepath.Join("..", "..", "..", "testdata", "golden", "schema_v1", "bar_simple_update.json")
+}
+
+func TestLoadSnapshotMigratesV1(t *testing.T) {
+	snap, err := LoadSnapshot(v1GoldenPath())
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if snap.SchemaVersion != CurrentSchemaVersion {
+		t.Fatalf("expected schema version %d, got %d", CurrentSchemaVersion, snap.SchemaVersion)
+	}
+	if snap.StoredVersion != 1 {
+		t.Fatalf("expected stored version 1, got %d", snap.StoredVersion)
+	}
+	if !reflect.DeepEqual(snap.Migrations, []string{"v1_to_v2_series_count"}) {
+		t.Fatalf("unexpected migrations: %v", snap.Migrations)
+	}
+	if len(snap.Charts) != 1 || snap.Charts[0].SeriesCount != 1 {
+		t.Fatalf("expected migrated series count 1, got %#v", snap.Charts)
+	}
+}
+
+func TestLoadSnapshotCurrentVersionRunsNoMigrations(t *testing.T) {
+	snap, err := LoadSnapshot(v1GoldenPath())
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snap.json")
+	if err := WriteSnapshot(path, snap); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+	reloaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if len(reloaded.Migrations) != 0 {
+		t.Fatalf("expected no migrations, got %v", reloaded.Migrations)
+	}
+	if reloaded.StoredVersion != CurrentSchemaVersion {
+		t.Fatalf("expected stored version %d, got %d", CurrentSchemaVersion, reloaded.StoredVersion)
+	}
+	if _, _, ok := compareSnapshots(reloaded, snap); !ok {
+		t.Fatalf("expected round-tripped snapshot to match")
+	}
+}
+
+func TestLoadSnapshotRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.json")
+	if err := os.WriteFile(path, []byte(`{"schemaVersion": 99, "entries": []}`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadSnapshot(path); err == nil {
+		t.Fatalf("expected error for future schema version")
+	}
+}
+
+func TestCompareSnapshotsIgnoreNewerFields(t *testing.T) {
+	want, err := LoadSnapshot(v1GoldenPath())
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+
+	got := canonicalSnapshot(want)
+	got.Charts[0].SeriesCount = 7
+
+	if _, _, ok := compareSnapshots(got, want); ok {
+		t.Fatalf("expected strict comparison to detect series count change")
+	}
+	if _, _, ok := compareSnapshots(got, want, IgnoreFieldsNewerThanStored()); !ok {
+		t.Fatalf("expected v1 comparison to ignore v2 fields")
+	}
+
+	got.Charts[0].ChartType = "line"
+	if _, _, ok := compareSnapshots(got, want, IgnoreFieldsNewerThanStored()); ok {
+		t.Fatalf("expected v1 comparison to detect v1 field change")
+	}
+}
+
+func TestWriteSnapshotCanonical(t *testing.T) {
+	snap := Snapshot{
+		Entries: []string{"b.xml", "a.xml"},
+		Charts: []ChartSnapshot{
+			{ChartPath: "ppt/charts/chart2.xml", Series: []ChartSeriesSnapshot{{Index: 1}, {Index: 0}}},
+			{ChartPath: "ppt/charts/chart1.xml", Series: []ChartSeriesSnapshot{}},
+		},
+		Workbooks: []WorkbookSnapshot{
+			{WorkbookPath: "w2.xlsx", Sheets: []SheetSnapshot{{Sheet: "B", Cells: []CellSnapshot{{Ref: "A2"}, {Ref: "A1"}}}, {Sheet: "A"}}},
+			{WorkbookPath: "w1.xlsx"},
+		},
+	}
+	reordered := canonicalSnapshot(snap)
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.json")
+	second := filepath.Join(dir, "second.json")
+	if err := WriteSnapshot(first, snap); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+	if err := WriteSnapshot(second, reordered); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+
+	a, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	b, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Fatalf("expected identical canonical output\n%s\n---\n%s", a, b)
+	}
+	if !bytes.HasSuffix(a, []byte("\n")) {
+		t.Fatalf("expected trailing newline")
+	}
+	if snap.Entries[0] != "b.xml" {
+		t.Fatalf("WriteSnapshot must not mutate its input")
+	}
+}
@@ -0,0 +1,136 @@
+package pptxassert
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// CurrentSchemaVersion is the snapshot schema written by WriteSnapshot.
+//
+// Version history:
+//   - 1: initial format (no schemaVersion field).
+//   - 2: adds schemaVersion and ChartSnapshot.SeriesCount.
+const CurrentSchemaVersion = 2
+
+type snapshotMigration struct {
+	name string
+	// from is the version the migration upgrades; it produces from+1.
+	from  int
+	apply func(*Snapshot)
+}
+
+// snapshotMigrations must stay ordered and contiguous: one entry per version
+// bump, so LoadSnapshot can walk any stored version up to the current one.
+var snapshotMigrations = []snapshotMigration{
+	{name: "v1_to_v2_series_count", from: 1, apply: migrateV1ToV2},
+}
+
+// snapshotFieldStrippers clear the fields introduced by a schema version so
+// snapshots can be compared at an older version's level of detail.
+var snapshotFieldStrippers = map[int]func(*Snapshot){
+	2: func(snap *Snapshot) {
+		for i := range snap.Charts {
+			snap.Charts[i].SeriesCount = 0
+		}
+	},
+}
+
+func migrateSnapshot(snap *Snapshot) error {
+	if snap.SchemaVersion == 0 {
+		snap.SchemaVersion = 1
+	}
+	if snap.SchemaVersion > CurrentSchemaVersion {
+		return fmt.Errorf("snapshot schema version %d is newer than supported version %d", snap.SchemaVersion, CurrentSchemaVersion)
+	}
+	snap.StoredVersion = snap.SchemaVersion
+	snap.Migrations = nil
+
+	for _, migration := range snapshotMigrations {
+		if migration.from != snap.SchemaVersion {
+			continue
+		}
+		migration.apply(snap)
+		snap.SchemaVersion = migration.from + 1
+		snap.Migrations = append(snap.Migrations, migration.name)
+	}
+	if snap.SchemaVersion != CurrentSchemaVersion {
+		return fmt.Errorf("no migration path from snapshot schema version %d", snap.SchemaVersion)
+	}
+	return nil
+}
+
+func migrateV1ToV2(snap *Snapshot) {
+	for i := range snap.Charts {
+		snap.Charts[i].SeriesCount = len(snap.Charts[i].Series)
+	}
+}
+
+func stripFieldsNewerThan(snap Snapshot, version int) Snapshot {
+	for v := version + 1; v <= CurrentSchemaVersion; v++ {
+		if strip, ok := snapshotFieldStrippers[v]; ok {
+			strip(&snap)
+		}
+	}
+	return snap
+}
+
+// canonicalSnapshot returns a deep copy of snap with set-like lists sorted.
+// Lists whose order carries meaning (plots, axis groups, cache points) keep
+// document order.
+func canonicalSnapshot(snap Snapshot) Snapshot {
+	out := snap
+	out.Entries = slices.Clone(snap.Entries)
+	sort.Strings(out.Entries)
+	out.Migrations = slices.Clone(snap.Migrations)
+
+	out.Charts = slices.Clone(snap.Charts)
+	for i, chart := range out.Charts {
+		c := chart
+		c.Plots = slices.Clone(chart.Plots)
+		for j := range c.Plots {
+			c.Plots[j].AxisIDs = slices.Clone(c.Plots[j].AxisIDs)
+		}
+		c.AxisGroups = slices.Clone(chart.AxisGroups)
+		c.Series = slices.Clone(chart.Series)
+		sort.SliceStable(c.Series, func(a, b int) bool {
+			return c.Series[a].Index < c.Series[b].Index
+		})
+		c.Cache.Series = slices.Clone(chart.Cache.Series)
+		for j := range c.Cache.Series {
+			c.Cache.Series[j].Points = slices.Clone(c.Cache.Series[j].Points)
+		}
+		sort.SliceStable(c.Cache.Series, func(a, b int) bool {
+			if c.Cache.Series[a].SeriesIndex != c.Cache.Series[b].SeriesIndex {
+				return c.Cache.Series[a].SeriesIndex < c.Cache.Series[b].SeriesIndex
+			}
+			return c.Cache.Series[a].Kind < c.Cache.Series[b].Kind
+		})
+		out.Charts[i] = c
+	}
+	sort.SliceStable(out.Charts, func(a, b int) bool {
+		return out.Charts[a].ChartPath < out.Charts[b].ChartPath
+	})
+
+	out.Workbooks = slices.Clone(snap.Workbooks)
+	for i, workbook := range out.Workbooks {
+		w := workbook
+		w.Sheets = slices.Clone(workbook.Sheets)
+		for j, sheet := range w.Sheets {
+			sheet.Cells = slices.Clone(sheet.Cells)
+			sort.SliceStable(sheet.Cells, func(a, b int) bool {
+				return sheet.Cells[a].Ref < sheet.Cells[b].Ref
+			})
+			w.Sheets[j] = sheet
+		}
+		sort.SliceStable(w.Sheets, func(a, b int) bool {
+			return w.Sheets[a].Sheet < w.Sheets[b].Sheet
+		})
+		out.Workbooks[i] = w
+	}
+	sort.SliceStable(out.Workbooks, func(a, b int) bool {
+		return out.Workbooks[a].WorkbookPath < out.Workbooks[b].WorkbookPath
+	})
+
+	return out
+}
@@ -0,0 +1,102 @@
+package pptxassert
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// CanonicalXML renders data in a form where structurally equal documents
+// compare equal: names are resolved to their namespace URIs, namespace
+// declarations are dropped, attributes are sorted, whitespace-only text
+// between elements is removed, and the XML declaration, comments, and
+// processing instructions are skipped. Each element event is one line,
+// indented by depth, so a line diff points at the first difference.
+func CanonicalXML(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var b strings.Builder
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("parse xml: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			attrs := make([]string, 0, len(tok.Attr))
+			for _, attr := range tok.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				attrs = append(attrs, fmt.Sprintf("%s=%q", canonicalName(attr.Name), attr.Value))
+			}
+			sort.Strings(attrs)
+			b.WriteString(strings.Repeat("  ", depth))
+			b.WriteString("<" + canonicalName(tok.Name))
+			for _, attr := range attrs {
+				b.WriteString(" " + attr)
+			}
+			b.WriteString(">\n")
+			depth++
+		case xml.EndElement:
+			depth--
+			b.WriteString(strings.Repeat("  ", depth))
+			b.WriteString("</" + canonicalName(tok.Name) + ">\n")
+		case xml.CharData:
+			if len(bytes.TrimSpace(tok)) == 0 {
+				continue
+			}
+			b.WriteString(strings.Repeat("  ", depth))
+			fmt.Fprintf(&b, "%q\n", string(tok))
+		}
+	}
+}
+
+// AssertXMLEquivalent fails the test when got and want differ after
+// CanonicalXML, reporting the first differing line. name labels the part in
+// the failure message.
+func AssertXMLEquivalent(t *testing.T, name string, got, want []byte) {
+	t.Helper()
+
+	gotCanonical, err := CanonicalXML(got)
+	if err != nil {
+		t.Fatalf("%s: canonicalize got: %v", name, err)
+	}
+	wantCanonical, err := CanonicalXML(want)
+	if err != nil {
+		t.Fatalf("%s: canonicalize want: %v", name, err)
+	}
+	if gotCanonical == wantCanonical {
+		return
+	}
+
+	gotLines := strings.Split(gotCanonical, "\n")
+	wantLines := strings.Split(wantCanonical, "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Fatalf("%s: XML differs at canonical line %d:\n got: %s\nwant: %s", name, i+1, strings.TrimSpace(g), strings.TrimSpace(w))
+		}
+	}
+}
+
+func canonicalName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
@@ -0,0 +1,45 @@
+package pptxassert
+
+import "testing"
+
+func TestCanonicalXMLIgnoresInsignificantDifferences(t *testing.T) {
+	a := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:ptCount val="2"/>
+  <c:pt idx="0" formatCode="General"><c:v>10</c:v></c:pt>
+</c:chartSpace>`)
+	b := []byte(`<chartSpace xmlns="http://schemas.openxmlformats.org/drawingml/2006/chart"><ptCount val="2"></ptCount><!-- cache --><pt formatCode="General" idx="0"><v>10</v></pt></chartSpace>`)
+
+	canonA, err := CanonicalXML(a)
+	if err != nil {
+		t.Fatalf("CanonicalXML a: %v", err)
+	}
+	canonB, err := CanonicalXML(b)
+	if err != nil {
+		t.Fatalf("CanonicalXML b: %v", err)
+	}
+	if canonA != canonB {
+		t.Fatalf("expected equivalent canonical forms:\n%s\n---\n%s", canonA, canonB)
+	}
+}
+
+func TestCanonicalXMLKeepsSignificantDifferences(t *testing.T) {
+	base := `<c:v xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">10</c:v>`
+	for name, other := range map[string]string{
+		"text":      `<c:v xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">11</c:v>`,
+		"namespace": `<c:v xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/main">10</c:v>`,
+		"attribute": `<c:v xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" x="1">10</c:v>`,
+	} {
+		canonBase, err := CanonicalXML([]byte(base))
+		if err != nil {
+			t.Fatalf("CanonicalXML base: %v", err)
+		}
+		canonOther, err := CanonicalXML([]byte(other))
+		if err != nil {
+			t.Fatalf("CanonicalXML %s: %v", name, err)
+		}
+		if canonBase == canonOther {
+			t.Fatalf("%s: expected canonical forms to differ", name)
+		}
+	}
+}
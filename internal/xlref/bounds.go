@@ -0,0 +1,109 @@
+package xlref
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MaxRows is the last worksheet row. A whole-column range spans 1..MaxRows.
+const MaxRows = 1048576
+
+// MaxColumns is the index of the last worksheet column, XFD.
+const MaxColumns = 16384
+
+// InGrid reports whether a split cell reference lies on the worksheet grid.
+func InGrid(col string, row int) bool {
+	return row <= MaxRows && ColumnIndex(col) <= MaxColumns
+}
+
+// Bounds is a rectangular cell area with 1-based, inclusive column and row
+// indexes.
+type Bounds struct {
+	StartCol int
+	EndCol   int
+	StartRow int
+	EndRow   int
+}
+
+// Bounds returns the area covered by r, with start and end ordered.
+func (r RangeRef) Bounds() (Bounds, error) {
+	startCol, startRow, err := cellIndexes(r.StartCell)
+	if err != nil {
+		return Bounds{}, err
+	}
+	endCol, endRow, err := cellIndexes(r.EndCell)
+	if err != nil {
+		return Bounds{}, err
+	}
+	if startCol > endCol {
+		startCol, endCol = endCol, startCol
+	}
+	if startRow > endRow {
+		startRow, endRow = endRow, startRow
+	}
+	return Bounds{StartCol: startCol, EndCol: endCol, StartRow: startRow, EndRow: endRow}, nil
+}
+
+// Cells returns the number of cells in b.
+func (b Bounds) Cells() int {
+	return (b.EndCol - b.StartCol + 1) * (b.EndRow - b.StartRow + 1)
+}
+
+// Intersect returns the cells shared by b and other.
+func (b Bounds) Intersect(other Bounds) (Bounds, bool) {
+	out := Bounds{
+		StartCol: max(b.StartCol, other.StartCol),
+		EndCol:   min(b.EndCol, other.EndCol),
+		StartRow: max(b.StartRow, other.StartRow),
+		EndRow:   min(b.EndRow, other.EndRow),
+	}
+	if out.StartCol > out.EndCol || out.StartRow > out.EndRow {
+		return Bounds{}, false
+	}
+	return out, true
+}
+
+// String formats b without a sheet: "B3", "A2:A6", or "A:A" for a whole
+// column.
+func (b Bounds) String() string {
+	if b.StartRow == 1 && b.EndRow == MaxRows {
+		return ColumnName(b.StartCol) + ":" + ColumnName(b.EndCol)
+	}
+	start := ColumnName(b.StartCol) + strconv.Itoa(b.StartRow)
+	if b.StartCol == b.EndCol && b.StartRow == b.EndRow {
+		return start
+	}
+	return start + ":" + ColumnName(b.EndCol) + strconv.Itoa(b.EndRow)
+}
+
+// ColumnIndex converts a column name such as "AB" to its 1-based index.
+func ColumnIndex(col string) int {
+	index := 0
+	for i := 0; i < len(col); i++ {
+		ch := col[i]
+		if ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		index = index*26 + int(ch-'A'+1)
+	}
+	return index
+}
+
+// ColumnName converts a 1-based column index to its name.
+func ColumnName(index int) string {
+	name := ""
+	for index > 0 {
+		index--
+		name = string(rune('A'+index%26)) + name
+		index /= 26
+	}
+	return name
+}
+
+func cellIndexes(cell string) (int, int, error) {
+	col, row, _, err := SplitCellRef(cell)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cell %q: %w", cell, err)
+	}
+	return ColumnIndex(col), row, nil
+}
@@ -0,0 +1,80 @@
+package xlref
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResizeA1Range returns formula with its range stretched or cut to cells
+// cells. The start cell and everything before it (sheet, quoting, "=") are
+// kept as written, and the new end cell takes the $ anchors of the old one.
+// A column range grows down and a row range grows right; a single cell
+// grows right when across is set and down otherwise. A one-cell result is
+// written without an end cell. Rectangular and reversed ranges are refused,
+// as are whole-column and whole-row ranges and a result past the edge of
+// the worksheet.
+func ResizeA1Range(formula string, cells int, across bool) (string, error) {
+	if cells < 1 {
+		return "", fmt.Errorf("range must keep at least one cell")
+	}
+	ref, err := ParseA1Range(formula)
+	if err != nil {
+		return "", err
+	}
+	if ref.Whole != NotWhole {
+		return "", fmt.Errorf("whole-column and whole-row ranges cannot be resized")
+	}
+	startCol, startRow, _, err := SplitCellRef(ref.StartCell)
+	if err != nil {
+		return "", err
+	}
+	endCol, endRow, _, err := SplitCellRef(ref.EndCell)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case startCol == endCol && startRow == endRow:
+	case startCol == endCol && startRow < endRow:
+		across = false
+	case startRow == endRow && ColumnIndex(startCol) < ColumnIndex(endCol):
+		across = true
+	default:
+		return "", fmt.Errorf("range %s:%s is not a single row or column in reading order", ref.StartCell, ref.EndCell)
+	}
+
+	col, row := startCol, startRow+cells-1
+	if across {
+		col, row = ColumnName(ColumnIndex(startCol)+cells-1), startRow
+	}
+	if !InGrid(col, row) {
+		return "", fmt.Errorf("range from %s over %d cells runs past the worksheet", ref.StartCell, cells)
+	}
+
+	// The cell part follows the last "!": a quoted sheet name may contain
+	// one, a cell reference cannot.
+	sep := strings.LastIndex(formula, "!")
+	prefix, cellPart := formula[:sep+1], strings.TrimSpace(formula[sep+1:])
+	startRaw, endRaw, ok := strings.Cut(cellPart, ":")
+	if !ok {
+		endRaw = startRaw
+	}
+	if cells == 1 {
+		return prefix + startRaw, nil
+	}
+
+	end := strings.TrimSpace(endRaw)
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString(startRaw)
+	b.WriteByte(':')
+	if strings.HasPrefix(end, "$") {
+		b.WriteByte('$')
+	}
+	b.WriteString(col)
+	if strings.Contains(strings.TrimPrefix(end, "$"), "$") {
+		b.WriteByte('$')
+	}
+	fmt.Fprintf(&b, "%d", row)
+	return b.String(), nil
+}
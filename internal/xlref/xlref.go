@@ -1,15 +1,78 @@
 package xlref
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 )
 
+// ErrNamedRange is wrapped by the errors of formulas that are a defined
+// name rather than a cell range.
+var ErrNamedRange = errors.New("defined names are not supported")
+
+// NamedRangeError is the error for a formula that is a defined name, such
+// as "Sheet1!Revenue" for a sheet-scoped name or "[0]!Revenue" for a
+// workbook-scoped one, as charts write them. Name is the formula as
+// written, without a leading "=".
+type NamedRangeError struct {
+	Name string
+}
+
+func (e *NamedRangeError) Error() string {
+	return fmt.Sprintf("%q is a defined name, not a cell range", e.Name)
+}
+
+func (e *NamedRangeError) Unwrap() error {
+	return ErrNamedRange
+}
+
+// Whole tells references to entire columns or rows apart from cell ranges.
+type Whole int
+
+const (
+	NotWhole Whole = iota
+	// WholeColumns is a reference such as "$A:$A": StartCell is in row 1
+	// and EndCell in row MaxRows.
+	WholeColumns
+	// WholeRows is a reference such as "$2:$2": StartCell is in column A
+	// and EndCell in column XFD.
+	WholeRows
+)
+
 type RangeRef struct {
 	Sheet     string
 	StartCell string
 	EndCell   string
+	// Whole is set for a whole-column or whole-row reference, whose cells
+	// span the worksheet grid until Narrow cuts them to the cells in use.
+	Whole Whole
+}
+
+// Narrow returns r with a whole-column reference cut to the rows of used,
+// and a whole-row reference to its columns. Other references are returned
+// as they are.
+func (r RangeRef) Narrow(used Bounds) RangeRef {
+	startCol, startRow, _, err := SplitCellRef(r.StartCell)
+	if err != nil {
+		return r
+	}
+	endCol, endRow, _, err := SplitCellRef(r.EndCell)
+	if err != nil {
+		return r
+	}
+	switch r.Whole {
+	case WholeColumns:
+		startRow, endRow = used.StartRow, used.EndRow
+	case WholeRows:
+		startCol, endCol = ColumnName(used.StartCol), ColumnName(used.EndCol)
+	default:
+		return r
+	}
+	r.StartCell = startCol + strconv.Itoa(startRow)
+	r.EndCell = endCol + strconv.Itoa(endRow)
+	r.Whole = NotWhole
+	return r
 }
 
 func NormalizeCellRef(cell string) (string, error) {
@@ -58,8 +121,14 @@ func ParseA1Range(formula string) (RangeRef, error) {
 		return RangeRef{}, err
 	}
 
+	if start, end, whole, ok := parseWholeRange(cellPart); ok {
+		return RangeRef{Sheet: sheet, StartCell: start, EndCell: end, Whole: whole}, nil
+	}
 	start, end, err := parseCellRange(cellPart)
 	if err != nil {
+		if isDefinedName(cellPart) {
+			return RangeRef{}, &NamedRangeError{Name: trimmed}
+		}
 		return RangeRef{}, err
 	}
 
@@ -70,6 +139,104 @@ func ParseA1Range(formula string) (RangeRef, error) {
 	}, nil
 }
 
+// parseWholeRange reads a whole-column ("$A:$C") or whole-row ("$2:$5")
+// reference, with or without $ anchors.
+func parseWholeRange(cellPart string) (string, string, Whole, bool) {
+	first, last, ok := strings.Cut(cellPart, ":")
+	if !ok {
+		return "", "", NotWhole, false
+	}
+	first = strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(first), "$"))
+	last = strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(last), "$"))
+	if first == "" || last == "" {
+		return "", "", NotWhole, false
+	}
+	switch {
+	case strings.Trim(first, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" && strings.Trim(last, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "":
+		if ColumnIndex(first) > MaxColumns || ColumnIndex(last) > MaxColumns {
+			return "", "", NotWhole, false
+		}
+		return first + "1", last + strconv.Itoa(MaxRows), WholeColumns, true
+	case strings.Trim(first, "0123456789") == "" && strings.Trim(last, "0123456789") == "":
+		startRow, err1 := strconv.Atoi(first)
+		endRow, err2 := strconv.Atoi(last)
+		if err1 != nil || err2 != nil || startRow < 1 || endRow < 1 || startRow > MaxRows || endRow > MaxRows {
+			return "", "", NotWhole, false
+		}
+		return "A" + strconv.Itoa(startRow), ColumnName(MaxColumns) + strconv.Itoa(endRow), WholeRows, true
+	}
+	return "", "", NotWhole, false
+}
+
+// isDefinedName reports whether s has the syntax of a defined name: a
+// letter, '_', or '\' followed by letters, digits, '_', '.', and '\'. A
+// string that reads as a cell reference is not a name.
+func isDefinedName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_' || r == '\\' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r > 0x7f:
+		case i > 0 && (r >= '0' && r <= '9' || r == '.'):
+		default:
+			return false
+		}
+	}
+	_, err := parseCell(s)
+	return err != nil
+}
+
+// ParseA1Union parses a series formula that may be a union of ranges, as
+// PowerPoint writes when non-adjacent cells are selected:
+// "(Sheet1!$B$2:$B$5,Sheet1!$B$8:$B$10)". The parentheses are optional and
+// commas inside quoted sheet names do not split. A single range yields one
+// RangeRef.
+func ParseA1Union(formula string) ([]RangeRef, error) {
+	trimmed := strings.TrimSpace(formula)
+	if strings.HasPrefix(trimmed, "=") {
+		trimmed = strings.TrimSpace(trimmed[1:])
+	}
+	if strings.HasPrefix(trimmed, "(") && strings.HasSuffix(trimmed, ")") {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+
+	parts, err := splitUnion(trimmed)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]RangeRef, 0, len(parts))
+	for _, part := range parts {
+		ref, err := ParseA1Range(part)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+func splitUnion(formula string) ([]string, error) {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(formula); i++ {
+		switch formula[i] {
+		case '\'':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				parts = append(parts, formula[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated sheet name")
+	}
+	return append(parts, formula[start:]), nil
+}
+
 func splitSheetAndCells(formula string) (string, string, error) {
 	if strings.HasPrefix(formula, "'") {
 		sheet, rest, err := parseQuotedSheet(formula)
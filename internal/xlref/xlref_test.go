@@ -1,6 +1,9 @@
 package xlref
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestParseA1Range(t *testing.T) {
 	tests := []struct {
@@ -36,3 +39,191 @@ func TestParseA1Range(t *testing.T) {
 		}
 	}
 }
+
+func TestParseA1RangeWholeAndNamed(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    RangeRef
+		narrow  RangeRef
+	}{
+		{
+			formula: "Sheet1!$B:$B",
+			want:    RangeRef{Sheet: "Sheet1", StartCell: "B1", EndCell: "B1048576", Whole: WholeColumns},
+			narrow:  RangeRef{Sheet: "Sheet1", StartCell: "B2", EndCell: "B6"},
+		},
+		{
+			formula: "'My Sheet'!a:c",
+			want:    RangeRef{Sheet: "My Sheet", StartCell: "A1", EndCell: "C1048576", Whole: WholeColumns},
+			narrow:  RangeRef{Sheet: "My Sheet", StartCell: "A2", EndCell: "C6"},
+		},
+		{
+			formula: "Sheet1!$2:$2",
+			want:    RangeRef{Sheet: "Sheet1", StartCell: "A2", EndCell: "XFD2", Whole: WholeRows},
+			narrow:  RangeRef{Sheet: "Sheet1", StartCell: "A2", EndCell: "D2"},
+		},
+		{
+			formula: "Sheet1!$B$2:$B$3",
+			want:    RangeRef{Sheet: "Sheet1", StartCell: "B2", EndCell: "B3"},
+			narrow:  RangeRef{Sheet: "Sheet1", StartCell: "B2", EndCell: "B3"},
+		},
+	}
+	used := Bounds{StartCol: 1, EndCol: 4, StartRow: 2, EndRow: 6}
+	for _, test := range tests {
+		ref, err := ParseA1Range(test.formula)
+		if err != nil {
+			t.Fatalf("ParseA1Range(%q): %v", test.formula, err)
+		}
+		if ref != test.want {
+			t.Fatalf("unexpected ref for %q: %+v", test.formula, ref)
+		}
+		if narrowed := ref.Narrow(used); narrowed != test.narrow {
+			t.Fatalf("unexpected narrowed ref for %q: %+v", test.formula, narrowed)
+		}
+	}
+
+	for _, formula := range []string{"Sheet1!$XFE:$XFE", "Sheet1!$0:$0", "Sheet1!$A:$2"} {
+		if _, err := ParseA1Range(formula); err == nil {
+			t.Fatalf("expected error for %q", formula)
+		}
+	}
+
+	for formula, name := range map[string]string{"[0]!Revenue": "[0]!Revenue", "=Sheet1!_Sales.2024": "Sheet1!_Sales.2024", "'My Sheet'!Totals": "'My Sheet'!Totals"} {
+		_, err := ParseA1Range(formula)
+		var named *NamedRangeError
+		if !errors.As(err, &named) || named.Name != name || !errors.Is(err, ErrNamedRange) {
+			t.Fatalf("expected a NamedRangeError %q for %q, got %v", name, formula, err)
+		}
+	}
+	for _, formula := range []string{"Sheet1!$A$0", "Sheet1A2"} {
+		if _, err := ParseA1Range(formula); err == nil || errors.Is(err, ErrNamedRange) {
+			t.Fatalf("expected %q to fail without reading as a name, got %v", formula, err)
+		}
+	}
+}
+
+func TestParseA1Union(t *testing.T) {
+	tests := []struct {
+		formula  string
+		want     []RangeRef
+		hasError bool
+	}{
+		{formula: "Sheet1!$B$2:$B$5", want: []RangeRef{{Sheet: "Sheet1", StartCell: "B2", EndCell: "B5"}}},
+		{formula: "(Sheet1!$B$2:$B$5,Sheet1!$B$8:$B$10)", want: []RangeRef{
+			{Sheet: "Sheet1", StartCell: "B2", EndCell: "B5"},
+			{Sheet: "Sheet1", StartCell: "B8", EndCell: "B10"},
+		}},
+		{formula: "('Q1, Q2'!A2:A3,'It''s, ok'!C4)", want: []RangeRef{
+			{Sheet: "Q1, Q2", StartCell: "A2", EndCell: "A3"},
+			{Sheet: "It's, ok", StartCell: "C4", EndCell: "C4"},
+		}},
+		{formula: "(Sheet1!A2,)", hasError: true},
+		{formula: "('Sheet1!A2,Sheet1!A3)", hasError: true},
+	}
+
+	for _, test := range tests {
+		refs, err := ParseA1Union(test.formula)
+		if test.hasError {
+			if err == nil {
+				t.Fatalf("expected error for %q", test.formula)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ParseA1Union(%q): %v", test.formula, err)
+		}
+		if len(refs) != len(test.want) {
+			t.Fatalf("unexpected refs for %q: %+v", test.formula, refs)
+		}
+		for i := range refs {
+			if refs[i] != test.want[i] {
+				t.Fatalf("unexpected ref %d for %q: %+v", i, test.formula, refs[i])
+			}
+		}
+	}
+}
+
+func TestRangeBoundsIntersect(t *testing.T) {
+	bounds := func(formula string) Bounds {
+		t.Helper()
+		ref, err := ParseA1Range(formula)
+		if err != nil {
+			t.Fatalf("ParseA1Range(%q): %v", formula, err)
+		}
+		b, err := ref.Bounds()
+		if err != nil {
+			t.Fatalf("Bounds(%q): %v", formula, err)
+		}
+		return b
+	}
+	wholeColumnA := Bounds{StartCol: 1, EndCol: 1, StartRow: 1, EndRow: MaxRows}
+
+	tests := []struct {
+		a, b Bounds
+		want string
+	}{
+		{a: bounds("Sheet1!$A$2:$A$6"), b: bounds("Sheet1!A5:A9"), want: "A5:A6"},
+		{a: bounds("Sheet1!A6:A2"), b: bounds("Sheet1!A1:A2"), want: "A2"},
+		{a: bounds("Sheet1!A2:A6"), b: bounds("Sheet1!A7:A9"), want: ""},
+		{a: bounds("Sheet1!A2:A6"), b: bounds("Sheet1!B2:B6"), want: ""},
+		{a: bounds("Sheet1!A3:D3"), b: bounds("Sheet1!C1:C9"), want: "C3"},
+		{a: bounds("Sheet1!Z10:AB10"), b: bounds("Sheet1!AA1:AA20"), want: "AA10"},
+		{a: wholeColumnA, b: bounds("Sheet1!A100:A200"), want: "A100:A200"},
+		{a: wholeColumnA, b: wholeColumnA, want: "A:A"},
+	}
+
+	for _, test := range tests {
+		got, ok := test.a.Intersect(test.b)
+		if test.want == "" {
+			if ok {
+				t.Fatalf("expected %v and %v to be disjoint, got %v", test.a, test.b, got)
+			}
+			continue
+		}
+		if !ok || got.String() != test.want {
+			t.Fatalf("Intersect(%v, %v) = %v, %v; want %s", test.a, test.b, got, ok, test.want)
+		}
+	}
+
+	if cells := wholeColumnA.Cells(); cells != MaxRows {
+		t.Fatalf("expected whole column to hold %d cells, got %d", MaxRows, cells)
+	}
+}
+
+func TestResizeA1Range(t *testing.T) {
+	tests := []struct {
+		formula  string
+		cells    int
+		across   bool
+		want     string
+		hasError bool
+	}{
+		{formula: "Sheet1!$A$2:$A$5", cells: 12, want: "Sheet1!$A$2:$A$13"},
+		{formula: "Sheet1!$A$2:$A$5", cells: 2, want: "Sheet1!$A$2:$A$3"},
+		{formula: "Sheet1!$A$2:$A$5", cells: 1, want: "Sheet1!$A$2"},
+		{formula: "'Q1!Q2'!B1:D1", cells: 5, want: "'Q1!Q2'!B1:F1"},
+		{formula: "Sheet1!$B$2:B$4", cells: 3, want: "Sheet1!$B$2:B$4"},
+		{formula: "Sheet1!$B$2", cells: 3, want: "Sheet1!$B$2:$B$4"},
+		{formula: "Sheet1!$B$2", cells: 3, across: true, want: "Sheet1!$B$2:$D$2"},
+		{formula: "Sheet1!$A$2:$B$5", cells: 3, hasError: true},
+		{formula: "Sheet1!$A$5:$A$2", cells: 3, hasError: true},
+		{formula: "Sheet1!$A$2:$A$5", cells: 0, hasError: true},
+		{formula: "Sheet1!A1048575:A1048576", cells: 3, hasError: true},
+		{formula: "Sheet1!$A:$A", cells: 3, hasError: true},
+	}
+
+	for _, test := range tests {
+		got, err := ResizeA1Range(test.formula, test.cells, test.across)
+		if test.hasError {
+			if err == nil {
+				t.Fatalf("expected error for %q over %d cells, got %q", test.formula, test.cells, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ResizeA1Range(%q, %d): %v", test.formula, test.cells, err)
+		}
+		if got != test.want {
+			t.Fatalf("ResizeA1Range(%q, %d): expected %q, got %q", test.formula, test.cells, test.want, got)
+		}
+	}
+}
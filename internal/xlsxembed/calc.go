@@ -0,0 +1,115 @@
+package xlsxembed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// calcPrFollowers are the workbook children that come after calcPr in
+// CT_Workbook, so a new calcPr goes before the first of them.
+var calcPrFollowers = map[string]bool{
+	"oleSize":             true,
+	"customWorkbookViews": true,
+	"pivotCaches":         true,
+	"smartTagPr":          true,
+	"smartTagTypes":       true,
+	"webPublishing":       true,
+	"fileRecoveryPr":      true,
+	"webPublishObjects":   true,
+	"extLst":              true,
+}
+
+var fullCalcOnLoadAttr = regexp.MustCompile(`\sfullCalcOnLoad\s*=\s*("[^"]*"|'[^']*')`)
+
+// SetFullCalcOnLoad sets fullCalcOnLoad="1" on the calcPr of
+// xl/workbook.xml in the overlay, adding a calcPr if there is none, so Excel
+// recalculates every formula the next time it opens the workbook. The rest
+// of workbook.xml is kept byte for byte.
+func (wb *Workbook) SetFullCalcOnLoad() error {
+	if wb == nil || wb.reader == nil {
+		return fmt.Errorf("workbook not initialized")
+	}
+	data, err := wb.readPart("xl/workbook.xml")
+	if err != nil {
+		return fmt.Errorf("read workbook.xml: %w", err)
+	}
+	updated, err := setFullCalcOnLoad(data)
+	if err != nil {
+		return err
+	}
+	wb.writePart("xl/workbook.xml", updated)
+	return nil
+}
+
+func setFullCalcOnLoad(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	prefix := ""
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return nil, fmt.Errorf("parse workbook: no workbook element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse workbook: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				prefix = tok.Name.Space
+				continue
+			}
+			if depth != 2 {
+				continue
+			}
+			if tok.Name.Local == "calcPr" {
+				return setCalcPrAttr(data, int(offset), int(decoder.InputOffset())), nil
+			}
+			if calcPrFollowers[tok.Name.Local] {
+				return insertCalcPr(data, int(offset), prefix), nil
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				return insertCalcPr(data, int(offset), prefix), nil
+			}
+		}
+	}
+}
+
+// setCalcPrAttr rewrites the calcPr start tag data[start:end].
+func setCalcPrAttr(data []byte, start, end int) []byte {
+	tag := data[start:end]
+	var updated []byte
+	if fullCalcOnLoadAttr.Match(tag) {
+		updated = fullCalcOnLoadAttr.ReplaceAll(tag, []byte(` fullCalcOnLoad="1"`))
+	} else {
+		cut := len(tag) - 1
+		if bytes.HasSuffix(tag, []byte("/>")) {
+			cut = len(tag) - 2
+		}
+		updated = append(append(append([]byte{}, tag[:cut]...), ` fullCalcOnLoad="1"`...), tag[cut:]...)
+	}
+	return splice(data, start, end, updated)
+}
+
+func insertCalcPr(data []byte, at int, prefix string) []byte {
+	name := "calcPr"
+	if prefix != "" {
+		name = prefix + ":" + name
+	}
+	return splice(data, at, at, []byte("<"+name+` fullCalcOnLoad="1"/>`))
+}
+
+func splice(data []byte, start, end int, replacement []byte) []byte {
+	out := make([]byte, 0, len(data)-(end-start)+len(replacement))
+	out = append(out, data[:start]...)
+	out = append(out, replacement...)
+	return append(out, data[end:]...)
+}
@@ -0,0 +1,61 @@
+package xlsxembed
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrSheetNotFound       = errors.New("xlsxembed: sheet not found")
+	ErrUnsupportedCellType = errors.New("xlsxembed: unsupported cell type")
+	ErrPartMissing         = errors.New("xlsxembed: part missing")
+)
+
+// SheetNotFoundError is the error for a sheet name the workbook does not
+// have. It wraps ErrSheetNotFound.
+type SheetNotFoundError struct {
+	Sheet string
+}
+
+func (e *SheetNotFoundError) Error() string {
+	return fmt.Sprintf("sheet %q not found", e.Sheet)
+}
+
+func (e *SheetNotFoundError) Unwrap() error {
+	return ErrSheetNotFound
+}
+
+// CellTypeError is the error for a read of a cell typed other than a
+// number, boolean, error, shared string, or inline string, such as a
+// formula string (t="str"), or for a formula cell read under FormulaReject,
+// with Formula set. It wraps ErrUnsupportedCellType.
+type CellTypeError struct {
+	Type    string
+	Cell    string
+	Formula bool
+}
+
+func (e *CellTypeError) Error() string {
+	if e.Formula {
+		return fmt.Sprintf("formula cell at %s", e.Cell)
+	}
+	return fmt.Sprintf("unsupported cell type %q at %s", e.Type, e.Cell)
+}
+
+func (e *CellTypeError) Unwrap() error {
+	return ErrUnsupportedCellType
+}
+
+// PartMissingError is the error for a part the workbook's relationships or
+// index name but its zip does not hold. It wraps ErrPartMissing.
+type PartMissingError struct {
+	Part string
+}
+
+func (e *PartMissingError) Error() string {
+	return fmt.Sprintf("part %q not found", e.Part)
+}
+
+func (e *PartMissingError) Unwrap() error {
+	return ErrPartMissing
+}
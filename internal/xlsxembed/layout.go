@@ -0,0 +1,252 @@
+package xlsxembed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Pane is the pane element of a sheet's first sheetView.
+type Pane struct {
+	// State is "frozen", "frozenSplit", or "split", the schema default.
+	State string
+	// XSplit and YSplit count the frozen columns and rows for a frozen pane;
+	// for a plain split they are positions in twentieths of a point.
+	XSplit      float64
+	YSplit      float64
+	TopLeftCell string
+	ActivePane  string
+}
+
+// Frozen reports whether the pane freezes rows or columns rather than only
+// splitting the window.
+func (p Pane) Frozen() bool {
+	return p.State == "frozen" || p.State == "frozenSplit"
+}
+
+// FrozenRows returns the number of rows frozen at the top of the sheet.
+func (p Pane) FrozenRows() int {
+	if !p.Frozen() {
+		return 0
+	}
+	return int(p.YSplit)
+}
+
+// FrozenColumns returns the number of columns frozen at the left of the
+// sheet.
+func (p Pane) FrozenColumns() int {
+	if !p.Frozen() {
+		return 0
+	}
+	return int(p.XSplit)
+}
+
+// SheetLayout holds the view and print settings a template reviewer checks.
+// Writes never touch them: updateSheetXML passes sheetViews through byte for
+// byte and workbook.xml is only ever read.
+type SheetLayout struct {
+	// Pane is nil when the sheet's first sheetView has no pane.
+	Pane *Pane
+	// PrintArea is the _xlnm.Print_Area defined name scoped to the sheet,
+	// such as "Sheet1!$A$1:$D$20", or empty when the sheet has none.
+	PrintArea string
+}
+
+// SheetNames returns the sheet names in workbook.xml order.
+func (wb *Workbook) SheetNames() ([]string, error) {
+	if wb == nil || wb.reader == nil {
+		return nil, fmt.Errorf("workbook not initialized")
+	}
+	data, err := wb.readPart("xl/workbook.xml")
+	if err != nil {
+		return nil, fmt.Errorf("read workbook.xml: %w", err)
+	}
+	names, _, err := parseWorkbookNames(data)
+	return names, err
+}
+
+// Date1904 reports whether the workbook uses the 1904 date system, set by
+// the workbookPr date1904 attribute: its date serials count days from
+// 1904-01-01 rather than from 1900.
+func (wb *Workbook) Date1904() (bool, error) {
+	if wb == nil || wb.reader == nil {
+		return false, fmt.Errorf("workbook not initialized")
+	}
+	data, err := wb.readPart("xl/workbook.xml")
+	if err != nil {
+		return false, fmt.Errorf("read workbook.xml: %w", err)
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("parse workbook: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "workbookPr":
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "date1904" {
+					return attr.Value == "1" || attr.Value == "true", nil
+				}
+			}
+			return false, nil
+		case "sheets":
+			// workbookPr precedes sheets.
+			return false, nil
+		}
+	}
+}
+
+// SheetPath returns the worksheet part of a sheet, such as
+// "xl/worksheets/sheet1.xml".
+func (wb *Workbook) SheetPath(sheetName string) (string, bool) {
+	if wb == nil {
+		return "", false
+	}
+	sheetPath, ok := wb.sheets[sheetName]
+	return sheetPath, ok
+}
+
+// SheetLayout reads the pane and print area of a sheet.
+func (wb *Workbook) SheetLayout(sheetName string) (SheetLayout, error) {
+	if wb == nil || wb.reader == nil {
+		return SheetLayout{}, fmt.Errorf("workbook not initialized")
+	}
+	sheetPath, ok := wb.sheets[sheetName]
+	if !ok {
+		return SheetLayout{}, &SheetNotFoundError{Sheet: sheetName}
+	}
+
+	workbookData, err := wb.readPart("xl/workbook.xml")
+	if err != nil {
+		return SheetLayout{}, fmt.Errorf("read workbook.xml: %w", err)
+	}
+	_, printAreas, err := parseWorkbookNames(workbookData)
+	if err != nil {
+		return SheetLayout{}, err
+	}
+
+	data, err := wb.readPart(sheetPath)
+	if err != nil {
+		return SheetLayout{}, fmt.Errorf("read sheet %q: %w", sheetPath, err)
+	}
+	pane, err := parseSheetPane(data)
+	if err != nil {
+		return SheetLayout{}, fmt.Errorf("parse sheet %q: %w", sheetPath, err)
+	}
+	return SheetLayout{Pane: pane, PrintArea: printAreas[sheetName]}, nil
+}
+
+// parseWorkbookNames returns the sheet names in order and the print area of
+// each sheet that has one. A print area is a definedName scoped to a sheet
+// through localSheetId, the 0-based position of the sheet element.
+func parseWorkbookNames(data []byte) ([]string, map[string]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var names []string
+	byPosition := make(map[int]string)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse workbook: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "sheet":
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "name" && attr.Name.Space == "" {
+					names = append(names, attr.Value)
+				}
+			}
+		case "definedName":
+			var name, local string
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "name":
+					name = attr.Value
+				case "localSheetId":
+					local = attr.Value
+				}
+			}
+			if name != "_xlnm.Print_Area" {
+				continue
+			}
+			position, err := strconv.Atoi(local)
+			if err != nil {
+				continue
+			}
+			var text string
+			if err := decoder.DecodeElement(&text, &start); err != nil {
+				return nil, nil, fmt.Errorf("parse workbook: %w", err)
+			}
+			byPosition[position] = text
+		}
+	}
+
+	printAreas := make(map[string]string, len(byPosition))
+	for position, area := range byPosition {
+		if position >= 0 && position < len(names) {
+			printAreas[names[position]] = area
+		}
+	}
+	return names, printAreas, nil
+}
+
+// parseSheetPane reads the pane of the first sheetView. sheetViews precedes
+// sheetData, so the scan stops there.
+func parseSheetPane(data []byte) (*Pane, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "sheetData":
+				return nil, nil
+			case "pane":
+				pane := &Pane{State: "split"}
+				for _, attr := range tok.Attr {
+					switch attr.Name.Local {
+					case "state":
+						pane.State = attr.Value
+					case "xSplit":
+						pane.XSplit, _ = strconv.ParseFloat(attr.Value, 64)
+					case "ySplit":
+						pane.YSplit, _ = strconv.ParseFloat(attr.Value, 64)
+					case "topLeftCell":
+						pane.TopLeftCell = attr.Value
+					case "activePane":
+						pane.ActivePane = attr.Value
+					}
+				}
+				return pane, nil
+			}
+		case xml.EndElement:
+			if tok.Name.Local == "sheetView" {
+				return nil, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package xlsxembed
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"why-pptx/internal/xlref"
+)
+
+// ErrCellOutOfBounds is wrapped by the error for a write to a row past
+// xlref.MaxRows or a column past xlref.MaxColumns.
+var ErrCellOutOfBounds = errors.New("cell beyond worksheet limits")
+
+func checkCellBounds(col string, row int, ref string) error {
+	if !xlref.InGrid(col, row) {
+		return fmt.Errorf("%w: %s", ErrCellOutOfBounds, ref)
+	}
+	return nil
+}
+
+// NewRows counts the rows that do not exist yet in the sheet, so a caller
+// can bound how much one write grows it before writing anything.
+func (wb *Workbook) NewRows(sheetName string, rows map[int]struct{}) (int, error) {
+	added, err := wb.NewRowNumbers(sheetName, rows)
+	return len(added), err
+}
+
+// NewRowNumbers returns, in ascending order, the rows of rows that do not
+// exist yet in the sheet and that a write to them would append.
+func (wb *Workbook) NewRowNumbers(sheetName string, rows map[int]struct{}) ([]int, error) {
+	if wb == nil || wb.reader == nil {
+		return nil, fmt.Errorf("workbook not initialized")
+	}
+	sheetPath, ok := wb.sheets[sheetName]
+	if !ok {
+		return nil, &SheetNotFoundError{Sheet: sheetName}
+	}
+	data, err := wb.readPart(sheetPath)
+	if err != nil {
+		return nil, fmt.Errorf("read sheet %q: %w", sheetPath, err)
+	}
+
+	rowNumbers, err := scanRowNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[int]struct{})
+	for _, row := range rowNumbers {
+		if _, ok := rows[row]; ok {
+			existing[row] = struct{}{}
+		}
+	}
+	added := make([]int, 0, len(rows)-len(existing))
+	for row := range rows {
+		if _, ok := existing[row]; !ok {
+			added = append(added, row)
+		}
+	}
+	sort.Ints(added)
+	return added, nil
+}
@@ -0,0 +1,84 @@
+package xlsxembed
+
+import (
+	"fmt"
+
+	"why-pptx/internal/xlref"
+)
+
+// RangeDirection is the way SetRangeValues walks from its start cell.
+type RangeDirection int
+
+const (
+	// RangeDown fills a column, one row after another.
+	RangeDown RangeDirection = iota
+	// RangeRight fills a row, one column after another.
+	RangeRight
+)
+
+// SetRangeValues writes values to consecutive cells from startCell, down a
+// column or along a row, with a single rewrite of the sheet. Each value
+// follows the rules of SetCell. Missing rows are created in order, and
+// other cells and row attributes are kept. It returns the normalized last
+// cell written; nothing is written when a value is invalid or the range
+// runs past the worksheet grid.
+func (wb *Workbook) SetRangeValues(sheetName, startCell string, values []CellValue, direction RangeDirection) (string, error) {
+	if wb == nil || wb.reader == nil {
+		return "", fmt.Errorf("workbook not initialized")
+	}
+	if sheetName == "" {
+		return "", fmt.Errorf("sheet name is required")
+	}
+	if len(values) == 0 {
+		return "", fmt.Errorf("values are required")
+	}
+	if direction != RangeDown && direction != RangeRight {
+		return "", fmt.Errorf("unknown range direction %d", direction)
+	}
+	col, row, normalized, err := xlref.SplitCellRef(startCell)
+	if err != nil {
+		return "", err
+	}
+	if err := checkCellBounds(col, row, normalized); err != nil {
+		return "", err
+	}
+
+	sheetPath, ok := wb.sheets[sheetName]
+	if !ok {
+		return "", &SheetNotFoundError{Sheet: sheetName}
+	}
+	data, err := wb.readPart(sheetPath)
+	if err != nil {
+		return "", fmt.Errorf("read sheet %q: %w", sheetPath, err)
+	}
+
+	startCol := colToIndex(col)
+	updates := make([]cellUpdate, len(values))
+	for i, v := range values {
+		if (v.Number == nil && v.String == nil) || (v.Number != nil && v.String != nil) {
+			return "", fmt.Errorf("cell value %d must specify exactly one of number or string", i)
+		}
+		if v.String != nil {
+			text := NormalizeText(*v.String)
+			v.String = &text
+		}
+		cellCol, cellRow := col, row
+		if direction == RangeDown {
+			cellRow = row + i
+		} else {
+			cellCol = indexToCol(startCol + i)
+		}
+		ref := fmt.Sprintf("%s%d", cellCol, cellRow)
+		if err := checkCellBounds(cellCol, cellRow, ref); err != nil {
+			return "", err
+		}
+		updates[i] = cellUpdate{Ref: ref, Row: cellRow, Col: cellCol, Value: v}
+	}
+
+	updated, err := updateSheetXML(data, updates, wb.formulaOverwrite(sheetName))
+	if err != nil {
+		return "", fmt.Errorf("update sheet %q: %w", sheetPath, err)
+	}
+	wb.writePart(sheetPath, updated)
+	return updates[len(updates)-1].Ref, nil
+}
@@ -0,0 +1,104 @@
+package xlsxembed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// scanRowNumbers returns the r of every row element in document order.
+// Excel writes rows sorted, but other generators do not always, so callers
+// must not assume the result is ascending.
+func scanRowNumbers(data []byte) ([]int, error) {
+	var rows []int
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse worksheet: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		if row := parseRowNumber(start.Attr); row > 0 {
+			rows = append(rows, row)
+		}
+	}
+}
+
+// rowInserts says where updateSheetXML writes the rows it creates. A new
+// row goes right after the existing row with the next lower number, or, when
+// it is lower than every existing row, right before the lowest one. In a
+// sorted sheet that is plain sorted order; in an out-of-order sheet, existing
+// rows keep their order and each new row sits next to its numeric neighbour.
+type rowInserts struct {
+	before map[int][]int
+	after  map[int][]int
+}
+
+func planRowInserts(existing []int, added []int) rowInserts {
+	plan := rowInserts{before: map[int][]int{}, after: map[int][]int{}}
+	if len(existing) == 0 || len(added) == 0 {
+		return plan
+	}
+	sorted := append([]int(nil), existing...)
+	sort.Ints(sorted)
+	for _, row := range added {
+		i := sort.SearchInts(sorted, row)
+		if i == 0 {
+			plan.before[sorted[0]] = append(plan.before[sorted[0]], row)
+			continue
+		}
+		plan.after[sorted[i-1]] = append(plan.after[sorted[i-1]], row)
+	}
+	for _, rows := range plan.before {
+		sort.Ints(rows)
+	}
+	for _, rows := range plan.after {
+		sort.Ints(rows)
+	}
+	return plan
+}
+
+// writeNewRows writes the given rows with their pending cells and removes
+// those cells from pending. Rows without pending cells are skipped, so a row
+// number listed twice is written once.
+func writeNewRows(encoder *xml.Encoder, rowName, cellName xml.Name, pending map[string]cellUpdate, updatesByRow map[int][]cellUpdate, rows []int) {
+	if rowName.Local == "" {
+		rowName = xml.Name{Local: "row"}
+	}
+	if cellName.Local == "" {
+		cellName = xml.Name{Local: "c"}
+	}
+
+	for _, row := range rows {
+		cells := make([]cellUpdate, 0, len(updatesByRow[row]))
+		for _, update := range updatesByRow[row] {
+			if current, ok := pending[update.Ref]; ok {
+				cells = append(cells, current)
+				delete(pending, update.Ref)
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		sortCellUpdates(cells)
+
+		start := xml.StartElement{
+			Name: rowName,
+			Attr: []xml.Attr{{Name: xml.Name{Local: "r"}, Value: strconv.Itoa(row)}},
+		}
+		_ = encoder.EncodeToken(start)
+		for _, cell := range cells {
+			_ = writeCell(encoder, cellName, cell.Ref, nil, cell.Value)
+		}
+		_ = encoder.EncodeToken(xml.EndElement{Name: rowName})
+	}
+}
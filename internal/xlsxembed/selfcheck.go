@@ -0,0 +1,117 @@
+package xlsxembed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// rewritableSheetChildren are the worksheet children SetCell may change.
+var rewritableSheetChildren = map[string]bool{
+	"sheetData": true,
+	"dimension": true,
+}
+
+type worksheetChild struct {
+	name string
+	raw  []byte
+}
+
+// checkRewrittenSheets compares every rewritten worksheet with the original
+// part before Save writes it. Only sheetData and dimension may differ; any
+// other child that was dropped, added, moved, or altered fails the save.
+func (wb *Workbook) checkRewrittenSheets() error {
+	sheetPaths := make([]string, 0, len(wb.sheets))
+	for _, sheetPath := range wb.sheets {
+		if _, ok := wb.overlay[sheetPath]; ok {
+			sheetPaths = append(sheetPaths, sheetPath)
+		}
+	}
+	sort.Strings(sheetPaths)
+
+	for _, sheetPath := range sheetPaths {
+		original, err := wb.readOriginalPart(sheetPath)
+		if err != nil {
+			return fmt.Errorf("read sheet %q: %w", sheetPath, err)
+		}
+		if err := checkWorksheetChildren(original, wb.overlay[sheetPath]); err != nil {
+			return fmt.Errorf("sheet %q: %w", sheetPath, err)
+		}
+	}
+	return nil
+}
+
+func checkWorksheetChildren(before, after []byte) error {
+	beforeChildren, err := worksheetChildren(before)
+	if err != nil {
+		return fmt.Errorf("parse original worksheet: %w", err)
+	}
+	afterChildren, err := worksheetChildren(after)
+	if err != nil {
+		return fmt.Errorf("parse rewritten worksheet: %w", err)
+	}
+
+	if len(beforeChildren) != len(afterChildren) {
+		return fmt.Errorf("worksheet children changed: %v became %v", childNames(beforeChildren), childNames(afterChildren))
+	}
+	for i, child := range beforeChildren {
+		rewritten := afterChildren[i]
+		if child.name != rewritten.name {
+			return fmt.Errorf("worksheet children changed: %v became %v", childNames(beforeChildren), childNames(afterChildren))
+		}
+		if rewritableSheetChildren[child.name] {
+			continue
+		}
+		if !bytes.Equal(child.raw, rewritten.raw) {
+			return fmt.Errorf("worksheet element <%s> changed outside sheetData", child.name)
+		}
+	}
+	return nil
+}
+
+// worksheetChildren lists the root's child elements with their raw bytes.
+func worksheetChildren(data []byte) ([]worksheetChild, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var children []worksheetChild
+	depth := 0
+	var childStart int64
+	var childName string
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				childStart = offset
+				childName = tok.Name.Local
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				children = append(children, worksheetChild{
+					name: childName,
+					raw:  data[childStart:decoder.InputOffset()],
+				})
+			}
+			depth--
+		}
+	}
+	return children, nil
+}
+
+func childNames(children []worksheetChild) []string {
+	names := make([]string, 0, len(children))
+	for _, child := range children {
+		names = append(names, child.name)
+	}
+	return names
+}
@@ -0,0 +1,111 @@
+package xlsxembed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/rels"
+)
+
+const sharedStringsRelType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings"
+
+// loadSharedStrings reads the shared string table the workbook relationships
+// point at, or xl/sharedStrings.xml when no relationship names one. A
+// workbook without a table returns nil.
+func (wb *Workbook) loadSharedStrings() ([]string, error) {
+	name := "xl/sharedStrings.xml"
+	if relsData, err := wb.readPart("xl/_rels/workbook.xml.rels"); err == nil {
+		parsed, err := rels.Parse(bytes.NewReader(relsData))
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range parsed.ByID {
+			if rel.Type == sharedStringsRelType {
+				name = path.Clean(rels.ResolveTarget("xl/workbook.xml", rel.Target))
+				break
+			}
+		}
+	}
+	if _, ok := wb.index[name]; !ok {
+		return nil, nil
+	}
+
+	data, err := wb.readPart(name)
+	if err != nil {
+		return nil, fmt.Errorf("read shared strings %q: %w", name, err)
+	}
+	shared, err := parseSharedStrings(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse shared strings %q: %w", name, err)
+	}
+	return shared, nil
+}
+
+// parseSharedStrings returns the text of every <si> in order. The <t> of
+// a plain item and the <t> of each rich-text <r> run are concatenated;
+// phonetic runs (<rPh>) are not part of the text and are skipped.
+func parseSharedStrings(data []byte) ([]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var shared []string
+	var inItem bool
+	var inText bool
+	var phoneticDepth int
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "si":
+				inItem = true
+				text.Reset()
+			case "rPh":
+				phoneticDepth++
+			case "t":
+				inText = inItem && phoneticDepth == 0
+			}
+		case xml.EndElement:
+			switch tok.Name.Local {
+			case "si":
+				if inItem {
+					shared = append(shared, text.String())
+				}
+				inItem = false
+			case "rPh":
+				phoneticDepth--
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(tok)
+			}
+		}
+	}
+
+	return shared, nil
+}
+
+// sharedString resolves the <v> of a t="s" cell against the shared string
+// table.
+func sharedString(shared []string, value, cellRef string) (string, error) {
+	index, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || index < 0 || index >= len(shared) {
+		return "", fmt.Errorf("shared string index %q at %s is not in the table of %d strings", strings.TrimSpace(value), cellRef, len(shared))
+	}
+	return shared[index], nil
+}
@@ -0,0 +1,136 @@
+package xlsxembed
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"why-pptx/internal/xlref"
+)
+
+// sheetIndex holds the cells of one worksheet by row and column, as
+// walkCells read them. Types are checked, and formula and error cells
+// reported, when a read resolves them, so the Workbook's settings at read
+// time apply.
+type sheetIndex struct {
+	rows map[int]map[int]sheetCell
+}
+
+// LoadSheetIndex parses a worksheet once into an index of its cells, which
+// GetRangeValues, GetRangeValues2D, and GetRanges then read instead of
+// scanning the sheet XML again. The index lasts for the Workbook's lifetime;
+// a write to the sheet drops it, and the next LoadSheetIndex builds it anew.
+// A sheet that is already indexed is left as it is. Only the sheets named
+// here are indexed, so memory follows the sheets actually read.
+func (wb *Workbook) LoadSheetIndex(sheetName string) error {
+	if wb == nil || wb.reader == nil {
+		return fmt.Errorf("workbook not initialized")
+	}
+	sheetPath, ok := wb.sheets[sheetName]
+	if !ok {
+		return &SheetNotFoundError{Sheet: sheetName}
+	}
+	if _, ok := wb.indexes[sheetPath]; ok {
+		return nil
+	}
+	data, err := wb.readPart(sheetPath)
+	if err != nil {
+		return fmt.Errorf("read sheet %q: %w", sheetPath, err)
+	}
+
+	index := &sheetIndex{rows: make(map[int]map[int]sheetCell)}
+	all := func(string) bool { return true }
+	err = walkCells(bytes.NewReader(data), wb.cancel, all, func(cell sheetCell) error {
+		col, row, _, err := xlref.SplitCellRef(cell.ref)
+		if err != nil {
+			return nil
+		}
+		cols, ok := index.rows[row]
+		if !ok {
+			cols = make(map[int]sheetCell)
+			index.rows[row] = cols
+		}
+		cols[colToIndex(col)] = cell
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if wb.indexes == nil {
+		wb.indexes = make(map[string]*sheetIndex)
+	}
+	wb.indexes[sheetPath] = index
+	return nil
+}
+
+// SheetIndexed reports whether reads of sheetName use an index built by
+// LoadSheetIndex.
+func (wb *Workbook) SheetIndexed(sheetName string) bool {
+	if wb == nil {
+		return false
+	}
+	sheetPath, ok := wb.sheets[sheetName]
+	if !ok {
+		return false
+	}
+	_, ok = wb.indexes[sheetPath]
+	return ok
+}
+
+// values is readCellValues over the index: the targets present are resolved
+// in sheet order, as a scan would meet them, and the others get the
+// missing value of policy.
+func (index *sheetIndex) values(targets map[string]struct{}, policy MissingNumericPolicy, scan cellScan) (map[string]string, error) {
+	if err := scan.cancel.Err(); err != nil {
+		return nil, err
+	}
+	found := make([]sheetCell, 0, len(targets))
+	for ref := range targets {
+		col, row, _, err := xlref.SplitCellRef(ref)
+		if err != nil {
+			continue
+		}
+		if cell, ok := index.rows[row][colToIndex(col)]; ok {
+			found = append(found, cell)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].pos < found[j].pos })
+
+	values := make(map[string]string, len(targets))
+	for _, cell := range found {
+		err := resolveCell(cell, scan, func(ref, value string) error {
+			values[ref] = value
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for ref := range targets {
+		if _, ok := values[ref]; !ok {
+			values[ref] = missingValue(policy)
+		}
+	}
+	return values, nil
+}
+
+// sheetValues reads the targets of sheetName from its index when
+// LoadSheetIndex built one, or by scanning the sheet XML.
+func (wb *Workbook) sheetValues(sheetName string, targets map[string]struct{}, policy MissingNumericPolicy) (map[string]string, error) {
+	sheetPath := wb.sheets[sheetName]
+	if index, ok := wb.indexes[sheetPath]; ok {
+		return index.values(targets, policy, wb.cellScan(sheetName))
+	}
+	data, err := wb.readPart(sheetPath)
+	if err != nil {
+		return nil, fmt.Errorf("read sheet %q: %w", sheetPath, err)
+	}
+	return readCellValues(data, targets, policy, wb.cellScan(sheetName))
+}
+
+// writePart stores a rewritten part in the overlay and drops the index of
+// the sheet it holds, if there is one.
+func (wb *Workbook) writePart(name string, data []byte) {
+	wb.overlay[name] = data
+	delete(wb.indexes, name)
+}
@@ -0,0 +1,174 @@
+package xlsxembed
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"why-pptx/internal/xlref"
+)
+
+// cellSpan is a 1D cell range with its ends ordered.
+type cellSpan struct {
+	startCol, endCol int
+	startRow, endRow int
+}
+
+func parseCellSpan(startCell, endCell string) (cellSpan, error) {
+	startCol, startRow, startRef, err := xlref.SplitCellRef(startCell)
+	if err != nil {
+		return cellSpan{}, fmt.Errorf("invalid start cell %q: %w", startCell, err)
+	}
+	endCol, endRow, endRef, err := xlref.SplitCellRef(endCell)
+	if err != nil {
+		return cellSpan{}, fmt.Errorf("invalid end cell %q: %w", endCell, err)
+	}
+
+	if startCol != endCol && startRow != endRow {
+		return cellSpan{}, fmt.Errorf("2D range %s:%s not supported", startRef, endRef)
+	}
+
+	span := cellSpan{
+		startCol: colToIndex(startCol),
+		endCol:   colToIndex(endCol),
+		startRow: startRow,
+		endRow:   endRow,
+	}
+	if span.startRow > span.endRow {
+		span.startRow, span.endRow = span.endRow, span.startRow
+	}
+	if span.startCol > span.endCol {
+		span.startCol, span.endCol = span.endCol, span.startCol
+	}
+	return span, nil
+}
+
+func (s cellSpan) len() int {
+	return (s.endCol - s.startCol) + (s.endRow - s.startRow) + 1
+}
+
+func (s cellSpan) ref(pos int) string {
+	if s.startCol == s.endCol {
+		return indexToCol(s.startCol) + strconv.Itoa(s.startRow+pos)
+	}
+	return indexToCol(s.startCol+pos) + strconv.Itoa(s.startRow)
+}
+
+// pos returns the offset of the cell at col/row within the span.
+func (s cellSpan) pos(col, row int) (int, bool) {
+	if col < s.startCol || col > s.endCol || row < s.startRow || row > s.endRow {
+		return 0, false
+	}
+	return (col - s.startCol) + (row - s.startRow), true
+}
+
+type streamRange struct {
+	index int
+	span  cellSpan
+	seen  []uint64
+}
+
+func (r *streamRange) mark(pos int) bool {
+	word, bit := pos/64, uint64(1)<<(pos%64)
+	if r.seen[word]&bit != 0 {
+		return false
+	}
+	r.seen[word] |= bit
+	return true
+}
+
+// StreamRanges reads the same values as GetRanges, scanning each referenced
+// sheet once, but passes each value to emit as its cell is reached instead of
+// collecting them. pos is the offset within ranges[rangeIndex]. Values arrive
+// in sheet order; cells absent from the sheet are emitted per policy after
+// the sheet's scan, and a cell repeated in the sheet is emitted only for
+// its first occurrence. Sheets are decompressed as they are parsed, so memory
+// stays at the decoder's buffers plus one bit per requested cell. An error
+// from emit stops the scan and is returned as is.
+func (wb *Workbook) StreamRanges(ranges []Range, policy MissingNumericPolicy, emit func(rangeIndex, pos int, value string) error) error {
+	if wb == nil || wb.reader == nil {
+		return fmt.Errorf("workbook not initialized")
+	}
+
+	bySheet := make(map[string][]*streamRange)
+	var sheetOrder []string
+	for i, r := range ranges {
+		if r.Sheet == "" {
+			return fmt.Errorf("sheet name is required")
+		}
+		if _, ok := wb.sheets[r.Sheet]; !ok {
+			return &SheetNotFoundError{Sheet: r.Sheet}
+		}
+		span, err := parseCellSpan(r.StartCell, r.EndCell)
+		if err != nil {
+			return err
+		}
+		if _, ok := bySheet[r.Sheet]; !ok {
+			sheetOrder = append(sheetOrder, r.Sheet)
+		}
+		bySheet[r.Sheet] = append(bySheet[r.Sheet], &streamRange{
+			index: i,
+			span:  span,
+			seen:  make([]uint64, (span.len()+63)/64),
+		})
+	}
+
+	for _, sheet := range sheetOrder {
+		sheetPath := wb.sheets[sheet]
+		reader, err := wb.openPart(sheetPath)
+		if err != nil {
+			return fmt.Errorf("read sheet %q: %w", sheetPath, err)
+		}
+		err = streamSheet(reader, bySheet[sheet], policy, wb.cellScan(sheet), emit)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func streamSheet(r io.Reader, ranges []*streamRange, policy MissingNumericPolicy, scan cellScan, emit func(rangeIndex, pos int, value string) error) error {
+	col, row := 0, 0
+	want := func(ref string) bool {
+		colName, rowNum, _, err := xlref.SplitCellRef(ref)
+		if err != nil {
+			return false
+		}
+		col, row = colToIndex(colName), rowNum
+		for _, r := range ranges {
+			if _, ok := r.span.pos(col, row); ok {
+				return true
+			}
+		}
+		return false
+	}
+	err := scanCells(r, want, scan, func(ref, value string) error {
+		for _, r := range ranges {
+			pos, ok := r.span.pos(col, row)
+			if !ok || !r.mark(pos) {
+				continue
+			}
+			if err := emit(r.index, pos, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	missing := missingValue(policy)
+	for _, r := range ranges {
+		for pos := 0; pos < r.span.len(); pos++ {
+			if r.seen[pos/64]&(uint64(1)<<(pos%64)) != 0 {
+				continue
+			}
+			if err := emit(r.index, pos, missing); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
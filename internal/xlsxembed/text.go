@@ -0,0 +1,49 @@
+package xlsxembed
+
+import "strings"
+
+// NormalizeText returns value as SetCell stores it: CRLF and lone CR become
+// LF, and runes XML 1.0 does not allow in character data (C0 controls other
+// than tab and LF, surrogates, U+FFFE, U+FFFF) are removed. Everything else,
+// emoji and other supplementary-plane characters included, is kept as is.
+func NormalizeText(value string) string {
+	if strings.IndexByte(value, '\r') >= 0 {
+		value = strings.ReplaceAll(value, "\r\n", "\n")
+		value = strings.ReplaceAll(value, "\r", "\n")
+	}
+	if _, ok := InvalidXMLRune(value); !ok {
+		return value
+	}
+	return strings.Map(func(r rune) rune {
+		if !isXMLChar(r) {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// InvalidXMLRune reports the first rune in value that NormalizeText would
+// remove. CR is not reported; it is a newline, not an invalid character.
+func InvalidXMLRune(value string) (rune, bool) {
+	for _, r := range value {
+		if r != '\r' && !isXMLChar(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// isXMLChar reports whether r matches the XML 1.0 Char production.
+func isXMLChar(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return true
+	case r >= 0x20 && r <= 0xD7FF:
+		return true
+	case r >= 0xE000 && r <= 0xFFFD:
+		return true
+	case r >= 0x10000 && r <= 0x10FFFF:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package xlsxembed
+
+import (
+	"bytes"
+	"fmt"
+
+	"why-pptx/internal/xlref"
+)
+
+// UsedRange returns the smallest area holding every cell of sheetName that
+// has a value or a formula, as Excel's used range would be without the
+// formatting-only cells. ok is false for a sheet without such cells.
+func (wb *Workbook) UsedRange(sheetName string) (used xlref.Bounds, ok bool, err error) {
+	if wb == nil || wb.reader == nil {
+		return xlref.Bounds{}, false, fmt.Errorf("workbook not initialized")
+	}
+	sheetPath, found := wb.sheets[sheetName]
+	if !found {
+		return xlref.Bounds{}, false, &SheetNotFoundError{Sheet: sheetName}
+	}
+	data, err := wb.readPart(sheetPath)
+	if err != nil {
+		return xlref.Bounds{}, false, fmt.Errorf("read sheet %q: %w", sheetPath, err)
+	}
+
+	all := func(string) bool { return true }
+	err = walkCells(bytes.NewReader(data), wb.cancel, all, func(cell sheetCell) error {
+		if !cell.hasValue && !cell.formula {
+			return nil
+		}
+		col, row, _, err := xlref.SplitCellRef(cell.ref)
+		if err != nil {
+			return nil
+		}
+		index := colToIndex(col)
+		if !ok {
+			used = xlref.Bounds{StartCol: index, EndCol: index, StartRow: row, EndRow: row}
+			ok = true
+			return nil
+		}
+		used.StartCol, used.EndCol = min(used.StartCol, index), max(used.EndCol, index)
+		used.StartRow, used.EndRow = min(used.StartRow, row), max(used.EndRow, row)
+		return nil
+	})
+	if err != nil {
+		return xlref.Bounds{}, false, err
+	}
+	return used, ok, nil
+}
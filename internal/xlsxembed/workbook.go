@@ -3,7 +3,6 @@ package xlsxembed
 import (
 	"archive/zip"
 	"bytes"
-	"compress/flate"
 	"encoding/xml"
 	"fmt"
 	"hash/crc32"
@@ -13,8 +12,10 @@ import (
 	"strconv"
 	"strings"
 
+	"why-pptx/internal/ooxmlpkg"
 	"why-pptx/internal/rels"
 	"why-pptx/internal/xlref"
+	"why-pptx/internal/xmlcancel"
 )
 
 type CellValue struct {
@@ -35,9 +36,50 @@ type Workbook struct {
 	index   map[string]*zip.File
 	overlay map[string][]byte
 	sheets  map[string]string
+	shared  []string
+	// indexes holds the sheets LoadSheetIndex indexed, by part name.
+	indexes map[string]*sheetIndex
+	cancel  *xmlcancel.Flag
+	output  ooxmlpkg.Output
+	budget  *ooxmlpkg.Budget
+
+	formulaPolicy      FormulaPolicy
+	onFormulaCached    func(sheet, cell string)
+	onFormulaOverwrite func(sheet, cell string)
+	boolFormat         BoolFormat
+	onErrorCell        func(sheet, cell, value string)
 }
 
+// FormulaPolicy is how range reads treat a numeric formula cell, whose <v>
+// caches the result Excel last calculated.
+type FormulaPolicy int
+
+const (
+	// FormulaCachedValue reads the cached <v> of a formula cell.
+	FormulaCachedValue FormulaPolicy = iota
+	// FormulaReject fails the read with a CellTypeError.
+	FormulaReject
+)
+
+// BoolFormat is how range reads show a boolean cell (t="b").
+type BoolFormat int
+
+const (
+	// BoolDigits reads booleans as stored, "1" and "0".
+	BoolDigits BoolFormat = iota
+	// BoolWords reads them as Excel shows them, "TRUE" and "FALSE".
+	BoolWords
+)
+
 func Open(data []byte) (*Workbook, error) {
+	return OpenWithBudget(data, nil)
+}
+
+// OpenWithBudget opens a workbook whose parts are read within budget, the
+// workbook.xml and sharedStrings.xml that Open loads included, so a small
+// embedded zip cannot inflate into a huge sheet. A nil budget reads parts
+// without limits.
+func OpenWithBudget(data []byte, budget *ooxmlpkg.Budget) (*Workbook, error) {
 	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
 		return nil, fmt.Errorf("open xlsx: %w", err)
@@ -53,6 +95,7 @@ func Open(data []byte) (*Workbook, error) {
 		reader:  reader,
 		index:   index,
 		overlay: make(map[string][]byte),
+		budget:  budget,
 	}
 
 	sheets, err := wb.loadSheets()
@@ -61,9 +104,101 @@ func Open(data []byte) (*Workbook, error) {
 	}
 	wb.sheets = sheets
 
+	shared, err := wb.loadSharedStrings()
+	if err != nil {
+		return nil, err
+	}
+	wb.shared = shared
+
 	return wb, nil
 }
 
+// SetCancel makes subsequent range reads stop with xmlcancel.ErrCanceled once
+// flag is set. A nil flag disables cancellation.
+func (wb *Workbook) SetCancel(flag *xmlcancel.Flag) {
+	wb.cancel = flag
+}
+
+// SetFormulaPolicy sets how subsequent range reads treat formula cells.
+// With FormulaCachedValue, onCached, if not nil, is called with the sheet
+// and reference of every formula cell a read returns the cached value of.
+func (wb *Workbook) SetFormulaPolicy(policy FormulaPolicy, onCached func(sheet, cell string)) {
+	wb.formulaPolicy = policy
+	wb.onFormulaCached = onCached
+}
+
+// SetFormulaOverwrite sets a function called with the sheet and reference
+// of every formula cell a subsequent SetCell or SetRangeValues writes. The
+// write drops the cell's <f>, so Excel does not recalculate over the value.
+func (wb *Workbook) SetFormulaOverwrite(fn func(sheet, cell string)) {
+	wb.onFormulaOverwrite = fn
+}
+
+// SetBoolFormat sets how subsequent range reads show boolean cells.
+func (wb *Workbook) SetBoolFormat(format BoolFormat) {
+	wb.boolFormat = format
+}
+
+// SetErrorCells sets a function called with the sheet, reference, and
+// literal (such as "#DIV/0!") of every error cell (t="e") a subsequent range
+// read meets. Error cells read as missing, under the read's
+// MissingNumericPolicy.
+func (wb *Workbook) SetErrorCells(fn func(sheet, cell, value string)) {
+	wb.onErrorCell = fn
+}
+
+// cellScan is what scanCells reads the cells of one sheet with.
+type cellScan struct {
+	shared []string
+	cancel *xmlcancel.Flag
+	// formula, if not nil, is called with every numeric or boolean formula
+	// cell; an error fails the read.
+	formula   func(ref string) error
+	boolWords bool
+	// errorCell, if not nil, is called with every error cell and its
+	// literal.
+	errorCell func(ref, value string)
+}
+
+// cellScan returns the scan settings for reads of sheet.
+func (wb *Workbook) cellScan(sheet string) cellScan {
+	scan := cellScan{
+		shared:    wb.shared,
+		cancel:    wb.cancel,
+		boolWords: wb.boolFormat == BoolWords,
+		formula: func(ref string) error {
+			if wb.formulaPolicy == FormulaReject {
+				return &CellTypeError{Cell: ref, Formula: true}
+			}
+			if wb.onFormulaCached != nil {
+				wb.onFormulaCached(sheet, ref)
+			}
+			return nil
+		},
+	}
+	if wb.onErrorCell != nil {
+		scan.errorCell = func(ref, value string) {
+			wb.onErrorCell(sheet, ref, value)
+		}
+	}
+	return scan
+}
+
+// formulaOverwrite is the formula callback of updateSheetXML for writes to
+// sheet, nil without SetFormulaOverwrite.
+func (wb *Workbook) formulaOverwrite(sheet string) func(ref string) {
+	if wb.onFormulaOverwrite == nil {
+		return nil
+	}
+	return func(ref string) {
+		wb.onFormulaOverwrite(sheet, ref)
+	}
+}
+
+// SetCell writes v to a cell in the overlay. String values are stored as
+// inline strings after NormalizeText. A shared string cell (t="s") that is
+// written becomes an inline string or a number; other shared string cells
+// and the shared string table are left as they are.
 func (wb *Workbook) SetCell(sheetName, cellRef string, v CellValue) error {
 	if wb == nil || wb.reader == nil {
 		return fmt.Errorf("workbook not initialized")
@@ -75,15 +210,22 @@ func (wb *Workbook) SetCell(sheetName, cellRef string, v CellValue) error {
 	if (v.Number == nil && v.String == nil) || (v.Number != nil && v.String != nil) {
 		return fmt.Errorf("cell value must specify exactly one of number or string")
 	}
+	if v.String != nil {
+		text := NormalizeText(*v.String)
+		v.String = &text
+	}
 
 	col, row, normalized, err := xlref.SplitCellRef(cellRef)
 	if err != nil {
 		return err
 	}
+	if err := checkCellBounds(col, row, normalized); err != nil {
+		return err
+	}
 
 	sheetPath, ok := wb.sheets[sheetName]
 	if !ok {
-		return fmt.Errorf("sheet %q not found", sheetName)
+		return &SheetNotFoundError{Sheet: sheetName}
 	}
 
 	data, err := wb.readPart(sheetPath)
@@ -98,28 +240,70 @@ func (wb *Workbook) SetCell(sheetName, cellRef string, v CellValue) error {
 		Value: v,
 	}
 
-	updated, err := updateSheetXML(data, []cellUpdate{update})
+	updated, err := updateSheetXML(data, []cellUpdate{update}, wb.formulaOverwrite(sheetName))
 	if err != nil {
 		return fmt.Errorf("update sheet %q: %w", sheetPath, err)
 	}
 
-	wb.overlay[sheetPath] = updated
+	wb.writePart(sheetPath, updated)
 	return nil
 }
 
+// ClearCell removes the value of a cell in the overlay: its <v>, <is>, and
+// <f> and its t attribute are dropped, and its style is kept. A cell that
+// does not exist is left alone; no row is added for it.
+func (wb *Workbook) ClearCell(sheetName, cellRef string) error {
+	if wb == nil || wb.reader == nil {
+		return fmt.Errorf("workbook not initialized")
+	}
+	col, row, normalized, err := xlref.SplitCellRef(cellRef)
+	if err != nil {
+		return err
+	}
+
+	sheetPath, ok := wb.sheets[sheetName]
+	if !ok {
+		return &SheetNotFoundError{Sheet: sheetName}
+	}
+	data, err := wb.readPart(sheetPath)
+	if err != nil {
+		return fmt.Errorf("read sheet %q: %w", sheetPath, err)
+	}
+
+	updated, err := updateSheetXML(data, []cellUpdate{{Ref: normalized, Row: row, Col: col, Clear: true}}, nil)
+	if err != nil {
+		return fmt.Errorf("update sheet %q: %w", sheetPath, err)
+	}
+	wb.writePart(sheetPath, updated)
+	return nil
+}
+
+// SetOutput sets how Save writes the parts the workbook rewrote or added,
+// as Package.SetOutput does for the presentation.
+func (wb *Workbook) SetOutput(out ooxmlpkg.Output) {
+	if wb == nil {
+		return
+	}
+	wb.output = out
+}
+
 func (wb *Workbook) Save() ([]byte, error) {
 	if wb == nil || wb.reader == nil {
 		return nil, fmt.Errorf("workbook not initialized")
 	}
 
+	if err := wb.checkRewrittenSheets(); err != nil {
+		return nil, err
+	}
+
 	var buf bytes.Buffer
-	writer := zip.NewWriter(&buf)
+	writer := wb.output.NewWriter(&buf)
 	written := make(map[string]struct{}, len(wb.reader.File)+len(wb.overlay))
 
 	for _, part := range wb.reader.File {
 		name := part.Name
 		if data, ok := wb.overlay[name]; ok {
-			if err := writeOverrideEntry(writer, part, data); err != nil {
+			if err := writeOverrideEntry(writer, wb.output, part, data); err != nil {
 				_ = writer.Close()
 				return nil, fmt.Errorf("write part %q: %w", name, err)
 			}
@@ -132,11 +316,15 @@ func (wb *Workbook) Save() ([]byte, error) {
 		written[name] = struct{}{}
 	}
 
-	for name, data := range wb.overlay {
-		if _, ok := written[name]; ok {
-			continue
+	added := make([]string, 0, len(wb.overlay))
+	for name := range wb.overlay {
+		if _, ok := written[name]; !ok {
+			added = append(added, name)
 		}
-		if err := writeNewEntry(writer, name, data); err != nil {
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		if err := writeNewEntry(writer, wb.output, name, wb.overlay[name]); err != nil {
 			_ = writer.Close()
 			return nil, fmt.Errorf("write part %q: %w", name, err)
 		}
@@ -157,62 +345,162 @@ func (wb *Workbook) GetRangeValues(sheetName, startCell, endCell string, policy
 		return nil, fmt.Errorf("sheet name is required")
 	}
 
-	sheetPath, ok := wb.sheets[sheetName]
-	if !ok {
-		return nil, fmt.Errorf("sheet %q not found", sheetName)
+	if _, ok := wb.sheets[sheetName]; !ok {
+		return nil, &SheetNotFoundError{Sheet: sheetName}
 	}
 
-	startCol, startRow, startRef, err := xlref.SplitCellRef(startCell)
+	ordered, err := rangeCellRefs(startCell, endCell)
 	if err != nil {
-		return nil, fmt.Errorf("invalid start cell %q: %w", startCell, err)
+		return nil, err
 	}
-	endCol, endRow, endRef, err := xlref.SplitCellRef(endCell)
+	targets := make(map[string]struct{}, len(ordered))
+	for _, ref := range ordered {
+		targets[ref] = struct{}{}
+	}
+
+	values, err := wb.sheetValues(sheetName, targets, policy)
 	if err != nil {
-		return nil, fmt.Errorf("invalid end cell %q: %w", endCell, err)
+		return nil, err
 	}
 
-	if startCol != endCol && startRow != endRow {
-		return nil, fmt.Errorf("2D range %s:%s not supported", startRef, endRef)
+	out := make([]string, len(ordered))
+	for i, ref := range ordered {
+		out[i] = values[ref]
 	}
+	return out, nil
+}
+
+// Grid is a rectangular block of cell values, row by row.
+type Grid struct {
+	Rows   int
+	Cols   int
+	Values []string
+}
+
+// At returns the value in row and col, both 0-based.
+func (g Grid) At(row, col int) string {
+	return g.Values[row*g.Cols+col]
+}
 
-	if startCol == endCol && startRow > endRow {
-		startRow, endRow = endRow, startRow
+// GetRangeValues2D reads a rectangular range such as A2:B10 in row-major
+// order. The corners may be given in either order, so B10:A2 reads the same
+// block. A 1D range reads as a grid of one column or one row. policy applies
+// to each cell as it does in GetRangeValues.
+func (wb *Workbook) GetRangeValues2D(sheetName, startCell, endCell string, policy MissingNumericPolicy) (Grid, error) {
+	if wb == nil || wb.reader == nil {
+		return Grid{}, fmt.Errorf("workbook not initialized")
+	}
+	if sheetName == "" {
+		return Grid{}, fmt.Errorf("sheet name is required")
 	}
-	if startRow == endRow && colToIndex(startCol) > colToIndex(endCol) {
-		startCol, endCol = endCol, startCol
+
+	if _, ok := wb.sheets[sheetName]; !ok {
+		return Grid{}, &SheetNotFoundError{Sheet: sheetName}
 	}
 
-	targets := make(map[string]struct{})
-	var ordered []string
-	if startCol == endCol {
-		for row := startRow; row <= endRow; row++ {
-			ref := fmt.Sprintf("%s%d", startCol, row)
+	bounds, err := xlref.RangeRef{Sheet: sheetName, StartCell: startCell, EndCell: endCell}.Bounds()
+	if err != nil {
+		return Grid{}, fmt.Errorf("invalid range %s:%s: %w", startCell, endCell, err)
+	}
+	grid := Grid{
+		Rows: bounds.EndRow - bounds.StartRow + 1,
+		Cols: bounds.EndCol - bounds.StartCol + 1,
+	}
+	ordered := make([]string, 0, bounds.Cells())
+	targets := make(map[string]struct{}, bounds.Cells())
+	for row := bounds.StartRow; row <= bounds.EndRow; row++ {
+		for col := bounds.StartCol; col <= bounds.EndCol; col++ {
+			ref := xlref.ColumnName(col) + strconv.Itoa(row)
 			ordered = append(ordered, ref)
 			targets[ref] = struct{}{}
 		}
-	} else {
-		for col := colToIndex(startCol); col <= colToIndex(endCol); col++ {
-			ref := fmt.Sprintf("%s%d", indexToCol(col), startRow)
-			ordered = append(ordered, ref)
+	}
+
+	values, err := wb.sheetValues(sheetName, targets, policy)
+	if err != nil {
+		return Grid{}, err
+	}
+
+	grid.Values = make([]string, len(ordered))
+	for i, ref := range ordered {
+		grid.Values[i] = values[ref]
+	}
+	return grid, nil
+}
+
+// Range identifies a 1D cell range on a named sheet.
+type Range struct {
+	Sheet     string
+	StartCell string
+	EndCell   string
+}
+
+// GetRanges reads several ranges at once, scanning each referenced sheet a
+// single time. Results are returned in request order and match what
+// GetRangeValues would return for each range individually.
+func (wb *Workbook) GetRanges(ranges []Range, policy MissingNumericPolicy) ([][]string, error) {
+	if wb == nil || wb.reader == nil {
+		return nil, fmt.Errorf("workbook not initialized")
+	}
+
+	refs := make([][]string, len(ranges))
+	targetsBySheet := make(map[string]map[string]struct{})
+	var sheetOrder []string
+	for i, r := range ranges {
+		if r.Sheet == "" {
+			return nil, fmt.Errorf("sheet name is required")
+		}
+		if _, ok := wb.sheets[r.Sheet]; !ok {
+			return nil, &SheetNotFoundError{Sheet: r.Sheet}
+		}
+		ordered, err := rangeCellRefs(r.StartCell, r.EndCell)
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = ordered
+
+		targets, ok := targetsBySheet[r.Sheet]
+		if !ok {
+			targets = make(map[string]struct{})
+			targetsBySheet[r.Sheet] = targets
+			sheetOrder = append(sheetOrder, r.Sheet)
+		}
+		for _, ref := range ordered {
 			targets[ref] = struct{}{}
 		}
 	}
 
-	data, err := wb.readPart(sheetPath)
-	if err != nil {
-		return nil, fmt.Errorf("read sheet %q: %w", sheetPath, err)
+	valuesBySheet := make(map[string]map[string]string, len(sheetOrder))
+	for _, sheet := range sheetOrder {
+		values, err := wb.sheetValues(sheet, targetsBySheet[sheet], policy)
+		if err != nil {
+			return nil, err
+		}
+		valuesBySheet[sheet] = values
 	}
 
-	values, err := readCellValues(data, targets, policy)
+	out := make([][]string, len(ranges))
+	for i, r := range ranges {
+		values := valuesBySheet[r.Sheet]
+		row := make([]string, len(refs[i]))
+		for j, ref := range refs[i] {
+			row[j] = values[ref]
+		}
+		out[i] = row
+	}
+	return out, nil
+}
+
+func rangeCellRefs(startCell, endCell string) ([]string, error) {
+	span, err := parseCellSpan(startCell, endCell)
 	if err != nil {
 		return nil, err
 	}
-
-	out := make([]string, len(ordered))
-	for i, ref := range ordered {
-		out[i] = values[ref]
+	ordered := make([]string, 0, span.len())
+	for i := 0; i < span.len(); i++ {
+		ordered = append(ordered, span.ref(i))
 	}
-	return out, nil
+	return ordered, nil
 }
 
 func (wb *Workbook) loadSheets() (map[string]string, error) {
@@ -256,11 +544,28 @@ func (wb *Workbook) readPart(name string) ([]byte, error) {
 	if data, ok := wb.overlay[name]; ok {
 		return append([]byte(nil), data...), nil
 	}
+	return wb.readOriginalPart(name)
+}
+
+// openPart streams a part without reading it into memory first, unless it
+// has been rewritten and only exists in the overlay.
+func (wb *Workbook) openPart(name string) (io.ReadCloser, error) {
+	if data, ok := wb.overlay[name]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
 	part, ok := wb.index[name]
 	if !ok {
-		return nil, fmt.Errorf("part %q not found", name)
+		return nil, &PartMissingError{Part: name}
 	}
-	reader, err := part.Open()
+	return wb.budget.Open(part)
+}
+
+func (wb *Workbook) readOriginalPart(name string) ([]byte, error) {
+	part, ok := wb.index[name]
+	if !ok {
+		return nil, &PartMissingError{Part: name}
+	}
+	reader, err := wb.budget.Open(part)
 	if err != nil {
 		return nil, err
 	}
@@ -319,16 +624,29 @@ type cellUpdate struct {
 	Row   int
 	Col   string
 	Value CellValue
+	// Clear empties an existing cell instead of writing Value.
+	Clear bool
 }
 
-func updateSheetXML(data []byte, updates []cellUpdate) ([]byte, error) {
+// updateSheetXML rewrites the sheetData element and splices it back between
+// the original bytes before and after it. Every other worksheet child
+// (dataValidations, hyperlinks, legacyDrawing, pageSetup, extLst, ...) is
+// passed through byte for byte, so its position and namespace prefixes
+// survive; re-encoding them would turn prefixed attributes such as r:id into
+// generated namespace declarations.
+func updateSheetXML(data []byte, updates []cellUpdate, overwrite func(ref string)) ([]byte, error) {
 	if len(updates) == 0 {
 		return data, nil
 	}
 
 	updateByRef := make(map[string]cellUpdate, len(updates))
 	updatesByRow := make(map[int][]cellUpdate)
+	clears := make(map[string]struct{})
 	for _, update := range updates {
+		if update.Clear {
+			clears[update.Ref] = struct{}{}
+			continue
+		}
 		updateByRef[update.Ref] = update
 		updatesByRow[update.Row] = append(updatesByRow[update.Row], update)
 	}
@@ -338,18 +656,34 @@ func updateSheetXML(data []byte, updates []cellUpdate) ([]byte, error) {
 		pending[ref] = update
 	}
 
-	seenRows := make(map[int]bool)
+	existingRows, err := scanRowNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	seenRows := make(map[int]bool, len(existingRows))
+	for _, row := range existingRows {
+		seenRows[row] = true
+	}
+	var added []int
+	for row := range updatesByRow {
+		if !seenRows[row] {
+			added = append(added, row)
+		}
+	}
+	inserts := planRowInserts(existingRows, added)
+
 	var rowName xml.Name
 	var cellName xml.Name
 	var currentRow int
 	rowPending := map[string]cellUpdate(nil)
-	foundSheetData := false
+	sheetDataStart, sheetDataEnd := int64(-1), int64(-1)
 
 	decoder := xml.NewDecoder(bytes.NewReader(data))
 	var buf bytes.Buffer
 	encoder := xml.NewEncoder(&buf)
 
 	for {
+		offset := decoder.InputOffset()
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
@@ -358,18 +692,24 @@ func updateSheetXML(data []byte, updates []cellUpdate) ([]byte, error) {
 			return nil, fmt.Errorf("parse worksheet: %w", err)
 		}
 
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "sheetData" && sheetDataStart < 0 {
+			sheetDataStart = offset
+		}
+		if sheetDataStart < 0 || sheetDataEnd >= 0 {
+			// Outside sheetData: the original bytes are copied when splicing.
+			continue
+		}
+
 		switch tok := token.(type) {
 		case xml.StartElement:
-			if tok.Name.Local == "sheetData" {
-				foundSheetData = true
-			}
+			tok = dropDefaultNS(tok)
 			if tok.Name.Local == "row" {
 				if rowName.Local == "" {
 					rowName = tok.Name
 				}
 				currentRow = parseRowNumber(tok.Attr)
 				if currentRow > 0 {
-					seenRows[currentRow] = true
+					writeNewRows(encoder, rowName, cellName, pending, updatesByRow, inserts.before[currentRow])
 					rowPending = make(map[string]cellUpdate)
 					for _, update := range updatesByRow[currentRow] {
 						if _, ok := pending[update.Ref]; ok {
@@ -390,15 +730,28 @@ func updateSheetXML(data []byte, updates []cellUpdate) ([]byte, error) {
 				cellRef := cellRefFromAttrs(tok.Attr)
 				if cellRef != "" {
 					normalized, err := xlref.NormalizeCellRef(cellRef)
+					if col, _, _, splitErr := xlref.SplitCellRef(normalized); err == nil && splitErr == nil && len(rowPending) > 0 {
+						writePendingCellsBefore(encoder, cellName, rowPending, pending, colToIndex(col))
+					}
+					if _, ok := clears[normalized]; ok && err == nil {
+						if err := writeClearedCell(decoder, encoder, tok); err != nil {
+							return nil, err
+						}
+						continue
+					}
 					if err == nil {
 						if update, ok := pending[normalized]; ok {
 							delete(pending, normalized)
 							if rowPending != nil {
 								delete(rowPending, normalized)
 							}
-							if err := writeUpdatedCell(decoder, encoder, tok, normalized, update.Value); err != nil {
+							hadFormula, err := writeUpdatedCell(decoder, encoder, tok, normalized, update.Value)
+							if err != nil {
 								return nil, err
 							}
+							if hadFormula && overwrite != nil {
+								overwrite(normalized)
+							}
 							continue
 						}
 					}
@@ -419,6 +772,9 @@ func updateSheetXML(data []byte, updates []cellUpdate) ([]byte, error) {
 				if err := encoder.EncodeToken(tok); err != nil {
 					return nil, err
 				}
+				if currentRow > 0 {
+					writeNewRows(encoder, rowName, cellName, pending, updatesByRow, inserts.after[currentRow])
+				}
 				currentRow = 0
 				rowPending = nil
 				continue
@@ -426,11 +782,18 @@ func updateSheetXML(data []byte, updates []cellUpdate) ([]byte, error) {
 
 			if tok.Name.Local == "sheetData" {
 				if len(pending) > 0 {
-					appendMissingRows(encoder, rowName, cellName, pending, seenRows)
+					// Only a sheet without rows gets here with work left.
+					remaining := make([]int, 0, len(pending))
+					for _, update := range pending {
+						remaining = append(remaining, update.Row)
+					}
+					sort.Ints(remaining)
+					writeNewRows(encoder, rowName, cellName, pending, updatesByRow, remaining)
 				}
 				if err := encoder.EncodeToken(tok); err != nil {
 					return nil, err
 				}
+				sheetDataEnd = decoder.InputOffset()
 				continue
 			}
 
@@ -447,11 +810,30 @@ func updateSheetXML(data []byte, updates []cellUpdate) ([]byte, error) {
 	if err := encoder.Flush(); err != nil {
 		return nil, err
 	}
-	if !foundSheetData {
+	if sheetDataStart < 0 || sheetDataEnd < 0 {
 		return nil, fmt.Errorf("worksheet missing sheetData")
 	}
 
-	return buf.Bytes(), nil
+	out := make([]byte, 0, len(data)+buf.Len())
+	out = append(out, data[:sheetDataStart]...)
+	out = append(out, buf.Bytes()...)
+	out = append(out, data[sheetDataEnd:]...)
+	return out, nil
+}
+
+// dropDefaultNS removes an xmlns attribute that repeats the element's own
+// namespace. The encoder declares Name.Space itself, so passing the attribute
+// through as well would write xmlns twice on a sheet rewritten a second time.
+func dropDefaultNS(start xml.StartElement) xml.StartElement {
+	for i, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" && attr.Value == start.Name.Space {
+			attrs := make([]xml.Attr, 0, len(start.Attr)-1)
+			attrs = append(attrs, start.Attr[:i]...)
+			start.Attr = append(attrs, start.Attr[i+1:]...)
+			return start
+		}
+	}
+	return start
 }
 
 func parseRowNumber(attrs []xml.Attr) int {
@@ -492,10 +874,12 @@ func skipElement(decoder *xml.Decoder) error {
 	return nil
 }
 
-func writeUpdatedCell(decoder *xml.Decoder, encoder *xml.Encoder, start xml.StartElement, cellRef string, value CellValue) error {
+// writeUpdatedCell writes value to a cell, keeping its other children. A
+// formula (<f>) is dropped; hadFormula reports one.
+func writeUpdatedCell(decoder *xml.Decoder, encoder *xml.Encoder, start xml.StartElement, cellRef string, value CellValue) (hadFormula bool, err error) {
 	start.Attr = buildCellAttrs(cellRef, start.Attr, value)
 	if err := encoder.EncodeToken(start); err != nil {
-		return err
+		return hadFormula, err
 	}
 
 	depth := 1
@@ -504,45 +888,52 @@ func writeUpdatedCell(decoder *xml.Decoder, encoder *xml.Encoder, start xml.Star
 	for depth > 0 {
 		token, err := decoder.Token()
 		if err != nil {
-			return err
+			return hadFormula, err
 		}
 
 		switch tok := token.(type) {
 		case xml.StartElement:
+			if depth == 1 && tok.Name.Local == "f" {
+				hadFormula = true
+				if err := skipElement(decoder); err != nil {
+					return hadFormula, err
+				}
+				continue
+			}
 			if depth == 1 && (tok.Name.Local == "v" || tok.Name.Local == "is") {
 				if wroteValue {
 					if err := skipElement(decoder); err != nil {
-						return err
+						return hadFormula, err
 					}
 					continue
 				}
 				if tok.Name.Local == "v" && value.Number != nil {
 					if err := writeNumberValue(encoder, *value.Number); err != nil {
-						return err
+						return hadFormula, err
 					}
 					wroteValue = true
 					if err := skipElement(decoder); err != nil {
-						return err
+						return hadFormula, err
 					}
 					continue
 				}
 				if tok.Name.Local == "is" && value.String != nil {
 					if err := writeInlineStr(encoder, *value.String); err != nil {
-						return err
+						return hadFormula, err
 					}
 					wroteValue = true
 					if err := skipElement(decoder); err != nil {
-						return err
+						return hadFormula, err
 					}
 					continue
 				}
 				if err := skipElement(decoder); err != nil {
-					return err
+					return hadFormula, err
 				}
 				continue
 			}
-			if err := encoder.EncodeToken(tok); err != nil {
-				return err
+			if err := encoder.EncodeToken(dropDefaultNS(tok)); err != nil {
+				return hadFormula, err
 			}
 			depth++
 		case xml.EndElement:
@@ -551,62 +942,144 @@ func writeUpdatedCell(decoder *xml.Decoder, encoder *xml.Encoder, start xml.Star
 				if !wroteValue {
 					if value.String != nil {
 						if err := writeInlineStr(encoder, *value.String); err != nil {
-							return err
+							return hadFormula, err
 						}
 					} else if value.Number != nil {
 						if err := writeNumberValue(encoder, *value.Number); err != nil {
-							return err
+							return hadFormula, err
 						}
 					}
 				}
 				if err := encoder.EncodeToken(tok); err != nil {
-					return err
+					return hadFormula, err
 				}
-				return nil
+				return hadFormula, nil
 			}
 			if err := encoder.EncodeToken(tok); err != nil {
-				return err
+				return hadFormula, err
 			}
 		default:
 			if err := encoder.EncodeToken(tok); err != nil {
-				return err
+				return hadFormula, err
 			}
 		}
 	}
 
-	return nil
+	return hadFormula, nil
 }
 
-func readCellValues(data []byte, targets map[string]struct{}, policy MissingNumericPolicy) (map[string]string, error) {
-	decoder := xml.NewDecoder(bytes.NewReader(data))
+func readCellValues(data []byte, targets map[string]struct{}, policy MissingNumericPolicy, scan cellScan) (map[string]string, error) {
 	values := make(map[string]string, len(targets))
+	want := func(ref string) bool {
+		_, ok := targets[ref]
+		return ok
+	}
+	err := scanCells(bytes.NewReader(data), want, scan, func(ref, value string) error {
+		values[ref] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for ref := range targets {
+		if _, ok := values[ref]; !ok {
+			values[ref] = missingValue(policy)
+		}
+	}
+
+	return values, nil
+}
+
+func missingValue(policy MissingNumericPolicy) string {
+	if policy == MissingNumericZero {
+		return "0"
+	}
+	return ""
+}
+
+// readableCellType reports whether scanCells reads cells of type t.
+func readableCellType(t string) bool {
+	switch t {
+	case "", "n", "s", "inlineStr", "b", "e":
+		return true
+	}
+	return false
+}
+
+// boolText shows the <v> of a boolean cell, "1" or "0", as "TRUE" or
+// "FALSE" when words is set. Other values are left as they are.
+func boolText(value string, words bool) string {
+	if !words {
+		return value
+	}
+	switch value {
+	case "1":
+		return "TRUE"
+	case "0":
+		return "FALSE"
+	}
+	return value
+}
+
+// scanCells walks a worksheet read from r and calls fn with the normalized reference and
+// value of every cell that want accepts. Accepted cells must be numeric,
+// boolean, inline strings, shared strings resolved through scan.shared, or
+// errors; a numeric, boolean, or shared string cell without a <v> is
+// skipped, and so is an error cell, after scan.errorCell. A formula cell
+// reads as its cached <v> once scan.formula, if not nil, accepts it.
+func scanCells(r io.Reader, want func(ref string) bool, scan cellScan, fn func(ref, value string) error) error {
+	return walkCells(r, scan.cancel, want, func(cell sheetCell) error {
+		return resolveCell(cell, scan, fn)
+	})
+}
+
+// sheetCell is one <c> of a worksheet as walkCells read it, before its type
+// and value are checked.
+type sheetCell struct {
+	// ref is the normalized reference; pos counts the cells before it in
+	// the sheet.
+	ref string
+	pos int
+	typ string
+	// value is the text of the <v>, or of the <t> runs of an inline
+	// string; hasValue reports that there was one.
+	value    string
+	hasValue bool
+	formula  bool
+}
+
+// walkCells calls fn with every cell of a worksheet read from r whose
+// normalized reference want accepts, in sheet order.
+func walkCells(r io.Reader, cancel *xmlcancel.Flag, want func(ref string) bool, fn func(cell sheetCell) error) error {
+	decoder := xml.NewDecoder(r)
 
 	var inCell bool
-	var cellRef string
-	var cellType string
+	var cell sheetCell
 	var inInlineStr bool
 	var inValue bool
-	var hasValue bool
 	var valueBuf strings.Builder
+	pos := 0
 
 	for {
+		if err := cancel.Err(); err != nil {
+			return err
+		}
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("parse worksheet: %w", err)
+			return fmt.Errorf("parse worksheet: %w", err)
 		}
 
 		switch tok := token.(type) {
 		case xml.StartElement:
 			switch tok.Name.Local {
 			case "c":
-				cellRef = ""
-				cellType = ""
 				inCell = false
 				inInlineStr = false
-				hasValue = false
+				cellRef, cellType := "", ""
 				for _, attr := range tok.Attr {
 					if attr.Name.Local == "r" {
 						cellRef = attr.Value
@@ -616,48 +1089,44 @@ func readCellValues(data []byte, targets map[string]struct{}, policy MissingNume
 				}
 				if cellRef != "" {
 					normalized, err := xlref.NormalizeCellRef(cellRef)
-					if err == nil {
-						if _, ok := targets[normalized]; ok {
-							if cellType != "" && cellType != "n" && cellType != "inlineStr" {
-								return nil, fmt.Errorf("unsupported cell type %q at %s", cellType, normalized)
-							}
-							cellRef = normalized
-							inCell = true
-							valueBuf.Reset()
-							if cellType == "inlineStr" {
-								inInlineStr = true
-							}
+					if err == nil && want(normalized) {
+						cell = sheetCell{ref: normalized, pos: pos, typ: cellType}
+						inCell = true
+						valueBuf.Reset()
+						if cellType == "inlineStr" {
+							inInlineStr = true
 						}
 					}
 				}
+				pos++
+			case "f":
+				if inCell {
+					cell.formula = true
+				}
 			case "v":
-				if inCell && (cellType == "" || cellType == "n") {
+				if inCell && cell.typ != "inlineStr" {
 					inValue = true
 					valueBuf.Reset()
-					hasValue = true
+					cell.hasValue = true
 				}
 			case "t":
 				if inInlineStr {
 					inValue = true
-					hasValue = true
+					cell.hasValue = true
 				}
 			}
 		case xml.EndElement:
 			switch tok.Name.Local {
 			case "c":
 				if inCell {
-					if cellType == "inlineStr" {
-						values[cellRef] = valueBuf.String()
-					} else if hasValue {
-						values[cellRef] = strings.TrimSpace(valueBuf.String())
+					cell.value = valueBuf.String()
+					if err := fn(cell); err != nil {
+						return err
 					}
 				}
 				inCell = false
 				inInlineStr = false
 				inValue = false
-				hasValue = false
-				cellRef = ""
-				cellType = ""
 			case "v":
 				inValue = false
 			case "t":
@@ -670,17 +1139,86 @@ func readCellValues(data []byte, targets map[string]struct{}, policy MissingNume
 		}
 	}
 
-	for ref := range targets {
-		if _, ok := values[ref]; !ok {
-			if policy == MissingNumericZero {
-				values[ref] = "0"
-			} else {
-				values[ref] = ""
-			}
+	return nil
+}
+
+// resolveCell checks a cell the way scanCells describes and calls fn with
+// its value, if it has one.
+func resolveCell(cell sheetCell, scan cellScan, fn func(ref, value string) error) error {
+	if !readableCellType(cell.typ) {
+		return &CellTypeError{Type: cell.typ, Cell: cell.ref}
+	}
+	if cell.formula && (cell.typ == "" || cell.typ == "n" || cell.typ == "b") && scan.formula != nil {
+		if err := scan.formula(cell.ref); err != nil {
+			return err
+		}
+	}
+	switch {
+	case cell.typ == "inlineStr":
+		return fn(cell.ref, cell.value)
+	case cell.typ == "s" && cell.hasValue:
+		text, err := sharedString(scan.shared, cell.value, cell.ref)
+		if err != nil {
+			return err
+		}
+		return fn(cell.ref, text)
+	case cell.typ == "b" && cell.hasValue:
+		return fn(cell.ref, boolText(strings.TrimSpace(cell.value), scan.boolWords))
+	case cell.typ == "e":
+		if scan.errorCell != nil {
+			scan.errorCell(cell.ref, strings.TrimSpace(cell.value))
+		}
+		return nil
+	case cell.hasValue:
+		return fn(cell.ref, strings.TrimSpace(cell.value))
+	}
+	return nil
+}
+
+// writeClearedCell copies a cell without its value: the t attribute and
+// the <v>, <is>, and <f> children are dropped.
+func writeClearedCell(decoder *xml.Decoder, encoder *xml.Encoder, start xml.StartElement) error {
+	attrs := make([]xml.Attr, 0, len(start.Attr))
+	for _, attr := range start.Attr {
+		if attr.Name.Local != "t" {
+			attrs = append(attrs, attr)
 		}
 	}
+	start.Attr = attrs
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
 
-	return values, nil
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if depth == 1 && (tok.Name.Local == "v" || tok.Name.Local == "is" || tok.Name.Local == "f") {
+				if err := skipElement(decoder); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := encoder.EncodeToken(dropDefaultNS(tok)); err != nil {
+				return err
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if err := encoder.EncodeToken(tok); err != nil {
+				return err
+			}
+		default:
+			if err := encoder.EncodeToken(tok); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func writePendingCells(encoder *xml.Encoder, cellName xml.Name, pending map[string]cellUpdate) {
@@ -695,61 +1233,37 @@ func writePendingCells(encoder *xml.Encoder, cellName xml.Name, pending map[stri
 	for _, update := range pending {
 		updates = append(updates, update)
 	}
-	sort.Slice(updates, func(i, j int) bool {
-		if updates[i].Col == updates[j].Col {
-			return updates[i].Ref < updates[j].Ref
-		}
-		return updates[i].Col < updates[j].Col
-	})
+	sortCellUpdates(updates)
 
 	for _, update := range updates {
 		_ = writeCell(encoder, cellName, update.Ref, nil, update.Value)
 	}
 }
 
-func appendMissingRows(encoder *xml.Encoder, rowName, cellName xml.Name, pending map[string]cellUpdate, seenRows map[int]bool) {
-	if rowName.Local == "" {
-		rowName = xml.Name{Local: "row"}
-	}
-	if cellName.Local == "" {
-		cellName = xml.Name{Local: "c"}
-	}
-
-	rows := make(map[int][]cellUpdate)
-	for _, update := range pending {
-		if seenRows[update.Row] {
+// writePendingCellsBefore writes the cells of rowPending left of column
+// col, so new cells land in column order among the existing ones, and
+// removes them from rowPending and pending.
+func writePendingCellsBefore(encoder *xml.Encoder, cellName xml.Name, rowPending, pending map[string]cellUpdate, col int) {
+	var before map[string]cellUpdate
+	for ref, update := range rowPending {
+		if colToIndex(update.Col) >= col {
 			continue
 		}
-		rows[update.Row] = append(rows[update.Row], update)
-	}
-
-	rowNumbers := make([]int, 0, len(rows))
-	for row := range rows {
-		rowNumbers = append(rowNumbers, row)
-	}
-	sort.Ints(rowNumbers)
-
-	for _, row := range rowNumbers {
-		start := xml.StartElement{
-			Name: rowName,
-			Attr: []xml.Attr{{Name: xml.Name{Local: "r"}, Value: strconv.Itoa(row)}},
+		if before == nil {
+			before = make(map[string]cellUpdate)
 		}
-		_ = encoder.EncodeToken(start)
-
-		cells := rows[row]
-		sort.Slice(cells, func(i, j int) bool {
-			if cells[i].Col == cells[j].Col {
-				return cells[i].Ref < cells[j].Ref
-			}
-			return cells[i].Col < cells[j].Col
-		})
-		for _, cell := range cells {
-			_ = writeCell(encoder, cellName, cell.Ref, nil, cell.Value)
-			delete(pending, cell.Ref)
-		}
-
-		_ = encoder.EncodeToken(xml.EndElement{Name: rowName})
+		before[ref] = update
+		delete(rowPending, ref)
+		delete(pending, ref)
 	}
+	writePendingCells(encoder, cellName, before)
+}
+
+// sortCellUpdates orders the updates of one row by column.
+func sortCellUpdates(updates []cellUpdate) {
+	sort.Slice(updates, func(i, j int) bool {
+		return colToIndex(updates[i].Col) < colToIndex(updates[j].Col)
+	})
 }
 
 func writeCell(encoder *xml.Encoder, name xml.Name, cellRef string, attrs []xml.Attr, value CellValue) error {
@@ -842,12 +1356,12 @@ func formatNumber(value float64) string {
 	return strconv.FormatFloat(value, 'f', -1, 64)
 }
 
-func writeOverrideEntry(writer *zip.Writer, part *zip.File, data []byte) error {
-	header := part.FileHeader
-	if part.Flags&0x8 != 0 {
+func writeOverrideEntry(writer *zip.Writer, output ooxmlpkg.Output, part *zip.File, data []byte) error {
+	header := output.Header(part.FileHeader)
+	if header.Flags&0x8 != 0 {
 		return writeEntryWithDescriptor(writer, &header, data)
 	}
-	return writeRawEntry(writer, &header, data)
+	return writeRawEntry(writer, output, &header, data)
 }
 
 func writeEntryWithDescriptor(writer *zip.Writer, header *zip.FileHeader, data []byte) error {
@@ -868,8 +1382,8 @@ func writeEntryWithDescriptor(writer *zip.Writer, header *zip.FileHeader, data [
 	return err
 }
 
-func writeRawEntry(writer *zip.Writer, header *zip.FileHeader, data []byte) error {
-	compressed, err := compressData(header.Method, data)
+func writeRawEntry(writer *zip.Writer, output ooxmlpkg.Output, header *zip.FileHeader, data []byte) error {
+	compressed, err := output.CompressData(header.Method, data)
 	if err != nil {
 		return err
 	}
@@ -892,39 +1406,15 @@ func writeRawEntry(writer *zip.Writer, header *zip.FileHeader, data []byte) erro
 	return err
 }
 
-func writeNewEntry(writer *zip.Writer, name string, data []byte) error {
+func writeNewEntry(writer *zip.Writer, output ooxmlpkg.Output, name string, data []byte) error {
 	if strings.HasSuffix(name, "/") {
-		_, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		header := output.Header(zip.FileHeader{Name: name, Method: zip.Store})
+		_, err := writer.CreateHeader(&header)
 		return err
 	}
 
-	header := zip.FileHeader{Name: name, Method: zip.Deflate}
-	return writeRawEntry(writer, &header, data)
-}
-
-func compressData(method uint16, data []byte) ([]byte, error) {
-	switch method {
-	case zip.Store:
-		return data, nil
-	case zip.Deflate:
-		var buf bytes.Buffer
-		zw, err := flate.NewWriter(&buf, flate.DefaultCompression)
-		if err != nil {
-			return nil, err
-		}
-		if len(data) > 0 {
-			if _, err := zw.Write(data); err != nil {
-				_ = zw.Close()
-				return nil, err
-			}
-		}
-		if err := zw.Close(); err != nil {
-			return nil, err
-		}
-		return buf.Bytes(), nil
-	default:
-		return nil, fmt.Errorf("unsupported compression method: %d", method)
-	}
+	header := output.Header(zip.FileHeader{Name: name, Method: zip.Deflate})
+	return writeRawEntry(writer, output, &header, data)
 }
 
 func colToIndex(col string) int {
@@ -4,9 +4,15 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"io"
+	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
+
+	"why-pptx/internal/ooxmlpkg"
 )
 
 func TestSetCellNumericExisting(t *testing.T) {
@@ -36,6 +42,47 @@ func TestSetCellNumericExisting(t *testing.T) {
 	}
 }
 
+func TestSetCellRejectsCellsPastGrid(t *testing.T) {
+	wb, err := Open(buildTestXLSX(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	value := 1.0
+	for _, ref := range []string{"A1048577", "XFE1"} {
+		if err := wb.SetCell("Sheet1", ref, CellValue{Number: &value}); !errors.Is(err, ErrCellOutOfBounds) {
+			t.Fatalf("%s: expected ErrCellOutOfBounds, got %v", ref, err)
+		}
+	}
+}
+
+func TestNewRows(t *testing.T) {
+	wb, err := Open(buildTestXLSX(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	added, err := wb.NewRows("Sheet1", map[int]struct{}{1: {}, 2: {}, 9: {}})
+	if err != nil {
+		t.Fatalf("NewRows: %v", err)
+	}
+	if added != 2 {
+		t.Fatalf("expected rows 2 and 9 to be new, got %d", added)
+	}
+}
+
+func TestNewRowNumbers(t *testing.T) {
+	wb, err := Open(buildTestXLSX(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	added, err := wb.NewRowNumbers("Sheet1", map[int]struct{}{9: {}, 1: {}, 2: {}})
+	if err != nil {
+		t.Fatalf("NewRowNumbers: %v", err)
+	}
+	if !reflect.DeepEqual(added, []int{2, 9}) {
+		t.Fatalf("expected rows 2 and 9, got %v", added)
+	}
+}
+
 func TestSetCellCreatesMissing(t *testing.T) {
 	data := buildTestXLSX(t)
 	wb, err := Open(data)
@@ -43,208 +90,1367 @@ func TestSetCellCreatesMissing(t *testing.T) {
 		t.Fatalf("Open: %v", err)
 	}
 
-	value := 3.5
-	if err := wb.SetCell("Sheet1", "B3", CellValue{Number: &value}); err != nil {
-		t.Fatalf("SetCell: %v", err)
+	value := 3.5
+	if err := wb.SetCell("Sheet1", "B3", CellValue{Number: &value}); err != nil {
+		t.Fatalf("SetCell: %v", err)
+	}
+
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
+	typ, val, ok := readCell(sheetData, "B3")
+	if !ok {
+		t.Fatalf("expected cell B3")
+	}
+	if typ != "" || val != "3.5" {
+		t.Fatalf("unexpected cell value: type=%q val=%q", typ, val)
+	}
+}
+
+func TestClearCellKeepsCellWithoutValue(t *testing.T) {
+	data := buildTestXLSXInlineStrRich(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	for _, ref := range []string{"A1", "B1", "C9"} {
+		if err := wb.ClearCell("Sheet1", ref); err != nil {
+			t.Fatalf("ClearCell %s: %v", ref, err)
+		}
+	}
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
+	for _, ref := range []string{"A1", "B1"} {
+		if typ, val, ok := readCell(sheetData, ref); !ok || typ != "" || val != "" {
+			t.Fatalf("expected %s to stay without a value, got type=%q val=%q ok=%v", ref, typ, val, ok)
+		}
+	}
+	// A missing cell is not created.
+	if _, _, ok := readCell(sheetData, "C9"); ok {
+		t.Fatalf("ClearCell must not add cells")
+	}
+	if rows, err := scanRowNumbers(sheetData); err != nil || len(rows) != 1 {
+		t.Fatalf("ClearCell must not add rows, got %v (%v)", rows, err)
+	}
+}
+
+func TestSetCellInsertsRowsInOrder(t *testing.T) {
+	cases := map[string]struct {
+		rows string
+		refs []string
+		want []int
+	}{
+		"sorted sheet": {
+			rows: `<row r="2"><c r="A2"><v>2</v></c></row><row r="5"><c r="A5"><v>5</v></c></row>`,
+			refs: []string{"B7", "A3", "A1", "B3", "B2"},
+			want: []int{1, 2, 3, 5, 7},
+		},
+		// Existing rows keep their order; each new row follows the row with
+		// the next lower number, or precedes the lowest row.
+		"out of order sheet": {
+			rows: `<row r="10"><c r="A10"><v>10</v></c></row><row r="2"><c r="A2"><v>2</v></c></row><row r="5"><c r="A5"><v>5</v></c></row>`,
+			refs: []string{"B7", "A3", "A1", "A11", "B2"},
+			want: []int{10, 11, 1, 2, 3, 5, 7},
+		},
+		"empty sheet": {
+			refs: []string{"B7", "A3", "A1", "B3"},
+			want: []int{1, 3, 7},
+		},
+	}
+	for name, tc := range cases {
+		sheet := `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + tc.rows + `</sheetData></worksheet>`
+		wb, err := Open(writeZip(t, sheetExtrasParts(sheet)))
+		if err != nil {
+			t.Fatalf("%s: Open: %v", name, err)
+		}
+		for i, ref := range tc.refs {
+			value := float64(i)
+			if err := wb.SetCell("Sheet1", ref, CellValue{Number: &value}); err != nil {
+				t.Fatalf("%s: SetCell %s: %v", name, ref, err)
+			}
+		}
+		out, err := wb.Save()
+		if err != nil {
+			t.Fatalf("%s: Save: %v", name, err)
+		}
+
+		sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
+		rows, err := scanRowNumbers(sheetData)
+		if err != nil {
+			t.Fatalf("%s: scanRowNumbers: %v", name, err)
+		}
+		if !reflect.DeepEqual(rows, tc.want) {
+			t.Fatalf("%s: rows %v, want %v", name, rows, tc.want)
+		}
+		for i, ref := range tc.refs {
+			if _, val, ok := readCell(sheetData, ref); !ok || val != strconv.Itoa(i) {
+				t.Fatalf("%s: cell %s = %q, %v", name, ref, val, ok)
+			}
+		}
+	}
+}
+
+func TestSetRangeValuesDown(t *testing.T) {
+	sheet := `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="2" spans="1:3" ht="20" customHeight="1"><c r="A2"><v>1</v></c><c r="C2" s="3"><v>3</v></c></row><row r="5"><c r="A5"><v>5</v></c></row></sheetData></worksheet>`
+	wb, err := Open(writeZip(t, sheetExtrasParts(sheet)))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	values := make([]CellValue, 6)
+	for i := range values {
+		value := float64(10 + i)
+		values[i] = CellValue{Number: &value}
+	}
+	label := "Total"
+	values[5] = CellValue{String: &label}
+
+	end, err := wb.SetRangeValues("Sheet1", "b1", values, RangeDown)
+	if err != nil {
+		t.Fatalf("SetRangeValues: %v", err)
+	}
+	if end != "B6" {
+		t.Fatalf("expected end cell B6, got %q", end)
+	}
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
+	rows, err := scanRowNumbers(sheetData)
+	if err != nil {
+		t.Fatalf("scanRowNumbers: %v", err)
+	}
+	if !reflect.DeepEqual(rows, []int{1, 2, 3, 4, 5, 6}) {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+	if want := []string{"B1", "A2", "B2", "C2", "B3", "B4", "A5", "B5", "B6"}; !reflect.DeepEqual(cellRefsInOrder(sheetData), want) {
+		t.Fatalf("cells %v, want %v", cellRefsInOrder(sheetData), want)
+	}
+	if !bytes.Contains(sheetData, []byte(`spans="1:3" ht="20" customHeight="1"`)) || !bytes.Contains(sheetData, []byte(`r="C2" s="3"`)) {
+		t.Fatalf("row attributes or unrelated cells not kept:\n%s", sheetData)
+	}
+	for ref, want := range map[string]string{"A2": "1", "B2": "11", "B5": "14", "C2": "3", "B6": "Total"} {
+		if _, val, ok := readCell(sheetData, ref); !ok || val != want {
+			t.Fatalf("cell %s = %q (ok=%v), want %q", ref, val, ok, want)
+		}
+	}
+}
+
+func TestSetRangeValuesRight(t *testing.T) {
+	wb, err := Open(buildTestXLSX(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	values := make([]CellValue, 4)
+	for i := range values {
+		value := float64(i)
+		values[i] = CellValue{Number: &value}
+	}
+	end, err := wb.SetRangeValues("Sheet1", "Y1", values, RangeRight)
+	if err != nil {
+		t.Fatalf("SetRangeValues: %v", err)
+	}
+	if end != "AB1" {
+		t.Fatalf("expected end cell AB1, got %q", end)
+	}
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
+	if want := []string{"A1", "Y1", "Z1", "AA1", "AB1"}; !reflect.DeepEqual(cellRefsInOrder(sheetData), want) {
+		t.Fatalf("cells %v, want %v", cellRefsInOrder(sheetData), want)
+	}
+}
+
+func TestSetRangeValuesRejectsWithoutWriting(t *testing.T) {
+	wb, err := Open(buildTestXLSX(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	one := 1.0
+	if _, err := wb.SetRangeValues("Sheet1", "A2", []CellValue{{Number: &one}, {}}, RangeDown); err == nil {
+		t.Fatalf("expected an empty value to be rejected")
+	}
+	if _, err := wb.SetRangeValues("Sheet1", "XFC1", []CellValue{{Number: &one}, {Number: &one}, {Number: &one}}, RangeRight); !errors.Is(err, ErrCellOutOfBounds) {
+		t.Fatalf("expected ErrCellOutOfBounds, got %v", err)
+	}
+	if _, err := wb.SetRangeValues("Sheet1", "A1", nil, RangeDown); err == nil {
+		t.Fatalf("expected empty values to be rejected")
+	}
+	if len(wb.overlay) != 0 {
+		t.Fatalf("rejected writes must not touch the sheet")
+	}
+}
+
+func TestSetCellInlineStr(t *testing.T) {
+	data := buildTestXLSX(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	text := "hello"
+	if err := wb.SetCell("Sheet1", "C2", CellValue{String: &text}); err != nil {
+		t.Fatalf("SetCell: %v", err)
+	}
+
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
+	typ, val, ok := readCell(sheetData, "C2")
+	if !ok {
+		t.Fatalf("expected cell C2")
+	}
+	if typ != "inlineStr" || val != "hello" {
+		t.Fatalf("unexpected cell value: type=%q val=%q", typ, val)
+	}
+}
+
+func TestSetCellNormalizesText(t *testing.T) {
+	wb, err := Open(buildTestXLSX(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	cases := map[string][2]string{
+		"C2": {"one\r\ntwo\rthree", "one\ntwo\nthree"},
+		"C3": {"tab\there\vgone\x00", "tab\theregone"},
+		"C4": {"chart 📈 ok", "chart 📈 ok"},
+	}
+	for ref, tc := range cases {
+		text := tc[0]
+		if err := wb.SetCell("Sheet1", ref, CellValue{String: &text}); err != nil {
+			t.Fatalf("SetCell %s: %v", ref, err)
+		}
+	}
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
+	for ref, tc := range cases {
+		_, val, ok := readCell(sheetData, ref)
+		if !ok || val != tc[1] {
+			t.Fatalf("%s: got %q, want %q", ref, val, tc[1])
+		}
+	}
+}
+
+func TestInvalidXMLRune(t *testing.T) {
+	if r, ok := InvalidXMLRune("a\r\nb\vc"); !ok || r != '\v' {
+		t.Fatalf("got %U %v, want U+000B", r, ok)
+	}
+	if r, ok := InvalidXMLRune("line\r\nbreak 📈"); ok {
+		t.Fatalf("unexpected invalid rune %U", r)
+	}
+}
+
+func TestSetCellUnicodeSheetName(t *testing.T) {
+	data := buildTestXLSX(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	value := 7.0
+	if err := wb.SetCell("Data 📈", "A1", CellValue{Number: &value}); err != nil {
+		t.Fatalf("SetCell: %v", err)
+	}
+
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	sheetData := readSheet(t, out, "xl/worksheets/sheet2.xml")
+	typ, val, ok := readCell(sheetData, "A1")
+	if !ok {
+		t.Fatalf("expected cell A1")
+	}
+	if typ != "" || val != "7" {
+		t.Fatalf("unexpected cell value: type=%q val=%q", typ, val)
+	}
+}
+
+func TestPreservesSheetExtras(t *testing.T) {
+	data := buildTestXLSXWithExtras(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	value := 5.0
+	if err := wb.SetCell("Sheet1", "A1", CellValue{Number: &value}); err != nil {
+		t.Fatalf("SetCell: %v", err)
+	}
+
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
+	if !bytes.Contains(sheetData, []byte("<cols")) {
+		t.Fatalf("expected cols element preserved")
+	}
+	if !bytes.Contains(sheetData, []byte("<mergeCells")) {
+		t.Fatalf("expected mergeCells element preserved")
+	}
+
+	typ, val, ok := readCell(sheetData, "A1")
+	if !ok {
+		t.Fatalf("expected cell A1")
+	}
+	if typ != "" || val != "5" {
+		t.Fatalf("unexpected cell value: type=%q val=%q", typ, val)
+	}
+	typ, val, ok = readCell(sheetData, "B2")
+	if !ok {
+		t.Fatalf("expected cell B2")
+	}
+	if typ != "" || val != "2" {
+		t.Fatalf("unexpected cell value for B2: type=%q val=%q", typ, val)
+	}
+}
+
+func TestPreservesNonSheetDataChildrenBytes(t *testing.T) {
+	data := writeZip(t, sheetExtrasParts(sheetWithAllExtras))
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	value := 30.0
+	if err := wb.SetCell("Sheet1", "B2", CellValue{Number: &value}); err != nil {
+		t.Fatalf("SetCell existing: %v", err)
+	}
+	label := "Q3"
+	if err := wb.SetCell("Sheet1", "A4", CellValue{String: &label}); err != nil {
+		t.Fatalf("SetCell appended row: %v", err)
+	}
+
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	sheet := readSheet(t, out, "xl/worksheets/sheet1.xml")
+
+	original := []byte(sheetWithAllExtras)
+	prefix := original[:bytes.Index(original, []byte("<sheetData>"))]
+	suffix := original[bytes.Index(original, []byte("</sheetData>"))+len("</sheetData>"):]
+	if !bytes.HasPrefix(sheet, prefix) {
+		t.Fatalf("bytes before sheetData changed:\n%s", sheet)
+	}
+	if !bytes.HasSuffix(sheet, suffix) {
+		t.Fatalf("bytes after sheetData changed:\n%s", sheet)
+	}
+
+	if typ, val, ok := readCell(sheet, "A4"); !ok || typ != "inlineStr" || val != "Q3" {
+		t.Fatalf("unexpected appended cell A4: type=%q val=%q ok=%v", typ, val, ok)
+	}
+	if typ, val, ok := readCell(sheet, "B2"); !ok || typ != "" || val != "30" {
+		t.Fatalf("unexpected cell B2: type=%q val=%q ok=%v", typ, val, ok)
+	}
+}
+
+// frozenSheet freezes the header row and splits the second sheetView, whose
+// pane must be ignored.
+const frozenSheet = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetViews><sheetView tabSelected="1" workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/><selection pane="bottomLeft" activeCell="A2" sqref="A2"/></sheetView><sheetView workbookViewId="1"><pane xSplit="2400" ySplit="1800"/></sheetView></sheetViews>
+  <sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Quarter</t></is></c></row><row r="2"><c r="A2" t="inlineStr"><is><t>Q1</t></is></c><c r="B2"><v>10</v></c></row></sheetData>
+  <pageMargins left="0.7" right="0.7" top="0.75" bottom="0.75" header="0.3" footer="0.3"/>
+</worksheet>`
+
+func frozenSheetParts() map[string][]byte {
+	parts := sheetExtrasParts(frozenSheet)
+	parts["xl/workbook.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Notes" sheetId="2" r:id="rId2"/>
+    <sheet name="Sheet1" sheetId="1" r:id="rId1"/>
+  </sheets>
+  <definedNames>
+    <definedName name="_xlnm.Print_Area" localSheetId="1">Sheet1!$A$1:$B$2</definedName>
+    <definedName name="Totals">Sheet1!$B$2</definedName>
+  </definedNames>
+</workbook>`)
+	parts["xl/_rels/workbook.xml.rels"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+</Relationships>`)
+	parts["xl/worksheets/sheet2.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`)
+	return parts
+}
+
+func TestSheetLayout(t *testing.T) {
+	wb, err := Open(writeZip(t, frozenSheetParts()))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	names, err := wb.SheetNames()
+	if err != nil {
+		t.Fatalf("SheetNames: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"Notes", "Sheet1"}) {
+		t.Fatalf("unexpected sheet order %v", names)
+	}
+
+	layout, err := wb.SheetLayout("Sheet1")
+	if err != nil {
+		t.Fatalf("SheetLayout: %v", err)
+	}
+	if layout.PrintArea != "Sheet1!$A$1:$B$2" {
+		t.Fatalf("unexpected print area %q", layout.PrintArea)
+	}
+	want := &Pane{State: "frozen", YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}
+	if !reflect.DeepEqual(layout.Pane, want) || layout.Pane.FrozenRows() != 1 || layout.Pane.FrozenColumns() != 0 {
+		t.Fatalf("unexpected pane %+v", layout.Pane)
+	}
+
+	notes, err := wb.SheetLayout("Notes")
+	if err != nil {
+		t.Fatalf("SheetLayout Notes: %v", err)
+	}
+	if notes.Pane != nil || notes.PrintArea != "" {
+		t.Fatalf("expected no layout on Notes, got %+v", notes)
+	}
+
+	if split := (Pane{State: "split", YSplit: 1800}); split.Frozen() || split.FrozenRows() != 0 {
+		t.Fatalf("a split pane freezes nothing")
+	}
+}
+
+func TestDate1904(t *testing.T) {
+	wb, err := Open(writeZip(t, frozenSheetParts()))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if date1904, err := wb.Date1904(); err != nil || date1904 {
+		t.Fatalf("expected the 1900 date system, got %v, %v", date1904, err)
+	}
+
+	parts := frozenSheetParts()
+	parts["xl/workbook.xml"] = bytes.Replace(parts["xl/workbook.xml"], []byte("<sheets>"), []byte(`<workbookPr date1904="1"/><sheets>`), 1)
+	wb, err = Open(writeZip(t, parts))
+	if err != nil {
+		t.Fatalf("Open 1904: %v", err)
+	}
+	if date1904, err := wb.Date1904(); err != nil || !date1904 {
+		t.Fatalf("expected the 1904 date system, got %v, %v", date1904, err)
+	}
+}
+
+func TestSheetLayoutSurvivesWrites(t *testing.T) {
+	wb, err := Open(writeZip(t, frozenSheetParts()))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	before, err := wb.SheetLayout("Sheet1")
+	if err != nil {
+		t.Fatalf("SheetLayout: %v", err)
+	}
+
+	value := 30.0
+	if err := wb.SetCell("Sheet1", "B2", CellValue{Number: &value}); err != nil {
+		t.Fatalf("SetCell existing: %v", err)
+	}
+	for _, ref := range []string{"A3", "B4", "C9"} {
+		if err := wb.SetCell("Sheet1", ref, CellValue{Number: &value}); err != nil {
+			t.Fatalf("SetCell appended %s: %v", ref, err)
+		}
+	}
+	out, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	sheet := readSheet(t, out, "xl/worksheets/sheet1.xml")
+	views := frozenSheet[strings.Index(frozenSheet, "<sheetViews>"):strings.Index(frozenSheet, "<sheetData>")]
+	if !bytes.Contains(sheet, []byte(views)) || bytes.Index(sheet, []byte("<sheetViews>")) > bytes.Index(sheet, []byte("<sheetData")) {
+		t.Fatalf("sheetViews dropped or moved:\n%s", sheet)
+	}
+	if bytes.Contains(sheet, []byte(`main" xmlns=`)) {
+		t.Fatalf("repeated writes declared the namespace twice:\n%s", sheet)
+	}
+
+	reopened, err := Open(out)
+	if err != nil {
+		t.Fatalf("Open saved: %v", err)
+	}
+	after, err := reopened.SheetLayout("Sheet1")
+	if err != nil {
+		t.Fatalf("SheetLayout saved: %v", err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Fatalf("layout changed: before %+v, after %+v", before, after)
+	}
+}
+
+func TestCheckWorksheetChildren(t *testing.T) {
+	original := []byte(sheetWithAllExtras)
+	cases := []struct {
+		name    string
+		rewrite func([]byte) []byte
+		wantErr bool
+	}{
+		{
+			name: "sheetData and dimension may change",
+			rewrite: func(data []byte) []byte {
+				data = bytes.Replace(data, []byte(`<dimension ref="A1:B3"/>`), []byte(`<dimension ref="A1:B4"/>`), 1)
+				return bytes.Replace(data, []byte("</sheetData>"), []byte(`<row r="4"/></sheetData>`), 1)
+			},
+		},
+		{
+			name: "dropped dataValidations",
+			rewrite: func(data []byte) []byte {
+				start := bytes.Index(data, []byte("<dataValidations"))
+				end := bytes.Index(data, []byte("</dataValidations>")) + len("</dataValidations>")
+				return append(append([]byte{}, data[:start]...), data[end:]...)
+			},
+			wantErr: true,
+		},
+		{
+			name: "altered legacyDrawing",
+			rewrite: func(data []byte) []byte {
+				return bytes.Replace(data, []byte(`<legacyDrawing r:id="rId1"/>`), []byte(`<legacyDrawing r:id="rId9"/>`), 1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "reordered hyperlinks",
+			rewrite: func(data []byte) []byte {
+				links := []byte(`<hyperlinks><hyperlink ref="B1" r:id="rId2" display="Source"/></hyperlinks>`)
+				data = bytes.Replace(data, links, nil, 1)
+				return bytes.Replace(data, []byte("<legacyDrawing"), append(links, []byte("<legacyDrawing")...), 1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkWorksheetChildren(original, tc.rewrite(append([]byte{}, original...)))
+			if tc.wantErr && err == nil {
+				t.Fatalf("expected structure check to fail")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("expected structure check to pass, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetRangeValuesColumn(t *testing.T) {
+	data := buildTestXLSX(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	values, err := wb.GetRangeValues("Sheet1", "A1", "A2", MissingNumericEmpty)
+	if err != nil {
+		t.Fatalf("GetRangeValues: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(values))
+	}
+	if values[0] != "1" || values[1] != "" {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+}
+
+func TestGetRangeValuesRow(t *testing.T) {
+	data := buildTestXLSX(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	text := "hello"
+	if err := wb.SetCell("Sheet1", "B1", CellValue{String: &text}); err != nil {
+		t.Fatalf("SetCell: %v", err)
+	}
+	updated, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	wb, err = Open(updated)
+	if err != nil {
+		t.Fatalf("Open updated: %v", err)
+	}
+
+	values, err := wb.GetRangeValues("Sheet1", "A1", "B1", MissingNumericEmpty)
+	if err != nil {
+		t.Fatalf("GetRangeValues: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(values))
+	}
+	if values[0] != "1" || values[1] != "hello" {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+}
+
+func TestGetRangeValuesMissingNumericZero(t *testing.T) {
+	data := buildTestXLSX(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	values, err := wb.GetRangeValues("Sheet1", "A1", "A2", MissingNumericZero)
+	if err != nil {
+		t.Fatalf("GetRangeValues: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(values))
+	}
+	if values[0] != "1" || values[1] != "0" {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+}
+
+func TestGetRangeValues2DReversedCorners(t *testing.T) {
+	data := buildTestXLSX(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	text := "hello"
+	if err := wb.SetCell("Sheet1", "B1", CellValue{String: &text}); err != nil {
+		t.Fatalf("SetCell: %v", err)
+	}
+	number := 7.0
+	if err := wb.SetCell("Sheet1", "B2", CellValue{Number: &number}); err != nil {
+		t.Fatalf("SetCell: %v", err)
+	}
+	updated, err := wb.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	wb, err = Open(updated)
+	if err != nil {
+		t.Fatalf("Open updated: %v", err)
+	}
+
+	want := []string{"1", "hello", "0", "7"}
+	for _, corners := range [][2]string{{"A1", "B2"}, {"B2", "A1"}, {"$B$1", "$A$2"}} {
+		grid, err := wb.GetRangeValues2D("Sheet1", corners[0], corners[1], MissingNumericZero)
+		if err != nil {
+			t.Fatalf("GetRangeValues2D %s:%s: %v", corners[0], corners[1], err)
+		}
+		if grid.Rows != 2 || grid.Cols != 2 || !reflect.DeepEqual(grid.Values, want) {
+			t.Fatalf("%s:%s: unexpected grid %#v", corners[0], corners[1], grid)
+		}
+		if grid.At(1, 0) != "0" {
+			t.Fatalf("the missing A2 must follow the policy, got %q", grid.At(1, 0))
+		}
+	}
+
+	grid, err := wb.GetRangeValues2D("Sheet1", "A1", "A2", MissingNumericEmpty)
+	if err != nil {
+		t.Fatalf("GetRangeValues2D column: %v", err)
+	}
+	if grid.Rows != 2 || grid.Cols != 1 || !reflect.DeepEqual(grid.Values, []string{"1", ""}) {
+		t.Fatalf("unexpected column grid %#v", grid)
+	}
+
+	if _, err := wb.GetRangeValues("Sheet1", "A1", "B2", MissingNumericEmpty); err == nil {
+		t.Fatalf("GetRangeValues must keep rejecting 2D ranges")
+	}
+}
+
+func TestGetRangeValuesInlineStrRichText(t *testing.T) {
+	data := buildTestXLSXInlineStrRich(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	values, err := wb.GetRangeValues("Sheet1", "A1", "B1", MissingNumericEmpty)
+	if err != nil {
+		t.Fatalf("GetRangeValues: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(values))
+	}
+	if values[0] != "1" || values[1] != "Hello World" {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+}
+
+func TestGetRangeValuesSharedStrings(t *testing.T) {
+	parts := sheetExtrasParts(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="s"><v>1</v></c></row>
+    <row r="2"><c r="A2" t="s"><v>0</v></c></row>
+    <row r="3"><c r="A3"><v>5</v></c></row>
+    <row r="4"><c r="A4" t="s"><v>2</v></c></row>
+  </sheetData>
+</worksheet>`)
+	// No relationship names the table, so the default part is used.
+	parts["xl/sharedStrings.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si><t>North</t></si>
+  <si><r><rPr><b/></rPr><t>Hello</t></r><r><t xml:space="preserve"> World</t></r></si>
+  <si><t>West</t><rPh sb="0" eb="4"><t>ignored</t></rPh></si>
+</sst>`)
+	wb, err := Open(writeZip(t, parts))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	want := []string{"Hello World", "North", "5", "West"}
+	values, err := wb.GetRangeValues("Sheet1", "A1", "A4", MissingNumericEmpty)
+	if err != nil {
+		t.Fatalf("GetRangeValues: %v", err)
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+
+	streamed := make([]string, len(want))
+	err = wb.StreamRanges([]Range{{Sheet: "Sheet1", StartCell: "A1", EndCell: "A4"}}, MissingNumericEmpty, func(_, pos int, value string) error {
+		streamed[pos] = value
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamRanges: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, want) {
+		t.Fatalf("unexpected streamed values: %#v", streamed)
+	}
+}
+
+func TestGetRangeValuesSharedStringIndexOutOfRange(t *testing.T) {
+	parts := sheetExtrasParts(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="s"><v>3</v></c></row>
+  </sheetData>
+</worksheet>`)
+	wb, err := Open(writeZip(t, parts))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	_, err = wb.GetRangeValues("Sheet1", "A1", "A1", MissingNumericEmpty)
+	if err == nil || !strings.Contains(err.Error(), `shared string index "3" at A1`) {
+		t.Fatalf("expected a shared string index error, got %v", err)
+	}
+}
+
+func TestGetRangesMatchesGetRangeValues(t *testing.T) {
+	data := buildTestXLSXInlineStrRich(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	ranges := []Range{
+		{Sheet: "Sheet1", StartCell: "A1", EndCell: "B1"},
+		{Sheet: "Sheet1", StartCell: "A2", EndCell: "A1"},
+		{Sheet: "Sheet1", StartCell: "B1", EndCell: "B1"},
+	}
+	batched, err := wb.GetRanges(ranges, MissingNumericZero)
+	if err != nil {
+		t.Fatalf("GetRanges: %v", err)
+	}
+	if len(batched) != len(ranges) {
+		t.Fatalf("expected %d results, got %d", len(ranges), len(batched))
+	}
+	for i, r := range ranges {
+		single, err := wb.GetRangeValues(r.Sheet, r.StartCell, r.EndCell, MissingNumericZero)
+		if err != nil {
+			t.Fatalf("GetRangeValues: %v", err)
+		}
+		if len(single) != len(batched[i]) {
+			t.Fatalf("range %d length mismatch: %v vs %v", i, single, batched[i])
+		}
+		for j := range single {
+			if single[j] != batched[i][j] {
+				t.Fatalf("range %d value mismatch: %v vs %v", i, single, batched[i])
+			}
+		}
+	}
+}
+
+func TestGetRangesUnknownSheet(t *testing.T) {
+	data := buildTestXLSX(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if _, err := wb.GetRanges([]Range{{Sheet: "Missing", StartCell: "A1", EndCell: "A2"}}, MissingNumericEmpty); err == nil {
+		t.Fatalf("expected missing sheet error")
+	}
+}
+
+func TestStreamRangesMatchesGetRanges(t *testing.T) {
+	data := buildTestXLSXInlineStrRich(t)
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	ranges := []Range{
+		{Sheet: "Sheet1", StartCell: "A1", EndCell: "B1"},
+		{Sheet: "Sheet1", StartCell: "A2", EndCell: "A1"},
+		{Sheet: "Sheet1", StartCell: "B1", EndCell: "B1"},
+	}
+	batched, err := wb.GetRanges(ranges, MissingNumericZero)
+	if err != nil {
+		t.Fatalf("GetRanges: %v", err)
+	}
+
+	streamed := make([][]string, len(ranges))
+	for i := range ranges {
+		streamed[i] = make([]string, len(batched[i]))
+	}
+	calls := 0
+	err = wb.StreamRanges(ranges, MissingNumericZero, func(rangeIndex, pos int, value string) error {
+		calls++
+		streamed[rangeIndex][pos] = value
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamRanges: %v", err)
+	}
+	if calls != 5 {
+		t.Fatalf("expected one call per requested cell, got %d", calls)
+	}
+	for i := range ranges {
+		for j := range batched[i] {
+			if streamed[i][j] != batched[i][j] {
+				t.Fatalf("range %d value mismatch: %v vs %v", i, streamed[i], batched[i])
+			}
+		}
+	}
+}
+
+func TestStreamRangesStopsOnEmitError(t *testing.T) {
+	wb, err := Open(buildTestXLSX(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = wb.StreamRanges([]Range{{Sheet: "Sheet1", StartCell: "A1", EndCell: "A3"}}, MissingNumericEmpty, func(rangeIndex, pos int, value string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("expected the emit error after one call, got %v after %d calls", err, calls)
+	}
+}
+
+func TestOpenWithBudgetBoundsParts(t *testing.T) {
+	original := buildTestXLSX(t)
+	reader, err := zip.NewReader(bytes.NewReader(original), int64(len(original)))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	entries := make(map[string][]byte)
+	for _, part := range reader.File {
+		rc, err := part.Open()
+		if err != nil {
+			t.Fatalf("Open entry: %v", err)
+		}
+		entries[part.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+	}
+	sheet := entries["xl/worksheets/sheet2.xml"]
+	entries["xl/worksheets/sheet2.xml"] = bytes.Replace(sheet, []byte("</worksheet>"), append(bytes.Repeat([]byte(" "), 4096), "</worksheet>"...), 1)
+	data := writeZip(t, entries)
+
+	var refused []string
+	budget := ooxmlpkg.NewBudget(ooxmlpkg.Limits{MaxPartSize: 2048}, func(err *ooxmlpkg.PartTooLargeError) {
+		refused = append(refused, err.Part)
+	})
+	wb, err := OpenWithBudget(data, budget)
+	if err != nil {
+		t.Fatalf("OpenWithBudget: %v", err)
+	}
+	if values, err := wb.GetRanges([]Range{{Sheet: "Sheet1", StartCell: "A1", EndCell: "A1"}}, MissingNumericEmpty); err != nil || values[0][0] != "1" {
+		t.Fatalf("expected Sheet1 to read, got %v, %v", values, err)
+	}
+	if _, err := wb.GetRanges([]Range{{Sheet: "Data 📈", StartCell: "A1", EndCell: "A1"}}, MissingNumericEmpty); !errors.Is(err, ooxmlpkg.ErrPartTooLarge) {
+		t.Fatalf("expected ErrPartTooLarge, got %v", err)
+	}
+	if !reflect.DeepEqual(refused, []string{"xl/worksheets/sheet2.xml"}) {
+		t.Fatalf("unexpected refused parts: %v", refused)
+	}
+
+	if _, err := OpenWithBudget(data, ooxmlpkg.NewBudget(ooxmlpkg.Limits{MaxTotalSize: 256}, nil)); !errors.Is(err, ooxmlpkg.ErrPartTooLarge) {
+		t.Fatalf("expected Open to stop at the total, got %v", err)
+	}
+}
+
+func TestReadErrorsAreTyped(t *testing.T) {
+	data := writeZip(t, map[string][]byte{
+		"xl/workbook.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="not found" sheetId="1" r:id="rId1"/>
+    <sheet name="Gone" sheetId="2" r:id="rId2"/>
+  </sheets>
+</workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+</Relationships>`),
+		"xl/worksheets/sheet1.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="str"><f>B1&amp;"x"</f><v>x</v></c></row>
+  </sheetData>
+</worksheet>`),
+	})
+	wb, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	_, err = wb.GetRangeValues("Missing", "A1", "A1", MissingNumericEmpty)
+	var sheetErr *SheetNotFoundError
+	if !errors.Is(err, ErrSheetNotFound) || !errors.As(err, &sheetErr) || sheetErr.Sheet != "Missing" {
+		t.Fatalf("expected SheetNotFoundError, got %v", err)
 	}
 
-	out, err := wb.Save()
-	if err != nil {
-		t.Fatalf("Save: %v", err)
+	_, err = wb.GetRangeValues("not found", "A1", "A1", MissingNumericEmpty)
+	var cellErr *CellTypeError
+	if !errors.Is(err, ErrUnsupportedCellType) || !errors.As(err, &cellErr) || cellErr.Cell != "A1" || cellErr.Type != "str" {
+		t.Fatalf("expected CellTypeError, got %v", err)
 	}
-
-	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
-	typ, val, ok := readCell(sheetData, "B3")
-	if !ok {
-		t.Fatalf("expected cell B3")
+	if errors.Is(err, ErrSheetNotFound) {
+		t.Fatalf("a sheet named %q must not read as missing", "not found")
 	}
-	if typ != "" || val != "3.5" {
-		t.Fatalf("unexpected cell value: type=%q val=%q", typ, val)
+
+	_, err = wb.GetRangeValues("Gone", "A1", "A1", MissingNumericEmpty)
+	var partErr *PartMissingError
+	if !errors.Is(err, ErrPartMissing) || !errors.As(err, &partErr) || partErr.Part != "xl/worksheets/sheet2.xml" {
+		t.Fatalf("expected PartMissingError, got %v", err)
 	}
 }
 
-func TestSetCellInlineStr(t *testing.T) {
-	data := buildTestXLSX(t)
+func TestFormulaCells(t *testing.T) {
+	data := writeZip(t, map[string][]byte{
+		"xl/workbook.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`),
+		"xl/worksheets/sheet1.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1"><v>40</v></c><c r="B1" s="2"><f>SUM(A1,2)</f><v>42</v></c></row>
+  </sheetData>
+</worksheet>`),
+	})
 	wb, err := Open(data)
 	if err != nil {
 		t.Fatalf("Open: %v", err)
 	}
 
-	text := "hello"
-	if err := wb.SetCell("Sheet1", "C2", CellValue{String: &text}); err != nil {
-		t.Fatalf("SetCell: %v", err)
-	}
-
-	out, err := wb.Save()
+	var cached []string
+	wb.SetFormulaPolicy(FormulaCachedValue, func(sheet, cell string) {
+		cached = append(cached, sheet+"!"+cell)
+	})
+	values, err := wb.GetRangeValues("Sheet1", "A1", "B1", MissingNumericEmpty)
 	if err != nil {
-		t.Fatalf("Save: %v", err)
+		t.Fatalf("GetRangeValues: %v", err)
 	}
-
-	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
-	typ, val, ok := readCell(sheetData, "C2")
-	if !ok {
-		t.Fatalf("expected cell C2")
+	if !reflect.DeepEqual(values, []string{"40", "42"}) {
+		t.Fatalf("unexpected values: %v", values)
 	}
-	if typ != "inlineStr" || val != "hello" {
-		t.Fatalf("unexpected cell value: type=%q val=%q", typ, val)
+	if !reflect.DeepEqual(cached, []string{"Sheet1!B1"}) {
+		t.Fatalf("unexpected cached formula cells: %v", cached)
 	}
-}
 
-func TestSetCellUnicodeSheetName(t *testing.T) {
-	data := buildTestXLSX(t)
-	wb, err := Open(data)
-	if err != nil {
-		t.Fatalf("Open: %v", err)
+	wb.SetFormulaPolicy(FormulaReject, nil)
+	_, err = wb.GetRangeValues("Sheet1", "A1", "B1", MissingNumericEmpty)
+	var cellErr *CellTypeError
+	if !errors.Is(err, ErrUnsupportedCellType) || !errors.As(err, &cellErr) || !cellErr.Formula || cellErr.Cell != "B1" {
+		t.Fatalf("expected formula CellTypeError, got %v", err)
 	}
 
-	value := 7.0
-	if err := wb.SetCell("Data 📈", "A1", CellValue{Number: &value}); err != nil {
+	var overwritten []string
+	wb.SetFormulaOverwrite(func(sheet, cell string) {
+		overwritten = append(overwritten, sheet+"!"+cell)
+	})
+	number := 7.0
+	if err := wb.SetCell("Sheet1", "B1", CellValue{Number: &number}); err != nil {
 		t.Fatalf("SetCell: %v", err)
 	}
-
-	out, err := wb.Save()
-	if err != nil {
-		t.Fatalf("Save: %v", err)
+	if err := wb.SetCell("Sheet1", "A1", CellValue{Number: &number}); err != nil {
+		t.Fatalf("SetCell: %v", err)
 	}
-
-	sheetData := readSheet(t, out, "xl/worksheets/sheet2.xml")
-	typ, val, ok := readCell(sheetData, "A1")
-	if !ok {
-		t.Fatalf("expected cell A1")
+	if !reflect.DeepEqual(overwritten, []string{"Sheet1!B1"}) {
+		t.Fatalf("unexpected overwritten formula cells: %v", overwritten)
 	}
-	if typ != "" || val != "7" {
-		t.Fatalf("unexpected cell value: type=%q val=%q", typ, val)
+	sheet := string(wb.overlay["xl/worksheets/sheet1.xml"])
+	if strings.Contains(sheet, "<f>") || !strings.Contains(sheet, `r="B1" s="2"`) {
+		t.Fatalf("formula was not replaced: %s", sheet)
+	}
+	values, err = wb.GetRangeValues("Sheet1", "B1", "B1", MissingNumericEmpty)
+	if err != nil || values[0] != "7" {
+		t.Fatalf("expected the written value, got %v, %v", values, err)
 	}
 }
 
-func TestPreservesSheetExtras(t *testing.T) {
-	data := buildTestXLSXWithExtras(t)
+func TestBoolAndErrorCells(t *testing.T) {
+	data := writeZip(t, map[string][]byte{
+		"xl/workbook.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`),
+		"xl/worksheets/sheet1.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="b"><v>1</v></c><c r="B1" t="b"><f>A2&gt;0</f><v>0</v></c><c r="C1" t="e"><f>1/0</f><v>#DIV/0!</v></c><c r="D1"><v>4</v></c></row>
+  </sheetData>
+</worksheet>`),
+	})
 	wb, err := Open(data)
 	if err != nil {
 		t.Fatalf("Open: %v", err)
 	}
 
-	value := 5.0
-	if err := wb.SetCell("Sheet1", "A1", CellValue{Number: &value}); err != nil {
-		t.Fatalf("SetCell: %v", err)
+	var errorCells []string
+	wb.SetErrorCells(func(sheet, cell, value string) {
+		errorCells = append(errorCells, sheet+"!"+cell+"="+value)
+	})
+	values, err := wb.GetRangeValues("Sheet1", "A1", "D1", MissingNumericZero)
+	if err != nil {
+		t.Fatalf("GetRangeValues: %v", err)
+	}
+	if !reflect.DeepEqual(values, []string{"1", "0", "0", "4"}) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	if !reflect.DeepEqual(errorCells, []string{"Sheet1!C1=#DIV/0!"}) {
+		t.Fatalf("unexpected error cells: %v", errorCells)
 	}
 
-	out, err := wb.Save()
+	wb.SetBoolFormat(BoolWords)
+	var streamed []string
+	err = wb.StreamRanges([]Range{{Sheet: "Sheet1", StartCell: "A1", EndCell: "D1"}}, MissingNumericEmpty, func(rangeIndex, pos int, value string) error {
+		streamed = append(streamed, value)
+		return nil
+	})
 	if err != nil {
-		t.Fatalf("Save: %v", err)
+		t.Fatalf("StreamRanges: %v", err)
 	}
+	if !reflect.DeepEqual(streamed, []string{"TRUE", "FALSE", "4", ""}) {
+		t.Fatalf("unexpected streamed values: %v", streamed)
+	}
+}
 
-	sheetData := readSheet(t, out, "xl/worksheets/sheet1.xml")
-	if !bytes.Contains(sheetData, []byte("<cols")) {
-		t.Fatalf("expected cols element preserved")
+func TestSetFullCalcOnLoad(t *testing.T) {
+	cases := map[string]struct {
+		in, want string
+	}{
+		"adds calcPr before extLst": {
+			`<workbook xmlns="m"><sheets><sheet name="A"/></sheets><definedNames/><extLst/></workbook>`,
+			`<workbook xmlns="m"><sheets><sheet name="A"/></sheets><definedNames/><calcPr fullCalcOnLoad="1"/><extLst/></workbook>`,
+		},
+		"adds calcPr at the end": {
+			`<x:workbook xmlns:x="m"><x:sheets/></x:workbook>`,
+			`<x:workbook xmlns:x="m"><x:sheets/><x:calcPr fullCalcOnLoad="1"/></x:workbook>`,
+		},
+		"sets the attribute": {
+			`<workbook xmlns="m"><sheets/><calcPr calcId="191029"/></workbook>`,
+			`<workbook xmlns="m"><sheets/><calcPr calcId="191029" fullCalcOnLoad="1"/></workbook>`,
+		},
+		"replaces the attribute": {
+			`<workbook xmlns="m"><sheets/><calcPr fullCalcOnLoad='0' calcId="1"></calcPr></workbook>`,
+			`<workbook xmlns="m"><sheets/><calcPr fullCalcOnLoad="1" calcId="1"></calcPr></workbook>`,
+		},
 	}
-	if !bytes.Contains(sheetData, []byte("<mergeCells")) {
-		t.Fatalf("expected mergeCells element preserved")
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := setFullCalcOnLoad([]byte(tc.in))
+			if err != nil {
+				t.Fatalf("setFullCalcOnLoad: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("got %s, want %s", got, tc.want)
+			}
+		})
 	}
+}
 
-	typ, val, ok := readCell(sheetData, "A1")
-	if !ok {
-		t.Fatalf("expected cell A1")
+// indexTestWorkbook holds every cell kind reads resolve: numbers, shared
+// and rich inline strings, booleans, errors, and formulas.
+func indexTestWorkbook(t *testing.T) []byte {
+	t.Helper()
+
+	return writeZip(t, map[string][]byte{
+		"xl/workbook.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/><sheet name="Sheet2" sheetId="2" r:id="rId2"/></sheets>
+</workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+</Relationships>`),
+		"xl/sharedStrings.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>shared</t></si></sst>`),
+		"xl/worksheets/sheet1.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><r><t>Rich</t></r><r><t> text</t></r></is></c><c r="C1" t="b"><v>1</v></c></row>
+    <row r="2"><c r="A2"><v> 12 </v></c><c r="B2"><f>A2*2</f><v>24</v></c><c r="C2" t="e"><v>#N/A</v></c></row>
+    <row r="4"><c r="A4"><f>B2+1</f><v>25</v></c><c r="C4"/></row>
+  </sheetData>
+</worksheet>`),
+		"xl/worksheets/sheet2.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData><row r="1"><c r="A1"><v>7</v></c></row></sheetData>
+</worksheet>`),
+	})
+}
+
+func TestLoadSheetIndexMatchesScan(t *testing.T) {
+	ranges := []Range{
+		{Sheet: "Sheet1", StartCell: "A1", EndCell: "A4"},
+		{Sheet: "Sheet1", StartCell: "C1", EndCell: "A1"},
+		{Sheet: "Sheet1", StartCell: "B1", EndCell: "B4"},
+		{Sheet: "Sheet1", StartCell: "C1", EndCell: "C4"},
+		{Sheet: "Sheet2", StartCell: "A1", EndCell: "B1"},
 	}
-	if typ != "" || val != "5" {
-		t.Fatalf("unexpected cell value: type=%q val=%q", typ, val)
+	read := func(indexed bool) ([][]string, Grid, []string) {
+		wb, err := Open(indexTestWorkbook(t))
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		var reported []string
+		wb.SetFormulaPolicy(FormulaCachedValue, func(sheet, cell string) {
+			reported = append(reported, "formula "+sheet+"!"+cell)
+		})
+		wb.SetErrorCells(func(sheet, cell, value string) {
+			reported = append(reported, "error "+sheet+"!"+cell+"="+value)
+		})
+		wb.SetBoolFormat(BoolWords)
+		if indexed {
+			if err := wb.LoadSheetIndex("Sheet1"); err != nil {
+				t.Fatalf("LoadSheetIndex: %v", err)
+			}
+			if !wb.SheetIndexed("Sheet1") || wb.SheetIndexed("Sheet2") {
+				t.Fatalf("expected only Sheet1 to be indexed")
+			}
+		}
+		var out [][]string
+		for _, r := range ranges {
+			values, err := wb.GetRangeValues(r.Sheet, r.StartCell, r.EndCell, MissingNumericZero)
+			if err != nil {
+				t.Fatalf("GetRangeValues %v: %v", r, err)
+			}
+			out = append(out, values)
+		}
+		batched, err := wb.GetRanges(ranges, MissingNumericEmpty)
+		if err != nil {
+			t.Fatalf("GetRanges: %v", err)
+		}
+		out = append(out, batched...)
+		grid, err := wb.GetRangeValues2D("Sheet1", "C4", "A1", MissingNumericEmpty)
+		if err != nil {
+			t.Fatalf("GetRangeValues2D: %v", err)
+		}
+		return out, grid, reported
 	}
-	typ, val, ok = readCell(sheetData, "B2")
-	if !ok {
-		t.Fatalf("expected cell B2")
+
+	scanned, scannedGrid, scannedReports := read(false)
+	indexed, indexedGrid, indexedReports := read(true)
+	if !reflect.DeepEqual(indexed, scanned) {
+		t.Fatalf("indexed values differ:\nindexed=%q\nscanned=%q", indexed, scanned)
 	}
-	if typ != "" || val != "2" {
-		t.Fatalf("unexpected cell value for B2: type=%q val=%q", typ, val)
+	if !reflect.DeepEqual(indexedGrid, scannedGrid) {
+		t.Fatalf("indexed grid differs:\nindexed=%q\nscanned=%q", indexedGrid, scannedGrid)
+	}
+	if !reflect.DeepEqual(indexedReports, scannedReports) {
+		t.Fatalf("indexed reports differ:\nindexed=%q\nscanned=%q", indexedReports, scannedReports)
+	}
+	if want := []string{"shared", "12", "0", "25"}; !reflect.DeepEqual(indexed[0], want) {
+		t.Fatalf("unexpected A1:A4 %q, want %q", indexed[0], want)
 	}
-}
 
-func TestGetRangeValuesColumn(t *testing.T) {
-	data := buildTestXLSX(t)
-	wb, err := Open(data)
+	wb, err := Open(indexTestWorkbook(t))
 	if err != nil {
 		t.Fatalf("Open: %v", err)
 	}
-
-	values, err := wb.GetRangeValues("Sheet1", "A1", "A2", MissingNumericEmpty)
-	if err != nil {
-		t.Fatalf("GetRangeValues: %v", err)
+	if err := wb.LoadSheetIndex("Sheet1"); err != nil {
+		t.Fatalf("LoadSheetIndex: %v", err)
 	}
-	if len(values) != 2 {
-		t.Fatalf("expected 2 values, got %d", len(values))
+	wb.SetFormulaPolicy(FormulaReject, nil)
+	var cellErr *CellTypeError
+	if _, err := wb.GetRangeValues("Sheet1", "B1", "B2", MissingNumericEmpty); !errors.As(err, &cellErr) || !cellErr.Formula || cellErr.Cell != "B2" {
+		t.Fatalf("expected the policy set after indexing to apply, got %v", err)
 	}
-	if values[0] != "1" || values[1] != "" {
-		t.Fatalf("unexpected values: %#v", values)
+	if err := wb.LoadSheetIndex("Missing"); !errors.Is(err, ErrSheetNotFound) {
+		t.Fatalf("expected ErrSheetNotFound, got %v", err)
 	}
 }
 
-func TestGetRangeValuesRow(t *testing.T) {
-	data := buildTestXLSX(t)
-	wb, err := Open(data)
+func TestSheetIndexDroppedOnWrite(t *testing.T) {
+	wb, err := Open(indexTestWorkbook(t))
 	if err != nil {
 		t.Fatalf("Open: %v", err)
 	}
-
-	text := "hello"
-	if err := wb.SetCell("Sheet1", "B1", CellValue{String: &text}); err != nil {
+	if err := wb.LoadSheetIndex("Sheet1"); err != nil {
+		t.Fatalf("LoadSheetIndex: %v", err)
+	}
+	value := 99.0
+	if err := wb.SetCell("Sheet1", "A2", CellValue{Number: &value}); err != nil {
 		t.Fatalf("SetCell: %v", err)
 	}
-	updated, err := wb.Save()
-	if err != nil {
-		t.Fatalf("Save: %v", err)
+	if wb.SheetIndexed("Sheet1") {
+		t.Fatalf("expected SetCell to drop the index")
 	}
-	wb, err = Open(updated)
-	if err != nil {
-		t.Fatalf("Open updated: %v", err)
+	if err := wb.LoadSheetIndex("Sheet1"); err != nil {
+		t.Fatalf("LoadSheetIndex: %v", err)
+	}
+	values, err := wb.GetRangeValues("Sheet1", "A2", "B2", MissingNumericEmpty)
+	if err != nil || !reflect.DeepEqual(values, []string{"99", "24"}) {
+		t.Fatalf("expected the written value, got %q, %v", values, err)
 	}
 
-	values, err := wb.GetRangeValues("Sheet1", "A1", "B1", MissingNumericEmpty)
-	if err != nil {
-		t.Fatalf("GetRangeValues: %v", err)
+	if err := wb.ClearCell("Sheet1", "A2"); err != nil {
+		t.Fatalf("ClearCell: %v", err)
 	}
-	if len(values) != 2 {
-		t.Fatalf("expected 2 values, got %d", len(values))
+	if _, err := wb.SetRangeValues("Sheet1", "A5", []CellValue{{Number: &value}}, RangeDown); err != nil {
+		t.Fatalf("SetRangeValues: %v", err)
 	}
-	if values[0] != "1" || values[1] != "hello" {
-		t.Fatalf("unexpected values: %#v", values)
+	if wb.SheetIndexed("Sheet1") {
+		t.Fatalf("expected ClearCell and SetRangeValues to drop the index")
+	}
+	values, err = wb.GetRangeValues("Sheet1", "A2", "A5", MissingNumericEmpty)
+	if err != nil || !reflect.DeepEqual(values, []string{"", "", "25", "99"}) {
+		t.Fatalf("unexpected values after writes: %q, %v", values, err)
 	}
 }
 
-func TestGetRangeValuesMissingNumericZero(t *testing.T) {
-	data := buildTestXLSX(t)
-	wb, err := Open(data)
-	if err != nil {
-		t.Fatalf("Open: %v", err)
-	}
+const (
+	wideSheetSeries = 8
+	wideSheetPoints = 2000
+)
 
-	values, err := wb.GetRangeValues("Sheet1", "A1", "A2", MissingNumericZero)
-	if err != nil {
-		t.Fatalf("GetRangeValues: %v", err)
-	}
-	if len(values) != 2 {
-		t.Fatalf("expected 2 values, got %d", len(values))
+// BenchmarkWideChartRangesScanned and BenchmarkWideChartRangesIndexed read
+// the 17 ranges of a wide chart, categories and 8 series with their names,
+// one GetRangeValues call each: scanning the sheet per range, and through
+// LoadSheetIndex.
+func BenchmarkWideChartRangesScanned(b *testing.B) {
+	data, ranges := wideChartWorkbook(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wb, err := Open(data)
+		if err != nil {
+			b.Fatalf("Open: %v", err)
+		}
+		readWideChartRanges(b, wb, ranges)
 	}
-	if values[0] != "1" || values[1] != "0" {
-		t.Fatalf("unexpected values: %#v", values)
+}
+
+func BenchmarkWideChartRangesIndexed(b *testing.B) {
+	data, ranges := wideChartWorkbook(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wb, err := Open(data)
+		if err != nil {
+			b.Fatalf("Open: %v", err)
+		}
+		if err := wb.LoadSheetIndex("Sheet1"); err != nil {
+			b.Fatalf("LoadSheetIndex: %v", err)
+		}
+		readWideChartRanges(b, wb, ranges)
 	}
 }
 
-func TestGetRangeValuesInlineStrRichText(t *testing.T) {
-	data := buildTestXLSXInlineStrRich(t)
-	wb, err := Open(data)
-	if err != nil {
-		t.Fatalf("Open: %v", err)
+func readWideChartRanges(b *testing.B, wb *Workbook, ranges []Range) {
+	for _, r := range ranges {
+		if _, err := wb.GetRangeValues(r.Sheet, r.StartCell, r.EndCell, MissingNumericEmpty); err != nil {
+			b.Fatalf("GetRangeValues: %v", err)
+		}
 	}
+}
 
-	values, err := wb.GetRangeValues("Sheet1", "A1", "B1", MissingNumericEmpty)
-	if err != nil {
-		t.Fatalf("GetRangeValues: %v", err)
+// wideChartWorkbook lays out a chart table: names in row 1, categories in
+// column A, and one column of values per series.
+func wideChartWorkbook(b *testing.B) ([]byte, []Range) {
+	b.Helper()
+
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1">`)
+	for s := 0; s < wideSheetSeries; s++ {
+		col := indexToCol(s + 1)
+		sheet.WriteString(`<c r="` + col + `1" t="inlineStr"><is><t>Series ` + strconv.Itoa(s+1) + `</t></is></c>`)
 	}
-	if len(values) != 2 {
-		t.Fatalf("expected 2 values, got %d", len(values))
+	sheet.WriteString("</row>")
+	for row := 2; row <= wideSheetPoints+1; row++ {
+		r := strconv.Itoa(row)
+		sheet.WriteString(`<row r="` + r + `"><c r="A` + r + `" t="inlineStr"><is><t>Point ` + r + `</t></is></c>`)
+		for s := 0; s < wideSheetSeries; s++ {
+			sheet.WriteString(`<c r="` + indexToCol(s+1) + r + `"><v>` + strconv.Itoa(row*(s+1)) + `</v></c>`)
+		}
+		sheet.WriteString("</row>")
 	}
-	if values[0] != "1" || values[1] != "Hello World" {
-		t.Fatalf("unexpected values: %#v", values)
+	sheet.WriteString("</sheetData></worksheet>")
+
+	data := writeZip(b, map[string][]byte{
+		"xl/workbook.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`),
+		"xl/worksheets/sheet1.xml": []byte(sheet.String()),
+	})
+
+	last := strconv.Itoa(wideSheetPoints + 1)
+	ranges := []Range{{Sheet: "Sheet1", StartCell: "A2", EndCell: "A" + last}}
+	for s := 0; s < wideSheetSeries; s++ {
+		col := indexToCol(s + 1)
+		ranges = append(ranges,
+			Range{Sheet: "Sheet1", StartCell: col + "1", EndCell: col + "1"},
+			Range{Sheet: "Sheet1", StartCell: col + "2", EndCell: col + last},
+		)
 	}
+	return data, ranges
 }
 
 func buildTestXLSX(t *testing.T) []byte {
@@ -368,7 +1574,43 @@ func buildTestXLSXWithExtras(t *testing.T) []byte {
 	return writeZip(t, parts)
 }
 
-func writeZip(t *testing.T, parts map[string][]byte) []byte {
+// sheetWithAllExtras carries every worksheet child templates commonly keep
+// next to sheetData, in schema order, with prefixed r:id attributes.
+const sheetWithAllExtras = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" mc:Ignorable="x14ac" xmlns:x14ac="http://schemas.microsoft.com/office/spreadsheetml/2009/9/ac">
+  <dimension ref="A1:B3"/>
+  <sheetViews><sheetView workbookViewId="0"/></sheetViews>
+  <sheetFormatPr defaultRowHeight="15" x14ac:dyDescent="0.25"/>
+  <sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Quarter</t></is></c><c r="B1" t="inlineStr"><is><t>Revenue</t></is></c></row><row r="2"><c r="A2" t="inlineStr"><is><t>Q1</t></is></c><c r="B2"><v>10</v></c></row><row r="3"><c r="A3" t="inlineStr"><is><t>Q2</t></is></c><c r="B3"><v>20</v></c></row></sheetData>
+  <dataValidations count="1"><dataValidation type="list" allowBlank="1" showInputMessage="1" sqref="A2:A10"><formula1>"Q1,Q2,Q3,Q4"</formula1></dataValidation></dataValidations>
+  <hyperlinks><hyperlink ref="B1" r:id="rId2" display="Source"/></hyperlinks>
+  <pageMargins left="0.7" right="0.7" top="0.75" bottom="0.75" header="0.3" footer="0.3"/>
+  <pageSetup orientation="portrait"/>
+  <legacyDrawing r:id="rId1"/>
+  <extLst><ext uri="{CCE6A557-97BC-4b89-ADB6-D9C93CAAB3DF}" xmlns:x14="http://schemas.microsoft.com/office/spreadsheetml/2009/9/main"><x14:dataValidations count="0" xmlns:xm="http://schemas.microsoft.com/office/excel/2006/main"/></ext></extLst>
+</worksheet>`
+
+func sheetExtrasParts(sheetXML string) map[string][]byte {
+	return map[string][]byte{
+		"[Content_Types].xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="xml" ContentType="application/xml"/>
+</Types>`),
+		"xl/workbook.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Sheet1" sheetId="1" r:id="rId1"/>
+  </sheets>
+</workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`),
+		"xl/worksheets/sheet1.xml": []byte(sheetXML),
+	}
+}
+
+func writeZip(t testing.TB, parts map[string][]byte) []byte {
 	t.Helper()
 
 	var buf bytes.Buffer
@@ -482,3 +1724,22 @@ func readCell(data []byte, ref string) (string, string, bool) {
 
 	return "", "", false
 }
+
+// cellRefsInOrder lists the r attribute of every cell in document order.
+func cellRefsInOrder(data []byte) []string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var refs []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return refs
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "c" {
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "r" {
+					refs = append(refs, attr.Value)
+				}
+			}
+		}
+	}
+}
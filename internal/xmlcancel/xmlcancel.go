@@ -0,0 +1,51 @@
+// Package xmlcancel provides a cooperative cancellation flag for XML token
+// loops. Loops call Err between tokens and stop as soon as the flag is set, so
+// abandoned work ends promptly without leaking goroutines.
+package xmlcancel
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var ErrCanceled = errors.New("xmlcancel: processing canceled")
+
+// Flag is safe for concurrent use. A nil *Flag is never canceled.
+type Flag struct {
+	canceled atomic.Bool
+}
+
+func New() *Flag {
+	return &Flag{}
+}
+
+// After returns a flag that is canceled once d elapses, plus a stop
+// function releasing the timer. A non-positive d yields a nil flag.
+func After(d time.Duration) (*Flag, func()) {
+	if d <= 0 {
+		return nil, func() {}
+	}
+	flag := New()
+	timer := time.AfterFunc(d, flag.Cancel)
+	return flag, func() { timer.Stop() }
+}
+
+func (f *Flag) Cancel() {
+	if f == nil {
+		return
+	}
+	f.canceled.Store(true)
+}
+
+func (f *Flag) Canceled() bool {
+	return f != nil && f.canceled.Load()
+}
+
+// Err returns ErrCanceled once the flag is set, nil otherwise.
+func (f *Flag) Err() error {
+	if f.Canceled() {
+		return ErrCanceled
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package pptx
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Exemplar contexts are shipped to external monitoring, so they are bounded:
+// at most maxExemplarContextKeys keys, in key order, each value cut to
+// maxExemplarValueBytes.
+const (
+	maxExemplarContextKeys = 16
+	maxExemplarValueBytes  = 256
+)
+
+// redactedValue replaces the value of a redacted context key.
+const redactedValue = "[redacted]"
+
+// AlertSummary aggregates an alert list for batch reporting. Its JSON shape
+// is stable: maps are always present (encoding/json sorts their keys) and
+// exemplars are ordered by code.
+type AlertSummary struct {
+	Total   int            `json:"total"`
+	ByCode  map[string]int `json:"byCode"`
+	ByLevel map[string]int `json:"byLevel"`
+	// ByChart counts alerts by their "chart" context value, a part name
+	// such as "ppt/charts/chart1.xml". Alerts without one are not counted.
+	ByChart   map[string]int  `json:"byChart"`
+	Exemplars []AlertExemplar `json:"exemplars"`
+}
+
+// AlertExemplar is the first alert seen for a code.
+type AlertExemplar struct {
+	Code    string            `json:"code"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Context map[string]string `json:"context,omitempty"`
+	// Truncated is set when context keys were dropped or values cut.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// AggregateAlerts counts alerts per code, level, and chart and keeps the
+// first alert of each code as an exemplar. Alerts from several documents
+// can be concatenated first. Contexts are copied, not redacted; see
+// AlertSummary.Redacted.
+func AggregateAlerts(alerts []Alert) AlertSummary {
+	summary := AlertSummary{
+		ByCode:    map[string]int{},
+		ByLevel:   map[string]int{},
+		ByChart:   map[string]int{},
+		Exemplars: []AlertExemplar{},
+	}
+	for _, alert := range alerts {
+		summary.Total++
+		if summary.ByCode[alert.Code] == 0 {
+			summary.Exemplars = append(summary.Exemplars, newAlertExemplar(alert))
+		}
+		summary.ByCode[alert.Code]++
+		summary.ByLevel[alert.Level]++
+		if chart := alert.Context["chart"]; chart != "" {
+			summary.ByChart[chart]++
+		}
+	}
+	sort.SliceStable(summary.Exemplars, func(i, j int) bool {
+		return summary.Exemplars[i].Code < summary.Exemplars[j].Code
+	})
+	return summary
+}
+
+// Redacted returns a copy of the summary with the exemplar context values of
+// keys replaced by "[redacted]". Counts are unchanged.
+func (s AlertSummary) Redacted(keys []string) AlertSummary {
+	if len(keys) == 0 {
+		return s
+	}
+	redact := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redact[key] = true
+	}
+	out := s
+	out.Exemplars = make([]AlertExemplar, len(s.Exemplars))
+	for i, exemplar := range s.Exemplars {
+		if exemplar.Context != nil {
+			ctx := make(map[string]string, len(exemplar.Context))
+			for key, value := range exemplar.Context {
+				if redact[key] {
+					value = redactedValue
+				}
+				ctx[key] = value
+			}
+			exemplar.Context = ctx
+		}
+		out.Exemplars[i] = exemplar
+	}
+	return out
+}
+
+// AlertSummary aggregates the document's alerts, redacting the context keys
+// listed in Options.Privacy.RedactContextKeys.
+func (d *Document) AlertSummary() AlertSummary {
+	if d == nil {
+		return AggregateAlerts(nil)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return AggregateAlerts(d.alerts).Redacted(d.opts.Privacy.RedactContextKeys)
+}
+
+func newAlertExemplar(alert Alert) AlertExemplar {
+	exemplar := AlertExemplar{Code: alert.Code, Level: alert.Level, Message: alert.Message}
+	if len(alert.Context) == 0 {
+		return exemplar
+	}
+	keys := make([]string, 0, len(alert.Context))
+	for key := range alert.Context {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > maxExemplarContextKeys {
+		keys = keys[:maxExemplarContextKeys]
+		exemplar.Truncated = true
+	}
+	exemplar.Context = make(map[string]string, len(keys))
+	for _, key := range keys {
+		value := alert.Context[key]
+		if len(value) > maxExemplarValueBytes {
+			value = truncateUTF8(value, maxExemplarValueBytes) + "..."
+			exemplar.Truncated = true
+		}
+		exemplar.Context[key] = value
+	}
+	return exemplar
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,131 @@
+package pptx
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func syntheticAlerts() []Alert {
+	chart1 := "ppt/charts/chart1.xml"
+	chart2 := "ppt/charts/chart2.xml"
+	return []Alert{
+		{Level: "warn", Code: "CHART_CACHE_SYNC_FAILED", Message: "first", Context: map[string]string{"chart": chart1, "workbook": "/srv/decks/q1/embeddedWorkbook1.xlsx"}},
+		{Level: "warn", Code: "CHART_CACHE_SYNC_FAILED", Message: "second", Context: map[string]string{"chart": chart2}},
+		{Level: "warn", Code: "CHART_CACHE_SYNC_FAILED", Message: "third", Context: map[string]string{"chart": chart1}},
+		{Level: "info", Code: "CHART_LINT_PTCOUNT_MISSING", Message: "lint", Context: map[string]string{"chart": chart2, "path": "/srv/decks/q1.pptx"}},
+		{Level: "error", Code: "EXPORT_CHART_FAILED", Message: "export", Context: map[string]string{"chart": chart1}},
+		{Level: "warn", Code: "WORKBOOK_WRITE_IN_FROZEN_HEADER", Message: "no chart", Context: map[string]string{"sheet": "Data"}},
+		{Level: "warn", Code: "CHART_CACHE_SYNC_FAILED", Message: "fourth"},
+	}
+}
+
+func TestAggregateAlertsCounts(t *testing.T) {
+	summary := AggregateAlerts(syntheticAlerts())
+
+	if summary.Total != 7 {
+		t.Fatalf("unexpected total %d", summary.Total)
+	}
+	wantCodes := map[string]int{
+		"CHART_CACHE_SYNC_FAILED":         4,
+		"CHART_LINT_PTCOUNT_MISSING":      1,
+		"EXPORT_CHART_FAILED":             1,
+		"WORKBOOK_WRITE_IN_FROZEN_HEADER": 1,
+	}
+	if !reflect.DeepEqual(summary.ByCode, wantCodes) {
+		t.Fatalf("unexpected codes %v", summary.ByCode)
+	}
+	if want := map[string]int{"warn": 5, "info": 1, "error": 1}; !reflect.DeepEqual(summary.ByLevel, want) {
+		t.Fatalf("unexpected levels %v", summary.ByLevel)
+	}
+	if want := map[string]int{"ppt/charts/chart1.xml": 3, "ppt/charts/chart2.xml": 2}; !reflect.DeepEqual(summary.ByChart, want) {
+		t.Fatalf("unexpected charts %v", summary.ByChart)
+	}
+
+	var codes []string
+	for _, exemplar := range summary.Exemplars {
+		codes = append(codes, exemplar.Code)
+	}
+	if !reflect.DeepEqual(codes, []string{"CHART_CACHE_SYNC_FAILED", "CHART_LINT_PTCOUNT_MISSING", "EXPORT_CHART_FAILED", "WORKBOOK_WRITE_IN_FROZEN_HEADER"}) {
+		t.Fatalf("unexpected exemplar order %v", codes)
+	}
+	if first := summary.Exemplars[0]; first.Message != "first" || first.Context["chart"] != "ppt/charts/chart1.xml" {
+		t.Fatalf("exemplar is not the first occurrence: %#v", first)
+	}
+}
+
+func TestAggregateAlertsJSONShape(t *testing.T) {
+	empty, err := json.Marshal(AggregateAlerts(nil))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `{"total":0,"byCode":{},"byLevel":{},"byChart":{},"exemplars":[]}`; string(empty) != want {
+		t.Fatalf("unexpected empty summary %s", empty)
+	}
+
+	first, err := json.Marshal(AggregateAlerts(syntheticAlerts()))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	second, err := json.Marshal(AggregateAlerts(syntheticAlerts()))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(first) != string(second) {
+		t.Fatalf("summary JSON is not stable:\n%s\n%s", first, second)
+	}
+}
+
+func TestAggregateAlertsCapsExemplarContext(t *testing.T) {
+	ctx := map[string]string{"chart": "ppt/charts/chart1.xml", "detail": strings.Repeat("é", maxExemplarValueBytes)}
+	for i := 0; i < maxExemplarContextKeys; i++ {
+		ctx["key"+strconv.Itoa(i)] = "v"
+	}
+	alerts := []Alert{{Level: "error", Code: "CHART_INTERNAL_PANIC", Context: ctx}}
+
+	exemplar := AggregateAlerts(alerts).Exemplars[0]
+	if !exemplar.Truncated || len(exemplar.Context) != maxExemplarContextKeys {
+		t.Fatalf("context not capped: %d keys, truncated %v", len(exemplar.Context), exemplar.Truncated)
+	}
+	detail, ok := exemplar.Context["detail"]
+	if !ok {
+		t.Fatalf("expected the first keys in order to be kept: %v", exemplar.Context)
+	}
+	if len(detail) > maxExemplarValueBytes+len("...") || !strings.HasSuffix(detail, "é...") {
+		t.Fatalf("value not cut on a rune boundary: %d bytes", len(detail))
+	}
+	if len(alerts[0].Context["detail"]) != 2*maxExemplarValueBytes {
+		t.Fatalf("input alert was modified")
+	}
+}
+
+func TestDocumentAlertSummaryRedactsContextKeys(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Privacy.RedactContextKeys = []string{"path", "workbook"}
+	doc := &Document{opts: opts, alerts: syntheticAlerts()}
+
+	summary := doc.AlertSummary()
+	if summary.Total != 7 || summary.ByChart["ppt/charts/chart1.xml"] != 3 {
+		t.Fatalf("redaction changed counts: %#v", summary)
+	}
+	for _, exemplar := range summary.Exemplars {
+		for key, value := range exemplar.Context {
+			if (key == "path" || key == "workbook") != (value == redactedValue) {
+				t.Fatalf("%s: unexpected %s=%q", exemplar.Code, key, value)
+			}
+		}
+	}
+	if encoded, _ := json.Marshal(summary); strings.Contains(string(encoded), "/srv/decks") {
+		t.Fatalf("summary leaks a file path: %s", encoded)
+	}
+	if got := doc.Alerts()[0].Context["workbook"]; got != "/srv/decks/q1/embeddedWorkbook1.xlsx" {
+		t.Fatalf("Alerts must not be redacted, got %q", got)
+	}
+
+	var nilDoc *Document
+	if summary := nilDoc.AlertSummary(); summary.Total != 0 || summary.ByCode == nil {
+		t.Fatalf("unexpected nil document summary %#v", summary)
+	}
+}
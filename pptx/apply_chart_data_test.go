@@ -6,6 +6,8 @@ import (
 )
 
 func TestApplyChartDataUpdatesWorkbook(t *testing.T) {
+	exercisesFeature(t, "apply.bar")
+
 	dir := t.TempDir()
 	inputPath := filepath.Join(dir, "input.pptx")
 	outputPath := filepath.Join(dir, "output.pptx")
@@ -11,6 +11,8 @@ import (
 )
 
 func TestAreaApplyChartDataStrict(t *testing.T) {
+	exercisesFeature(t, "apply.area", "cachesync.area")
+
 	input := fixturePath("area_edit_valid.pptx")
 	output := filepath.Join(t.TempDir(), "output.pptx")
 
@@ -224,7 +226,7 @@ func TestAreaPostflightInvalidCacheStrict(t *testing.T) {
 		Mode:             postflight.ModeStrict,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, badChart)
 	})
 	if err == nil {
@@ -257,7 +259,7 @@ func TestAreaPostflightInvalidCacheMultiSeriesStrict(t *testing.T) {
 		Mode:             postflight.ModeStrict,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, badChart)
 	})
 	if err == nil {
@@ -0,0 +1,86 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/chartxml"
+	"why-pptx/internal/overlaystage"
+)
+
+// SetValueAxisNumberFormat sets the tick label format of a chart's value
+// axis, for example to `#,##0,,"M"` after ApplyChartData expressions scaled
+// the data to millions. axis is "primary" or "secondary" (empty means
+// primary), as reported by ChartAxis.Group. The numFmt gets sourceLinked="0"
+// so PowerPoint stops taking the format from the workbook cells. The rewrite
+// is staged and validated like any chart write.
+func (d *Document) SetValueAxisNumberFormat(chartPath, formatCode, axis string) error {
+	if d == nil || d.pkg == nil {
+		return fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	chartPath = normalizeChartPath(chartPath)
+	if chartPath == "" {
+		return fmt.Errorf("chart path is required")
+	}
+	if formatCode == "" {
+		return fmt.Errorf("format code is required")
+	}
+	if axis == "" {
+		axis = "primary"
+	}
+	if axis != "primary" && axis != "secondary" {
+		return fmt.Errorf("unknown axis %q: expected primary or secondary", axis)
+	}
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return err
+	}
+	var chart *EmbeddedChart
+	for _, item := range embedded {
+		if item.ChartPath == chartPath {
+			converted := embeddedChartFrom(item)
+			chart = &converted
+			break
+		}
+	}
+	if chart == nil {
+		return d.chartPathError(chartPath, embedded, skipped)
+	}
+
+	dep, ok, err := call.chartDependencies(*chart)
+	if err != nil || !ok {
+		return err
+	}
+
+	return call.withChartStage(call.validateContext(dep), func(stage overlaystage.Overlay) error {
+		data, err := stage.Get(dep.ChartPath)
+		if err != nil {
+			return fmt.Errorf("read chart %q: %w", dep.ChartPath, err)
+		}
+		parsed, err := call.parsedChart(dep.ChartPath, data)
+		if err != nil {
+			return fmt.Errorf("parse chart %q: %w", dep.ChartPath, err)
+		}
+		roles := chartxml.AxisGroupRoles(parsed.Plots, parsed.AxisGroups)
+		valAxID := ""
+		for i, group := range parsed.AxisGroups {
+			if roles[i] == axis {
+				valAxID = group.ValAxID
+				break
+			}
+		}
+		if valAxID == "" {
+			return fmt.Errorf("chart %q has no %s value axis", dep.ChartPath, axis)
+		}
+		updated, err := chartxml.SetValueAxisNumFmt(data, valAxID, formatCode)
+		if err != nil {
+			return fmt.Errorf("set value axis format in %q: %w", dep.ChartPath, err)
+		}
+		if err := stage.Set(dep.ChartPath, updated); err != nil {
+			return fmt.Errorf("write chart %q: %w", dep.ChartPath, err)
+		}
+		d.manifest.stage(chartChange("setValueAxisNumberFormat", dep, nil, 0))
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetValueAxisNumberFormatOnSecondaryAxis(t *testing.T) {
+	exercisesFeature(t, "chart.axis-numfmt")
+
+	const chartPath = "ppt/charts/chart1.xml"
+	inputPath := fixturePath("mix_write_secondary_axis_valid.pptx")
+	doc, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	before, err := doc.ExtractChartDataByPath(chartPath)
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if len(before.Axes) != 2 || before.Axes[1].ValueFormatCode != "" {
+		t.Fatalf("unexpected axes before the write: %#v", before.Axes)
+	}
+
+	if err := doc.SetValueAxisNumberFormat(chartPath, `#,##0,,"M"`, "secondary"); err != nil {
+		t.Fatalf("SetValueAxisNumberFormat: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	original := string(readZipEntry(t, inputPath, chartPath))
+	chart := string(readZipEntry(t, output, chartPath))
+	numFmt := `<c:numFmt formatCode="#,##0,,&#34;M&#34;" sourceLinked="0"/>`
+	want := `<c:valAx><c:axId val="4"/>` + numFmt + `<c:crossAx val="3"/>`
+	if !strings.Contains(chart, want) {
+		t.Fatalf("numFmt not placed after axId on the secondary axis:\n%s", chart)
+	}
+	if strings.Replace(chart, numFmt, "", 1) != original {
+		t.Fatalf("the write changed more than the secondary axis:\n%s", chart)
+	}
+
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	after, err := saved.ExtractChartDataByPath(chartPath)
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	wantAxes := []ChartAxis{{Group: "primary"}, {Group: "secondary", ValueFormatCode: `#,##0,,"M"`}}
+	if !reflect.DeepEqual(after.Axes, wantAxes) {
+		t.Fatalf("unexpected axes after the write: %#v", after.Axes)
+	}
+	if !reflect.DeepEqual(after.Series, before.Series) {
+		t.Fatalf("series data changed: %#v", after.Series)
+	}
+	if len(saved.Alerts()) != 0 {
+		t.Fatalf("unexpected alerts: %#v", saved.Alerts())
+	}
+}
+
+func TestSetValueAxisNumberFormatRejectsMissingAxis(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_category_reversed.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for axis, want := range map[string]string{
+		"secondary": "has no secondary value axis",
+		"right":     `unknown axis "right"`,
+	} {
+		err := doc.SetValueAxisNumberFormat("ppt/charts/chart1.xml", "0%", axis)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: unexpected error %v", axis, err)
+		}
+	}
+
+	if err := doc.SetValueAxisNumberFormat("/ppt/charts/chart1.xml", "0%", ""); err != nil {
+		t.Fatalf("SetValueAxisNumberFormat primary: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if len(data.Axes) != 1 || data.Axes[0].ValueFormatCode != "0%" || !data.Axes[0].CategoryReversed {
+		t.Fatalf("unexpected axes: %#v", data.Axes)
+	}
+}
@@ -0,0 +1,130 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractReportsReversedCategoryAxis(t *testing.T) {
+	exercisesFeature(t, "extract.bar")
+
+	doc, err := OpenFile(fixturePath("bar_category_reversed.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	reversed, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	want := []ChartAxis{{Group: "primary", CategoryReversed: true}}
+	if !reflect.DeepEqual(reversed.Axes, want) {
+		t.Fatalf("unexpected axes: %#v", reversed.Axes)
+	}
+
+	// The flag is a rendering hint only: labels keep workbook order.
+	plain, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	baseline, err := plain.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(reversed.Labels, baseline.Labels) || !reflect.DeepEqual(reversed.Series[0].Data, baseline.Series[0].Data) {
+		t.Fatalf("orientation reordered data: %v %v, want %v %v",
+			reversed.Labels, reversed.Series[0].Data, baseline.Labels, baseline.Series[0].Data)
+	}
+	if baseline.Axes != nil {
+		t.Fatalf("chart without axes reported %#v", baseline.Axes)
+	}
+}
+
+func TestExportChartJSReversesScales(t *testing.T) {
+	exercisesFeature(t, "export.chartjs")
+
+	doc, err := OpenFile(fixturePath("bar_category_reversed.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	extracted, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	payload, err := doc.ExportChartByPathFormat("ppt/charts/chart1.xml", ExportChartJS)
+	if err != nil {
+		t.Fatalf("ExportChartByPathFormat: %v", err)
+	}
+	if !reflect.DeepEqual(payload.Data["labels"], extracted.Labels) {
+		t.Fatalf("labels reordered: %#v", payload.Data["labels"])
+	}
+	options, ok := payload.Data["options"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected scale options, got %#v", payload.Data)
+	}
+	scales := options["scales"].(map[string]any)
+	want := map[string]any{"x": map[string]any{"reverse": true}}
+	if !reflect.DeepEqual(scales, want) {
+		t.Fatalf("unexpected scales: %#v", scales)
+	}
+}
+
+func TestChartJSExporterInvertedSecondaryAxis(t *testing.T) {
+	exporter := ChartJSExporter{MissingNumericPolicy: MissingNumericEmpty}
+	payload, err := exporter.Export(ExtractedChartData{
+		Type:   "mixed",
+		Labels: []string{"A"},
+		Series: []ExtractedSeries{
+			{Index: 0, Name: "Bars", Data: []string{"1"}, PlotType: "bar", Axis: "primary"},
+			{Index: 1, Name: "Line", Data: []string{"2"}, PlotType: "line", Axis: "secondary"},
+		},
+		Axes: []ChartAxis{
+			{Group: "primary"},
+			{Group: "secondary", CategoryReversed: true, ValueInverted: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	scales := payload.Data["options"].(map[string]any)["scales"].(map[string]any)
+	if _, ok := scales["x"]; ok {
+		t.Fatalf("a secondary category axis must not reverse x: %#v", scales)
+	}
+	if scales["y"].(map[string]any)["reverse"] != nil || scales["y1"].(map[string]any)["reverse"] != true {
+		t.Fatalf("unexpected scales: %#v", scales)
+	}
+}
+
+func TestExtractMixedAxesPerGroup(t *testing.T) {
+	exercisesFeature(t, "extract.mixed")
+
+	source := fixturePath("mix_write_secondary_axis_valid.pptx")
+	parts := make(map[string][]byte)
+	for name := range zipEntryNames(t, source) {
+		parts[name] = readZipEntry(t, source, name)
+	}
+	chart := string(parts["ppt/charts/chart1.xml"])
+	inverted := `<c:valAx><c:axId val="4"/><c:scaling><c:orientation val="maxMin"/></c:scaling>`
+	if !strings.Contains(chart, `<c:valAx><c:axId val="4"/>`) {
+		t.Fatalf("fixture changed: %s", chart)
+	}
+	parts["ppt/charts/chart1.xml"] = []byte(strings.Replace(chart, `<c:valAx><c:axId val="4"/>`, inverted, 1))
+	path := filepath.Join(t.TempDir(), "inverted.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	want := []ChartAxis{{Group: "primary"}, {Group: "secondary", ValueInverted: true}}
+	if data.Type != "mixed" || !reflect.DeepEqual(data.Axes, want) {
+		t.Fatalf("unexpected axes for %s chart: %#v", data.Type, data.Axes)
+	}
+}
@@ -0,0 +1,56 @@
+package pptx
+
+import (
+	"fmt"
+	"strconv"
+
+	"why-pptx/internal/xlref"
+)
+
+// cachePointsOverCap returns the first categories or values formula of dep
+// whose cache would hold more points than Options.Chart.MaxCachePoints, and
+// that count. Union formulas count all of their segments.
+func (d *Document) cachePointsOverCap(dep ChartDependencies) (string, int, bool) {
+	limit := d.opts.Chart.MaxCachePoints
+	if limit <= 0 {
+		return "", 0, false
+	}
+	for _, r := range dep.Ranges {
+		if r.UnionIndex > 0 || (r.Kind != RangeCategories && r.Kind != RangeValues) {
+			continue
+		}
+		points := 0
+		for _, segment := range formulaSegments(dep.Ranges, r) {
+			bounds, err := xlref.RangeRef{Sheet: segment.Sheet, StartCell: segment.StartCell, EndCell: segment.EndCell}.Bounds()
+			if err != nil {
+				// Left to the sync, which reports malformed ranges itself.
+				continue
+			}
+			points += bounds.Cells()
+		}
+		if points > limit {
+			return r.Formula, points, true
+		}
+	}
+	return "", 0, false
+}
+
+// reportCachePointsExceeded records CHART_CACHE_POINTS_EXCEEDED for a chart
+// whose cache sync was skipped and returns the reason as an error.
+func (d *docCall) reportCachePointsExceeded(dep ChartDependencies, formula string, points int) error {
+	err := fmt.Errorf("formula %s covers %d cells, over Chart.MaxCachePoints (%d); caches not synced", formula, points, d.opts.Chart.MaxCachePoints)
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "CHART_CACHE_POINTS_EXCEEDED",
+		Message: "Chart range is longer than Options.Chart.MaxCachePoints; cache sync is skipped",
+		Context: map[string]string{
+			"slide":          dep.SlidePath,
+			"chart":          dep.ChartPath,
+			"workbook":       dep.WorkbookPath,
+			"formula":        formula,
+			"points":         strconv.Itoa(points),
+			"maxCachePoints": strconv.Itoa(d.opts.Chart.MaxCachePoints),
+		},
+	})
+	return err
+}
@@ -0,0 +1,93 @@
+package pptx
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaxCachePointsSkipsSyncOfLongRanges(t *testing.T) {
+	const points = 40
+	input := writeLongRangeDeck(t, points)
+	opts := DefaultOptions()
+	opts.Mode = BestEffort
+	opts.Chart.MaxCachePoints = 30
+	doc, err := OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	original := readZipEntry(t, input, "ppt/charts/chart1.xml")
+
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || !results[0].Skipped || !strings.Contains(results[0].Error, "over Chart.MaxCachePoints (30)") {
+		t.Fatalf("expected the chart to be skipped, got %#v", results)
+	}
+	alerts := doc.Alerts()
+	if len(alerts) != 1 || alerts[0].Code != "CHART_CACHE_POINTS_EXCEEDED" {
+		t.Fatalf("expected CHART_CACHE_POINTS_EXCEEDED, got %#v", alerts)
+	}
+	want := map[string]string{
+		"slide":          "ppt/slides/slide1.xml",
+		"chart":          "ppt/charts/chart1.xml",
+		"workbook":       "ppt/embeddings/embeddedWorkbook1.xlsx",
+		"formula":        fmt.Sprintf("Sheet1!$A$2:$A$%d", points+1),
+		"points":         fmt.Sprint(points),
+		"maxCachePoints": "30",
+	}
+	for key, value := range want {
+		if alerts[0].Context[key] != value {
+			t.Fatalf("context %s: expected %q, got %#v", key, value, alerts[0].Context)
+		}
+	}
+
+	categories := make([]string, points)
+	values := make([]string, points)
+	for i := range values {
+		categories[i] = fmt.Sprintf("Q%d", i+1)
+		values[i] = fmt.Sprint(i)
+	}
+	if err := doc.ApplyChartData(0, map[string][]string{"categories": categories, "values:0": values}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	if got := doc.Alerts(); len(got) != 2 || got[1].Code != "CHART_CACHE_POINTS_EXCEEDED" {
+		t.Fatalf("expected ApplyChartData to report the skipped sync, got %#v", got)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if chart := readZipEntry(t, output, "ppt/charts/chart1.xml"); !bytes.Equal(chart, original) {
+		t.Fatalf("the chart part must keep its trimmed caches")
+	}
+	sheet := readSheetFromXLSX(t, readEmbeddedWorkbook(t, output, "ppt/embeddings/embeddedWorkbook1.xlsx"), "xl/worksheets/sheet1.xml")
+	if _, val, ok := readCellFromSheet(sheet, fmt.Sprintf("B%d", points+1)); !ok || val != fmt.Sprint(points-1) {
+		t.Fatalf("the workbook write must go ahead, got %q (ok=%v)", val, ok)
+	}
+}
+
+func TestMaxCachePointsAllowsRangesWithinCap(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Chart.MaxCachePoints = 1200
+	doc, err := OpenFile(writeLongRangeDeck(t, 1200), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || results[0].Skipped || !results[0].Changed {
+		t.Fatalf("expected a sync at the cap, got %#v", results)
+	}
+	// The deck's trimmed caches are reported before they are replaced.
+	for _, alert := range doc.Alerts() {
+		if alert.Code != "CHART_CACHE_PRESYNC_MISMATCH" {
+			t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package pptx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"why-pptx/internal/chartdiscover"
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/postflight"
+)
+
+// CacheSyncResult is the outcome of syncing one chart's caches.
+type CacheSyncResult struct {
+	ChartPath    string
+	SlidePath    string
+	WorkbookPath string
+	// Changed reports that at least one cache differed from the workbook
+	// values in ptCount, point idx, values, or formatCode. A chart whose
+	// caches were already current is not rewritten.
+	Changed bool
+	// SeriesChanged lists, in order, the series whose caches changed.
+	SeriesChanged []int
+	// Skipped reports that the chart was not synced; Error says why.
+	Skipped bool
+	Error   string
+}
+
+// SyncChartCaches rewrites the caches of every embedded chart from its
+// workbook values and returns one result per chart. Charts whose caches
+// already match are left untouched, so a second sync reports no changes and
+// writes nothing. It is a no-op when Options.Chart.CacheSync is disabled.
+//
+// BestEffort skips charts that cannot be synced, with the usual alert codes,
+// and reports them as Skipped. Strict returns the results so far with the
+// first error.
+func (d *Document) SyncChartCaches(opts ...CallOption) ([]CacheSyncResult, error) {
+	return d.SyncChartCachesContext(context.Background(), opts...)
+}
+
+// SyncChartCachesContext is SyncChartCaches checking ctx before each chart.
+// Once ctx is done it returns the results so far with a *CanceledError
+// naming the next chart; the charts already synced stay synced.
+func (d *Document) SyncChartCachesContext(ctx context.Context, opts ...CallOption) ([]CacheSyncResult, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(opts)
+	if !d.opts.Chart.CacheSync {
+		return []CacheSyncResult{}, nil
+	}
+
+	deps, err := call.allChartDependencies()
+	if err != nil {
+		return nil, err
+	}
+	return call.syncChartDependencies(ctx, deps)
+}
+
+// SyncChartCachesFor is SyncChartCaches restricted to the named charts, in
+// the order given; other charts are neither read nor rewritten. Paths are
+// normalized like ExtractChartDataByPath does, a repeated path is synced
+// once, and no path syncs nothing. A path that names no chart fails Strict
+// with a *ChartPathError and is reported as CHART_NOT_FOUND in BestEffort.
+func (d *Document) SyncChartCachesFor(chartPaths ...string) ([]CacheSyncResult, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	if !d.opts.Chart.CacheSync {
+		return []CacheSyncResult{}, nil
+	}
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]chartdiscover.EmbeddedChart, len(embedded))
+	for _, chart := range embedded {
+		byPath[chart.ChartPath] = chart
+	}
+	skippedByPath := make(map[string]chartdiscover.SkippedChart, len(skipped))
+	for _, skip := range skipped {
+		skippedByPath[skip.ChartPath] = skip
+	}
+
+	targets := make([]EmbeddedChart, 0, len(chartPaths))
+	seen := make(map[string]struct{}, len(chartPaths))
+	for _, chartPath := range chartPaths {
+		chartPath = normalizeChartPath(chartPath)
+		if _, ok := seen[chartPath]; ok {
+			continue
+		}
+		seen[chartPath] = struct{}{}
+
+		if chart, ok := byPath[chartPath]; ok {
+			targets = append(targets, embeddedChartFrom(chart))
+			continue
+		}
+		if skip, ok := skippedByPath[chartPath]; ok {
+			if err := call.handleTargetSkip(skip, "cache sync"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err := d.chartPathError(chartPath, embedded, skipped)
+		if call.mode() != BestEffort {
+			return nil, err
+		}
+		d.addChartNotFound(map[string]string{"chart": chartPath, "error": err.Error()})
+	}
+	return call.syncChartTargets(targets)
+}
+
+// SyncChartCachesForWorkbook is SyncChartCaches restricted to the charts
+// backed by workbookPath, in discovery order. A workbook that backs no chart
+// fails Strict with an error wrapping ErrChartNotFound and is reported as
+// CHART_NOT_FOUND in BestEffort.
+func (d *Document) SyncChartCachesForWorkbook(workbookPath string) ([]CacheSyncResult, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	if !d.opts.Chart.CacheSync {
+		return []CacheSyncResult{}, nil
+	}
+	workbookPath = normalizeChartPath(workbookPath)
+
+	charts, err := d.DiscoverEmbeddedCharts()
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]EmbeddedChart, 0)
+	for _, chart := range charts {
+		if chart.WorkbookPath == workbookPath {
+			targets = append(targets, chart)
+		}
+	}
+	if len(targets) == 0 {
+		err := fmt.Errorf("no chart is backed by workbook %q: %w", workbookPath, ErrChartNotFound)
+		if call.mode() != BestEffort {
+			return nil, err
+		}
+		d.addChartNotFound(map[string]string{"workbook": workbookPath, "error": err.Error()})
+		return []CacheSyncResult{}, nil
+	}
+	return call.syncChartTargets(targets)
+}
+
+// syncChartTargets reads the dependencies of the given charts only and syncs
+// them.
+func (d *docCall) syncChartTargets(targets []EmbeddedChart) ([]CacheSyncResult, error) {
+	deps := make([]ChartDependencies, 0, len(targets))
+	for _, chart := range targets {
+		dep, ok, err := d.chartDependencies(chart)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deps = append(deps, dep)
+		}
+	}
+	return d.syncChartDependencies(context.Background(), deps)
+}
+
+func (d *Document) addChartNotFound(alertContext map[string]string) {
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "CHART_NOT_FOUND",
+		Message: "Requested chart was not found; it is skipped",
+		Context: alertContext,
+	})
+}
+
+// syncChartDependencies syncs the caches of deps in order, opening each
+// workbook once for all the charts it backs.
+func (d *docCall) syncChartDependencies(ctx context.Context, deps []ChartDependencies) ([]CacheSyncResult, error) {
+	defer d.beginWorkbookCache()()
+	results := make([]CacheSyncResult, 0, len(deps))
+	for _, dep := range deps {
+		if err := checkCanceled(ctx, "syncChartCaches", dep.ChartPath); err != nil {
+			return results, err
+		}
+		if err := d.validateWritableChart(dep); err != nil {
+			if d.mode() == BestEffort {
+				results = append(results, skippedCacheSync(dep, err))
+				continue
+			}
+			return results, err
+		}
+		if formula, points, over := d.cachePointsOverCap(dep); over {
+			results = append(results, skippedCacheSync(dep, d.reportCachePointsExceeded(dep, formula, points)))
+			continue
+		}
+
+		var records []chartRepairRecord
+		validate := d.validateContext(dep)
+		// As in repair, guarding here tells a BestEffort timeout apart from
+		// a committed sync.
+		err := recoverChart("syncChartCaches", dep.ChartPath, func() error {
+			return d.guardChart("write", dep.SlidePath, dep.ChartPath, dep.WorkbookPath, func() error {
+				return d.withChartStage(validate, func(stage overlaystage.Overlay) error {
+					var err error
+					if dep.ChartType == "mixed" {
+						records, err = d.syncMixedChartCacheInOverlay(stage, dep)
+					} else {
+						records, err = d.syncChartCacheInOverlay(stage, dep)
+					}
+					if err != nil {
+						return err
+					}
+					if recordsChanged(records) {
+						d.manifest.stage(chartChange("syncChartCaches", dep, dependencyFormulas(dep), 0))
+					}
+					return nil
+				})
+			})
+		})
+		var panicErr *ChartPanicError
+		if errors.As(err, &panicErr) {
+			if err := d.handleChartPanic(panicErr, dep.SlidePath, dep.WorkbookPath); err != nil {
+				return results, err
+			}
+			results = append(results, skippedCacheSync(dep, err))
+			continue
+		}
+		if errors.Is(err, ErrChartProcessingTimeout) && d.mode() == BestEffort {
+			results = append(results, skippedCacheSync(dep, err))
+			continue
+		}
+		if err != nil {
+			if postflight.IsPostflightError(err) {
+				return results, err
+			}
+			if err := d.handleChartCacheError(dep, err); err != nil {
+				return results, err
+			}
+			results = append(results, skippedCacheSync(dep, err))
+			continue
+		}
+
+		result, err := d.recordCacheSync(dep, records)
+		if err != nil {
+			return results, err
+		}
+		if err := d.reportPresyncMismatches(dep, records); err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// CacheSyncResults returns the outcome of every cache sync this Document has
+// committed, in order: those of SyncChartCaches and the sync ApplyChartData
+// runs after writing the workbook.
+func (d *Document) CacheSyncResults() []CacheSyncResult {
+	if d == nil || len(d.cacheSyncs) == 0 {
+		return []CacheSyncResult{}
+	}
+	out := make([]CacheSyncResult, len(d.cacheSyncs))
+	copy(out, d.cacheSyncs)
+	return out
+}
+
+// recordCacheSync builds the result of a committed sync and appends it to
+// the Document's log.
+func (d *docCall) recordCacheSync(dep ChartDependencies, records []chartRepairRecord) (CacheSyncResult, error) {
+	seriesIndex, err := d.repairSeriesIndexer(dep)
+	if err != nil {
+		return CacheSyncResult{}, err
+	}
+
+	result := CacheSyncResult{
+		ChartPath:    dep.ChartPath,
+		SlidePath:    dep.SlidePath,
+		WorkbookPath: dep.WorkbookPath,
+	}
+	changed := make(map[int]struct{})
+	for _, record := range records {
+		if !record.cache.Changed() {
+			continue
+		}
+		result.Changed = true
+		index := seriesIndex(record.plotType, record.cache.SeriesIndex)
+		if _, ok := changed[index]; !ok {
+			changed[index] = struct{}{}
+			result.SeriesChanged = append(result.SeriesChanged, index)
+		}
+	}
+	sort.Ints(result.SeriesChanged)
+
+	if d.dryRun == nil {
+		d.cacheSyncs = append(d.cacheSyncs, result)
+	}
+	return result, nil
+}
+
+// reportPresyncMismatches records CHART_CACHE_PRESYNC_MISMATCH for every
+// cache the sync replaced whose ptCount or pt idx values did not fit its
+// formula range, which usually means the template was edited by hand. The
+// alert is informational in both modes.
+func (d *docCall) reportPresyncMismatches(dep ChartDependencies, records []chartRepairRecord) error {
+	seriesIndex, err := d.repairSeriesIndexer(dep)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		cache := record.cache
+		if cache.Added {
+			continue
+		}
+		ptCountOff := cache.OldPtCount >= 0 && cache.OldPtCount != cache.NewPoints
+		if !ptCountOff && !cache.IdxOutOfRange {
+			continue
+		}
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "CHART_CACHE_PRESYNC_MISMATCH",
+			Message: "Existing chart cache did not match its formula range before the sync",
+			Context: map[string]string{
+				"slide":          dep.SlidePath,
+				"chart":          dep.ChartPath,
+				"workbook":       dep.WorkbookPath,
+				"seriesIndex":    strconv.Itoa(seriesIndex(record.plotType, cache.SeriesIndex)),
+				"kind":           string(cache.Kind),
+				"expectedPoints": strconv.Itoa(cache.NewPoints),
+				"ptCount":        strconv.Itoa(cache.OldPtCount),
+				"points":         strconv.Itoa(cache.OldPoints),
+				"idxOutOfRange":  strconv.FormatBool(cache.IdxOutOfRange),
+			},
+		})
+	}
+	return nil
+}
+
+func skippedCacheSync(dep ChartDependencies, err error) CacheSyncResult {
+	return CacheSyncResult{
+		ChartPath:    dep.ChartPath,
+		SlidePath:    dep.SlidePath,
+		WorkbookPath: dep.WorkbookPath,
+		Skipped:      true,
+		Error:        err.Error(),
+	}
+}
@@ -4,14 +4,20 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 )
 
 func TestSyncChartCachesIntegration(t *testing.T) {
+	exercisesFeature(t, "cachesync.bar")
+
 	dir := t.TempDir()
 	inputPath := filepath.Join(dir, "input.pptx")
 	outputPath := filepath.Join(dir, "output.pptx")
@@ -53,7 +59,7 @@ func TestSyncChartCachesIntegration(t *testing.T) {
 		t.Fatalf("SetWorkbookCells: %v", err)
 	}
 
-	if err := doc.SyncChartCaches(); err != nil {
+	if _, err := doc.SyncChartCaches(); err != nil {
 		t.Fatalf("SyncChartCaches: %v", err)
 	}
 	if err := doc.SaveFile(outputPath); err != nil {
@@ -105,7 +111,7 @@ func TestSyncChartCachesBestEffort(t *testing.T) {
 		t.Fatalf("OpenFile: %v", err)
 	}
 
-	if err := doc.SyncChartCaches(); err != nil {
+	if _, err := doc.SyncChartCaches(); err != nil {
 		t.Fatalf("SyncChartCaches: %v", err)
 	}
 	if err := doc.SaveFile(outputPath); err != nil {
@@ -158,7 +164,7 @@ func TestSyncChartCachesStrictFails(t *testing.T) {
 		t.Fatalf("OpenFile: %v", err)
 	}
 
-	if err := doc.SyncChartCaches(); err == nil {
+	if _, err := doc.SyncChartCaches(); err == nil {
 		t.Fatalf("expected error in strict mode")
 	}
 }
@@ -195,7 +201,7 @@ func TestSyncChartCachesDisabledNoop(t *testing.T) {
 		t.Fatalf("OpenFile: %v", err)
 	}
 
-	if err := doc.SyncChartCaches(); err != nil {
+	if _, err := doc.SyncChartCaches(); err != nil {
 		t.Fatalf("SyncChartCaches: %v", err)
 	}
 	if err := doc.SaveFile(outputPath); err != nil {
@@ -249,7 +255,7 @@ func TestSyncChartCachesMissingNumericPolicy(t *testing.T) {
 				t.Fatalf("OpenFile: %v", err)
 			}
 
-			if err := doc.SyncChartCaches(); err != nil {
+			if _, err := doc.SyncChartCaches(); err != nil {
 				t.Fatalf("SyncChartCaches: %v", err)
 			}
 
@@ -483,3 +489,486 @@ func buildWorkbookWithMissingNumeric(t *testing.T, cat1, cat2 string, val1 float
 
 	return writeZipBytes(t, parts)
 }
+
+func TestSyncChartCachesReportsChangesOnce(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := writeStaleCacheDeck(t, dir)
+	outputPath := filepath.Join(dir, "output.pptx")
+
+	doc, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || !results[0].Changed || results[0].Skipped || results[0].ChartPath != "ppt/charts/chart1.xml" {
+		t.Fatalf("unexpected first sync results: %#v", results)
+	}
+	if !reflect.DeepEqual(results[0].SeriesChanged, []int{0}) {
+		t.Fatalf("unexpected changed series: %v", results[0].SeriesChanged)
+	}
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	reopened, err := OpenFile(outputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err = reopened.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || results[0].Changed || len(results[0].SeriesChanged) != 0 {
+		t.Fatalf("expected no changes on the second sync, got %#v", results)
+	}
+	infos, err := reopened.pkg.ListPartsWithInfo()
+	if err != nil {
+		t.Fatalf("ListPartsWithInfo: %v", err)
+	}
+	for _, info := range infos {
+		if info.Overwritten {
+			t.Fatalf("second sync wrote %q", info.Name)
+		}
+	}
+	if !reflect.DeepEqual(reopened.CacheSyncResults(), results) {
+		t.Fatalf("unexpected logged results: %#v", reopened.CacheSyncResults())
+	}
+}
+
+func TestApplyChartDataRecordsCacheSync(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, map[string][]string{
+		"categories": {"A", "B"},
+		"values:0":   {"5", "6"},
+	}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	results := doc.CacheSyncResults()
+	if len(results) != 1 || !results[0].Changed || !reflect.DeepEqual(results[0].SeriesChanged, []int{0}) {
+		t.Fatalf("unexpected apply sync results: %#v", results)
+	}
+}
+
+func TestSyncChartCachesDisabledReturnsNoResults(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Chart.CacheSync = false
+	doc, err := OpenFile(writeStaleCacheDeck(t, t.TempDir()), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCaches()
+	if err != nil || results == nil || len(results) != 0 {
+		t.Fatalf("expected an empty result list, got %#v, %v", results, err)
+	}
+}
+
+// writeStaleCacheDeck writes a one-chart deck whose caches hold Old1/Old2
+// and 1/2 over a workbook holding Cat1/Cat2 and 10/20.
+func writeStaleCacheDeck(t *testing.T, dir string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "stale.pptx")
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte("<slide/>"),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": chartWithCaches("Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3", []string{"Old1", "Old2"}, []string{"1", "2"}),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20),
+	}
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestSyncChartCachesPresyncMismatch(t *testing.T) {
+	handEdited := strings.NewReplacer(
+		`<c:ptCount val="2"/>
+                <c:pt idx="0">`, `<c:ptCount val="12"/>
+                <c:pt idx="0">`,
+		`<c:pt idx="2">`, `<c:pt idx="1">`,
+	).Replace(corruptIdxGapChart)
+	cases := []struct {
+		name        string
+		chartXML    string
+		ptCount     string
+		idxOutRange string
+	}{
+		{name: "ptCount", chartXML: handEdited, ptCount: "12", idxOutRange: "false"},
+		{name: "idx", chartXML: corruptIdxGapChart, ptCount: "2", idxOutRange: "true"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := OpenFile(writeRepairDeck(t, t.TempDir(), tc.chartXML, nil))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			results, err := doc.SyncChartCaches()
+			if err != nil {
+				t.Fatalf("Strict sync must proceed, got %v", err)
+			}
+			if len(results) != 1 || !results[0].Changed {
+				t.Fatalf("unexpected results: %#v", results)
+			}
+			alerts := doc.AlertsByCode("CHART_CACHE_PRESYNC_MISMATCH")
+			if len(alerts) != 1 {
+				t.Fatalf("expected one mismatch alert, got %#v", doc.Alerts())
+			}
+			ctx := alerts[0].Context
+			if alerts[0].Level != "warn" || ctx["chart"] != "ppt/charts/chart1.xml" || ctx["seriesIndex"] != "0" || ctx["kind"] != "values" ||
+				ctx["expectedPoints"] != "2" || ctx["ptCount"] != tc.ptCount || ctx["idxOutOfRange"] != tc.idxOutRange {
+				t.Fatalf("unexpected alert: %#v", alerts[0])
+			}
+		})
+	}
+
+	// A sparse cache that skips a blank point still fits its range.
+	doc, err := OpenFile(writeRepairDeck(t, t.TempDir(), corruptPtCountChart, nil))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.SyncChartCaches(); err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if alerts := doc.AlertsByCode("CHART_CACHE_PRESYNC_MISMATCH"); len(alerts) != 0 {
+		t.Fatalf("expected no mismatch for a sparse cache, got %#v", alerts)
+	}
+}
+
+func TestSyncChartCachesForSelectedCharts(t *testing.T) {
+	exercisesFeature(t, "cachesync.selective")
+
+	path := writeRepairDeck(t, t.TempDir(), corruptIdxGapChart, buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20))
+	overwritten := func(t *testing.T, doc *Document) []string {
+		t.Helper()
+		infos, err := doc.pkg.ListPartsWithInfo()
+		if err != nil {
+			t.Fatalf("ListPartsWithInfo: %v", err)
+		}
+		var names []string
+		for _, info := range infos {
+			if info.Overwritten {
+				names = append(names, info.Name)
+			}
+		}
+		return names
+	}
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCachesFor(`\ppt\charts\chart2.xml`, "/ppt/charts/chart2.xml")
+	if err != nil {
+		t.Fatalf("SyncChartCachesFor: %v", err)
+	}
+	if len(results) != 1 || results[0].ChartPath != "ppt/charts/chart2.xml" || !results[0].Changed {
+		t.Fatalf("unexpected results: %#v", results)
+	}
+	if got := overwritten(t, doc); !reflect.DeepEqual(got, []string{"ppt/charts/chart2.xml"}) {
+		t.Fatalf("expected only chart2 to be rewritten, got %v", got)
+	}
+
+	doc, err = OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err = doc.SyncChartCachesForWorkbook("ppt/embeddings/embeddedWorkbook1.xlsx")
+	if err != nil {
+		t.Fatalf("SyncChartCachesForWorkbook: %v", err)
+	}
+	if len(results) != 1 || results[0].ChartPath != "ppt/charts/chart1.xml" {
+		t.Fatalf("unexpected results: %#v", results)
+	}
+	if got := overwritten(t, doc); !reflect.DeepEqual(got, []string{"ppt/charts/chart1.xml"}) {
+		t.Fatalf("expected only chart1 to be rewritten, got %v", got)
+	}
+
+	if results, err := doc.SyncChartCachesFor(); err != nil || len(results) != 0 {
+		t.Fatalf("expected no paths to sync nothing, got %#v %v", results, err)
+	}
+}
+
+func TestSyncChartCachesForUnknownChart(t *testing.T) {
+	path := writeRepairDeck(t, t.TempDir(), corruptIdxGapChart, buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20))
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	_, err = doc.SyncChartCachesFor("ppt/charts/chart1.xml", "ppt/charts/chart9.xml")
+	var pathErr *ChartPathError
+	if !errors.As(err, &pathErr) || !errors.Is(err, ErrChartNotFound) || pathErr.Path != "ppt/charts/chart9.xml" {
+		t.Fatalf("expected a *ChartPathError, got %v", err)
+	}
+	if _, err := doc.SyncChartCachesForWorkbook("ppt/embeddings/embeddedWorkbook9.xlsx"); !errors.Is(err, ErrChartNotFound) {
+		t.Fatalf("expected ErrChartNotFound, got %v", err)
+	}
+
+	doc, err = OpenFile(path, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCachesFor("ppt/charts/chart9.xml", "ppt/charts/chart2.xml")
+	if err != nil {
+		t.Fatalf("SyncChartCachesFor: %v", err)
+	}
+	if len(results) != 1 || results[0].ChartPath != "ppt/charts/chart2.xml" {
+		t.Fatalf("expected the known chart to be synced, got %#v", results)
+	}
+	alerts := doc.AlertsByCode("CHART_NOT_FOUND")
+	if len(alerts) != 1 || alerts[0].Context["chart"] != "ppt/charts/chart9.xml" {
+		t.Fatalf("expected one CHART_NOT_FOUND alert, got %#v", doc.Alerts())
+	}
+	if _, err := doc.SyncChartCachesForWorkbook("ppt/embeddings/embeddedWorkbook9.xlsx"); err != nil {
+		t.Fatalf("SyncChartCachesForWorkbook: %v", err)
+	}
+	if alerts := doc.AlertsByCode("CHART_NOT_FOUND"); len(alerts) != 2 || alerts[1].Context["workbook"] != "ppt/embeddings/embeddedWorkbook9.xlsx" {
+		t.Fatalf("expected a CHART_NOT_FOUND alert for the workbook, got %#v", alerts)
+	}
+}
+
+func TestSyncChartCachesPieAndAreaAfterWorkbookEdit(t *testing.T) {
+	cases := []struct {
+		fixture string
+		feature string
+	}{
+		{fixture: "pie_simple_embedded.pptx", feature: "cachesync.pie"},
+		{fixture: "doughnut_two_rings_embedded.pptx", feature: "cachesync.doughnut"},
+		{fixture: "area_multi_series_valid.pptx", feature: "cachesync.area"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			exercisesFeature(t, tc.feature)
+
+			doc, err := OpenFile(fixturePath(tc.fixture))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			plan, err := doc.Plan()
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+			if len(plan.Charts) != 1 || plan.Charts[0].Action != "apply" {
+				t.Fatalf("expected the chart to be planned for apply with cache sync on, got %#v", plan.Charts)
+			}
+
+			deps, err := doc.GetChartDependencies()
+			if err != nil || len(deps) != 1 {
+				t.Fatalf("GetChartDependencies: %#v %v", deps, err)
+			}
+			var values *Range
+			for i := range deps[0].Ranges {
+				if deps[0].Ranges[i].Kind == RangeValues {
+					values = &deps[0].Ranges[i]
+					break
+				}
+			}
+			if values == nil {
+				t.Fatalf("no values range in %#v", deps[0].Ranges)
+			}
+			if err := doc.SetWorkbookCells([]CellUpdate{{
+				WorkbookPath: deps[0].WorkbookPath,
+				Sheet:        values.Sheet,
+				Cell:         values.StartCell,
+				Value:        Num(987),
+			}}); err != nil {
+				t.Fatalf("SetWorkbookCells: %v", err)
+			}
+
+			results, err := doc.SyncChartCaches()
+			if err != nil {
+				t.Fatalf("SyncChartCaches: %v", err)
+			}
+			if len(results) != 1 || !results[0].Changed || results[0].Skipped {
+				t.Fatalf("expected the cache to be rewritten, got %#v", results)
+			}
+			chartXML, err := doc.pkg.ReadPart(deps[0].ChartPath)
+			if err != nil {
+				t.Fatalf("ReadPart: %v", err)
+			}
+			if _, vals := extractChartCacheValues(t, chartXML); len(vals) == 0 || vals[0] != "987" {
+				t.Fatalf("value cache not synced: %v", vals)
+			}
+		})
+	}
+}
+
+func TestSyncChartCachesMixedRefreshesBothPlots(t *testing.T) {
+	exercisesFeature(t, "cachesync.mixed")
+
+	doc, err := OpenFile(fixturePath("mix_write_bar_line_valid.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "B2", Value: Num(111)},
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "C3", Value: Num(222)},
+	}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	// Series are numbered across both plots in document order.
+	if len(results) != 1 || !reflect.DeepEqual(results[0].SeriesChanged, []int{0, 1}) {
+		t.Fatalf("expected both series to change, got %#v", results)
+	}
+	chartXML, err := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ReadPart: %v", err)
+	}
+	_, vals := extractChartCacheValues(t, chartXML)
+	if len(vals) != 4 || vals[0] != "111" || vals[3] != "222" {
+		t.Fatalf("expected the bar and line value caches to be refreshed, got %v", vals)
+	}
+}
+
+const sharedWorkbookSyncCharts = 20
+
+func TestSyncChartCachesOpensSharedWorkbookOnce(t *testing.T) {
+	doc, err := OpenFile(writeSparklineDeck(t, t.TempDir(), sharedWorkbookSyncCharts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != sharedWorkbookSyncCharts {
+		t.Fatalf("expected %d results, got %d", sharedWorkbookSyncCharts, len(results))
+	}
+	for _, result := range results {
+		if result.Skipped || !result.Changed {
+			t.Fatalf("unexpected result %#v", result)
+		}
+	}
+	if opens := doc.Stats().WorkbookOpens; opens != 1 {
+		t.Fatalf("expected 1 workbook open, got %d", opens)
+	}
+
+	if err := doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "B2", Value: Num(999)},
+	}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	results, err = doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches after edit: %v", err)
+	}
+	if !results[0].Changed || results[1].Changed {
+		t.Fatalf("expected only chart1 to change, got %#v", results[:2])
+	}
+	if opens := doc.Stats().WorkbookOpens; opens != 2 {
+		t.Fatalf("expected the edited workbook to be opened once more, got %d opens", opens)
+	}
+}
+
+func TestOpenSharedWorkbookReopensRewrittenWorkbook(t *testing.T) {
+	doc, err := OpenFile(writeSparklineDeck(t, t.TempDir(), 1))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	const workbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+	original := buildSparklineWorkbook(t, 1)
+	rewritten := buildSparklineWorkbook(t, 2)
+
+	call := doc.newCall(nil)
+	end := call.beginWorkbookCache()
+	first, err := call.openSharedWorkbook(workbookPath, original)
+	if err != nil {
+		t.Fatalf("openSharedWorkbook: %v", err)
+	}
+	if again, err := call.openSharedWorkbook(workbookPath, original); err != nil || again != first {
+		t.Fatalf("expected the cached workbook, got %p (%v)", again, err)
+	}
+	if other, err := doc.newCall(nil).openSharedWorkbook(workbookPath, original); err != nil || other == first {
+		t.Fatalf("expected another call not to share the cache, got %p (%v)", other, err)
+	}
+	if other, err := call.openSharedWorkbook(workbookPath, rewritten); err != nil || other == first {
+		t.Fatalf("expected a rewritten workbook to be reopened, got %p (%v)", other, err)
+	}
+	end()
+
+	if outside, err := call.openSharedWorkbook(workbookPath, original); err != nil || outside == first {
+		t.Fatalf("expected no sharing outside a cache, got %p (%v)", outside, err)
+	}
+}
+
+// BenchmarkSyncChartCachesSharedWorkbook and
+// BenchmarkSyncChartCachesPerChart sync 20 charts backed by one workbook
+// with a large shared string table, in one call and in one call per chart;
+// the first opens the workbook once.
+func BenchmarkSyncChartCachesSharedWorkbook(b *testing.B) {
+	doc, err := OpenFile(writeSharedStringsSparklineDeck(b))
+	if err != nil {
+		b.Fatalf("OpenFile: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := doc.SyncChartCaches(); err != nil {
+			b.Fatalf("SyncChartCaches: %v", err)
+		}
+	}
+}
+
+func BenchmarkSyncChartCachesPerChart(b *testing.B) {
+	doc, err := OpenFile(writeSharedStringsSparklineDeck(b))
+	if err != nil {
+		b.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.DiscoverEmbeddedCharts()
+	if err != nil {
+		b.Fatalf("DiscoverEmbeddedCharts: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, chart := range charts {
+			if _, err := doc.SyncChartCachesFor(chart.ChartPath); err != nil {
+				b.Fatalf("SyncChartCachesFor: %v", err)
+			}
+		}
+	}
+}
+
+// writeSharedStringsSparklineDeck is a sparkline deck whose workbook also
+// holds a shared string table of a few megabytes, which opening the workbook
+// parses.
+func writeSharedStringsSparklineDeck(b *testing.B) string {
+	b.Helper()
+
+	var shared strings.Builder
+	shared.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&shared, "<si><t>Shared string number %d</t></si>", i)
+	}
+	shared.WriteString("</sst>")
+
+	workbook := sparklineWorkbookParts(b, sharedWorkbookSyncCharts)
+	workbook["xl/sharedStrings.xml"] = []byte(shared.String())
+	parts := sparklineDeckParts(b, sharedWorkbookSyncCharts)
+	parts["ppt/embeddings/embeddedWorkbook1.xlsx"] = writeZipBytes(b, workbook)
+
+	path := filepath.Join(b.TempDir(), "sparklines.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		b.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
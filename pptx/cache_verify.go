@@ -0,0 +1,199 @@
+package pptx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/chartdiscover"
+	"why-pptx/internal/chartxml"
+)
+
+// CacheDiff is one cached chart point that does not match the workbook
+// cell it caches. The JSON field names are stable.
+type CacheDiff struct {
+	ChartPath string `json:"chartPath"`
+	// SeriesIndex is the chart series, numbered as ChartRange.SeriesIndex.
+	SeriesIndex int `json:"seriesIndex"`
+	// Kind is the range the point belongs to: RangeCategories, RangeValues,
+	// RangeSeriesName, or RangeXValues and RangeYValues for scatter charts.
+	Kind ChartRangeKind `json:"kind"`
+	// Idx is the point's index in the range, 0 for a series name.
+	Idx int `json:"idx"`
+	// CachedValue is "" for a point the cache lacks, WorkbookValue for a
+	// cached point past the end of the range. A blank series name cell
+	// reads as the default name extraction gives it.
+	CachedValue   string `json:"cachedValue"`
+	WorkbookValue string `json:"workbookValue"`
+}
+
+// VerifyChartCaches compares every series cache of every embedded chart with
+// the workbook cells it references, without changing anything, and lists the
+// points that differ: a stale cache shows cells edited outside PowerPoint.
+// Blank cells follow Options.Workbook.MissingNumericPolicy, and numbers are
+// compared by value, so "7" matches a cached "7.0".
+//
+// A chart that cannot be read is skipped with the extraction alerts in
+// BestEffort; Strict returns its error. Mismatches are never errors.
+func (d *Document) VerifyChartCaches() ([]CacheDiff, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return nil, err
+	}
+	for _, skip := range skipped {
+		if err := call.handleTargetSkip(skip, "cache verification"); err != nil {
+			return nil, err
+		}
+	}
+
+	diffs := []CacheDiff{}
+	for _, chart := range embedded {
+		var chartDiffs []CacheDiff
+		err := recoverChart("verifyChartCaches", chart.ChartPath, func() error {
+			return call.guardChart("verifyChartCaches", chart.SlidePath, chart.ChartPath, chart.WorkbookPath, func() error {
+				var err error
+				chartDiffs, err = call.verifyChartCache(chart)
+				return err
+			})
+		})
+		var panicErr *ChartPanicError
+		if errors.As(err, &panicErr) {
+			if err := call.handleChartPanic(panicErr, chart.SlidePath, chart.WorkbookPath); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			if call.mode() == BestEffort {
+				continue
+			}
+			return nil, err
+		}
+		diffs = append(diffs, chartDiffs...)
+	}
+	return diffs, nil
+}
+
+// verifyChartCache reads chart as extraction does and compares the result
+// with the chart's caches, series by series.
+func (d *docCall) verifyChartCache(chart chartdiscover.EmbeddedChart) ([]CacheDiff, error) {
+	plan, err := d.planChartExtraction(nil, chart)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.readExtractPlan(nil, chart, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	chartXML, err := d.pkg.ReadPart(chart.ChartPath)
+	if err == nil {
+		var caches []chartxml.SeriesCache
+		caches, err = chartxml.ParseCaches(bytes.NewReader(chartXML), d.cancel)
+		if err == nil {
+			return d.cacheDiffs(chart, plan, data, caches), nil
+		}
+	}
+	return nil, d.handleExtractError(extractIssue{
+		code:    "CHART_DEPENDENCIES_PARSE_FAILED",
+		message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
+		err:     err,
+		context: map[string]string{
+			"chart":    chart.ChartPath,
+			"slide":    chart.SlidePath,
+			"workbook": chart.WorkbookPath,
+			"error":    err.Error(),
+		},
+	})
+}
+
+func (d *Document) cacheDiffs(chart chartdiscover.EmbeddedChart, plan extractPlan, data ExtractedChartData, caches []chartxml.SeriesCache) []CacheDiff {
+	byIndex := make(map[int]chartxml.SeriesCache, len(caches))
+	for _, cache := range caches {
+		byIndex[cache.Index] = cache
+	}
+
+	var diffs []CacheDiff
+	compare := func(seriesIndex int, kind ChartRangeKind, cached, workbook []string) {
+		for i := 0; i < len(cached) || i < len(workbook); i++ {
+			var cachedValue, workbookValue string
+			if i < len(cached) {
+				cachedValue = cached[i]
+			}
+			if i < len(workbook) {
+				workbookValue = workbook[i]
+			}
+			if !cacheValueMatches(cachedValue, workbookValue) {
+				diffs = append(diffs, CacheDiff{
+					ChartPath:     chart.ChartPath,
+					SeriesIndex:   seriesIndex,
+					Kind:          kind,
+					Idx:           i,
+					CachedValue:   cachedValue,
+					WorkbookValue: workbookValue,
+				})
+			}
+		}
+	}
+
+	// Caches hold date serials, not the dates ConvertDates shows.
+	labels := data.Labels
+	if data.RawLabels != nil {
+		labels = data.RawLabels
+	}
+	for i, planned := range plan.series {
+		series := data.Series[i]
+		cache := byIndex[planned.index]
+		switch {
+		case planned.xValues != nil:
+			compare(planned.index, planned.xValues.Kind, cache.Categories, series.XValues)
+		case plan.labels != nil:
+			compare(planned.index, plan.labels.Kind, cache.Categories, labels)
+		}
+		workbook := series.Data
+		if d.opts.Workbook.MissingNumericPolicy == MissingNumericZero {
+			workbook = make([]string, len(series.Data))
+			for j, value := range series.Data {
+				if strings.TrimSpace(value) == "" {
+					value = "0"
+				}
+				workbook[j] = value
+			}
+		}
+		compare(planned.index, planned.values.Kind, cache.Values, workbook)
+		if planned.name != nil {
+			shown := strings.TrimSpace(cache.Name)
+			if shown == "" {
+				shown = planned.defaultName()
+			}
+			if shown != series.Name {
+				diffs = append(diffs, CacheDiff{
+					ChartPath:     chart.ChartPath,
+					SeriesIndex:   planned.index,
+					Kind:          RangeSeriesName,
+					CachedValue:   cache.Name,
+					WorkbookValue: series.Name,
+				})
+			}
+		}
+	}
+	return diffs
+}
+
+// cacheValueMatches reports whether a cached point shows the workbook
+// value: the same text, or the same number.
+func cacheValueMatches(cached, workbook string) bool {
+	if cached == workbook {
+		return true
+	}
+	cachedNumber, cachedErr := strconv.ParseFloat(strings.TrimSpace(cached), 64)
+	workbookNumber, workbookErr := strconv.ParseFloat(strings.TrimSpace(workbook), 64)
+	return cachedErr == nil && workbookErr == nil && cachedNumber == workbookNumber
+}
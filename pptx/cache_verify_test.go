@@ -0,0 +1,71 @@
+package pptx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerifyChartCachesReportsStaleCells(t *testing.T) {
+	exercisesFeature(t, "chart.verify-caches")
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	diffs, err := doc.VerifyChartCaches()
+	if err != nil {
+		t.Fatalf("VerifyChartCaches: %v", err)
+	}
+	if diffs == nil || len(diffs) != 0 {
+		t.Fatalf("expected an empty report for a fresh deck, got %#v", diffs)
+	}
+
+	before, err := doc.GetWorkbookCells("ppt/embeddings/embeddedWorkbook1.xlsx", "Sheet1", []string{"A2", "B2", "B3"})
+	if err != nil {
+		t.Fatalf("GetWorkbookCells: %v", err)
+	}
+	if err := doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "A2", Value: Str("Edited")},
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "B2", Value: Num(42)},
+		// The same number written differently is not a change.
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "B3", Value: Str(before["B3"] + ".0")},
+	}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	diffs, err = doc.VerifyChartCaches()
+	if err != nil {
+		t.Fatalf("VerifyChartCaches: %v", err)
+	}
+	want := []CacheDiff{
+		{ChartPath: "ppt/charts/chart1.xml", SeriesIndex: 0, Kind: RangeCategories, Idx: 0, CachedValue: before["A2"], WorkbookValue: "Edited"},
+		{ChartPath: "ppt/charts/chart1.xml", SeriesIndex: 0, Kind: RangeValues, Idx: 0, CachedValue: before["B2"], WorkbookValue: "42"},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Fatalf("unexpected diffs:\n got %#v\nwant %#v", diffs, want)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("mismatches must not alert: %#v", doc.Alerts())
+	}
+}
+
+func TestVerifyChartCachesUnreadableCharts(t *testing.T) {
+	doc, err := OpenFile(fixturePath("linked_workbook_chart.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.VerifyChartCaches(); err == nil {
+		t.Fatalf("expected Strict to fail on the linked chart")
+	}
+
+	doc, err = OpenFile(fixturePath("linked_workbook_chart.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	diffs, err := doc.VerifyChartCaches()
+	if err != nil || len(diffs) != 0 {
+		t.Fatalf("VerifyChartCaches = %#v, %v", diffs, err)
+	}
+	if len(doc.AlertsByCode("CHART_LINKED_WORKBOOK")) != 1 {
+		t.Fatalf("expected CHART_LINKED_WORKBOOK, got %#v", doc.Alerts())
+	}
+}
@@ -0,0 +1,77 @@
+package pptx
+
+import (
+	"time"
+
+	"why-pptx/internal/xmlcancel"
+)
+
+// CallOption changes how a single call runs, taking precedence over the
+// document Options for that call only.
+type CallOption func(*callOptions)
+
+// callOptions is what the CallOptions of a call set.
+type callOptions struct {
+	mode ErrorMode
+}
+
+// WithMode runs the call in mode instead of Options.Mode. Alerts it raises
+// still go to the document.
+func WithMode(mode ErrorMode) CallOption {
+	return func(c *callOptions) {
+		c.mode = mode
+	}
+}
+
+// docCall is one invocation of a Document method: the document and the
+// options in effect for it, built once when the call starts. Helpers whose
+// behaviour depends on those options are docCall methods, so calls running
+// at the same time on one Document each keep their own. A docCall belongs to
+// one goroutine; work fanned out to others takes a copy.
+type docCall struct {
+	*Document
+	errorMode ErrorMode
+	// cancel is the flag of the chart guard the call is running under, if
+	// any.
+	cancel *xmlcancel.Flag
+	// dryRun is set on a ValidateChartData call; its stages are discarded
+	// where they would commit and its alerts are collected in it.
+	dryRun *dryRun
+	// workbooks is the workbook cache of a SyncChartCaches call, nil
+	// outside one.
+	workbooks *workbookCache
+}
+
+// newCall starts a call under opts. Without options it runs in
+// Options.Mode. On a nil Document the call has no document either, which
+// its methods report as not initialized.
+func (d *Document) newCall(opts []CallOption) *docCall {
+	if d == nil {
+		return &docCall{}
+	}
+	call := callOptions{mode: d.opts.Mode}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&call)
+		}
+	}
+	return &docCall{Document: d, errorMode: call.mode}
+}
+
+// mode is the error mode of the call: its WithMode, or Options.Mode.
+func (d *docCall) mode() ErrorMode {
+	return d.errorMode
+}
+
+// addAlert records alert on the document, or in the call's dry run when it
+// is validating.
+func (d *docCall) addAlert(alert Alert) {
+	if d.dryRun == nil {
+		d.Document.addAlert(alert)
+		return
+	}
+	if alert.Timestamp.IsZero() {
+		alert.Timestamp = time.Now().UTC()
+	}
+	d.dryRun.alerts = append(d.dryRun.alerts, alert)
+}
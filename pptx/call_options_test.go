@@ -0,0 +1,138 @@
+package pptx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// unreadableSheetDeck is bar_simple_embedded.pptx with its chart pointing
+// at a sheet the workbook lacks, which Strict rejects and BestEffort alerts.
+func unreadableSheetDeck(t *testing.T) string {
+	t.Helper()
+	return rewrittenChartDeck(t, func(chart []byte) []byte {
+		return bytes.ReplaceAll(chart, []byte("Sheet1!"), []byte("Gone!"))
+	})
+}
+
+func rewrittenChartDeck(t *testing.T, rewrite func([]byte) []byte) string {
+	t.Helper()
+	entries := corpusZipEntries(t, readCorpusFile(t, fixturePath("bar_simple_embedded.pptx")))
+	entries["ppt/charts/chart1.xml"] = rewrite(entries["ppt/charts/chart1.xml"])
+	inputPath := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(inputPath, entries); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return inputPath
+}
+
+func TestWithModeOverridesOneCall(t *testing.T) {
+	data := ChartDataInput{"categories": {"A", "B"}, "values:0": {"1", "2"}}
+	cases := []struct {
+		name  string
+		input string
+		run   func(doc *Document, opts ...CallOption) error
+	}{
+		{"ExtractAllCharts", unreadableSheetDeck(t), func(doc *Document, opts ...CallOption) error {
+			_, err := doc.ExtractAllCharts(opts...)
+			return err
+		}},
+		{"GetChartDependencies", rewrittenChartDeck(t, func(chart []byte) []byte { return chart[:len(chart)/2] }), func(doc *Document, opts ...CallOption) error {
+			_, err := doc.GetChartDependencies(opts...)
+			return err
+		}},
+		{"SyncChartCaches", unreadableSheetDeck(t), func(doc *Document, opts ...CallOption) error {
+			_, err := doc.SyncChartCaches(opts...)
+			return err
+		}},
+		{"ApplyChartDataByPath", fixturePath("area_multi_series_mismatched_categories.pptx"), func(doc *Document, opts ...CallOption) error {
+			return doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data, opts...)
+		}},
+		{"ExtractAllChartsParallel", unreadableSheetDeck(t), func(doc *Document, opts ...CallOption) error {
+			_, err := doc.ExtractAllChartsParallel(context.Background(), 2, opts...)
+			return err
+		}},
+		{"PlanChanges", unreadableSheetDeck(t), func(doc *Document, opts ...CallOption) error {
+			_, err := doc.PlanChanges(PlanRequest{Data: data}, opts...)
+			return err
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := OpenFile(tc.input)
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			if err := tc.run(doc); err == nil || len(doc.Alerts()) != 0 {
+				t.Fatalf("expected a Strict error without alerts, got %v, %#v", err, doc.Alerts())
+			}
+			if err := tc.run(doc, WithMode(BestEffort)); err != nil && len(doc.Alerts()) == 0 {
+				t.Fatalf("expected WithMode(BestEffort) to alert or succeed, got %v", err)
+			}
+			alerts := len(doc.Alerts())
+			if err := tc.run(doc); err == nil || len(doc.Alerts()) != alerts {
+				t.Fatalf("expected the next call to run in Strict again, got %v, %#v", err, doc.Alerts())
+			}
+		})
+	}
+}
+
+func TestWithModeStrictInBestEffortDocument(t *testing.T) {
+	doc, err := OpenFile(unreadableSheetDeck(t), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.ExtractAllCharts(WithMode(Strict)); err == nil {
+		t.Fatalf("expected WithMode(Strict) to fail on an unreadable sheet")
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("expected no alerts in Strict, got %#v", doc.Alerts())
+	}
+	charts, err := doc.ExtractAllCharts()
+	if err != nil || len(charts) != 0 || len(doc.Alerts()) != 1 {
+		t.Fatalf("expected BestEffort to skip the chart with an alert, got %#v, %v, %#v", charts, err, doc.Alerts())
+	}
+}
+
+func TestWithModeConcurrentCalls(t *testing.T) {
+	doc, err := OpenFile(unreadableSheetDeck(t))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	const calls = 16
+	done := make(chan error)
+	for i := 0; i < calls; i++ {
+		go func(i int) {
+			var opts []CallOption
+			if i%2 == 0 {
+				opts = append(opts, WithMode(BestEffort))
+			}
+			var charts []ExtractedChartData
+			var err error
+			if i%4 < 2 {
+				charts, err = doc.ExtractAllCharts(opts...)
+			} else {
+				charts, err = doc.ExtractAllChartsParallel(context.Background(), 2, opts...)
+			}
+			switch {
+			case len(opts) > 0 && (err != nil || len(charts) != 0):
+				done <- fmt.Errorf("call %d in BestEffort: expected the chart skipped, got %d charts, %v", i, len(charts), err)
+			case len(opts) == 0 && err == nil:
+				done <- fmt.Errorf("call %d in Strict: expected an error", i)
+			default:
+				done <- nil
+			}
+		}(i)
+	}
+	for i := 0; i < calls; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+	if alerts := doc.Alerts(); len(alerts) != calls/2 {
+		t.Fatalf("expected one alert per BestEffort call, got %#v", alerts)
+	}
+}
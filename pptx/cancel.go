@@ -0,0 +1,54 @@
+package pptx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"why-pptx/internal/ooxmlpkg"
+)
+
+// CanceledError is returned by the Context methods (ExtractAllChartsContext,
+// SyncChartCachesContext, ApplyChartDataByPathContext, SaveFileContext) when
+// their context is done before they finish. Err is the context's error, so
+// errors.Is(err, context.Canceled) holds. ChartPath is the chart that was
+// next in line, or Part the package part a save was about to write; the
+// work before it stays done, and nothing of the chart or part is written.
+type CanceledError struct {
+	Operation string
+	ChartPath string
+	Part      string
+	Err       error
+}
+
+func (e *CanceledError) Error() string {
+	switch {
+	case e.ChartPath != "":
+		return fmt.Sprintf("%s canceled at chart %q: %v", e.Operation, e.ChartPath, e.Err)
+	case e.Part != "":
+		return fmt.Sprintf("%s canceled at part %q: %v", e.Operation, e.Part, e.Err)
+	}
+	return fmt.Sprintf("%s canceled: %v", e.Operation, e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// checkCanceled returns a *CanceledError for chartPath once ctx is done.
+func checkCanceled(ctx context.Context, operation, chartPath string) error {
+	if err := ctx.Err(); err != nil {
+		return &CanceledError{Operation: operation, ChartPath: chartPath, Err: err}
+	}
+	return nil
+}
+
+// saveCanceled turns a save the package stopped on ctx into a
+// *CanceledError.
+func saveCanceled(err error) error {
+	var canceled *ooxmlpkg.CanceledError
+	if errors.As(err, &canceled) {
+		return &CanceledError{Operation: "save", Part: canceled.Part, Err: canceled.Err}
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package pptx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cancelOnAlert opens a BestEffort deck of six charts on one workbook whose
+// chart2 reads a missing sheet, with a context canceled by the first alert.
+func cancelOnAlert(t *testing.T) (*Document, context.Context, string) {
+	t.Helper()
+
+	parts := parallelDeckParts(t, 6, 1)
+	parts["ppt/charts/chart2.xml"] = sparklineChart("Missing!$B$1:$M$1", "Missing!$B$3:$M$3", "Missing!$A$3")
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	doc, err := OpenFile(path, WithErrorMode(BestEffort), WithAlertHandler(func(Alert) { cancel() }))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	return doc, ctx, path
+}
+
+func TestExtractAllChartsContextCanceledMidway(t *testing.T) {
+	doc, ctx, _ := cancelOnAlert(t)
+
+	charts, err := doc.ExtractAllChartsContext(ctx)
+	var canceled *CanceledError
+	if !errors.As(err, &canceled) || canceled.Operation != "extract" || canceled.ChartPath != "ppt/charts/chart3.xml" {
+		t.Fatalf("expected extraction to stop at chart3, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if charts != nil {
+		t.Fatalf("expected no charts, got %d", len(charts))
+	}
+	alerts := doc.Alerts()
+	if len(alerts) != 1 || alerts[0].Code != "EXTRACT_SHEET_NOT_FOUND" || alerts[0].Context["chart"] != "ppt/charts/chart2.xml" {
+		t.Fatalf("expected the alerts of the charts before the cancel, got %#v", alerts)
+	}
+
+	// The Document is still usable.
+	all, err := doc.ExtractAllCharts()
+	if err != nil || len(all) != 5 {
+		t.Fatalf("ExtractAllCharts after cancel: %d charts, %v", len(all), err)
+	}
+}
+
+func TestSyncChartCachesContextCanceledMidway(t *testing.T) {
+	doc, ctx, input := cancelOnAlert(t)
+	before := make(map[string][]byte)
+	for _, name := range []string{"ppt/charts/chart1.xml", "ppt/charts/chart3.xml", "ppt/charts/chart6.xml"} {
+		data, err := doc.pkg.ReadPart(name)
+		if err != nil {
+			t.Fatalf("ReadPart: %v", err)
+		}
+		before[name] = data
+	}
+
+	results, err := doc.SyncChartCachesContext(ctx)
+	var canceled *CanceledError
+	if !errors.As(err, &canceled) || canceled.ChartPath != "ppt/charts/chart3.xml" || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the sync to stop at chart3, got %v", err)
+	}
+	if len(results) != 2 || !results[0].Changed || !results[1].Skipped {
+		t.Fatalf("expected chart1 synced and chart2 skipped, got %#v", results)
+	}
+	if len(doc.Alerts()) != 1 {
+		t.Fatalf("expected one alert, got %#v", doc.Alerts())
+	}
+
+	chart1, _ := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if bytes.Equal(chart1, before["ppt/charts/chart1.xml"]) {
+		t.Fatalf("chart1 caches should have been written")
+	}
+	for _, name := range []string{"ppt/charts/chart3.xml", "ppt/charts/chart6.xml"} {
+		if data, _ := doc.pkg.ReadPart(name); !bytes.Equal(data, before[name]) {
+			t.Fatalf("%s must be untouched after the cancel", name)
+		}
+	}
+
+	// What was committed saves and reads back, and a later sync picks up
+	// the rest.
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	saved, err := OpenFile(output, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	want, err := OpenFile(input, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile input: %v", err)
+	}
+	got, err := saved.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if original, err := want.ExtractChartDataByPath("ppt/charts/chart1.xml"); err != nil || original.Labels[0] != got.Labels[0] {
+		t.Fatalf("chart1 round trip mismatch: %v", err)
+	}
+	again, err := saved.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(again) != 6 || again[0].Changed || !again[2].Changed {
+		t.Fatalf("expected only the charts after the cancel to change, got %#v", again)
+	}
+}
+
+func TestContextMethodsCanceledUpFront(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	chartXML, err := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ReadPart: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = doc.ApplyChartDataByPathContext(ctx, "ppt/charts/chart1.xml", map[string][]string{"values:0": {"1", "2", "3"}})
+	var canceled *CanceledError
+	if !errors.As(err, &canceled) || canceled.ChartPath != "ppt/charts/chart1.xml" || !errors.Is(err, context.Canceled) {
+		t.Fatalf("ApplyChartDataByPathContext: %v", err)
+	}
+	if after, _ := doc.pkg.ReadPart("ppt/charts/chart1.xml"); !bytes.Equal(after, chartXML) {
+		t.Fatalf("a canceled apply must not write the chart")
+	}
+
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFileContext(ctx, output); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SaveFileContext: %v", err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Fatalf("a canceled save must not write the output: %v", err)
+	}
+	if err := doc.SaveFileContext(context.Background(), output); err != nil {
+		t.Fatalf("SaveFileContext: %v", err)
+	}
+}
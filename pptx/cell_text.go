@@ -0,0 +1,52 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/xlsxembed"
+)
+
+// InvalidCharacterError is returned in Strict mode for a string cell value
+// containing a rune XML 1.0 does not allow, such as a vertical tab or another
+// C0 control character. Rune is the first such rune in the value.
+type InvalidCharacterError struct {
+	WorkbookPath string
+	Sheet        string
+	Cell         string
+	Rune         rune
+}
+
+func (e *InvalidCharacterError) Error() string {
+	return fmt.Sprintf("cell %s!%s in %q contains %U, which XML 1.0 does not allow", e.Sheet, e.Cell, e.WorkbookPath, e.Rune)
+}
+
+// checkCellText rejects string values that would lose characters when
+// written. In BestEffort mode the write goes ahead with those characters
+// stripped and a WORKBOOK_TEXT_SANITIZED alert. Newlines are normalized by
+// SetCell in either mode and never reported.
+func (d *docCall) checkCellText(update CellUpdate) error {
+	if update.Value.String == nil {
+		return nil
+	}
+	r, ok := xlsxembed.InvalidXMLRune(*update.Value.String)
+	if !ok {
+		return nil
+	}
+	err := &InvalidCharacterError{WorkbookPath: update.WorkbookPath, Sheet: update.Sheet, Cell: update.Cell, Rune: r}
+	if d.mode() != BestEffort {
+		return err
+	}
+
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "WORKBOOK_TEXT_SANITIZED",
+		Message: "Removed characters XML does not allow from a string cell value",
+		Context: map[string]string{
+			"workbook": update.WorkbookPath,
+			"sheet":    update.Sheet,
+			"cell":     update.Cell,
+			"rune":     fmt.Sprintf("%U", r),
+		},
+	})
+	return nil
+}
@@ -0,0 +1,77 @@
+package pptx
+
+import (
+	"errors"
+	"testing"
+
+	"why-pptx/internal/xlsxembed"
+)
+
+func TestSetWorkbookCellsNormalizesNewlines(t *testing.T) {
+	doc := openLimitsDeck(t, Strict, func(o *WorkbookOptions) {})
+	if err := doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "A2", Value: Str("North\r\nSouth\rEast")},
+		{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "A3", Value: Str("Growth 📈\tQ1")},
+	}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+
+	got := readCellText(t, doc, "A2", "A3")
+	if got[0] != "North\nSouth\nEast" {
+		t.Fatalf("unexpected A2 %q", got[0])
+	}
+	if got[1] != "Growth 📈\tQ1" {
+		t.Fatalf("unexpected A3 %q", got[1])
+	}
+}
+
+func TestSetWorkbookCellsRejectsControlCharactersInStrict(t *testing.T) {
+	doc := openLimitsDeck(t, Strict, func(o *WorkbookOptions) {})
+	err := doc.SetWorkbookCells([]CellUpdate{{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "a2", Value: Str("one\vtwo")}})
+	var charErr *InvalidCharacterError
+	if !errors.As(err, &charErr) {
+		t.Fatalf("expected InvalidCharacterError, got %v", err)
+	}
+	if charErr.Rune != '\v' || charErr.Sheet != "Sheet1" || charErr.Cell != "A2" || charErr.WorkbookPath != limitsWorkbookPath {
+		t.Fatalf("unexpected error fields: %#v", charErr)
+	}
+}
+
+func TestSetWorkbookCellsStripsControlCharactersInBestEffort(t *testing.T) {
+	doc := openLimitsDeck(t, BestEffort, func(o *WorkbookOptions) {})
+	if err := doc.SetWorkbookCells([]CellUpdate{{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "A2", Value: Str("one\vtwo")}}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	alerts := doc.Alerts()
+	if len(alerts) != 1 || alerts[0].Code != "WORKBOOK_TEXT_SANITIZED" || alerts[0].Context["rune"] != "U+000B" || alerts[0].Context["cell"] != "A2" {
+		t.Fatalf("unexpected alerts: %#v", alerts)
+	}
+	if got := readCellText(t, doc, "A2"); got[0] != "onetwo" {
+		t.Fatalf("unexpected A2 %q", got[0])
+	}
+}
+
+func readCellText(t *testing.T, doc *Document, cells ...string) []string {
+	t.Helper()
+
+	data, err := doc.pkg.ReadPart(limitsWorkbookPath)
+	if err != nil {
+		t.Fatalf("ReadPart: %v", err)
+	}
+	wb, err := xlsxembed.Open(data)
+	if err != nil {
+		t.Fatalf("xlsxembed.Open: %v", err)
+	}
+	out := make([]string, len(cells))
+	for i, cell := range cells {
+		values, err := wb.GetRangeValues("Sheet1", cell, cell, xlsxembed.MissingNumericEmpty)
+		if err != nil {
+			t.Fatalf("GetRangeValues %s: %v", cell, err)
+		}
+		out[i] = values[0]
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package pptx
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/xlsxembed"
+)
+
+// staleAnnotations records a write that moves chart values far enough for
+// the chart's userShapes callouts to point at the wrong place.
+type staleAnnotations struct {
+	drawing   string
+	workbook  []byte
+	maxChange float64
+}
+
+// checkAnnotationStaleness compares the numeric updates against the current
+// workbook values. It returns nil when the chart has no userShapes drawing,
+// the check is disabled, or no value moves by more than
+// Options.Chart.AnnotationStaleThreshold relative to its old value. A value
+// leaving zero counts as an unbounded change.
+func (d *docCall) checkAnnotationStaleness(dep ChartDependencies, updates []CellUpdate) *staleAnnotations {
+	threshold := d.opts.Chart.AnnotationStaleThreshold
+	if threshold <= 0 {
+		return nil
+	}
+	drawing := d.chartUserShapesPart(dep.ChartPath)
+	if drawing == "" {
+		return nil
+	}
+	wbBytes, err := d.pkg.ReadPart(dep.WorkbookPath)
+	if err != nil {
+		return nil
+	}
+	wb, err := d.openEmbeddedWorkbook(dep.WorkbookPath, wbBytes)
+	if err != nil {
+		return nil
+	}
+
+	numeric := make([]CellUpdate, 0, len(updates))
+	ranges := make([]xlsxembed.Range, 0, len(updates))
+	for _, update := range updates {
+		if update.Value.Number == nil {
+			continue
+		}
+		numeric = append(numeric, update)
+		ranges = append(ranges, xlsxembed.Range{Sheet: update.Sheet, StartCell: update.Cell, EndCell: update.Cell})
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+	current, err := wb.GetRanges(ranges, xlsxembed.MissingNumericEmpty)
+	if err != nil {
+		return nil
+	}
+
+	maxChange := 0.0
+	for i, update := range numeric {
+		if len(current[i]) == 0 {
+			continue
+		}
+		old, err := strconv.ParseFloat(strings.TrimSpace(current[i][0]), 64)
+		if err != nil {
+			continue
+		}
+		next := *update.Value.Number
+		change := 0.0
+		switch {
+		case old == next:
+		case old == 0:
+			change = math.Inf(1)
+		default:
+			change = math.Abs(next-old) / math.Abs(old)
+		}
+		maxChange = math.Max(maxChange, change)
+	}
+	if maxChange <= threshold {
+		return nil
+	}
+	return &staleAnnotations{drawing: drawing, workbook: wbBytes, maxChange: maxChange}
+}
+
+// reportStaleAnnotations emits CHART_ANNOTATIONS_MAY_BE_STALE once the write
+// checked by checkAnnotationStaleness has landed. A write that was skipped
+// (for example by a BestEffort timeout) leaves the workbook untouched and
+// reports nothing.
+func (d *docCall) reportStaleAnnotations(dep ChartDependencies, stale *staleAnnotations) {
+	if stale == nil {
+		return
+	}
+	if current, err := d.pkg.ReadPart(dep.WorkbookPath); err == nil && bytes.Equal(current, stale.workbook) {
+		return
+	}
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "CHART_ANNOTATIONS_MAY_BE_STALE",
+		Message: "chart values changed beyond the annotation threshold; review userShapes callouts",
+		Context: map[string]string{
+			"slide":             dep.SlidePath,
+			"chart":             dep.ChartPath,
+			"drawing":           stale.drawing,
+			"maxRelativeChange": strconv.FormatFloat(stale.maxChange, 'g', 4, 64),
+			"threshold":         strconv.FormatFloat(d.opts.Chart.AnnotationStaleThreshold, 'g', -1, 64),
+		},
+	})
+}
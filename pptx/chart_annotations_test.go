@@ -0,0 +1,158 @@
+package pptx
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"why-pptx/internal/rels"
+)
+
+func TestListChartsReportsUserShapes(t *testing.T) {
+	for name, want := range map[string]bool{
+		"chart_user_shapes.pptx":   true,
+		"bar_simple_embedded.pptx": false,
+	} {
+		doc, err := OpenFile(fixturePath(name))
+		if err != nil {
+			t.Fatalf("OpenFile %s: %v", name, err)
+		}
+		charts, err := doc.ListCharts()
+		if err != nil {
+			t.Fatalf("ListCharts %s: %v", name, err)
+		}
+		if len(charts) != 1 || charts[0].HasUserShapes != want {
+			t.Fatalf("%s: expected HasUserShapes=%v, got %#v", name, want, charts)
+		}
+	}
+}
+
+func TestImportChartCopiesUserShapes(t *testing.T) {
+	dir := t.TempDir()
+	doc, err := OpenFile(fixturePath("chart_user_shapes.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	chartPath, err := doc.ImportChart(doc, "ppt/charts/chart1.xml", "ppt/slides/slide1.xml")
+	if err != nil {
+		t.Fatalf("ImportChart: %v", err)
+	}
+	if chartPath != "ppt/charts/chart2.xml" {
+		t.Fatalf("unexpected chart path %q", chartPath)
+	}
+	output := filepath.Join(dir, "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	chartRels, err := rels.Parse(bytes.NewReader(readZipEntry(t, output, "ppt/charts/_rels/chart2.xml.rels")))
+	if err != nil {
+		t.Fatalf("parse chart rels: %v", err)
+	}
+	if rel := chartRels.ByID["rId2"]; rel.Type != userShapesRelType || rel.Target != "../drawings/drawing2.xml" {
+		t.Fatalf("expected userShapes rel to the copied drawing, got %#v", chartRels.ByID)
+	}
+	drawingRels, err := rels.Parse(bytes.NewReader(readZipEntry(t, output, "ppt/drawings/_rels/drawing2.xml.rels")))
+	if err != nil {
+		t.Fatalf("parse drawing rels: %v", err)
+	}
+	if rel := drawingRels.ByID["rId1"]; rel.Target != "../media/image2.png" {
+		t.Fatalf("expected drawing rel to the copied image, got %#v", drawingRels.ByID)
+	}
+	if !bytes.Equal(readZipEntry(t, output, "ppt/drawings/drawing2.xml"), readZipEntry(t, output, "ppt/drawings/drawing1.xml")) {
+		t.Fatalf("copied drawing differs from the source drawing")
+	}
+	if len(readZipEntry(t, output, "ppt/media/image2.png")) == 0 {
+		t.Fatalf("copied image is empty")
+	}
+
+	original, err := rels.Parse(bytes.NewReader(readZipEntry(t, output, "ppt/drawings/_rels/drawing1.xml.rels")))
+	if err != nil {
+		t.Fatalf("parse source drawing rels: %v", err)
+	}
+	if original.ByID["rId1"].Target != "../media/image1.png" {
+		t.Fatalf("source drawing rels changed: %#v", original.ByID)
+	}
+
+	contentTypes := string(readZipEntry(t, output, "[Content_Types].xml"))
+	if !strings.Contains(contentTypes, `<Override PartName="/ppt/drawings/drawing2.xml" ContentType="`+userShapesContentType+`"/>`) {
+		t.Fatalf("copied drawing not declared:\n%s", contentTypes)
+	}
+
+	reopened, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile output: %v", err)
+	}
+	charts, err := reopened.ListCharts()
+	if err != nil {
+		t.Fatalf("ListCharts: %v", err)
+	}
+	if len(charts) != 2 || !charts[0].HasUserShapes || !charts[1].HasUserShapes {
+		t.Fatalf("expected both charts to keep their userShapes, got %#v", charts)
+	}
+}
+
+func TestApplyChartDataReportsStaleAnnotations(t *testing.T) {
+	cases := []struct {
+		name      string
+		threshold float64
+		values    []string
+		wantAlert bool
+	}{
+		{name: "small change", threshold: 0.2, values: []string{"11", "22"}},
+		{name: "large change", threshold: 0.2, values: []string{"10", "35"}, wantAlert: true},
+		{name: "disabled", threshold: 0, values: []string{"100", "200"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Chart.AnnotationStaleThreshold = tc.threshold
+			doc, err := OpenFile(fixturePath("chart_user_shapes.pptx"), WithOptions(opts))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+
+			data := map[string][]string{
+				"categories": {"A", "B"},
+				"values:0":   tc.values,
+			}
+			if err := doc.ApplyChartData(0, data); err != nil {
+				t.Fatalf("ApplyChartData: %v", err)
+			}
+
+			alerts := doc.AlertsByCode("CHART_ANNOTATIONS_MAY_BE_STALE")
+			if !tc.wantAlert {
+				if len(alerts) != 0 {
+					t.Fatalf("unexpected alerts: %#v", alerts)
+				}
+				return
+			}
+			if len(alerts) != 1 {
+				t.Fatalf("expected one stale-annotations alert, got %#v", alerts)
+			}
+			ctx := alerts[0].Context
+			if ctx["chart"] != "ppt/charts/chart1.xml" || ctx["drawing"] != "ppt/drawings/drawing1.xml" || ctx["maxRelativeChange"] != "0.75" {
+				t.Fatalf("unexpected alert context: %#v", ctx)
+			}
+		})
+	}
+}
+
+func TestApplyChartDataWithoutUserShapesSkipsStalenessCheck(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data := map[string][]string{
+		"categories": {"A", "B"},
+		"values:0":   {"1000", "2000"},
+	}
+	if err := doc.ApplyChartData(0, data); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	if alerts := doc.AlertsByCode("CHART_ANNOTATIONS_MAY_BE_STALE"); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts: %#v", alerts)
+	}
+}
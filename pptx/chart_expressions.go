@@ -0,0 +1,217 @@
+package pptx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/xlsxembed"
+)
+
+// valueExpression is a relative update accepted by ApplyChartData when
+// Options.Chart.AllowExpressions is set. The grammar is deliberately tiny:
+//
+//	+N%        current * (1 + N/100)
+//	-N%        current * (1 - N/100)
+//	*F         current * F
+//	=prev+N    current + N (also -, * and /)
+//
+// N and F are plain decimal numbers; spaces around the parts are ignored and
+// "prev" is case-insensitive. Anything else is validated as a literal.
+type valueExpression struct {
+	op      byte
+	operand float64
+}
+
+func parseValueExpression(value string) (valueExpression, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return valueExpression{}, false
+	}
+
+	switch value[0] {
+	case '+', '-':
+		if !strings.HasSuffix(value, "%") {
+			return valueExpression{}, false
+		}
+		percent, ok := expressionOperand(value[1 : len(value)-1])
+		if !ok {
+			return valueExpression{}, false
+		}
+		if value[0] == '-' {
+			percent = -percent
+		}
+		return valueExpression{op: '%', operand: percent}, true
+	case '*':
+		factor, ok := expressionOperand(value[1:])
+		return valueExpression{op: '*', operand: factor}, ok
+	case '=':
+		rest := strings.TrimSpace(value[1:])
+		if len(rest) < 5 || !strings.EqualFold(rest[:4], "prev") {
+			return valueExpression{}, false
+		}
+		rest = strings.TrimSpace(rest[4:])
+		if rest == "" || !strings.ContainsRune("+-*/", rune(rest[0])) {
+			return valueExpression{}, false
+		}
+		operand, ok := expressionOperand(rest[1:])
+		return valueExpression{op: rest[0], operand: operand}, ok
+	}
+	return valueExpression{}, false
+}
+
+// expressionOperand accepts digits with an optional decimal point, leaving
+// signs, exponents, and NaN/Inf spellings out of the grammar.
+func expressionOperand(text string) (float64, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.Trim(text, "0123456789.") != "" {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	return number, err == nil
+}
+
+func (e valueExpression) eval(current float64) (float64, error) {
+	switch e.op {
+	case '%':
+		return current + current*e.operand/100, nil
+	case '+':
+		return current + e.operand, nil
+	case '-':
+		return current - e.operand, nil
+	case '*':
+		return current * e.operand, nil
+	case '/':
+		if e.operand == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return current / e.operand, nil
+	}
+	return 0, fmt.Errorf("unknown operator %q", e.op)
+}
+
+// pendingExpression is a value update whose number depends on the cell's
+// current value. update indexes the placeholder in the update list.
+type pendingExpression struct {
+	update      int
+	seriesIndex int
+	raw         string
+	expr        valueExpression
+}
+
+// chartNumericValue parses the value at position in one ApplyChartData
+// series. With expressions enabled, a value matching the grammar is queued
+// in pending and a placeholder returned; evaluateExpressions fills it in.
+// A value CoerceNumbers coerces is recorded in coerced, and a missing one
+// is returned as a CellValue with neither field set, which clears the cell.
+func (d *Document) chartNumericValue(raw string, seriesIndex, position, update int, pending *[]pendingExpression, coerced numberCoercions) (CellValue, error) {
+	value := strings.TrimSpace(raw)
+	if d.opts.Chart.AllowExpressions {
+		if expr, ok := parseValueExpression(value); ok {
+			*pending = append(*pending, pendingExpression{update: update, seriesIndex: seriesIndex, raw: value, expr: expr})
+			return Num(0), nil
+		}
+	}
+	number, missing, wasCoerced, err := readInputNumber(value, d.opts.Input)
+	if err != nil {
+		return CellValue{}, &InvalidNumberError{SeriesIndex: seriesIndex, Position: position, Value: raw}
+	}
+	if wasCoerced {
+		coerced.add(seriesIndex, position)
+	}
+	if missing && d.opts.Workbook.MissingNumericPolicy != MissingNumericZero {
+		return CellValue{}, nil
+	}
+	return Num(number), nil
+}
+
+// evaluateExpressions reads the current value of every cell targeted by an
+// expression, before anything is written, and replaces the placeholders.
+// In BestEffort a cell that cannot be evaluated keeps its current value and
+// its update is dropped.
+func (d *docCall) evaluateExpressions(dep ChartDependencies, updates []CellUpdate, pending []pendingExpression) ([]CellUpdate, error) {
+	if len(pending) == 0 {
+		return updates, nil
+	}
+
+	wbBytes, err := d.pkg.ReadPart(dep.WorkbookPath)
+	if err != nil {
+		return nil, fmt.Errorf("read workbook %q: %w", dep.WorkbookPath, err)
+	}
+	wb, err := d.openEmbeddedWorkbook(dep.WorkbookPath, wbBytes)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]xlsxembed.Range, len(pending))
+	for i, p := range pending {
+		update := updates[p.update]
+		ranges[i] = xlsxembed.Range{Sheet: update.Sheet, StartCell: update.Cell, EndCell: update.Cell}
+	}
+	current, err := wb.GetRanges(ranges, xlsxembed.MissingNumericEmpty)
+	if err != nil {
+		return nil, err
+	}
+
+	drop := make(map[int]struct{})
+	for i, p := range pending {
+		var value string
+		if len(current[i]) > 0 {
+			value = strings.TrimSpace(current[i][0])
+		}
+		result, err := evalExpression(p.expr, value)
+		if err != nil {
+			if err := d.handleExpressionError(dep, updates[p.update], p, err); err != nil {
+				return nil, err
+			}
+			drop[p.update] = struct{}{}
+			continue
+		}
+		updates[p.update].Value = Num(result)
+	}
+	if len(drop) == 0 {
+		return updates, nil
+	}
+
+	kept := updates[:0]
+	for i, update := range updates {
+		if _, ok := drop[i]; !ok {
+			kept = append(kept, update)
+		}
+	}
+	return kept, nil
+}
+
+func evalExpression(expr valueExpression, current string) (float64, error) {
+	if current == "" {
+		return 0, fmt.Errorf("current value is empty")
+	}
+	number, err := strconv.ParseFloat(current, 64)
+	if err != nil {
+		return 0, fmt.Errorf("current value %q is not numeric", current)
+	}
+	return expr.eval(number)
+}
+
+func (d *docCall) handleExpressionError(dep ChartDependencies, update CellUpdate, p pendingExpression, err error) error {
+	err = fmt.Errorf("evaluate %q for %s!%s (series %d): %w", p.raw, update.Sheet, update.Cell, p.seriesIndex, err)
+	if d.mode() != BestEffort {
+		return err
+	}
+
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "CHART_EXPRESSION_EVAL_FAILED",
+		Message: "Value expression could not be evaluated; cell left unchanged",
+		Context: map[string]string{
+			"slide":       dep.SlidePath,
+			"chart":       dep.ChartPath,
+			"workbook":    dep.WorkbookPath,
+			"sheet":       update.Sheet,
+			"cell":        update.Cell,
+			"expression":  p.raw,
+			"seriesIndex": strconv.Itoa(p.seriesIndex),
+			"error":       err.Error(),
+		},
+	})
+	return nil
+}
@@ -0,0 +1,202 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func expressionOptions(mode ErrorMode) Options {
+	opts := DefaultOptions()
+	opts.Mode = mode
+	opts.Chart.AllowExpressions = true
+	return opts
+}
+
+func TestApplyChartDataExpressions(t *testing.T) {
+	exercisesFeature(t, "apply.expressions")
+
+	// bar_simple_embedded holds 10 and 20 in B2:B3.
+	cases := []struct {
+		expr string
+		want []string
+	}{
+		{"+10%", []string{"11", "22"}},
+		{"-25%", []string{"7.5", "15"}},
+		{"*3", []string{"30", "60"}},
+		{"=prev+5", []string{"15", "25"}},
+		{"=prev-2.5", []string{"7.5", "17.5"}},
+		{"=PREV * 1.5", []string{"15", "30"}},
+		{"=prev/4", []string{"2.5", "5"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(expressionOptions(Strict)))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			err = doc.ApplyChartData(0, map[string][]string{
+				"categories": {"A", "B"},
+				"values:0":   {tc.expr, " " + tc.expr + " "},
+			})
+			if err != nil {
+				t.Fatalf("ApplyChartData: %v", err)
+			}
+			assertExpressionResult(t, doc, tc.want)
+		})
+	}
+}
+
+func TestApplyChartDataMixesLiteralsAndExpressions(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(expressionOptions(Strict)))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.ApplyChartData(0, map[string][]string{
+		"categories": {"A", "B"},
+		"values:0":   {"-3", "+50%"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	assertExpressionResult(t, doc, []string{"-3", "30"})
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	_, vals := extractChartCacheValues(t, readZipEntry(t, outputPath, "ppt/charts/chart1.xml"))
+	if strings.Join(vals, ",") != "-3,30" {
+		t.Fatalf("cache does not hold the evaluated values: %v", vals)
+	}
+}
+
+func TestApplyChartDataExpressionsRequireOption(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.ApplyChartData(0, map[string][]string{
+		"categories": {"A", "B"},
+		"values:0":   {"+10%", "20"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `invalid numeric value "+10%"`) {
+		t.Fatalf("expected expressions to be rejected by default, got %v", err)
+	}
+}
+
+func TestApplyChartDataRejectsNearExpressions(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(expressionOptions(Strict)))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for _, value := range []string{"10%", "+1e2%", "**2", "=next+1", "=prev", "=prev^2", "*-2"} {
+		err := doc.ApplyChartData(0, map[string][]string{
+			"categories": {"A", "B"},
+			"values:0":   {value, "1"},
+		})
+		if err == nil || !strings.Contains(err.Error(), "invalid numeric value") {
+			t.Fatalf("%q: expected a literal validation error, got %v", value, err)
+		}
+	}
+}
+
+func TestApplyChartDataExpressionOnEmptyCell(t *testing.T) {
+	t.Run("Strict", func(t *testing.T) {
+		doc, err := OpenFile(writeEmptyCellDeck(t), WithOptions(expressionOptions(Strict)))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		err = doc.ApplyChartData(0, map[string][]string{
+			"categories": {"A", "B"},
+			"values:0":   {"+10%", "*2"},
+		})
+		if err == nil || !strings.Contains(err.Error(), `evaluate "*2" for Sheet1!B3`) || !strings.Contains(err.Error(), "empty") {
+			t.Fatalf("expected empty-cell evaluation error, got %v", err)
+		}
+	})
+
+	t.Run("BestEffort", func(t *testing.T) {
+		doc, err := OpenFile(writeEmptyCellDeck(t), WithOptions(expressionOptions(BestEffort)))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		err = doc.ApplyChartData(0, map[string][]string{
+			"categories": {"A", "B"},
+			"values:0":   {"+10%", "*2"},
+		})
+		if err != nil {
+			t.Fatalf("ApplyChartData: %v", err)
+		}
+		alerts := doc.AlertsByCode("CHART_EXPRESSION_EVAL_FAILED")
+		if len(alerts) != 1 || alerts[0].Context["cell"] != "B3" || alerts[0].Context["expression"] != "*2" {
+			t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+		}
+		assertExpressionResult(t, doc, []string{"11", ""})
+	})
+}
+
+func assertExpressionResult(t *testing.T, doc *Document, want []string) {
+	t.Helper()
+
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(data.Series[0].Data, want) {
+		t.Fatalf("unexpected values: got %v want %v", data.Series[0].Data, want)
+	}
+}
+
+// writeEmptyCellDeck builds the bar_simple_embedded chart over a workbook
+// with B2 = 10 and no B3 cell.
+func writeEmptyCellDeck(t *testing.T) string {
+	t.Helper()
+
+	fixture := fixturePath("bar_simple_embedded.pptx")
+	workbook := baseXLSXParts(t)
+	workbook["xl/worksheets/sheet1.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="2"><c r="A2" t="inlineStr"><is><t>A</t></is></c><c r="B2"><v>10</v></c></row>
+    <row r="3"><c r="A3" t="inlineStr"><is><t>B</t></is></c></row>
+  </sheetData>
+</worksheet>`)
+
+	parts := map[string][]byte{
+		"ppt/embeddings/embeddedWorkbook1.xlsx": writeZipBytes(t, workbook),
+	}
+	for _, name := range []string{
+		"[Content_Types].xml",
+		"ppt/slides/slide1.xml",
+		"ppt/slides/_rels/slide1.xml.rels",
+		"ppt/charts/chart1.xml",
+		"ppt/charts/_rels/chart1.xml.rels",
+	} {
+		parts[name] = readZipEntry(t, fixture, name)
+	}
+
+	path := filepath.Join(t.TempDir(), "empty_cell.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestPlanChangesAcceptsExpressions(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(expressionOptions(Strict)))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	plan, err := doc.PlanChanges(PlanRequest{Data: ChartDataInput{
+		"categories": {"A", "B"},
+		"values:0":   {"=prev*1.05", "5"},
+	}})
+	if err != nil {
+		t.Fatalf("PlanChanges: %v", err)
+	}
+	if len(plan.Charts) != 1 || plan.Charts[0].Action != "apply" {
+		t.Fatalf("unexpected plan: %#v", plan)
+	}
+}
@@ -0,0 +1,69 @@
+package pptx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/xlref"
+)
+
+// fingerprintVersion prefixes every chart fingerprint. The digest input
+// built by chartFingerprint is part of the format: any change to what goes
+// into it, or how it is encoded, must bump the version so stored
+// fingerprints from an older release compare as different rather than
+// silently colliding or drifting.
+const fingerprintVersion = "v1"
+
+// chartFingerprint hashes the structure of a chart: its type, its workbook
+// part, and its ranges other than data label ranges in chart order by kind,
+// series position, sheet, and normalized bounds. Values and cached points
+// are not part of it, so data updates keep the fingerprint. It also returns
+// the number of cells the ranges cover, counting shared cells once per range.
+func chartFingerprint(chartType, workbookPath string, ranges []ChartRange) (string, int) {
+	var b strings.Builder
+	b.WriteString("why-pptx chart ")
+	b.WriteString(fingerprintVersion)
+	writeFingerprintField(&b, chartType)
+	writeFingerprintField(&b, workbookPath)
+
+	cells := 0
+	for _, r := range ranges {
+		// Data label ranges were not read when v1 was defined; leaving them
+		// out keeps the fingerprints of charts that have one unchanged.
+		if r.Kind == RangeDataLabels {
+			continue
+		}
+		writeFingerprintField(&b, string(r.Kind))
+		writeFingerprintField(&b, strconv.Itoa(r.SeriesIndex))
+		writeFingerprintField(&b, r.Sheet)
+		bounds, err := xlref.RangeRef{Sheet: r.Sheet, StartCell: r.StartCell, EndCell: r.EndCell}.Bounds()
+		if err != nil {
+			writeFingerprintField(&b, normalizeFingerprintCell(r.StartCell))
+			writeFingerprintField(&b, normalizeFingerprintCell(r.EndCell))
+			continue
+		}
+		// Indexes rather than Bounds.String, whose formatting is not part
+		// of the fingerprint format.
+		writeFingerprintField(&b, fmt.Sprintf("%d,%d:%d,%d", bounds.StartCol, bounds.StartRow, bounds.EndCol, bounds.EndRow))
+		cells += bounds.Cells()
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return fingerprintVersion + ":" + hex.EncodeToString(sum[:16]), cells
+}
+
+// writeFingerprintField length-prefixes each field so that no sheet name or
+// path can run into the next field.
+func writeFingerprintField(b *strings.Builder, field string) {
+	b.WriteByte('\n')
+	b.WriteString(strconv.Itoa(len(field)))
+	b.WriteByte(':')
+	b.WriteString(field)
+}
+
+func normalizeFingerprintCell(cell string) string {
+	return strings.ToUpper(strings.ReplaceAll(cell, "$", ""))
+}
@@ -0,0 +1,110 @@
+package pptx
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// barSimpleFingerprint pins the fingerprint of bar_simple_embedded.pptx. It
+// must only change together with fingerprintVersion.
+const barSimpleFingerprint = "v1:dcf14aa2a17d3a5bea879ffae547c599"
+
+func TestChartFingerprintIsPinned(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil {
+		t.Fatalf("ListCharts: %v", err)
+	}
+	if len(charts) != 1 || charts[0].Fingerprint != barSimpleFingerprint || charts[0].CellCount != 4 {
+		t.Fatalf("unexpected chart info: %+v", charts)
+	}
+
+	plan, err := doc.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if plan.Charts[0].Fingerprint != barSimpleFingerprint || plan.Charts[0].CellCount != 4 {
+		t.Fatalf("unexpected planned chart: %+v", plan.Charts[0])
+	}
+}
+
+func TestChartFingerprintStableAcrossValueChanges(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, map[string][]string{
+		"categories": {"North", "South"},
+		"values:0":   {"125.5", "-3"},
+	}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	charts, err := saved.ListCharts()
+	if err != nil {
+		t.Fatalf("ListCharts: %v", err)
+	}
+	if charts[0].Fingerprint != barSimpleFingerprint {
+		t.Fatalf("value update changed the fingerprint to %s", charts[0].Fingerprint)
+	}
+}
+
+func TestChartFingerprintTracksStructure(t *testing.T) {
+	base := []ChartRange{
+		{Kind: RangeCategories, Sheet: "Sheet1", StartCell: "A2", EndCell: "A3"},
+		{Kind: RangeValues, Sheet: "Sheet1", StartCell: "B2", EndCell: "B3"},
+	}
+	baseline, cells := chartFingerprint("bar", "ppt/embeddings/embeddedWorkbook1.xlsx", base)
+	if cells != 4 || !strings.HasPrefix(baseline, fingerprintVersion+":") {
+		t.Fatalf("unexpected fingerprint %q with %d cells", baseline, cells)
+	}
+
+	absolute := []ChartRange{
+		{Kind: RangeCategories, Sheet: "Sheet1", StartCell: "$A$2", EndCell: "$A$3"},
+		{Kind: RangeValues, Sheet: "Sheet1", StartCell: "$B$2", EndCell: "$B$3", Formula: "Sheet1!$B$2:$B$3"},
+	}
+	if got, _ := chartFingerprint("bar", "ppt/embeddings/embeddedWorkbook1.xlsx", absolute); got != baseline {
+		t.Fatalf("absolute references changed the fingerprint")
+	}
+
+	changed := map[string]struct {
+		chartType string
+		workbook  string
+		ranges    []ChartRange
+	}{
+		"range moved": {"bar", "ppt/embeddings/embeddedWorkbook1.xlsx", []ChartRange{
+			base[0],
+			{Kind: RangeValues, Sheet: "Sheet1", StartCell: "C2", EndCell: "C3"},
+		}},
+		"range grown": {"bar", "ppt/embeddings/embeddedWorkbook1.xlsx", []ChartRange{
+			{Kind: RangeCategories, Sheet: "Sheet1", StartCell: "A2", EndCell: "A4"},
+			{Kind: RangeValues, Sheet: "Sheet1", StartCell: "B2", EndCell: "B4"},
+		}},
+		"series added": {"bar", "ppt/embeddings/embeddedWorkbook1.xlsx", append(append([]ChartRange(nil), base...),
+			ChartRange{Kind: RangeValues, SeriesIndex: 1, Sheet: "Sheet1", StartCell: "C2", EndCell: "C3"},
+		)},
+		"sheet renamed": {"bar", "ppt/embeddings/embeddedWorkbook1.xlsx", []ChartRange{
+			{Kind: RangeCategories, Sheet: "Data", StartCell: "A2", EndCell: "A3"},
+			{Kind: RangeValues, Sheet: "Data", StartCell: "B2", EndCell: "B3"},
+		}},
+		"type converted":   {"line", "ppt/embeddings/embeddedWorkbook1.xlsx", base},
+		"workbook swapped": {"bar", "ppt/embeddings/embeddedWorkbook2.xlsx", base},
+	}
+	for name, tc := range changed {
+		if got, _ := chartFingerprint(tc.chartType, tc.workbook, tc.ranges); got == baseline {
+			t.Fatalf("%s: fingerprint did not change", name)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package pptx
+
+import (
+	"why-pptx/internal/chartxml"
+)
+
+// lintCharts runs the open-time structural lint enabled by
+// Options.Discovery.LintCharts. Findings are recorded as info alerts in every
+// mode; the lint never fails OpenFile, so a deck whose charts cannot be
+// discovered is simply left unlinted.
+func (d *Document) lintCharts() {
+	refs, err := d.discoverChartRefs()
+	if err != nil {
+		return
+	}
+
+	for _, ref := range refs {
+		data, err := d.pkg.ReadPart(ref.ChartPath)
+		if err == nil {
+			var findings []chartxml.LintFinding
+			findings, err = chartxml.Lint(data)
+			for _, finding := range findings {
+				d.addAlert(Alert{
+					Level:   "info",
+					Code:    "CHART_LINT_" + finding.Rule,
+					Message: "Chart lint: " + finding.Message,
+					Context: map[string]string{
+						"slide":   ref.SlidePath,
+						"chart":   ref.ChartPath,
+						"pointer": finding.Pointer,
+					},
+				})
+			}
+		}
+		if err != nil {
+			d.addAlert(Alert{
+				Level:   "info",
+				Code:    "CHART_LINT_UNREADABLE",
+				Message: "Chart lint could not read chart XML",
+				Context: map[string]string{
+					"slide": ref.SlidePath,
+					"chart": ref.ChartPath,
+					"error": err.Error(),
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package pptx
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLintChartsReportsPointers(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Discovery.LintCharts = true
+	doc, err := OpenFile(fixturePath("chart_lint_violations.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	var got []string
+	for _, alert := range doc.Alerts() {
+		if alert.Level != "info" {
+			t.Fatalf("expected info alert, got %#v", alert)
+		}
+		got = append(got, alert.Context["chart"]+" "+alert.Code+" "+alert.Context["pointer"])
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"ppt/charts/chart1.xml CHART_LINT_FORMULA_MISSING plotArea/barChart/ser[3]/cat/strRef/f",
+		"ppt/charts/chart1.xml CHART_LINT_PLOT_UNRECOGNIZED plotArea/bubbleChart",
+		"ppt/charts/chart1.xml CHART_LINT_PTCOUNT_MISSING plotArea/barChart/ser[3]/val/numRef/numCache",
+		"ppt/charts/chart1.xml CHART_LINT_REF_COUNT plotArea/barChart/ser[2]/val",
+		"ppt/charts/chart1.xml CHART_LINT_SERIES_ID_MISSING plotArea/barChart/ser[2]/order",
+		"ppt/charts/chart2.xml CHART_LINT_NO_SERIES plotArea",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected lint alerts:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintChartsDisabledByDefault(t *testing.T) {
+	doc, err := OpenFile(fixturePath("chart_lint_violations.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("expected no alerts without LintCharts, got %#v", doc.Alerts())
+	}
+}
@@ -0,0 +1,111 @@
+package pptx
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"why-pptx/internal/chartxml"
+)
+
+// chartModel holds the parsed views of one version of a chart part. Each
+// view is decoded the first time a read path asks for it.
+type chartModel struct {
+	sum    [sha256.Size]byte
+	info   *chartxml.Info
+	parsed *chartxml.ParsedChart
+	mixed  *chartxml.MixedChart
+}
+
+// chartModels caches parsed chart XML by part path. An entry is reused only
+// while the part's bytes hash to the same sum, so a chart rewritten through
+// the package or a staged overlay gets a fresh entry without the write paths
+// having to invalidate anything. Only the latest version of a path is kept.
+//
+// The cached values are shared: callers read them and copy anything they
+// hand out. Document.mu guards the map and the entries; a chart is parsed
+// outside the lock, so two goroutines may both parse it and the later
+// result wins.
+type chartModels struct {
+	byPath map[string]*chartModel
+}
+
+func (m *chartModels) model(chartPath string, data []byte) *chartModel {
+	sum := sha256.Sum256(data)
+	if entry, ok := m.byPath[chartPath]; ok && entry.sum == sum {
+		return entry
+	}
+	if m.byPath == nil {
+		m.byPath = make(map[string]*chartModel)
+	}
+	entry := &chartModel{sum: sum}
+	m.byPath[chartPath] = entry
+	return entry
+}
+
+// chartInfo returns chartxml.ParseInfo of data, the current bytes of
+// chartPath.
+func (d *docCall) chartInfo(chartPath string, data []byte) (*chartxml.Info, error) {
+	d.mu.Lock()
+	model := d.charts.model(chartPath, data)
+	cached := model.info
+	if cached == nil {
+		d.stats.ChartParses++
+	}
+	d.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+	info, err := chartxml.ParseInfoWithCancel(bytes.NewReader(data), d.cancel)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	model.info = info
+	d.mu.Unlock()
+	return info, nil
+}
+
+// parsedChart returns chartxml.Parse of data, the current bytes of chartPath.
+func (d *docCall) parsedChart(chartPath string, data []byte) (*chartxml.ParsedChart, error) {
+	d.mu.Lock()
+	model := d.charts.model(chartPath, data)
+	cached := model.parsed
+	if cached == nil {
+		d.stats.ChartParses++
+	}
+	d.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+	parsed, err := chartxml.ParseWithCancel(bytes.NewReader(data), d.cancel)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	model.parsed = parsed
+	d.mu.Unlock()
+	return parsed, nil
+}
+
+// mixedChart returns chartxml.ParseMixed of data, the current bytes of
+// chartPath.
+func (d *docCall) mixedChart(chartPath string, data []byte) (*chartxml.MixedChart, error) {
+	d.mu.Lock()
+	model := d.charts.model(chartPath, data)
+	cached := model.mixed
+	if cached == nil {
+		d.stats.ChartParses++
+	}
+	d.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+	mixed, err := chartxml.ParseMixedWithCancel(bytes.NewReader(data), d.cancel)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	model.mixed = mixed
+	d.mu.Unlock()
+	return mixed, nil
+}
@@ -0,0 +1,62 @@
+package pptx
+
+import "testing"
+
+func TestChartParsedOncePerContentVersion(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	steps := []struct {
+		name   string
+		run    func() error
+		parses int
+	}{
+		// Info for the chart list, then the full parse for dependencies.
+		{"plan", func() error { _, err := doc.Plan(); return err }, 2},
+		{"plan again", func() error { _, err := doc.Plan(); return err }, 2},
+		// Apply reuses the dependencies; the caches it writes change the part.
+		{"apply", func() error {
+			return doc.ApplyChartData(0, map[string][]string{"categories": {"A", "B"}, "values:0": {"1", "2"}})
+		}, 2},
+		// The rewritten chart is parsed once for its dependencies...
+		{"sync", func() error { _, err := doc.SyncChartCaches(); return err }, 3},
+		// ...once more for the info view, and then reused.
+		{"extract", func() error { _, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml"); return err }, 4},
+		{"plan after write", func() error { _, err := doc.Plan(); return err }, 4},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := doc.Stats().ChartParses; got != step.parses {
+			t.Fatalf("%s: expected %d chart parses in total, got %d", step.name, step.parses, got)
+		}
+	}
+}
+
+// BenchmarkChartDependenciesWideChart reads the dependencies of the
+// generated 30 x 10k chart with the parse cache warm; the Cold variant drops
+// the cache every iteration, as every read did before the cache existed.
+func BenchmarkChartDependenciesWideChart(b *testing.B) {
+	doc := openWideChartDeck(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := doc.GetChartDependencies(); err != nil {
+			b.Fatalf("GetChartDependencies: %v", err)
+		}
+	}
+}
+
+func BenchmarkChartDependenciesWideChartCold(b *testing.B) {
+	doc := openWideChartDeck(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		doc.charts = chartModels{}
+		if _, err := doc.GetChartDependencies(); err != nil {
+			b.Fatalf("GetChartDependencies: %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package pptx
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"why-pptx/internal/xlsxembed"
+)
+
+// openWorkbook opens an embedded workbook for every read and write path,
+// through Document.openEmbeddedWorkbook. Tests swap it to inject
+// failing or panicking workbooks.
+var openWorkbook = xlsxembed.OpenWithBudget
+
+// maxPanicStack bounds the stack kept on a ChartPanicError and its alert.
+const maxPanicStack = 4096
+
+// ChartPanicError is returned in Strict mode, and recorded as a
+// CHART_INTERNAL_PANIC alert in BestEffort, when processing one chart of a
+// batch (ExtractAllCharts, ExportAllCharts, SyncChartCaches,
+// RepairChartCaches, PlanChanges) panics. Stack is the goroutine stack at the
+// panic, cut to a few kilobytes. Single-chart methods do not recover.
+type ChartPanicError struct {
+	Operation string
+	ChartPath string
+	Value     string
+	Stack     string
+}
+
+func (e *ChartPanicError) Error() string {
+	return fmt.Sprintf("chart %q panicked during %s: %s", e.ChartPath, e.Operation, e.Value)
+}
+
+// recoverChart runs fn, the work of one chart in a batch, and turns a panic
+// into a *ChartPanicError.
+func recoverChart(operation, chartPath string, fn func() error) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		stack := debug.Stack()
+		if len(stack) > maxPanicStack {
+			stack = append(stack[:maxPanicStack:maxPanicStack], "\n..."...)
+		}
+		err = &ChartPanicError{Operation: operation, ChartPath: chartPath, Value: fmt.Sprint(r), Stack: string(stack)}
+	}()
+	return fn()
+}
+
+// handleChartPanic logs a recovered panic, and in BestEffort records it and
+// returns nil so the batch moves on to the next chart.
+func (d *docCall) handleChartPanic(err *ChartPanicError, slidePath, workbookPath string) error {
+	d.logChartPanic(err)
+	if d.mode() != BestEffort {
+		return err
+	}
+	d.addAlert(chartPanicAlert(err, slidePath, workbookPath))
+	return nil
+}
+
+func (d *Document) logChartPanic(err *ChartPanicError) {
+	d.logger.Error("chart processing panicked", "chart", err.ChartPath, "operation", err.Operation, "panic", err.Value)
+}
+
+func chartPanicAlert(err *ChartPanicError, slidePath, workbookPath string) Alert {
+	return Alert{
+		Level:   "error",
+		Code:    "CHART_INTERNAL_PANIC",
+		Message: "Chart processing panicked; chart is skipped",
+		Context: map[string]string{
+			"slide":     slidePath,
+			"chart":     err.ChartPath,
+			"workbook":  workbookPath,
+			"operation": err.Operation,
+			"panic":     err.Value,
+			"stack":     err.Stack,
+		},
+	}
+}
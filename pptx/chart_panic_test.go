@@ -0,0 +1,160 @@
+package pptx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"why-pptx/internal/ooxmlpkg"
+	"why-pptx/internal/xlsxembed"
+)
+
+// writeTwoChartDeck writes a slide with two charts, each backed by its own
+// workbook, and returns the path and the bytes of the second workbook.
+func writeTwoChartDeck(t *testing.T) (string, []byte) {
+	t.Helper()
+
+	bad := buildWorkbookWithValues(t, "Cat1", "Cat2", 30, 40)
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte("<slide/>"),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart2.xml"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20),
+		"ppt/embeddings/embeddedWorkbook2.xlsx": bad,
+	}
+	for i := 1; i <= 2; i++ {
+		parts[fmt.Sprintf("ppt/charts/chart%d.xml", i)] = chartWithCaches("Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3", []string{"Old1", "Old2"}, []string{"1", "2"})
+		parts[fmt.Sprintf("ppt/charts/_rels/chart%d.xml.rels", i)] = []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook%d.xlsx"/>
+</Relationships>`, i))
+	}
+
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path, bad
+}
+
+// panicOnWorkbook makes opening the given workbook panic the way a decoder
+// bug on a malformed part would.
+func panicOnWorkbook(t *testing.T, bad []byte) {
+	t.Helper()
+	original := openWorkbook
+	openWorkbook = func(data []byte, budget *ooxmlpkg.Budget) (*xlsxembed.Workbook, error) {
+		if bytes.Equal(data, bad) {
+			var cells []string
+			_ = cells[7]
+		}
+		return original(data, budget)
+	}
+	t.Cleanup(func() { openWorkbook = original })
+}
+
+func assertPanicAlert(t *testing.T, doc *Document, operation string) {
+	t.Helper()
+	alerts := doc.AlertsByCode("CHART_INTERNAL_PANIC")
+	if len(alerts) != 1 {
+		t.Fatalf("expected one CHART_INTERNAL_PANIC alert, got %#v", doc.Alerts())
+	}
+	ctx := alerts[0].Context
+	if ctx["chart"] != "ppt/charts/chart2.xml" || ctx["operation"] != operation || !strings.Contains(ctx["panic"], "index out of range") {
+		t.Fatalf("unexpected alert context: %#v", ctx)
+	}
+	if ctx["stack"] == "" || len(ctx["stack"]) > maxPanicStack+4 {
+		t.Fatalf("unexpected stack length %d", len(ctx["stack"]))
+	}
+}
+
+func TestBatchesRecoverChartPanicsBestEffort(t *testing.T) {
+	path, bad := writeTwoChartDeck(t)
+	panicOnWorkbook(t, bad)
+	opts := DefaultOptions()
+	opts.Mode = BestEffort
+
+	t.Run("ExportAllChartsDetailed", func(t *testing.T) {
+		doc, err := OpenFile(path, WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		result, err := doc.ExportAllChartsDetailed(ChartJSExporter{})
+		if err != nil {
+			t.Fatalf("ExportAllChartsDetailed: %v", err)
+		}
+		if len(result.Exported) != 1 || result.Exported[0].ChartPath != "ppt/charts/chart1.xml" {
+			t.Fatalf("expected chart1 to export, got %#v", result.Exported)
+		}
+		assertPanicAlert(t, doc, "extract")
+	})
+
+	t.Run("SyncChartCaches", func(t *testing.T) {
+		doc, err := OpenFile(path, WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		results, err := doc.SyncChartCaches()
+		if err != nil {
+			t.Fatalf("SyncChartCaches: %v", err)
+		}
+		if len(results) != 2 || !results[0].Changed || !results[1].Skipped {
+			t.Fatalf("unexpected results: %#v", results)
+		}
+		assertPanicAlert(t, doc, "syncChartCaches")
+	})
+
+	t.Run("RepairChartCaches", func(t *testing.T) {
+		doc, err := OpenFile(path, WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		if _, err := doc.RepairChartCaches(); err != nil {
+			t.Fatalf("RepairChartCaches: %v", err)
+		}
+		assertPanicAlert(t, doc, "repairChartCaches")
+	})
+}
+
+func TestBatchesReturnChartPanicStrict(t *testing.T) {
+	path, bad := writeTwoChartDeck(t)
+	panicOnWorkbook(t, bad)
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	_, err = doc.ExtractAllCharts()
+	var panicErr *ChartPanicError
+	if !errors.As(err, &panicErr) || panicErr.ChartPath != "ppt/charts/chart2.xml" || panicErr.Stack == "" {
+		t.Fatalf("expected ChartPanicError for chart2, got %v", err)
+	}
+	if len(doc.AlertsByCode("CHART_INTERNAL_PANIC")) != 0 {
+		t.Fatalf("Strict must not record the panic as an alert")
+	}
+
+	if _, err := doc.SyncChartCaches(); !errors.As(err, &panicErr) {
+		t.Fatalf("expected ChartPanicError from SyncChartCaches, got %v", err)
+	}
+}
+
+func TestSingleChartCallsDoNotRecover(t *testing.T) {
+	path, bad := writeTwoChartDeck(t)
+	panicOnWorkbook(t, bad)
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected ExtractChartDataByPath to panic")
+		}
+	}()
+	_, _ = doc.ExtractChartDataByPath("ppt/charts/chart2.xml")
+}
@@ -0,0 +1,137 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"why-pptx/internal/chartdiscover"
+)
+
+// ErrChartNotFound is wrapped by the errors returned when a chart path or
+// name matches no discovered chart.
+var ErrChartNotFound = errors.New("chart not found")
+
+// ErrChartIndexOutOfRange is returned for a chart index that is negative or
+// past the charts ListCharts returns.
+var ErrChartIndexOutOfRange = errors.New("chart index out of range")
+
+// ErrChartSkipped is wrapped by the error a write returns in BestEffort when
+// the chart it targets was skipped, already alerted, so nothing was written.
+var ErrChartSkipped = errors.New("chart skipped")
+
+// ChartPathKind classifies the part named by a chart path that is not a
+// discovered chart.
+type ChartPathKind string
+
+const (
+	ChartPathMissing   ChartPathKind = "missing"
+	ChartPathSlide     ChartPathKind = "slide"
+	ChartPathWorkbook  ChartPathKind = "workbook"
+	ChartPathChartRels ChartPathKind = "chartRels"
+	ChartPathOtherPart ChartPathKind = "otherPart"
+)
+
+// ChartPathError is returned by the ...ByPath methods when the path names no
+// discovered chart. Charts lists the chart paths related to the part: the
+// charts on a slide, the charts backed by a workbook, or the chart a rels
+// file belongs to.
+type ChartPathError struct {
+	Path   string
+	Kind   ChartPathKind
+	Charts []string
+}
+
+func (e *ChartPathError) Error() string {
+	related := strings.Join(e.Charts, ", ")
+	switch e.Kind {
+	case ChartPathMissing:
+		return fmt.Sprintf("chart not found: %q is not a part of the package", e.Path)
+	case ChartPathSlide:
+		if related == "" {
+			return fmt.Sprintf("chart not found: %q is a slide part with no charts", e.Path)
+		}
+		return fmt.Sprintf("chart not found: %q is a slide part; charts on this slide: %s", e.Path, related)
+	case ChartPathWorkbook:
+		if related == "" {
+			return fmt.Sprintf("chart not found: %q is an embedded workbook not used by any chart", e.Path)
+		}
+		return fmt.Sprintf("chart not found: %q is an embedded workbook; charts backed by it: %s", e.Path, related)
+	case ChartPathChartRels:
+		if related == "" {
+			return fmt.Sprintf("chart not found: %q is a chart relationships part", e.Path)
+		}
+		return fmt.Sprintf("chart not found: %q is a chart relationships part; use the chart path %s", e.Path, related)
+	default:
+		return fmt.Sprintf("chart not found: %q is not a discovered chart", e.Path)
+	}
+}
+
+func (e *ChartPathError) Unwrap() error {
+	return ErrChartNotFound
+}
+
+// normalizeChartPath turns a user-supplied part path into package form:
+// forward slashes, no leading slash.
+func normalizeChartPath(chartPath string) string {
+	return strings.TrimLeft(strings.ReplaceAll(chartPath, `\`, "/"), "/")
+}
+
+// chartPathError classifies chartPath, which matched none of the discovered
+// charts, into a *ChartPathError.
+func (d *Document) chartPathError(chartPath string, embedded []chartdiscover.EmbeddedChart, skipped []chartdiscover.SkippedChart) error {
+	out := &ChartPathError{Path: chartPath, Kind: ChartPathOtherPart}
+	if _, err := d.pkg.PartInfo(chartPath); err != nil {
+		out.Kind = ChartPathMissing
+		return out
+	}
+
+	related := make(map[string]struct{})
+	switch {
+	case isSlidePart(chartPath):
+		out.Kind = ChartPathSlide
+		for _, chart := range embedded {
+			if chart.SlidePath == chartPath {
+				related[chart.ChartPath] = struct{}{}
+			}
+		}
+		for _, skip := range skipped {
+			if skip.SlidePath == chartPath && skip.ChartPath != "" {
+				related[skip.ChartPath] = struct{}{}
+			}
+		}
+	case isEmbeddedWorkbookPart(chartPath):
+		out.Kind = ChartPathWorkbook
+		for _, chart := range embedded {
+			if chart.WorkbookPath == chartPath {
+				related[chart.ChartPath] = struct{}{}
+			}
+		}
+	case isChartRelsPart(chartPath):
+		out.Kind = ChartPathChartRels
+		chart := path.Join(path.Dir(path.Dir(chartPath)), strings.TrimSuffix(path.Base(chartPath), ".rels"))
+		if _, err := d.pkg.PartInfo(chart); err == nil {
+			related[chart] = struct{}{}
+		}
+	}
+
+	for chart := range related {
+		out.Charts = append(out.Charts, chart)
+	}
+	sort.Strings(out.Charts)
+	return out
+}
+
+func isSlidePart(name string) bool {
+	return path.Dir(name) == "ppt/slides" && strings.HasSuffix(name, ".xml")
+}
+
+func isEmbeddedWorkbookPart(name string) bool {
+	return path.Dir(name) == "ppt/embeddings" && strings.HasSuffix(strings.ToLower(name), ".xlsx")
+}
+
+func isChartRelsPart(name string) bool {
+	return path.Dir(name) == "ppt/charts/_rels" && strings.HasSuffix(name, ".xml.rels")
+}
@@ -0,0 +1,117 @@
+package pptx
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChartPathErrorClassifiesWrongParts(t *testing.T) {
+	cases := []struct {
+		path       string
+		kind       ChartPathKind
+		charts     []string
+		wantInText string
+	}{
+		{
+			path:       "ppt/slides/slide1.xml",
+			kind:       ChartPathSlide,
+			charts:     []string{"ppt/charts/chart1.xml", "ppt/charts/chart2.xml"},
+			wantInText: "is a slide part; charts on this slide: ppt/charts/chart1.xml, ppt/charts/chart2.xml",
+		},
+		{
+			path:       "ppt/embeddings/embeddedWorkbook1.xlsx",
+			kind:       ChartPathWorkbook,
+			charts:     []string{"ppt/charts/chart1.xml", "ppt/charts/chart2.xml"},
+			wantInText: "is an embedded workbook; charts backed by it: ppt/charts/chart1.xml, ppt/charts/chart2.xml",
+		},
+		{
+			path:       "ppt/charts/_rels/chart2.xml.rels",
+			kind:       ChartPathChartRels,
+			charts:     []string{"ppt/charts/chart2.xml"},
+			wantInText: "use the chart path ppt/charts/chart2.xml",
+		},
+		{
+			path:       "ppt/slides/_rels/slide1.xml.rels",
+			kind:       ChartPathOtherPart,
+			wantInText: "is not a discovered chart",
+		},
+		{
+			path:       "ppt/charts/chart9.xml",
+			kind:       ChartPathMissing,
+			wantInText: "is not a part of the package",
+		},
+	}
+
+	doc, err := OpenFile(fixturePath("shared_workbook_two_charts.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			for name, call := range map[string]func() error{
+				"extract": func() error {
+					_, err := doc.ExtractChartDataByPath(tc.path)
+					return err
+				},
+				"apply": func() error {
+					return doc.ApplyChartDataByPath(tc.path, map[string][]string{"values:0": {"1"}})
+				},
+			} {
+				err := call()
+				var pathErr *ChartPathError
+				if !errors.As(err, &pathErr) {
+					t.Fatalf("%s: expected *ChartPathError, got %v", name, err)
+				}
+				if !errors.Is(err, ErrChartNotFound) {
+					t.Fatalf("%s: expected ErrChartNotFound, got %v", name, err)
+				}
+				if pathErr.Kind != tc.kind || !reflect.DeepEqual(pathErr.Charts, tc.charts) {
+					t.Fatalf("%s: unexpected error fields: %#v", name, pathErr)
+				}
+				if !strings.Contains(err.Error(), tc.wantInText) {
+					t.Fatalf("%s: expected %q in %q", name, tc.wantInText, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestChartPathNormalizesSlashes(t *testing.T) {
+	for _, chartPath := range []string{"/ppt/charts/chart1.xml", `ppt\charts\chart1.xml`} {
+		doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		data, err := doc.ExtractChartDataByPath(chartPath)
+		if err != nil {
+			t.Fatalf("ExtractChartDataByPath(%q): %v", chartPath, err)
+		}
+		if data.Meta.ChartPath != "ppt/charts/chart1.xml" {
+			t.Fatalf("unexpected chart path %q", data.Meta.ChartPath)
+		}
+		update := map[string][]string{
+			"categories": {"A", "B"},
+			"values:0":   {"1", "2"},
+		}
+		if err := doc.ApplyChartDataByPath(chartPath, update); err != nil {
+			t.Fatalf("ApplyChartDataByPath(%q): %v", chartPath, err)
+		}
+	}
+}
+
+func TestChartPathErrorNormalizesWrongPart(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	_, err = doc.ExtractChartDataByPath("/ppt/slides/slide1.xml")
+	var pathErr *ChartPathError
+	if !errors.As(err, &pathErr) || pathErr.Kind != ChartPathSlide || pathErr.Path != "ppt/slides/slide1.xml" {
+		t.Fatalf("expected slide classification for a leading-slash path, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "charts on this slide: ppt/charts/chart1.xml") {
+		t.Fatalf("unexpected message: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/xlref"
+)
+
+// RangeOrientation is the shape of a ChartRange.
+type RangeOrientation string
+
+const (
+	OrientationCell   RangeOrientation = "cell"
+	OrientationRow    RangeOrientation = "row"
+	OrientationColumn RangeOrientation = "column"
+	// OrientationGrid is a rectangular range, which only a categories range
+	// holding multi-level labels may be.
+	OrientationGrid RangeOrientation = "grid"
+)
+
+// Cells lists the cells of r in order, down a column or along a row, as
+// normalized references such as "B2". A rectangular range is an error.
+func (r ChartRange) Cells() ([]string, error) {
+	return expandRangeCells(r.StartCell, r.EndCell)
+}
+
+// Len returns the number of cells r covers, rectangular ranges included.
+func (r ChartRange) Len() (int, error) {
+	bounds, err := r.bounds()
+	if err != nil {
+		return 0, err
+	}
+	return bounds.Cells(), nil
+}
+
+// Orientation reports whether r is a single cell, a row, a column, or a
+// grid. It returns "" when a cell of r is malformed.
+func (r ChartRange) Orientation() RangeOrientation {
+	bounds, err := r.bounds()
+	if err != nil {
+		return ""
+	}
+	switch {
+	case bounds.StartCol == bounds.EndCol && bounds.StartRow == bounds.EndRow:
+		return OrientationCell
+	case bounds.StartRow == bounds.EndRow:
+		return OrientationRow
+	case bounds.StartCol == bounds.EndCol:
+		return OrientationColumn
+	default:
+		return OrientationGrid
+	}
+}
+
+func (r ChartRange) bounds() (xlref.Bounds, error) {
+	return xlref.RangeRef{Sheet: r.Sheet, StartCell: r.StartCell, EndCell: r.EndCell}.Bounds()
+}
+
+// chartRangeError returns why the cells of r cannot be read, or "" when
+// they can: a cell past the worksheet grid, or a rectangular range that is
+// not a categories range.
+func chartRangeError(r ChartRange) string {
+	bounds, err := r.bounds()
+	if err != nil {
+		return err.Error()
+	}
+	if bounds.EndCol > xlref.MaxColumns || bounds.EndRow > xlref.MaxRows {
+		return fmt.Sprintf("range %s:%s is past the worksheet grid", r.StartCell, r.EndCell)
+	}
+	if r.Orientation() == OrientationGrid && r.Kind != RangeCategories {
+		return fmt.Sprintf("2D range %s:%s not supported", r.StartCell, r.EndCell)
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package pptx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChartRangeShape(t *testing.T) {
+	cases := []struct {
+		start, end  string
+		orientation RangeOrientation
+		length      int
+		cells       []string
+	}{
+		{start: "B2", end: "B2", orientation: OrientationCell, length: 1, cells: []string{"B2"}},
+		{start: "B4", end: "B2", orientation: OrientationColumn, length: 3, cells: []string{"B2", "B3", "B4"}},
+		{start: "Y1", end: "AB1", orientation: OrientationRow, length: 4, cells: []string{"Y1", "Z1", "AA1", "AB1"}},
+		{start: "A2", end: "B4", orientation: OrientationGrid, length: 6},
+	}
+	for _, tc := range cases {
+		r := ChartRange{Kind: RangeCategories, Sheet: "Sheet1", StartCell: tc.start, EndCell: tc.end}
+		if got := r.Orientation(); got != tc.orientation {
+			t.Fatalf("%s:%s orientation = %q, want %q", tc.start, tc.end, got, tc.orientation)
+		}
+		if got, err := r.Len(); err != nil || got != tc.length {
+			t.Fatalf("%s:%s Len = %d, %v; want %d", tc.start, tc.end, got, err, tc.length)
+		}
+		cells, err := r.Cells()
+		if tc.cells == nil {
+			if err == nil {
+				t.Fatalf("%s:%s: expected Cells to refuse a grid, got %v", tc.start, tc.end, cells)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(cells, tc.cells) {
+			t.Fatalf("%s:%s Cells = %v, %v; want %v", tc.start, tc.end, cells, err, tc.cells)
+		}
+	}
+
+	bad := ChartRange{StartCell: "2B", EndCell: "B3"}
+	if bad.Orientation() != "" {
+		t.Fatalf("expected no orientation for a malformed cell")
+	}
+	if _, err := bad.Len(); err == nil {
+		t.Fatalf("expected Len to fail for a malformed cell")
+	}
+}
+
+func TestGetChartDependenciesFlagsUnreadableRanges(t *testing.T) {
+	chartXML := strings.Replace(corruptIdxGapChart, "Sheet1!$B$2:$B$3", "Sheet1!$B$2:$C$3", 1)
+	doc, err := OpenFile(writeRepairDeck(t, t.TempDir(), chartXML, nil))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	deps, err := doc.GetChartDependencies()
+	if err != nil || len(deps) != 1 {
+		t.Fatalf("GetChartDependencies: %#v %v", deps, err)
+	}
+	for _, r := range deps[0].Ranges {
+		switch r.Kind {
+		case RangeCategories:
+			if r.Error != "" {
+				t.Fatalf("categories range must be readable, got %q", r.Error)
+			}
+		case RangeValues:
+			if !strings.Contains(r.Error, "2D range B2:C3") {
+				t.Fatalf("expected the values grid to carry an error, got %q", r.Error)
+			}
+		}
+	}
+}
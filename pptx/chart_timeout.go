@@ -0,0 +1,64 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"why-pptx/internal/xmlcancel"
+)
+
+// ErrChartProcessingTimeout is wrapped by the error returned when a chart
+// exceeds Options.Limits.PerChartTimeout.
+var ErrChartProcessingTimeout = errors.New("chart processing timeout")
+
+// guardChart runs fn under the per-chart timeout. The XML token loops reached
+// from fn watch d.cancel, so an expired chart unwinds on the calling goroutine
+// with nothing left running. Nested calls share the outermost guard, giving a
+// chart one budget across extraction, cache sync, and postflight validation.
+// The flag belongs to the call, so charts of calls running at the same time
+// keep their own budgets.
+func (d *docCall) guardChart(operation, slidePath, chartPath, workbookPath string, fn func() error) error {
+	timeout := d.opts.Limits.PerChartTimeout
+	if timeout <= 0 || d.cancel != nil {
+		return fn()
+	}
+
+	flag, stop := xmlcancel.After(timeout)
+	d.cancel = flag
+	// Deferred so a chart that panics does not leave its flag behind for
+	// the next one.
+	defer func() {
+		stop()
+		d.cancel = nil
+	}()
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	if err == nil || !errors.Is(err, xmlcancel.ErrCanceled) {
+		return err
+	}
+	return d.handleChartTimeout(operation, slidePath, chartPath, workbookPath, timeout, elapsed)
+}
+
+func (d *docCall) handleChartTimeout(operation, slidePath, chartPath, workbookPath string, timeout, elapsed time.Duration) error {
+	err := fmt.Errorf("%w: chart %q exceeded %s during %s (elapsed %s)", ErrChartProcessingTimeout, chartPath, timeout, operation, elapsed)
+	d.logger.Warn("chart processing timeout", "chart", chartPath, "operation", operation, "elapsed", elapsed.String())
+	if d.mode() == BestEffort {
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "CHART_PROCESSING_TIMEOUT",
+			Message: "Chart processing exceeded the per-chart timeout; chart is skipped",
+			Context: map[string]string{
+				"slide":     slidePath,
+				"chart":     chartPath,
+				"workbook":  workbookPath,
+				"operation": operation,
+				"elapsed":   elapsed.String(),
+				"timeout":   timeout.String(),
+			},
+		})
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+package pptx
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/xmlcancel"
+)
+
+// slowChartPoints is split evenly between the category and value caches of
+// the slow chart, giving a 2M-point chart.
+const slowChartPoints = 2_000_000
+
+const slowChartTimeout = 50 * time.Millisecond
+
+func TestPerChartTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a 2M-point chart")
+	}
+	path := writeSlowChartDeck(t, t.TempDir())
+
+	t.Run("BestEffort", func(t *testing.T) {
+		doc, err := OpenFile(path, WithOptions(timeoutOptions(BestEffort)))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+
+		charts, err := doc.ExtractAllCharts()
+		if err != nil {
+			t.Fatalf("ExtractAllCharts: %v", err)
+		}
+		if len(charts) != 1 || charts[0].Meta.ChartPath != "ppt/charts/chart2.xml" {
+			t.Fatalf("expected only chart2 to be extracted, got %#v", charts)
+		}
+		if len(charts[0].Series) != 1 || len(charts[0].Series[0].Data) != 2 || charts[0].Series[0].Data[0] != "10" {
+			t.Fatalf("unexpected chart2 data: %#v", charts[0])
+		}
+		assertTimeoutAlert(t, doc, "extract")
+
+		if _, err := doc.SyncChartCaches(); err != nil {
+			t.Fatalf("SyncChartCaches: %v", err)
+		}
+		assertTimeoutAlert(t, doc, "extract", "dependencies")
+		if len(doc.Alerts()) != 2 {
+			t.Fatalf("expected only timeout alerts, got %#v", doc.Alerts())
+		}
+
+		outputPath := filepath.Join(t.TempDir(), "output.pptx")
+		if err := doc.SaveFile(outputPath); err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		cats, vals := extractChartCacheValues(t, readZipEntry(t, outputPath, "ppt/charts/chart2.xml"))
+		if len(cats) != 2 || cats[0] != "Cat1" || len(vals) != 2 || vals[1] != "20" {
+			t.Fatalf("expected chart2 caches synced, got cats=%v vals=%v", cats, vals)
+		}
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		doc, err := OpenFile(path, WithOptions(timeoutOptions(Strict)))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+
+		if _, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml"); !errors.Is(err, ErrChartProcessingTimeout) {
+			t.Fatalf("expected timeout error, got %v", err)
+		}
+		if _, err := doc.ExtractChartDataByPath("ppt/charts/chart2.xml"); err != nil {
+			t.Fatalf("expected chart2 to extract after timeout, got %v", err)
+		}
+		if _, err := doc.SyncChartCaches(); !errors.Is(err, ErrChartProcessingTimeout) {
+			t.Fatalf("expected timeout error from SyncChartCaches, got %v", err)
+		}
+		if len(doc.Alerts()) != 0 {
+			t.Fatalf("expected no alerts in strict mode, got %#v", doc.Alerts())
+		}
+	})
+}
+
+func TestPerChartTimeoutAbandonsChartStage(t *testing.T) {
+	path := fixturePath("bar_simple_embedded.pptx")
+	for _, mode := range []ErrorMode{BestEffort, Strict} {
+		doc, err := OpenFile(path, WithOptions(timeoutOptions(mode)))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		deps, err := doc.GetChartDependencies()
+		if err != nil || len(deps) == 0 {
+			t.Fatalf("GetChartDependencies: %v", err)
+		}
+		dep := deps[0]
+
+		call := doc.newCall(nil)
+		err = call.withChartStage(call.validateContext(dep), func(stage overlaystage.Overlay) error {
+			if err := stage.Set(dep.ChartPath, []byte("<partial/>")); err != nil {
+				return err
+			}
+			for !call.cancel.Canceled() {
+				time.Sleep(time.Millisecond)
+			}
+			return call.cancel.Err()
+		})
+		if call.cancel != nil {
+			t.Fatalf("expected guard to be released")
+		}
+		if mode == BestEffort {
+			if err != nil {
+				t.Fatalf("expected timeout to be recorded as an alert, got %v", err)
+			}
+			alerts := doc.AlertsByCode("CHART_PROCESSING_TIMEOUT")
+			if len(alerts) != 1 || alerts[0].Context["operation"] != "write" {
+				t.Fatalf("expected write timeout alert, got %#v", doc.Alerts())
+			}
+		} else if !errors.Is(err, ErrChartProcessingTimeout) || errors.Is(err, xmlcancel.ErrCanceled) {
+			t.Fatalf("expected timeout error, got %v", err)
+		}
+
+		data, err := doc.overlay.Get(dep.ChartPath)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if string(data) == "<partial/>" {
+			t.Fatalf("expected abandoned stage to be discarded")
+		}
+	}
+}
+
+func TestPerChartTimeoutDisabledByDefault(t *testing.T) {
+	if DefaultOptions().Limits.PerChartTimeout != 0 {
+		t.Fatalf("expected per-chart timeout to be disabled by default")
+	}
+}
+
+func timeoutOptions(mode ErrorMode) Options {
+	opts := DefaultOptions()
+	opts.Mode = mode
+	opts.Limits.PerChartTimeout = slowChartTimeout
+	return opts
+}
+
+func assertTimeoutAlert(t *testing.T, doc *Document, operations ...string) {
+	t.Helper()
+
+	alerts := doc.AlertsByCode("CHART_PROCESSING_TIMEOUT")
+	if len(alerts) != len(operations) {
+		t.Fatalf("expected %d timeout alerts, got %#v", len(operations), doc.Alerts())
+	}
+	for i, alert := range alerts {
+		if alert.Context["chart"] != "ppt/charts/chart1.xml" {
+			t.Fatalf("expected timeout for chart1, got %#v", alert)
+		}
+		if alert.Context["operation"] != operations[i] {
+			t.Fatalf("expected operation %q, got %#v", operations[i], alert)
+		}
+		if alert.Context["timeout"] != slowChartTimeout.String() || alert.Context["elapsed"] == "" {
+			t.Fatalf("expected timeout and elapsed context, got %#v", alert)
+		}
+	}
+}
+
+// writeSlowChartDeck builds a deck whose first chart carries 2M cached points
+// and whose second chart is small, each with its own embedded workbook.
+func writeSlowChartDeck(t *testing.T, dir string) string {
+	t.Helper()
+
+	count := slowChartPoints / 2
+	catValues := make([]string, count)
+	valValues := make([]string, count)
+	for i := range catValues {
+		catValues[i] = intToString(i)
+		valValues[i] = intToString(i)
+	}
+	last := intToString(count + 1)
+
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte("<slide/>"),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart2.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": chartWithCaches("Sheet1!$A$2:$A$"+last, "Sheet1!$B$2:$B$"+last, catValues, valValues),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/charts/chart2.xml": chartWithCaches("Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3", []string{"Old1", "Old2"}, []string{"1", "2"}),
+		"ppt/charts/_rels/chart2.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook2.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20),
+		"ppt/embeddings/embeddedWorkbook2.xlsx": buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20),
+	}
+
+	path := filepath.Join(dir, "slow.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
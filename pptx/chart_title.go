@@ -0,0 +1,88 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+
+	"why-pptx/internal/chartxml"
+	"why-pptx/internal/overlaystage"
+)
+
+// ErrTitleNotEditable is wrapped by the SetChartTitle error for a title
+// whose text does not live in the chart part.
+var ErrTitleNotEditable = chartxml.ErrTitleNotEditable
+
+// SetChartTitle replaces the text of a chart's title, keeping the run and
+// paragraph properties of its first run so the font, size, and color stay
+// as they were. A chart without a title gets a minimal one. The rewrite is
+// staged and validated like any chart write.
+//
+// A title linked to a cell or holding a field is reported with
+// CHART_TITLE_NOT_EDITABLE and left alone; Strict also returns an error
+// wrapping ErrTitleNotEditable.
+func (d *Document) SetChartTitle(chartPath, title string) error {
+	if d == nil || d.pkg == nil {
+		return fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	chartPath = normalizeChartPath(chartPath)
+	if chartPath == "" {
+		return fmt.Errorf("chart path is required")
+	}
+	if title == "" {
+		return fmt.Errorf("title is required")
+	}
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return err
+	}
+	var chart *EmbeddedChart
+	for _, item := range embedded {
+		if item.ChartPath == chartPath {
+			converted := embeddedChartFrom(item)
+			chart = &converted
+			break
+		}
+	}
+	if chart == nil {
+		return d.chartPathError(chartPath, embedded, skipped)
+	}
+
+	dep, ok, err := call.chartDependencies(*chart)
+	if err != nil || !ok {
+		return err
+	}
+
+	err = call.withChartStage(call.validateContext(dep), func(stage overlaystage.Overlay) error {
+		data, err := stage.Get(dep.ChartPath)
+		if err != nil {
+			return fmt.Errorf("read chart %q: %w", dep.ChartPath, err)
+		}
+		updated, err := chartxml.SetTitle(data, title)
+		if err != nil {
+			return fmt.Errorf("set title in %q: %w", dep.ChartPath, err)
+		}
+		if err := stage.Set(dep.ChartPath, updated); err != nil {
+			return fmt.Errorf("write chart %q: %w", dep.ChartPath, err)
+		}
+		d.manifest.stage(chartChange("setChartTitle", dep, nil, 0))
+		return nil
+	})
+	if err != nil && errors.Is(err, ErrTitleNotEditable) {
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "CHART_TITLE_NOT_EDITABLE",
+			Message: "Chart title is linked to a cell or holds a field; title is left unchanged",
+			Context: map[string]string{
+				"slide": dep.SlidePath,
+				"chart": dep.ChartPath,
+				"error": err.Error(),
+			},
+		})
+		if call.mode() == BestEffort {
+			return nil
+		}
+	}
+	return err
+}
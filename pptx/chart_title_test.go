@@ -0,0 +1,98 @@
+package pptx
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const richTitleChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">
+  <c:chart>
+    <c:title><c:tx><c:rich><a:bodyPr/><a:p><a:r><a:rPr lang="en-US" sz="1800" b="1"/><a:t>Old title</a:t></a:r></a:p></c:rich></c:tx><c:overlay val="0"/></c:title>
+    <c:plotArea>
+      <c:barChart><c:ser><c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Cat1</c:v></c:pt><c:pt idx="1"><c:v>Cat2</c:v></c:pt></c:strCache></c:strRef></c:cat><c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:ptCount val="2"/><c:pt idx="0"><c:v>10</c:v></c:pt><c:pt idx="1"><c:v>20</c:v></c:pt></c:numCache></c:numRef></c:val></c:ser></c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+func TestSetChartTitle(t *testing.T) {
+	exercisesFeature(t, "chart.title")
+
+	const chartPath = "ppt/charts/chart1.xml"
+	cases := map[string]struct {
+		input string
+		want  string
+	}{
+		"rich title":   {input: writeRepairDeck(t, t.TempDir(), richTitleChart, nil), want: `<a:rPr lang="en-US" sz="1800" b="1"/><a:t>Quarterly revenue</a:t>`},
+		"no title yet": {input: fixturePath("bar_simple_embedded.pptx"), want: `<c:title><c:tx><c:rich xmlns:a=`},
+	}
+	for name, tc := range cases {
+		doc, err := OpenFile(tc.input)
+		if err != nil {
+			t.Fatalf("%s: OpenFile: %v", name, err)
+		}
+		if err := doc.SetChartTitle(chartPath, "Quarterly revenue"); err != nil {
+			t.Fatalf("%s: SetChartTitle: %v", name, err)
+		}
+		output := filepath.Join(t.TempDir(), "output.pptx")
+		if err := doc.SaveFile(output); err != nil {
+			t.Fatalf("%s: SaveFile: %v", name, err)
+		}
+		if chart := string(readZipEntry(t, output, chartPath)); !strings.Contains(chart, tc.want) {
+			t.Fatalf("%s: unexpected chart:\n%s", name, chart)
+		}
+
+		saved, err := OpenFile(output)
+		if err != nil {
+			t.Fatalf("%s: OpenFile saved: %v", name, err)
+		}
+		charts, err := saved.ListCharts()
+		if err != nil {
+			t.Fatalf("%s: ListCharts: %v", name, err)
+		}
+		if len(charts) != 1 || charts[0].Title != "Quarterly revenue" {
+			t.Fatalf("%s: unexpected charts: %#v", name, charts)
+		}
+	}
+}
+
+func TestSetChartTitleLinkedToCell(t *testing.T) {
+	const chartPath = "ppt/charts/chart1.xml"
+	linked := strings.Replace(richTitleChart,
+		`<c:tx><c:rich><a:bodyPr/><a:p><a:r><a:rPr lang="en-US" sz="1800" b="1"/><a:t>Old title</a:t></a:r></a:p></c:rich></c:tx>`,
+		`<c:tx><c:strRef><c:f>Sheet1!$B$1</c:f><c:strCache><c:ptCount val="1"/><c:pt idx="0"><c:v>Old title</c:v></c:pt></c:strCache></c:strRef></c:tx>`, 1)
+	input := writeRepairDeck(t, t.TempDir(), linked, nil)
+
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SetChartTitle(chartPath, "New"); !errors.Is(err, ErrTitleNotEditable) {
+		t.Fatalf("expected ErrTitleNotEditable, got %v", err)
+	}
+	if alerts := doc.AlertsByCode("CHART_TITLE_NOT_EDITABLE"); len(alerts) != 1 || alerts[0].Context["chart"] != chartPath {
+		t.Fatalf("expected CHART_TITLE_NOT_EDITABLE, got %#v", doc.Alerts())
+	}
+
+	opts := DefaultOptions()
+	opts.Mode = BestEffort
+	doc, err = OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SetChartTitle(chartPath, "New"); err != nil {
+		t.Fatalf("SetChartTitle: %v", err)
+	}
+	if len(doc.AlertsByCode("CHART_TITLE_NOT_EDITABLE")) != 1 {
+		t.Fatalf("expected CHART_TITLE_NOT_EDITABLE, got %#v", doc.Alerts())
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if chart := string(readZipEntry(t, output, chartPath)); chart != linked {
+		t.Fatalf("the linked title must be left alone:\n%s", chart)
+	}
+}
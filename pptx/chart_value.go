@@ -0,0 +1,77 @@
+package pptx
+
+import (
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/chartdiscover"
+)
+
+// ChartValue is one point of an extracted series. Exactly one of Number,
+// String, and Empty is set: String holds a cell that does not parse as a
+// number, verbatim.
+type ChartValue struct {
+	Number *float64 `json:"number,omitempty"`
+	String *string  `json:"string,omitempty"`
+	Empty  bool     `json:"empty,omitempty"`
+}
+
+// chartValues types the cells of a values range. A blank cell is Empty, or
+// the number 0 under MissingNumericZero.
+func chartValues(data []string, policy MissingNumericPolicy) []ChartValue {
+	out := make([]ChartValue, len(data))
+	for i, raw := range data {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" {
+			if policy == MissingNumericZero {
+				zero := 0.0
+				out[i] = ChartValue{Number: &zero}
+			} else {
+				out[i] = ChartValue{Empty: true}
+			}
+			continue
+		}
+		if number, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			out[i] = ChartValue{Number: &number}
+			continue
+		}
+		text := raw
+		out[i] = ChartValue{String: &text}
+	}
+	return out
+}
+
+// typedValues returns s.Values, or the values typed from s.Data for series
+// built by hand without them.
+func (s ExtractedSeries) typedValues() []ChartValue {
+	if len(s.Values) == len(s.Data) {
+		return s.Values
+	}
+	return chartValues(s.Data, MissingNumericEmpty)
+}
+
+// reportNonNumericValues records EXTRACT_VALUE_NOT_NUMERIC in BestEffort for
+// every point of series that surfaced as text.
+func (d *docCall) reportNonNumericValues(chart chartdiscover.EmbeddedChart, series ExtractedSeries) {
+	if d.mode() != BestEffort {
+		return
+	}
+	for i, value := range series.Values {
+		if value.String == nil {
+			continue
+		}
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "EXTRACT_VALUE_NOT_NUMERIC",
+			Message: "Series value is not numeric; it is extracted as a string",
+			Context: map[string]string{
+				"chart":    chart.ChartPath,
+				"slide":    chart.SlidePath,
+				"workbook": chart.WorkbookPath,
+				"series":   strconv.Itoa(series.Index),
+				"point":    strconv.Itoa(i),
+				"value":    *value.String,
+			},
+		})
+	}
+}
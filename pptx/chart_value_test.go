@@ -0,0 +1,254 @@
+package pptx
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTypedValuesDeck writes a bar chart whose values range holds a number,
+// a blank cell, and text.
+func writeTypedValuesDeck(t *testing.T) string {
+	t.Helper()
+	return writeSheetChartDeck(t, `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="2"><c r="A2" t="inlineStr"><is><t>North</t></is></c><c r="B2"><v>12.5</v></c></row>
+    <row r="3"><c r="A3" t="inlineStr"><is><t>South</t></is></c></row>
+    <row r="4"><c r="A4" t="inlineStr"><is><t>West</t></is></c><c r="B4" t="inlineStr"><is><t>n/a</t></is></c></row>
+  </sheetData>
+</worksheet>`, "Sheet1!$A$2:$A$4", "Sheet1!$B$2:$B$4")
+}
+
+// writeSheetChartDeck writes a bar chart with one series, its categories
+// and values read from the given ranges of a workbook whose Sheet1 is sheet.
+func writeSheetChartDeck(t *testing.T, sheet, categories, values string) string {
+	t.Helper()
+
+	workbook := baseXLSXParts(t)
+	workbook["xl/worksheets/sheet1.xml"] = []byte(sheet)
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"></p:sld>`),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>` + categories + `</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>` + values + `</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": writeZipBytes(t, workbook),
+	}
+
+	path := filepath.Join(t.TempDir(), "chart.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestExtractTypedValues(t *testing.T) {
+	input := writeTypedValuesDeck(t)
+	opts := DefaultOptions()
+	opts.Mode = BestEffort
+	doc, err := OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	values := data.Series[0].Values
+	if len(values) != 3 {
+		t.Fatalf("expected three values, got %#v", values)
+	}
+	if values[0].Number == nil || *values[0].Number != 12.5 || values[0].String != nil || values[0].Empty {
+		t.Fatalf("point 0: %#v", values[0])
+	}
+	if !values[1].Empty || values[1].Number != nil {
+		t.Fatalf("point 1: %#v", values[1])
+	}
+	if values[2].String == nil || *values[2].String != "n/a" {
+		t.Fatalf("point 2: %#v", values[2])
+	}
+	if got := data.Series[0].Data; len(got) != 3 || got[0] != "12.5" || got[1] != "" || got[2] != "n/a" {
+		t.Fatalf("Data must keep the raw cells, got %#v", got)
+	}
+
+	alerts := doc.AlertsByCode("EXTRACT_VALUE_NOT_NUMERIC")
+	if len(alerts) != 1 || alerts[0].Level != "warn" {
+		t.Fatalf("expected one EXTRACT_VALUE_NOT_NUMERIC warning, got %#v", doc.Alerts())
+	}
+	if ctx := alerts[0].Context; ctx["point"] != "2" || ctx["value"] != "n/a" || ctx["series"] != "0" {
+		t.Fatalf("unexpected context: %#v", ctx)
+	}
+
+	strict, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := strict.ExtractChartDataByPath("ppt/charts/chart1.xml"); err != nil {
+		t.Fatalf("ExtractChartDataByPath strict: %v", err)
+	}
+	if len(strict.Alerts()) != 0 {
+		t.Fatalf("strict extraction must not alert: %#v", strict.Alerts())
+	}
+}
+
+func TestExtractTypedValuesMissingNumericZero(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Workbook.MissingNumericPolicy = MissingNumericZero
+	doc, err := OpenFile(writeTypedValuesDeck(t), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if blank := data.Series[0].Values[1]; blank.Empty || blank.Number == nil || *blank.Number != 0 {
+		t.Fatalf("expected a blank cell to be 0, got %#v", blank)
+	}
+}
+
+func TestChartJSExportEmitsNumbers(t *testing.T) {
+	in := ExtractedChartData{
+		Type:   "bar",
+		Labels: []string{"North", "South"},
+		Series: []ExtractedSeries{{Name: "Series 1", Data: []string{"3", ""}}},
+	}
+	payload, err := ChartJSExporter{}.Export(in)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	values := payload.Data["datasets"].([]map[string]any)[0]["data"].([]any)
+	if n, ok := values[0].(float64); !ok || n != 3 || values[1] != nil {
+		t.Fatalf("expected JSON numbers and null, got %#v", values)
+	}
+}
+
+// TestExtractUnsupportedCellType reads a formula cell from a sheet named
+// "not found", which must not pass for a missing sheet.
+func TestExtractBoolAndErrorCells(t *testing.T) {
+	input := writeSheetChartDeck(t, `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="2"><c r="A2" t="b"><v>1</v></c><c r="B2"><v>3</v></c></row>
+    <row r="3"><c r="A3" t="b"><v>0</v></c><c r="B3" t="e"><f>B2/0</f><v>#DIV/0!</v></c></row>
+  </sheetData>
+</worksheet>`, "Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3")
+
+	opts := DefaultOptions()
+	opts.Workbook.MissingNumericPolicy = MissingNumericZero
+	doc, err := OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if len(data.Labels) != 2 || data.Labels[0] != "1" || data.Labels[1] != "0" {
+		t.Fatalf("unexpected labels: %v", data.Labels)
+	}
+	if got := data.Series[0].Data; len(got) != 2 || got[0] != "3" || got[1] != "" {
+		t.Fatalf("expected the error cell to read as missing, got %v", got)
+	}
+	if missing := data.Series[0].Values[1]; missing.Number == nil || *missing.Number != 0 {
+		t.Fatalf("expected the error cell to follow MissingNumericZero, got %#v", missing)
+	}
+	alerts := doc.AlertsByCode("EXTRACT_CELL_ERROR_VALUE")
+	if len(alerts) != 1 || alerts[0].Context["cell"] != "B3" || alerts[0].Context["value"] != "#DIV/0!" {
+		t.Fatalf("expected one EXTRACT_CELL_ERROR_VALUE alert, got %#v", doc.Alerts())
+	}
+
+	opts.Workbook.BoolWords = true
+	doc, err = OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err = doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if len(data.Labels) != 2 || data.Labels[0] != "TRUE" || data.Labels[1] != "FALSE" {
+		t.Fatalf("unexpected labels with BoolWords: %v", data.Labels)
+	}
+}
+
+func TestExtractUnsupportedCellType(t *testing.T) {
+	workbook := baseXLSXParts(t)
+	workbook["xl/workbook.xml"] = []byte(strings.Replace(string(workbook["xl/workbook.xml"]), `name="Sheet1"`, `name="not found"`, 1))
+	workbook["xl/worksheets/sheet1.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="2"><c r="A2" t="inlineStr"><is><t>North</t></is></c><c r="B2" t="str"><f>C2</f><v>x</v></c></row>
+  </sheetData>
+</worksheet>`)
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"></p:sld>`),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>'not found'!$A$2</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>'not found'!$B$2</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": writeZipBytes(t, workbook),
+	}
+	input := filepath.Join(t.TempDir(), "formula.pptx")
+	if err := writeZipFile(input, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(input, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml"); err == nil {
+		t.Fatalf("expected the formula cell to fail extraction")
+	}
+	alerts := doc.AlertsByCode("EXTRACT_UNSUPPORTED_CELL_TYPE")
+	if len(alerts) != 1 || alerts[0].Context["cell"] != "B2" || alerts[0].Context["cellType"] != "str" || alerts[0].Context["sheet"] != "not found" {
+		t.Fatalf("expected one EXTRACT_UNSUPPORTED_CELL_TYPE alert, got %#v", doc.Alerts())
+	}
+	if len(doc.AlertsByCode("EXTRACT_SHEET_NOT_FOUND")) != 0 {
+		t.Fatalf("unexpected EXTRACT_SHEET_NOT_FOUND alert")
+	}
+
+	strict, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile strict: %v", err)
+	}
+	if _, err := strict.GetWorkbookCells("ppt/embeddings/embeddedWorkbook1.xlsx", "not found", []string{"B2"}); err == nil {
+		t.Fatalf("expected GetWorkbookCells to fail on the formula cell")
+	}
+}
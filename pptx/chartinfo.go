@@ -2,10 +2,14 @@ package pptx
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"maps"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 
 	"why-pptx/internal/chartxml"
@@ -15,20 +19,88 @@ import (
 type ChartInfo struct {
 	Index        int
 	SlidePath    string
+	Source       ChartSource
+	SlideIndex   int
 	ChartPath    string
 	WorkbookPath string
 	ChartType    string
 	Title        string
 	AltText      string
-	SeriesCount  int
+	// ShapeName is the name of the graphic frame showing the chart, such as
+	// "Chart 3". Title falls back to it for charts without a title.
+	ShapeName   string
+	SeriesCount int
+	// HasUserShapes reports that the chart has a userShapes drawing:
+	// shapes such as callouts positioned over the plot area.
+	HasUserShapes bool
+	// HiddenLegendEntries maps the index of each deleted legend entry to
+	// true: a series index, or a point index on pie charts. Nil when every
+	// entry is shown.
+	HiddenLegendEntries map[int]bool
+	// Fingerprint and CellCount are those of ChartDependencies; both are
+	// empty when the chart's ranges cannot be resolved.
+	Fingerprint string
+	CellCount   int
+	// AxisGroups lists the chart's axis pairs, primary first; pie and
+	// doughnut charts have none. HasSecondaryAxis reports a secondary pair.
+	AxisGroups       []AxisInfo
+	HasSecondaryAxis bool
+	// DataLabels is the plot-level c:dLbls, the default for every series;
+	// SeriesDataLabels holds those set on a series, by c:idx. Both are nil
+	// when the chart XML has none.
+	DataLabels       *DataLabels
+	SeriesDataLabels map[int]DataLabels
+}
+
+// AxisInfo is one category/value axis pair of a chart. For a scatter chart
+// the category axis is the x value axis. Fields are empty where the chart
+// XML leaves the element out.
+type AxisInfo struct {
+	// Group is "primary", or "secondary" for the axes of a secondary plot.
+	Group        string `json:"group"`
+	CatAxisTitle string `json:"catAxisTitle,omitempty"`
+	ValAxisTitle string `json:"valAxisTitle,omitempty"`
+	// ValAxisNumFmt is the value axis numFmt formatCode.
+	ValAxisNumFmt string `json:"valAxisNumFmt,omitempty"`
+	// Position is the value axis c:axPos: "l" or "r", or "b" or "t" on a
+	// horizontal bar chart.
+	Position string `json:"position,omitempty"`
+}
+
+// chartAxisInfo describes the axis groups of parsed, primary first.
+func chartAxisInfo(parsed *chartxml.Info) ([]AxisInfo, bool) {
+	if len(parsed.AxisGroups) == 0 {
+		return nil, false
+	}
+	roles := chartxml.AxisGroupRoles(parsed.Plots, parsed.AxisGroups)
+	out := make([]AxisInfo, len(parsed.AxisGroups))
+	secondary := false
+	for i, group := range parsed.AxisGroups {
+		out[i] = AxisInfo{
+			Group:         roles[i],
+			CatAxisTitle:  group.CatTitle,
+			ValAxisTitle:  group.ValTitle,
+			ValAxisNumFmt: group.ValFormatCode,
+			Position:      group.ValAxisPos,
+		}
+		secondary = secondary || roles[i] == "secondary"
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Group == "primary" && out[j].Group != "primary"
+	})
+	return out, secondary
 }
 
 func (d *Document) ListCharts() ([]ChartInfo, error) {
 	if d == nil || d.pkg == nil {
 		return nil, fmt.Errorf("document not initialized")
 	}
+	return d.newCall(nil).listCharts()
+}
 
-	charts, err := d.DiscoverEmbeddedCharts()
+// listCharts is ListCharts for callers already inside a call.
+func (d *docCall) listCharts() ([]ChartInfo, error) {
+	charts, err := d.discoverCharts()
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +113,8 @@ func (d *Document) ListCharts() ([]ChartInfo, error) {
 		info := ChartInfo{
 			Index:        i,
 			SlidePath:    chart.SlidePath,
+			Source:       chart.Source,
+			SlideIndex:   chart.SlideIndex,
 			ChartPath:    chart.ChartPath,
 			WorkbookPath: chart.WorkbookPath,
 			ChartType:    "unknown",
@@ -48,6 +122,8 @@ func (d *Document) ListCharts() ([]ChartInfo, error) {
 
 		titleFromSlide, altText := d.slideChartAltText(chart.SlidePath, chart.ChartPath)
 		info.AltText = altText
+		info.ShapeName = titleFromSlide
+		info.HasUserShapes = d.chartUserShapesPart(chart.ChartPath) != ""
 
 		data, err := d.pkg.ReadPart(chart.ChartPath)
 		if err != nil {
@@ -61,7 +137,7 @@ func (d *Document) ListCharts() ([]ChartInfo, error) {
 			continue
 		}
 
-		parsed, err := chartxml.ParseInfo(bytes.NewReader(data))
+		parsed, err := d.chartInfo(chart.ChartPath, data)
 		if err != nil {
 			if err := d.handleChartInfoError(chart, err); err != nil {
 				return nil, err
@@ -76,6 +152,13 @@ func (d *Document) ListCharts() ([]ChartInfo, error) {
 		info.ChartType = parsed.ChartType
 		info.SeriesCount = parsed.SeriesCount
 		info.Title = parsed.Title
+		info.HiddenLegendEntries = maps.Clone(parsed.HiddenLegendEntries)
+		info.AxisGroups, info.HasSecondaryAxis = chartAxisInfo(parsed)
+		info.DataLabels, info.SeriesDataLabels = chartDataLabels(parsed)
+		if deps, err := d.extractChartDependencies(chart); err == nil {
+			info.Fingerprint = deps.Fingerprint
+			info.CellCount = deps.CellCount
+		}
 		if info.Title == "" && titleFromSlide != "" {
 			info.Title = titleFromSlide
 		}
@@ -86,46 +169,131 @@ func (d *Document) ListCharts() ([]ChartInfo, error) {
 	return out, nil
 }
 
-func (d *Document) ApplyChartDataByName(name string, data map[string][]string) error {
+// NameOption adjusts how ApplyChartDataByName selects a chart.
+type NameOption func(*nameScope)
+
+type nameScope struct {
+	slide string
+}
+
+// OnSlide limits ApplyChartDataByName to the charts shown on slidePath, so
+// charts of the same name on different slides can be told apart. A path
+// that names no slide fails with ErrSlideNotFound.
+func OnSlide(slidePath string) NameOption {
+	return func(scope *nameScope) {
+		scope.slide = slidePath
+	}
+}
+
+// ApplyChartDataByName applies data to the chart whose title or alt text is
+// name; see matchChartsByName for how names compare. Several matches fail
+// with an error, alerted as CHART_NAME_AMBIGUOUS in BestEffort, unless an
+// OnSlide scope leaves one.
+func (d *Document) ApplyChartDataByName(name string, data map[string][]string, opts ...NameOption) error {
+	call := d.newCall(nil)
 	if name == "" {
 		return fmt.Errorf("chart name is required")
 	}
+	var scope nameScope
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&scope)
+		}
+	}
 
-	charts, err := d.ListCharts()
+	charts, err := call.listCharts()
 	if err != nil {
 		return err
 	}
+	if scope.slide != "" {
+		slide, err := d.resolveSlide(scope.slide)
+		if err != nil {
+			return err
+		}
+		onSlide := make([]ChartInfo, 0, len(charts))
+		for _, chart := range charts {
+			if chart.SlidePath == slide {
+				onSlide = append(onSlide, chart)
+			}
+		}
+		charts = onSlide
+	}
 
 	matches := matchChartsByName(charts, name)
 	if len(matches) == 0 {
-		return fmt.Errorf("chart not found")
+		return ErrChartNotFound
 	}
 	if len(matches) > 1 {
-		return d.handleChartNameAmbiguous(name, len(matches))
+		return call.handleChartNameAmbiguous(name, len(matches))
+	}
+
+	return call.applyChartData(matches[0].Index, data)
+}
+
+// ApplyChartDataByIndex applies data to the chart at index in ListCharts,
+// the index ExtractChartData takes too, and then behaves exactly as
+// ApplyChartDataByPath, cache sync and postflight included. An index past
+// the discovered charts fails with ErrChartIndexOutOfRange in Strict; in
+// BestEffort it is reported as CHART_INDEX_OUT_OF_RANGE and nothing is
+// written.
+func (d *Document) ApplyChartDataByIndex(index int, data ChartDataInput) error {
+	if d == nil || d.pkg == nil {
+		return fmt.Errorf("document not initialized")
 	}
+	call := d.newCall(nil)
+	charts, err := d.DiscoverEmbeddedCharts()
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(charts) {
+		return call.handleChartIndexOutOfRange(index, len(charts))
+	}
+	return call.applyChartData(index, data)
+}
 
-	return d.ApplyChartData(matches[0].Index, data)
+func (d *Document) ApplyChartDataByPath(chartPath string, data map[string][]string, opts ...CallOption) error {
+	return d.ApplyChartDataByPathContext(context.Background(), chartPath, data, opts...)
 }
 
-func (d *Document) ApplyChartDataByPath(chartPath string, data map[string][]string) error {
+// ApplyChartDataByPathContext is ApplyChartDataByPath checking ctx before
+// the chart is looked up and again before it is written, returning a
+// *CanceledError once ctx is done. A write that has started runs to the end,
+// so the chart is either fully updated or untouched.
+func (d *Document) ApplyChartDataByPathContext(ctx context.Context, chartPath string, data map[string][]string, opts ...CallOption) error {
+	call := d.newCall(opts)
+	chartPath = normalizeChartPath(chartPath)
 	if chartPath == "" {
 		return fmt.Errorf("chart path is required")
 	}
+	if err := checkCanceled(ctx, "applyChartData", chartPath); err != nil {
+		return err
+	}
 
-	charts, err := d.ListCharts()
+	charts, err := call.listCharts()
 	if err != nil {
 		return err
 	}
 
 	for _, chart := range charts {
 		if chart.ChartPath == chartPath {
-			return d.ApplyChartData(chart.Index, data)
+			if err := checkCanceled(ctx, "applyChartData", chartPath); err != nil {
+				return err
+			}
+			return call.applyChartData(chart.Index, data)
 		}
 	}
 
-	return fmt.Errorf("chart not found")
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return err
+	}
+	return d.chartPathError(chartPath, embedded, skipped)
 }
 
+// matchChartsByName returns the charts named name, trying in turn: the exact
+// title, the exact alt text, the title or alt text ignoring case and
+// surrounding or repeated whitespace, and last the shape name compared the
+// same way. The first step with a match decides.
 func matchChartsByName(charts []ChartInfo, name string) []ChartInfo {
 	matches := make([]ChartInfo, 0)
 	for _, chart := range charts {
@@ -144,20 +312,33 @@ func matchChartsByName(charts []ChartInfo, name string) []ChartInfo {
 	if len(matches) > 0 {
 		return matches
 	}
+	folded := foldChartName(name)
+	if folded == "" {
+		return matches
+	}
 	for _, chart := range charts {
-		if chart.Title != "" && strings.EqualFold(chart.Title, name) {
+		if foldChartName(chart.Title) == folded || foldChartName(chart.AltText) == folded {
 			matches = append(matches, chart)
-			continue
 		}
-		if chart.AltText != "" && strings.EqualFold(chart.AltText, name) {
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+	for _, chart := range charts {
+		if foldChartName(chart.ShapeName) == folded {
 			matches = append(matches, chart)
 		}
 	}
 	return matches
 }
 
-func (d *Document) handleChartInfoError(chart EmbeddedChart, err error) error {
-	if d.opts.Mode != BestEffort {
+// foldChartName lowercases name and trims and collapses its whitespace.
+func foldChartName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func (d *docCall) handleChartInfoError(chart EmbeddedChart, err error) error {
+	if d.mode() != BestEffort {
 		return err
 	}
 
@@ -175,9 +356,9 @@ func (d *Document) handleChartInfoError(chart EmbeddedChart, err error) error {
 	return nil
 }
 
-func (d *Document) handleChartNameAmbiguous(name string, matches int) error {
+func (d *docCall) handleChartNameAmbiguous(name string, matches int) error {
 	err := fmt.Errorf("ambiguous chart name")
-	if d.opts.Mode != BestEffort {
+	if d.mode() != BestEffort {
 		return err
 	}
 
@@ -194,6 +375,24 @@ func (d *Document) handleChartNameAmbiguous(name string, matches int) error {
 	return err
 }
 
+func (d *docCall) handleChartIndexOutOfRange(index, charts int) error {
+	if d.mode() != BestEffort {
+		return fmt.Errorf("%w: %d of %d charts", ErrChartIndexOutOfRange, index, charts)
+	}
+
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "CHART_INDEX_OUT_OF_RANGE",
+		Message: "Chart index is out of range; no chart selected",
+		Context: map[string]string{
+			"chartIndex": strconv.Itoa(index),
+			"charts":     strconv.Itoa(charts),
+		},
+	})
+
+	return nil
+}
+
 func (d *Document) slideChartAltText(slidePath, chartPath string) (string, string) {
 	relID, err := d.findChartRelID(slidePath, chartPath)
 	if err != nil || relID == "" {
@@ -212,6 +411,25 @@ func (d *Document) slideChartAltText(slidePath, chartPath string) (string, strin
 	return title, descr
 }
 
+// chartUserShapesPart returns the userShapes drawing of the chart, or ""
+// when it has none or its rels cannot be read.
+func (d *Document) chartUserShapesPart(chartPath string) string {
+	data, err := d.pkg.ReadPart(slideRelsPath(chartPath))
+	if err != nil {
+		return ""
+	}
+	parsed, err := rels.Parse(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	for _, rel := range parsed.ByID {
+		if rel.Type == userShapesRelType && rel.TargetMode != "External" {
+			return rels.ResolveTarget(chartPath, rel.Target)
+		}
+	}
+	return ""
+}
+
 func (d *Document) findChartRelID(slidePath, chartPath string) (string, error) {
 	relsPath := slideRelsPath(slidePath)
 	data, err := d.pkg.ReadPart(relsPath)
@@ -244,6 +462,10 @@ func slideRelsPath(slidePath string) string {
 	return path.Join(path.Dir(slidePath), "_rels", path.Base(slidePath)+".rels")
 }
 
+// parseSlideChartProps returns the cNvPr name and descr of the graphic frame
+// whose c:chart names relID. Frames are matched at any depth, so a chart
+// inside nested p:grpSp groups resolves like a top-level one; the groups' own
+// cNvPr are outside the frame and ignored.
 func parseSlideChartProps(data []byte, relID string) (string, string, error) {
 	decoder := xml.NewDecoder(bytes.NewReader(data))
 	inGraphicFrame := false
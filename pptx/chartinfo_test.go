@@ -1,8 +1,12 @@
 package pptx
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -293,3 +297,262 @@ func chartWithTitleAndRanges(title, catFormula, valFormula string) []byte {
   </c:chart>
 </c:chartSpace>`)
 }
+
+func TestListChartsGroupedGraphicFrame(t *testing.T) {
+	// The chart frame sits two groups deep, next to a captioned shape; the
+	// names and descriptions of the groups and the shape must not leak in.
+	doc, err := OpenFile(fixturePath("chart_grouped_shapes.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil {
+		t.Fatalf("ListCharts: %v", err)
+	}
+	if len(charts) != 1 || charts[0].Title != "Revenue Chart" || charts[0].AltText != "Quarterly revenue by region" {
+		t.Fatalf("unexpected charts: %#v", charts)
+	}
+
+	plan, err := doc.Plan()
+	if err != nil || len(plan.Charts) != 1 || plan.Charts[0].Title != "Revenue Chart" || plan.Charts[0].AltText != "Quarterly revenue by region" {
+		t.Fatalf("unexpected plan: %#v %v", plan.Charts, err)
+	}
+
+	if err := doc.ApplyChartDataByName("revenue chart", map[string][]string{
+		"categories": {"New1", "New2"},
+		"values:0":   {"11", "22"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByName: %v", err)
+	}
+}
+
+func TestApplyChartDataByIndex(t *testing.T) {
+	exercisesFeature(t, "apply.by-index")
+
+	data := ChartDataInput{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+	}
+
+	doc, err := OpenFile(fixturePath("shared_workbook_two_charts.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) != 2 {
+		t.Fatalf("ListCharts: %v %v", charts, err)
+	}
+	if err := doc.ApplyChartDataByIndex(1, data); err != nil {
+		t.Fatalf("ApplyChartDataByIndex: %v", err)
+	}
+	extracted, err := doc.ExtractChartData(1)
+	if err != nil {
+		t.Fatalf("ExtractChartData: %v", err)
+	}
+	if extracted.Meta.ChartPath != charts[1].ChartPath || strings.Join(extracted.Labels, ",") != "NewA,NewB" {
+		t.Fatalf("expected index 1 to write %s, got %s %v", charts[1].ChartPath, extracted.Meta.ChartPath, extracted.Labels)
+	}
+	if first, err := doc.ExtractChartData(0); err != nil || strings.Join(first.Labels, ",") == "NewA,NewB" {
+		t.Fatalf("expected chart 0 untouched, got %v %v", first.Labels, err)
+	}
+
+	for _, index := range []int{-1, 2} {
+		if err := doc.ApplyChartDataByIndex(index, data); !errors.Is(err, ErrChartIndexOutOfRange) {
+			t.Fatalf("index %d: expected ErrChartIndexOutOfRange, got %v", index, err)
+		}
+	}
+
+	doc, err = OpenFile(fixturePath("shared_workbook_two_charts.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByIndex(5, data); err != nil {
+		t.Fatalf("expected BestEffort to alert, got %v", err)
+	}
+	alerts := doc.AlertsByCode("CHART_INDEX_OUT_OF_RANGE")
+	if len(alerts) != 1 || alerts[0].Context["chartIndex"] != "5" || alerts[0].Context["charts"] != "2" {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+}
+
+func TestApplyChartDataByIndexMatchesListChartsAfterFailingChart(t *testing.T) {
+	entries := corpusZipEntries(t, readCorpusFile(t, fixturePath("shared_workbook_two_charts.pptx")))
+	entries["ppt/charts/chart1.xml"] = bytes.Replace(entries["ppt/charts/chart1.xml"], []byte("Sheet1!$B$2:$B$3"), []byte("Sheet1B2"), 1)
+	inputPath := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(inputPath, entries); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(inputPath, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) != 2 || charts[1].ChartPath != "ppt/charts/chart2.xml" {
+		t.Fatalf("ListCharts: %#v %v", charts, err)
+	}
+	if err := doc.ApplyChartDataByIndex(1, ChartDataInput{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByIndex: %v", err)
+	}
+	extracted, err := doc.ExtractChartData(1)
+	if err != nil {
+		t.Fatalf("ExtractChartData: %v", err)
+	}
+	if extracted.Meta.ChartPath != "ppt/charts/chart2.xml" || strings.Join(extracted.Labels, ",") != "NewA,NewB" {
+		t.Fatalf("expected chart2 written at index 1, got %s %v", extracted.Meta.ChartPath, extracted.Labels)
+	}
+}
+
+func TestApplyChartDataSkippedChartBestEffort(t *testing.T) {
+	entries := corpusZipEntries(t, readCorpusFile(t, fixturePath("shared_workbook_two_charts.pptx")))
+	entries["ppt/charts/chart1.xml"] = entries["ppt/charts/chart1.xml"][:len(entries["ppt/charts/chart1.xml"])/2]
+	inputPath := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(inputPath, entries); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	data := ChartDataInput{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+	}
+
+	cases := []struct {
+		name  string
+		apply func(doc *Document) error
+	}{
+		{"ApplyChartData", func(doc *Document) error { return doc.ApplyChartData(0, data) }},
+		{"ApplyChartDataByIndex", func(doc *Document) error { return doc.ApplyChartDataByIndex(0, data) }},
+		{"ApplyChartDataByPath", func(doc *Document) error { return doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := OpenFile(inputPath, WithErrorMode(BestEffort))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			err = tc.apply(doc)
+			if !errors.Is(err, ErrChartSkipped) || !strings.Contains(err.Error(), "ppt/charts/chart1.xml") {
+				t.Fatalf("expected ErrChartSkipped naming the chart, got %v", err)
+			}
+			if touched := doc.TouchedParts(); len(touched) != 0 {
+				t.Fatalf("expected nothing written, got %v", touched)
+			}
+			if len(doc.AlertsByCode("CHART_DEPENDENCIES_PARSE_FAILED")) != 1 {
+				t.Fatalf("expected the skip to be alerted, got %#v", doc.Alerts())
+			}
+		})
+	}
+}
+
+func TestApplyChartDataByNameFoldsAndScopes(t *testing.T) {
+	exercisesFeature(t, "apply.name-match")
+
+	inputPath := filepath.Join(t.TempDir(), "input.pptx")
+	slide := func(shapeName string) []byte {
+		return []byte(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:cSld><p:spTree><p:graphicFrame><p:nvGraphicFramePr><p:cNvPr id="2" name="` + shapeName + `"/></p:nvGraphicFramePr><a:graphic><a:graphicData><c:chart xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" r:id="rId1"/></a:graphicData></a:graphic></p:graphicFrame></p:spTree></p:cSld></p:sld>`)
+	}
+	slideRels := func(chart string) []byte {
+		return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/` + chart + `"/>
+</Relationships>`)
+	}
+	chartRels := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`)
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml":                 slide("Sales Chart"),
+		"ppt/slides/slide2.xml":                 slide("Chart 2"),
+		"ppt/slides/_rels/slide1.xml.rels":      slideRels("chart1.xml"),
+		"ppt/slides/_rels/slide2.xml.rels":      slideRels("chart2.xml"),
+		"ppt/charts/chart1.xml":                 chartWithTitleAndRanges("Revenue ", "Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3"),
+		"ppt/charts/chart2.xml":                 chartWithTitleAndRanges("REVENUE", "Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3"),
+		"ppt/charts/_rels/chart1.xml.rels":      chartRels,
+		"ppt/charts/_rels/chart2.xml.rels":      chartRels,
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildWorkbookWithValues(t, "Old1", "Old2", 10, 20),
+	}
+	if err := writeZipFile(inputPath, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	data := map[string][]string{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+	}
+
+	doc, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByName("revenue", data); err == nil || errors.Is(err, ErrChartNotFound) {
+		t.Fatalf("expected both charts to match and be ambiguous, got %v", err)
+	}
+	if err := doc.ApplyChartDataByName("Revenue", data, OnSlide("ppt/slides/slide9.xml")); !errors.Is(err, ErrSlideNotFound) {
+		t.Fatalf("expected ErrSlideNotFound, got %v", err)
+	}
+
+	chart1, _ := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if err := doc.ApplyChartDataByName(" revenue", data, OnSlide("ppt/slides/slide2.xml")); err != nil {
+		t.Fatalf("ApplyChartDataByName on slide 2: %v", err)
+	}
+	if after, _ := doc.pkg.ReadPart("ppt/charts/chart1.xml"); !bytes.Equal(after, chart1) {
+		t.Fatalf("the slide scope must leave chart1 alone")
+	}
+	if chart2, _ := doc.pkg.ReadPart("ppt/charts/chart2.xml"); !bytes.Contains(chart2, []byte(">NewA<")) {
+		t.Fatalf("expected chart2 caches to be synced")
+	}
+
+	if err := doc.ApplyChartDataByName("  sales   CHART ", data); err != nil {
+		t.Fatalf("ApplyChartDataByName by shape name: %v", err)
+	}
+	if after, _ := doc.pkg.ReadPart("ppt/charts/chart1.xml"); !bytes.Contains(after, []byte(">NewA<")) {
+		t.Fatalf("expected the shape name to select chart1")
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || charts[0].ShapeName != "Sales Chart" {
+		t.Fatalf("expected ShapeName on ListCharts, got %#v %v", charts, err)
+	}
+}
+
+func TestListChartsAxisGroups(t *testing.T) {
+	exercisesFeature(t, "chart.axis-info")
+
+	doc, err := OpenFile(fixturePath("line_secondary_axis.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) != 1 {
+		t.Fatalf("ListCharts: %v %v", charts, err)
+	}
+	want := []AxisInfo{{Group: "primary", Position: "l"}, {Group: "secondary", Position: "r"}}
+	if !charts[0].HasSecondaryAxis || !reflect.DeepEqual(charts[0].AxisGroups, want) {
+		t.Fatalf("unexpected axes: %#v %v", charts[0].AxisGroups, charts[0].HasSecondaryAxis)
+	}
+
+	plan, err := doc.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if !plan.Charts[0].HasSecondaryAxis || !reflect.DeepEqual(plan.Charts[0].AxisGroups, want) {
+		t.Fatalf("expected the plan to carry the axes, got %#v", plan.Charts[0])
+	}
+	encoded, err := json.Marshal(plan.Charts[0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !bytes.Contains(encoded, []byte(`"axisGroups":[{"group":"primary","position":"l"},{"group":"secondary","position":"r"}],"hasSecondaryAxis":true`)) {
+		t.Fatalf("unexpected plan JSON: %s", encoded)
+	}
+
+	doc, err = OpenFile(fixturePath("pie_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err = doc.ListCharts()
+	if err != nil || charts[0].AxisGroups != nil || charts[0].HasSecondaryAxis {
+		t.Fatalf("expected a pie chart without axes, got %#v %v", charts, err)
+	}
+}
@@ -0,0 +1,111 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+
+	"why-pptx/internal/overlaystage"
+)
+
+// ErrCheckpointNotFound is returned by Rollback for an id Checkpoint did not
+// return, or one a rollback to an older checkpoint invalidated.
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// CheckpointID names a checkpoint taken by Checkpoint.
+type CheckpointID int
+
+// checkpoint is the document state Rollback returns to.
+type checkpoint struct {
+	id      CheckpointID
+	overlay overlaystage.PackageCheckpoint
+	// runs and pending are the manifest's saved runs and pending changes,
+	// syncs the length of the cache sync log, when it was taken.
+	runs    int
+	pending int
+	syncs   int
+}
+
+// checkpoints is the stack of live checkpoints, oldest first.
+type checkpoints struct {
+	taken []checkpoint
+	next  CheckpointID
+}
+
+// Checkpoint records the parts written so far so that Rollback can return
+// to them, discarding the edits made in between without reopening the file.
+// It copies the set of written parts, not the package or their contents, so
+// a checkpoint is cheap however large the deck.
+func (d *Document) Checkpoint() (CheckpointID, error) {
+	if d == nil || d.pkg == nil {
+		return 0, fmt.Errorf("document not initialized")
+	}
+	if err := d.ensureOverlay(); err != nil {
+		return 0, err
+	}
+	overlay, ok := d.overlay.(*overlaystage.PackageOverlay)
+	if !ok {
+		return 0, fmt.Errorf("document overlay does not support checkpoints")
+	}
+
+	d.checkpoints.next++
+	d.checkpoints.taken = append(d.checkpoints.taken, checkpoint{
+		id:      d.checkpoints.next,
+		overlay: overlay.Checkpoint(),
+		runs:    len(d.manifest.runs),
+		pending: len(d.manifest.pending),
+		syncs:   len(d.cacheSyncs),
+	})
+	return d.checkpoints.next, nil
+}
+
+// Rollback returns the document's parts to checkpoint id: edits made since,
+// parts created by them included, are dropped, as are their entries in the
+// change manifest and CacheSyncResults. Alerts are kept. The checkpoint
+// stays valid, so Rollback can return to it again; checkpoints taken after
+// it are discarded and fail with ErrCheckpointNotFound.
+//
+// A save since the checkpoint does not change the saved file, and the
+// manifest keeps the run it recorded; the next save writes the rolled back
+// parts.
+func (d *Document) Rollback(id CheckpointID) error {
+	if d == nil || d.pkg == nil {
+		return fmt.Errorf("document not initialized")
+	}
+	index := -1
+	for i, taken := range d.checkpoints.taken {
+		if taken.id == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%w: %d", ErrCheckpointNotFound, id)
+	}
+	overlay, ok := d.overlay.(*overlaystage.PackageOverlay)
+	if !ok {
+		return fmt.Errorf("document overlay does not support checkpoints")
+	}
+
+	taken := d.checkpoints.taken[index]
+	overlay.Restore(taken.overlay)
+	d.checkpoints.taken = d.checkpoints.taken[:index+1]
+	// After a save, every pending change came after the checkpoint.
+	if len(d.manifest.runs) != taken.runs {
+		d.manifest.pending = nil
+	} else if len(d.manifest.pending) >= taken.pending {
+		d.manifest.pending = d.manifest.pending[:taken.pending]
+	}
+	if len(d.cacheSyncs) >= taken.syncs {
+		d.cacheSyncs = d.cacheSyncs[:taken.syncs]
+	}
+	return nil
+}
+
+// TouchedParts lists, sorted by name, the parts written since the document
+// was opened, whether by an edit or by a save, and not rolled back.
+func (d *Document) TouchedParts() []string {
+	if d == nil || d.pkg == nil {
+		return []string{}
+	}
+	return d.pkg.WrittenParts()
+}
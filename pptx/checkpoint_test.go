@@ -0,0 +1,166 @@
+package pptx
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+// savedChartValues saves doc and returns the values cached in the saved
+// chart and held by the workbook cells B2:B3 behind them.
+func savedChartValues(t *testing.T, doc *Document) (cached, cells []string) {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	chartXML, err := pptxassert.ReadEntry(output, "ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ReadEntry chart: %v", err)
+	}
+	snap, err := pptxassert.ExtractChartCacheSnapshot(chartXML)
+	if err != nil {
+		t.Fatalf("ExtractChartCacheSnapshot: %v", err)
+	}
+	for _, series := range snap.Series {
+		if series.Kind == "numCache" {
+			for _, point := range series.Points {
+				cached = append(cached, point.Value)
+			}
+		}
+	}
+	workbook, err := pptxassert.ReadEntry(output, "ppt/embeddings/embeddedWorkbook1.xlsx")
+	if err != nil {
+		t.Fatalf("ReadEntry workbook: %v", err)
+	}
+	snapshot, err := pptxassert.ExtractWorkbookCellSnapshot(workbook, "Sheet1", []string{"B2", "B3"})
+	if err != nil {
+		t.Fatalf("ExtractWorkbookCellSnapshot: %v", err)
+	}
+	return cached, []string{snapshot["B2"], snapshot["B3"]}
+}
+
+func TestCheckpointRollbackInterleavedApplies(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if touched := doc.TouchedParts(); len(touched) != 0 {
+		t.Fatalf("expected no touched parts after open, got %v", touched)
+	}
+	apply := func(values ...string) {
+		t.Helper()
+		data := map[string][]string{"categories": {"A", "B"}, "values:0": values}
+		if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data); err != nil {
+			t.Fatalf("ApplyChartDataByPath: %v", err)
+		}
+	}
+	expectValues := func(want ...string) {
+		t.Helper()
+		cached, cells := savedChartValues(t, doc)
+		if !reflect.DeepEqual(cached, want) || !reflect.DeepEqual(cells, want) {
+			t.Fatalf("expected %v saved, got cache %v and cells %v", want, cached, cells)
+		}
+	}
+
+	start, err := doc.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	original, _ := savedChartValues(t, doc)
+
+	apply("1", "2")
+	first, err := doc.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	want := []string{"ppt/charts/chart1.xml", "ppt/embeddings/embeddedWorkbook1.xlsx"}
+	if touched := doc.TouchedParts(); !reflect.DeepEqual(touched, want) {
+		t.Fatalf("expected touched parts %v, got %v", want, touched)
+	}
+	apply("3", "4")
+	if len(doc.CacheSyncResults()) != 2 {
+		t.Fatalf("expected two cache syncs, got %#v", doc.CacheSyncResults())
+	}
+	second, err := doc.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	if err := doc.Rollback(first); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	expectValues("1", "2")
+	if len(doc.CacheSyncResults()) != 1 {
+		t.Fatalf("expected the rolled back sync to be dropped, got %#v", doc.CacheSyncResults())
+	}
+
+	// The checkpoint survives its rollback.
+	apply("5", "6")
+	expectValues("5", "6")
+	if err := doc.Rollback(first); err != nil {
+		t.Fatalf("second Rollback: %v", err)
+	}
+	expectValues("1", "2")
+
+	if err := doc.Rollback(second); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Fatalf("expected the newer checkpoint to be invalidated, got %v", err)
+	}
+
+	if err := doc.Rollback(start); err != nil {
+		t.Fatalf("Rollback to start: %v", err)
+	}
+	if err := doc.Rollback(first); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Fatalf("expected the first checkpoint to be invalidated, got %v", err)
+	}
+	cached, _ := savedChartValues(t, doc)
+	if !reflect.DeepEqual(cached, original) {
+		t.Fatalf("expected the original cache %v, got %v", original, cached)
+	}
+	if touched := doc.TouchedParts(); len(touched) != 0 {
+		t.Fatalf("expected no touched parts after rolling back to the start, got %v", touched)
+	}
+}
+
+func TestRollbackRemovesCreatedParts(t *testing.T) {
+	dir := t.TempDir()
+	input := writeImportTarget(t, dir)
+	src, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile source: %v", err)
+	}
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile target: %v", err)
+	}
+
+	id, err := doc.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	chartPath, err := doc.ImportChart(src, "ppt/charts/chart1.xml", "ppt/slides/slide1.xml")
+	if err != nil {
+		t.Fatalf("ImportChart: %v", err)
+	}
+	if err := doc.Rollback(id); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if _, err := doc.ExtractChartDataByPath(chartPath); err == nil {
+		t.Fatalf("expected the imported chart to be gone")
+	}
+
+	output := filepath.Join(dir, "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	pptxassert.AssertSameEntrySet(t, input, output)
+
+	// The same import succeeds again: the rolled back parts are not left
+	// in the baseline.
+	if _, err := doc.ImportChart(src, "ppt/charts/chart1.xml", "ppt/slides/slide1.xml"); err != nil {
+		t.Fatalf("ImportChart after rollback: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/chartxml"
+	"why-pptx/internal/overlaystage"
+)
+
+// ErrUnsupportedConversion is wrapped by the ConvertChartType error for a
+// chart that cannot take the requested type; the message names the reason.
+var ErrUnsupportedConversion = chartxml.ErrUnsupportedConversion
+
+// ConvertChartType switches an embedded bar chart to a line chart or back,
+// in place. newType is "bar" or "line". Series references, caches, and axes
+// are kept, since both types read the same data; only the plot element and
+// its type-specific children change. The rewrite is staged and validated like
+// any chart write.
+//
+// Charts with several plots, 3-D, pie, or other plot types, stacked
+// groupings, and horizontal bars are rejected in every mode with an error
+// wrapping ErrUnsupportedConversion.
+func (d *Document) ConvertChartType(chartPath, newType string) error {
+	if d == nil || d.pkg == nil {
+		return fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	chartPath = normalizeChartPath(chartPath)
+	if chartPath == "" {
+		return fmt.Errorf("chart path is required")
+	}
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return err
+	}
+	var chart *EmbeddedChart
+	for _, item := range embedded {
+		if item.ChartPath == chartPath {
+			converted := embeddedChartFrom(item)
+			chart = &converted
+			break
+		}
+	}
+	if chart == nil {
+		return d.chartPathError(chartPath, embedded, skipped)
+	}
+
+	dep, ok, err := call.chartDependencies(*chart)
+	if err != nil || !ok {
+		return err
+	}
+
+	return call.withChartStage(call.validateContext(dep), func(stage overlaystage.Overlay) error {
+		data, err := stage.Get(dep.ChartPath)
+		if err != nil {
+			return fmt.Errorf("read chart %q: %w", dep.ChartPath, err)
+		}
+		converted, err := chartxml.ConvertPlot(data, newType)
+		if err != nil {
+			return fmt.Errorf("convert chart %q: %w", dep.ChartPath, err)
+		}
+		if err := stage.Set(dep.ChartPath, converted); err != nil {
+			return fmt.Errorf("write chart %q: %w", dep.ChartPath, err)
+		}
+		d.manifest.stage(chartChange("convertChartType", dep, nil, 0))
+		return nil
+	})
+}
@@ -0,0 +1,113 @@
+package pptx
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+func TestConvertChartTypeBarToLine(t *testing.T) {
+	exercisesFeature(t, "chart.convert.barline")
+
+	input := fixturePath("bar_simple_embedded.pptx")
+	before, err := pptxassert.ExtractChartCacheSnapshot(readZipEntry(t, input, "ppt/charts/chart1.xml"))
+	if err != nil {
+		t.Fatalf("ExtractChartCacheSnapshot: %v", err)
+	}
+
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ConvertChartType("/ppt/charts/chart1.xml", "line"); err != nil {
+		t.Fatalf("ConvertChartType: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	pptxassert.AssertSameEntrySet(t, input, output)
+
+	reopened, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := reopened.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if data.Type != "line" {
+		t.Fatalf("expected a line chart, got %q", data.Type)
+	}
+
+	chartXML := readZipEntry(t, output, "ppt/charts/chart1.xml")
+	after, err := pptxassert.ExtractChartCacheSnapshot(chartXML)
+	if err != nil {
+		t.Fatalf("ExtractChartCacheSnapshot: %v", err)
+	}
+	if !reflect.DeepEqual(after, before) {
+		t.Fatalf("caches changed:\nbefore %#v\nafter  %#v", before, after)
+	}
+
+	// Converting back restores a bar chart that still takes writes.
+	if err := reopened.ConvertChartType("ppt/charts/chart1.xml", "bar"); err != nil {
+		t.Fatalf("ConvertChartType back: %v", err)
+	}
+	if err := reopened.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{
+		"categories": {"A", "B"},
+		"values:0":   {"1", "2"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	data, err = reopened.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if data.Type != "bar" {
+		t.Fatalf("expected a bar chart, got %q", data.Type)
+	}
+}
+
+func TestConvertChartTypeRejectsUnsupported(t *testing.T) {
+	cases := []struct {
+		fixture string
+		to      string
+		reason  string
+	}{
+		{"pie_simple_embedded.pptx", "line", "pieChart charts cannot be converted"},
+		{"mix_bar_line_simple.pptx", "line", "2 plots"},
+		{"bar_simple_embedded.pptx", "area", `cannot convert to "area"`},
+	}
+	for _, tc := range cases {
+		for _, mode := range []ErrorMode{Strict, BestEffort} {
+			opts := DefaultOptions()
+			opts.Mode = mode
+			doc, err := OpenFile(fixturePath(tc.fixture), WithOptions(opts))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			charts, err := doc.ListCharts()
+			if err != nil || len(charts) == 0 {
+				t.Fatalf("ListCharts: %v", err)
+			}
+			err = doc.ConvertChartType(charts[0].ChartPath, tc.to)
+			if !errors.Is(err, ErrUnsupportedConversion) || !strings.Contains(err.Error(), tc.reason) {
+				t.Fatalf("%s: expected %q rejection, got %v", tc.fixture, tc.reason, err)
+			}
+		}
+	}
+}
+
+func TestConvertChartTypeUnknownPath(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ConvertChartType("ppt/slides/slide1.xml", "line"); !errors.Is(err, ErrChartNotFound) {
+		t.Fatalf("expected ErrChartNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+// corpusDir holds one directory per scenario: input.pptx, operations.json,
+// and expected/ with a golden file for every part the operations may change.
+// An embedded workbook's golden is a directory of the inner XML entries that
+// change. Regenerate with `go test ./pptx -run TestCorpus -update-golden`.
+var corpusDir = filepath.Join("..", "testdata", "corpus")
+
+type corpusCase struct {
+	Description string            `json:"description"`
+	Options     corpusOptions     `json:"options"`
+	Operations  []corpusOperation `json:"operations"`
+	// Alerts, when set, lists the alert codes the run must record.
+	Alerts []string `json:"alerts,omitempty"`
+}
+
+type corpusOptions struct {
+	Mode      string `json:"mode,omitempty"`
+	CacheSync *bool  `json:"cacheSync,omitempty"`
+}
+
+type corpusOperation struct {
+	Op    string              `json:"op"`
+	Chart string              `json:"chart,omitempty"`
+	Data  map[string][]string `json:"data,omitempty"`
+}
+
+func TestCorpus(t *testing.T) {
+	entries, err := os.ReadDir(corpusDir)
+	if err != nil {
+		t.Fatalf("ReadDir corpus: %v", err)
+	}
+	ran := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		ran++
+		dir := filepath.Join(corpusDir, entry.Name())
+		t.Run(entry.Name(), func(t *testing.T) {
+			runCorpusCase(t, dir)
+		})
+	}
+	if ran == 0 {
+		t.Fatalf("corpus %s has no cases", corpusDir)
+	}
+}
+
+func runCorpusCase(t *testing.T, dir string) {
+	raw, err := os.ReadFile(filepath.Join(dir, "operations.json"))
+	if err != nil {
+		t.Fatalf("read operations.json: %v", err)
+	}
+	var tc corpusCase
+	if err := json.Unmarshal(raw, &tc); err != nil {
+		t.Fatalf("parse operations.json: %v", err)
+	}
+
+	input := filepath.Join(dir, "input.pptx")
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	doc, err := OpenFile(input, WithOptions(tc.Options.build(t)))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for i, op := range tc.Operations {
+		if err := op.run(doc); err != nil {
+			t.Fatalf("operation %d (%s): %v", i, op.Op, err)
+		}
+	}
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	if tc.Alerts != nil {
+		got := make([]string, 0, len(doc.Alerts()))
+		for _, alert := range doc.Alerts() {
+			got = append(got, alert.Code)
+		}
+		want := append([]string(nil), tc.Alerts...)
+		sort.Strings(got)
+		sort.Strings(want)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("alerts: got %v, want %v", got, want)
+		}
+	}
+
+	pptxassert.AssertSameEntrySet(t, input, output)
+	before := corpusZipEntries(t, readCorpusFile(t, input))
+	after := corpusZipEntries(t, readCorpusFile(t, output))
+	expectedDir := filepath.Join(dir, "expected")
+
+	if *updateGolden {
+		if err := os.RemoveAll(expectedDir); err != nil {
+			t.Fatalf("clear expected: %v", err)
+		}
+		writeCorpusGoldens(t, expectedDir, before, after)
+		return
+	}
+
+	goldens := loadCorpusGoldens(t, expectedDir, after)
+	for name, data := range after {
+		golden, allowed := goldens[name]
+		if !allowed {
+			if !bytes.Equal(data, before[name]) {
+				t.Fatalf("%s changed but has no golden under expected/", name)
+			}
+			continue
+		}
+		if isZipPart(data) {
+			compareNestedGoldens(t, name, corpusZipEntries(t, before[name]), corpusZipEntries(t, data), golden)
+			continue
+		}
+		pptxassert.AssertXMLEquivalent(t, name, data, golden[""])
+	}
+}
+
+func (o corpusOptions) build(t *testing.T) Options {
+	t.Helper()
+	opts := DefaultOptions()
+	switch o.Mode {
+	case "", "strict":
+	case "bestEffort":
+		opts.Mode = BestEffort
+	default:
+		t.Fatalf("unknown mode %q", o.Mode)
+	}
+	if o.CacheSync != nil {
+		opts.Chart.CacheSync = *o.CacheSync
+	}
+	return opts
+}
+
+func (o corpusOperation) run(doc *Document) error {
+	switch o.Op {
+	case "applyChartData":
+		return doc.ApplyChartDataByPath(o.Chart, o.Data)
+	case "syncChartCaches":
+		_, err := doc.SyncChartCaches()
+		return err
+	case "repairChartCaches":
+		if o.Chart == "" {
+			_, err := doc.RepairChartCaches()
+			return err
+		}
+		_, err := doc.RepairChartCaches(o.Chart)
+		return err
+	default:
+		return errors.New("unknown operation")
+	}
+}
+
+// loadCorpusGoldens maps each part with a golden to its golden files, keyed
+// by the inner entry name for workbook goldens and by "" for XML parts.
+func loadCorpusGoldens(t *testing.T, expectedDir string, parts map[string][]byte) map[string]map[string][]byte {
+	t.Helper()
+	goldens := make(map[string]map[string][]byte)
+	err := filepath.WalkDir(expectedDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(expectedDir, path)
+		if err != nil {
+			return err
+		}
+		part, inner, ok := corpusGoldenPart(filepath.ToSlash(rel), parts)
+		if !ok {
+			t.Fatalf("golden %s does not match any part", rel)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if goldens[part] == nil {
+			goldens[part] = make(map[string][]byte)
+		}
+		goldens[part][inner] = data
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("load goldens: %v", err)
+	}
+	return goldens
+}
+
+// corpusGoldenPart splits a golden path into the part it belongs to and,
+// for workbook goldens, the entry inside that part.
+func corpusGoldenPart(rel string, parts map[string][]byte) (string, string, bool) {
+	for part := rel; ; {
+		if _, ok := parts[part]; ok {
+			return part, strings.TrimPrefix(rel[len(part):], "/"), true
+		}
+		cut := strings.LastIndex(part, "/")
+		if cut < 0 {
+			return "", "", false
+		}
+		part = part[:cut]
+	}
+}
+
+func compareNestedGoldens(t *testing.T, part string, before, after, goldens map[string][]byte) {
+	t.Helper()
+	if sortedEntryNames(before) != sortedEntryNames(after) {
+		t.Fatalf("%s: entry set changed: before=%s after=%s", part, sortedEntryNames(before), sortedEntryNames(after))
+	}
+	for inner, data := range after {
+		label := part + "/" + inner
+		golden, ok := goldens[inner]
+		if !ok {
+			if !bytes.Equal(data, before[inner]) {
+				t.Fatalf("%s changed but has no golden under expected/", label)
+			}
+			continue
+		}
+		pptxassert.AssertXMLEquivalent(t, label, data, golden)
+	}
+}
+
+func writeCorpusGoldens(t *testing.T, expectedDir string, before, after map[string][]byte) {
+	t.Helper()
+	write := func(rel string, data []byte) {
+		path := filepath.Join(expectedDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("write golden: %v", err)
+		}
+	}
+	for name, data := range after {
+		if bytes.Equal(data, before[name]) {
+			continue
+		}
+		if !isZipPart(data) {
+			write(name, data)
+			continue
+		}
+		innerBefore := corpusZipEntries(t, before[name])
+		for inner, innerData := range corpusZipEntries(t, data) {
+			if !bytes.Equal(innerData, innerBefore[inner]) {
+				write(name+"/"+inner, innerData)
+			}
+		}
+	}
+}
+
+func corpusZipEntries(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	entries := make(map[string][]byte, len(reader.File))
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", file.Name, err)
+		}
+		entries[file.Name] = content
+	}
+	return entries
+}
+
+func readCorpusFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return data
+}
+
+func isZipPart(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+func sortedEntryNames(entries map[string][]byte) string {
+	out := make([]string, 0, len(entries))
+	for name := range entries {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
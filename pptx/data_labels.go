@@ -0,0 +1,169 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"why-pptx/internal/chartxml"
+	"why-pptx/internal/overlaystage"
+)
+
+// ErrDataLabelsUnsupported is wrapped by the SetChartDataLabels error for a
+// chart whose plot type does not model data labels, such as scatter.
+var ErrDataLabelsUnsupported = chartxml.ErrDataLabelsUnsupported
+
+// DataLabels is what a chart's or series' c:dLbls shows. Labels turned off
+// with c:delete show nothing.
+type DataLabels struct {
+	ShowValue    bool `json:"showValue"`
+	ShowCategory bool `json:"showCategory"`
+	ShowPercent  bool `json:"showPercent"`
+	// FormatCode is the labels' numFmt formatCode, empty without one.
+	// FormatLinked is set for sourceLinked="1", where the labels follow the
+	// workbook cells' format.
+	FormatCode   string `json:"formatCode,omitempty"`
+	FormatLinked bool   `json:"formatLinked,omitempty"`
+}
+
+// DataLabelOptions says what SetChartDataLabels shows.
+type DataLabelOptions struct {
+	ShowValue    bool
+	ShowCategory bool
+	ShowPercent  bool
+	// FormatCode, when set, replaces the labels' number format, unlinked
+	// from the workbook cells. Empty keeps the format they have.
+	FormatCode string
+	// SeriesIdx limits the change to the series with this c:idx, the
+	// OriginalIndex of ExtractedSeries. Nil changes every series.
+	SeriesIdx *int
+}
+
+func dataLabelsFrom(labels chartxml.DataLabels) DataLabels {
+	return DataLabels{
+		ShowValue:    labels.ShowVal,
+		ShowCategory: labels.ShowCatName,
+		ShowPercent:  labels.ShowPercent,
+		FormatCode:   labels.FormatCode,
+		FormatLinked: labels.SourceLinked,
+	}
+}
+
+// chartDataLabels returns the chart-level labels of parsed and those of
+// each series by c:idx; either is nil when the chart has none.
+func chartDataLabels(parsed *chartxml.Info) (*DataLabels, map[int]DataLabels) {
+	var plot *DataLabels
+	if parsed.DataLabels != nil {
+		labels := dataLabelsFrom(*parsed.DataLabels)
+		plot = &labels
+	}
+	var series map[int]DataLabels
+	for idx, labels := range parsed.SeriesDataLabels {
+		if series == nil {
+			series = make(map[int]DataLabels, len(parsed.SeriesDataLabels))
+		}
+		series[idx] = dataLabelsFrom(labels)
+	}
+	return plot, series
+}
+
+// seriesDataLabels returns the labels of the series with c:idx idx, or nil.
+func seriesDataLabels(series map[int]DataLabels, idx int) *DataLabels {
+	labels, ok := series[idx]
+	if !ok {
+		return nil
+	}
+	return &labels
+}
+
+// SetChartDataLabels shows or hides the data labels of every series of a
+// chart, or of the one opts.SeriesIdx names. A series' existing c:dLbls is
+// updated in place, keeping its point labels, shape and text properties,
+// and anything else it holds; a series without one gets a new one. The
+// rewrite is staged and validated like any chart write.
+//
+// A chart whose plot type does not support labels, such as scatter, is
+// reported with CHART_DATA_LABELS_UNSUPPORTED and left alone; Strict also
+// returns an error wrapping ErrDataLabelsUnsupported.
+func (d *Document) SetChartDataLabels(chartPath string, opts DataLabelOptions) error {
+	if d == nil || d.pkg == nil {
+		return fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	chartPath = normalizeChartPath(chartPath)
+	if chartPath == "" {
+		return fmt.Errorf("chart path is required")
+	}
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return err
+	}
+	var chart *EmbeddedChart
+	for _, item := range embedded {
+		if item.ChartPath == chartPath {
+			converted := embeddedChartFrom(item)
+			chart = &converted
+			break
+		}
+	}
+	if chart == nil {
+		return d.chartPathError(chartPath, embedded, skipped)
+	}
+
+	dep, ok, err := call.chartDependencies(*chart)
+	if err != nil || !ok {
+		return err
+	}
+
+	seriesIdx := chartxml.AllSeries
+	if opts.SeriesIdx != nil {
+		if *opts.SeriesIdx < 0 {
+			return fmt.Errorf("series index %d is negative", *opts.SeriesIdx)
+		}
+		seriesIdx = *opts.SeriesIdx
+	}
+	labels := chartxml.DataLabels{
+		ShowVal:     opts.ShowValue,
+		ShowCatName: opts.ShowCategory,
+		ShowPercent: opts.ShowPercent,
+		FormatCode:  opts.FormatCode,
+	}
+
+	err = call.withChartStage(call.validateContext(dep), func(stage overlaystage.Overlay) error {
+		data, err := stage.Get(dep.ChartPath)
+		if err != nil {
+			return fmt.Errorf("read chart %q: %w", dep.ChartPath, err)
+		}
+		updated, err := chartxml.SetDataLabels(data, labels, seriesIdx)
+		if err != nil {
+			return fmt.Errorf("set data labels in %q: %w", dep.ChartPath, err)
+		}
+		if err := stage.Set(dep.ChartPath, updated); err != nil {
+			return fmt.Errorf("write chart %q: %w", dep.ChartPath, err)
+		}
+		d.manifest.stage(chartChange("setChartDataLabels", dep, nil, 0))
+		return nil
+	})
+	if err != nil && errors.Is(err, ErrDataLabelsUnsupported) {
+		context := map[string]string{
+			"slide":     dep.SlidePath,
+			"chart":     dep.ChartPath,
+			"chartType": dep.ChartType,
+			"error":     err.Error(),
+		}
+		if opts.SeriesIdx != nil {
+			context["series"] = strconv.Itoa(*opts.SeriesIdx)
+		}
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "CHART_DATA_LABELS_UNSUPPORTED",
+			Message: "Chart type does not support data labels; chart is left unchanged",
+			Context: context,
+		})
+		if call.mode() == BestEffort {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package pptx
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const labeledBarChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser><c:idx val="0"/><c:order val="0"/><c:dLbls><c:spPr><a:noFill/></c:spPr><c:delete val="1"/></c:dLbls><c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Cat1</c:v></c:pt><c:pt idx="1"><c:v>Cat2</c:v></c:pt></c:strCache></c:strRef></c:cat><c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:ptCount val="2"/><c:pt idx="0"><c:v>10</c:v></c:pt><c:pt idx="1"><c:v>20</c:v></c:pt></c:numCache></c:numRef></c:val></c:ser>
+        <c:ser><c:idx val="4"/><c:order val="1"/><c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Cat1</c:v></c:pt><c:pt idx="1"><c:v>Cat2</c:v></c:pt></c:strCache></c:strRef></c:cat><c:val><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f><c:numCache><c:ptCount val="2"/><c:pt idx="0"><c:v>30</c:v></c:pt><c:pt idx="1"><c:v>40</c:v></c:pt></c:numCache></c:numRef></c:val></c:ser>
+        <c:dLbls><c:showVal val="0"/><c:showCatName val="1"/></c:dLbls>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+func TestSetChartDataLabels(t *testing.T) {
+	exercisesFeature(t, "chart.data-labels")
+
+	const chartPath = "ppt/charts/chart1.xml"
+	doc, err := OpenFile(writeRepairDeck(t, t.TempDir(), labeledBarChart, nil))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil {
+		t.Fatalf("ListCharts: %v", err)
+	}
+	if len(charts) != 1 || charts[0].DataLabels == nil || !charts[0].DataLabels.ShowCategory {
+		t.Fatalf("unexpected charts: %#v", charts)
+	}
+	if labels, ok := charts[0].SeriesDataLabels[0]; !ok || labels != (DataLabels{}) {
+		t.Fatalf("expected series 0 labels deleted, got %#v", charts[0].SeriesDataLabels)
+	}
+
+	if err := doc.SetChartDataLabels(chartPath, DataLabelOptions{ShowValue: true, FormatCode: "0.0"}); err != nil {
+		t.Fatalf("SetChartDataLabels: %v", err)
+	}
+	series := 4
+	if err := doc.SetChartDataLabels(chartPath, DataLabelOptions{ShowPercent: true, SeriesIdx: &series}); err != nil {
+		t.Fatalf("SetChartDataLabels series 4: %v", err)
+	}
+	missing := 2
+	if err := doc.SetChartDataLabels(chartPath, DataLabelOptions{ShowValue: true, SeriesIdx: &missing}); err == nil || !strings.Contains(err.Error(), "series 2 not found") {
+		t.Fatalf("expected missing series error, got %v", err)
+	}
+
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	chart := string(readZipEntry(t, output, chartPath))
+	if !strings.Contains(chart, `<c:dLbls><c:numFmt formatCode="0.0" sourceLinked="0"/><c:spPr><a:noFill/></c:spPr><c:showLegendKey val="0"/><c:showVal val="1"/>`) {
+		t.Fatalf("series 0 labels must keep spPr and drop delete:\n%s", chart)
+	}
+
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	if issues, err := saved.Validate(ValidateOptions{}); err != nil || len(issues) != 0 {
+		t.Fatalf("Validate: %v %#v", err, issues)
+	}
+	data, err := saved.ExtractChartDataByPath(chartPath)
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if data.DataLabels == nil || !data.DataLabels.ShowCategory {
+		t.Fatalf("unexpected chart labels %#v", data.DataLabels)
+	}
+	want := []DataLabels{
+		{ShowValue: true, FormatCode: "0.0"},
+		{ShowPercent: true, FormatCode: "0.0"},
+	}
+	for i, s := range data.Series {
+		if s.DataLabels == nil || *s.DataLabels != want[i] {
+			t.Fatalf("series %d: unexpected labels %#v", i, s.DataLabels)
+		}
+	}
+}
+
+func TestSetChartDataLabelsUnsupported(t *testing.T) {
+	const chartPath = "ppt/charts/chart1.xml"
+	scatter := strings.NewReplacer("<c:barChart>", "<c:scatterChart>", "</c:barChart>", "</c:scatterChart>",
+		"<c:cat>", "<c:xVal>", "</c:cat>", "</c:xVal>", "<c:val>", "<c:yVal>", "</c:val>", "</c:yVal>").Replace(labeledBarChart)
+	input := writeRepairDeck(t, t.TempDir(), scatter, nil)
+
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SetChartDataLabels(chartPath, DataLabelOptions{ShowValue: true}); !errors.Is(err, ErrDataLabelsUnsupported) {
+		t.Fatalf("expected ErrDataLabelsUnsupported, got %v", err)
+	}
+	if alerts := doc.AlertsByCode("CHART_DATA_LABELS_UNSUPPORTED"); len(alerts) != 1 || alerts[0].Context["chart"] != chartPath {
+		t.Fatalf("expected CHART_DATA_LABELS_UNSUPPORTED, got %#v", doc.Alerts())
+	}
+
+	opts := DefaultOptions()
+	opts.Mode = BestEffort
+	doc, err = OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SetChartDataLabels(chartPath, DataLabelOptions{ShowValue: true}); err != nil {
+		t.Fatalf("SetChartDataLabels: %v", err)
+	}
+	if len(doc.AlertsByCode("CHART_DATA_LABELS_UNSUPPORTED")) != 1 {
+		t.Fatalf("expected CHART_DATA_LABELS_UNSUPPORTED, got %#v", doc.Alerts())
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if chart := string(readZipEntry(t, output, chartPath)); chart != scatter {
+		t.Fatalf("the scatter chart must be left alone:\n%s", chart)
+	}
+}
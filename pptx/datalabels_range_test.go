@@ -0,0 +1,148 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+func TestExtractDataLabelRange(t *testing.T) {
+	exercisesFeature(t, "ranges.datalabels")
+
+	doc, err := OpenFile(fixturePath("bar_datalabels_range.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	deps, err := doc.GetChartDependencies()
+	if err != nil {
+		t.Fatalf("GetChartDependencies: %v", err)
+	}
+	var labels []ChartRange
+	for _, r := range deps[0].Ranges {
+		if r.Kind == RangeDataLabels {
+			labels = append(labels, r)
+		}
+	}
+	if len(labels) != 1 || labels[0].SeriesIndex != 0 || labels[0].Formula != "Sheet1!$C$2:$C$3" {
+		t.Fatalf("unexpected data label ranges: %#v", labels)
+	}
+
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if len(data.Series) != 1 || !reflect.DeepEqual(data.Series[0].LabelTexts, []string{"Target met", "Above plan"}) {
+		t.Fatalf("unexpected label texts: %#v", data.Series)
+	}
+
+	var streamed []string
+	err = doc.ExtractChartDataStream("ppt/charts/chart1.xml", func(series int, kind ChartRangeKind, index int, value string) error {
+		if kind == RangeDataLabels && series == 0 {
+			streamed = append(streamed, value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExtractChartDataStream: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, data.Series[0].LabelTexts) {
+		t.Fatalf("streamed labels %v differ from %v", streamed, data.Series[0].LabelTexts)
+	}
+}
+
+func TestSyncChartCachesUpdatesDataLabelRange(t *testing.T) {
+	const chartPath = "ppt/charts/chart1.xml"
+	doc, err := OpenFile(fixturePath("bar_datalabels_range.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "C2", Value: Str("Missed")},
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "C3", Value: Str("Record")},
+	})
+	if err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || !results[0].Changed {
+		t.Fatalf("expected the label cache to change, got %#v", results)
+	}
+
+	// SaveFile runs postflight, which checks the rewritten label cache.
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+
+	chartXML, err := pptxassert.ReadEntry(output, chartPath)
+	if err != nil {
+		t.Fatalf("ReadEntry chart: %v", err)
+	}
+	if got := dataLabelCacheValues(t, chartXML); !reflect.DeepEqual(got, []string{"Missed", "Record"}) {
+		t.Fatalf("unexpected label cache: %v", got)
+	}
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	data, err := saved.ExtractChartDataByPath(chartPath)
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(data.Series[0].LabelTexts, []string{"Missed", "Record"}) || !reflect.DeepEqual(data.Labels, []string{"North", "South"}) {
+		t.Fatalf("round trip mismatch: %#v", data)
+	}
+}
+
+// dataLabelCacheValues returns the point values of the dlblRangeCache
+// elements in data, in document order.
+func dataLabelCacheValues(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	inCache := false
+	inValue := false
+	var buf bytes.Buffer
+	var values []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if tok.Name.Local == "dlblRangeCache" {
+				inCache = true
+			} else if inCache && tok.Name.Local == "v" {
+				inValue = true
+				buf.Reset()
+			}
+		case xml.EndElement:
+			if tok.Name.Local == "dlblRangeCache" {
+				inCache = false
+			} else if inValue && tok.Name.Local == "v" {
+				values = append(values, buf.String())
+				inValue = false
+			}
+		case xml.CharData:
+			if inValue {
+				buf.Write([]byte(tok))
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,151 @@
+package pptx
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDateSerial is 9999-12-31 in the 1900 date system, the last date Excel
+// shows.
+const maxDateSerial = 2958465
+
+// labelsDateFormat returns the date number format of data's categories: the
+// numCache formatCode PowerPoint copied from the category cells, or else the
+// category axis numFmt. It is "" when neither is a date format.
+func labelsDateFormat(data ExtractedChartData) string {
+	if isDateFormatCode(data.LabelsFormatCode) {
+		return data.LabelsFormatCode
+	}
+	if data.LabelsFormatCode == "" || strings.EqualFold(data.LabelsFormatCode, "General") {
+		for _, axis := range data.Axes {
+			if axis.Group == "primary" && isDateFormatCode(axis.CategoryFormatCode) {
+				return axis.CategoryFormatCode
+			}
+		}
+	}
+	return ""
+}
+
+// convertDateLabels rewrites the date serials of data.Labels as ISO-8601
+// dates, keeping the labels as read in RawLabels. A label with a time of
+// day keeps it ("2024-01-01T12:00:00") when the format shows hours or
+// seconds. Labels that are not serials, such as text cells, and serials
+// outside Excel's calendar are left alone.
+func convertDateLabels(data *ExtractedChartData, date1904 bool) {
+	format := labelsDateFormat(*data)
+	if format == "" {
+		return
+	}
+	withTime := formatShowsTime(format)
+
+	var converted []string
+	for i, label := range data.Labels {
+		iso, ok := dateSerialISO(label, date1904, withTime)
+		if !ok {
+			continue
+		}
+		if converted == nil {
+			converted = append([]string(nil), data.Labels...)
+		}
+		converted[i] = iso
+	}
+	if converted == nil {
+		return
+	}
+	data.RawLabels = data.Labels
+	data.Labels = converted
+	data.Meta.LabelsDateSystem = "1900"
+	if date1904 {
+		data.Meta.LabelsDateSystem = "1904"
+	}
+}
+
+// dateSerialISO converts one date serial. In the 1900 system serial 1 is
+// 1900-01-01 and serial 60 is the 1900-02-29 Lotus 1-2-3 made up, which has
+// no ISO form; in the 1904 system serial 0 is 1904-01-01.
+func dateSerialISO(label string, date1904, withTime bool) (string, bool) {
+	serial, err := strconv.ParseFloat(strings.TrimSpace(label), 64)
+	if err != nil || math.IsNaN(serial) || serial < 0 {
+		return "", false
+	}
+	var epoch time.Time
+	switch {
+	case date1904:
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+		if serial > maxDateSerial-1462 {
+			return "", false
+		}
+	case serial < 1 || serial > maxDateSerial || (serial >= 60 && serial < 61):
+		return "", false
+	case serial < 60:
+		epoch = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	default:
+		epoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	}
+
+	days := math.Floor(serial)
+	date := epoch.AddDate(0, 0, int(days))
+	if !withTime {
+		return date.Format("2006-01-02"), true
+	}
+	seconds := math.Round((serial - days) * 86400)
+	return date.Add(time.Duration(seconds) * time.Second).Format("2006-01-02T15:04:05"), true
+}
+
+// isDateFormatCode reports whether an Excel number format shows a date: it
+// has a year or day token, or a month token that is not minutes. Quoted and
+// escaped literals, [color] and [$-409] sections, and the characters after
+// _ and * do not count.
+func isDateFormatCode(code string) bool {
+	tokens := formatCodeTokens(code)
+	hasTime := strings.ContainsAny(tokens, "hs")
+	return strings.ContainsAny(tokens, "yd") || (strings.Contains(tokens, "m") && !hasTime)
+}
+
+// formatShowsTime reports whether an Excel number format has an hour or
+// second token.
+func formatShowsTime(code string) bool {
+	return strings.ContainsAny(formatCodeTokens(code), "hs")
+}
+
+// formatCodeTokens returns the lowercased letters of code outside literals
+// and bracketed sections, with an elapsed time section read as "h". Only the
+// first section of a format with several is read.
+func formatCodeTokens(code string) string {
+	var out strings.Builder
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; c {
+		case ';':
+			return out.String()
+		case '"':
+			if end := strings.IndexByte(code[i+1:], '"'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(code)
+			}
+		case '[':
+			end := strings.IndexByte(code[i+1:], ']')
+			if end < 0 {
+				return out.String()
+			}
+			// [h], [mm], and [ss] are elapsed time, so they count as an hour
+			// token.
+			if section := strings.ToLower(code[i+1 : i+1+end]); section != "" && strings.Trim(section, "hms") == "" {
+				out.WriteByte('h')
+			}
+			i += end + 1
+		case '\\', '_', '*':
+			i++
+		default:
+			if c >= 'A' && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			if c >= 'a' && c <= 'z' {
+				out.WriteByte(c)
+			}
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,153 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeDateLabelsDeck writes a bar chart over date categories: serials
+// formatted m/d/yyyy in a workbook using the 1900 or 1904 date system.
+func writeDateLabelsDeck(t *testing.T, date1904 bool) string {
+	t.Helper()
+
+	workbook := baseXLSXParts(t)
+	if date1904 {
+		workbook["xl/workbook.xml"] = []byte(strings.Replace(string(workbook["xl/workbook.xml"]), "<sheets>", `<workbookPr date1904="1"/><sheets>`, 1))
+	}
+	workbook["xl/worksheets/sheet1.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="2"><c r="A2"><v>45292</v></c><c r="B2"><v>10</v></c></row>
+    <row r="3"><c r="A3"><v>45323</v></c><c r="B3"><v>12</v></c></row>
+    <row r="4"><c r="A4" t="inlineStr"><is><t>TBD</t></is></c><c r="B4"><v>7</v></c></row>
+  </sheetData>
+</worksheet>`)
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"></p:sld>`),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:numRef><c:f>Sheet1!$A$2:$A$4</c:f><c:numCache><c:formatCode>m/d/yyyy</c:formatCode><c:ptCount val="3"/></c:numCache></c:numRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$4</c:f></c:numRef></c:val>
+        </c:ser>
+        <c:axId val="1"/>
+        <c:axId val="2"/>
+      </c:barChart>
+      <c:dateAx><c:axId val="1"/><c:numFmt formatCode="m/d/yyyy" sourceLinked="1"/><c:crossAx val="2"/></c:dateAx>
+      <c:valAx><c:axId val="2"/><c:crossAx val="1"/></c:valAx>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": writeZipBytes(t, workbook),
+	}
+
+	path := filepath.Join(t.TempDir(), "dates.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestExtractConvertDates(t *testing.T) {
+	exercisesFeature(t, "extract.dates")
+
+	tests := []struct {
+		name     string
+		date1904 bool
+		convert  bool
+		want     []string
+		system   string
+	}{
+		{name: "off", convert: false, want: []string{"45292", "45323", "TBD"}},
+		{name: "1900", convert: true, want: []string{"2024-01-01", "2024-02-01", "TBD"}, system: "1900"},
+		{name: "1904", date1904: true, convert: true, want: []string{"2028-01-02", "2028-02-02", "TBD"}, system: "1904"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Extract.ConvertDates = tt.convert
+			doc, err := OpenFile(writeDateLabelsDeck(t, tt.date1904), WithOptions(opts))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+			if err != nil {
+				t.Fatalf("ExtractChartDataByPath: %v", err)
+			}
+			if !reflect.DeepEqual(data.Labels, tt.want) {
+				t.Fatalf("unexpected labels %v", data.Labels)
+			}
+			if data.Meta.LabelsDateSystem != tt.system {
+				t.Fatalf("unexpected date system %q", data.Meta.LabelsDateSystem)
+			}
+			if tt.convert && !reflect.DeepEqual(data.RawLabels, []string{"45292", "45323", "TBD"}) {
+				t.Fatalf("unexpected raw labels %v", data.RawLabels)
+			}
+			if !tt.convert && data.RawLabels != nil {
+				t.Fatalf("expected no raw labels, got %v", data.RawLabels)
+			}
+			if len(data.Axes) != 1 || data.Axes[0].CategoryFormatCode != "m/d/yyyy" {
+				t.Fatalf("unexpected axes %#v", data.Axes)
+			}
+		})
+	}
+}
+
+func TestDateSerialISO(t *testing.T) {
+	tests := []struct {
+		serial   string
+		date1904 bool
+		withTime bool
+		want     string
+		ok       bool
+	}{
+		{serial: "1", want: "1900-01-01", ok: true},
+		{serial: "59", want: "1900-02-28", ok: true},
+		{serial: "60"},
+		{serial: "61", want: "1900-03-01", ok: true},
+		{serial: "45292.5", want: "2024-01-01", ok: true},
+		{serial: "45292.5", withTime: true, want: "2024-01-01T12:00:00", ok: true},
+		{serial: "0", date1904: true, want: "1904-01-01", ok: true},
+		{serial: "0"},
+		{serial: "-1"},
+		{serial: "3000000"},
+		{serial: "Q1"},
+	}
+	for _, tt := range tests {
+		got, ok := dateSerialISO(tt.serial, tt.date1904, tt.withTime)
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("dateSerialISO(%q, %v, %v) = %q, %v; want %q, %v", tt.serial, tt.date1904, tt.withTime, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	for code, want := range map[string]bool{
+		"m/d/yyyy":        true,
+		"mmm yy":          true,
+		"[$-409]d-mmm;@":  true,
+		"yyyy-mm-dd h:mm": true,
+		"h:mm:ss":         false,
+		"[h]:mm":          false,
+		"General":         false,
+		"0.00E+00":        false,
+		`#,##0 "days"`:    false,
+		`0.0\d`:           false,
+		"":                false,
+	} {
+		if got := isDateFormatCode(code); got != want {
+			t.Fatalf("isDateFormatCode(%q) = %v", code, got)
+		}
+	}
+}
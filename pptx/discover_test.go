@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -166,8 +167,8 @@ func TestDiscoverMissingChartRelsAlert(t *testing.T) {
 	if alerts[0].Code != "CHART_RELS_MISSING" {
 		t.Fatalf("unexpected alert code: %q", alerts[0].Code)
 	}
-	if alerts[0].Context["rels_path"] != "ppt/charts/_rels/chart1.xml.rels" {
-		t.Fatalf("unexpected rels_path: %q", alerts[0].Context["rels_path"])
+	if alerts[0].Context["relsPath"] != "ppt/charts/_rels/chart1.xml.rels" {
+		t.Fatalf("unexpected relsPath: %q", alerts[0].Context["relsPath"])
 	}
 }
 
@@ -489,3 +490,90 @@ func writeZip(path string, parts map[string][]byte) error {
 
 	return nil
 }
+
+// writeLayoutNotesDeck moves the chart of bar_simple_embedded.pptx onto a
+// slide layout and puts a copy of it on a notes slide, leaving slide1 empty.
+func writeLayoutNotesDeck(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	parts := corpusZipEntries(t, data)
+	slideRels := parts["ppt/slides/_rels/slide1.xml.rels"]
+	delete(parts, "ppt/slides/_rels/slide1.xml.rels")
+	parts["ppt/slideLayouts/slideLayout1.xml"] = []byte("<sldLayout/>")
+	parts["ppt/slideLayouts/_rels/slideLayout1.xml.rels"] = slideRels
+
+	parts["ppt/charts/chart2.xml"] = parts["ppt/charts/chart1.xml"]
+	parts["ppt/charts/_rels/chart2.xml.rels"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook2.xlsx"/>
+</Relationships>`)
+	parts["ppt/embeddings/embeddedWorkbook2.xlsx"] = parts["ppt/embeddings/embeddedWorkbook1.xlsx"]
+	parts["ppt/notesSlides/notesSlide1.xml"] = []byte("<notes/>")
+	parts["ppt/notesSlides/_rels/notesSlide1.xml.rels"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart2.xml"/>
+</Relationships>`)
+
+	path := filepath.Join(t.TempDir(), "layout.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestDiscoverChartsOnLayoutsAndNotes(t *testing.T) {
+	doc, err := OpenFile(writeLayoutNotesDeck(t))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	charts, err := doc.DiscoverEmbeddedCharts()
+	if err != nil {
+		t.Fatalf("DiscoverEmbeddedCharts: %v", err)
+	}
+	want := []EmbeddedChart{
+		{SlidePath: "ppt/slideLayouts/slideLayout1.xml", Source: SourceLayout, ChartPath: "ppt/charts/chart1.xml", WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx"},
+		{SlidePath: "ppt/notesSlides/notesSlide1.xml", Source: SourceNotes, ChartPath: "ppt/charts/chart2.xml", WorkbookPath: "ppt/embeddings/embeddedWorkbook2.xlsx"},
+	}
+	if !reflect.DeepEqual(charts, want) {
+		t.Fatalf("DiscoverEmbeddedCharts = %#v", charts)
+	}
+	if onSlide, err := doc.ChartsOnSlide("ppt/slides/slide1.xml"); err != nil || len(onSlide) != 0 {
+		t.Fatalf("expected no charts on slide1, got %#v %v", onSlide, err)
+	}
+
+	for _, chart := range want {
+		extracted, err := doc.ExtractChartDataByPath(chart.ChartPath)
+		if err != nil {
+			t.Fatalf("ExtractChartDataByPath %s: %v", chart.ChartPath, err)
+		}
+		if len(extracted.Series) == 0 {
+			t.Fatalf("expected series for %s", chart.ChartPath)
+		}
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{
+		"categories": {"New1", "New2"},
+		"values:0":   {"11", "22"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	extracted, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil || !reflect.DeepEqual(extracted.Labels, []string{"New1", "New2"}) {
+		t.Fatalf("expected the layout chart to take the new data, got %#v %v", extracted.Labels, err)
+	}
+
+	plan, err := doc.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plan.Charts) != 2 || plan.Charts[0].Source != SourceLayout || plan.Charts[1].Source != SourceNotes || plan.Charts[0].Action != "apply" {
+		t.Fatalf("unexpected plan: %#v", plan.Charts)
+	}
+	infos, err := doc.ListCharts()
+	if err != nil || len(infos) != 2 || infos[1].Source != SourceNotes {
+		t.Fatalf("unexpected ListCharts: %#v %v", infos, err)
+	}
+}
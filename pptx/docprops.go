@@ -0,0 +1,214 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/postflight"
+	"why-pptx/internal/rels"
+)
+
+const (
+	corePropertiesRelSuffix = "/metadata/core-properties"
+	corePropertiesNS        = "http://schemas.openxmlformats.org/package/2006/metadata/core-properties"
+	dcTermsNS               = "http://purl.org/dc/terms/"
+	xsiNS                   = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// scrubDocProps applies Options.Output.ScrubDocProps to the core properties
+// part the package relationships name, through a postflight-checked stage.
+// A package without one is left alone. A part that cannot be rewritten is
+// reported as SAVE_DOCPROPS_NOT_SCRUBBED in BestEffort mode and saved as it
+// is.
+func (d *docCall) scrubDocProps() error {
+	if err := d.ensureOverlay(); err != nil {
+		return err
+	}
+	part, err := d.corePropertiesPart()
+	if err != nil || part == "" {
+		return err
+	}
+
+	data, err := d.overlay.Get(part)
+	var updated []byte
+	if err == nil {
+		modified := d.opts.Output.Modified
+		if modified.IsZero() {
+			modified = time.Now()
+		}
+		updated, err = scrubCoreProperties(data, d.opts.Output.LastModifiedBy, modified.UTC().Format(time.RFC3339))
+	}
+	if err != nil {
+		err = fmt.Errorf("scrub %q: %w", part, err)
+		if d.mode() != BestEffort {
+			return err
+		}
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "SAVE_DOCPROPS_NOT_SCRUBBED",
+			Message: "Core properties could not be rewritten and were saved as they are",
+			Context: map[string]string{"part": part, "error": err.Error()},
+		})
+		return nil
+	}
+	if bytes.Equal(updated, data) {
+		return nil
+	}
+
+	ctx := postflight.ValidateContext{Mode: postflight.ModeStrict}
+	if d.mode() == BestEffort {
+		ctx.Mode = postflight.ModeBestEffort
+	}
+	return d.runChartStage(ctx, func(stage overlaystage.Overlay) error {
+		return stage.Set(part, updated)
+	})
+}
+
+// corePropertiesPart returns the part the package relationships name as
+// the core properties, or "" when there is none.
+func (d *Document) corePropertiesPart() (string, error) {
+	exists, err := d.overlay.Has(packageRelsPart)
+	if err != nil || !exists {
+		return "", err
+	}
+	data, err := d.overlay.Get(packageRelsPart)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := rels.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	ids := make([]string, 0, len(parsed.ByID))
+	for id := range parsed.ByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		rel := parsed.ByID[id]
+		if !strings.HasSuffix(rel.Type, corePropertiesRelSuffix) || strings.EqualFold(rel.TargetMode, "External") {
+			continue
+		}
+		part := rels.ResolveTarget("", rel.Target)
+		exists, err := d.overlay.Has(part)
+		if err != nil || !exists {
+			return "", err
+		}
+		return part, nil
+	}
+	return "", nil
+}
+
+// scrubCoreProperties sets the text of cp:lastModifiedBy and
+// dcterms:modified, adding either element at the end of the root when it is
+// missing. The rest of the part is kept byte for byte.
+func scrubCoreProperties(data []byte, modifiedBy, modified string) ([]byte, error) {
+	type field struct {
+		space, local string
+		text         string
+		element      string
+		found        bool
+	}
+	fields := []*field{
+		{
+			space: corePropertiesNS, local: "lastModifiedBy", text: modifiedBy,
+			element: `<cp:lastModifiedBy xmlns:cp="` + corePropertiesNS + `">%s</cp:lastModifiedBy>`,
+		},
+		{
+			space: dcTermsNS, local: "modified", text: modified,
+			element: `<dcterms:modified xmlns:dcterms="` + dcTermsNS + `" xmlns:xsi="` + xsiNS + `" xsi:type="dcterms:W3CDTF">%s</dcterms:modified>`,
+		},
+	}
+
+	type edit struct {
+		start, end  int
+		replacement []byte
+	}
+	var edits []edit
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	var current *field
+	var elementStart, contentStart int
+	var rawStart []byte
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("parse core properties: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse core properties: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth != 2 {
+				continue
+			}
+			for _, f := range fields {
+				if !f.found && tok.Name.Space == f.space && tok.Name.Local == f.local {
+					current, f.found = f, true
+					elementStart, contentStart = offset, int(decoder.InputOffset())
+					rawStart = data[elementStart:contentStart]
+				}
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 1 && current != nil {
+				text := escapeAttr(current.text)
+				end := int(decoder.InputOffset())
+				if end == contentStart {
+					// A self-closing element: give it content.
+					name := rawElementName(rawStart)
+					replacement := append([]byte{}, bytes.TrimRight(bytes.TrimSuffix(rawStart, []byte("/>")), " \t\r\n")...)
+					replacement = append(replacement, '>')
+					replacement = append(replacement, text...)
+					replacement = append(replacement, "</"+name+">"...)
+					edits = append(edits, edit{elementStart, end, replacement})
+				} else {
+					edits = append(edits, edit{contentStart, offset, []byte(text)})
+				}
+				current = nil
+			}
+			if depth == 0 {
+				var missing []byte
+				for _, f := range fields {
+					if !f.found {
+						missing = append(missing, fmt.Sprintf(f.element, escapeAttr(f.text))...)
+					}
+				}
+				if len(missing) > 0 && int(decoder.InputOffset()) == offset {
+					return nil, fmt.Errorf("parse core properties: empty root element")
+				}
+				if len(missing) > 0 {
+					edits = append(edits, edit{offset, offset, missing})
+				}
+				var out bytes.Buffer
+				last := 0
+				for _, e := range edits {
+					out.Write(data[last:e.start])
+					out.Write(e.replacement)
+					last = e.end
+				}
+				out.Write(data[last:])
+				return out.Bytes(), nil
+			}
+		}
+	}
+}
+
+// rawElementName is the qualified name at the start of a raw start tag.
+func rawElementName(tag []byte) string {
+	name := bytes.TrimPrefix(tag, []byte("<"))
+	if i := bytes.IndexAny(name, " \t\r\n/>"); i >= 0 {
+		name = name[:i]
+	}
+	return string(name)
+}
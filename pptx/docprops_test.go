@@ -0,0 +1,148 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeDocPropsDeck writes bar_simple_embedded.pptx with core properties.
+func writeDocPropsDeck(t *testing.T, core string) string {
+	t.Helper()
+
+	parts := corpusZipEntries(t, readCorpusFile(t, fixturePath("bar_simple_embedded.pptx")))
+	parts["_rels/.rels"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`)
+	parts["docProps/core.xml"] = []byte(core)
+	path := filepath.Join(t.TempDir(), "props.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestScrubDocProps(t *testing.T) {
+	cases := []struct {
+		name string
+		core string
+		want []string
+	}{
+		{
+			name: "rewrites existing fields",
+			core: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><dc:creator>Ann Author</dc:creator><cp:lastModifiedBy>Ann Author</cp:lastModifiedBy><dcterms:modified xsi:type="dcterms:W3CDTF">2019-05-01T10:00:00Z</dcterms:modified></cp:coreProperties>`,
+			want: []string{
+				`<dc:creator>Ann Author</dc:creator><cp:lastModifiedBy>Reports &amp; Co</cp:lastModifiedBy><dcterms:modified xsi:type="dcterms:W3CDTF">2024-03-01T12:00:00Z</dcterms:modified>`,
+			},
+		},
+		{
+			name: "adds missing fields",
+			core: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Q1</dc:title><cp:lastModifiedBy/></cp:coreProperties>`,
+			want: []string{
+				`<cp:lastModifiedBy>Reports &amp; Co</cp:lastModifiedBy>`,
+				`<dcterms:modified xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="dcterms:W3CDTF">2024-03-01T12:00:00Z</dcterms:modified></cp:coreProperties>`,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Output.ScrubDocProps = true
+			opts.Output.LastModifiedBy = "Reports & Co"
+			opts.Output.Modified = time.Date(2024, 3, 1, 13, 0, 0, 0, time.FixedZone("CET", 3600))
+			doc, err := OpenFile(writeDocPropsDeck(t, tc.core), WithOptions(opts))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			output := filepath.Join(t.TempDir(), "output.pptx")
+			if err := doc.SaveFile(output); err != nil {
+				t.Fatalf("SaveFile: %v", err)
+			}
+			core := string(readZipEntry(t, output, "docProps/core.xml"))
+			for _, want := range tc.want {
+				if !strings.Contains(core, want) {
+					t.Fatalf("core.xml lacks %s:\n%s", want, core)
+				}
+			}
+			if len(doc.Alerts()) != 0 {
+				t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+			}
+		})
+	}
+}
+
+func TestScrubDocPropsMalformed(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Output.ScrubDocProps = true
+	input := writeDocPropsDeck(t, `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"><cp:lastModifiedBy>x</cp:coreProperties>`)
+	doc, err := OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.Save(); err == nil {
+		t.Fatalf("expected Strict save to fail on malformed core properties")
+	}
+
+	opts.Mode = BestEffort
+	doc, err = OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if len(doc.AlertsByCode("SAVE_DOCPROPS_NOT_SCRUBBED")) != 1 {
+		t.Fatalf("expected SAVE_DOCPROPS_NOT_SCRUBBED, got %#v", doc.Alerts())
+	}
+}
+
+func TestForceFullCalcOnLoad(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Output.ForceFullCalcOnLoad = true
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	workbook := readEmbeddedWorkbook(t, output, rangeWorkbookPath)
+	reader, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatalf("open embedded workbook: %v", err)
+	}
+	for _, file := range reader.File {
+		if file.Name != "xl/workbook.xml" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		if !strings.Contains(string(data), `fullCalcOnLoad="1"`) {
+			t.Fatalf("workbook.xml lacks fullCalcOnLoad:\n%s", data)
+		}
+		return
+	}
+	t.Fatalf("workbook.xml not found")
+}
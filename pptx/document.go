@@ -2,13 +2,19 @@ package pptx
 
 import (
 	"bytes"
+	"compress/flate"
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"why-pptx/internal/chartcache"
 	"why-pptx/internal/chartdiscover"
@@ -26,6 +32,23 @@ type Alert struct {
 	Code    string
 	Message string
 	Context map[string]string
+	// Timestamp is when the document recorded the alert, in UTC. It is set
+	// once and never changes, so alerts can be matched to the call that
+	// raised them by comparing it with the call's start and end.
+	Timestamp time.Time
+}
+
+// clone returns a copy of a whose Context can be changed freely.
+func (a Alert) clone() Alert {
+	if a.Context == nil {
+		return a
+	}
+	ctx := make(map[string]string, len(a.Context))
+	for key, value := range a.Context {
+		ctx[key] = value
+	}
+	a.Context = ctx
+	return a
 }
 
 type Logger interface {
@@ -45,29 +68,100 @@ type Document struct {
 	strict    bool
 	opts      Options
 	exporters *ExporterRegistry
-}
+	stats     Stats
+	charts    chartModels
+	manifest  manifestState
+	// cacheSyncs logs every committed cache sync, see CacheSyncResults.
+	cacheSyncs []CacheSyncResult
+	// alertHandler is set by WithAlertHandler.
+	alertHandler func(Alert)
+	// checkpoints holds the checkpoints Rollback can return to.
+	checkpoints checkpoints
+	// partsTooLarge holds the parts PACKAGE_PART_TOO_LARGE was reported
+	// for, keyed by workbook and part name.
+	partsTooLarge map[string]bool
+	// cellsReported holds the cells EXTRACT_FORMULA_CELL_VALUE_USED and
+	// EXTRACT_CELL_ERROR_VALUE were reported for, keyed by code, workbook,
+	// sheet, and cell.
+	cellsReported map[string]bool
+	// mu guards alerts, stats, charts, partsTooLarge, cellsReported, and
+	// the lazily built exporters, the state read paths update, so reads can
+	// run from several goroutines.
+	mu sync.Mutex
+}
+
+// ChartSource is the kind of part that shows a chart.
+type ChartSource string
+
+const (
+	SourceSlide ChartSource = "slide"
+	// SourceLayout and SourceMaster charts sit on a slide layout or master
+	// and so show on every slide built from it; SlidePath is the layout or
+	// master part.
+	SourceLayout ChartSource = "layout"
+	SourceMaster ChartSource = "master"
+	SourceNotes  ChartSource = "notes"
+)
 
 type EmbeddedChart struct {
-	SlidePath    string
+	// SlidePath is the part that shows the chart, a slide unless Source
+	// says otherwise.
+	SlidePath string
+	Source    ChartSource
+	// SlideIndex is the 1-based position of the slide in the presentation,
+	// or 0 for a slide the presentation does not list.
+	SlideIndex   int
 	ChartPath    string
 	WorkbookPath string
 }
 
+// embeddedChartFrom returns the EmbeddedChart of a discovered chart.
+func embeddedChartFrom(chart chartdiscover.EmbeddedChart) EmbeddedChart {
+	return EmbeddedChart{
+		SlidePath:    chart.SlidePath,
+		Source:       ChartSource(chart.Source),
+		SlideIndex:   chart.SlideIndex,
+		ChartPath:    chart.ChartPath,
+		WorkbookPath: chart.WorkbookPath,
+	}
+}
+
 type ChartRangeKind string
 
 const (
 	RangeCategories ChartRangeKind = "categories"
 	RangeValues     ChartRangeKind = "values"
 	RangeSeriesName ChartRangeKind = "seriesName"
+	// RangeDataLabels is a series' data label range (c15:datalabelsRange),
+	// the cells its data labels show instead of the values.
+	RangeDataLabels ChartRangeKind = "dataLabels"
+	// RangeXValues and RangeYValues are the x and y values of a scatter
+	// series, which has no categories.
+	RangeXValues ChartRangeKind = "xValues"
+	RangeYValues ChartRangeKind = "yValues"
 )
 
 type ChartRange struct {
-	Kind        ChartRangeKind
+	Kind ChartRangeKind
+	// SeriesIndex is the series' place in the chart's c:order, which is its
+	// position in the chart XML unless the series were reordered.
 	SeriesIndex int
-	Sheet       string
-	StartCell   string
-	EndCell     string
-	Formula     string
+	// OriginalIndex is the series' c:idx. It equals SeriesIndex unless
+	// series were deleted in PowerPoint, which leaves gaps in c:idx.
+	OriginalIndex int
+	// UnionIndex is the position of the range within a union formula such
+	// as "(Sheet1!$B$2:$B$5,Sheet1!$B$8:$B$10)". Each sub-range is its own
+	// ChartRange with the same Kind, SeriesIndex, and Formula, listed in
+	// UnionIndex order; a plain range has UnionIndex 0.
+	UnionIndex int
+	Sheet      string
+	StartCell  string
+	EndCell    string
+	Formula    string
+	// Error is set by GetChartDependencies when the cells of the range
+	// cannot be read: a cell past the worksheet grid, or a rectangular
+	// range that is not a categories range. Writes to the chart fail.
+	Error string `json:",omitempty"`
 }
 
 type ChartDependencies struct {
@@ -76,6 +170,18 @@ type ChartDependencies struct {
 	WorkbookPath string
 	ChartType    string
 	Ranges       []ChartRange
+	// SeriesAxes maps a series index to "primary" or "secondary" when the
+	// chart has more than one value axis; it is nil otherwise.
+	SeriesAxes map[int]string
+	// Axes lists the chart's axis pairs with their orientation.
+	Axes []ChartAxis
+	// Fingerprint identifies the chart's structure: type, workbook, and
+	// ranges, but not values. It is "v1:" plus 32 hex digits; a given
+	// structure keeps its fingerprint across releases, and a change to the
+	// hash input comes with a new version prefix.
+	Fingerprint string
+	// CellCount sums the cells covered by Ranges.
+	CellCount int
 }
 
 type mixedWriteSeries struct {
@@ -125,17 +231,244 @@ type CellUpdate struct {
 }
 
 type Options struct {
-	Mode     ErrorMode
-	Chart    ChartOptions
-	Workbook WorkbookOptions
+	Mode      ErrorMode
+	Discovery DiscoveryOptions
+	Chart     ChartOptions
+	Workbook  WorkbookOptions
+	Limits    LimitsOptions
+	Save      SaveOptions
+	Output    OutputOptions
+	Privacy   PrivacyOptions
+	Input     InputOptions
+	Extract   ExtractOptions
+	Export    ExportOptions
+}
+
+type DiscoveryOptions struct {
+	// LintCharts makes OpenFile check every chart part for the structure the
+	// write path relies on and report violations as CHART_LINT_* info alerts.
+	LintCharts bool
+	// PartNameOrder lists slides by part name and charts by relationship id,
+	// the discovery order of earlier releases. By default discovery follows
+	// the presentation's slide order and each slide's shape order.
+	PartNameOrder bool
+}
+
+func (d *Document) discoveryOrder() chartdiscover.Order {
+	if d.opts.Discovery.PartNameOrder {
+		return chartdiscover.OrderPartName
+	}
+	return chartdiscover.OrderPresentation
 }
 
 type ChartOptions struct {
 	CacheSync bool
+	// AnnotationStaleThreshold is the relative value change (0.2 = 20%)
+	// past which a write to a chart with userShapes callouts reports
+	// CHART_ANNOTATIONS_MAY_BE_STALE. Zero disables the check.
+	AnnotationStaleThreshold float64
+	// AllowExpressions lets ApplyChartData values be relative updates such
+	// as "+5%" or "=prev*1.05", evaluated against the cell's current
+	// workbook value. See valueExpression for the grammar.
+	AllowExpressions bool
+	// MaxCachePoints skips the cache sync of a chart whose categories or
+	// values formula covers more cells than this, so a huge range does not
+	// write megabytes of cached points. The chart is reported with
+	// CHART_CACHE_POINTS_EXCEEDED in both modes and its caches are left as
+	// they were; ApplyChartData still writes the workbook. Zero disables
+	// the cap.
+	MaxCachePoints int
+	// AllowResize lets ApplyChartData take more or fewer points than the
+	// chart's ranges hold. The categories and values formulas are moved to
+	// the new extent, growing down a column or along a row from their start
+	// cell; cells a range gives up are cleared, and the caches are always
+	// synced. Mixed charts still need matching lengths.
+	AllowResize bool
+	// RequireAllSeries makes ApplyChartData and PlanChanges refuse data
+	// that leaves out the categories or a values series. By default such
+	// data is a partial update: the cells and caches of what it leaves out
+	// are not touched, and only the supplied series are validated. A mixed
+	// chart still syncs the caches of every series from the workbook.
+	RequireAllSeries bool
+}
+
+type LimitsOptions struct {
+	// PerChartTimeout bounds the time spent extracting, syncing, and
+	// validating a single chart. Zero disables the limit.
+	PerChartTimeout time.Duration
+	// MaxPartSize bounds the uncompressed size of any package part read
+	// from the file, guarding against zip bombs. A part whose zip header
+	// already claims more is rejected before it is inflated; the header is
+	// not trusted, so a part that inflates past the limit anyway fails too.
+	// Reads fail with a *PartTooLargeError, which wraps ErrPartTooLarge, and
+	// BestEffort records a PACKAGE_PART_TOO_LARGE alert. The parts of an
+	// embedded workbook are bounded the same way. DefaultOptions sets
+	// DefaultMaxPartSize; zero disables the limit.
+	MaxPartSize int64
+	// MaxTotalUncompressed bounds the uncompressed bytes of all the parts
+	// read from the package, each part counted once at the size its zip
+	// header declares, a read past that being refused anyway. Each opening
+	// of an embedded workbook has a budget of its own for its parts. Parts
+	// copied to the output unread, such as media, do not count. Reads over
+	// it fail as for MaxPartSize. DefaultOptions sets
+	// DefaultMaxTotalUncompressed; zero disables the limit.
+	MaxTotalUncompressed int64
+	// MaxChartCount is the most charts discovery lists; the charts of later
+	// slides are ignored and a CHART_COUNT_LIMIT_REACHED warning is
+	// recorded. DefaultOptions sets DefaultMaxChartCount; zero disables the
+	// limit.
+	MaxChartCount int
+}
+
+// ErrPartTooLarge is wrapped by the error of a part read that exceeds
+// Options.Limits.MaxPartSize or MaxTotalUncompressed.
+var ErrPartTooLarge = ooxmlpkg.ErrPartTooLarge
+
+type SaveOptions struct {
+	// WriteChangeManifest makes SaveFile append a record of the changes made
+	// since the last save to a JSON part inside the package. See ChangeManifest.
+	WriteChangeManifest bool
+	// MaxOutputBytes is a soft limit on the size of the file SaveFile
+	// writes. The save always completes; a larger file is reported with a
+	// SAVE_OUTPUT_SIZE_EXCEEDED warning that lists the largest parts and
+	// their growth since OpenFile. Zero disables the check.
+	MaxOutputBytes int64
+}
+
+// OutputOptions controls how SaveFile and the embedded workbook writes zip
+// the parts they rewrite or add. Parts left untouched are copied as they
+// are, compression and headers included.
+type OutputOptions struct {
+	CompressionLevel CompressionLevel
+	// Deterministic writes every rewritten or added part with a fixed
+	// 1980-01-01 modification time, no extra fields, and only the UTF-8
+	// header flag, and new parts in name order, so the same input file and
+	// edits save to the same bytes. The change manifest records save times,
+	// so leave Save.WriteChangeManifest off for byte-identical output.
+	Deterministic bool
+	// ScrubDocProps makes saves set cp:lastModifiedBy in the core
+	// properties (docProps/core.xml) to LastModifiedBy and dcterms:modified
+	// to Modified, or to the time of the save when Modified is zero, so the
+	// deck does not keep the name of whoever last edited it in PowerPoint.
+	// Set Modified for byte-identical output.
+	ScrubDocProps  bool
+	LastModifiedBy string
+	Modified       time.Time
+	// ForceFullCalcOnLoad sets fullCalcOnLoad="1" on the calcPr of every
+	// embedded workbook a write rewrites, so Excel recalculates the
+	// formulas that depend on the written cells when it next opens the
+	// workbook.
+	ForceFullCalcOnLoad bool
+}
+
+// CompressionLevel is how hard rewritten and added parts are compressed.
+type CompressionLevel int
+
+const (
+	// CompressionDefault deflates at the default level and keeps the
+	// method of a rewritten part.
+	CompressionDefault CompressionLevel = iota
+	// CompressionStore writes parts uncompressed.
+	CompressionStore
+	// CompressionFast deflates at the fastest level.
+	CompressionFast
+	// CompressionBest deflates at the smallest size.
+	CompressionBest
+)
+
+// packageOutput maps Options.Output to the zip writer settings.
+func (d *Document) packageOutput() ooxmlpkg.Output {
+	out := ooxmlpkg.Output{Deterministic: d.opts.Output.Deterministic}
+	switch d.opts.Output.CompressionLevel {
+	case CompressionStore:
+		out.Store = true
+	case CompressionFast:
+		out.Level = flate.BestSpeed
+	case CompressionBest:
+		out.Level = flate.BestCompression
+	}
+	return out
+}
+
+// saveWorkbook saves an embedded workbook with Options.Output, forcing a
+// full recalculation under ForceFullCalcOnLoad.
+func (d *Document) saveWorkbook(wb *xlsxembed.Workbook) ([]byte, error) {
+	if d.opts.Output.ForceFullCalcOnLoad {
+		if err := wb.SetFullCalcOnLoad(); err != nil {
+			return nil, err
+		}
+	}
+	wb.SetOutput(d.packageOutput())
+	return wb.Save()
+}
+
+type PrivacyOptions struct {
+	// RedactContextKeys lists alert context keys, such as "path" or
+	// "workbook", whose values Document.AlertSummary replaces in exemplars
+	// so summaries can leave the process. Alerts() is never redacted.
+	RedactContextKeys []string
+}
+
+type InputOptions struct {
+	// NumberFormat is how ApplyChartData and PlanChanges read the numbers
+	// of a ChartDataInput, such as "1.234,56" under
+	// NumberFormatDecimalComma. Numbers are written to the workbook, and
+	// from there to the caches, canonically. The default is
+	// NumberFormatCanonical.
+	NumberFormat NumberFormat
+	// CoerceNumbers accepts values NumberFormat alone rejects: spaces are
+	// removed, so "1 000" is a thousand, and a trailing percent sign reads
+	// "12%" as 0.12. "N/A", "-", and "" are missing points, written as
+	// blank cells or as 0 under Workbook.MissingNumericPolicy. Each series
+	// with a coerced value gets one CHART_DATA_NUMBERS_COERCED alert.
+	CoerceNumbers bool
+}
+
+type ExtractOptions struct {
+	// UseCacheFallback extracts a chart whose workbook cannot be reached,
+	// such as a linked one, from the strCache and numCache data in the
+	// chart XML instead of skipping it. Its skip alert is kept at info
+	// level, and Meta.Source is ExtractSourceCache.
+	UseCacheFallback bool
+	// ConvertDates rewrites category labels that are date serials, such as
+	// "45292", as ISO-8601 dates ("2024-01-01") when the categories carry a
+	// date number format. ExtractedChartData.RawLabels keeps the serials.
+	ConvertDates bool
+}
+
+type ExportOptions struct {
+	// CSVDelimiter is the field delimiter of the built-in "csv" exporter;
+	// zero means ','. Set ';' for Excel locales that use a decimal comma.
+	CSVDelimiter rune
 }
 
 type WorkbookOptions struct {
 	MissingNumericPolicy MissingNumericPolicy
+	// MaxRowsPerWrite bounds how many rows one SetWorkbookCells or
+	// ApplyChartData call may add to a worksheet beyond those it already
+	// has. The call is rejected before any cell is written. Zero disables
+	// the limit.
+	MaxRowsPerWrite int
+	// MaxWorkbookBytes bounds the size of a rewritten embedded workbook; a
+	// write that would grow it past the limit is rolled back. Zero disables
+	// the limit.
+	//
+	// Both limits, and writes past the last worksheet row or column, fail
+	// with an error even in BestEffort: they indicate a caller bug.
+	MaxWorkbookBytes int64
+	// ConvertSharedStrings lets ApplyChartData write to workbooks that use
+	// xl/sharedStrings.xml. Every written cell is stored as an inline string
+	// or a number, and cells outside the written ranges keep their shared
+	// strings. Postflight then only checks the written cells instead of
+	// rejecting the workbook with POSTFLIGHT_XLSX_SHAREDSTRINGS_DETECTED.
+	ConvertSharedStrings bool
+	// RejectFormulaCells makes reads of a numeric formula cell fail with
+	// EXTRACT_UNSUPPORTED_CELL_TYPE instead of returning the result cached
+	// in its <v> with an EXTRACT_FORMULA_CELL_VALUE_USED alert.
+	RejectFormulaCells bool
+	// BoolWords reads boolean cells as "TRUE" and "FALSE" instead of the
+	// "1" and "0" they store.
+	BoolWords bool
 }
 
 type MissingNumericPolicy int
@@ -153,23 +486,66 @@ const (
 )
 
 // DefaultOptions returns stable defaults for production use:
-// Mode=Strict, Chart.CacheSync=true, Workbook.MissingNumericPolicy=MissingNumericEmpty.
+// Mode=Strict, Chart.CacheSync=true, Chart.AnnotationStaleThreshold=0.2,
+// Workbook.MissingNumericPolicy=MissingNumericEmpty.
 func DefaultOptions() Options {
 	return Options{
 		Mode:  Strict,
-		Chart: ChartOptions{CacheSync: true},
+		Chart: ChartOptions{CacheSync: true, AnnotationStaleThreshold: 0.2},
 		Workbook: WorkbookOptions{
 			MissingNumericPolicy: MissingNumericEmpty,
 		},
+		Limits: LimitsOptions{
+			MaxPartSize:          DefaultMaxPartSize,
+			MaxTotalUncompressed: DefaultMaxTotalUncompressed,
+			MaxChartCount:        DefaultMaxChartCount,
+		},
 	}
 }
 
+// OpenFile opens the presentation at path. Parts are read from the file as
+// they are needed and untouched parts, such as embedded media, are copied to
+// the output without being inflated, so memory grows with the parts the
+// session reads and writes rather than with the file. The file stays open
+// until Close and must not be modified meanwhile; saving over it with
+// SaveFile is fine, since the output is renamed into place, except on
+// Windows, where an open file cannot be replaced.
 func OpenFile(path string, opts ...Option) (*Document, error) {
 	pkg, err := ooxmlpkg.OpenFile(path)
 	if err != nil {
 		return nil, err
 	}
+	doc, err := newDocument(pkg, opts)
+	if err != nil {
+		_ = pkg.Close()
+		return nil, err
+	}
+	return doc, nil
+}
 
+// Open reads a document held in memory, as OpenFile reads one from disk.
+// The document reads its parts from data until it is discarded, so data
+// must not be modified meanwhile.
+func Open(data []byte, opts ...Option) (*Document, error) {
+	pkg, err := ooxmlpkg.Open(data)
+	if err != nil {
+		return nil, err
+	}
+	return newDocument(pkg, opts)
+}
+
+// OpenReader reads a document of size bytes from r. Parts are inflated from
+// r as they are read, so r must stay readable until the document is last
+// used, Save and SaveFile included.
+func OpenReader(r io.ReaderAt, size int64, opts ...Option) (*Document, error) {
+	pkg, err := ooxmlpkg.OpenReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return newDocument(pkg, opts)
+}
+
+func newDocument(pkg *ooxmlpkg.Package, opts []Option) (*Document, error) {
 	overlay, err := overlaystage.NewPackageOverlay(pkg)
 	if err != nil {
 		return nil, err
@@ -189,45 +565,137 @@ func OpenFile(path string, opts ...Option) (*Document, error) {
 	if doc.exporters == nil {
 		doc.exporters = defaultExporterRegistry(doc.opts)
 	}
+	pkg.SetLimits(doc.packageLimits(), func(err *ooxmlpkg.PartTooLargeError) {
+		doc.newCall(nil).reportPartTooLarge("", err)
+	})
+	pkg.SetOutput(doc.packageOutput())
+	if doc.opts.Discovery.LintCharts {
+		doc.lintCharts()
+	}
 
 	return doc, nil
 }
 
 func (d *Document) SaveFile(path string) error {
-	return d.pkg.SaveFile(path)
+	return d.SaveFileContext(context.Background(), path)
+}
+
+// SaveFileContext is SaveFile checking ctx between the parts it writes.
+// Once ctx is done it returns a *CanceledError naming the next part and
+// leaves path as it was; the document is unchanged and can be saved again.
+func (d *Document) SaveFileContext(ctx context.Context, path string) error {
+	call := d.newCall(nil)
+	if err := ctx.Err(); err != nil {
+		return &CanceledError{Operation: "save", Err: err}
+	}
+	return call.save(path, func() (int64, error) {
+		if err := d.pkg.SaveFileContext(ctx, path); err != nil {
+			return 0, saveCanceled(err)
+		}
+		stat, err := os.Stat(path)
+		if err != nil {
+			return -1, nil
+		}
+		return stat.Size(), nil
+	})
+}
+
+// Save returns the document as SaveFile would write it.
+func (d *Document) Save() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.SaveTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveTo writes the document to w as SaveFile would write it to a file,
+// without a temporary file. When it fails, w may hold part of the output but
+// the document is unchanged, and no change manifest run is recorded, so the
+// save can be retried with another writer.
+func (d *Document) SaveTo(w io.Writer) error {
+	call := d.newCall(nil)
+	return call.save("", func() (int64, error) {
+		counter := &countingWriter{w: w}
+		if err := d.pkg.SaveTo(counter); err != nil {
+			return 0, err
+		}
+		return counter.n, nil
+	})
+}
+
+// save runs write with the scrubbed core properties and the change manifest
+// in place and checks the size it reports, -1 when unknown, against
+// Options.Save.MaxOutputBytes. path names the output in the size alert and
+// is empty for in-memory saves.
+func (d *docCall) save(path string, write func() (int64, error)) error {
+	if d.Document == nil || d.pkg == nil {
+		return fmt.Errorf("document not initialized")
+	}
+	if d.opts.Output.ScrubDocProps {
+		if err := d.scrubDocProps(); err != nil {
+			return err
+		}
+	}
+	if !d.opts.Save.WriteChangeManifest {
+		size, err := write()
+		if err != nil {
+			return err
+		}
+		d.checkOutputSize(path, size)
+		return nil
+	}
+
+	run, err := d.writeChangeManifest()
+	if err != nil {
+		return err
+	}
+	size, err := write()
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.manifest.saved(run, len(d.alerts))
+	d.mu.Unlock()
+	d.checkOutputSize(path, size)
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
 }
 
-func (d *Document) GetChartDependencies() ([]ChartDependencies, error) {
+func (d *Document) GetChartDependencies(opts ...CallOption) ([]ChartDependencies, error) {
 	if d == nil || d.pkg == nil {
 		return nil, fmt.Errorf("document not initialized")
 	}
+	return d.newCall(opts).allChartDependencies()
+}
 
-	charts, err := d.DiscoverEmbeddedCharts()
+// allChartDependencies is GetChartDependencies within call d, so the
+// options of a caller such as SyncChartCaches carry over.
+func (d *docCall) allChartDependencies() ([]ChartDependencies, error) {
+	charts, err := d.discoverCharts()
 	if err != nil {
 		return nil, err
 	}
 
 	deps := make([]ChartDependencies, 0, len(charts))
 	for _, chart := range charts {
-		dep, err := d.extractChartDependencies(chart)
+		dep, ok, err := d.chartDependencies(chart)
 		if err != nil {
-			if d.opts.Mode == BestEffort {
-				d.addAlert(Alert{
-					Level:   "warn",
-					Code:    "CHART_DEPENDENCIES_PARSE_FAILED",
-					Message: "Failed to extract chart dependencies; chart is skipped",
-					Context: map[string]string{
-						"slide":    chart.SlidePath,
-						"chart":    chart.ChartPath,
-						"workbook": chart.WorkbookPath,
-						"error":    err.Error(),
-					},
-				})
-				continue
-			}
 			return nil, err
 		}
-		deps = append(deps, dep)
+		if ok {
+			deps = append(deps, dep)
+		}
 	}
 
 	if len(deps) == 0 {
@@ -236,43 +704,102 @@ func (d *Document) GetChartDependencies() ([]ChartDependencies, error) {
 	return deps, nil
 }
 
-func (d *Document) extractChartDependencies(chart EmbeddedChart) (ChartDependencies, error) {
+// chartDependencies extracts one chart's dependencies under the chart guard.
+// In BestEffort a failure is alerted and reported as ok=false.
+func (d *docCall) chartDependencies(chart EmbeddedChart) (ChartDependencies, bool, error) {
+	var dep ChartDependencies
+	err := d.guardChart("dependencies", chart.SlidePath, chart.ChartPath, chart.WorkbookPath, func() error {
+		var err error
+		dep, err = d.extractChartDependencies(chart)
+		return err
+	})
+	if errors.Is(err, ErrChartProcessingTimeout) {
+		if d.mode() == BestEffort {
+			return ChartDependencies{}, false, nil
+		}
+		return ChartDependencies{}, false, err
+	}
+	if err != nil {
+		if d.mode() == BestEffort {
+			context := map[string]string{
+				"slide":    chart.SlidePath,
+				"chart":    chart.ChartPath,
+				"workbook": chart.WorkbookPath,
+				"error":    err.Error(),
+			}
+			code := dependencyFailureCode(err, context)
+			d.addAlert(Alert{
+				Level:   "warn",
+				Code:    code,
+				Message: extractMessageForCode(code),
+				Context: context,
+			})
+			return ChartDependencies{}, false, nil
+		}
+		return ChartDependencies{}, false, err
+	}
+	return dep, true, nil
+}
+
+func (d *docCall) extractChartDependencies(chart EmbeddedChart) (ChartDependencies, error) {
 	data, err := d.pkg.ReadPart(chart.ChartPath)
 	if err != nil {
 		return ChartDependencies{}, fmt.Errorf("read chart %q: %w", chart.ChartPath, err)
 	}
 
-	parsed, err := chartxml.Parse(bytes.NewReader(data))
+	parsed, err := d.parsedChart(chart.ChartPath, data)
 	if err != nil {
 		return ChartDependencies{}, fmt.Errorf("parse chart %q: %w", chart.ChartPath, err)
 	}
 
 	ranges := make([]ChartRange, 0, len(parsed.Formulas))
+	whole := &wholeRangeResolver{d: d, workbookPath: chart.WorkbookPath}
 	for _, formula := range parsed.Formulas {
-		if formula.Kind != chartxml.KindCategories && formula.Kind != chartxml.KindValues && formula.Kind != chartxml.KindSeriesName {
+		switch formula.Kind {
+		case chartxml.KindCategories, chartxml.KindValues, chartxml.KindSeriesName, chartxml.KindDataLabels, chartxml.KindXValues, chartxml.KindYValues:
+		default:
 			return ChartDependencies{}, fmt.Errorf("unknown chart formula kind %q in %s", formula.Kind, chart.ChartPath)
 		}
-		ref, err := xlref.ParseA1Range(formula.Formula)
+		refs, err := xlref.ParseA1Union(formula.Formula)
 		if err != nil {
 			return ChartDependencies{}, fmt.Errorf("parse chart formula %q in %s: %w", formula.Formula, chart.ChartPath, err)
 		}
 
-		ranges = append(ranges, ChartRange{
-			Kind:        ChartRangeKind(formula.Kind),
-			SeriesIndex: formula.SeriesIndex,
-			Sheet:       ref.Sheet,
-			StartCell:   ref.StartCell,
-			EndCell:     ref.EndCell,
-			Formula:     formula.Formula,
-		})
+		originalIndex, ok := parsed.SeriesIdx[formula.SeriesIndex]
+		if !ok {
+			originalIndex = formula.SeriesIndex
+		}
+		for i, ref := range refs {
+			ref, err := whole.narrow(ref, formula.PtCount)
+			if err != nil {
+				return ChartDependencies{}, fmt.Errorf("parse chart formula %q in %s: %w", formula.Formula, chart.ChartPath, err)
+			}
+			r := ChartRange{
+				Kind:          ChartRangeKind(formula.Kind),
+				SeriesIndex:   formula.SeriesIndex,
+				OriginalIndex: originalIndex,
+				UnionIndex:    i,
+				Sheet:         ref.Sheet,
+				StartCell:     ref.StartCell,
+				EndCell:       ref.EndCell,
+				Formula:       formula.Formula,
+			}
+			r.Error = chartRangeError(r)
+			ranges = append(ranges, r)
+		}
 	}
 
+	fingerprint, cellCount := chartFingerprint(parsed.ChartType, chart.WorkbookPath, ranges)
 	return ChartDependencies{
 		SlidePath:    chart.SlidePath,
 		ChartPath:    chart.ChartPath,
 		WorkbookPath: chart.WorkbookPath,
 		ChartType:    parsed.ChartType,
 		Ranges:       ranges,
+		SeriesAxes:   maps.Clone(parsed.SeriesAxes),
+		Axes:         chartAxes(parsed.Plots, parsed.AxisGroups),
+		Fingerprint:  fingerprint,
+		CellCount:    cellCount,
 	}, nil
 }
 
@@ -280,57 +807,20 @@ func (d *Document) DiscoverEmbeddedCharts() ([]EmbeddedChart, error) {
 	if d == nil || d.pkg == nil {
 		return nil, fmt.Errorf("document not initialized")
 	}
+	return d.newCall(nil).discoverCharts()
+}
 
-	embedded, skipped, err := chartdiscover.DiscoverEmbeddedCharts(d.pkg)
+// discoverCharts is DiscoverEmbeddedCharts for callers already inside a
+// call.
+func (d *docCall) discoverCharts() ([]EmbeddedChart, error) {
+	embedded, skipped, err := d.discoverEmbeddedCharts()
 	if err != nil {
 		return nil, err
 	}
 
 	for _, skip := range skipped {
-		switch skip.Reason {
-		case chartdiscover.ReasonLinked:
-			d.addAlert(Alert{
-				Level:   "warn",
-				Code:    "CHART_LINKED_WORKBOOK",
-				Message: "Chart uses linked workbook and is skipped",
-				Context: map[string]string{
-					"slide":  skip.SlidePath,
-					"chart":  skip.ChartPath,
-					"target": skip.Target,
-				},
-			})
-		case chartdiscover.ReasonRelsMissing:
-			d.addAlert(Alert{
-				Level:   "warn",
-				Code:    "CHART_RELS_MISSING",
-				Message: "Chart relationships file is missing; chart is skipped",
-				Context: map[string]string{
-					"slide":     skip.SlidePath,
-					"chart":     skip.ChartPath,
-					"rels_path": skip.RelsPath,
-				},
-			})
-		case chartdiscover.ReasonWorkbookNotFound:
-			d.addAlert(Alert{
-				Level:   "warn",
-				Code:    "CHART_WORKBOOK_NOT_FOUND",
-				Message: "No workbook relationship found for chart; chart is skipped",
-				Context: map[string]string{
-					"slide": skip.SlidePath,
-					"chart": skip.ChartPath,
-				},
-			})
-		case chartdiscover.ReasonUnsupported:
-			d.addAlert(Alert{
-				Level:   "warn",
-				Code:    "CHART_WORKBOOK_UNSUPPORTED_TARGET",
-				Message: "Chart workbook target is unsupported; chart is skipped",
-				Context: map[string]string{
-					"slide":  skip.SlidePath,
-					"chart":  skip.ChartPath,
-					"target": skip.Target,
-				},
-			})
+		if alert, ok := lookupSkipReason(skip).alert(skip); ok {
+			d.addAlert(alert)
 		}
 	}
 
@@ -340,11 +830,7 @@ func (d *Document) DiscoverEmbeddedCharts() ([]EmbeddedChart, error) {
 
 	out := make([]EmbeddedChart, len(embedded))
 	for i, item := range embedded {
-		out[i] = EmbeddedChart{
-			SlidePath:    item.SlidePath,
-			ChartPath:    item.ChartPath,
-			WorkbookPath: item.WorkbookPath,
-		}
+		out[i] = embeddedChartFrom(item)
 	}
 
 	return out, nil
@@ -354,6 +840,7 @@ func (d *Document) SetWorkbookCells(updates []CellUpdate) error {
 	if d == nil || d.pkg == nil {
 		return fmt.Errorf("document not initialized")
 	}
+	call := d.newCall(nil)
 	if len(updates) == 0 {
 		return nil
 	}
@@ -363,9 +850,18 @@ func (d *Document) SetWorkbookCells(updates []CellUpdate) error {
 		updatesByWorkbook[update.WorkbookPath] = append(updatesByWorkbook[update.WorkbookPath], update)
 	}
 
+	// Every workbook is rewritten before any is written back, so a write
+	// limit hit by one leaves all of them as they were.
+	type workbookWrite struct {
+		path    string
+		updates []CellUpdate
+		wb      *xlsxembed.Workbook
+		data    []byte
+	}
+	var writes []workbookWrite
 	for workbookPath, wbUpdates := range updatesByWorkbook {
 		if workbookPath == "" {
-			if err := d.handleWorkbookUpdateError(CellUpdate{}, fmt.Errorf("workbook path is required")); err != nil {
+			if err := call.handleWorkbookUpdateError(CellUpdate{}, fmt.Errorf("workbook path is required")); err != nil {
 				return err
 			}
 			continue
@@ -373,20 +869,28 @@ func (d *Document) SetWorkbookCells(updates []CellUpdate) error {
 
 		data, err := d.pkg.ReadPart(workbookPath)
 		if err != nil {
-			if err := d.handleWorkbookUpdateError(wbUpdates[0], fmt.Errorf("read workbook %q: %w", workbookPath, err)); err != nil {
+			if err := call.handleWorkbookUpdateError(wbUpdates[0], fmt.Errorf("read workbook %q: %w", workbookPath, err)); err != nil {
 				return err
 			}
 			continue
 		}
 
-		wb, err := xlsxembed.Open(data)
+		wb, err := call.openEmbeddedWorkbook(workbookPath, data)
 		if err != nil {
-			if err := d.handleWorkbookUpdateError(wbUpdates[0], fmt.Errorf("open workbook %q: %w", workbookPath, err)); err != nil {
+			if err := call.handleWorkbookUpdateError(wbUpdates[0], fmt.Errorf("open workbook %q: %w", workbookPath, err)); err != nil {
 				return err
 			}
 			continue
 		}
+		if err := call.checkWorkbookWrite(workbookPath, wb, wbUpdates); err != nil {
+			return err
+		}
+		writes = append(writes, workbookWrite{path: workbookPath, updates: wbUpdates, wb: wb})
+	}
 
+	saved := writes[:0]
+	for _, write := range writes {
+		workbookPath, wbUpdates, wb := write.path, write.updates, write.wb
 		applyFailed := false
 		var applyErr error
 		var failedUpdate CellUpdate
@@ -414,6 +918,12 @@ func (d *Document) SetWorkbookCells(updates []CellUpdate) error {
 				failedUpdate = update
 				break
 			}
+			if err := call.checkCellText(update); err != nil {
+				applyFailed = true
+				applyErr = err
+				failedUpdate = update
+				break
+			}
 
 			if err := wb.SetCell(update.Sheet, update.Cell, xlsxembed.CellValue{
 				Number: update.Value.Number,
@@ -427,27 +937,35 @@ func (d *Document) SetWorkbookCells(updates []CellUpdate) error {
 		}
 
 		if applyFailed {
-			if err := d.handleWorkbookUpdateError(failedUpdate, fmt.Errorf("update workbook %q: %w", workbookPath, applyErr)); err != nil {
+			if err := call.handleWorkbookUpdateError(failedUpdate, fmt.Errorf("update workbook %q: %w", workbookPath, applyErr)); err != nil {
 				return err
 			}
 			continue
 		}
 
-		newBytes, err := wb.Save()
+		newBytes, err := d.saveWorkbook(wb)
 		if err != nil {
-			if err := d.handleWorkbookUpdateError(wbUpdates[0], fmt.Errorf("save workbook %q: %w", workbookPath, err)); err != nil {
+			if err := call.handleWorkbookUpdateError(wbUpdates[0], fmt.Errorf("save workbook %q: %w", workbookPath, err)); err != nil {
 				return err
 			}
 			continue
 		}
+		if err := d.checkWorkbookSize(workbookPath, newBytes); err != nil {
+			return err
+		}
+		write.data = newBytes
+		saved = append(saved, write)
+	}
 
-		d.pkg.WritePart(workbookPath, newBytes)
+	for _, write := range saved {
+		d.pkg.WritePart(write.path, write.data)
+		d.manifest.record(workbookCellsChange(write.path, write.updates))
 	}
 
 	return nil
 }
 
-func (d *Document) setWorkbookCellsInOverlay(overlay overlaystage.Overlay, updates []CellUpdate) error {
+func (d *docCall) setWorkbookCellsInOverlay(overlay overlaystage.Overlay, updates []CellUpdate) error {
 	if d == nil || overlay == nil {
 		return fmt.Errorf("overlay not initialized")
 	}
@@ -470,10 +988,13 @@ func (d *Document) setWorkbookCellsInOverlay(overlay overlaystage.Overlay, updat
 			return fmt.Errorf("read workbook %q: %w", workbookPath, err)
 		}
 
-		wb, err := xlsxembed.Open(data)
+		wb, err := d.openEmbeddedWorkbook(workbookPath, data)
 		if err != nil {
 			return fmt.Errorf("open workbook %q: %w", workbookPath, err)
 		}
+		if err := d.checkWorkbookWrite(workbookPath, wb, wbUpdates); err != nil {
+			return err
+		}
 
 		for _, update := range wbUpdates {
 			normalized, err := xlref.NormalizeCellRef(update.Cell)
@@ -486,9 +1007,19 @@ func (d *Document) setWorkbookCellsInOverlay(overlay overlaystage.Overlay, updat
 				return fmt.Errorf("sheet name is required")
 			}
 
+			if update.Value.Number == nil && update.Value.String == nil {
+				// A missing CoerceNumbers value clears its cell.
+				if err := wb.ClearCell(update.Sheet, update.Cell); err != nil {
+					return err
+				}
+				continue
+			}
 			if err := validateCellValue(update.Value); err != nil {
 				return err
 			}
+			if err := d.checkCellText(update); err != nil {
+				return err
+			}
 
 			if err := wb.SetCell(update.Sheet, update.Cell, xlsxembed.CellValue{
 				Number: update.Value.Number,
@@ -498,10 +1029,13 @@ func (d *Document) setWorkbookCellsInOverlay(overlay overlaystage.Overlay, updat
 			}
 		}
 
-		newBytes, err := wb.Save()
+		newBytes, err := d.saveWorkbook(wb)
 		if err != nil {
 			return fmt.Errorf("save workbook %q: %w", workbookPath, err)
 		}
+		if err := d.checkWorkbookSize(workbookPath, newBytes); err != nil {
+			return err
+		}
 
 		if err := overlay.Set(workbookPath, newBytes); err != nil {
 			return fmt.Errorf("write workbook %q: %w", workbookPath, err)
@@ -511,74 +1045,52 @@ func (d *Document) setWorkbookCellsInOverlay(overlay overlaystage.Overlay, updat
 	return nil
 }
 
-func (d *Document) SyncChartCaches() error {
+func (d *Document) ApplyChartData(chartIndex int, data map[string][]string) error {
 	if d == nil || d.pkg == nil {
 		return fmt.Errorf("document not initialized")
 	}
-	if !d.opts.Chart.CacheSync {
-		return nil
+	return d.newCall(nil).applyChartData(chartIndex, data)
+}
+
+// applyChartData is the body of ApplyChartData, run in the mode of d.
+func (d *docCall) applyChartData(chartIndex int, data map[string][]string) error {
+	if chartIndex < 0 {
+		return ErrChartIndexOutOfRange
 	}
 
-	deps, err := d.GetChartDependencies()
+	// Index the discovered charts, as ListCharts and ExtractChartData do,
+	// not the charts whose dependencies parsed: in BestEffort a failing
+	// chart would otherwise shift every later index.
+	charts, err := d.discoverCharts()
 	if err != nil {
 		return err
 	}
-	if len(deps) == 0 {
-		return nil
-	}
-
-	for _, dep := range deps {
-		if err := d.validateWritableChart(dep); err != nil {
-			if d.opts.Mode == BestEffort {
-				continue
-			}
-			return err
-		}
-
-		ctx := d.validateContext(dep)
-		err := d.withChartStage(ctx, func(stage overlaystage.Overlay) error {
-			if dep.ChartType == "mixed" {
-				return d.syncMixedChartCacheInOverlay(stage, dep)
-			}
-			return d.syncChartCacheInOverlay(stage, dep)
-		})
-		if err != nil {
-			if postflight.IsPostflightError(err) {
-				return err
-			}
-			if err := d.handleChartCacheError(dep, err); err != nil {
-				return err
-			}
-			continue
-		}
-	}
-
-	return nil
-}
-
-func (d *Document) ApplyChartData(chartIndex int, data map[string][]string) error {
-	if d == nil || d.pkg == nil {
-		return fmt.Errorf("document not initialized")
-	}
-	if chartIndex < 0 {
-		return fmt.Errorf("chart index out of range")
+	if chartIndex >= len(charts) {
+		return ErrChartIndexOutOfRange
 	}
-
-	deps, err := d.GetChartDependencies()
+	dep, ok, err := d.chartDependencies(charts[chartIndex])
 	if err != nil {
 		return err
 	}
-	if chartIndex >= len(deps) {
-		return fmt.Errorf("chart index out of range")
+	if !ok {
+		return fmt.Errorf("chart %q: %w", charts[chartIndex].ChartPath, ErrChartSkipped)
 	}
 
-	dep := deps[chartIndex]
 	if dep.ChartType == "mixed" {
 		return d.applyMixedChartData(chartIndex, dep, data)
 	}
 	if err := d.validateWritableChart(dep); err != nil {
 		return err
 	}
+	seriesKeys := newSeriesKeys(dep.Ranges)
+	valueKeys, keysErr := seriesKeys.resolve(data, d.opts.Chart.RequireAllSeries)
+	var nameKeys map[int]string
+	if keysErr == nil {
+		nameKeys, err = seriesKeys.nameKeys(data, valueKeys)
+		if err != nil {
+			return err
+		}
+	}
 	categories, hasCategories := data["categories"]
 	if hasCategories {
 		categoriesLen := len(categories)
@@ -586,25 +1098,45 @@ func (d *Document) ApplyChartData(chartIndex int, data map[string][]string) erro
 			if r.Kind != RangeValues {
 				continue
 			}
-			key := fmt.Sprintf("values:%d", r.SeriesIndex)
-			values, ok := data[key]
+			values, ok := data[valueKeys[r.SeriesIndex]]
 			if !ok {
 				continue
 			}
 			if len(values) != categoriesLen {
-				return d.handleChartDataMismatch(chartIndex, categoriesLen, len(values), r.SeriesIndex)
+				return d.handleChartDataMismatch(chartIndex, categoriesLen, len(values), r.SeriesIndex, seriesKeys.hint())
 			}
 		}
 	}
+	var resize *chartResize
+	if d.opts.Chart.AllowResize && keysErr == nil {
+		resize, err = resizeChartRanges(dep.Ranges, data, valueKeys)
+		if err != nil {
+			return err
+		}
+		if resize != nil {
+			dep.Ranges = resize.ranges
+		}
+	}
 	updates := make([]CellUpdate, 0)
+	written := make([]string, 0, len(dep.Ranges))
+	supplied := make(map[int]bool)
+	var expressions []pendingExpression
+	coerced := make(numberCoercions)
 
 	for _, r := range dep.Ranges {
+		if r.UnionIndex > 0 {
+			continue
+		}
 		switch r.Kind {
 		case RangeCategories:
 			if !hasCategories {
-				return fmt.Errorf("categories data is required")
+				if d.opts.Chart.RequireAllSeries {
+					return fmt.Errorf("categories data is required")
+				}
+				continue
 			}
-			cells, err := expandRangeCells(r.StartCell, r.EndCell)
+			written = append(written, r.Formula)
+			cells, err := formulaCells(dep.Ranges, r)
 			if err != nil {
 				return err
 			}
@@ -614,76 +1146,137 @@ func (d *Document) ApplyChartData(chartIndex int, data map[string][]string) erro
 			for i, cell := range cells {
 				updates = append(updates, CellUpdate{
 					WorkbookPath: dep.WorkbookPath,
-					Sheet:        r.Sheet,
-					Cell:         cell,
+					Sheet:        cell.sheet,
+					Cell:         cell.cell,
 					Value:        Str(categories[i]),
 				})
 			}
 		case RangeValues:
-			key := fmt.Sprintf("values:%d", r.SeriesIndex)
-			values, ok := data[key]
+			if keysErr != nil {
+				return keysErr
+			}
+			values, ok := data[valueKeys[r.SeriesIndex]]
 			if !ok {
-				return fmt.Errorf("values data missing for series %d", r.SeriesIndex)
+				continue
 			}
-			cells, err := expandRangeCells(r.StartCell, r.EndCell)
+			supplied[r.SeriesIndex] = true
+			written = append(written, r.Formula)
+			cells, err := formulaCells(dep.Ranges, r)
 			if err != nil {
 				return err
 			}
 			if len(values) != len(cells) {
-				return fmt.Errorf("values length mismatch for series %d: expected %d got %d", r.SeriesIndex, len(cells), len(values))
+				return fmt.Errorf("values length mismatch for series %d: expected %d got %d%s", r.SeriesIndex, len(cells), len(values), seriesKeys.hint())
 			}
 			for i, cell := range cells {
-				value := strings.TrimSpace(values[i])
-				number, err := strconv.ParseFloat(value, 64)
+				value, err := d.chartNumericValue(values[i], r.SeriesIndex, i, len(updates), &expressions, coerced)
 				if err != nil {
-					return fmt.Errorf("invalid numeric value %q for series %d", values[i], r.SeriesIndex)
+					return d.handleInvalidNumber(chartIndex, err)
 				}
 				updates = append(updates, CellUpdate{
 					WorkbookPath: dep.WorkbookPath,
-					Sheet:        r.Sheet,
-					Cell:         cell,
-					Value:        Num(number),
+					Sheet:        cell.sheet,
+					Cell:         cell.cell,
+					Value:        value,
 				})
 			}
 		}
 	}
+	nameUpdates, nameFormulas, err := d.seriesNameUpdates(dep, data, nameKeys, seriesNameRanges(dep.Ranges), supplied)
+	if err != nil {
+		return err
+	}
+	updates = append(updates, nameUpdates...)
+	written = append(written, nameFormulas...)
 
+	updates, err = d.evaluateExpressions(dep, updates, expressions)
+	if err != nil {
+		return err
+	}
 	if len(updates) == 0 {
 		return fmt.Errorf("no chart ranges matched")
 	}
 
+	stale := d.checkAnnotationStaleness(dep, updates)
 	ctx := d.validateContext(dep)
-	return d.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	ctx.SharedStringCells = d.sharedStringCells(updates)
+	cacheSync := d.opts.Chart.CacheSync
+	formula, points, overCap := d.cachePointsOverCap(dep)
+	if overCap {
+		cacheSync = false
+	}
+	if resize != nil {
+		// Caches sized for the old formulas would contradict the new ones.
+		cacheSync, overCap = true, false
+		ctx.CacheSyncEnabled = true
+	}
+	var synced []chartRepairRecord
+	committed, err := d.stageChart(ctx, func(stage overlaystage.Overlay) error {
+		if resize != nil {
+			if err := d.resizeChartInOverlay(stage, dep, resize); err != nil {
+				return err
+			}
+		}
 		if err := d.setWorkbookCellsInOverlay(stage, updates); err != nil {
 			return err
 		}
-		if d.opts.Chart.CacheSync {
-			return d.syncChartCacheInOverlay(stage, dep)
+		d.manifest.stage(chartChange("applyChartData", dep, written, len(updates)))
+		if cacheSync {
+			var err error
+			synced, err = d.syncChartCacheInOverlay(stage, suppliedDependencies(dep, supplied, hasCategories))
+			return err
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	if committed && cacheSync {
+		if _, err := d.recordCacheSync(dep, synced); err != nil {
+			return err
+		}
+	}
+	if committed && overCap && d.opts.Chart.CacheSync {
+		_ = d.reportCachePointsExceeded(dep, formula, points)
+	}
+	d.reportNumberCoercions(chartIndex, coerced)
+	d.reportStaleAnnotations(dep, stale)
+	return nil
 }
 
-func (d *Document) applyMixedChartData(chartIndex int, dep ChartDependencies, data map[string][]string) error {
+func (d *docCall) applyMixedChartData(chartIndex int, dep ChartDependencies, data map[string][]string) error {
 	mixedDeps, code, err := d.mixedWriteDependencies(dep)
 	if err != nil {
 		return d.handleMixedWriteError(dep, code, err)
 	}
 
 	categories, hasCategories := data["categories"]
-	if !hasCategories {
+	if !hasCategories && d.opts.Chart.RequireAllSeries {
 		return fmt.Errorf("categories data is required")
 	}
 
+	valueRanges := make([]ChartRange, len(mixedDeps.Series))
+	for i, series := range mixedDeps.Series {
+		valueRanges[i] = series.Values
+		valueRanges[i].SeriesIndex = i
+	}
+	seriesKeys := newSeriesKeys(valueRanges)
+	valueKeys, err := seriesKeys.resolve(data, d.opts.Chart.RequireAllSeries)
+	if err != nil {
+		return err
+	}
+	nameKeys, err := seriesKeys.nameKeys(data, valueKeys)
+	if err != nil {
+		return err
+	}
 	valuesBySeries := make([][]string, len(mixedDeps.Series))
 	for i := range mixedDeps.Series {
-		key := fmt.Sprintf("values:%d", i)
-		values, ok := data[key]
+		values, ok := data[valueKeys[i]]
 		if !ok {
-			return fmt.Errorf("values data missing for series %d", i)
+			continue
 		}
-		if len(values) != len(categories) {
-			if err := d.handleChartDataMismatch(chartIndex, len(categories), len(values), i); err != nil {
+		if hasCategories && len(values) != len(categories) {
+			if err := d.handleChartDataMismatch(chartIndex, len(categories), len(values), i, seriesKeys.hint()); err != nil {
 				return err
 			}
 			return nil
@@ -692,83 +1285,133 @@ func (d *Document) applyMixedChartData(chartIndex int, dep ChartDependencies, da
 	}
 
 	updates := make([]CellUpdate, 0)
-	catCells, err := expandRangeCells(mixedDeps.Categories.StartCell, mixedDeps.Categories.EndCell)
-	if err != nil {
-		return err
-	}
-	if len(categories) != len(catCells) {
-		return fmt.Errorf("categories length mismatch: expected %d got %d", len(catCells), len(categories))
-	}
-	for i, cell := range catCells {
-		updates = append(updates, CellUpdate{
-			WorkbookPath: dep.WorkbookPath,
-			Sheet:        mixedDeps.Categories.Sheet,
-			Cell:         cell,
-			Value:        Str(categories[i]),
-		})
+	written := make([]string, 0, len(mixedDeps.Series)+1)
+	var expressions []pendingExpression
+	coerced := make(numberCoercions)
+	if hasCategories {
+		written = append(written, mixedDeps.Categories.Formula)
+		catCells, err := expandRangeCells(mixedDeps.Categories.StartCell, mixedDeps.Categories.EndCell)
+		if err != nil {
+			return err
+		}
+		if len(categories) != len(catCells) {
+			return fmt.Errorf("categories length mismatch: expected %d got %d", len(catCells), len(categories))
+		}
+		for i, cell := range catCells {
+			updates = append(updates, CellUpdate{
+				WorkbookPath: dep.WorkbookPath,
+				Sheet:        mixedDeps.Categories.Sheet,
+				Cell:         cell,
+				Value:        Str(categories[i]),
+			})
+		}
 	}
 
 	for i, series := range mixedDeps.Series {
+		if _, ok := data[valueKeys[i]]; !ok {
+			continue
+		}
+		written = append(written, series.Values.Formula)
 		values := valuesBySeries[i]
 		cells, err := expandRangeCells(series.Values.StartCell, series.Values.EndCell)
 		if err != nil {
 			return err
 		}
 		if len(values) != len(cells) {
-			return fmt.Errorf("values length mismatch for series %d: expected %d got %d", i, len(cells), len(values))
+			return fmt.Errorf("values length mismatch for series %d: expected %d got %d%s", i, len(cells), len(values), seriesKeys.hint())
 		}
 		for j, cell := range cells {
-			value := strings.TrimSpace(values[j])
-			number, err := strconv.ParseFloat(value, 64)
+			value, err := d.chartNumericValue(values[j], i, j, len(updates), &expressions, coerced)
 			if err != nil {
-				return fmt.Errorf("invalid numeric value %q for series %d", values[j], i)
+				return d.handleInvalidNumber(chartIndex, err)
 			}
 			updates = append(updates, CellUpdate{
 				WorkbookPath: dep.WorkbookPath,
 				Sheet:        series.Values.Sheet,
 				Cell:         cell,
-				Value:        Num(number),
+				Value:        value,
 			})
 		}
 	}
+	names := make(map[int]*ChartRange, len(mixedDeps.Series))
+	for i, series := range mixedDeps.Series {
+		names[i] = series.Name
+	}
+	nameUpdates, nameFormulas, err := d.seriesNameUpdates(dep, data, nameKeys, names, nil)
+	if err != nil {
+		return err
+	}
+	updates = append(updates, nameUpdates...)
+	written = append(written, nameFormulas...)
 
+	updates, err = d.evaluateExpressions(dep, updates, expressions)
+	if err != nil {
+		return err
+	}
 	if len(updates) == 0 {
 		return fmt.Errorf("no chart ranges matched")
 	}
 
+	stale := d.checkAnnotationStaleness(dep, updates)
 	ctx := d.validateContext(dep)
-	return d.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	ctx.SharedStringCells = d.sharedStringCells(updates)
+	cacheSync := d.opts.Chart.CacheSync
+	formula, points, overCap := d.cachePointsOverCap(dep)
+	if overCap {
+		cacheSync = false
+	}
+	var synced []chartRepairRecord
+	committed, err := d.stageChart(ctx, func(stage overlaystage.Overlay) error {
 		if err := d.setWorkbookCellsInOverlay(stage, updates); err != nil {
 			return err
 		}
-		if d.opts.Chart.CacheSync {
-			return d.syncMixedChartCacheInOverlay(stage, dep)
+		d.manifest.stage(chartChange("applyChartData", dep, written, len(updates)))
+		if cacheSync {
+			var err error
+			synced, err = d.syncMixedChartCacheInOverlay(stage, dep)
+			return err
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	if committed && cacheSync {
+		if _, err := d.recordCacheSync(dep, synced); err != nil {
+			return err
+		}
+	}
+	if committed && overCap && d.opts.Chart.CacheSync {
+		_ = d.reportCachePointsExceeded(dep, formula, points)
+	}
+	d.reportNumberCoercions(chartIndex, coerced)
+	d.reportStaleAnnotations(dep, stale)
+	return nil
 }
 
-// Close is a no-op in v0; Document does not hold OS resources yet.
+// Close releases the file OpenFile reads parts from; the document cannot be
+// read or saved afterwards. Documents from Open and OpenReader hold no OS
+// resources, so Close is a no-op for them.
 func (d *Document) Close() error {
-	return nil
+	if d == nil || d.pkg == nil {
+		return nil
+	}
+	return d.pkg.Close()
 }
 
 func (d *Document) Alerts() []Alert {
-	if d == nil || len(d.alerts) == 0 {
+	if d == nil {
+		return []Alert{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.alerts) == 0 {
 		return []Alert{}
 	}
 
 	out := make([]Alert, len(d.alerts))
 	for i, alert := range d.alerts {
-		out[i] = alert
-		if alert.Context == nil {
-			continue
-		}
-		ctxCopy := make(map[string]string, len(alert.Context))
-		for key, value := range alert.Context {
-			ctxCopy[key] = value
-		}
-		out[i].Context = ctxCopy
+		out[i] = alert.clone()
 	}
 
 	return out
@@ -778,6 +1421,8 @@ func (d *Document) HasAlerts() bool {
 	if d == nil {
 		return false
 	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	return len(d.alerts) > 0
 }
 
@@ -785,21 +1430,15 @@ func (d *Document) AlertsByCode(code string) []Alert {
 	if d == nil || code == "" {
 		return []Alert{}
 	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
 	filtered := make([]Alert, 0)
 	for _, alert := range d.alerts {
 		if alert.Code != code {
 			continue
 		}
-		copyAlert := alert
-		if alert.Context != nil {
-			ctxCopy := make(map[string]string, len(alert.Context))
-			for key, value := range alert.Context {
-				ctxCopy[key] = value
-			}
-			copyAlert.Context = ctxCopy
-		}
-		filtered = append(filtered, copyAlert)
+		filtered = append(filtered, alert.clone())
 	}
 
 	if len(filtered) == 0 {
@@ -808,11 +1447,48 @@ func (d *Document) AlertsByCode(code string) []Alert {
 	return filtered
 }
 
+// ClearAlerts drops the alerts recorded so far, so a long-lived document
+// reports only what later calls raise; alerts raised once per cell or part
+// are raised again. The change manifest still lists the codes of cleared
+// alerts in the next save's run.
+func (d *Document) ClearAlerts() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.manifest.clearAlerts(d.alerts)
+	d.alerts = nil
+	d.cellsReported = nil
+	d.partsTooLarge = nil
+}
+
 func (d *Document) addAlert(alert Alert) {
 	if d == nil {
 		return
 	}
+	if alert.Timestamp.IsZero() {
+		alert.Timestamp = time.Now().UTC()
+	}
+	d.mu.Lock()
 	d.alerts = append(d.alerts, alert)
+	d.mu.Unlock()
+	if d.alertHandler != nil {
+		d.alertHandler(alert.clone())
+	}
+}
+
+// WithAlertHandler calls handler with a copy of every alert as the document
+// records it, on the goroutine of the call that raised it, before that call
+// returns. The alert is still kept for Alerts. Concurrent reads may call the
+// handler from several goroutines at once.
+func WithAlertHandler(handler func(Alert)) Option {
+	return func(d *Document) {
+		if d == nil || handler == nil {
+			return
+		}
+		d.alertHandler = handler
+	}
 }
 
 func WithLogger(logger Logger) Option {
@@ -877,19 +1553,49 @@ func WithErrorMode(mode ErrorMode) Option {
 	}
 }
 
-func (d *Document) withChartStage(ctx postflight.ValidateContext, fn func(stage overlaystage.Overlay) error) error {
-	if d == nil || d.pkg == nil {
-		return fmt.Errorf("document not initialized")
+func (d *docCall) withChartStage(ctx postflight.ValidateContext, fn func(stage overlaystage.Overlay) error) error {
+	_, err := d.stageChart(ctx, fn)
+	return err
+}
+
+// stageChart is withChartStage that also reports whether the stage
+// committed, which a BestEffort timeout hides behind a nil error.
+func (d *docCall) stageChart(ctx postflight.ValidateContext, fn func(stage overlaystage.Overlay) error) (bool, error) {
+	if d.Document == nil || d.pkg == nil {
+		return false, fmt.Errorf("document not initialized")
 	}
-	if d.overlay == nil {
-		overlay, err := overlaystage.NewPackageOverlay(d.pkg)
-		if err != nil {
-			return err
-		}
-		d.overlay = overlay
+	if err := d.ensureOverlay(); err != nil {
+		return false, err
+	}
+
+	err := d.guardChart("write", ctx.SlidePath, ctx.ChartPath, ctx.WorkbookPath, func() error {
+		ctx.Cancel = d.cancel
+		return d.runChartStage(ctx, fn)
+	})
+	if err != nil && d.mode() == BestEffort && errors.Is(err, ErrChartProcessingTimeout) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (d *Document) ensureOverlay() error {
+	if d.overlay != nil {
+		return nil
+	}
+	overlay, err := overlaystage.NewPackageOverlay(d.pkg)
+	if err != nil {
+		return err
 	}
+	d.overlay = overlay
+	return nil
+}
 
+func (d *docCall) runChartStage(ctx postflight.ValidateContext, fn func(stage overlaystage.Overlay) error) error {
 	stage := overlaystage.NewStagingOverlay(d.overlay)
+	stage.AllowNewParts(ctx.AllowedNewParts...)
+	// Manifest changes noted by fn only count once the stage commits.
+	d.manifest.beginStage()
+	defer d.manifest.discardStage()
 	if err := fn(stage); err != nil {
 		stage.Discard()
 		return err
@@ -905,22 +1611,36 @@ func (d *Document) withChartStage(ctx postflight.ValidateContext, fn func(stage
 				Context: ctx,
 			})
 		},
+		EmitWarning: func(code, message string, ctx map[string]string) {
+			d.addAlert(Alert{
+				Level:   "warn",
+				Code:    code,
+				Message: message,
+				Context: ctx,
+			})
+		},
 	})
 	if err := validator.ValidateChartStage(ctx, stage); err != nil {
 		stage.Discard()
 		return err
 	}
+	if d.dryRun != nil {
+		d.dryRun.staged = true
+		stage.Discard()
+		return nil
+	}
 
 	if err := stage.Commit(); err != nil {
 		stage.Discard()
 		return err
 	}
+	d.manifest.commitStage()
 	return nil
 }
 
-func (d *Document) validateContext(dep ChartDependencies) postflight.ValidateContext {
+func (d *docCall) validateContext(dep ChartDependencies) postflight.ValidateContext {
 	mode := postflight.ModeStrict
-	if d.opts.Mode == BestEffort {
+	if d.mode() == BestEffort {
 		mode = postflight.ModeBestEffort
 	}
 	return postflight.ValidateContext{
@@ -933,71 +1653,140 @@ func (d *Document) validateContext(dep ChartDependencies) postflight.ValidateCon
 	}
 }
 
-func (d *Document) syncChartCacheInOverlay(overlay overlaystage.Overlay, dep ChartDependencies) error {
+// sharedStringCells lists the cells updates write, by sheet, for the
+// postflight shared strings check when Workbook.ConvertSharedStrings is set.
+func (d *Document) sharedStringCells(updates []CellUpdate) map[string]map[string]struct{} {
+	if !d.opts.Workbook.ConvertSharedStrings {
+		return nil
+	}
+	cells := make(map[string]map[string]struct{})
+	for _, update := range updates {
+		ref, err := xlref.NormalizeCellRef(update.Cell)
+		if err != nil {
+			continue
+		}
+		if cells[update.Sheet] == nil {
+			cells[update.Sheet] = make(map[string]struct{})
+		}
+		cells[update.Sheet][ref] = struct{}{}
+	}
+	return cells
+}
+
+// cacheRewriter rewrites the caches of one plot of a chart and describes
+// each cache it replaced. Cache sync and cache repair share the overlay
+// plumbing and differ only in the rewriter.
+type cacheRewriter func(chartXML []byte, deps chartcache.Dependencies, provider chartcache.ValueProvider) ([]byte, []chartcache.CacheRepair, error)
+
+func (d *docCall) syncCaches(chartXML []byte, deps chartcache.Dependencies, provider chartcache.ValueProvider) ([]byte, []chartcache.CacheRepair, error) {
+	return chartcache.SyncCachesReport(chartXML, deps, provider, d.cancel)
+}
+
+func (d *docCall) syncChartCacheInOverlay(overlay overlaystage.Overlay, dep ChartDependencies) ([]chartRepairRecord, error) {
+	return d.rewriteChartCacheInOverlay(overlay, dep, d.syncCaches)
+}
+
+// rewriteChartCacheInOverlay rewrites the chart's caches with rewrite. The
+// chart part is only written when at least one cache changed.
+func (d *docCall) rewriteChartCacheInOverlay(overlay overlaystage.Overlay, dep ChartDependencies, rewrite cacheRewriter) ([]chartRepairRecord, error) {
 	if overlay == nil {
-		return fmt.Errorf("overlay not initialized")
+		return nil, fmt.Errorf("overlay not initialized")
 	}
 
 	chartData, err := overlay.Get(dep.ChartPath)
 	if err != nil {
-		return fmt.Errorf("read chart %q: %w", dep.ChartPath, err)
+		return nil, fmt.Errorf("read chart %q: %w", dep.ChartPath, err)
 	}
 
 	wbData, err := overlay.Get(dep.WorkbookPath)
 	if err != nil {
-		return fmt.Errorf("read workbook %q: %w", dep.WorkbookPath, err)
+		return nil, fmt.Errorf("read workbook %q: %w", dep.WorkbookPath, err)
 	}
 
-	wb, err := xlsxembed.Open(wbData)
+	wb, err := d.openSharedWorkbook(dep.WorkbookPath, wbData)
 	if err != nil {
-		return fmt.Errorf("open workbook %q: %w", dep.WorkbookPath, err)
+		return nil, fmt.Errorf("open workbook %q: %w", dep.WorkbookPath, err)
 	}
+	wb.SetCancel(d.cancel)
 
 	cacheDeps, err := toCacheDeps(dep)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	parsed, err := d.parsedChart(dep.ChartPath, chartData)
+	if err != nil {
+		return nil, fmt.Errorf("parse chart %q: %w", dep.ChartPath, err)
+	}
+	// chartcache finds a series by its position in the chart XML.
+	positions := parsed.SeriesPositions
+	indices := make(map[int]int, len(positions))
+	for index, position := range positions {
+		indices[position] = index
+		for i := range cacheDeps.Ranges {
+			if dep.Ranges[i].SeriesIndex == index {
+				cacheDeps.Ranges[i].SeriesIndex = position
+			}
+		}
 	}
 
-	updated, err := chartcache.SyncCaches(chartData, cacheDeps, func(kind chartcache.RangeKind, sheet, start, end string) ([]string, error) {
+	updated, caches, err := rewrite(chartData, cacheDeps, func(kind chartcache.RangeKind, sheet, start, end string) ([]string, error) {
 		policy := xlsxembed.MissingNumericEmpty
 		if kind == chartcache.KindValues {
 			policy = xlsxembed.MissingNumericPolicy(d.opts.Workbook.MissingNumericPolicy)
 		}
-		return wb.GetRangeValues(sheet, start, end, policy)
+		return readChartRangeValues(wb, kind == chartcache.KindCategories, sheet, start, end, policy)
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if hasMultiLevelCategories(parsed) {
+		d.reportMultiLevelCachePreserved(dep)
 	}
 
+	records := make([]chartRepairRecord, 0, len(caches))
+	for _, cache := range caches {
+		if index, ok := indices[cache.SeriesIndex]; ok {
+			cache.SeriesIndex = index
+		}
+		records = append(records, chartRepairRecord{plotType: cacheDeps.ChartType, cache: cache})
+	}
+	if !recordsChanged(records) {
+		return records, nil
+	}
 	if err := overlay.Set(dep.ChartPath, updated); err != nil {
-		return fmt.Errorf("write chart %q: %w", dep.ChartPath, err)
+		return nil, fmt.Errorf("write chart %q: %w", dep.ChartPath, err)
 	}
-	return nil
+	return records, nil
+}
+
+func (d *docCall) syncMixedChartCacheInOverlay(overlay overlaystage.Overlay, dep ChartDependencies) ([]chartRepairRecord, error) {
+	return d.rewriteMixedChartCacheInOverlay(overlay, dep, d.syncCaches)
 }
 
-func (d *Document) syncMixedChartCacheInOverlay(overlay overlaystage.Overlay, dep ChartDependencies) error {
+func (d *docCall) rewriteMixedChartCacheInOverlay(overlay overlaystage.Overlay, dep ChartDependencies, rewrite cacheRewriter) ([]chartRepairRecord, error) {
 	if overlay == nil {
-		return errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("overlay not initialized"))
+		return nil, errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("overlay not initialized"))
 	}
 
 	chartData, err := overlay.Get(dep.ChartPath)
 	if err != nil {
-		return errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("read chart %q: %w", dep.ChartPath, err))
+		return nil, errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("read chart %q: %w", dep.ChartPath, err))
 	}
 
 	wbData, err := overlay.Get(dep.WorkbookPath)
 	if err != nil {
-		return errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("read workbook %q: %w", dep.WorkbookPath, err))
+		return nil, errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("read workbook %q: %w", dep.WorkbookPath, err))
 	}
 
-	wb, err := xlsxembed.Open(wbData)
+	wb, err := d.openSharedWorkbook(dep.WorkbookPath, wbData)
 	if err != nil {
-		return errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("open workbook %q: %w", dep.WorkbookPath, err))
+		return nil, errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("open workbook %q: %w", dep.WorkbookPath, err))
 	}
+	wb.SetCancel(d.cancel)
 
-	mixedDeps, _, err := mixedWriteDependenciesFromChart(chartData)
+	mixedDeps, _, err := d.mixedWriteDependenciesFromChart(dep.ChartPath, dep.WorkbookPath, chartData)
 	if err != nil {
-		return errwrap.WrapOp("mix-write: cache-sync", err)
+		return nil, errwrap.WrapOp("mix-write: cache-sync", err)
 	}
 
 	barRanges := make([]chartcache.Range, 0)
@@ -1033,40 +1822,47 @@ func (d *Document) syncMixedChartCacheInOverlay(overlay overlaystage.Overlay, de
 	}
 
 	if len(barRanges) == 0 || len(lineRanges) == 0 {
-		return errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("mixed chart requires bar and line series"))
+		return nil, errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("mixed chart requires bar and line series"))
 	}
+	multiLevel := d.chartHasMultiLevelCategories(dep.ChartPath, chartData)
 
 	provider := func(kind chartcache.RangeKind, sheet, start, end string) ([]string, error) {
 		policy := xlsxembed.MissingNumericEmpty
 		if kind == chartcache.KindValues {
 			policy = xlsxembed.MissingNumericPolicy(d.opts.Workbook.MissingNumericPolicy)
 		}
-		return wb.GetRangeValues(sheet, start, end, policy)
+		return readChartRangeValues(wb, kind == chartcache.KindCategories, sheet, start, end, policy)
 	}
 
-	updated, err := chartcache.SyncCaches(chartData, chartcache.Dependencies{
-		ChartType: "bar",
-		Ranges:    barRanges,
-	}, provider)
-	if err != nil {
-		return errwrap.WrapOp("mix-write: cache-sync", err)
+	var records []chartRepairRecord
+	updated := chartData
+	for _, plot := range []chartcache.Dependencies{
+		{ChartType: "bar", Ranges: barRanges},
+		{ChartType: "line", Ranges: lineRanges},
+	} {
+		var caches []chartcache.CacheRepair
+		updated, caches, err = rewrite(updated, plot, provider)
+		if err != nil {
+			return nil, errwrap.WrapOp("mix-write: cache-sync", err)
+		}
+		for _, cache := range caches {
+			records = append(records, chartRepairRecord{plotType: plot.ChartType, cache: cache})
+		}
 	}
-
-	updated, err = chartcache.SyncCaches(updated, chartcache.Dependencies{
-		ChartType: "line",
-		Ranges:    lineRanges,
-	}, provider)
-	if err != nil {
-		return errwrap.WrapOp("mix-write: cache-sync", err)
+	if multiLevel {
+		d.reportMultiLevelCachePreserved(dep)
 	}
 
+	if !recordsChanged(records) {
+		return records, nil
+	}
 	if err := overlay.Set(dep.ChartPath, updated); err != nil {
-		return errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("write chart %q: %w", dep.ChartPath, err))
+		return nil, errwrap.WrapOp("mix-write: cache-sync", fmt.Errorf("write chart %q: %w", dep.ChartPath, err))
 	}
-	return nil
+	return records, nil
 }
 
-func (d *Document) mixedWriteDependencies(dep ChartDependencies) (*mixedWriteDeps, string, error) {
+func (d *docCall) mixedWriteDependencies(dep ChartDependencies) (*mixedWriteDeps, string, error) {
 	if d == nil || d.pkg == nil {
 		return nil, "CHART_DEPENDENCIES_PARSE_FAILED", fmt.Errorf("document not initialized")
 	}
@@ -1076,14 +1872,14 @@ func (d *Document) mixedWriteDependencies(dep ChartDependencies) (*mixedWriteDep
 		return nil, "CHART_DEPENDENCIES_PARSE_FAILED", errwrap.WrapOp("mix-write: eligibility", fmt.Errorf("read chart %q: %w", dep.ChartPath, err))
 	}
 
-	return mixedWriteDependenciesFromChart(data)
+	return d.mixedWriteDependenciesFromChart(dep.ChartPath, dep.WorkbookPath, data)
 }
 
-func mixedWriteDependenciesFromChart(chartXML []byte) (*mixedWriteDeps, string, error) {
-	parsed, err := chartxml.ParseMixed(bytes.NewReader(chartXML))
+func (d *docCall) mixedWriteDependenciesFromChart(chartPath, workbookPath string, chartXML []byte) (*mixedWriteDeps, string, error) {
+	parsed, err := d.mixedChart(chartPath, chartXML)
 	if err != nil {
 		code := "WRITE_MIX_UNSUPPORTED_SHAPE"
-		if strings.Contains(err.Error(), "parse mixed chart") {
+		if errors.Is(err, chartxml.ErrMixedChartSyntax) {
 			code = "CHART_DEPENDENCIES_PARSE_FAILED"
 		}
 		return nil, code, errwrap.WrapOp("mix-write: eligibility", err)
@@ -1121,6 +1917,7 @@ func mixedWriteDependenciesFromChart(chartXML []byte) (*mixedWriteDeps, string,
 	}
 
 	seriesRanges := make(map[int]*mixedWriteSeries, len(parsed.Series))
+	whole := &wholeRangeResolver{d: d, workbookPath: workbookPath}
 	for _, series := range parsed.Series {
 		if series.PlotType != "bar" && series.PlotType != "line" {
 			return nil, "WRITE_MIX_UNSUPPORTED_SHAPE", &mixedWriteError{
@@ -1148,17 +1945,26 @@ func mixedWriteDependenciesFromChart(chartXML []byte) (*mixedWriteDeps, string,
 
 		for _, formula := range series.Formulas {
 			ref, err := xlref.ParseA1Range(formula.Formula)
+			if err == nil {
+				ref, err = whole.narrow(ref, formula.PtCount)
+			}
 			if err != nil {
-				return nil, "CHART_DEPENDENCIES_PARSE_FAILED", errwrap.WrapOp("mix-write: eligibility", fmt.Errorf("parse chart formula %q: %w", formula.Formula, err))
+				ctx := map[string]string{}
+				code := dependencyFailureCode(err, ctx)
+				return nil, code, &mixedWriteError{
+					err: errwrap.WrapOp("mix-write: eligibility", fmt.Errorf("parse chart formula %q: %w", formula.Formula, err)),
+					ctx: ctx,
+				}
 			}
 
 			r := ChartRange{
-				Kind:        ChartRangeKind(formula.Kind),
-				SeriesIndex: series.Index,
-				Sheet:       ref.Sheet,
-				StartCell:   ref.StartCell,
-				EndCell:     ref.EndCell,
-				Formula:     formula.Formula,
+				Kind:          ChartRangeKind(formula.Kind),
+				SeriesIndex:   series.Index,
+				OriginalIndex: series.Idx,
+				Sheet:         ref.Sheet,
+				StartCell:     ref.StartCell,
+				EndCell:       ref.EndCell,
+				Formula:       formula.Formula,
 			}
 
 			switch r.Kind {
@@ -1464,8 +2270,8 @@ func validateCellValue(value CellValue) error {
 	return nil
 }
 
-func (d *Document) handleWorkbookUpdateError(update CellUpdate, err error) error {
-	if d.opts.Mode != BestEffort {
+func (d *docCall) handleWorkbookUpdateError(update CellUpdate, err error) error {
+	if d.mode() != BestEffort {
 		return err
 	}
 
@@ -1484,6 +2290,21 @@ func (d *Document) handleWorkbookUpdateError(update CellUpdate, err error) error
 	return nil
 }
 
+// suppliedDependencies narrows dep to the ranges a partial ApplyChartData
+// wrote, so the cache sync leaves the caches of the series it left out as
+// they were: every range of the supplied series, and the categories of all
+// series when categories were supplied.
+func suppliedDependencies(dep ChartDependencies, supplied map[int]bool, hasCategories bool) ChartDependencies {
+	ranges := make([]ChartRange, 0, len(dep.Ranges))
+	for _, r := range dep.Ranges {
+		if supplied[r.SeriesIndex] || (hasCategories && r.Kind == RangeCategories) {
+			ranges = append(ranges, r)
+		}
+	}
+	dep.Ranges = ranges
+	return dep
+}
+
 func toCacheDeps(dep ChartDependencies) (chartcache.Dependencies, error) {
 	ranges := make([]chartcache.Range, len(dep.Ranges))
 	for i, r := range dep.Ranges {
@@ -1495,6 +2316,8 @@ func toCacheDeps(dep ChartDependencies) (chartcache.Dependencies, error) {
 			kind = chartcache.KindValues
 		case RangeSeriesName:
 			kind = chartcache.KindSeriesName
+		case RangeDataLabels:
+			kind = chartcache.KindDataLabels
 		default:
 			return chartcache.Dependencies{}, fmt.Errorf("unsupported range kind %q", r.Kind)
 		}
@@ -1502,6 +2325,7 @@ func toCacheDeps(dep ChartDependencies) (chartcache.Dependencies, error) {
 		ranges[i] = chartcache.Range{
 			Kind:        kind,
 			SeriesIndex: r.SeriesIndex,
+			UnionIndex:  r.UnionIndex,
 			Sheet:       r.Sheet,
 			StartCell:   r.StartCell,
 			EndCell:     r.EndCell,
@@ -1514,8 +2338,8 @@ func toCacheDeps(dep ChartDependencies) (chartcache.Dependencies, error) {
 	}, nil
 }
 
-func (d *Document) handleChartCacheError(dep ChartDependencies, err error) error {
-	if d.opts.Mode != BestEffort {
+func (d *docCall) handleChartCacheError(dep ChartDependencies, err error) error {
+	if d.mode() != BestEffort {
 		return err
 	}
 
@@ -1534,7 +2358,7 @@ func (d *Document) handleChartCacheError(dep ChartDependencies, err error) error
 	return nil
 }
 
-func (d *Document) validateWritableChart(dep ChartDependencies) error {
+func (d *docCall) validateWritableChart(dep ChartDependencies) error {
 	switch dep.ChartType {
 	case "mixed":
 		if code, err := d.validateMixedWrite(dep); err != nil {
@@ -1551,7 +2375,7 @@ func (d *Document) validateWritableChart(dep ChartDependencies) error {
 		if code, err := validateAreaDependencies(dep); err != nil {
 			return d.handleAreaWriteError(dep, code, err)
 		}
-	case "bar", "line":
+	case "bar", "line", "stock", "doughnut":
 		return nil
 	default:
 		return d.handleChartTypeUnsupported(dep)
@@ -1559,9 +2383,9 @@ func (d *Document) validateWritableChart(dep ChartDependencies) error {
 	return nil
 }
 
-func (d *Document) handleChartTypeUnsupported(dep ChartDependencies) error {
+func (d *docCall) handleChartTypeUnsupported(dep ChartDependencies) error {
 	err := fmt.Errorf("unsupported chart type %q", dep.ChartType)
-	if d.opts.Mode != BestEffort {
+	if d.mode() != BestEffort {
 		return err
 	}
 
@@ -1579,13 +2403,13 @@ func (d *Document) handleChartTypeUnsupported(dep ChartDependencies) error {
 	return err
 }
 
-func (d *Document) validateMixedWrite(dep ChartDependencies) (string, error) {
+func (d *docCall) validateMixedWrite(dep ChartDependencies) (string, error) {
 	_, code, err := d.mixedWriteDependencies(dep)
 	return code, err
 }
 
-func (d *Document) handleMixedWriteError(dep ChartDependencies, code string, err error) error {
-	if d.opts.Mode != BestEffort {
+func (d *docCall) handleMixedWriteError(dep ChartDependencies, code string, err error) error {
+	if d.mode() != BestEffort {
 		return err
 	}
 
@@ -1601,6 +2425,8 @@ func (d *Document) handleMixedWriteError(dep ChartDependencies, code string, err
 		message = "Mixed chart shape is unsupported; chart is skipped"
 	case "CHART_DEPENDENCIES_PARSE_FAILED":
 		message = "Failed to extract chart dependencies; chart is skipped"
+	case "CHART_NAMED_RANGE_UNSUPPORTED":
+		message = "Series formula is a defined name, which is unsupported; chart is skipped"
 	}
 
 	ctx := map[string]string{
@@ -1626,8 +2452,8 @@ func (d *Document) handleMixedWriteError(dep ChartDependencies, code string, err
 	return err
 }
 
-func (d *Document) handlePieWriteError(dep ChartDependencies, code string, err error) error {
-	if d.opts.Mode != BestEffort {
+func (d *docCall) handlePieWriteError(dep ChartDependencies, code string, err error) error {
+	if d.mode() != BestEffort {
 		return err
 	}
 
@@ -1653,8 +2479,8 @@ func (d *Document) handlePieWriteError(dep ChartDependencies, code string, err e
 	return err
 }
 
-func (d *Document) handleAreaWriteError(dep ChartDependencies, code string, err error) error {
-	if d.opts.Mode != BestEffort {
+func (d *docCall) handleAreaWriteError(dep ChartDependencies, code string, err error) error {
+	if d.mode() != BestEffort {
 		return err
 	}
 
@@ -1786,11 +2612,17 @@ func validateAreaDependencies(dep ChartDependencies) (string, error) {
 	for _, r := range dep.Ranges {
 		switch r.Kind {
 		case RangeValues:
+			if r.UnionIndex > 0 {
+				continue
+			}
 			if _, ok := valueRanges[r.SeriesIndex]; ok {
 				return "CHART_DEPENDENCIES_PARSE_FAILED", fmt.Errorf("duplicate values range for series %d", r.SeriesIndex)
 			}
 			valueRanges[r.SeriesIndex] = r
 		case RangeCategories:
+			if r.UnionIndex > 0 {
+				continue
+			}
 			if _, ok := catRanges[r.SeriesIndex]; ok {
 				return "CHART_DEPENDENCIES_PARSE_FAILED", fmt.Errorf("duplicate categories range for series %d", r.SeriesIndex)
 			}
@@ -1822,9 +2654,9 @@ func validateAreaDependencies(dep ChartDependencies) (string, error) {
 	return "", nil
 }
 
-func (d *Document) handleChartDataMismatch(chartIndex, categoriesLen, valuesLen, seriesIndex int) error {
-	if d.opts.Mode != BestEffort {
-		return fmt.Errorf("categories length %d does not match values length %d for series %d", categoriesLen, valuesLen, seriesIndex)
+func (d *docCall) handleChartDataMismatch(chartIndex, categoriesLen, valuesLen, seriesIndex int, hint string) error {
+	if d.mode() != BestEffort {
+		return fmt.Errorf("categories length %d does not match values length %d for series %d%s", categoriesLen, valuesLen, seriesIndex, hint)
 	}
 
 	d.addAlert(Alert{
@@ -1,6 +1,9 @@
 package pptx
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestAlertsDefensiveCopy(t *testing.T) {
 	doc := &Document{}
@@ -86,3 +89,50 @@ func TestAlertHelpers(t *testing.T) {
 		t.Fatalf("expected empty slice, got %#v", none)
 	}
 }
+
+func TestClearAlerts(t *testing.T) {
+	doc := &Document{}
+	before := time.Now().UTC()
+	doc.addAlert(Alert{Level: "warn", Code: "W001", Message: "first"})
+	after := time.Now().UTC()
+
+	alerts := doc.Alerts()
+	if len(alerts) != 1 || alerts[0].Timestamp.Before(before) || alerts[0].Timestamp.After(after) || alerts[0].Timestamp.Location() != time.UTC {
+		t.Fatalf("unexpected timestamp %v, expected within [%v, %v] UTC", alerts[0].Timestamp, before, after)
+	}
+	if again := doc.Alerts(); !again[0].Timestamp.Equal(alerts[0].Timestamp) {
+		t.Fatalf("timestamp changed between reads: %v then %v", alerts[0].Timestamp, again[0].Timestamp)
+	}
+
+	doc.ClearAlerts()
+	if doc.HasAlerts() || len(doc.Alerts()) != 0 {
+		t.Fatalf("expected no alerts after ClearAlerts, got %#v", doc.Alerts())
+	}
+	doc.addAlert(Alert{Level: "info", Code: "I002", Message: "second"})
+	if alerts := doc.Alerts(); len(alerts) != 1 || alerts[0].Code != "I002" {
+		t.Fatalf("unexpected alerts after clearing: %#v", alerts)
+	}
+
+	var nilDoc *Document
+	nilDoc.ClearAlerts()
+}
+
+func TestWithAlertHandler(t *testing.T) {
+	var received []Alert
+	doc := &Document{}
+	WithAlertHandler(func(alert Alert) {
+		received = append(received, alert)
+		alert.Context["key"] = "changed"
+	})(doc)
+
+	doc.addAlert(Alert{Level: "warn", Code: "W001", Message: "first", Context: map[string]string{"key": "value"}})
+	if len(received) != 1 || received[0].Code != "W001" || received[0].Timestamp.IsZero() {
+		t.Fatalf("expected the handler to see the alert as it was added, got %#v", received)
+	}
+	if doc.alerts[0].Context["key"] != "value" {
+		t.Fatalf("handler mutated the recorded alert: %#v", doc.alerts[0].Context)
+	}
+	if !received[0].Timestamp.Equal(doc.alerts[0].Timestamp) {
+		t.Fatalf("handler and Alerts disagree on the timestamp: %v vs %v", received[0].Timestamp, doc.alerts[0].Timestamp)
+	}
+}
@@ -0,0 +1,140 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/postflight"
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+func TestExtractDoughnutChart(t *testing.T) {
+	exercisesFeature(t, "extract.doughnut")
+
+	doc, err := OpenFile(fixturePath("doughnut_two_rings_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if data.Type != "doughnut" || !reflect.DeepEqual(data.Labels, []string{"North", "South", "West"}) {
+		t.Fatalf("unexpected chart: %q %#v", data.Type, data.Labels)
+	}
+	if len(data.Series) != 2 || data.Series[0].Name != "2023" || !reflect.DeepEqual(data.Series[1].Data, []string{"12", "18", "35"}) {
+		t.Fatalf("expected one series per ring, got %#v", data.Series)
+	}
+
+	payload, err := ChartJSExporter{}.Export(data)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if payload.Data["type"] != "doughnut" {
+		t.Fatalf("unexpected payload: %#v", payload.Data)
+	}
+	datasets := payload.Data["datasets"].([]map[string]any)
+	if len(datasets) != 2 || datasets[1]["label"] != "2024" || !reflect.DeepEqual(datasets[0]["data"], []any{float64(10), float64(20), float64(30)}) {
+		t.Fatalf("unexpected datasets: %#v", datasets)
+	}
+}
+
+func TestSyncChartCachesDoughnut(t *testing.T) {
+	exercisesFeature(t, "cachesync.doughnut")
+
+	doc, err := OpenFile(fixturePath("doughnut_two_rings_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || !results[0].Changed {
+		t.Fatalf("expected the stale caches to be rewritten, got %#v", results)
+	}
+	chartXML, err := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ReadPart: %v", err)
+	}
+	cats, vals := extractChartCacheValues(t, chartXML)
+	wantCats := []string{"2023", "North", "South", "West", "2024", "North", "South", "West"}
+	if !reflect.DeepEqual(cats, wantCats) || !reflect.DeepEqual(vals, []string{"10", "20", "30", "12", "18", "35"}) {
+		t.Fatalf("caches not synced: %#v %#v", cats, vals)
+	}
+}
+
+func TestApplyChartDataDoughnut(t *testing.T) {
+	exercisesFeature(t, "apply.doughnut")
+
+	doc, err := OpenFile(fixturePath("doughnut_two_rings_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.ApplyChartData(0, map[string][]string{
+		"categories": {"East", "Central", "Coast"},
+		"values:0":   {"1", "2", "3"},
+		"values:1":   {"4", "5", "6"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+
+	_, vals := extractChartCacheValues(t, readZipEntry(t, output, "ppt/charts/chart1.xml"))
+	if !reflect.DeepEqual(vals, []string{"1", "2", "3", "4", "5", "6"}) {
+		t.Fatalf("unexpected numCache values: %#v", vals)
+	}
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	data, err := saved.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(data.Labels, []string{"East", "Central", "Coast"}) || !reflect.DeepEqual(data.Series[1].Data, []string{"4", "5", "6"}) {
+		t.Fatalf("round trip mismatch: %#v", data)
+	}
+}
+
+func TestDoughnutPostflightStaleRingStrict(t *testing.T) {
+	input := fixturePath("doughnut_edit_cache_invalid.pptx")
+	output := filepath.Join(t.TempDir(), "output.pptx")
+
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	chartPath := "ppt/charts/chart1.xml"
+	staleChart, err := pptxassert.ReadEntry(input, chartPath)
+	if err != nil {
+		t.Fatalf("ReadEntry chart: %v", err)
+	}
+
+	ctx := postflight.ValidateContext{
+		ChartPath:        chartPath,
+		Mode:             postflight.ModeStrict,
+		CacheSyncEnabled: true,
+	}
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
+		return stage.Set(chartPath, staleChart)
+	})
+	if err == nil {
+		t.Fatalf("expected postflight to reject the stale ring")
+	}
+
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	pptxassert.AssertSameEntrySet(t, input, output)
+}
@@ -3,13 +3,33 @@ package pptx
 import (
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 )
 
 // ChartJSExporter builds a minimal Chart.js payload from extracted chart data.
+// Series on the secondary axis get "yAxisID": "y1" and a right-hand scale
+// whatever the options. The zero value of every other field leaves the
+// payload as bare as it has always been.
 type ChartJSExporter struct {
 	MissingNumericPolicy MissingNumericPolicy
+	// Palette colors the datasets, cycling through the colors in series
+	// order: each gets its color as borderColor and backgroundColor. Pie
+	// and doughnut datasets get one backgroundColor per label instead.
+	Palette []string
+	// DisableAreaFill leaves the datasets of area charts without
+	// "fill": true.
+	DisableAreaFill bool
+	// Tension is the curve tension of the datasets drawn as lines: line,
+	// area, stock, and radar charts and the line series of mixed charts.
+	// Zero leaves it out, and Chart.js draws straight segments.
+	Tension float64
+	// Stacked sets "stacked": true on the x and y scales, and on y1 when
+	// a series is on the secondary axis.
+	Stacked bool
+	// Scales is merged into options.scales by scale ID, such as "x", "y",
+	// or "y1": each key replaces the one the exporter set. Pie, doughnut,
+	// and radar charts, which have no x and y scales, ignore it.
+	Scales map[string]map[string]any
 }
 
 func (e ChartJSExporter) Format() ExportFormat {
@@ -18,7 +38,7 @@ func (e ChartJSExporter) Format() ExportFormat {
 
 func (e ChartJSExporter) Export(in ExtractedChartData) (ExportedPayload, error) {
 	switch in.Type {
-	case "bar", "line", "pie", "area", "mixed":
+	case "bar", "line", "pie", "doughnut", "area", "mixed", "stock", "scatter", "radar":
 	default:
 		return ExportedPayload{}, fmt.Errorf("unsupported chart type %q", in.Type)
 	}
@@ -33,21 +53,85 @@ func (e ChartJSExporter) Export(in ExtractedChartData) (ExportedPayload, error)
 		if len(series) != 1 {
 			return ExportedPayload{}, fmt.Errorf("pie chart requires a single series")
 		}
-		values, err := chartJSValues(series[0].Index, series[0].Data, e.MissingNumericPolicy)
+		values, err := chartJSValues(series[0], e.MissingNumericPolicy)
 		if err != nil {
 			return ExportedPayload{}, err
 		}
 		labels := append([]string(nil), in.Labels...)
+		dataset := map[string]any{
+			"label": series[0].Name,
+			"data":  values,
+		}
+		applyChartJSFormat(dataset, "formatCode", series[0].FormatCode)
+		e.colorSegments(dataset, len(labels))
+		data := map[string]any{
+			"type":     "pie",
+			"labels":   labels,
+			"datasets": []map[string]any{dataset},
+		}
+		applyChartJSFormat(data, "labelsFormatCode", in.LabelsFormatCode)
 		return ExportedPayload{
 			Format: ExportChartJS,
-			Data: map[string]any{
-				"type":   "pie",
-				"labels": labels,
-				"datasets": []map[string]any{{
-					"label": series[0].Name,
-					"data":  values,
-				}},
-			},
+			Data:   data,
+		}, nil
+	}
+
+	if in.Type == "doughnut" {
+		// One dataset per ring, in series order.
+		datasets := make([]map[string]any, 0, len(series))
+		for _, s := range series {
+			values, err := chartJSValues(s, e.MissingNumericPolicy)
+			if err != nil {
+				return ExportedPayload{}, err
+			}
+			dataset := map[string]any{
+				"label": s.Name,
+				"data":  values,
+			}
+			applyChartJSFormat(dataset, "formatCode", s.FormatCode)
+			e.colorSegments(dataset, len(in.Labels))
+			datasets = append(datasets, dataset)
+		}
+		data := map[string]any{
+			"type":     "doughnut",
+			"labels":   append([]string(nil), in.Labels...),
+			"datasets": datasets,
+		}
+		applyChartJSFormat(data, "labelsFormatCode", in.LabelsFormatCode)
+		return ExportedPayload{
+			Format: ExportChartJS,
+			Data:   data,
+		}, nil
+	}
+
+	if in.Type == "scatter" {
+		datasets := make([]map[string]any, 0, len(series))
+		for _, s := range series {
+			xs := in.Labels
+			if s.XValues != nil {
+				xs = s.XValues
+			}
+			points, err := chartJSScatterPoints(s, xs, e.MissingNumericPolicy)
+			if err != nil {
+				return ExportedPayload{}, err
+			}
+			dataset := map[string]any{
+				"label": s.Name,
+				"data":  points,
+			}
+			applyChartJSFormat(dataset, "formatCode", s.FormatCode)
+			datasets = append(datasets, dataset)
+		}
+		e.styleDatasets(datasets, func(int) bool { return false })
+		data := map[string]any{
+			"type":     "scatter",
+			"datasets": datasets,
+		}
+		applyChartJSAxes(data, in.Axes, series, datasets)
+		e.applyScales(data, series)
+		return ExportedPayload{
+			Format: ExportChartJS,
+			Data:   data,
 		}, nil
 	}
 
@@ -68,7 +152,7 @@ func (e ChartJSExporter) Export(in ExtractedChartData) (ExportedPayload, error)
 			if s.PlotType != "bar" && s.PlotType != "line" {
 				return ExportedPayload{}, fmt.Errorf("mixed chart series %d has unsupported plot type %q", s.Index, s.PlotType)
 			}
-			values, err := chartJSValues(s.Index, s.Data, e.MissingNumericPolicy)
+			values, err := chartJSValues(s, e.MissingNumericPolicy)
 			if err != nil {
 				return ExportedPayload{}, err
 			}
@@ -77,17 +161,23 @@ func (e ChartJSExporter) Export(in ExtractedChartData) (ExportedPayload, error)
 				"data":  values,
 				"type":  s.PlotType,
 			}
+			applyChartJSFormat(dataset, "formatCode", s.FormatCode)
 			datasets = append(datasets, dataset)
 		}
 
+		e.styleDatasets(datasets, func(i int) bool { return series[i].PlotType == "line" })
 		labels := append([]string(nil), in.Labels...)
+		data := map[string]any{
+			"type":     chartType,
+			"labels":   labels,
+			"datasets": datasets,
+		}
+		applyChartJSFormat(data, "labelsFormatCode", in.LabelsFormatCode)
+		applyChartJSAxes(data, in.Axes, series, datasets)
+		e.applyScales(data, series)
 		return ExportedPayload{
 			Format: ExportChartJS,
-			Data: map[string]any{
-				"type":     chartType,
-				"labels":   labels,
-				"datasets": datasets,
-			},
+			Data:   data,
 		}, nil
 	}
 
@@ -95,12 +185,15 @@ func (e ChartJSExporter) Export(in ExtractedChartData) (ExportedPayload, error)
 	fill := false
 	if in.Type == "area" {
 		chartType = "line"
-		fill = true
+		fill = !e.DisableAreaFill
+	}
+	if in.Type == "stock" {
+		chartType = "line"
 	}
 
 	datasets := make([]map[string]any, 0, len(series))
 	for _, s := range series {
-		values, err := chartJSValues(s.Index, s.Data, e.MissingNumericPolicy)
+		values, err := chartJSValues(s, e.MissingNumericPolicy)
 		if err != nil {
 			return ExportedPayload{}, err
 		}
@@ -111,47 +204,186 @@ func (e ChartJSExporter) Export(in ExtractedChartData) (ExportedPayload, error)
 		if fill {
 			dataset["fill"] = true
 		}
+		applyChartJSFormat(dataset, "formatCode", s.FormatCode)
 		datasets = append(datasets, dataset)
 	}
+	e.styleDatasets(datasets, func(int) bool { return chartType == "line" || chartType == "radar" })
 
 	labels := append([]string(nil), in.Labels...)
+	data := map[string]any{
+		"type":     chartType,
+		"labels":   labels,
+		"datasets": datasets,
+	}
+	applyChartJSFormat(data, "labelsFormatCode", in.LabelsFormatCode)
+	if in.Type == "stock" {
+		// Chart.js has no candlestick type; the note keeps the source kind.
+		data["typeDetails"] = strings.TrimSuffix("stock:"+in.TypeDetails, ":")
+	}
+	if in.Type != "radar" {
+		// A radar chart has a single radial scale, not x and y.
+		applyChartJSAxes(data, in.Axes, series, datasets)
+		e.applyScales(data, series)
+	}
 	return ExportedPayload{
 		Format: ExportChartJS,
-		Data: map[string]any{
-			"type":     chartType,
-			"labels":   labels,
-			"datasets": datasets,
-		},
+		Data:   data,
 	}, nil
 }
 
-func chartJSValues(seriesIndex int, values []string, policy MissingNumericPolicy) ([]any, error) {
-	out := make([]any, len(values))
-	for i, raw := range values {
-		val, err := chartJSValue(raw, policy)
-		if err != nil {
-			return nil, fmt.Errorf("series %d value %q: %w", seriesIndex, raw, err)
+// applyChartJSAxes puts secondary-axis series on a right-hand "y1" scale and
+// reverses the scales of axes drawn maxMin in the deck. Labels and data keep
+// their workbook order; Chart.js flips the axis. The category axis of a
+// secondary plot is normally hidden, so only the primary one reverses "x".
+// A value axis with its own number format passes its formatCode on.
+// Payloads without a secondary series, a reversed axis, or an axis format are
+// left unchanged.
+func applyChartJSAxes(data map[string]any, axes []ChartAxis, series []ExtractedSeries, datasets []map[string]any) {
+	scales := map[string]any{}
+	hasSecondary := false
+	for _, s := range series {
+		if s.Axis == "secondary" {
+			hasSecondary = true
+			break
+		}
+	}
+	if hasSecondary {
+		for i, s := range series {
+			if s.Axis == "secondary" {
+				datasets[i]["yAxisID"] = "y1"
+			} else {
+				datasets[i]["yAxisID"] = "y"
+			}
+		}
+		scales["y"] = map[string]any{"type": "linear", "position": "left"}
+		scales["y1"] = map[string]any{"type": "linear", "position": "right", "grid": map[string]any{"drawOnChartArea": false}}
+	}
+
+	for _, axis := range axes {
+		valueScale := "y"
+		if axis.Group == "secondary" {
+			if !hasSecondary {
+				continue
+			}
+			valueScale = "y1"
+		} else if axis.CategoryReversed {
+			chartJSScale(scales, "x")["reverse"] = true
+		}
+		if axis.ValueInverted {
+			chartJSScale(scales, valueScale)["reverse"] = true
+		}
+		if axis.ValueFormatCode != "" && !axis.ValueFormatLinked {
+			// Ticks linked to the source take the datasets' formatCode.
+			chartJSScale(scales, valueScale)["formatCode"] = axis.ValueFormatCode
+		}
+	}
+
+	if len(scales) > 0 {
+		data["options"] = map[string]any{"scales": scales}
+	}
+}
+
+// styleDatasets applies Palette to datasets, and Tension to those that line
+// reports are drawn as lines.
+func (e ChartJSExporter) styleDatasets(datasets []map[string]any, line func(i int) bool) {
+	for i, dataset := range datasets {
+		if len(e.Palette) > 0 {
+			color := e.Palette[i%len(e.Palette)]
+			dataset["borderColor"] = color
+			dataset["backgroundColor"] = color
+		}
+		if e.Tension != 0 && line(i) {
+			dataset["tension"] = e.Tension
 		}
-		out[i] = val
 	}
-	return out, nil
 }
 
-func chartJSValue(raw string, policy MissingNumericPolicy) (any, error) {
-	trimmed := strings.TrimSpace(raw)
-	if trimmed == "" {
-		if policy == MissingNumericZero {
-			return float64(0), nil
+// colorSegments gives a pie or doughnut dataset one Palette color per label.
+func (e ChartJSExporter) colorSegments(dataset map[string]any, labels int) {
+	if len(e.Palette) == 0 {
+		return
+	}
+	colors := make([]string, labels)
+	for i := range colors {
+		colors[i] = e.Palette[i%len(e.Palette)]
+	}
+	dataset["backgroundColor"] = colors
+}
+
+// applyScales applies Stacked and Scales to the options.scales block
+// applyChartJSAxes built, adding one if it built none.
+func (e ChartJSExporter) applyScales(data map[string]any, series []ExtractedSeries) {
+	if !e.Stacked && len(e.Scales) == 0 {
+		return
+	}
+	options, ok := data["options"].(map[string]any)
+	if !ok {
+		options = map[string]any{}
+		data["options"] = options
+	}
+	scales, ok := options["scales"].(map[string]any)
+	if !ok {
+		scales = map[string]any{}
+		options["scales"] = scales
+	}
+	if e.Stacked {
+		names := []string{"x", "y"}
+		for _, s := range series {
+			if s.Axis == "secondary" {
+				names = append(names, "y1")
+				break
+			}
+		}
+		for _, name := range names {
+			chartJSScale(scales, name)["stacked"] = true
+		}
+	}
+	for name, config := range e.Scales {
+		scale := chartJSScale(scales, name)
+		for key, value := range config {
+			scale[key] = value
 		}
-		return nil, nil
 	}
+}
+
+// applyChartJSFormat sets key to an Excel number format code for the front
+// end to format ticks and tooltips with; Chart.js itself ignores it. An
+// empty code is left out.
+func applyChartJSFormat(target map[string]any, key, formatCode string) {
+	if formatCode != "" {
+		target[key] = formatCode
+	}
+}
+
+// chartJSScale returns the named scale config, adding an empty one if needed.
+func chartJSScale(scales map[string]any, name string) map[string]any {
+	scale, ok := scales[name].(map[string]any)
+	if !ok {
+		scale = map[string]any{}
+		scales[name] = scale
+	}
+	return scale
+}
 
-	value, err := strconv.ParseFloat(trimmed, 64)
-	if err != nil {
-		if policy == MissingNumericZero {
-			return float64(0), nil
+// chartJSValues returns the points of s as JSON numbers. Blank points are
+// null, or 0 under MissingNumericZero, which also turns text into 0.
+func chartJSValues(s ExtractedSeries, policy MissingNumericPolicy) ([]any, error) {
+	values := s.typedValues()
+	out := make([]any, len(values))
+	for i, value := range values {
+		switch {
+		case value.Number != nil:
+			out[i] = *value.Number
+		case value.Empty:
+			if policy == MissingNumericZero {
+				out[i] = float64(0)
+			}
+		default:
+			if policy != MissingNumericZero {
+				return nil, fmt.Errorf("series %d value %q: invalid number %q", s.Index, *value.String, *value.String)
+			}
+			out[i] = float64(0)
 		}
-		return nil, fmt.Errorf("invalid number %q", raw)
 	}
-	return value, nil
+	return out, nil
 }
@@ -135,6 +135,9 @@ func TestChartJSExporterArea(t *testing.T) {
 	if fill, ok := datasets[0]["fill"].(bool); !ok || !fill {
 		t.Fatalf("expected fill=true for area dataset")
 	}
+	if _, ok := payload.Data["options"]; ok {
+		t.Fatalf("expected no axis options without a secondary series")
+	}
 }
 
 func TestChartJSExporterMixed(t *testing.T) {
@@ -170,3 +173,169 @@ func TestChartJSExporterMixed(t *testing.T) {
 		t.Fatalf("unexpected dataset types: %#v", datasets)
 	}
 }
+
+func TestChartJSExporterSecondaryAxis(t *testing.T) {
+	exporter := ChartJSExporter{MissingNumericPolicy: MissingNumericEmpty}
+	input := ExtractedChartData{
+		Type:   "line",
+		Labels: []string{"A"},
+		Series: []ExtractedSeries{{
+			Index: 1,
+			Name:  "Right",
+			Data:  []string{"100"},
+			Axis:  "secondary",
+		}, {
+			Index: 0,
+			Name:  "Left",
+			Data:  []string{"1"},
+			Axis:  "primary",
+		}},
+	}
+
+	payload, err := exporter.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	datasets := payload.Data["datasets"].([]map[string]any)
+	if datasets[0]["label"] != "Left" || datasets[0]["yAxisID"] != "y" || datasets[1]["yAxisID"] != "y1" {
+		t.Fatalf("unexpected datasets: %#v", datasets)
+	}
+	options, ok := payload.Data["options"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected axis options, got %#v", payload.Data)
+	}
+	scales := options["scales"].(map[string]any)
+	if scales["y"].(map[string]any)["position"] != "left" || scales["y1"].(map[string]any)["position"] != "right" {
+		t.Fatalf("unexpected scales: %#v", scales)
+	}
+}
+
+func TestChartJSExporterFormatCodes(t *testing.T) {
+	exporter := ChartJSExporter{MissingNumericPolicy: MissingNumericEmpty}
+	input := ExtractedChartData{
+		Type:             "bar",
+		Labels:           []string{"45658"},
+		LabelsFormatCode: "mmm yy",
+		Series: []ExtractedSeries{
+			{Index: 0, Name: "Share", Data: []string{"0.1234"}, FormatCode: "0.0%"},
+			{Index: 1, Name: "Count", Data: []string{"12"}},
+		},
+		Axes: []ChartAxis{{Group: "primary", ValueFormatCode: "#,##0"}},
+	}
+
+	payload, err := exporter.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if payload.Data["labelsFormatCode"] != "mmm yy" {
+		t.Fatalf("expected labelsFormatCode, got %#v", payload.Data)
+	}
+	datasets := payload.Data["datasets"].([]map[string]any)
+	if datasets[0]["formatCode"] != "0.0%" {
+		t.Fatalf("expected dataset formatCode, got %#v", datasets[0])
+	}
+	if _, ok := datasets[1]["formatCode"]; ok {
+		t.Fatalf("a series without a format must not get one: %#v", datasets[1])
+	}
+	scales := payload.Data["options"].(map[string]any)["scales"].(map[string]any)
+	if scales["y"].(map[string]any)["formatCode"] != "#,##0" {
+		t.Fatalf("expected the value axis format on y, got %#v", scales)
+	}
+
+	input.Axes[0].ValueFormatLinked = true
+	payload, err = exporter.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if _, ok := payload.Data["options"]; ok {
+		t.Fatalf("a source-linked axis format must be left to the datasets: %#v", payload.Data["options"])
+	}
+}
+
+func TestChartJSExporterOptions(t *testing.T) {
+	exercisesFeature(t, "export.chartjs-options")
+
+	exporter := ChartJSExporter{
+		Palette: []string{"#111", "#222"},
+		Tension: 0.4,
+		Stacked: true,
+		Scales:  map[string]map[string]any{"y": {"beginAtZero": true, "position": "right"}},
+	}
+	input := ExtractedChartData{
+		Type:   "mixed",
+		Labels: []string{"A", "B"},
+		Series: []ExtractedSeries{
+			{Index: 0, Name: "Revenue", Data: []string{"1", "2"}, PlotType: "bar"},
+			{Index: 1, Name: "Margin", Data: []string{"3", "4"}, PlotType: "line", Axis: "secondary"},
+			{Index: 2, Name: "Target", Data: []string{"5", "6"}, PlotType: "line"},
+		},
+	}
+
+	payload, err := exporter.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	datasets := payload.Data["datasets"].([]map[string]any)
+	for i, color := range []string{"#111", "#222", "#111"} {
+		if datasets[i]["borderColor"] != color || datasets[i]["backgroundColor"] != color {
+			t.Fatalf("dataset %d: expected color %s, got %#v", i, color, datasets[i])
+		}
+	}
+	if _, ok := datasets[0]["tension"]; ok {
+		t.Fatalf("expected no tension on the bar dataset, got %#v", datasets[0])
+	}
+	if datasets[1]["tension"] != 0.4 || datasets[2]["tension"] != 0.4 {
+		t.Fatalf("expected tension on the line datasets, got %#v", datasets)
+	}
+	if datasets[1]["yAxisID"] != "y1" || datasets[2]["yAxisID"] != "y" {
+		t.Fatalf("unexpected axis ids: %#v", datasets)
+	}
+
+	scales := payload.Data["options"].(map[string]any)["scales"].(map[string]any)
+	for _, name := range []string{"x", "y", "y1"} {
+		if scales[name].(map[string]any)["stacked"] != true {
+			t.Fatalf("expected scale %s to be stacked, got %#v", name, scales)
+		}
+	}
+	y := scales["y"].(map[string]any)
+	if y["beginAtZero"] != true || y["position"] != "right" || y["type"] != "linear" {
+		t.Fatalf("expected the y passthrough merged over the exporter's scale, got %#v", y)
+	}
+}
+
+func TestChartJSExporterOptionsPieAndArea(t *testing.T) {
+	exporter := ChartJSExporter{Palette: []string{"red", "blue"}, DisableAreaFill: true, Scales: map[string]map[string]any{"y": {"min": 0}}}
+
+	payload, err := exporter.Export(ExtractedChartData{
+		Type:   "pie",
+		Labels: []string{"A", "B", "C"},
+		Series: []ExtractedSeries{{Index: 0, Name: "Share", Data: []string{"1", "2", "3"}}},
+	})
+	if err != nil {
+		t.Fatalf("Export pie: %v", err)
+	}
+	dataset := payload.Data["datasets"].([]map[string]any)[0]
+	colors, ok := dataset["backgroundColor"].([]string)
+	if !ok || len(colors) != 3 || colors[0] != "red" || colors[1] != "blue" || colors[2] != "red" {
+		t.Fatalf("expected one color per label, got %#v", dataset["backgroundColor"])
+	}
+	if _, ok := payload.Data["options"]; ok {
+		t.Fatalf("expected no scales on a pie chart, got %#v", payload.Data["options"])
+	}
+
+	payload, err = exporter.Export(ExtractedChartData{
+		Type:   "area",
+		Labels: []string{"A", "B"},
+		Series: []ExtractedSeries{{Index: 0, Name: "Volume", Data: []string{"1", "2"}}},
+	})
+	if err != nil {
+		t.Fatalf("Export area: %v", err)
+	}
+	dataset = payload.Data["datasets"].([]map[string]any)[0]
+	if _, ok := dataset["fill"]; ok {
+		t.Fatalf("expected DisableAreaFill to drop fill, got %#v", dataset)
+	}
+	if dataset["backgroundColor"] != "red" {
+		t.Fatalf("expected the first palette color, got %#v", dataset)
+	}
+}
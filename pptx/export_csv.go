@@ -0,0 +1,115 @@
+package pptx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CSVExporter writes extracted chart data as a table: the labels in the
+// first column and one column per series, named by the series, with a
+// header row. Mixed chart series carry their plot type in the header, as in
+// "Revenue (bar)". A scatter series whose x values differ from the chart's
+// labels gets its own x column in front of its values.
+//
+// The payload Data holds "csv", the text as RFC 4180 describes it (CRLF line
+// ends, also inside quoted fields, and fields with the delimiter, quotes, or
+// line breaks quoted), "rows", the same table as [][]string with the cells
+// verbatim, and "delimiter".
+type CSVExporter struct {
+	// Delimiter separates the fields; zero means ','. European Excel
+	// locales expect ';'.
+	Delimiter            rune
+	MissingNumericPolicy MissingNumericPolicy
+}
+
+func (e CSVExporter) Format() ExportFormat {
+	return ExportCSV
+}
+
+func (e CSVExporter) Export(in ExtractedChartData) (ExportedPayload, error) {
+	switch in.Type {
+	case "bar", "line", "pie", "doughnut", "area", "mixed", "stock", "scatter", "radar":
+	default:
+		return ExportedPayload{}, fmt.Errorf("unsupported chart type %q", in.Type)
+	}
+	delimiter := e.Delimiter
+	if delimiter == 0 {
+		delimiter = ','
+	}
+
+	series := make([]ExtractedSeries, len(in.Series))
+	copy(series, in.Series)
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Index < series[j].Index
+	})
+
+	labelHeader := "Category"
+	if in.Type == "scatter" {
+		labelHeader = "X"
+	}
+	header := []string{labelHeader}
+	columns := [][]string{in.Labels}
+	for _, s := range series {
+		name := s.Name
+		if in.Type == "mixed" && s.PlotType != "" {
+			name = fmt.Sprintf("%s (%s)", name, s.PlotType)
+		}
+		if in.Type == "scatter" && s.XValues != nil {
+			header = append(header, name+" X")
+			columns = append(columns, s.XValues)
+		}
+		header = append(header, name)
+		columns = append(columns, csvValues(s.Data, e.MissingNumericPolicy))
+	}
+
+	points := 0
+	for _, column := range columns {
+		if len(column) > points {
+			points = len(column)
+		}
+	}
+	rows := make([][]string, 0, points+1)
+	rows = append(rows, header)
+	for i := 0; i < points; i++ {
+		row := make([]string, len(columns))
+		for c, column := range columns {
+			if i < len(column) {
+				row[c] = column[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	var out strings.Builder
+	writer := csv.NewWriter(&out)
+	writer.Comma = delimiter
+	writer.UseCRLF = true
+	if err := writer.WriteAll(rows); err != nil {
+		return ExportedPayload{}, fmt.Errorf("write csv: %w", err)
+	}
+
+	return ExportedPayload{
+		Format: ExportCSV,
+		Data: map[string]any{
+			"csv":       out.String(),
+			"rows":      rows,
+			"delimiter": string(delimiter),
+		},
+	}, nil
+}
+
+// csvValues returns the cells of a values range, with blanks written as 0
+// under MissingNumericZero.
+func csvValues(data []string, policy MissingNumericPolicy) []string {
+	out := make([]string, len(data))
+	for i, raw := range data {
+		if policy == MissingNumericZero && strings.TrimSpace(raw) == "" {
+			out[i] = "0"
+			continue
+		}
+		out[i] = raw
+	}
+	return out
+}
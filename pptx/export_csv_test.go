@@ -0,0 +1,120 @@
+package pptx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCSVExporterQuotesAndDelimiter(t *testing.T) {
+	input := ExtractedChartData{
+		Type:   "bar",
+		Labels: []string{"North, East", "Say \"hi\"", "two\nlines"},
+		Series: []ExtractedSeries{
+			{Index: 1, Name: "Cost; net", Data: []string{"4", "5", "6"}},
+			{Index: 0, Name: "Revenue", Data: []string{"1.5", "", "3"}},
+		},
+	}
+
+	payload, err := CSVExporter{}.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if payload.Format != ExportCSV || payload.Data["delimiter"] != "," {
+		t.Fatalf("unexpected payload: %#v", payload)
+	}
+	want := "Category,Revenue,Cost; net\r\n" +
+		"\"North, East\",1.5,4\r\n" +
+		"\"Say \"\"hi\"\"\",,5\r\n" +
+		"\"two\r\nlines\",3,6\r\n"
+	if payload.Data["csv"] != want {
+		t.Fatalf("unexpected csv:\n%q\nwant\n%q", payload.Data["csv"], want)
+	}
+	rows := payload.Data["rows"].([][]string)
+	if !reflect.DeepEqual(rows[0], []string{"Category", "Revenue", "Cost; net"}) || !reflect.DeepEqual(rows[2], []string{"Say \"hi\"", "", "5"}) {
+		t.Fatalf("unexpected rows: %#v", rows)
+	}
+
+	payload, err = CSVExporter{Delimiter: ';', MissingNumericPolicy: MissingNumericZero}.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	want = "Category;Revenue;\"Cost; net\"\r\n" +
+		"North, East;1.5;4\r\n" +
+		"\"Say \"\"hi\"\"\";0;5\r\n" +
+		"\"two\r\nlines\";3;6\r\n"
+	if payload.Data["csv"] != want || payload.Data["delimiter"] != ";" {
+		t.Fatalf("unexpected csv:\n%q\nwant\n%q", payload.Data["csv"], want)
+	}
+
+	if _, err := (CSVExporter{Delimiter: '"'}).Export(input); err == nil {
+		t.Fatalf("expected an invalid delimiter to fail")
+	}
+}
+
+func TestCSVExporterMixedAndScatter(t *testing.T) {
+	payload, err := CSVExporter{}.Export(ExtractedChartData{
+		Type:   "mixed",
+		Labels: []string{"Q1", "Q2"},
+		Series: []ExtractedSeries{
+			{Index: 0, Name: "Revenue", Data: []string{"10", "20"}, PlotType: "bar"},
+			{Index: 1, Name: "Margin", Data: []string{"0.1", "0.2"}, PlotType: "line"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Export mixed: %v", err)
+	}
+	if want := "Category,Revenue (bar),Margin (line)\r\nQ1,10,0.1\r\nQ2,20,0.2\r\n"; payload.Data["csv"] != want {
+		t.Fatalf("unexpected mixed csv: %q", payload.Data["csv"])
+	}
+
+	payload, err = CSVExporter{}.Export(ExtractedChartData{
+		Type:   "scatter",
+		Labels: []string{"1", "2", "3"},
+		Series: []ExtractedSeries{
+			{Index: 0, Name: "A", Data: []string{"5", "6", "7"}},
+			{Index: 1, Name: "B", Data: []string{"8", "9"}, XValues: []string{"10", "20"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Export scatter: %v", err)
+	}
+	if want := "X,A,B X,B\r\n1,5,10,8\r\n2,6,20,9\r\n3,7,,\r\n"; payload.Data["csv"] != want {
+		t.Fatalf("unexpected scatter csv: %q", payload.Data["csv"])
+	}
+
+	if _, err := (CSVExporter{}).Export(ExtractedChartData{Type: "bubble"}); err == nil {
+		t.Fatalf("expected an unsupported chart type to fail")
+	}
+}
+
+func TestExportChartByPathFormatCSV(t *testing.T) {
+	exercisesFeature(t, "export.csv")
+
+	opts := DefaultOptions()
+	opts.Export.CSVDelimiter = ';'
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	payload, err := doc.ExportChartByPathFormat("ppt/charts/chart1.xml", ExportCSV)
+	if err != nil {
+		t.Fatalf("ExportChartByPathFormat: %v", err)
+	}
+	rows := payload.Data["rows"].([][]string)
+	if len(rows) != len(data.Labels)+1 || len(rows[0]) != len(data.Series)+1 {
+		t.Fatalf("unexpected table shape: %#v", rows)
+	}
+	if rows[0][1] != data.Series[0].Name || rows[1][0] != data.Labels[0] || rows[1][1] != data.Series[0].Data[0] {
+		t.Fatalf("table does not match the extracted data: %#v", rows)
+	}
+	if payload.Data["delimiter"] != ";" {
+		t.Fatalf("expected Options.Export.CSVDelimiter to be used, got %#v", payload.Data["delimiter"])
+	}
+	if _, ok := DefaultExporterRegistry().Get(ExportCSV); !ok {
+		t.Fatalf("expected csv exporter in default registry")
+	}
+}
@@ -3,6 +3,7 @@ package pptx
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -18,8 +19,9 @@ func NewExporterRegistry() *ExporterRegistry {
 	}
 }
 
-// DefaultExporterRegistry returns a new registry with built-in exporters.
-// It does not share state across calls.
+// DefaultExporterRegistry returns a new registry with built-in exporters
+// and those added by RegisterDefaultExporter. It does not share state across
+// calls.
 func DefaultExporterRegistry() *ExporterRegistry {
 	return defaultExporterRegistry(DefaultOptions())
 }
@@ -27,10 +29,68 @@ func DefaultExporterRegistry() *ExporterRegistry {
 func defaultExporterRegistry(opts Options) *ExporterRegistry {
 	reg := NewExporterRegistry()
 	_ = reg.Register(ChartJSExporter{MissingNumericPolicy: opts.Workbook.MissingNumericPolicy})
+	_ = reg.Register(CSVExporter{Delimiter: opts.Export.CSVDelimiter, MissingNumericPolicy: opts.Workbook.MissingNumericPolicy})
+	_ = reg.Register(VegaLiteExporter{MissingNumericPolicy: opts.Workbook.MissingNumericPolicy})
+	for _, exp := range processExporters.list() {
+		_ = reg.Register(exp, ReplaceExporter())
+	}
 	return reg
 }
 
-func (r *ExporterRegistry) Register(exp Exporter) error {
+// processExporters holds the exporters added by RegisterDefaultExporter.
+var processExporters = NewExporterRegistry()
+
+// builtinExportFormats are the formats defaultExporterRegistry always has.
+var builtinExportFormats = map[ExportFormat]bool{
+	ExportChartJS:  true,
+	ExportCSV:      true,
+	ExportVegaLite: true,
+}
+
+// RegisterDefaultExporter adds exp to the registry of every Document opened
+// afterwards without WithExporterRegistry, and to DefaultExporterRegistry.
+// Documents already open keep their registry; use Document.RegisterExporter
+// for those. A format that is built in or already registered is an error
+// unless ReplaceExporter is given. It is safe for concurrent use.
+func RegisterDefaultExporter(exp Exporter, opts ...RegisterOption) error {
+	if exp == nil {
+		return fmt.Errorf("exporter is nil")
+	}
+	format := exp.Format()
+	if builtinExportFormats[format] && !registerConfig(opts).replace {
+		return fmt.Errorf("exporter format %q already registered", format)
+	}
+	return processExporters.Register(exp, opts...)
+}
+
+// RegisterOption adjusts how an exporter is registered.
+type RegisterOption func(*registerOptions)
+
+type registerOptions struct {
+	replace bool
+}
+
+// ReplaceExporter registers the exporter over one already registered for
+// its format instead of failing.
+func ReplaceExporter() RegisterOption {
+	return func(o *registerOptions) {
+		o.replace = true
+	}
+}
+
+func registerConfig(opts []RegisterOption) registerOptions {
+	var cfg registerOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+// Register adds exp under its format. A format already registered is an
+// error unless ReplaceExporter is given.
+func (r *ExporterRegistry) Register(exp Exporter, opts ...RegisterOption) error {
 	if r == nil {
 		return fmt.Errorf("exporter registry is nil")
 	}
@@ -41,11 +101,12 @@ func (r *ExporterRegistry) Register(exp Exporter) error {
 	if format == "" {
 		return fmt.Errorf("exporter format is empty")
 	}
+	cfg := registerConfig(opts)
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.exporters[format]; exists {
+	if _, exists := r.exporters[format]; exists && !cfg.replace {
 		return fmt.Errorf("exporter format %q already registered", format)
 	}
 	r.exporters[format] = exp
@@ -85,6 +146,30 @@ func (r *ExporterRegistry) Formats() []ExportFormat {
 	return formats
 }
 
+// list returns the registered exporters in format order.
+func (r *ExporterRegistry) list() []Exporter {
+	formats := r.Formats()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Exporter, 0, len(formats))
+	for _, format := range formats {
+		if exp, ok := r.exporters[format]; ok {
+			out = append(out, exp)
+		}
+	}
+	return out
+}
+
+// formatList joins the registered formats for messages.
+func (r *ExporterRegistry) formatList() string {
+	formats := r.Formats()
+	names := make([]string, len(formats))
+	for i, format := range formats {
+		names[i] = string(format)
+	}
+	return strings.Join(names, ",")
+}
+
 func (r *ExporterRegistry) Unregister(format ExportFormat) {
 	if r == nil || format == "" {
 		return
@@ -2,6 +2,8 @@ package pptx
 
 import (
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -63,6 +65,8 @@ func TestDefaultExporterRegistryHasChartJS(t *testing.T) {
 }
 
 func TestExportChartByPathFormatMatchesExporter(t *testing.T) {
+	exercisesFeature(t, "export.chartjs")
+
 	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
 	if err != nil {
 		t.Fatalf("OpenFile: %v", err)
@@ -101,4 +105,79 @@ func TestExportChartByPathFormatUnknown(t *testing.T) {
 	if len(alerts) != 1 {
 		t.Fatalf("expected EXPORT_FORMAT_UNSUPPORTED alert, got %d", len(alerts))
 	}
+	if got := alerts[0].Context["formats"]; got != "chartjs,csv,vegalite" {
+		t.Fatalf("expected the registered formats in the alert context, got %q", got)
+	}
+}
+
+func TestDocumentRegisterExporter(t *testing.T) {
+	exercisesFeature(t, "export.register")
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.RegisterExporter(dummyExporter{format: ExportChartJS}); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("expected a built-in format collision to fail, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = doc.RegisterExporter(dummyExporter{format: "custom"})
+			_, _ = doc.ExportChartByPathFormat("ppt/charts/chart1.xml", ExportChartJS)
+		}()
+	}
+	wg.Wait()
+
+	payload, err := doc.ExportChartByPathFormat("ppt/charts/chart1.xml", "custom")
+	if err != nil || payload.Format != "custom" {
+		t.Fatalf("expected the custom format to export, got %#v %v", payload, err)
+	}
+
+	if err := doc.RegisterExporter(dummyExporter{format: ExportChartJS}, ReplaceExporter()); err != nil {
+		t.Fatalf("RegisterExporter with ReplaceExporter: %v", err)
+	}
+	payload, err = doc.ExportChartByPathFormat("ppt/charts/chart1.xml", ExportChartJS)
+	if err != nil || payload.Data != nil {
+		t.Fatalf("expected the replacement chartjs exporter, got %#v %v", payload, err)
+	}
+}
+
+func TestRegisterDefaultExporter(t *testing.T) {
+	t.Cleanup(func() { processExporters.Unregister("process") })
+
+	if err := RegisterDefaultExporter(dummyExporter{format: ExportCSV}); err == nil {
+		t.Fatalf("expected a built-in format collision to fail")
+	}
+	if err := RegisterDefaultExporter(dummyExporter{format: "process"}); err != nil {
+		t.Fatalf("RegisterDefaultExporter: %v", err)
+	}
+	if err := RegisterDefaultExporter(dummyExporter{format: "process"}); err == nil {
+		t.Fatalf("expected a second registration to fail")
+	}
+	if err := RegisterDefaultExporter(dummyExporter{format: "process"}, ReplaceExporter()); err != nil {
+		t.Fatalf("RegisterDefaultExporter with ReplaceExporter: %v", err)
+	}
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if payload, err := doc.ExportChartByPathFormat("ppt/charts/chart1.xml", "process"); err != nil || payload.Format != "process" {
+		t.Fatalf("expected new documents to have the process exporter, got %#v %v", payload, err)
+	}
+	if _, ok := DefaultExporterRegistry().Get("process"); !ok {
+		t.Fatalf("expected DefaultExporterRegistry to include the process exporter")
+	}
+
+	custom, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithExporterRegistry(NewExporterRegistry()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, ok := custom.exporterRegistry().Get("process"); ok {
+		t.Fatalf("WithExporterRegistry must not pick up process exporters")
+	}
 }
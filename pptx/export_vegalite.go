@@ -0,0 +1,378 @@
+package pptx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const vegaLiteSchema = "https://vega.github.io/schema/vega-lite/v5.json"
+
+// VegaLiteExporter builds a Vega-Lite v5 spec from extracted chart data.
+// data.values holds one row per series point with the fields "label",
+// "series", "seriesIndex", and "value" ("x" instead of "label" for scatter
+// charts). The labels are the x encoding, in workbook order, and the series
+// the color. Bar, line, area, and stock charts map to the bar, line, area,
+// and line marks, scatter charts to points, and pie and doughnut charts to
+// arcs whose theta is the value and color the label, one ring per doughnut
+// series. Mixed charts are layered by plot type; series with Axis
+// "secondary" go in a second layer group with resolve.scale.y
+// "independent", whatever the chart type.
+//
+// A Vega-Lite field is either quantitative or not, so when a value (or a
+// scatter x value) does not parse as a number the whole field falls back to
+// the cells' text with type "ordinal", and the payload carries an
+// EXPORT_VALUE_NOT_NUMERIC alert for each series with such a point.
+// MissingNumericZero turns blanks and text values into 0, as it does for
+// ChartJSExporter.
+//
+// The payload Data is the spec itself, ready for json.Marshal.
+type VegaLiteExporter struct {
+	MissingNumericPolicy MissingNumericPolicy
+}
+
+func (e VegaLiteExporter) Format() ExportFormat {
+	return ExportVegaLite
+}
+
+func (e VegaLiteExporter) Export(in ExtractedChartData) (ExportedPayload, error) {
+	switch in.Type {
+	case "bar", "line", "pie", "doughnut", "area", "mixed", "stock", "scatter":
+	default:
+		return ExportedPayload{}, fmt.Errorf("unsupported chart type %q", in.Type)
+	}
+
+	series := make([]ExtractedSeries, len(in.Series))
+	copy(series, in.Series)
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Index < series[j].Index
+	})
+
+	zero := e.MissingNumericPolicy == MissingNumericZero
+	cells := make([][]string, len(series))
+	typed := make([][]ChartValue, len(series))
+	for i, s := range series {
+		cells[i] = s.Data
+		typed[i] = s.typedValues()
+	}
+	values := vegaLiteFieldOf(cells, typed, zero)
+
+	var alerts []Alert
+	for i, s := range series {
+		if text, ok := values.text[i]; ok {
+			alerts = append(alerts, vegaLiteNotNumeric(in, s, "value", text))
+		}
+	}
+
+	var xs vegaLiteField
+	if in.Type == "scatter" {
+		xCells := make([][]string, len(series))
+		xTyped := make([][]ChartValue, len(series))
+		for i, s := range series {
+			xCells[i] = in.Labels
+			if s.XValues != nil {
+				xCells[i] = s.XValues
+			}
+			xTyped[i] = chartValues(xCells[i], MissingNumericEmpty)
+		}
+		xs = vegaLiteFieldOf(xCells, xTyped, false)
+		for i, s := range series {
+			if text, ok := xs.text[i]; ok {
+				alerts = append(alerts, vegaLiteNotNumeric(in, s, "x", text))
+			}
+		}
+	}
+
+	rows := make([]map[string]any, 0)
+	for i, s := range series {
+		for j := range values.values[i] {
+			row := map[string]any{
+				"series":      s.Name,
+				"seriesIndex": s.Index,
+				"value":       values.values[i][j],
+			}
+			if in.Type == "scatter" {
+				var x any
+				if j < len(xs.values[i]) {
+					x = xs.values[i][j]
+				}
+				row["x"] = x
+			} else {
+				label := ""
+				if j < len(in.Labels) {
+					label = in.Labels[j]
+				}
+				row["label"] = label
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	spec := map[string]any{
+		"$schema": vegaLiteSchema,
+		"data":    map[string]any{"values": rows},
+	}
+	switch in.Type {
+	case "pie":
+		if len(series) != 1 {
+			return ExportedPayload{}, fmt.Errorf("pie chart requires a single series")
+		}
+		spec["mark"] = map[string]any{"type": "arc"}
+		spec["encoding"] = vegaLiteArcEncoding(values.typ)
+	case "doughnut":
+		if len(series) == 1 {
+			spec["mark"] = map[string]any{"type": "arc", "innerRadius": vegaLiteRingInner}
+			spec["encoding"] = vegaLiteArcEncoding(values.typ)
+			break
+		}
+		// The first series is the inner ring, as PowerPoint draws it.
+		layers := make([]map[string]any, 0, len(series))
+		for k, s := range series {
+			layers = append(layers, map[string]any{
+				"transform": vegaLiteSeriesFilter([]int{s.Index}),
+				"mark": map[string]any{
+					"type":    "arc",
+					"radius2": vegaLiteRingInner + k*vegaLiteRingWidth,
+					"radius":  vegaLiteRingInner + (k+1)*vegaLiteRingWidth,
+				},
+				"encoding": vegaLiteArcEncoding(values.typ),
+			})
+		}
+		spec["layer"] = layers
+	default:
+		if err := vegaLiteLayers(spec, in, series, values.typ, xs.typ); err != nil {
+			return ExportedPayload{}, err
+		}
+	}
+
+	return ExportedPayload{
+		Format: ExportVegaLite,
+		Data:   spec,
+		Alerts: alerts,
+	}, nil
+}
+
+// Doughnut rings are drawn vegaLiteRingWidth pixels wide around a hole of
+// vegaLiteRingInner pixels.
+const (
+	vegaLiteRingInner = 40
+	vegaLiteRingWidth = 40
+)
+
+// vegaLiteLayers sets the mark and encoding of a bar, line, area, stock,
+// scatter, or mixed chart on spec. A chart with one plot type on one axis is
+// a single view; otherwise each plot type is a layer filtered to its series,
+// and the secondary-axis layers form a group whose y scale is independent of
+// the primary one.
+func vegaLiteLayers(spec map[string]any, in ExtractedChartData, series []ExtractedSeries, valueType, xType string) error {
+	if in.Type == "mixed" && len(series) == 0 {
+		return fmt.Errorf("mixed chart requires at least one series")
+	}
+
+	type plot struct {
+		plotType string
+		series   []int
+	}
+	var groups [2][]*plot
+	for _, s := range series {
+		plotType := in.Type
+		if in.Type == "mixed" {
+			plotType = s.PlotType
+			switch plotType {
+			case "bar", "line", "area":
+			default:
+				return fmt.Errorf("mixed chart series %d has unsupported plot type %q", s.Index, s.PlotType)
+			}
+		}
+		group := 0
+		if s.Axis == "secondary" {
+			group = 1
+		}
+		var target *plot
+		for _, p := range groups[group] {
+			if p.plotType == plotType {
+				target = p
+				break
+			}
+		}
+		if target == nil {
+			target = &plot{plotType: plotType}
+			groups[group] = append(groups[group], target)
+		}
+		target.series = append(target.series, s.Index)
+	}
+
+	var categoryReversed bool
+	var valueInverted [2]bool
+	for _, axis := range in.Axes {
+		if axis.Group == "secondary" {
+			valueInverted[1] = axis.ValueInverted
+			continue
+		}
+		categoryReversed = axis.CategoryReversed
+		valueInverted[0] = axis.ValueInverted
+	}
+
+	view := func(group int, p *plot) map[string]any {
+		x := map[string]any{"field": "label", "type": "ordinal", "sort": nil}
+		if in.Type == "scatter" {
+			x = map[string]any{"field": "x", "type": xType}
+		}
+		if categoryReversed {
+			x["scale"] = map[string]any{"reverse": true}
+		}
+		y := map[string]any{"field": "value", "type": valueType}
+		if valueInverted[group] {
+			y["scale"] = map[string]any{"reverse": true}
+		}
+		encoding := map[string]any{
+			"x":     x,
+			"y":     y,
+			"color": map[string]any{"field": "series", "type": "nominal", "sort": nil},
+		}
+
+		var mark map[string]any
+		switch p.plotType {
+		case "bar":
+			mark = map[string]any{"type": "bar"}
+			if len(p.series) > 1 {
+				encoding["xOffset"] = map[string]any{"field": "series", "type": "nominal", "sort": nil}
+			}
+		case "area":
+			mark = map[string]any{"type": "area", "opacity": 0.7}
+		case "scatter":
+			mark = map[string]any{"type": "point"}
+		default:
+			mark = map[string]any{"type": "line", "point": true}
+		}
+		if (p.plotType == "bar" || p.plotType == "area") && valueType == "quantitative" {
+			// PowerPoint clusters bars and overlaps areas; Vega-Lite would stack them.
+			y["stack"] = nil
+		}
+		return map[string]any{"mark": mark, "encoding": encoding}
+	}
+
+	layers := func(group int) []map[string]any {
+		out := make([]map[string]any, 0, len(groups[group]))
+		for _, p := range groups[group] {
+			layer := view(group, p)
+			layer["transform"] = vegaLiteSeriesFilter(p.series)
+			out = append(out, layer)
+		}
+		return out
+	}
+
+	if len(groups[0]) > 0 && len(groups[1]) > 0 {
+		groupSpecs := make([]map[string]any, 0, 2)
+		for group := range groups {
+			if views := layers(group); len(views) == 1 {
+				groupSpecs = append(groupSpecs, views[0])
+			} else {
+				groupSpecs = append(groupSpecs, map[string]any{"layer": views})
+			}
+		}
+		spec["layer"] = groupSpecs
+		spec["resolve"] = map[string]any{"scale": map[string]any{"y": "independent"}}
+		return nil
+	}
+
+	group := 0
+	if len(groups[0]) == 0 {
+		group = 1
+	}
+	switch len(groups[group]) {
+	case 0:
+		// No series: an empty view of the chart's mark.
+		groups[group] = append(groups[group], &plot{plotType: in.Type})
+		fallthrough
+	case 1:
+		for key, value := range view(group, groups[group][0]) {
+			spec[key] = value
+		}
+	default:
+		spec["layer"] = layers(group)
+	}
+	return nil
+}
+
+// vegaLiteArcEncoding is the encoding of a pie or doughnut ring.
+func vegaLiteArcEncoding(valueType string) map[string]any {
+	return map[string]any{
+		"theta": map[string]any{"field": "value", "type": valueType},
+		"color": map[string]any{"field": "label", "type": "nominal", "sort": nil},
+	}
+}
+
+// vegaLiteSeriesFilter keeps the rows of the series with the given Index.
+func vegaLiteSeriesFilter(indices []int) []map[string]any {
+	return []map[string]any{{
+		"filter": map[string]any{"field": "seriesIndex", "oneOf": indices},
+	}}
+}
+
+// vegaLiteField is one data field of the spec, per series and point. typ is
+// "quantitative", or "ordinal" when a point is text; an ordinal field holds
+// the trimmed text of every non-blank cell. text has the first text point of
+// each series position that has one.
+type vegaLiteField struct {
+	values [][]any
+	typ    string
+	text   map[int]string
+}
+
+// vegaLiteFieldOf types cells by typed, the ChartValue of each cell. zero
+// stands for MissingNumericZero, under which text is 0 rather than a reason
+// to fall back.
+func vegaLiteFieldOf(cells [][]string, typed [][]ChartValue, zero bool) vegaLiteField {
+	field := vegaLiteField{
+		values: make([][]any, len(cells)),
+		typ:    "quantitative",
+		text:   map[int]string{},
+	}
+	if !zero {
+		for i := range typed {
+			for _, value := range typed[i] {
+				if value.Number == nil && !value.Empty {
+					field.text[i] = *value.String
+					break
+				}
+			}
+		}
+	}
+	if len(field.text) > 0 {
+		field.typ = "ordinal"
+	}
+
+	for i := range typed {
+		out := make([]any, len(typed[i]))
+		for j, value := range typed[i] {
+			switch {
+			case value.Empty && !zero:
+			case field.typ == "ordinal":
+				out[j] = strings.TrimSpace(cells[i][j])
+			case value.Number != nil:
+				out[j] = *value.Number
+			default:
+				out[j] = float64(0)
+			}
+		}
+		field.values[i] = out
+	}
+	return field
+}
+
+func vegaLiteNotNumeric(in ExtractedChartData, s ExtractedSeries, field, text string) Alert {
+	return Alert{
+		Level:   "warn",
+		Code:    "EXPORT_VALUE_NOT_NUMERIC",
+		Message: "Series has text values; Vega-Lite field exported as ordinal strings",
+		Context: map[string]string{
+			"chart":  in.Meta.ChartPath,
+			"format": string(ExportVegaLite),
+			"series": strconv.Itoa(s.Index),
+			"field":  field,
+			"value":  text,
+		},
+	}
+}
@@ -0,0 +1,157 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVegaLiteExporterGolden(t *testing.T) {
+	exercisesFeature(t, "export.vegalite")
+
+	cases := []struct {
+		name    string
+		fixture string
+	}{
+		{name: "bar", fixture: "bar_simple_embedded.pptx"},
+		{name: "bar_category_reversed", fixture: "bar_category_reversed.pptx"},
+		{name: "line", fixture: "line_multi_series_embedded.pptx"},
+		{name: "line_secondary_axis", fixture: "line_secondary_axis.pptx"},
+		{name: "area", fixture: "area_multi_series_valid.pptx"},
+		{name: "pie", fixture: "pie_simple_embedded.pptx"},
+		{name: "doughnut", fixture: "doughnut_two_rings_embedded.pptx"},
+		{name: "scatter", fixture: "scatter_xy.pptx"},
+		{name: "stock", fixture: "stock_hlc.pptx"},
+		{name: "mixed", fixture: "mix_bar_line_simple.pptx"},
+		{name: "mixed_secondary_axis", fixture: "mix_write_secondary_axis_valid.pptx"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := OpenFile(fixturePath(tc.fixture))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			payload, err := doc.ExportChartByPathFormat("ppt/charts/chart1.xml", ExportVegaLite)
+			if err != nil {
+				t.Fatalf("ExportChartByPathFormat: %v", err)
+			}
+			if payload.Format != ExportVegaLite || payload.Data["$schema"] != vegaLiteSchema || len(payload.Alerts) != 0 {
+				t.Fatalf("unexpected payload: %#v", payload)
+			}
+			got, err := json.MarshalIndent(payload.Data, "", "  ")
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			got = append(got, '\n')
+
+			goldenPath := filepath.Join("..", "testdata", "golden", "vegalite", tc.name+".json")
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+					t.Fatalf("MkdirAll: %v", err)
+				}
+				if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("golden spec missing: %s (run tests with -update-golden)", goldenPath)
+				}
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("spec differs from %s:\n%s", goldenPath, got)
+			}
+		})
+	}
+}
+
+func TestVegaLiteExporterNonNumericFallsBack(t *testing.T) {
+	input := ExtractedChartData{
+		Type:   "bar",
+		Labels: []string{"A", "B", "C"},
+		Series: []ExtractedSeries{
+			{Index: 0, Name: "Sales", Data: []string{"1", "n/a", ""}},
+			{Index: 1, Name: "Cost", Data: []string{"2", "3", "4"}},
+		},
+		Meta: ExtractMeta{ChartPath: "ppt/charts/chart1.xml"},
+	}
+
+	payload, err := VegaLiteExporter{}.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	rows := payload.Data["data"].(map[string]any)["values"].([]map[string]any)
+	if len(rows) != 6 || rows[0]["value"] != "1" || rows[1]["value"] != "n/a" || rows[2]["value"] != nil || rows[5]["value"] != "4" {
+		t.Fatalf("expected ordinal strings, got %#v", rows)
+	}
+	y := payload.Data["encoding"].(map[string]any)["y"].(map[string]any)
+	if y["type"] != "ordinal" {
+		t.Fatalf("expected an ordinal y encoding, got %#v", y)
+	}
+	if len(payload.Alerts) != 1 || payload.Alerts[0].Code != "EXPORT_VALUE_NOT_NUMERIC" || payload.Alerts[0].Context["series"] != "0" || payload.Alerts[0].Context["value"] != "n/a" {
+		t.Fatalf("unexpected alerts: %#v", payload.Alerts)
+	}
+
+	payload, err = VegaLiteExporter{MissingNumericPolicy: MissingNumericZero}.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	rows = payload.Data["data"].(map[string]any)["values"].([]map[string]any)
+	if rows[1]["value"] != float64(0) || rows[2]["value"] != float64(0) || len(payload.Alerts) != 0 {
+		t.Fatalf("expected MissingNumericZero to write zeros, got %#v %#v", rows, payload.Alerts)
+	}
+
+	input = ExtractedChartData{
+		Type:   "scatter",
+		Labels: []string{"1", "two"},
+		Series: []ExtractedSeries{{Index: 0, Name: "Y", Data: []string{"5", "6"}}},
+	}
+	payload, err = VegaLiteExporter{}.Export(input)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	x := payload.Data["encoding"].(map[string]any)["x"].(map[string]any)
+	if x["type"] != "ordinal" || len(payload.Alerts) != 1 || payload.Alerts[0].Context["field"] != "x" {
+		t.Fatalf("expected the scatter x field to fall back, got %#v %#v", x, payload.Alerts)
+	}
+}
+
+func TestVegaLiteExporterRejectsRadar(t *testing.T) {
+	if _, err := (VegaLiteExporter{}).Export(ExtractedChartData{Type: "radar"}); err == nil {
+		t.Fatalf("expected radar charts to be unsupported")
+	}
+}
+
+type alertingExporter struct{}
+
+func (alertingExporter) Format() ExportFormat { return "alerting" }
+
+func (alertingExporter) Export(in ExtractedChartData) (ExportedPayload, error) {
+	return ExportedPayload{Format: "alerting", Alerts: []Alert{{Level: "warn", Code: "EXPORT_VALUE_NOT_NUMERIC", Context: map[string]string{"chart": in.Meta.ChartPath}}}}, nil
+}
+
+func TestExportAddsPayloadAlerts(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	payload, err := doc.ExportChartByPath("ppt/charts/chart1.xml", alertingExporter{})
+	if err != nil {
+		t.Fatalf("ExportChartByPath: %v", err)
+	}
+	if _, err := doc.ExportAllCharts(alertingExporter{}); err != nil {
+		t.Fatalf("ExportAllCharts: %v", err)
+	}
+	alerts := doc.AlertsByCode("EXPORT_VALUE_NOT_NUMERIC")
+	if len(alerts) != 2 || !reflect.DeepEqual(alerts[0].Context, payload.Alerts[0].Context) {
+		t.Fatalf("expected the payload alerts on the document, got %#v", doc.Alerts())
+	}
+}
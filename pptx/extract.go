@@ -1,35 +1,126 @@
 package pptx
 
 import (
-	"archive/zip"
-	"bytes"
-	"encoding/xml"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"sort"
+	"strconv"
 	"strings"
 
 	"why-pptx/internal/chartdiscover"
 	"why-pptx/internal/chartxml"
 	"why-pptx/internal/xlref"
 	"why-pptx/internal/xlsxembed"
+	"why-pptx/internal/xmlcancel"
 )
 
+// ErrPieMultipleSeries is wrapped by the error Strict extraction returns for
+// a pie chart with more than one series.
+var ErrPieMultipleSeries = errors.New("pie chart has more than one series")
+
 type ExtractedChartData struct {
-	Type   string            `json:"type"`
-	Labels []string          `json:"labels"`
-	Series []ExtractedSeries `json:"series"`
-	Meta   ExtractMeta       `json:"meta"`
+	Type   string   `json:"type"`
+	Labels []string `json:"labels"`
+	// LabelGroups holds the outer levels of multi-level categories
+	// (c:multiLvlStrRef), outermost first, while Labels holds the innermost
+	// one. Each level has one entry per label: the group's name at its
+	// first label and "" at the labels that continue the group. It is nil
+	// for single-level categories.
+	LabelGroups [][]string `json:"labelGroups,omitempty"`
+	// LabelsFormatCode is the numCache formatCode of the categories, such
+	// as "mmm yy" for date labels; empty when they are text.
+	LabelsFormatCode string `json:"labelsFormatCode,omitempty"`
+	// RawLabels holds the labels as read when Options.Extract.ConvertDates
+	// rewrote date serials in Labels; it is nil otherwise.
+	RawLabels []string          `json:"rawLabels,omitempty"`
+	Series    []ExtractedSeries `json:"series"`
+	// Axes lists the chart's category/value axis pairs, primary first. Pie
+	// charts have none.
+	Axes []ChartAxis `json:"axes,omitempty"`
+	// TypeDetails qualifies Type where one word is not enough. Stock charts
+	// report their legs: "high-low-close" or "open-high-low-close".
+	TypeDetails string `json:"typeDetails,omitempty"`
+	// DataLabels is the chart's plot-level data labels, the default for
+	// series without their own; nil when the chart has none.
+	DataLabels *DataLabels `json:"dataLabels,omitempty"`
+	Meta       ExtractMeta `json:"meta"`
+}
+
+// ChartAxis is one category/value axis pair. The flags are presentation
+// hints from c:orientation maxMin: Labels and Data stay in workbook order,
+// and a renderer reverses the axis to match the deck.
+type ChartAxis struct {
+	// Group is "primary", or "secondary" for the axes of a secondary plot.
+	Group            string `json:"group"`
+	CategoryReversed bool   `json:"categoryReversed"`
+	ValueInverted    bool   `json:"valueInverted"`
+	// ValueFormatCode is the value axis numFmt, empty when the axis has
+	// none. ValueFormatLinked is set for sourceLinked="1", where the tick
+	// labels follow the workbook cells' format; see SetValueAxisNumberFormat.
+	ValueFormatCode   string `json:"valueFormatCode,omitempty"`
+	ValueFormatLinked bool   `json:"valueFormatLinked,omitempty"`
+	// CategoryFormatCode is the category axis numFmt, empty when the axis
+	// has none.
+	CategoryFormatCode string `json:"categoryFormatCode,omitempty"`
+}
+
+func chartAxes(plots []chartxml.MixedPlot, groups []chartxml.AxisGroup) []ChartAxis {
+	if len(groups) == 0 {
+		return nil
+	}
+	roles := chartxml.AxisGroupRoles(plots, groups)
+	out := make([]ChartAxis, len(groups))
+	for i, group := range groups {
+		out[i] = ChartAxis{
+			Group:              roles[i],
+			CategoryReversed:   group.CatReversed,
+			ValueInverted:      group.ValReversed,
+			ValueFormatCode:    group.ValFormatCode,
+			ValueFormatLinked:  group.ValSourceLinked,
+			CategoryFormatCode: group.CatFormatCode,
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Group == "primary" && out[j].Group != "primary"
+	})
+	return out
 }
 
 type ExtractedSeries struct {
-	Index int      `json:"index"`
-	Name  string   `json:"name"`
-	Data  []string `json:"data"`
+	// Index is the position of the series in ExtractedChartData.Series,
+	// 0 to N-1 in the chart's c:order, and the N of its "values:N" key in
+	// ChartDataInput.
+	Index int `json:"index"`
+	// OriginalIndex is the series' c:idx in the chart. PowerPoint leaves gaps
+	// when a series is deleted, so it can skip numbers; ChartDataInput
+	// accepts it as the key number too.
+	OriginalIndex int      `json:"originalIndex"`
+	Name          string   `json:"name"`
+	Data          []string `json:"data"`
+	// Values types each point of Data: a number, a blank cell, or text
+	// that does not parse as a number. Blank cells follow
+	// Options.Workbook.MissingNumericPolicy, so MissingNumericZero reports
+	// them as the number 0.
+	Values []ChartValue `json:"values,omitempty"`
 	// PlotType is set for mixed charts (e.g., "bar" or "line").
 	PlotType string `json:"plotType,omitempty"`
-	// Axis is set for mixed charts when a secondary axis is detected.
+	// Axis is set when the chart has more than one value axis ("primary" or
+	// "secondary").
 	Axis string `json:"axis,omitempty"`
+	// LabelTexts holds the cells of the series' data label range
+	// (c15:datalabelsRange), one per point, when its labels show a range
+	// rather than the values.
+	LabelTexts []string `json:"labelTexts,omitempty"`
+	// XValues is set for a scatter series whose x values differ from the
+	// chart's Labels, which hold the x values of the first series.
+	XValues []string `json:"xValues,omitempty"`
+	// FormatCode is the numCache formatCode of the values, such as "0.0%"
+	// or "#,##0", which PowerPoint shows them in. Data holds the raw cell
+	// values; the value axis format is ChartAxis.ValueFormatCode.
+	FormatCode string `json:"formatCode,omitempty"`
+	// DataLabels is the series' own c:dLbls, nil when it takes the chart's.
+	DataLabels *DataLabels `json:"dataLabels,omitempty"`
 }
 
 type ExtractMeta struct {
@@ -37,18 +128,37 @@ type ExtractMeta struct {
 	SlidePath    string `json:"slidePath"`
 	WorkbookPath string `json:"workbookPath"`
 	Sheet        string `json:"sheet,omitempty"`
+	// Source is ExtractSourceWorkbook, or ExtractSourceCache for a chart
+	// read from its caches by Options.Extract.UseCacheFallback.
+	Source string `json:"source"`
+	// LabelsDateSystem is "1900" or "1904", the date system of the serials
+	// Options.Extract.ConvertDates converted in Labels; empty when none were.
+	LabelsDateSystem string `json:"labelsDateSystem,omitempty"`
 }
 
+// Sources of ExtractMeta. Workbook data is read from the cells the chart
+// references; cache data is what the chart last saved, which can be stale.
+const (
+	ExtractSourceWorkbook = "workbook"
+	ExtractSourceCache    = "cache"
+)
+
 type ExportFormat string
 
 const (
-	ExportChartJS ExportFormat = "chartjs"
-	ExportD3      ExportFormat = "d3"
+	ExportChartJS  ExportFormat = "chartjs"
+	ExportD3       ExportFormat = "d3"
+	ExportCSV      ExportFormat = "csv"
+	ExportVegaLite ExportFormat = "vegalite"
 )
 
 type ExportedPayload struct {
 	Format ExportFormat   `json:"format"`
 	Data   map[string]any `json:"data"`
+	// Alerts are issues the exporter worked around, such as
+	// EXPORT_VALUE_NOT_NUMERIC. The Document export methods also add them
+	// to the document's alerts.
+	Alerts []Alert `json:"alerts,omitempty"`
 }
 
 type Exporter interface {
@@ -64,48 +174,69 @@ type mixedSeriesRanges struct {
 }
 
 func (d *Document) ExtractChartDataByPath(chartPath string) (ExtractedChartData, error) {
+	call := d.newCall(nil)
+	chart, skip, err := d.lookupExtractChart(chartPath)
+	if err != nil {
+		return ExtractedChartData{}, err
+	}
+	if skip != nil {
+		data, _, err := call.extractSkippedChart(*skip)
+		return data, err
+	}
+	return call.extractChartData(nil, chart)
+}
+
+// findExtractChart resolves chartPath to an extractable chart, reporting
+// skipped charts through handleExtractError.
+func (d *docCall) findExtractChart(chartPath string) (chartdiscover.EmbeddedChart, error) {
+	chart, skip, err := d.lookupExtractChart(chartPath)
+	if err != nil {
+		return chartdiscover.EmbeddedChart{}, err
+	}
+	if skip != nil {
+		return chartdiscover.EmbeddedChart{}, d.handleExtractError(skipExtractIssue(*skip, "extraction"))
+	}
+	return chart, nil
+}
+
+// lookupExtractChart resolves chartPath to the chart discovery found, or
+// to the chart it skipped.
+func (d *Document) lookupExtractChart(chartPath string) (chartdiscover.EmbeddedChart, *chartdiscover.SkippedChart, error) {
 	if d == nil || d.pkg == nil {
-		return ExtractedChartData{}, fmt.Errorf("document not initialized")
+		return chartdiscover.EmbeddedChart{}, nil, fmt.Errorf("document not initialized")
 	}
+	chartPath = normalizeChartPath(chartPath)
 	if chartPath == "" {
-		return ExtractedChartData{}, fmt.Errorf("chart path is required")
+		return chartdiscover.EmbeddedChart{}, nil, fmt.Errorf("chart path is required")
 	}
 
-	embedded, skipped, err := chartdiscover.DiscoverEmbeddedCharts(d.pkg)
+	embedded, skipped, err := d.discoverEmbeddedCharts()
 	if err != nil {
-		return ExtractedChartData{}, err
+		return chartdiscover.EmbeddedChart{}, nil, err
 	}
 
-	for _, skip := range skipped {
-		if skip.ChartPath == chartPath {
-			return ExtractedChartData{}, d.handleExtractError(extractIssue{
-				code:    mapSkipReasonCode(skip),
-				message: extractMessageForCode(mapSkipReasonCode(skip)),
-				err:     fmt.Errorf("chart %q is not eligible for extraction", chartPath),
-				context: extractSkipContext(skip),
-			})
+	for i := range skipped {
+		if skipped[i].ChartPath == chartPath {
+			return chartdiscover.EmbeddedChart{}, &skipped[i], nil
 		}
 	}
 
 	for _, item := range embedded {
 		if item.ChartPath == chartPath {
-			data, err := d.extractChartData(item)
-			if err != nil {
-				return ExtractedChartData{}, err
-			}
-			return data, nil
+			return item, nil, nil
 		}
 	}
 
-	return ExtractedChartData{}, fmt.Errorf("chart not found")
+	return chartdiscover.EmbeddedChart{}, nil, d.chartPathError(chartPath, embedded, skipped)
 }
 
 func (d *Document) ExtractChartData(chartIndex int) (ExtractedChartData, error) {
 	if d == nil || d.pkg == nil {
 		return ExtractedChartData{}, fmt.Errorf("document not initialized")
 	}
+	call := d.newCall(nil)
 	if chartIndex < 0 {
-		return ExtractedChartData{}, fmt.Errorf("chart index out of range")
+		return ExtractedChartData{}, ErrChartIndexOutOfRange
 	}
 
 	charts, err := d.DiscoverEmbeddedCharts()
@@ -113,44 +244,66 @@ func (d *Document) ExtractChartData(chartIndex int) (ExtractedChartData, error)
 		return ExtractedChartData{}, err
 	}
 	if chartIndex >= len(charts) {
-		return ExtractedChartData{}, fmt.Errorf("chart index out of range")
+		return ExtractedChartData{}, ErrChartIndexOutOfRange
 	}
 
-	return d.extractChartData(chartdiscover.EmbeddedChart{
+	return call.extractChartData(nil, chartdiscover.EmbeddedChart{
 		SlidePath:    charts[chartIndex].SlidePath,
 		ChartPath:    charts[chartIndex].ChartPath,
 		WorkbookPath: charts[chartIndex].WorkbookPath,
 	})
 }
 
-func (d *Document) ExtractAllCharts() ([]ExtractedChartData, error) {
+func (d *Document) ExtractAllCharts(opts ...CallOption) ([]ExtractedChartData, error) {
+	return d.ExtractAllChartsContext(context.Background(), opts...)
+}
+
+// ExtractAllChartsContext is ExtractAllCharts checking ctx before each chart.
+// Once ctx is done it returns a *CanceledError naming the next chart; the
+// alerts of the charts before it are kept.
+func (d *Document) ExtractAllChartsContext(ctx context.Context, opts ...CallOption) ([]ExtractedChartData, error) {
 	if d == nil || d.pkg == nil {
 		return nil, fmt.Errorf("document not initialized")
 	}
+	call := d.newCall(opts)
 
-	embedded, skipped, err := chartdiscover.DiscoverEmbeddedCharts(d.pkg)
+	embedded, skipped, err := d.discoverEmbeddedCharts()
 	if err != nil {
 		return nil, err
 	}
+	return call.extractCharts(ctx, embedded, skipped)
+}
 
+// extractCharts extracts embedded in order after reporting skipped.
+func (d *docCall) extractCharts(ctx context.Context, embedded []chartdiscover.EmbeddedChart, skipped []chartdiscover.SkippedChart) ([]ExtractedChartData, error) {
 	out := make([]ExtractedChartData, 0, len(embedded))
 
-	for _, skip := range skipped {
-		err := d.handleExtractError(extractIssue{
-			code:    mapSkipReasonCode(skip),
-			message: extractMessageForCode(mapSkipReasonCode(skip)),
-			err:     fmt.Errorf("chart %q is not eligible for extraction", skip.ChartPath),
-			context: extractSkipContext(skip),
-		})
-		if err != nil && d.opts.Mode == Strict {
-			return nil, err
-		}
+	fromCache, err := d.extractSkippedCharts(skipped)
+	if err != nil {
+		return nil, err
 	}
 
+	session := d.newExtractSession(embedded)
+	d.prefetchExtractWorkbooks(session, embedded)
 	for _, chart := range embedded {
-		data, err := d.extractChartData(chart)
+		if err := checkCanceled(ctx, "extract", chart.ChartPath); err != nil {
+			return nil, err
+		}
+		var data ExtractedChartData
+		err := recoverChart("extract", chart.ChartPath, func() error {
+			var err error
+			data, err = d.extractChartData(session, chart)
+			return err
+		})
+		var panicErr *ChartPanicError
+		if errors.As(err, &panicErr) {
+			if err := d.handleChartPanic(panicErr, chart.SlidePath, chart.WorkbookPath); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		if err != nil {
-			if d.opts.Mode == BestEffort {
+			if d.mode() == BestEffort {
 				continue
 			}
 			return nil, err
@@ -158,6 +311,12 @@ func (d *Document) ExtractAllCharts() ([]ExtractedChartData, error) {
 		out = append(out, data)
 	}
 
+	if len(fromCache) > 0 {
+		out = append(out, fromCache...)
+		if err := d.sortChartsByDiscovery(out); err != nil {
+			return nil, err
+		}
+	}
 	if len(out) == 0 {
 		return []ExtractedChartData{}, nil
 	}
@@ -165,6 +324,7 @@ func (d *Document) ExtractAllCharts() ([]ExtractedChartData, error) {
 }
 
 func (d *Document) ExportChartByPath(chartPath string, exporter Exporter) (ExportedPayload, error) {
+	call := d.newCall(nil)
 	if exporter == nil {
 		return ExportedPayload{}, fmt.Errorf("exporter is required")
 	}
@@ -172,51 +332,136 @@ func (d *Document) ExportChartByPath(chartPath string, exporter Exporter) (Expor
 	if err != nil {
 		return ExportedPayload{}, err
 	}
-	payload, err := exporter.Export(data)
+	payload, err := safeExport(exporter, data)
 	if err != nil {
-		return ExportedPayload{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_CELL_PARSE_ERROR",
-			message: extractMessageForCode("EXTRACT_CELL_PARSE_ERROR"),
-			err:     err,
-			context: map[string]string{"chart": chartPath, "error": err.Error()},
-		})
+		return ExportedPayload{}, call.handleExtractError(exportFailureIssue(data, safeExportFormat(exporter), err))
 	}
+	d.addExportAlerts(payload)
 	return payload, nil
 }
 
+// ExportedChart is one successful export with the chart it came from.
+type ExportedChart struct {
+	ChartPath string          `json:"chartPath"`
+	SlidePath string          `json:"slidePath"`
+	Payload   ExportedPayload `json:"payload"`
+}
+
+// ExportFailure records a chart whose exporter call failed or panicked.
+type ExportFailure struct {
+	ChartPath string       `json:"chartPath"`
+	SlidePath string       `json:"slidePath"`
+	Format    ExportFormat `json:"format"`
+	Error     string       `json:"error"`
+}
+
+// ExportResult pairs each exported payload with its chart so callers can
+// tell which charts ExportAllCharts dropped.
+type ExportResult struct {
+	Exported []ExportedChart `json:"exported"`
+	Failures []ExportFailure `json:"failures"`
+}
+
 func (d *Document) ExportAllCharts(exporter Exporter) ([]ExportedPayload, error) {
+	result, err := d.ExportAllChartsDetailed(exporter)
+	if err != nil {
+		return nil, err
+	}
+	payloads := make([]ExportedPayload, 0, len(result.Exported))
+	for _, exported := range result.Exported {
+		payloads = append(payloads, exported.Payload)
+	}
+	return payloads, nil
+}
+
+// ExportAllChartsDetailed exports every extractable chart. In BestEffort an
+// exporter error or panic skips the chart with an EXPORT_CHART_FAILED alert
+// and a matching entry in Failures; in Strict the first failure is returned.
+// Charts that fail extraction are reported through extraction alerts only.
+func (d *Document) ExportAllChartsDetailed(exporter Exporter) (ExportResult, error) {
+	call := d.newCall(nil)
 	if exporter == nil {
-		return nil, fmt.Errorf("exporter is required")
+		return ExportResult{}, fmt.Errorf("exporter is required")
 	}
 	charts, err := d.ExtractAllCharts()
 	if err != nil {
-		return nil, err
+		return ExportResult{}, err
+	}
+	format := safeExportFormat(exporter)
+	result := ExportResult{
+		Exported: make([]ExportedChart, 0, len(charts)),
+		Failures: []ExportFailure{},
 	}
-	payloads := make([]ExportedPayload, 0, len(charts))
 	for _, chart := range charts {
-		payload, err := exporter.Export(chart)
+		payload, err := safeExport(exporter, chart)
 		if err != nil {
-			if d.opts.Mode == BestEffort {
-				_ = d.handleExtractError(extractIssue{
-					code:    "EXTRACT_CELL_PARSE_ERROR",
-					message: extractMessageForCode("EXTRACT_CELL_PARSE_ERROR"),
-					err:     err,
-					context: map[string]string{"chart": chart.Meta.ChartPath, "error": err.Error()},
-				})
-				continue
+			issue := exportFailureIssue(chart, format, err)
+			if call.mode() != BestEffort {
+				return ExportResult{}, call.handleExtractError(issue)
 			}
-			return nil, err
+			_ = call.handleExtractError(issue)
+			result.Failures = append(result.Failures, ExportFailure{
+				ChartPath: chart.Meta.ChartPath,
+				SlidePath: chart.Meta.SlidePath,
+				Format:    format,
+				Error:     err.Error(),
+			})
+			continue
 		}
-		payloads = append(payloads, payload)
+		d.addExportAlerts(payload)
+		result.Exported = append(result.Exported, ExportedChart{
+			ChartPath: chart.Meta.ChartPath,
+			SlidePath: chart.Meta.SlidePath,
+			Payload:   payload,
+		})
 	}
-	if len(payloads) == 0 {
-		return []ExportedPayload{}, nil
+	return result, nil
+}
+
+// safeExport calls a possibly third-party exporter, turning a panic into an
+// error so one bad exporter cannot take down a batch.
+func safeExport(exporter Exporter, chart ExtractedChartData) (payload ExportedPayload, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			payload = ExportedPayload{}
+			err = fmt.Errorf("exporter panicked: %v", r)
+		}
+	}()
+	return exporter.Export(chart)
+}
+
+// addExportAlerts adds the alerts an exporter returned with payload.
+func (d *Document) addExportAlerts(payload ExportedPayload) {
+	for _, alert := range payload.Alerts {
+		d.addAlert(alert)
+	}
+}
+
+func safeExportFormat(exporter Exporter) (format ExportFormat) {
+	defer func() {
+		if recover() != nil {
+			format = ""
+		}
+	}()
+	return exporter.Format()
+}
+
+func exportFailureIssue(chart ExtractedChartData, format ExportFormat, err error) extractIssue {
+	return extractIssue{
+		code:    "EXPORT_CHART_FAILED",
+		message: extractMessageForCode("EXPORT_CHART_FAILED"),
+		err:     err,
+		context: map[string]string{
+			"chart":  chart.Meta.ChartPath,
+			"format": string(format),
+			"error":  err.Error(),
+		},
 	}
-	return payloads, nil
 }
 
 func (d *Document) ExportChartByPathFormat(chartPath string, format ExportFormat) (ExportedPayload, error) {
-	exporter, err := d.exporterForFormat(format)
+	call := d.newCall(nil)
+	exporter, err := call.exporterForFormat(format)
 	if err != nil {
 		return ExportedPayload{}, err
 	}
@@ -224,7 +469,8 @@ func (d *Document) ExportChartByPathFormat(chartPath string, format ExportFormat
 }
 
 func (d *Document) ExportAllChartsFormat(format ExportFormat) ([]ExportedPayload, error) {
-	exporter, err := d.exporterForFormat(format)
+	call := d.newCall(nil)
+	exporter, err := call.exporterForFormat(format)
 	if err != nil {
 		return nil, err
 	}
@@ -238,11 +484,11 @@ type extractIssue struct {
 	context map[string]string
 }
 
-func (d *Document) handleExtractError(issue extractIssue) error {
-	if issue.code == "" {
+func (d *docCall) handleExtractError(issue extractIssue) error {
+	if issue.code == "" || errors.Is(issue.err, xmlcancel.ErrCanceled) {
 		return issue.err
 	}
-	if d.opts.Mode == BestEffort {
+	if d.mode() == BestEffort {
 		d.addAlert(Alert{
 			Level:   "warn",
 			Code:    issue.code,
@@ -253,34 +499,70 @@ func (d *Document) handleExtractError(issue extractIssue) error {
 	return issue.err
 }
 
-func (d *Document) exporterForFormat(format ExportFormat) (Exporter, error) {
-	if d == nil || d.pkg == nil {
+func (d *docCall) exporterForFormat(format ExportFormat) (Exporter, error) {
+	if d.Document == nil || d.pkg == nil {
 		return nil, fmt.Errorf("document not initialized")
 	}
 	if format == "" {
 		return nil, fmt.Errorf("export format is required")
 	}
-	if d.exporters == nil {
-		d.exporters = defaultExporterRegistry(d.opts)
-	}
-
-	exporter, ok := d.exporters.Get(format)
+	exporters := d.exporterRegistry()
+	exporter, ok := exporters.Get(format)
 	if !ok || exporter == nil {
-		err := fmt.Errorf("export format %q not registered", format)
+		registered := exporters.formatList()
+		err := fmt.Errorf("export format %q not registered (registered: %s)", format, registered)
 		return nil, d.handleExtractError(extractIssue{
 			code:    "EXPORT_FORMAT_UNSUPPORTED",
 			message: extractMessageForCode("EXPORT_FORMAT_UNSUPPORTED"),
 			err:     err,
-			context: map[string]string{"format": string(format)},
+			context: map[string]string{"format": string(format), "formats": registered},
 		})
 	}
 	return exporter, nil
 }
 
-func (d *Document) extractChartData(chart chartdiscover.EmbeddedChart) (ExtractedChartData, error) {
+// RegisterExporter adds exp to the document's exporter registry, so
+// ExportChartByPathFormat and ExportAllChartsFormat find its format. A
+// format already registered, built-in ones included, is an error unless
+// ReplaceExporter is given. With WithExporterRegistry, exp goes into that
+// registry. It is safe to call while other goroutines export.
+func (d *Document) RegisterExporter(exp Exporter, opts ...RegisterOption) error {
+	if d == nil || d.pkg == nil {
+		return fmt.Errorf("document not initialized")
+	}
+	return d.exporterRegistry().Register(exp, opts...)
+}
+
+// exporterRegistry returns the document's registry, building the default
+// one on first use.
+func (d *Document) exporterRegistry() *ExporterRegistry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.exporters == nil {
+		d.exporters = defaultExporterRegistry(d.opts)
+	}
+	return d.exporters
+}
+
+func (d *docCall) extractChartData(session *extractSession, chart chartdiscover.EmbeddedChart) (ExtractedChartData, error) {
+	var data ExtractedChartData
+	err := d.guardChart("extract", chart.SlidePath, chart.ChartPath, chart.WorkbookPath, func() error {
+		var err error
+		data, err = d.extractChartDataUnguarded(session, chart)
+		return err
+	})
+	if err != nil {
+		return ExtractedChartData{}, err
+	}
+	return data, nil
+}
+
+// planChartExtraction resolves which workbook ranges a chart reads, running
+// every chart-side check before the workbook is opened.
+func (d *docCall) planChartExtraction(session *extractSession, chart chartdiscover.EmbeddedChart) (extractPlan, error) {
 	chartXML, err := d.pkg.ReadPart(chart.ChartPath)
 	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
+		return extractPlan{}, d.handleExtractError(extractIssue{
 			code:    "CHART_DEPENDENCIES_PARSE_FAILED",
 			message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
 			err:     fmt.Errorf("read chart %q: %w", chart.ChartPath, err),
@@ -293,9 +575,9 @@ func (d *Document) extractChartData(chart chartdiscover.EmbeddedChart) (Extracte
 		})
 	}
 
-	info, err := chartxml.ParseInfo(bytes.NewReader(chartXML))
+	info, err := d.chartInfo(chart.ChartPath, chartXML)
 	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
+		return extractPlan{}, d.handleExtractError(extractIssue{
 			code:    "CHART_DEPENDENCIES_PARSE_FAILED",
 			message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
 			err:     err,
@@ -307,31 +589,36 @@ func (d *Document) extractChartData(chart chartdiscover.EmbeddedChart) (Extracte
 			},
 		})
 	}
+	formats := d.chartFormatCodes(chart.ChartPath, chartXML)
+	multiLevel := d.chartHasMultiLevelCategories(chart.ChartPath, chartXML)
+	dataLabels, seriesLabels := chartDataLabels(info)
 	if info.ChartType == "mixed" {
-		return d.extractMixedChartData(chart, chartXML)
+		plan, err := d.planMixedChartExtraction(chart, chartXML)
+		plan.formatCodes = formats
+		plan.multiLevel = multiLevel
+		plan.dataLabels, plan.seriesDataLabels = dataLabels, seriesLabels
+		return plan, err
 	}
 
-	deps, err := d.extractChartDependencies(EmbeddedChart{
-		SlidePath:    chart.SlidePath,
-		ChartPath:    chart.ChartPath,
-		WorkbookPath: chart.WorkbookPath,
-	})
+	deps, err := session.chartDependencies(d, chart)
 	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "CHART_DEPENDENCIES_PARSE_FAILED",
-			message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
+		context := map[string]string{
+			"chart":    chart.ChartPath,
+			"slide":    chart.SlidePath,
+			"workbook": chart.WorkbookPath,
+			"error":    err.Error(),
+		}
+		code := dependencyFailureCode(err, context)
+		return extractPlan{}, d.handleExtractError(extractIssue{
+			code:    code,
+			message: extractMessageForCode(code),
 			err:     err,
-			context: map[string]string{
-				"chart":    chart.ChartPath,
-				"slide":    chart.SlidePath,
-				"workbook": chart.WorkbookPath,
-				"error":    err.Error(),
-			},
+			context: context,
 		})
 	}
 
-	if deps.ChartType != "bar" && deps.ChartType != "line" && deps.ChartType != "pie" && deps.ChartType != "area" {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
+	if deps.ChartType != "bar" && deps.ChartType != "line" && deps.ChartType != "pie" && deps.ChartType != "doughnut" && deps.ChartType != "area" && deps.ChartType != "stock" && deps.ChartType != "scatter" && deps.ChartType != "radar" {
+		return extractPlan{}, d.handleExtractError(extractIssue{
 			code:    "CHART_TYPE_UNSUPPORTED",
 			message: extractMessageForCode("CHART_TYPE_UNSUPPORTED"),
 			err:     fmt.Errorf("unsupported chart type %q", deps.ChartType),
@@ -339,8 +626,8 @@ func (d *Document) extractChartData(chart chartdiscover.EmbeddedChart) (Extracte
 		})
 	}
 
-	if err := validatePlanRanges(deps.Ranges); err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
+	if err := validateExtractRanges(deps.Ranges); err != nil {
+		return extractPlan{}, d.handleExtractError(extractIssue{
 			code:    "EXTRACT_INVALID_RANGE",
 			message: extractMessageForCode("EXTRACT_INVALID_RANGE"),
 			err:     err,
@@ -353,77 +640,17 @@ func (d *Document) extractChartData(chart chartdiscover.EmbeddedChart) (Extracte
 		})
 	}
 
-	wbBytes, err := d.pkg.ReadPart(chart.WorkbookPath)
-	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_CELL_PARSE_ERROR",
-			message: extractMessageForCode("EXTRACT_CELL_PARSE_ERROR"),
-			err:     fmt.Errorf("read workbook %q: %w", chart.WorkbookPath, err),
-			context: map[string]string{
-				"chart":    chart.ChartPath,
-				"slide":    chart.SlidePath,
-				"workbook": chart.WorkbookPath,
-				"error":    err.Error(),
-			},
-		})
+	catRange, valuesRanges, nameRanges, labelRanges := splitDependencies(deps.Ranges)
+	var xRanges map[int]Range
+	if deps.ChartType == "scatter" {
+		catRange, valuesRanges, xRanges = splitScatterDependencies(deps.Ranges)
 	}
-
-	sharedFound, sheetPath, cellRef, err := detectSharedStrings(wbBytes)
-	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_CELL_PARSE_ERROR",
-			message: extractMessageForCode("EXTRACT_CELL_PARSE_ERROR"),
-			err:     err,
-			context: map[string]string{
-				"chart":    chart.ChartPath,
-				"slide":    chart.SlidePath,
-				"workbook": chart.WorkbookPath,
-				"error":    err.Error(),
-			},
-		})
-	}
-	if sharedFound {
-		ctx := map[string]string{
-			"chart":    chart.ChartPath,
-			"slide":    chart.SlidePath,
-			"workbook": chart.WorkbookPath,
-		}
-		if sheetPath != "" {
-			ctx["sheetPath"] = sheetPath
-		}
-		if cellRef != "" {
-			ctx["cell"] = cellRef
-		}
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_SHAREDSTRINGS_UNSUPPORTED",
-			message: extractMessageForCode("EXTRACT_SHAREDSTRINGS_UNSUPPORTED"),
-			err:     fmt.Errorf("sharedStrings not supported"),
-			context: ctx,
-		})
-	}
-
-	wb, err := xlsxembed.Open(wbBytes)
-	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_CELL_PARSE_ERROR",
-			message: extractMessageForCode("EXTRACT_CELL_PARSE_ERROR"),
-			err:     err,
-			context: map[string]string{
-				"chart":    chart.ChartPath,
-				"slide":    chart.SlidePath,
-				"workbook": chart.WorkbookPath,
-				"error":    err.Error(),
-			},
-		})
-	}
-
-	catRange, valuesRanges, nameRanges := splitDependencies(deps.Ranges)
 	if deps.ChartType == "pie" {
-		if len(valuesRanges) == 0 || len(valuesRanges) > 1 {
-			return ExtractedChartData{}, d.handleExtractError(extractIssue{
+		if len(valuesRanges) == 0 {
+			return extractPlan{}, d.handleExtractError(extractIssue{
 				code:    "EXTRACT_INVALID_RANGE",
 				message: extractMessageForCode("EXTRACT_INVALID_RANGE"),
-				err:     fmt.Errorf("pie chart requires exactly one series"),
+				err:     fmt.Errorf("pie chart has no values range"),
 				context: map[string]string{
 					"chart":    chart.ChartPath,
 					"slide":    chart.SlidePath,
@@ -431,70 +658,188 @@ func (d *Document) extractChartData(chart chartdiscover.EmbeddedChart) (Extracte
 				},
 			})
 		}
+		if len(valuesRanges) > 1 {
+			kept, err := d.dropExtraPieSeries(chart, valuesRanges)
+			if err != nil {
+				return extractPlan{}, err
+			}
+			valuesRanges = kept
+		}
 	}
-	labels := []string{}
-	primarySheet := ""
+
+	plan := extractPlan{chartType: deps.ChartType, labels: catRange, multiLevel: multiLevel, axes: deps.Axes, ranges: deps.Ranges, formatCodes: formats, dataLabels: dataLabels, seriesDataLabels: seriesLabels}
 	if catRange != nil {
-		labels, err = wb.GetRangeValues(catRange.Sheet, catRange.StartCell, catRange.EndCell, xlsxembed.MissingNumericEmpty)
-		if err != nil {
-			return ExtractedChartData{}, d.handleWorkbookRangeError(chart, catRange.Sheet, err)
+		plan.sheet = catRange.Sheet
+	}
+	var legs []string
+	if deps.ChartType == "stock" {
+		legs = stockLegNames(len(valuesRanges))
+		plan.typeDetails = strings.ToLower(strings.Join(legs, "-"))
+	}
+	for position, index := range sortedKeys(valuesRanges) {
+		series := extractPlanSeries{
+			index:  index,
+			values: valuesRanges[index],
+			axis:   deps.SeriesAxes[index],
 		}
-		primarySheet = catRange.Sheet
-	}
-	if primarySheet == "" && len(valuesRanges) > 0 {
-		if valueRange, ok := valuesRanges[sortedKeys(valuesRanges)[0]]; ok {
-			primarySheet = valueRange.Sheet
+		if legs != nil {
+			series.leg = legs[position]
+		}
+		if plan.sheet == "" {
+			plan.sheet = series.values.Sheet
 		}
+		if nameRange, ok := nameRanges[index]; ok {
+			series.name = &nameRange
+		}
+		if labelRange, ok := labelRanges[index]; ok {
+			series.labels = &labelRange
+		}
+		if xRange, ok := xRanges[index]; ok && (catRange == nil || xRange.Formula != catRange.Formula) {
+			series.xValues = &xRange
+		}
+		plan.series = append(plan.series, series)
 	}
+	return plan, nil
+}
 
-	series := make([]ExtractedSeries, 0, len(valuesRanges))
-	for _, index := range sortedKeys(valuesRanges) {
-		valueRange := valuesRanges[index]
-		values, err := wb.GetRangeValues(valueRange.Sheet, valueRange.StartCell, valueRange.EndCell, xlsxembed.MissingNumericEmpty)
+// dropExtraPieSeries keeps the first series, by order, of a pie chart with
+// several. PowerPoint plots only that one, so BestEffort extracts it with an
+// EXTRACT_PIE_EXTRA_SERIES_IGNORED warning; Strict fails with
+// ErrPieMultipleSeries.
+func (d *docCall) dropExtraPieSeries(chart chartdiscover.EmbeddedChart, valuesRanges map[int]Range) (map[int]Range, error) {
+	indexes := sortedKeys(valuesRanges)
+	if err := d.reportExtraPieSeries(chart, len(indexes)); err != nil {
+		return nil, err
+	}
+	return map[int]Range{indexes[0]: valuesRanges[indexes[0]]}, nil
+}
+
+// reportExtraPieSeries records the EXTRACT_PIE_EXTRA_SERIES_IGNORED warning
+// for a pie chart of count series, or returns the Strict error.
+func (d *docCall) reportExtraPieSeries(chart chartdiscover.EmbeddedChart, count int) error {
+	if d.mode() != BestEffort {
+		return fmt.Errorf("%w: %d series, only the first is plotted", ErrPieMultipleSeries, count)
+	}
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "EXTRACT_PIE_EXTRA_SERIES_IGNORED",
+		Message: extractMessageForCode("EXTRACT_PIE_EXTRA_SERIES_IGNORED"),
+		Context: map[string]string{
+			"chart":       chart.ChartPath,
+			"slide":       chart.SlidePath,
+			"workbook":    chart.WorkbookPath,
+			"seriesCount": strconv.Itoa(count),
+			"ignored":     strconv.Itoa(count - 1),
+		},
+	})
+	return nil
+}
+
+func (d *docCall) extractChartDataUnguarded(session *extractSession, chart chartdiscover.EmbeddedChart) (ExtractedChartData, error) {
+	plan, err := d.planChartExtraction(session, chart)
+	if err != nil {
+		return ExtractedChartData{}, err
+	}
+	return d.readExtractPlan(session, chart, plan)
+}
+
+// readExtractPlan reads the ranges of plan from the chart's workbook. Series
+// has one entry per plan.series, in order.
+func (d *docCall) readExtractPlan(session *extractSession, chart chartdiscover.EmbeddedChart, plan extractPlan) (ExtractedChartData, error) {
+	wb, err := d.openExtractWorkbook(session, chart)
+	if err != nil {
+		return ExtractedChartData{}, err
+	}
+
+	labels, labelGroups, err := d.readPlanLabels(session, wb, plan)
+	if err != nil {
+		return ExtractedChartData{}, d.handleWorkbookRangeError(chart, plan.labels.Sheet, err)
+	}
+
+	series := make([]ExtractedSeries, 0, len(plan.series))
+	for _, planned := range plan.series {
+		values, err := d.readExtractFormula(session, wb, plan, planned.values)
 		if err != nil {
-			return ExtractedChartData{}, d.handleWorkbookRangeError(chart, valueRange.Sheet, err)
+			return ExtractedChartData{}, d.handleWorkbookRangeError(chart, planned.values.Sheet, err)
 		}
 
-		name := fmt.Sprintf("Series %d", index+1)
-		if nameRange, ok := nameRanges[index]; ok {
-			names, err := wb.GetRangeValues(nameRange.Sheet, nameRange.StartCell, nameRange.EndCell, xlsxembed.MissingNumericEmpty)
+		name := planned.defaultName()
+		if planned.name != nil {
+			names, err := d.readExtractFormula(session, wb, plan, *planned.name)
 			if err != nil {
-				return ExtractedChartData{}, d.handleWorkbookRangeError(chart, nameRange.Sheet, err)
+				return ExtractedChartData{}, d.handleWorkbookRangeError(chart, planned.name.Sheet, err)
 			}
 			if len(names) > 0 {
-				trimmed := strings.TrimSpace(names[0])
-				if trimmed != "" {
-					name = trimmed
-				}
+				name = planned.resolveName(names[0])
 			}
 		}
 
-		series = append(series, ExtractedSeries{
-			Index: index,
-			Name:  name,
-			Data:  values,
-		})
+		var xValues []string
+		if planned.xValues != nil {
+			xValues, err = d.readExtractFormula(session, wb, plan, *planned.xValues)
+			if err != nil {
+				return ExtractedChartData{}, d.handleWorkbookRangeError(chart, planned.xValues.Sheet, err)
+			}
+		}
+
+		var labelTexts []string
+		if planned.labels != nil {
+			labelTexts, err = d.readExtractFormula(session, wb, plan, *planned.labels)
+			if err != nil {
+				return ExtractedChartData{}, d.handleWorkbookRangeError(chart, planned.labels.Sheet, err)
+			}
+		}
+
+		extracted := ExtractedSeries{
+			Index:         len(series),
+			OriginalIndex: planned.values.OriginalIndex,
+			Name:          name,
+			Data:          values,
+			Values:        chartValues(values, d.opts.Workbook.MissingNumericPolicy),
+			PlotType:      planned.plotType,
+			Axis:          planned.axis,
+			LabelTexts:    labelTexts,
+			XValues:       xValues,
+			FormatCode:    plan.formatCode(&planned.values),
+			DataLabels:    seriesDataLabels(plan.seriesDataLabels, planned.values.OriginalIndex),
+		}
+		d.reportNonNumericValues(chart, extracted)
+		series = append(series, extracted)
 	}
 
 	meta := ExtractMeta{
 		ChartPath:    chart.ChartPath,
 		SlidePath:    chart.SlidePath,
 		WorkbookPath: chart.WorkbookPath,
-		Sheet:        primarySheet,
+		Sheet:        plan.sheet,
+		Source:       ExtractSourceWorkbook,
+	}
+
+	data := ExtractedChartData{
+		Type:             plan.chartType,
+		Labels:           labels,
+		LabelGroups:      labelGroups,
+		LabelsFormatCode: plan.formatCode(plan.labels),
+		Series:           series,
+		Axes:             plan.axes,
+		TypeDetails:      plan.typeDetails,
+		DataLabels:       plan.dataLabels,
+		Meta:             meta,
+	}
+	if d.opts.Extract.ConvertDates && labelsDateFormat(data) != "" {
+		date1904, err := wb.Date1904()
+		if err != nil {
+			return ExtractedChartData{}, d.handleWorkbookRangeError(chart, plan.sheet, err)
+		}
+		convertDateLabels(&data, date1904)
 	}
-
-	return ExtractedChartData{
-		Type:   deps.ChartType,
-		Labels: labels,
-		Series: series,
-		Meta:   meta,
-	}, nil
+	return data, nil
 }
 
-func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, chartXML []byte) (ExtractedChartData, error) {
-	parsed, err := chartxml.ParseMixed(bytes.NewReader(chartXML))
+func (d *docCall) planMixedChartExtraction(chart chartdiscover.EmbeddedChart, chartXML []byte) (extractPlan, error) {
+	parsed, err := d.mixedChart(chart.ChartPath, chartXML)
 	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
+		return extractPlan{}, d.handleExtractError(extractIssue{
 			code:    "EXTRACT_MIXED_CHART_DETECTED",
 			message: extractMessageForCode("EXTRACT_MIXED_CHART_DETECTED"),
 			err:     err,
@@ -507,7 +852,7 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 		})
 	}
 	if len(parsed.Series) == 0 {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
+		return extractPlan{}, d.handleExtractError(extractIssue{
 			code:    "EXTRACT_MIXED_CHART_DETECTED",
 			message: extractMessageForCode("EXTRACT_MIXED_CHART_DETECTED"),
 			err:     fmt.Errorf("mixed chart has no series"),
@@ -520,37 +865,44 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 	}
 
 	seriesRanges := make(map[int]*mixedSeriesRanges, len(parsed.Series))
+	whole := &wholeRangeResolver{d: d, workbookPath: chart.WorkbookPath}
 	for _, series := range parsed.Series {
 		seriesRanges[series.Index] = &mixedSeriesRanges{series: series}
 		for _, formula := range series.Formulas {
 			ref, err := xlref.ParseA1Range(formula.Formula)
+			if err == nil {
+				ref, err = whole.narrow(ref, formula.PtCount)
+			}
 			if err != nil {
-				return ExtractedChartData{}, d.handleExtractError(extractIssue{
-					code:    "CHART_DEPENDENCIES_PARSE_FAILED",
-					message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
+				context := map[string]string{
+					"chart":    chart.ChartPath,
+					"slide":    chart.SlidePath,
+					"workbook": chart.WorkbookPath,
+					"error":    err.Error(),
+				}
+				code := dependencyFailureCode(err, context)
+				return extractPlan{}, d.handleExtractError(extractIssue{
+					code:    code,
+					message: extractMessageForCode(code),
 					err:     err,
-					context: map[string]string{
-						"chart":    chart.ChartPath,
-						"slide":    chart.SlidePath,
-						"workbook": chart.WorkbookPath,
-						"error":    err.Error(),
-					},
+					context: context,
 				})
 			}
 
 			r := ChartRange{
-				Kind:        ChartRangeKind(formula.Kind),
-				SeriesIndex: series.Index,
-				Sheet:       ref.Sheet,
-				StartCell:   ref.StartCell,
-				EndCell:     ref.EndCell,
-				Formula:     formula.Formula,
+				Kind:          ChartRangeKind(formula.Kind),
+				SeriesIndex:   series.Index,
+				OriginalIndex: series.Idx,
+				Sheet:         ref.Sheet,
+				StartCell:     ref.StartCell,
+				EndCell:       ref.EndCell,
+				Formula:       formula.Formula,
 			}
 			entry := seriesRanges[series.Index]
 			switch r.Kind {
 			case RangeCategories:
 				if entry.categories != nil {
-					return ExtractedChartData{}, d.handleExtractError(extractIssue{
+					return extractPlan{}, d.handleExtractError(extractIssue{
 						code:    "CHART_DEPENDENCIES_PARSE_FAILED",
 						message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
 						err:     fmt.Errorf("duplicate categories range for series %d", series.Index),
@@ -564,7 +916,7 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 				entry.categories = &r
 			case RangeValues:
 				if entry.values != nil {
-					return ExtractedChartData{}, d.handleExtractError(extractIssue{
+					return extractPlan{}, d.handleExtractError(extractIssue{
 						code:    "CHART_DEPENDENCIES_PARSE_FAILED",
 						message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
 						err:     fmt.Errorf("duplicate values range for series %d", series.Index),
@@ -578,7 +930,7 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 				entry.values = &r
 			case RangeSeriesName:
 				if entry.name != nil {
-					return ExtractedChartData{}, d.handleExtractError(extractIssue{
+					return extractPlan{}, d.handleExtractError(extractIssue{
 						code:    "CHART_DEPENDENCIES_PARSE_FAILED",
 						message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
 						err:     fmt.Errorf("duplicate series name range for series %d", series.Index),
@@ -597,7 +949,7 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 	var catKey string
 	for _, entry := range seriesRanges {
 		if entry.categories == nil || entry.values == nil {
-			return ExtractedChartData{}, d.handleExtractError(extractIssue{
+			return extractPlan{}, d.handleExtractError(extractIssue{
 				code:    "CHART_DEPENDENCIES_PARSE_FAILED",
 				message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
 				err:     fmt.Errorf("mixed chart requires categories and values for each series"),
@@ -612,7 +964,7 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 		if catKey == "" {
 			catKey = key
 		} else if key != catKey {
-			return ExtractedChartData{}, d.handleExtractError(extractIssue{
+			return extractPlan{}, d.handleExtractError(extractIssue{
 				code:    "CHART_DEPENDENCIES_PARSE_FAILED",
 				message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
 				err:     fmt.Errorf("mixed chart categories must match across series"),
@@ -625,7 +977,7 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 		}
 
 		if _, err := expandRangeCells(entry.categories.StartCell, entry.categories.EndCell); err != nil {
-			return ExtractedChartData{}, d.handleExtractError(extractIssue{
+			return extractPlan{}, d.handleExtractError(extractIssue{
 				code:    "EXTRACT_INVALID_RANGE",
 				message: extractMessageForCode("EXTRACT_INVALID_RANGE"),
 				err:     err,
@@ -638,7 +990,7 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 			})
 		}
 		if _, err := expandRangeCells(entry.values.StartCell, entry.values.EndCell); err != nil {
-			return ExtractedChartData{}, d.handleExtractError(extractIssue{
+			return extractPlan{}, d.handleExtractError(extractIssue{
 				code:    "EXTRACT_INVALID_RANGE",
 				message: extractMessageForCode("EXTRACT_INVALID_RANGE"),
 				err:     err,
@@ -652,151 +1004,173 @@ func (d *Document) extractMixedChartData(chart chartdiscover.EmbeddedChart, char
 		}
 	}
 
-	wbBytes, err := d.pkg.ReadPart(chart.WorkbookPath)
-	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_CELL_PARSE_ERROR",
-			message: extractMessageForCode("EXTRACT_CELL_PARSE_ERROR"),
-			err:     fmt.Errorf("read workbook %q: %w", chart.WorkbookPath, err),
-			context: map[string]string{
-				"chart":    chart.ChartPath,
-				"slide":    chart.SlidePath,
-				"workbook": chart.WorkbookPath,
-				"error":    err.Error(),
-			},
-		})
+	seriesKeys := make([]int, 0, len(seriesRanges))
+	for idx := range seriesRanges {
+		seriesKeys = append(seriesKeys, idx)
 	}
+	sort.Ints(seriesKeys)
 
-	sharedFound, sheetPath, cellRef, err := detectSharedStrings(wbBytes)
-	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_CELL_PARSE_ERROR",
-			message: extractMessageForCode("EXTRACT_CELL_PARSE_ERROR"),
-			err:     err,
-			context: map[string]string{
-				"chart":    chart.ChartPath,
-				"slide":    chart.SlidePath,
-				"workbook": chart.WorkbookPath,
-				"error":    err.Error(),
-			},
-		})
+	catRange := seriesRanges[seriesKeys[0]].categories
+	plan := extractPlan{
+		chartType: "mixed",
+		labels:    catRange,
+		sheet:     catRange.Sheet,
+		axes:      chartAxes(parsed.Plots, parsed.AxisGroups),
 	}
-	if sharedFound {
-		ctx := map[string]string{
-			"chart":    chart.ChartPath,
-			"slide":    chart.SlidePath,
-			"workbook": chart.WorkbookPath,
-		}
-		if sheetPath != "" {
-			ctx["sheetPath"] = sheetPath
-		}
-		if cellRef != "" {
-			ctx["cell"] = cellRef
-		}
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_SHAREDSTRINGS_UNSUPPORTED",
-			message: extractMessageForCode("EXTRACT_SHAREDSTRINGS_UNSUPPORTED"),
-			err:     fmt.Errorf("sharedStrings not supported"),
-			context: ctx,
+	for _, idx := range seriesKeys {
+		entry := seriesRanges[idx]
+		plan.series = append(plan.series, extractPlanSeries{
+			index:    idx,
+			values:   *entry.values,
+			name:     entry.name,
+			plotType: entry.series.PlotType,
+			axis:     entry.series.Axis,
 		})
 	}
+	return plan, nil
+}
 
-	wb, err := xlsxembed.Open(wbBytes)
-	if err != nil {
-		return ExtractedChartData{}, d.handleExtractError(extractIssue{
-			code:    "EXTRACT_CELL_PARSE_ERROR",
-			message: extractMessageForCode("EXTRACT_CELL_PARSE_ERROR"),
-			err:     err,
-			context: map[string]string{
-				"chart":    chart.ChartPath,
-				"slide":    chart.SlidePath,
-				"workbook": chart.WorkbookPath,
-				"error":    err.Error(),
-			},
-		})
-	}
+// extractPlan lists the workbook ranges behind one chart's extraction.
+type extractPlan struct {
+	chartType string
+	labels    *ChartRange
+	series    []extractPlanSeries
+	sheet     string
+	axes      []ChartAxis
+	// ranges holds every dependency, so union segments can be found from
+	// the first one. It is nil for mixed charts.
+	ranges      []ChartRange
+	typeDetails string
+	// formatCodes holds the numCache formatCode of each range that has
+	// one.
+	formatCodes map[formatKey]string
+	// dataLabels and seriesDataLabels are the chart's data labels, as
+	// chartDataLabels returns them.
+	dataLabels       *DataLabels
+	seriesDataLabels map[int]DataLabels
+	// multiLevel is set when the chart's categories are a c:multiLvlStrRef,
+	// read as levels by readPlanLabels.
+	multiLevel bool
+}
 
-	seriesKeys := make([]int, 0, len(seriesRanges))
-	for idx := range seriesRanges {
-		seriesKeys = append(seriesKeys, idx)
+// formatKey names a range of a chart by series and kind.
+type formatKey struct {
+	series int
+	kind   ChartRangeKind
+}
+
+// formatCode returns the numCache formatCode of r, or "".
+func (p extractPlan) formatCode(r *ChartRange) string {
+	if r == nil {
+		return ""
 	}
-	sort.Ints(seriesKeys)
+	return p.formatCodes[formatKey{series: r.SeriesIndex, kind: r.Kind}]
+}
 
-	catRange := seriesRanges[seriesKeys[0]].categories
-	labels, err := wb.GetRangeValues(catRange.Sheet, catRange.StartCell, catRange.EndCell, xlsxembed.MissingNumericEmpty)
+// chartFormatCodes collects the numCache formatCodes of a chart's formulas.
+// They only inform rendering, so a chart that does not parse has none.
+func (d *docCall) chartFormatCodes(chartPath string, chartXML []byte) map[formatKey]string {
+	parsed, err := d.parsedChart(chartPath, chartXML)
 	if err != nil {
-		return ExtractedChartData{}, d.handleWorkbookRangeError(chart, catRange.Sheet, err)
+		return nil
 	}
-
-	series := make([]ExtractedSeries, 0, len(seriesKeys))
-	for _, idx := range seriesKeys {
-		entry := seriesRanges[idx]
-		values, err := wb.GetRangeValues(entry.values.Sheet, entry.values.StartCell, entry.values.EndCell, xlsxembed.MissingNumericEmpty)
-		if err != nil {
-			return ExtractedChartData{}, d.handleWorkbookRangeError(chart, entry.values.Sheet, err)
+	var out map[formatKey]string
+	for _, formula := range parsed.Formulas {
+		if formula.FormatCode == "" {
+			continue
 		}
-
-		name := fmt.Sprintf("Series %d", idx+1)
-		if entry.name != nil {
-			names, err := wb.GetRangeValues(entry.name.Sheet, entry.name.StartCell, entry.name.EndCell, xlsxembed.MissingNumericEmpty)
-			if err != nil {
-				return ExtractedChartData{}, d.handleWorkbookRangeError(chart, entry.name.Sheet, err)
-			}
-			if len(names) > 0 {
-				trimmed := strings.TrimSpace(names[0])
-				if trimmed != "" {
-					name = trimmed
-				}
-			}
+		if out == nil {
+			out = make(map[formatKey]string)
 		}
-
-		series = append(series, ExtractedSeries{
-			Index:    idx,
-			Name:     name,
-			Data:     values,
-			PlotType: entry.series.PlotType,
-			Axis:     entry.series.Axis,
-		})
+		out[formatKey{series: formula.SeriesIndex, kind: ChartRangeKind(formula.Kind)}] = formula.FormatCode
 	}
+	return out
+}
 
-	meta := ExtractMeta{
-		ChartPath:    chart.ChartPath,
-		SlidePath:    chart.SlidePath,
-		WorkbookPath: chart.WorkbookPath,
-		Sheet:        catRange.Sheet,
+type extractPlanSeries struct {
+	index  int
+	values ChartRange
+	name   *ChartRange
+	labels *ChartRange
+	// xValues is set for a scatter series whose x values are not the
+	// chart's labels.
+	xValues  *ChartRange
+	plotType string
+	axis     string
+	// leg is the stock chart leg ("High", "Low", ...) the series draws,
+	// used as its name when it has no tx.
+	leg string
+}
+
+func (s extractPlanSeries) defaultName() string {
+	if s.leg != "" {
+		return s.leg
 	}
+	return fmt.Sprintf("Series %d", s.index+1)
+}
 
-	return ExtractedChartData{
-		Type:   "mixed",
-		Labels: labels,
-		Series: series,
-		Meta:   meta,
-	}, nil
+// resolveName returns the trimmed first cell of the series name range, or
+// the default name when it is blank.
+func (s extractPlanSeries) resolveName(first string) string {
+	if trimmed := strings.TrimSpace(first); trimmed != "" {
+		return trimmed
+	}
+	return s.defaultName()
 }
 
-func (d *Document) handleWorkbookRangeError(chart chartdiscover.EmbeddedChart, sheet string, err error) error {
-	code := "EXTRACT_CELL_PARSE_ERROR"
-	if strings.Contains(err.Error(), "sheet") && strings.Contains(err.Error(), "not found") {
-		code = "EXTRACT_SHEET_NOT_FOUND"
+func (d *docCall) handleWorkbookRangeError(chart chartdiscover.EmbeddedChart, sheet string, err error) error {
+	code := workbookReadCode(err)
+	ctx := map[string]string{
+		"chart":    chart.ChartPath,
+		"slide":    chart.SlidePath,
+		"workbook": chart.WorkbookPath,
+		"sheet":    sheet,
+		"error":    err.Error(),
 	}
+	addCellTypeContext(ctx, err)
 	return d.handleExtractError(extractIssue{
 		code:    code,
 		message: extractMessageForCode(code),
 		err:     err,
-		context: map[string]string{
-			"chart":    chart.ChartPath,
-			"slide":    chart.SlidePath,
-			"workbook": chart.WorkbookPath,
-			"sheet":    sheet,
-			"error":    err.Error(),
-		},
+		context: ctx,
 	})
 }
 
-func splitDependencies(ranges []Range) (*Range, map[int]Range, map[int]Range) {
+// workbookReadCode classifies an error reading workbook cells:
+// EXTRACT_SHEET_NOT_FOUND, EXTRACT_UNSUPPORTED_CELL_TYPE for a formula,
+// boolean, or error cell, or EXTRACT_CELL_PARSE_ERROR for anything else.
+func workbookReadCode(err error) string {
+	var sheetErr *xlsxembed.SheetNotFoundError
+	var cellErr *xlsxembed.CellTypeError
+	switch {
+	case errors.As(err, &sheetErr):
+		return "EXTRACT_SHEET_NOT_FOUND"
+	case errors.As(err, &cellErr):
+		return "EXTRACT_UNSUPPORTED_CELL_TYPE"
+	default:
+		return "EXTRACT_CELL_PARSE_ERROR"
+	}
+}
+
+// addCellTypeContext adds the cell and its type to the alert context of an
+// unsupported cell type error; a formula cell rejected by
+// Options.Workbook.RejectFormulaCells has cellType "formula".
+func addCellTypeContext(ctx map[string]string, err error) {
+	var cellErr *xlsxembed.CellTypeError
+	if errors.As(err, &cellErr) {
+		ctx["cell"] = cellErr.Cell
+		ctx["cellType"] = cellErr.Type
+		if cellErr.Formula {
+			ctx["cellType"] = "formula"
+		}
+	}
+}
+
+func splitDependencies(ranges []Range) (*Range, map[int]Range, map[int]Range, map[int]Range) {
 	var catRange *Range
 	values := make(map[int]Range)
 	names := make(map[int]Range)
+	labels := make(map[int]Range)
 
 	for i := range ranges {
 		r := ranges[i]
@@ -814,10 +1188,14 @@ func splitDependencies(ranges []Range) (*Range, map[int]Range, map[int]Range) {
 			if _, ok := names[r.SeriesIndex]; !ok {
 				names[r.SeriesIndex] = r
 			}
+		case RangeDataLabels:
+			if _, ok := labels[r.SeriesIndex]; !ok {
+				labels[r.SeriesIndex] = r
+			}
 		}
 	}
 
-	return catRange, values, names
+	return catRange, values, names, labels
 }
 
 func sortedKeys(ranges map[int]Range) []int {
@@ -829,128 +1207,32 @@ func sortedKeys(ranges map[int]Range) []int {
 	return keys
 }
 
-func detectSharedStrings(data []byte) (bool, string, string, error) {
-	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
-	if err != nil {
-		return false, "", "", err
-	}
-
-	for _, part := range reader.File {
-		if part.Name == "xl/sharedStrings.xml" {
-			return true, part.Name, "", nil
-		}
-	}
-
-	for _, part := range reader.File {
-		if !strings.HasPrefix(part.Name, "xl/worksheets/") || !strings.HasSuffix(part.Name, ".xml") {
-			continue
-		}
-		rc, err := part.Open()
-		if err != nil {
-			return false, part.Name, "", err
-		}
-		found, cellRef, err := scanSharedStringCells(rc)
-		_ = rc.Close()
-		if err != nil {
-			return false, part.Name, "", err
-		}
-		if found {
-			return true, part.Name, cellRef, nil
-		}
-	}
-
-	return false, "", "", nil
-}
-
-func scanSharedStringCells(r io.Reader) (bool, string, error) {
-	decoder := xml.NewDecoder(r)
-	for {
-		token, err := decoder.Token()
-		if err == io.EOF {
-			return false, "", nil
-		}
-		if err != nil {
-			return false, "", err
-		}
-
-		start, ok := token.(xml.StartElement)
-		if !ok || start.Name.Local != "c" {
-			continue
-		}
-
-		cellType := ""
-		cellRef := ""
-		for _, attr := range start.Attr {
-			switch attr.Name.Local {
-			case "t":
-				cellType = attr.Value
-			case "r":
-				cellRef = attr.Value
-			}
-		}
-		if cellType == "s" {
-			return true, cellRef, nil
-		}
-	}
-}
-
-func mapSkipReasonCode(skip chartdiscover.SkippedChart) string {
-	switch skip.Reason {
-	case chartdiscover.ReasonLinked:
-		return "CHART_LINKED_WORKBOOK"
-	case chartdiscover.ReasonRelsMissing:
-		return "CHART_RELS_MISSING"
-	case chartdiscover.ReasonWorkbookNotFound:
-		return "CHART_WORKBOOK_NOT_FOUND"
-	case chartdiscover.ReasonUnsupported:
-		return "CHART_WORKBOOK_UNSUPPORTED_TARGET"
-	default:
-		return ""
-	}
-}
-
-func extractSkipContext(skip chartdiscover.SkippedChart) map[string]string {
-	ctx := map[string]string{
-		"slide": skip.SlidePath,
-		"chart": skip.ChartPath,
-	}
-	switch skip.Reason {
-	case chartdiscover.ReasonLinked:
-		ctx["target"] = skip.Target
-	case chartdiscover.ReasonRelsMissing:
-		ctx["rels_path"] = skip.RelsPath
-	case chartdiscover.ReasonUnsupported:
-		ctx["target"] = skip.Target
-	}
-	return ctx
-}
-
 func extractMessageForCode(code string) string {
 	switch code {
-	case "CHART_LINKED_WORKBOOK":
-		return "Chart uses linked workbook and is skipped"
-	case "CHART_RELS_MISSING":
-		return "Chart relationships file is missing; chart is skipped"
-	case "CHART_WORKBOOK_NOT_FOUND":
-		return "No workbook relationship found for chart; chart is skipped"
-	case "CHART_WORKBOOK_UNSUPPORTED_TARGET":
-		return "Chart workbook target is unsupported; chart is skipped"
 	case "CHART_DEPENDENCIES_PARSE_FAILED":
 		return "Failed to extract chart dependencies; chart is skipped"
+	case "CHART_NAMED_RANGE_UNSUPPORTED":
+		return "Series formula is a defined name, which is unsupported; chart is skipped"
 	case "CHART_TYPE_UNSUPPORTED":
 		return "Chart type is unsupported; chart is skipped"
 	case "EXTRACT_INVALID_RANGE":
 		return "Chart range is invalid or unsupported; chart is skipped"
-	case "EXTRACT_SHAREDSTRINGS_UNSUPPORTED":
-		return "Workbook uses sharedStrings, which is unsupported"
 	case "EXTRACT_SHEET_NOT_FOUND":
 		return "Workbook sheet not found"
+	case "EXTRACT_WORKBOOK_NOT_FOUND":
+		return "Embedded workbook not found"
 	case "EXTRACT_CELL_PARSE_ERROR":
 		return "Failed to parse workbook cells"
+	case "EXTRACT_UNSUPPORTED_CELL_TYPE":
+		return "Workbook cell is a formula, boolean, or error value, which extraction does not read"
 	case "EXTRACT_MIXED_CHART_DETECTED":
 		return "Mixed chart type is unsupported; chart is skipped"
+	case "EXTRACT_PIE_EXTRA_SERIES_IGNORED":
+		return "Pie chart has more than one series; only the first is extracted"
 	case "EXPORT_FORMAT_UNSUPPORTED":
 		return "Export format is not registered"
+	case "EXPORT_CHART_FAILED":
+		return "Exporter failed for chart; chart is skipped"
 	default:
 		return "Chart extraction failed"
 	}
@@ -0,0 +1,333 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/chartdiscover"
+	"why-pptx/internal/xlref"
+	"why-pptx/internal/xlsxembed"
+	"why-pptx/internal/xmlcancel"
+)
+
+// extractBatchMinCharts is the number of charts sharing one workbook at
+// which ExtractAllCharts switches to a single batched sheet scan.
+const extractBatchMinCharts = 2
+
+// Stats reports cumulative work counters for the document's read paths.
+type Stats struct {
+	// WorkbookOpens counts embedded workbooks opened for extraction and
+	// for cache syncs and repairs.
+	WorkbookOpens int
+	// SheetScans counts worksheet XML passes made to read cell values.
+	SheetScans int
+	// BatchedCharts counts charts whose ranges were read in a shared batch.
+	BatchedCharts int
+	// ChartParses counts chart XML decodes on read paths. A chart is decoded
+	// once per content version and view; later reads reuse the result.
+	ChartParses int
+}
+
+// Stats returns a snapshot of the document's work counters.
+func (d *Document) Stats() Stats {
+	if d == nil {
+		return Stats{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// addStats adds delta to the document's counters.
+func (d *Document) addStats(delta Stats) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stats.WorkbookOpens += delta.WorkbookOpens
+	d.stats.SheetScans += delta.SheetScans
+	d.stats.BatchedCharts += delta.BatchedCharts
+	d.stats.ChartParses += delta.ChartParses
+}
+
+// extractSession shares workbook state across the charts of one
+// ExtractAllCharts call. A nil session disables sharing.
+type extractSession struct {
+	charts     map[string][]chartdiscover.EmbeddedChart
+	deps       map[string]ChartDependencies
+	workbooks  map[string]*extractWorkbook
+	prefetched map[*xlsxembed.Workbook]map[string][]string
+}
+
+type extractWorkbook struct {
+	wb   *xlsxembed.Workbook
+	fail *workbookLoadFailure
+}
+
+type workbookLoadFailure struct {
+	code    string
+	err     error
+	context map[string]string
+}
+
+func (d *Document) newExtractSession(charts []chartdiscover.EmbeddedChart) *extractSession {
+	session := &extractSession{
+		charts:     make(map[string][]chartdiscover.EmbeddedChart),
+		deps:       make(map[string]ChartDependencies),
+		workbooks:  make(map[string]*extractWorkbook),
+		prefetched: make(map[*xlsxembed.Workbook]map[string][]string),
+	}
+	for _, chart := range charts {
+		session.charts[chart.WorkbookPath] = append(session.charts[chart.WorkbookPath], chart)
+	}
+	return session
+}
+
+func (s *extractSession) chartDependencies(d *docCall, chart chartdiscover.EmbeddedChart) (ChartDependencies, error) {
+	if s != nil {
+		if dep, ok := s.deps[chart.ChartPath]; ok {
+			return dep, nil
+		}
+	}
+	return d.extractChartDependencies(EmbeddedChart{
+		SlidePath:    chart.SlidePath,
+		ChartPath:    chart.ChartPath,
+		WorkbookPath: chart.WorkbookPath,
+	})
+}
+
+func (d *docCall) openExtractWorkbook(session *extractSession, chart chartdiscover.EmbeddedChart) (*xlsxembed.Workbook, error) {
+	var entry *extractWorkbook
+	if session != nil {
+		entry = session.workbooks[chart.WorkbookPath]
+	}
+	if entry == nil {
+		wb, fail := d.loadExtractWorkbook(chart.WorkbookPath)
+		entry = &extractWorkbook{wb: wb, fail: fail}
+		if session != nil {
+			session.workbooks[chart.WorkbookPath] = entry
+		}
+	}
+
+	if entry.fail != nil {
+		ctx := map[string]string{
+			"chart":    chart.ChartPath,
+			"slide":    chart.SlidePath,
+			"workbook": chart.WorkbookPath,
+		}
+		for key, value := range entry.fail.context {
+			ctx[key] = value
+		}
+		return nil, d.handleExtractError(extractIssue{
+			code:    entry.fail.code,
+			message: extractMessageForCode(entry.fail.code),
+			err:     entry.fail.err,
+			context: ctx,
+		})
+	}
+	entry.wb.SetCancel(d.cancel)
+	return entry.wb, nil
+}
+
+func (d *docCall) loadExtractWorkbook(workbookPath string) (*xlsxembed.Workbook, *workbookLoadFailure) {
+	wbBytes, err := d.pkg.ReadPart(workbookPath)
+	if err != nil {
+		return nil, &workbookLoadFailure{
+			code:    "EXTRACT_CELL_PARSE_ERROR",
+			err:     fmt.Errorf("read workbook %q: %w", workbookPath, err),
+			context: map[string]string{"error": err.Error()},
+		}
+	}
+
+	wb, err := d.openEmbeddedWorkbook(workbookPath, wbBytes)
+	if err != nil {
+		return nil, &workbookLoadFailure{
+			code:    "EXTRACT_CELL_PARSE_ERROR",
+			err:     err,
+			context: map[string]string{"error": err.Error()},
+		}
+	}
+	d.addStats(Stats{WorkbookOpens: 1})
+	return wb, nil
+}
+
+// prefetchExtractWorkbooks opens every workbook shared by enough charts and
+// batch-reads their ranges before any chart is extracted. The batch serves all
+// charts of the workbook, so it runs under its own PerChartTimeout budget
+// instead of inside the guard of whichever chart comes first. Load failures
+// are cached and reported later by openExtractWorkbook, per chart.
+func (d *docCall) prefetchExtractWorkbooks(session *extractSession, charts []chartdiscover.EmbeddedChart) {
+	for _, chart := range charts {
+		workbookPath := chart.WorkbookPath
+		if _, ok := session.workbooks[workbookPath]; ok || len(session.charts[workbookPath]) < extractBatchMinCharts {
+			continue
+		}
+		d.prefetchWorkbook(session, workbookPath)
+	}
+}
+
+// prefetchWorkbook loads and batch-reads one workbook. A panic abandons the
+// batch for it only: the charts it backs are then read one by one, each
+// under its own recover in ExtractAllCharts.
+func (d *docCall) prefetchWorkbook(session *extractSession, workbookPath string) {
+	defer func() {
+		if r := recover(); r != nil {
+			delete(session.workbooks, workbookPath)
+			d.logger.Warn("extract batch panicked", "workbook", workbookPath, "panic", fmt.Sprint(r))
+		}
+	}()
+
+	wb, fail := d.loadExtractWorkbook(workbookPath)
+	session.workbooks[workbookPath] = &extractWorkbook{wb: wb, fail: fail}
+	if fail != nil {
+		return
+	}
+
+	flag, stop := xmlcancel.After(d.opts.Limits.PerChartTimeout)
+	d.cancel = flag
+	defer func() {
+		stop()
+		d.cancel = nil
+	}()
+	wb.SetCancel(flag)
+	d.prefetchWorkbookRanges(session, workbookPath, wb)
+}
+
+// prefetchWorkbookRanges reads every range of every chart backed by the
+// workbook in one pass per sheet. Charts whose ranges cannot be resolved are
+// left out; they fall back to per-range reads and report their own errors.
+// A failed batch read is not an error: extraction simply proceeds unbatched.
+func (d *docCall) prefetchWorkbookRanges(session *extractSession, workbookPath string, wb *xlsxembed.Workbook) {
+	charts := session.charts[workbookPath]
+	seen := make(map[string]struct{})
+	var requests []xlsxembed.Range
+	sheets := make(map[string]struct{})
+
+	for _, chart := range charts {
+		ranges, ok := d.prefetchChartRanges(session, chart)
+		if !ok {
+			continue
+		}
+		for _, r := range ranges {
+			if _, err := expandRangeCells(r.StartCell, r.EndCell); err != nil {
+				continue
+			}
+			key := extractRangeKey(r)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			sheets[r.Sheet] = struct{}{}
+			requests = append(requests, xlsxembed.Range{Sheet: r.Sheet, StartCell: r.StartCell, EndCell: r.EndCell})
+		}
+	}
+	if len(requests) == 0 {
+		return
+	}
+
+	results, err := wb.GetRanges(requests, xlsxembed.MissingNumericEmpty)
+	if err != nil {
+		d.logger.Debug("extract batch skipped", "workbook", workbookPath, "error", err.Error())
+		return
+	}
+	d.addStats(Stats{SheetScans: len(sheets), BatchedCharts: len(charts)})
+
+	values := make(map[string][]string, len(requests))
+	for i, r := range requests {
+		values[extractRangeKey(ChartRange{Sheet: r.Sheet, StartCell: r.StartCell, EndCell: r.EndCell})] = results[i]
+	}
+	session.prefetched[wb] = values
+
+	d.logger.Debug("extract batch", "workbook", workbookPath, "charts", len(charts), "ranges", len(requests), "sheets", len(sheets))
+}
+
+func (d *docCall) prefetchChartRanges(session *extractSession, chart chartdiscover.EmbeddedChart) ([]ChartRange, bool) {
+	chartXML, err := d.pkg.ReadPart(chart.ChartPath)
+	if err != nil {
+		return nil, false
+	}
+	info, err := d.chartInfo(chart.ChartPath, chartXML)
+	if err != nil {
+		return nil, false
+	}
+
+	if info.ChartType != "mixed" {
+		dep, err := d.extractChartDependencies(EmbeddedChart{
+			SlidePath:    chart.SlidePath,
+			ChartPath:    chart.ChartPath,
+			WorkbookPath: chart.WorkbookPath,
+		})
+		if err != nil {
+			return nil, false
+		}
+		session.deps[chart.ChartPath] = dep
+		return dep.Ranges, true
+	}
+
+	parsed, err := d.mixedChart(chart.ChartPath, chartXML)
+	if err != nil {
+		return nil, false
+	}
+	var ranges []ChartRange
+	for _, series := range parsed.Series {
+		for _, formula := range series.Formulas {
+			ref, err := xlref.ParseA1Range(formula.Formula)
+			if err != nil || ref.Whole != xlref.NotWhole {
+				return nil, false
+			}
+			ranges = append(ranges, ChartRange{Sheet: ref.Sheet, StartCell: ref.StartCell, EndCell: ref.EndCell})
+		}
+	}
+	return ranges, true
+}
+
+func (d *Document) readExtractRange(session *extractSession, wb *xlsxembed.Workbook, r ChartRange) ([]string, error) {
+	if session != nil {
+		if values, ok := session.prefetched[wb][extractRangeKey(r)]; ok {
+			return append([]string(nil), values...), nil
+		}
+	}
+	if !wb.SheetIndexed(r.Sheet) {
+		d.addStats(Stats{SheetScans: 1})
+	}
+	return readChartRangeValues(wb, r.Kind == RangeCategories, r.Sheet, r.StartCell, r.EndCell, xlsxembed.MissingNumericEmpty)
+}
+
+// readExtractFormula reads r and the union segments that follow it in
+// plan.ranges, concatenated in order.
+func (d *Document) readExtractFormula(session *extractSession, wb *xlsxembed.Workbook, plan extractPlan, r ChartRange) ([]string, error) {
+	segments := formulaSegments(plan.ranges, r)
+	if len(segments) == 1 {
+		return d.readExtractRange(session, wb, segments[0])
+	}
+	var out []string
+	for _, segment := range segments {
+		values, err := d.readExtractRange(session, wb, segment)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, values...)
+	}
+	return out, nil
+}
+
+// readPlanLabels reads the chart's categories. Multi-level categories are
+// read as levels: the innermost one as the labels and the others as the
+// label groups. Other categories have no groups.
+func (d *Document) readPlanLabels(session *extractSession, wb *xlsxembed.Workbook, plan extractPlan) ([]string, [][]string, error) {
+	if plan.labels == nil {
+		return []string{}, nil, nil
+	}
+	if !plan.multiLevel {
+		labels, err := d.readExtractFormula(session, wb, plan, *plan.labels)
+		return labels, nil, err
+	}
+	segments := formulaSegments(plan.ranges, *plan.labels)
+	for _, segment := range segments {
+		if !wb.SheetIndexed(segment.Sheet) {
+			d.addStats(Stats{SheetScans: 1})
+		}
+	}
+	return readCategoryLevels(wb, segments)
+}
+
+func extractRangeKey(r ChartRange) string {
+	return r.Sheet + "!" + r.StartCell + ":" + r.EndCell
+}
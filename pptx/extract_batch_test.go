@@ -0,0 +1,219 @@
+package pptx
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sparklineChartCount = 50
+
+func TestExtractAllChartsSharedWorkbookBatched(t *testing.T) {
+	path := writeSparklineDeck(t, t.TempDir(), sparklineChartCount)
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	batched, err := doc.ExtractAllCharts()
+	if err != nil {
+		t.Fatalf("ExtractAllCharts: %v", err)
+	}
+	if len(batched) != sparklineChartCount {
+		t.Fatalf("expected %d charts, got %d", sparklineChartCount, len(batched))
+	}
+
+	stats := doc.Stats()
+	if stats.WorkbookOpens != 1 {
+		t.Fatalf("expected 1 workbook open, got %d", stats.WorkbookOpens)
+	}
+	if stats.SheetScans != 1 {
+		t.Fatalf("expected 1 sheet scan, got %d", stats.SheetScans)
+	}
+	if stats.BatchedCharts != sparklineChartCount {
+		t.Fatalf("expected %d batched charts, got %d", sparklineChartCount, stats.BatchedCharts)
+	}
+
+	naive, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for _, chart := range batched {
+		single, err := naive.ExtractChartDataByPath(chart.Meta.ChartPath)
+		if err != nil {
+			t.Fatalf("ExtractChartDataByPath(%s): %v", chart.Meta.ChartPath, err)
+		}
+		if !reflect.DeepEqual(single, chart) {
+			t.Fatalf("batched output differs for %s:\nbatched=%#v\nsingle=%#v", chart.Meta.ChartPath, chart, single)
+		}
+	}
+	if naive.Stats().BatchedCharts != 0 {
+		t.Fatalf("expected single-chart extraction to stay unbatched")
+	}
+}
+
+func TestExtractAllChartsBatchFallsBackOnBadRange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "input.pptx")
+
+	parts := sparklineDeckParts(t, 3)
+	parts["ppt/charts/chart2.xml"] = sparklineChart("Missing!$B$1:$M$1", "Missing!$B$3:$M$3", "Missing!$A$3")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(path, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ExtractAllCharts()
+	if err != nil {
+		t.Fatalf("ExtractAllCharts: %v", err)
+	}
+	if len(charts) != 2 {
+		t.Fatalf("expected 2 charts, got %d", len(charts))
+	}
+	alerts := doc.AlertsByCode("EXTRACT_SHEET_NOT_FOUND")
+	if len(alerts) != 1 || alerts[0].Context["chart"] != "ppt/charts/chart2.xml" {
+		t.Fatalf("expected sheet not found alert for chart2, got %#v", doc.Alerts())
+	}
+	if doc.Stats().WorkbookOpens != 1 {
+		t.Fatalf("expected workbook to be opened once, got %d", doc.Stats().WorkbookOpens)
+	}
+}
+
+func BenchmarkExtractAllChartsSharedWorkbook(b *testing.B) {
+	path := writeSparklineDeck(b, b.TempDir(), sparklineChartCount)
+	doc, err := OpenFile(path)
+	if err != nil {
+		b.Fatalf("OpenFile: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := doc.ExtractAllCharts(); err != nil {
+			b.Fatalf("ExtractAllCharts: %v", err)
+		}
+	}
+}
+
+func BenchmarkExtractChartsNaiveLoop(b *testing.B) {
+	path := writeSparklineDeck(b, b.TempDir(), sparklineChartCount)
+	doc, err := OpenFile(path)
+	if err != nil {
+		b.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.DiscoverEmbeddedCharts()
+	if err != nil {
+		b.Fatalf("DiscoverEmbeddedCharts: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, chart := range charts {
+			if _, err := doc.ExtractChartDataByPath(chart.ChartPath); err != nil {
+				b.Fatalf("ExtractChartDataByPath: %v", err)
+			}
+		}
+	}
+}
+
+func writeSparklineDeck(tb testing.TB, dir string, count int) string {
+	tb.Helper()
+
+	path := filepath.Join(dir, "sparklines.pptx")
+	if err := writeZipFile(path, sparklineDeckParts(tb, count)); err != nil {
+		tb.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+// sparklineDeckParts builds one slide holding count tiny line charts, each
+// plotting one row of a shared 12-month table in a single embedded workbook.
+func sparklineDeckParts(tb testing.TB, count int) map[string][]byte {
+	tb.Helper()
+
+	var slideRels strings.Builder
+	slideRels.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml":                 []byte(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"></p:sld>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildSparklineWorkbook(tb, count),
+	}
+	for i := 1; i <= count; i++ {
+		row := i + 1
+		fmt.Fprintf(&slideRels, `
+  <Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart%d.xml"/>`, i, i)
+		parts[fmt.Sprintf("ppt/charts/chart%d.xml", i)] = sparklineChart(
+			"Sheet1!$B$1:$M$1",
+			fmt.Sprintf("Sheet1!$B$%d:$M$%d", row, row),
+			fmt.Sprintf("Sheet1!$A$%d", row),
+		)
+		parts[fmt.Sprintf("ppt/charts/_rels/chart%d.xml.rels", i)] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`)
+	}
+	slideRels.WriteString("\n</Relationships>")
+	parts["ppt/slides/_rels/slide1.xml.rels"] = []byte(slideRels.String())
+	return parts
+}
+
+func sparklineChart(catFormula, valFormula, nameFormula string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:lineChart>
+        <c:ser>
+          <c:idx val="0"/>
+          <c:order val="0"/>
+          <c:tx><c:strRef><c:f>` + nameFormula + `</c:f></c:strRef></c:tx>
+          <c:cat><c:strRef><c:f>` + catFormula + `</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>` + valFormula + `</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:lineChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`)
+}
+
+func buildSparklineWorkbook(tb testing.TB, rows int) []byte {
+	tb.Helper()
+
+	return writeZipBytes(tb, sparklineWorkbookParts(tb, rows))
+}
+
+// sparklineWorkbookParts holds a 12-month table with one row per chart of a
+// sparkline deck.
+func sparklineWorkbookParts(tb testing.TB, rows int) map[string][]byte {
+	tb.Helper()
+
+	months := []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1">`)
+	for i, month := range months {
+		fmt.Fprintf(&sheet, `<c r="%s1" t="inlineStr"><is><t>%s</t></is></c>`, indexToCol(i+2), month)
+	}
+	sheet.WriteString("</row>")
+	for r := 2; r <= rows+1; r++ {
+		fmt.Fprintf(&sheet, `
+    <row r="%d"><c r="A%d" t="inlineStr"><is><t>Item %d</t></is></c>`, r, r, r-1)
+		for i := range months {
+			fmt.Fprintf(&sheet, `<c r="%s%d"><v>%d</v></c>`, indexToCol(i+2), r, r*10+i)
+		}
+		sheet.WriteString("</row>")
+	}
+	sheet.WriteString(`
+  </sheetData>
+</worksheet>`)
+
+	parts := baseXLSXParts(tb)
+	parts["xl/worksheets/sheet1.xml"] = []byte(sheet.String())
+	return parts
+}
@@ -0,0 +1,217 @@
+package pptx
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"why-pptx/internal/chartdiscover"
+	"why-pptx/internal/chartxml"
+)
+
+// extractSkippedChart reports a chart discovery skipped. With
+// Options.Extract.UseCacheFallback the chart is extracted from its caches
+// and the skip alert is recorded at info level; ok is false when it was
+// not extracted.
+func (d *docCall) extractSkippedChart(skip chartdiscover.SkippedChart) (data ExtractedChartData, ok bool, err error) {
+	if !d.opts.Extract.UseCacheFallback {
+		return ExtractedChartData{}, false, d.handleExtractError(skipExtractIssue(skip, "extraction"))
+	}
+	chart := chartdiscover.EmbeddedChart{SlidePath: skip.SlidePath, ChartPath: skip.ChartPath}
+	err = d.guardChart("extract", chart.SlidePath, chart.ChartPath, "", func() error {
+		var err error
+		data, err = d.extractChartFromCache(chart)
+		return err
+	})
+	if err != nil {
+		return ExtractedChartData{}, false, err
+	}
+
+	reason := lookupSkipReason(skip)
+	if reason.code != "" {
+		ctx := reason.contextFor(skip)
+		ctx["source"] = ExtractSourceCache
+		d.addAlert(Alert{
+			Level:   "info",
+			Code:    reason.code,
+			Message: "Chart workbook is not reachable; data is extracted from the chart caches",
+			Context: ctx,
+		})
+	}
+	return data, true, nil
+}
+
+// extractSkippedCharts reports the charts discovery skipped, returning those
+// extracted from their caches. In Strict mode the first error is returned.
+func (d *docCall) extractSkippedCharts(skipped []chartdiscover.SkippedChart) ([]ExtractedChartData, error) {
+	var out []ExtractedChartData
+	for _, skip := range skipped {
+		data, ok, err := d.extractSkippedChart(skip)
+		if err != nil && d.mode() == Strict {
+			return nil, err
+		}
+		if ok {
+			out = append(out, data)
+		}
+	}
+	return out, nil
+}
+
+// extractChartFromCache builds the ExtractedChartData of chart from the
+// points cached in its XML, series numbered as ExtractChartData numbers
+// them. The caches hold what the chart showed when it was last saved.
+func (d *docCall) extractChartFromCache(chart chartdiscover.EmbeddedChart) (ExtractedChartData, error) {
+	parseFailed := func(err error) error {
+		return d.handleExtractError(extractIssue{
+			code:    "CHART_DEPENDENCIES_PARSE_FAILED",
+			message: extractMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
+			err:     err,
+			context: map[string]string{
+				"chart": chart.ChartPath,
+				"slide": chart.SlidePath,
+				"error": err.Error(),
+			},
+		})
+	}
+	chartXML, err := d.pkg.ReadPart(chart.ChartPath)
+	if err != nil {
+		return ExtractedChartData{}, parseFailed(fmt.Errorf("read chart %q: %w", chart.ChartPath, err))
+	}
+	parsed, err := d.parsedChart(chart.ChartPath, chartXML)
+	if err != nil {
+		return ExtractedChartData{}, parseFailed(err)
+	}
+	caches, err := chartxml.ParseCaches(bytes.NewReader(chartXML), d.cancel)
+	if err != nil {
+		return ExtractedChartData{}, parseFailed(err)
+	}
+
+	switch parsed.ChartType {
+	case "bar", "line", "pie", "doughnut", "area", "stock", "scatter", "radar", "mixed":
+	default:
+		return ExtractedChartData{}, d.handleExtractError(extractIssue{
+			code:    "CHART_TYPE_UNSUPPORTED",
+			message: extractMessageForCode("CHART_TYPE_UNSUPPORTED"),
+			err:     fmt.Errorf("unsupported chart type %q", parsed.ChartType),
+			context: map[string]string{"chart": chart.ChartPath, "slide": chart.SlidePath, "chartType": parsed.ChartType},
+		})
+	}
+
+	withValues := caches[:0]
+	for _, cache := range caches {
+		if cache.Values != nil {
+			withValues = append(withValues, cache)
+		}
+	}
+	caches = withValues
+	if parsed.ChartType == "pie" && len(caches) > 1 {
+		if err := d.reportExtraPieSeries(chart, len(caches)); err != nil {
+			return ExtractedChartData{}, err
+		}
+		caches = caches[:1]
+	}
+
+	plotTypes := make(map[int]string)
+	if parsed.ChartType == "mixed" {
+		for _, plot := range parsed.Plots {
+			for _, index := range plot.SeriesIndices {
+				plotTypes[index] = plot.PlotType
+			}
+		}
+	}
+	var legs []string
+	typeDetails := ""
+	if parsed.ChartType == "stock" {
+		legs = stockLegNames(len(caches))
+		typeDetails = strings.ToLower(strings.Join(legs, "-"))
+	}
+
+	labels := []string{}
+	labelsFormat := ""
+	for _, cache := range caches {
+		if cache.Categories != nil {
+			labels, labelsFormat = cache.Categories, cache.CategoriesFormatCode
+			break
+		}
+	}
+
+	// Data labels only inform rendering, so a chart whose info does not
+	// parse has none.
+	var dataLabels *DataLabels
+	var seriesLabels map[int]DataLabels
+	if info, err := d.chartInfo(chart.ChartPath, chartXML); err == nil {
+		dataLabels, seriesLabels = chartDataLabels(info)
+	}
+	series := make([]ExtractedSeries, 0, len(caches))
+	for position, cache := range caches {
+		planned := extractPlanSeries{index: cache.Index}
+		if legs != nil {
+			planned.leg = legs[position]
+		}
+		name := planned.defaultName()
+		if cache.HasName {
+			name = planned.resolveName(cache.Name)
+		}
+		originalIndex, ok := parsed.SeriesIdx[cache.Index]
+		if !ok {
+			originalIndex = cache.Index
+		}
+		extracted := ExtractedSeries{
+			Index:         len(series),
+			OriginalIndex: originalIndex,
+			Name:          name,
+			Data:          cache.Values,
+			Values:        chartValues(cache.Values, d.opts.Workbook.MissingNumericPolicy),
+			PlotType:      plotTypes[cache.Index],
+			Axis:          parsed.SeriesAxes[cache.Index],
+			FormatCode:    cache.FormatCode,
+			DataLabels:    seriesDataLabels(seriesLabels, originalIndex),
+		}
+		if parsed.ChartType == "scatter" && cache.Categories != nil && !equalStringSlice(cache.Categories, labels) {
+			extracted.XValues = cache.Categories
+		}
+		d.reportNonNumericValues(chart, extracted)
+		series = append(series, extracted)
+	}
+
+	data := ExtractedChartData{
+		Type:             parsed.ChartType,
+		Labels:           labels,
+		LabelsFormatCode: labelsFormat,
+		Series:           series,
+		Axes:             chartAxes(parsed.Plots, parsed.AxisGroups),
+		TypeDetails:      typeDetails,
+		DataLabels:       dataLabels,
+		Meta: ExtractMeta{
+			ChartPath: chart.ChartPath,
+			SlidePath: chart.SlidePath,
+			Source:    ExtractSourceCache,
+		},
+	}
+	if d.opts.Extract.ConvertDates && labelsDateFormat(data) != "" {
+		// Without the workbook, the chart's own c:date1904 gives the date
+		// system.
+		convertDateLabels(&data, parsed.Date1904)
+	}
+	return data, nil
+}
+
+// sortChartsByDiscovery puts charts back in discovery order after charts
+// extracted from their caches were added to the end.
+func (d *Document) sortChartsByDiscovery(charts []ExtractedChartData) error {
+	refs, err := d.discoverChartRefs()
+	if err != nil {
+		return err
+	}
+	position := make(map[string]int, len(refs))
+	for i, ref := range refs {
+		if _, ok := position[ref.ChartPath]; !ok {
+			position[ref.ChartPath] = i
+		}
+	}
+	sort.SliceStable(charts, func(i, j int) bool {
+		return position[charts[i].Meta.ChartPath] < position[charts[j].Meta.ChartPath]
+	})
+	return nil
+}
@@ -0,0 +1,60 @@
+package pptx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractChartDataByPathCacheFallback(t *testing.T) {
+	exercisesFeature(t, "extract.cache-fallback")
+
+	opts := DefaultOptions()
+	opts.Extract.UseCacheFallback = true
+	doc, err := OpenFile(fixturePath("area_multi_series_linked_workbook.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if data.Type != "area" || !reflect.DeepEqual(data.Labels, []string{"Old1", "Old2"}) || data.Meta.Source != ExtractSourceCache || data.Meta.WorkbookPath != "" {
+		t.Fatalf("unexpected chart data: %#v", data)
+	}
+	if len(data.Series) != 2 || data.Series[0].Name != "Series 1" || !reflect.DeepEqual(data.Series[0].Data, []string{"10", "20"}) || !reflect.DeepEqual(data.Series[1].Data, []string{"30", "40"}) || data.Series[1].Index != 1 {
+		t.Fatalf("unexpected series: %#v", data.Series)
+	}
+
+	alerts := doc.AlertsByCode("CHART_LINKED_WORKBOOK")
+	if len(alerts) != 1 || alerts[0].Level != "info" || alerts[0].Context["source"] != ExtractSourceCache {
+		t.Fatalf("expected an info CHART_LINKED_WORKBOOK alert, got %#v", doc.Alerts())
+	}
+}
+
+func TestExtractAllChartsCacheFallback(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Extract.UseCacheFallback = true
+	doc, err := OpenFile(fixturePath("pie_linked_workbook.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ExtractAllCharts()
+	if err != nil {
+		t.Fatalf("ExtractAllCharts: %v", err)
+	}
+	if len(charts) != 1 || charts[0].Type != "pie" || !reflect.DeepEqual(charts[0].Labels, []string{"Slice1", "Slice2", "Slice3"}) || charts[0].Series[0].Data[2] != "25" {
+		t.Fatalf("unexpected charts: %#v", charts)
+	}
+
+	doc, err = OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err = doc.ExtractAllCharts()
+	if err != nil {
+		t.Fatalf("ExtractAllCharts: %v", err)
+	}
+	if len(charts) != 1 || charts[0].Meta.Source != ExtractSourceWorkbook {
+		t.Fatalf("expected workbook-backed data, got %#v", charts)
+	}
+}
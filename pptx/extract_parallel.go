@@ -0,0 +1,193 @@
+package pptx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"why-pptx/internal/chartdiscover"
+)
+
+// parallelChart is the outcome of one chart of ExtractAllChartsParallel.
+type parallelChart struct {
+	ran    bool
+	ok     bool
+	data   ExtractedChartData
+	err    error
+	alerts []Alert
+}
+
+// ExtractAllChartsParallel extracts every chart like ExtractAllCharts, on up
+// to workers goroutines; workers < 1 uses runtime.GOMAXPROCS(0). Charts that
+// share an embedded workbook go to the same worker, so the workbook is still
+// read once for all of them. The result is in chart order, and the alerts of
+// every worker are recorded on d in chart order once all of them are done,
+// so both match what ExtractAllCharts gives.
+//
+// In Strict mode the first failing chart stops the workers before their
+// next chart, and the error of the earliest failed chart is returned.
+// Canceling ctx stops them the same way and returns a *CanceledError naming
+// the first chart that did not run. The Logger is called from the worker
+// goroutines.
+func (d *Document) ExtractAllChartsParallel(ctx context.Context, workers int, opts ...CallOption) ([]ExtractedChartData, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(opts)
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return nil, err
+	}
+	fromCache, err := call.extractSkippedCharts(skipped)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := workbookGroups(embedded)
+	if workers > len(groups) {
+		workers = len(groups)
+	}
+	results := make([]parallelChart, len(embedded))
+	stats := make([]Stats, workers)
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	jobs := make(chan []int)
+	var wg sync.WaitGroup
+	for slot := 0; slot < workers; slot++ {
+		wg.Add(1)
+		go func(slot int) {
+			defer wg.Done()
+			worker := call.extractWorker()
+			for group := range jobs {
+				worker.extractGroup(runCtx, stop, embedded, group, results)
+			}
+			stats[slot] = worker.Stats()
+		}(slot)
+	}
+feed:
+	for _, group := range groups {
+		select {
+		case jobs <- group:
+		case <-runCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, s := range stats {
+		d.addStats(s)
+	}
+	out := make([]ExtractedChartData, 0, len(embedded))
+	for i, result := range results {
+		if !result.ran {
+			if err := checkCanceled(ctx, "extract", embedded[i].ChartPath); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, alert := range result.alerts {
+			d.addAlert(alert)
+		}
+		if result.err != nil {
+			return nil, result.err
+		}
+		if result.ok {
+			out = append(out, result.data)
+		}
+	}
+	if len(fromCache) > 0 {
+		out = append(out, fromCache...)
+		if err := d.sortChartsByDiscovery(out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// workbookGroups splits the indexes of charts into groups by embedded
+// workbook, in the order each workbook first appears.
+func workbookGroups(charts []chartdiscover.EmbeddedChart) [][]int {
+	var groups [][]int
+	byWorkbook := make(map[string]int)
+	for i, chart := range charts {
+		group, ok := byWorkbook[chart.WorkbookPath]
+		if !ok {
+			group = len(groups)
+			byWorkbook[chart.WorkbookPath] = group
+			groups = append(groups, nil)
+		}
+		groups[group] = append(groups[group], i)
+	}
+	return groups
+}
+
+// extractWorker returns a call in the mode of d on a Document reading the
+// same package as d with its own alerts, stats, chart models, and chart
+// guard, for one worker of ExtractAllChartsParallel.
+func (d *docCall) extractWorker() *docCall {
+	worker := &Document{
+		pkg:       d.pkg,
+		overlay:   d.overlay,
+		logger:    d.logger,
+		strict:    d.strict,
+		opts:      d.opts,
+		exporters: d.exporters,
+	}
+	return &docCall{Document: worker, errorMode: d.errorMode}
+}
+
+// extractGroup extracts the charts at the indexes in group into results,
+// each with the alerts it raised. A Strict error calls stop.
+func (d *docCall) extractGroup(ctx context.Context, stop context.CancelFunc, embedded []chartdiscover.EmbeddedChart, group []int, results []parallelChart) {
+	charts := make([]chartdiscover.EmbeddedChart, len(group))
+	for i, index := range group {
+		charts[i] = embedded[index]
+	}
+	session := d.newExtractSession(charts)
+	d.prefetchExtractWorkbooks(session, charts)
+
+	for i, index := range group {
+		if ctx.Err() != nil {
+			return
+		}
+		chart := charts[i]
+		result := parallelChart{ran: true}
+		err := recoverChart("extract", chart.ChartPath, func() error {
+			var err error
+			result.data, err = d.extractChartData(session, chart)
+			return err
+		})
+		var panicErr *ChartPanicError
+		switch {
+		case errors.As(err, &panicErr):
+			result.err = d.handleChartPanic(panicErr, chart.SlidePath, chart.WorkbookPath)
+		case err == nil:
+			result.ok = true
+		case d.mode() != BestEffort:
+			result.err = err
+		}
+		result.alerts = d.takeAlerts()
+		results[index] = result
+		if result.err != nil {
+			stop()
+			return
+		}
+	}
+}
+
+// takeAlerts returns the alerts recorded so far and forgets them.
+func (d *Document) takeAlerts() []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	alerts := d.alerts
+	d.alerts = nil
+	return alerts
+}
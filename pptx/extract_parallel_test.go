@@ -0,0 +1,171 @@
+package pptx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractAllChartsParallelMatchesSequential(t *testing.T) {
+	exercisesFeature(t, "extract.parallel")
+
+	parts := parallelDeckParts(t, 24, 6)
+	parts["ppt/charts/chart5.xml"] = sparklineChart("Missing!$B$1:$M$1", "Missing!$B$3:$M$3", "Missing!$A$3")
+	parts["ppt/charts/chart17.xml"] = sparklineChart("Missing!$B$1:$M$1", "Missing!$B$3:$M$3", "Missing!$A$3")
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	sequential, err := OpenFile(path, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	want, err := sequential.ExtractAllCharts()
+	if err != nil {
+		t.Fatalf("ExtractAllCharts: %v", err)
+	}
+
+	for _, workers := range []int{0, 1, 4, 32} {
+		doc, err := OpenFile(path, WithErrorMode(BestEffort))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		got, err := doc.ExtractAllChartsParallel(context.Background(), workers)
+		if err != nil {
+			t.Fatalf("workers=%d: ExtractAllChartsParallel: %v", workers, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("workers=%d: output differs from ExtractAllCharts", workers)
+		}
+		if !reflect.DeepEqual(alertKeys(doc.Alerts()), alertKeys(sequential.Alerts())) {
+			t.Fatalf("workers=%d: alerts differ:\n got %v\nwant %v", workers, alertKeys(doc.Alerts()), alertKeys(sequential.Alerts()))
+		}
+		if stats := doc.Stats(); stats.WorkbookOpens != 6 {
+			t.Fatalf("workers=%d: expected each workbook to be opened once, got %d", workers, stats.WorkbookOpens)
+		}
+	}
+}
+
+func TestExtractAllChartsParallelStrictStops(t *testing.T) {
+	parts := parallelDeckParts(t, 12, 4)
+	parts["ppt/charts/chart7.xml"] = sparklineChart("Missing!$B$1:$M$1", "Missing!$B$3:$M$3", "Missing!$A$3")
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	sequential, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	_, want := sequential.ExtractAllCharts()
+	if want == nil {
+		t.Fatalf("expected ExtractAllCharts to fail")
+	}
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ExtractAllChartsParallel(context.Background(), 4)
+	if err == nil || err.Error() != want.Error() {
+		t.Fatalf("expected %v, got %v", want, err)
+	}
+	if charts != nil {
+		t.Fatalf("expected no charts, got %d", len(charts))
+	}
+}
+
+func TestExtractAllChartsParallelCanceled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(path, parallelDeckParts(t, 8, 4)); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := doc.ExtractAllChartsParallel(ctx, 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDocumentConcurrentReads(t *testing.T) {
+	testDocumentConcurrentReads(t, WithErrorMode(BestEffort))
+}
+
+func TestDocumentConcurrentReadsWithPerChartTimeout(t *testing.T) {
+	opts := timeoutOptions(BestEffort)
+	opts.Limits.PerChartTimeout = time.Minute
+	testDocumentConcurrentReads(t, WithOptions(opts))
+}
+
+// testDocumentConcurrentReads runs the read paths, the batched
+// ExtractAllCharts included, from several goroutines on one Document.
+func testDocumentConcurrentReads(t *testing.T, opts ...Option) {
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(path, parallelDeckParts(t, 8, 2)); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	doc, err := OpenFile(path, opts...)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	done := make(chan error)
+	for i := 1; i <= 8; i++ {
+		go func(i int) {
+			_, err := doc.ExtractChartDataByPath(fmt.Sprintf("ppt/charts/chart%d.xml", i))
+			if err == nil {
+				_, err = doc.ExportChartByPathFormat(fmt.Sprintf("ppt/charts/chart%d.xml", i), ExportChartJS)
+			}
+			if err == nil {
+				_, err = doc.ExtractAllCharts()
+			}
+			doc.Alerts()
+			doc.Stats()
+			done <- err
+		}(i)
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("concurrent read: %v", err)
+		}
+	}
+	if alerts := doc.AlertsByCode("CHART_PROCESSING_TIMEOUT"); len(alerts) != 0 {
+		t.Fatalf("expected no chart to time out, got %#v", alerts)
+	}
+}
+
+// parallelDeckParts is sparklineDeckParts with the count charts spread
+// round robin over workbooks embedded workbooks.
+func parallelDeckParts(tb testing.TB, count, workbooks int) map[string][]byte {
+	tb.Helper()
+
+	parts := sparklineDeckParts(tb, count)
+	for w := 2; w <= workbooks; w++ {
+		parts[fmt.Sprintf("ppt/embeddings/embeddedWorkbook%d.xlsx", w)] = buildSparklineWorkbook(tb, count)
+	}
+	for i := 1; i <= count; i++ {
+		parts[fmt.Sprintf("ppt/charts/_rels/chart%d.xml.rels", i)] = []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook%d.xlsx"/>
+</Relationships>`, (i-1)%workbooks+1))
+	}
+	return parts
+}
+
+func alertKeys(alerts []Alert) []string {
+	keys := make([]string, len(alerts))
+	for i, alert := range alerts {
+		keys[i] = alert.Code + " " + alert.Context["chart"]
+	}
+	return keys
+}
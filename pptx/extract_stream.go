@@ -0,0 +1,146 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/chartdiscover"
+	"why-pptx/internal/xlsxembed"
+)
+
+// ChartValueSink receives the values of a streamed chart extraction. series
+// is the series' ExtractedSeries.Index, or -1 for the chart's categories; index is the
+// position within the range, counting on across the segments of a union
+// formula. A series name arrives once, at index 0, resolved
+// the way ExtractedSeries.Name is; a data label range arrives as
+// RangeDataLabels, one value per point. Multi-level categories arrive as
+// their innermost level, as in ExtractedChartData.Labels. A scatter chart delivers its
+// labels, the x values of its first series, as categories, its y values as
+// RangeValues, and the x values of any series that differ as RangeXValues.
+// Returning an error stops the extraction.
+type ChartValueSink func(series int, kind ChartRangeKind, index int, value string) error
+
+// ExtractChartDataStream reads the same values as ExtractChartDataByPath but
+// hands them to sink while the worksheet is scanned, without building the
+// per-series slices. Each sheet is read in a single pass shared by all of the
+// chart's ranges, so values of different series interleave in sheet order.
+// Cells missing from the sheet are delivered as empty strings after their
+// sheet is scanned. An error returned by sink is returned unchanged.
+func (d *Document) ExtractChartDataStream(chartPath string, sink ChartValueSink) error {
+	call := d.newCall(nil)
+	if sink == nil {
+		return fmt.Errorf("sink is required")
+	}
+	chart, err := call.findExtractChart(chartPath)
+	if err != nil {
+		return err
+	}
+	return call.guardChart("extract", chart.SlidePath, chart.ChartPath, chart.WorkbookPath, func() error {
+		return call.streamChartData(chart, sink)
+	})
+}
+
+type streamTarget struct {
+	series   *extractPlanSeries
+	position int
+	kind     ChartRangeKind
+	// offset is the number of cells in earlier segments of a union formula.
+	offset int
+}
+
+func (d *docCall) streamChartData(chart chartdiscover.EmbeddedChart, sink ChartValueSink) error {
+	plan, err := d.planChartExtraction(nil, chart)
+	if err != nil {
+		return err
+	}
+	wb, err := d.openExtractWorkbook(nil, chart)
+	if err != nil {
+		return err
+	}
+
+	var ranges []xlsxembed.Range
+	var targets []streamTarget
+	sheets := make(map[string]struct{})
+	add := func(r ChartRange, target streamTarget) error {
+		for _, segment := range formulaSegments(plan.ranges, r) {
+			ranges = append(ranges, xlsxembed.Range{Sheet: segment.Sheet, StartCell: segment.StartCell, EndCell: segment.EndCell})
+			targets = append(targets, target)
+			sheets[segment.Sheet] = struct{}{}
+			cells, err := expandRangeCells(segment.StartCell, segment.EndCell)
+			if err != nil {
+				return err
+			}
+			target.offset += len(cells)
+		}
+		return nil
+	}
+
+	if plan.labels != nil && (plan.multiLevel || formulaHasGrid(plan.ranges, *plan.labels)) {
+		// Collapsed labels and the innermost level of multi-level ones need
+		// the whole block, so they are read up front.
+		labels, _, err := d.readPlanLabels(nil, wb, plan)
+		if err != nil {
+			return d.handleWorkbookRangeError(chart, plan.labels.Sheet, err)
+		}
+		for i, label := range labels {
+			if err := sink(-1, RangeCategories, i, label); err != nil {
+				return err
+			}
+		}
+	} else if plan.labels != nil {
+		if err := add(*plan.labels, streamTarget{kind: RangeCategories}); err != nil {
+			return err
+		}
+	}
+	for i := range plan.series {
+		series := &plan.series[i]
+		if series.name == nil {
+			if err := sink(i, RangeSeriesName, 0, series.defaultName()); err != nil {
+				return err
+			}
+		} else if err := add(*series.name, streamTarget{series: series, position: i, kind: RangeSeriesName}); err != nil {
+			return err
+		}
+		if err := add(series.values, streamTarget{series: series, position: i, kind: RangeValues}); err != nil {
+			return err
+		}
+		if series.labels != nil {
+			if err := add(*series.labels, streamTarget{series: series, position: i, kind: RangeDataLabels}); err != nil {
+				return err
+			}
+		}
+		if series.xValues != nil {
+			if err := add(*series.xValues, streamTarget{series: series, position: i, kind: RangeXValues}); err != nil {
+				return err
+			}
+		}
+	}
+
+	var sinkErr error
+	err = wb.StreamRanges(ranges, xlsxembed.MissingNumericEmpty, func(rangeIndex, pos int, value string) error {
+		target := targets[rangeIndex]
+		seriesIndex := -1
+		if target.series != nil {
+			seriesIndex = target.position
+		}
+		pos += target.offset
+		if target.kind == RangeSeriesName {
+			if pos != 0 {
+				return nil
+			}
+			value = target.series.resolveName(value)
+		}
+		if err := sink(seriesIndex, target.kind, pos, value); err != nil {
+			sinkErr = err
+			return err
+		}
+		return nil
+	})
+	if sinkErr != nil {
+		return sinkErr
+	}
+	if err != nil {
+		return d.handleWorkbookRangeError(chart, plan.sheet, err)
+	}
+	d.addStats(Stats{SheetScans: len(sheets)})
+	return nil
+}
@@ -0,0 +1,268 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExtractChartDataStreamMatchesMaterialized(t *testing.T) {
+	exercisesFeature(t, "extract.stream")
+
+	fixtures := []string{
+		"bar_simple_embedded.pptx",
+		"line_multi_series_embedded.pptx",
+		"line_secondary_axis.pptx",
+		"pie_simple_embedded.pptx",
+		"area_multi_series_valid.pptx",
+		"mix_bar_line_simple.pptx",
+		"mix_bar_line_secondary_axis.pptx",
+		"shared_workbook_two_charts.pptx",
+		"workbook_inlineStr_edgecases.pptx",
+	}
+	for _, name := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			doc, err := OpenFile(fixturePath(name))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			charts, err := doc.DiscoverEmbeddedCharts()
+			if err != nil {
+				t.Fatalf("DiscoverEmbeddedCharts: %v", err)
+			}
+			if len(charts) == 0 {
+				t.Fatalf("fixture has no charts")
+			}
+			for _, chart := range charts {
+				want, err := doc.ExtractChartDataByPath(chart.ChartPath)
+				if err != nil {
+					t.Fatalf("ExtractChartDataByPath %s: %v", chart.ChartPath, err)
+				}
+				got := streamToExtracted(t, doc, chart.ChartPath, want)
+				if !reflect.DeepEqual(got.Labels, want.Labels) {
+					t.Fatalf("%s labels: streamed %v, materialized %v", chart.ChartPath, got.Labels, want.Labels)
+				}
+				if !reflect.DeepEqual(got.Series, want.Series) {
+					t.Fatalf("%s series: streamed %+v, materialized %+v", chart.ChartPath, got.Series, want.Series)
+				}
+			}
+		})
+	}
+}
+
+// streamToExtracted rebuilds labels, names, and values from a streamed
+// extraction, laid out like want so the two can be compared directly.
+func streamToExtracted(t *testing.T, doc *Document, chartPath string, want ExtractedChartData) ExtractedChartData {
+	t.Helper()
+
+	got := ExtractedChartData{Labels: make([]string, len(want.Labels))}
+	position := make(map[int]int, len(want.Series))
+	for i, series := range want.Series {
+		position[series.Index] = i
+		got.Series = append(got.Series, ExtractedSeries{
+			Index:         series.Index,
+			OriginalIndex: series.OriginalIndex,
+			Data:          make([]string, len(series.Data)),
+			PlotType:      series.PlotType,
+			Axis:          series.Axis,
+		})
+	}
+
+	err := doc.ExtractChartDataStream(chartPath, func(series int, kind ChartRangeKind, index int, value string) error {
+		if kind == RangeCategories {
+			if series != -1 || index >= len(got.Labels) {
+				return fmt.Errorf("unexpected category %d for series %d", index, series)
+			}
+			got.Labels[index] = value
+			return nil
+		}
+		i, ok := position[series]
+		if !ok {
+			return fmt.Errorf("unexpected series %d", series)
+		}
+		switch kind {
+		case RangeSeriesName:
+			got.Series[i].Name = value
+		case RangeValues:
+			if index >= len(got.Series[i].Data) {
+				return fmt.Errorf("series %d index %d out of range", series, index)
+			}
+			got.Series[i].Data[index] = value
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExtractChartDataStream %s: %v", chartPath, err)
+	}
+	// The stream delivers raw cells; type them as extraction does.
+	for i := range got.Series {
+		got.Series[i].Values = chartValues(got.Series[i].Data, doc.opts.Workbook.MissingNumericPolicy)
+	}
+	return got
+}
+
+func TestExtractChartDataStreamReturnsSinkError(t *testing.T) {
+	doc, err := OpenFile(fixturePath("line_multi_series_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	stop := errors.New("stop")
+	err = doc.ExtractChartDataStream("ppt/charts/chart1.xml", func(series int, kind ChartRangeKind, index int, value string) error {
+		if kind == RangeValues {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected sink error, got %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("sink errors must not be alerted: %#v", doc.Alerts())
+	}
+}
+
+func TestExtractChartDataStreamRejectsWrongPath(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.ExtractChartDataStream("ppt/slides/slide1.xml", func(int, ChartRangeKind, int, string) error { return nil })
+	var pathErr *ChartPathError
+	if !errors.As(err, &pathErr) || pathErr.Kind != ChartPathSlide {
+		t.Fatalf("expected slide ChartPathError, got %v", err)
+	}
+}
+
+const (
+	wideChartSeries = 30
+	wideChartPoints = 10000
+)
+
+// BenchmarkExtractWideChartMaterialized and BenchmarkExtractWideChartStream
+// read a generated 30 x 10k (300k-point) chart. live-B/op is the heap still
+// reachable while the values are in hand: all of them for the materialized
+// API, the scanner state for the stream.
+func BenchmarkExtractWideChartMaterialized(b *testing.B) {
+	doc := openWideChartDeck(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	var live uint64
+	for i := 0; i < b.N; i++ {
+		base := liveHeap()
+		data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+		if err != nil {
+			b.Fatalf("ExtractChartDataByPath: %v", err)
+		}
+		live += liveHeap() - base
+		runtime.KeepAlive(data)
+	}
+	b.ReportMetric(float64(live)/float64(b.N), "live-B/op")
+}
+
+func BenchmarkExtractWideChartStream(b *testing.B) {
+	doc := openWideChartDeck(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	var live uint64
+	for i := 0; i < b.N; i++ {
+		base := liveHeap()
+		var peak uint64
+		count := 0
+		err := doc.ExtractChartDataStream("ppt/charts/chart1.xml", func(series int, kind ChartRangeKind, index int, value string) error {
+			count++
+			if count == wideChartSeries*wideChartPoints/2 {
+				if heap := liveHeap(); heap > base {
+					peak = heap - base
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatalf("ExtractChartDataStream: %v", err)
+		}
+		live += peak
+	}
+	b.ReportMetric(float64(live)/float64(b.N), "live-B/op")
+}
+
+func liveHeap() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func openWideChartDeck(b *testing.B) *Document {
+	b.Helper()
+
+	var formulas strings.Builder
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1">`)
+	lastRow := wideChartPoints + 1
+	for s := 0; s < wideChartSeries; s++ {
+		col := indexToCol(s + 2)
+		fmt.Fprintf(&sheet, `<c r="%s1" t="inlineStr"><is><t>Series %d</t></is></c>`, col, s+1)
+		fmt.Fprintf(&formulas, `
+        <c:ser>
+          <c:idx val="%d"/>
+          <c:order val="%d"/>
+          <c:tx><c:strRef><c:f>Sheet1!$%s$1</c:f></c:strRef></c:tx>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$%d</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$%s$2:$%s$%d</c:f></c:numRef></c:val>
+        </c:ser>`, s, s, col, lastRow, col, col, lastRow)
+	}
+	sheet.WriteString("</row>")
+	for r := 2; r <= lastRow; r++ {
+		fmt.Fprintf(&sheet, `
+    <row r="%d"><c r="A%d" t="inlineStr"><is><t>P%d</t></is></c>`, r, r, r-1)
+		for s := 0; s < wideChartSeries; s++ {
+			fmt.Fprintf(&sheet, `<c r="%s%d"><v>%d</v></c>`, indexToCol(s+2), r, r*wideChartSeries+s)
+		}
+		sheet.WriteString("</row>")
+	}
+	sheet.WriteString(`
+  </sheetData>
+</worksheet>`)
+
+	workbook := baseXLSXParts(b)
+	workbook["xl/worksheets/sheet1.xml"] = []byte(sheet.String())
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"></p:sld>`),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:lineChart>` + formulas.String() + `
+      </c:lineChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": writeZipBytes(b, workbook),
+	}
+
+	path := filepath.Join(b.TempDir(), "wide.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		b.Fatalf("writeZipFile: %v", err)
+	}
+	doc, err := OpenFile(path)
+	if err != nil {
+		b.Fatalf("OpenFile: %v", err)
+	}
+	return doc
+}
@@ -1,11 +1,17 @@
 package pptx
 
 import (
+	"bytes"
+	"errors"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestExtractChartDataByPath_BarSimple(t *testing.T) {
+	exercisesFeature(t, "extract.bar")
+
 	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
 	if err != nil {
 		t.Fatalf("OpenFile: %v", err)
@@ -55,6 +61,8 @@ func TestExtractChartDataByPath_BarSimple(t *testing.T) {
 }
 
 func TestExtractChartDataByPath_LineMultiSeries(t *testing.T) {
+	exercisesFeature(t, "extract.line")
+
 	doc, err := OpenFile(fixturePath("line_multi_series_embedded.pptx"))
 	if err != nil {
 		t.Fatalf("OpenFile: %v", err)
@@ -118,6 +126,8 @@ func TestExtractChartDataByPath_LineMissingCachedValues(t *testing.T) {
 }
 
 func TestExtractChartDataByPath_PieSimple(t *testing.T) {
+	exercisesFeature(t, "extract.pie")
+
 	doc, err := OpenFile(fixturePath("pie_simple_embedded.pptx"))
 	if err != nil {
 		t.Fatalf("OpenFile: %v", err)
@@ -143,6 +153,8 @@ func TestExtractChartDataByPath_PieSimple(t *testing.T) {
 }
 
 func TestExtractChartDataByPath_AreaSimple(t *testing.T) {
+	exercisesFeature(t, "extract.area")
+
 	doc, err := OpenFile(fixturePath("area_simple_embedded.pptx"))
 	if err != nil {
 		t.Fatalf("OpenFile: %v", err)
@@ -187,6 +199,8 @@ func TestExtractChartDataByPath_InlineStrRichText(t *testing.T) {
 }
 
 func TestExtractChartDataByPath_MixedBarLine(t *testing.T) {
+	exercisesFeature(t, "extract.mixed")
+
 	doc, err := OpenFile(fixturePath("mix_bar_line_simple.pptx"))
 	if err != nil {
 		t.Fatalf("OpenFile: %v", err)
@@ -239,6 +253,62 @@ func TestExtractChartDataByPath_MixedSecondaryAxis(t *testing.T) {
 	}
 }
 
+func TestExtractChartDataByPath_LineSecondaryAxis(t *testing.T) {
+	doc, err := OpenFile(fixturePath("line_secondary_axis.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+
+	if data.Type != "line" || len(data.Series) != 2 {
+		t.Fatalf("unexpected extraction: %+v", data)
+	}
+	if data.Series[0].Axis != "primary" || data.Series[1].Axis != "secondary" {
+		t.Fatalf("unexpected axis values: %+v", data.Series)
+	}
+}
+
+func TestExtractChartDataByPath_SingleAxisLeavesAxisEmpty(t *testing.T) {
+	doc, err := OpenFile(fixturePath("line_multi_series_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	for _, series := range data.Series {
+		if series.Axis != "" {
+			t.Fatalf("expected no axis on a single-axis chart, got %+v", data.Series)
+		}
+	}
+}
+
+func TestExportChartByPathFormat_LineSecondaryAxis(t *testing.T) {
+	doc, err := OpenFile(fixturePath("line_secondary_axis.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	payload, err := doc.ExportChartByPathFormat("ppt/charts/chart1.xml", ExportChartJS)
+	if err != nil {
+		t.Fatalf("ExportChartByPathFormat: %v", err)
+	}
+	datasets := payload.Data["datasets"].([]map[string]any)
+	if len(datasets) != 2 || datasets[0]["yAxisID"] != "y" || datasets[1]["yAxisID"] != "y1" {
+		t.Fatalf("unexpected datasets: %#v", datasets)
+	}
+	scales := payload.Data["options"].(map[string]any)["scales"].(map[string]any)
+	if scales["y1"].(map[string]any)["position"] != "right" {
+		t.Fatalf("expected right-hand secondary scale, got %#v", scales)
+	}
+}
+
 func TestExportChartByPathFormat_Pie(t *testing.T) {
 	doc, err := OpenFile(fixturePath("pie_simple_embedded.pptx"))
 	if err != nil {
@@ -350,7 +420,7 @@ func TestExtractMixedUnsupported_BestEffort(t *testing.T) {
 	}
 }
 
-func TestExtractAllCharts_SharedStrings_BestEffort(t *testing.T) {
+func TestExtractAllCharts_SharedStringIndexOutOfRange_BestEffort(t *testing.T) {
 	opts := DefaultOptions()
 	opts.Mode = BestEffort
 	doc, err := OpenFile(fixturePath("xlsx_sharedStrings_present.pptx"), WithOptions(opts))
@@ -366,13 +436,14 @@ func TestExtractAllCharts_SharedStrings_BestEffort(t *testing.T) {
 		t.Fatalf("expected no charts, got %d", len(charts))
 	}
 
-	alerts := doc.AlertsByCode("EXTRACT_SHAREDSTRINGS_UNSUPPORTED")
+	// The fixture's t="s" cell points past its empty table.
+	alerts := doc.AlertsByCode("EXTRACT_CELL_PARSE_ERROR")
 	if len(alerts) != 1 {
-		t.Fatalf("expected EXTRACT_SHAREDSTRINGS_UNSUPPORTED alert, got %d", len(alerts))
+		t.Fatalf("expected EXTRACT_CELL_PARSE_ERROR alert, got %d", len(alerts))
 	}
 }
 
-func TestExtractChartDataByPath_SharedStrings_Strict(t *testing.T) {
+func TestExtractChartDataByPath_SharedStringIndexOutOfRange_Strict(t *testing.T) {
 	doc, err := OpenFile(fixturePath("xlsx_sharedStrings_present.pptx"))
 	if err != nil {
 		t.Fatalf("OpenFile: %v", err)
@@ -426,55 +497,33 @@ func TestExtractChartDataByPath_PieMultiSeries(t *testing.T) {
 	if err != nil {
 		t.Fatalf("OpenFile: %v", err)
 	}
-	if _, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml"); err == nil {
-		t.Fatalf("expected ExtractChartDataByPath error")
+	if _, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml"); !errors.Is(err, ErrPieMultipleSeries) {
+		t.Fatalf("expected ErrPieMultipleSeries, got %v", err)
+	}
+	if alerts := doc.AlertsByCode("EXTRACT_INVALID_RANGE"); len(alerts) != 0 {
+		t.Fatalf("expected no EXTRACT_INVALID_RANGE alert, got %#v", alerts)
 	}
 }
 
-func TestDetectSharedStringsPart(t *testing.T) {
-	parts := baseXLSXParts(t)
-	parts["xl/sharedStrings.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?><sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"></sst>`)
-	data := writeZipBytes(t, parts)
-
-	found, partPath, cellRef, err := detectSharedStrings(data)
+func TestExtractChartDataByPath_PieMultiSeriesBestEffort(t *testing.T) {
+	doc, err := OpenFile(fixturePath("pie_edit_multiple_series.pptx"), WithErrorMode(BestEffort))
 	if err != nil {
-		t.Fatalf("detectSharedStrings: %v", err)
-	}
-	if !found {
-		t.Fatalf("expected sharedStrings detection")
-	}
-	if partPath != "xl/sharedStrings.xml" {
-		t.Fatalf("unexpected part path %q", partPath)
-	}
-	if cellRef != "" {
-		t.Fatalf("unexpected cell ref %q", cellRef)
+		t.Fatalf("OpenFile: %v", err)
 	}
-}
-
-func TestDetectSharedStringsCell(t *testing.T) {
-	parts := baseXLSXParts(t)
-	parts["xl/worksheets/sheet1.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
-<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
-  <sheetData>
-    <row r="1">
-      <c r="A1" t="s"><v>0</v></c>
-    </row>
-  </sheetData>
-</worksheet>`)
-	data := writeZipBytes(t, parts)
-
-	found, partPath, cellRef, err := detectSharedStrings(data)
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
 	if err != nil {
-		t.Fatalf("detectSharedStrings: %v", err)
+		t.Fatalf("ExtractChartDataByPath: %v", err)
 	}
-	if !found {
-		t.Fatalf("expected shared string cell detection")
+	if len(data.Series) != 1 || data.Series[0].Index != 0 || len(data.Series[0].Data) != 2 {
+		t.Fatalf("expected only the first series, got %#v", data.Series)
 	}
-	if partPath != "xl/worksheets/sheet1.xml" {
-		t.Fatalf("unexpected sheet path %q", partPath)
+
+	alerts := doc.AlertsByCode("EXTRACT_PIE_EXTRA_SERIES_IGNORED")
+	if len(alerts) != 1 || alerts[0].Context["seriesCount"] != "2" || alerts[0].Context["ignored"] != "1" {
+		t.Fatalf("expected EXTRACT_PIE_EXTRA_SERIES_IGNORED, got %#v", doc.Alerts())
 	}
-	if cellRef != "A1" {
-		t.Fatalf("unexpected cell ref %q", cellRef)
+	if alerts := doc.AlertsByCode("EXTRACT_INVALID_RANGE"); len(alerts) != 0 {
+		t.Fatalf("expected no EXTRACT_INVALID_RANGE alert, got %#v", alerts)
 	}
 }
 
@@ -500,7 +549,7 @@ func TestExpandRangeCells(t *testing.T) {
 	}
 }
 
-func baseXLSXParts(t *testing.T) map[string][]byte {
+func baseXLSXParts(t testing.TB) map[string][]byte {
 	t.Helper()
 
 	return map[string][]byte{
@@ -528,3 +577,148 @@ func baseXLSXParts(t *testing.T) map[string][]byte {
 </worksheet>`),
 	}
 }
+
+// flakyExporter fails on failPath and panics on panicPath.
+type flakyExporter struct {
+	failPath  string
+	panicPath string
+}
+
+func (f flakyExporter) Format() ExportFormat { return "flaky" }
+
+func (f flakyExporter) Export(in ExtractedChartData) (ExportedPayload, error) {
+	switch in.Meta.ChartPath {
+	case f.failPath:
+		return ExportedPayload{}, errors.New("boom")
+	case f.panicPath:
+		panic("exporter bug")
+	}
+	return ExportedPayload{Format: "flaky", Data: map[string]any{"chart": in.Meta.ChartPath}}, nil
+}
+
+func TestExportAllChartsDetailedBestEffortFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter flakyExporter
+		wantErr  string
+	}{
+		{name: "Error", exporter: flakyExporter{failPath: "ppt/charts/chart1.xml"}, wantErr: "boom"},
+		{name: "Panic", exporter: flakyExporter{panicPath: "ppt/charts/chart1.xml"}, wantErr: "exporter panicked: exporter bug"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := OpenFile(fixturePath("shared_workbook_two_charts.pptx"), WithErrorMode(BestEffort))
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+
+			result, err := doc.ExportAllChartsDetailed(test.exporter)
+			if err != nil {
+				t.Fatalf("ExportAllChartsDetailed: %v", err)
+			}
+			if len(result.Exported) != 1 || result.Exported[0].ChartPath != "ppt/charts/chart2.xml" {
+				t.Fatalf("unexpected exported charts: %#v", result.Exported)
+			}
+			want := []ExportFailure{{
+				ChartPath: "ppt/charts/chart1.xml",
+				SlidePath: "ppt/slides/slide1.xml",
+				Format:    "flaky",
+				Error:     test.wantErr,
+			}}
+			if !reflect.DeepEqual(result.Failures, want) {
+				t.Fatalf("unexpected failures: %#v", result.Failures)
+			}
+
+			alerts := doc.AlertsByCode("EXPORT_CHART_FAILED")
+			if len(alerts) != 1 {
+				t.Fatalf("expected one EXPORT_CHART_FAILED alert, got %#v", doc.Alerts())
+			}
+			ctx := alerts[0].Context
+			if ctx["chart"] != "ppt/charts/chart1.xml" || ctx["format"] != "flaky" || ctx["error"] != test.wantErr {
+				t.Fatalf("unexpected alert context: %#v", ctx)
+			}
+			if len(doc.AlertsByCode("EXTRACT_CELL_PARSE_ERROR")) != 0 {
+				t.Fatalf("exporter failure must not be reported as a cell parse error")
+			}
+
+			payloads, err := doc.ExportAllCharts(test.exporter)
+			if err != nil {
+				t.Fatalf("ExportAllCharts: %v", err)
+			}
+			if len(payloads) != 1 || payloads[0].Data["chart"] != "ppt/charts/chart2.xml" {
+				t.Fatalf("unexpected payloads: %#v", payloads)
+			}
+		})
+	}
+}
+
+func TestExportAllChartsStrictPanicReturnsError(t *testing.T) {
+	doc, err := OpenFile(fixturePath("shared_workbook_two_charts.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.ExportAllCharts(flakyExporter{panicPath: "ppt/charts/chart2.xml"}); err == nil {
+		t.Fatalf("expected strict export to fail on a panicking exporter")
+	}
+	if _, err := doc.ExportChartByPath("ppt/charts/chart2.xml", flakyExporter{panicPath: "ppt/charts/chart2.xml"}); err == nil {
+		t.Fatalf("expected single-chart export to recover the panic as an error")
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("strict mode should not record alerts, got %#v", doc.Alerts())
+	}
+}
+
+func TestExtractChartDataFormatCodes(t *testing.T) {
+	exercisesFeature(t, "extract.format-codes")
+
+	chartXML := strings.Replace(string(chartWithCaches("Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3", []string{"Old1", "Old2"}, []string{"10", "20"})),
+		"<c:numCache>", "<c:numCache><c:formatCode>0.0%</c:formatCode>", 1)
+	inputPath := filepath.Join(t.TempDir(), "input.pptx")
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte("<slide/>"),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": []byte(chartXML),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildWorkbookWithValues(t, "Old1", "Old2", 10, 20),
+	}
+	if err := writeZipFile(inputPath, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if len(data.Series) != 1 || data.Series[0].FormatCode != "0.0%" || data.LabelsFormatCode != "" {
+		t.Fatalf("unexpected format codes: %#v", data)
+	}
+	payload, err := doc.ExportChartByPathFormat("ppt/charts/chart1.xml", ExportChartJS)
+	if err != nil {
+		t.Fatalf("ExportChartByPathFormat: %v", err)
+	}
+	if datasets := payload.Data["datasets"].([]map[string]any); datasets[0]["formatCode"] != "0.0%" {
+		t.Fatalf("expected the dataset formatCode, got %#v", datasets)
+	}
+
+	if err := doc.ApplyChartData(0, map[string][]string{"values:0": {"0.25", "0.5"}}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	synced, err := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ReadPart: %v", err)
+	}
+	if !bytes.Contains(synced, []byte(">0.0%</formatCode>")) || !bytes.Contains(synced, []byte(">0.5</v>")) {
+		t.Fatalf("expected cache sync to keep the formatCode:\n%s", synced)
+	}
+}
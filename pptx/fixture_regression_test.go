@@ -120,7 +120,7 @@ func TestStrictMalformedCache_Abort(t *testing.T) {
 		Mode:             postflight.ModeStrict,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, badChart)
 	})
 	if err == nil {
@@ -170,7 +170,7 @@ func TestBestEffortMalformedCache_Skip_AndContinue(t *testing.T) {
 		Mode:             postflight.ModeBestEffort,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set("ppt/charts/chart2.xml", badChart)
 	})
 	if err == nil {
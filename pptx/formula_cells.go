@@ -0,0 +1,94 @@
+package pptx
+
+import (
+	"why-pptx/internal/xlsxembed"
+)
+
+// openEmbeddedWorkbook opens the embedded workbook at workbookPath from
+// data, within workbookBudget, with formula, boolean, and error cells
+// handled as Options.Workbook says.
+func (d *docCall) openEmbeddedWorkbook(workbookPath string, data []byte) (*xlsxembed.Workbook, error) {
+	wb, err := openWorkbook(data, d.workbookBudget(workbookPath))
+	if err != nil {
+		return nil, err
+	}
+	if d.opts.Workbook.RejectFormulaCells {
+		wb.SetFormulaPolicy(xlsxembed.FormulaReject, nil)
+	} else {
+		wb.SetFormulaPolicy(xlsxembed.FormulaCachedValue, func(sheet, cell string) {
+			d.reportFormulaCellRead(workbookPath, sheet, cell)
+		})
+	}
+	wb.SetFormulaOverwrite(func(sheet, cell string) {
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "WORKBOOK_FORMULA_OVERWRITTEN",
+			Message: "Wrote a value over a formula cell; the formula was removed",
+			Context: map[string]string{
+				"workbook": workbookPath,
+				"sheet":    sheet,
+				"cell":     cell,
+			},
+		})
+	})
+	if d.opts.Workbook.BoolWords {
+		wb.SetBoolFormat(xlsxembed.BoolWords)
+	}
+	wb.SetErrorCells(func(sheet, cell, value string) {
+		d.reportErrorCellRead(workbookPath, sheet, cell, value)
+	})
+	return wb, nil
+}
+
+// firstCellReport reports whether code has not yet been reported for the
+// cell, and marks it reported.
+func (d *Document) firstCellReport(code, workbookPath, sheet, cell string) bool {
+	key := code + "!" + workbookPath + "!" + sheet + "!" + cell
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cellsReported == nil {
+		d.cellsReported = make(map[string]bool)
+	}
+	if d.cellsReported[key] {
+		return false
+	}
+	d.cellsReported[key] = true
+	return true
+}
+
+// reportFormulaCellRead records an EXTRACT_FORMULA_CELL_VALUE_USED alert,
+// in both modes, the first time the cached value of a formula cell is read.
+func (d *docCall) reportFormulaCellRead(workbookPath, sheet, cell string) {
+	if !d.firstCellReport("EXTRACT_FORMULA_CELL_VALUE_USED", workbookPath, sheet, cell) {
+		return
+	}
+	d.addAlert(Alert{
+		Level:   "info",
+		Code:    "EXTRACT_FORMULA_CELL_VALUE_USED",
+		Message: "Read the cached result of a formula cell; it may be stale if the workbook was not recalculated",
+		Context: map[string]string{
+			"workbook": workbookPath,
+			"sheet":    sheet,
+			"cell":     cell,
+		},
+	})
+}
+
+// reportErrorCellRead records an EXTRACT_CELL_ERROR_VALUE alert, in both
+// modes, the first time an error cell is read. The cell reads as missing.
+func (d *docCall) reportErrorCellRead(workbookPath, sheet, cell, value string) {
+	if !d.firstCellReport("EXTRACT_CELL_ERROR_VALUE", workbookPath, sheet, cell) {
+		return
+	}
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "EXTRACT_CELL_ERROR_VALUE",
+		Message: "Cell holds an Excel error; it is read as a missing value",
+		Context: map[string]string{
+			"workbook": workbookPath,
+			"sheet":    sheet,
+			"cell":     cell,
+			"value":    value,
+		},
+	})
+}
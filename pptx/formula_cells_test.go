@@ -0,0 +1,104 @@
+package pptx
+
+import (
+	"testing"
+)
+
+// writeFormulaDeck writes a bar chart whose second value is a SUM formula
+// cell with a cached result.
+func writeFormulaDeck(t *testing.T) string {
+	t.Helper()
+	return writeSheetChartDeck(t, `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="2"><c r="A2" t="inlineStr"><is><t>North</t></is></c><c r="B2"><v>40</v></c></row>
+    <row r="3"><c r="A3" t="inlineStr"><is><t>Total</t></is></c><c r="B3"><f>SUM(B2,2)</f><v>42</v></c></row>
+  </sheetData>
+</worksheet>`, "Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3")
+}
+
+func TestExtractFormulaCellCachedValue(t *testing.T) {
+	exercisesFeature(t, "extract.formula-cells")
+	input := writeFormulaDeck(t)
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+		if err != nil {
+			t.Fatalf("ExtractChartDataByPath: %v", err)
+		}
+		if got := data.Series[0].Data; len(got) != 2 || got[0] != "40" || got[1] != "42" {
+			t.Fatalf("unexpected values: %v", got)
+		}
+	}
+	alerts := doc.AlertsByCode("EXTRACT_FORMULA_CELL_VALUE_USED")
+	if len(alerts) != 1 || alerts[0].Level != "info" || alerts[0].Context["cell"] != "B3" || alerts[0].Context["sheet"] != "Sheet1" {
+		t.Fatalf("expected one EXTRACT_FORMULA_CELL_VALUE_USED alert, got %#v", doc.Alerts())
+	}
+
+	opts := DefaultOptions()
+	opts.Mode = BestEffort
+	opts.Workbook.RejectFormulaCells = true
+	strict, err := OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := strict.ExtractChartDataByPath("ppt/charts/chart1.xml"); err == nil {
+		t.Fatalf("expected RejectFormulaCells to fail extraction")
+	}
+	rejected := strict.AlertsByCode("EXTRACT_UNSUPPORTED_CELL_TYPE")
+	if len(rejected) != 1 || rejected[0].Context["cell"] != "B3" || rejected[0].Context["cellType"] != "formula" {
+		t.Fatalf("expected EXTRACT_UNSUPPORTED_CELL_TYPE for the formula cell, got %#v", strict.Alerts())
+	}
+	if len(strict.AlertsByCode("EXTRACT_FORMULA_CELL_VALUE_USED")) != 0 {
+		t.Fatalf("unexpected EXTRACT_FORMULA_CELL_VALUE_USED alert")
+	}
+}
+
+func TestFormulaCellAlertAfterClearAlerts(t *testing.T) {
+	doc, err := OpenFile(writeFormulaDeck(t))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml"); err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	doc.ClearAlerts()
+	if _, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml"); err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if alerts := doc.AlertsByCode("EXTRACT_FORMULA_CELL_VALUE_USED"); len(alerts) != 1 || alerts[0].Context["cell"] != "B3" {
+		t.Fatalf("expected EXTRACT_FORMULA_CELL_VALUE_USED again after ClearAlerts, got %#v", doc.Alerts())
+	}
+}
+
+func TestWriteFormulaCellDropsFormula(t *testing.T) {
+	doc, err := OpenFile(writeFormulaDeck(t))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "B2", Value: Num(1)},
+		{WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx", Sheet: "Sheet1", Cell: "B3", Value: Num(7)},
+	})
+	if err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	alerts := doc.AlertsByCode("WORKBOOK_FORMULA_OVERWRITTEN")
+	if len(alerts) != 1 || alerts[0].Context["cell"] != "B3" || alerts[0].Context["workbook"] != "ppt/embeddings/embeddedWorkbook1.xlsx" {
+		t.Fatalf("expected one WORKBOOK_FORMULA_OVERWRITTEN alert, got %#v", doc.Alerts())
+	}
+
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if got := data.Series[0].Data; len(got) != 2 || got[0] != "1" || got[1] != "7" {
+		t.Fatalf("unexpected values: %v", got)
+	}
+	if len(doc.AlertsByCode("EXTRACT_FORMULA_CELL_VALUE_USED")) != 0 {
+		t.Fatalf("the written cell must no longer read as a formula")
+	}
+}
@@ -90,6 +90,10 @@ func TestGoldenSnapshots(t *testing.T) {
 			name:  "workbook_inlineStr_edgecases",
 			input: fixturePath("workbook_inlineStr_edgecases.pptx"),
 		},
+		{
+			name:  "line_secondary_axis",
+			input: fixturePath("line_secondary_axis.pptx"),
+		},
 	}
 
 	for _, tc := range cases {
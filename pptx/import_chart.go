@@ -0,0 +1,676 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/chartdiscover"
+	"why-pptx/internal/ooxmlpkg"
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/postflight"
+	"why-pptx/internal/rels"
+)
+
+const (
+	nsPresentationML = "http://schemas.openxmlformats.org/presentationml/2006/main"
+	nsDrawingML      = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	nsChart          = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+	nsRelationships  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+	chartRelType     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+	chartContentType = "application/vnd.openxmlformats-officedocument.drawingml.chart+xml"
+
+	userShapesRelType     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chartUserShapes"
+	userShapesContentType = "application/vnd.openxmlformats-officedocument.drawingml.chartshapes+xml"
+)
+
+// relContentTypes covers parts whose content type follows from how the
+// chart references them when the source declares no override.
+var relContentTypes = map[string]string{
+	userShapesRelType: userShapesContentType,
+}
+
+// defaultContentTypes covers extensions a copied part may need when neither
+// deck declares them.
+var defaultContentTypes = map[string]string{
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"xml":  "application/xml",
+	"rels": "application/vnd.openxmlformats-package.relationships+xml",
+}
+
+// defaultFrameGeometry places an imported chart whose source slide has no
+// graphicFrame for it.
+var defaultFrameGeometry = frameGeometry{X: "0", Y: "0", CX: "6096000", CY: "4064000"}
+
+type frameGeometry struct {
+	X, Y, CX, CY string
+}
+
+type sourceFrame struct {
+	Name     string
+	Descr    string
+	Geometry frameGeometry
+}
+
+type copiedPart struct {
+	srcPath string
+	newPath string
+	// relType is the type of the relationship the part was reached
+	// through; empty for the chart itself and for rels parts.
+	relType string
+	data    []byte
+}
+
+// ImportChart copies a chart from src onto targetSlidePath: the chart part,
+// its relationships, the embedded workbook, and every other internal part
+// reachable from the chart (such as a userShapes drawing and the images it
+// uses), all under fresh names. The new graphicFrame reuses the
+// geometry of the chart's frame on its source slide. The import is staged
+// and validated like any other chart write, and the returned chart path can
+// be passed to ApplyChartDataByPath straight away. src may be d itself.
+func (d *Document) ImportChart(src *Document, srcChartPath, targetSlidePath string) (string, error) {
+	if d == nil || d.pkg == nil {
+		return "", fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	if src == nil || src.pkg == nil {
+		return "", fmt.Errorf("source document not initialized")
+	}
+	if err := d.ensureOverlay(); err != nil {
+		return "", err
+	}
+
+	chart, err := findImportableChart(src, srcChartPath)
+	if err != nil {
+		return "", err
+	}
+	slideXML, err := d.pkg.ReadPart(targetSlidePath)
+	if err != nil {
+		return "", fmt.Errorf("read target slide %q: %w", targetSlidePath, err)
+	}
+
+	taken, err := d.overlay.ListEntries()
+	if err != nil {
+		return "", err
+	}
+	names := newPartNames(taken)
+
+	newChartPath := names.allocate(chart.ChartPath)
+	parts, renamed, err := copyPartGraph(src, chart.ChartPath, newChartPath, names)
+	if err != nil {
+		return "", err
+	}
+	newWorkbookPath := renamed[chart.WorkbookPath]
+
+	frame := sourceFrame{Geometry: defaultFrameGeometry}
+	if relID, err := src.findChartRelID(chart.SlidePath, chart.ChartPath); err == nil && relID != "" {
+		if srcSlide, err := src.pkg.ReadPart(chart.SlidePath); err == nil {
+			if found, ok, err := parseSourceFrame(srcSlide, relID); err == nil && ok {
+				frame = found
+			}
+		}
+	}
+
+	srcContentTypes, err := src.pkg.ReadPart(contentTypesPart)
+	if err != nil && !errors.Is(err, ooxmlpkg.ErrPartNotFound) {
+		return "", fmt.Errorf("read %q: %w", contentTypesPart, err)
+	}
+	srcTypes, err := parseContentTypes(srcContentTypes)
+	if err != nil {
+		return "", err
+	}
+
+	mode := postflight.ModeStrict
+	if call.mode() == BestEffort {
+		mode = postflight.ModeBestEffort
+	}
+	ctx := postflight.ValidateContext{
+		ChartPath:            newChartPath,
+		SlidePath:            targetSlidePath,
+		WorkbookPath:         newWorkbookPath,
+		Mode:                 mode,
+		CacheSyncEnabled:     d.opts.Chart.CacheSync,
+		MissingNumericPolicy: int(d.opts.Workbook.MissingNumericPolicy),
+	}
+	for _, part := range parts {
+		ctx.AllowedNewParts = append(ctx.AllowedNewParts, part.newPath)
+	}
+	targetSlideRels := slideRelsPath(targetSlidePath)
+	for _, part := range []string{contentTypesPart, targetSlideRels} {
+		exists, err := d.overlay.Has(part)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			ctx.AllowedNewParts = append(ctx.AllowedNewParts, part)
+		}
+	}
+
+	err = call.runChartStage(ctx, func(stage overlaystage.Overlay) error {
+		for _, part := range parts {
+			if err := stage.Set(part.newPath, part.data); err != nil {
+				return err
+			}
+		}
+		if err := declareImportedParts(stage, srcTypes, parts); err != nil {
+			return err
+		}
+
+		relID, err := addSlideChartRelationship(stage, targetSlideRels, targetSlidePath, newChartPath)
+		if err != nil {
+			return err
+		}
+		updated, err := insertChartFrame(slideXML, relID, frame)
+		if err != nil {
+			return fmt.Errorf("insert chart frame into %q: %w", targetSlidePath, err)
+		}
+		if err := stage.Set(targetSlidePath, updated); err != nil {
+			return err
+		}
+
+		d.manifest.stage(ManifestChange{
+			Operation:    "importChart",
+			ChartPath:    newChartPath,
+			WorkbookPath: newWorkbookPath,
+		})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	// The imported parts are now part of the deck; writes such as
+	// ApplyChartDataByPath must be able to stage them again.
+	if adopter, ok := d.overlay.(overlaystage.BaselineAdopter); ok {
+		adopter.AdoptBaseline(ctx.AllowedNewParts...)
+	}
+	return newChartPath, nil
+}
+
+func findImportableChart(src *Document, chartPath string) (chartdiscover.EmbeddedChart, error) {
+	embedded, skipped, err := src.discoverEmbeddedCharts()
+	if err != nil {
+		return chartdiscover.EmbeddedChart{}, err
+	}
+	for _, chart := range embedded {
+		if chart.ChartPath == chartPath {
+			return chart, nil
+		}
+	}
+	for _, skip := range skipped {
+		if skip.ChartPath == chartPath {
+			return chartdiscover.EmbeddedChart{}, fmt.Errorf("chart %q cannot be imported: %s", chartPath, skip.Reason)
+		}
+	}
+	return chartdiscover.EmbeddedChart{}, fmt.Errorf("chart %q not found in source document", chartPath)
+}
+
+// copyPartGraph copies root under newRoot together with every internal part
+// reachable from it through relationships. Each copied part that has rels
+// gets them rewritten to the new names. renamed maps source paths to their
+// copies.
+func copyPartGraph(src *Document, root, newRoot string, names partNames) ([]copiedPart, map[string]string, error) {
+	renamed := map[string]string{root: newRoot}
+	relTypes := map[string]string{}
+	queue := []string{root}
+	var parts []copiedPart
+
+	for len(queue) > 0 {
+		srcPath := queue[0]
+		queue = queue[1:]
+		newPath := renamed[srcPath]
+
+		data, err := src.pkg.ReadPart(srcPath)
+		if err != nil {
+			if srcPath == root {
+				return nil, nil, fmt.Errorf("read chart %q: %w", srcPath, err)
+			}
+			return nil, nil, fmt.Errorf("read chart dependency %q: %w", srcPath, err)
+		}
+		parts = append(parts, copiedPart{srcPath: srcPath, newPath: newPath, relType: relTypes[srcPath], data: data})
+
+		srcRelsPath := slideRelsPath(srcPath)
+		relsXML, err := src.pkg.ReadPart(srcRelsPath)
+		if errors.Is(err, ooxmlpkg.ErrPartNotFound) && srcPath != root {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read rels %q: %w", srcRelsPath, err)
+		}
+		parsed, err := rels.Parse(bytes.NewReader(relsXML))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ids := make([]string, 0, len(parsed.ByID))
+		for id := range parsed.ByID {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		rewritten := make(map[string]rels.Relationship, len(ids))
+		for _, id := range ids {
+			rel := parsed.ByID[id]
+			if rel.TargetMode == "External" {
+				rewritten[id] = rel
+				continue
+			}
+			target := rels.ResolveTarget(srcPath, rel.Target)
+			if _, seen := renamed[target]; !seen {
+				renamed[target] = names.allocate(target)
+				relTypes[target] = rel.Type
+				queue = append(queue, target)
+			}
+			rel.Target = relativeTarget(newPath, renamed[target])
+			rewritten[id] = rel
+		}
+		parts = append(parts, copiedPart{srcPath: srcRelsPath, newPath: slideRelsPath(newPath), data: encodeRels(rewritten)})
+	}
+	return parts, renamed, nil
+}
+
+// partNames hands out part names that are free in the target package,
+// keeping the source directory and name stem: ppt/charts/chart1.xml becomes
+// the first free ppt/charts/chartN.xml.
+type partNames map[string]struct{}
+
+func newPartNames(existing []string) partNames {
+	names := make(partNames, len(existing))
+	for _, name := range existing {
+		names[name] = struct{}{}
+	}
+	return names
+}
+
+func (p partNames) allocate(srcPath string) string {
+	dir, base := path.Split(srcPath)
+	ext := path.Ext(base)
+	stem := strings.TrimRight(strings.TrimSuffix(base, ext), "0123456789")
+	for n := 1; ; n++ {
+		candidate := dir + stem + strconv.Itoa(n) + ext
+		if _, ok := p[candidate]; !ok {
+			p[candidate] = struct{}{}
+			return candidate
+		}
+	}
+}
+
+// relativeTarget returns the relationship target for toPart as seen from
+// fromPart.
+func relativeTarget(fromPart, toPart string) string {
+	from := strings.Split(path.Dir(fromPart), "/")
+	to := strings.Split(toPart, "/")
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+	var b strings.Builder
+	for i := common; i < len(from); i++ {
+		if from[i] != "." && from[i] != "" {
+			b.WriteString("../")
+		}
+	}
+	b.WriteString(strings.Join(to[common:], "/"))
+	return b.String()
+}
+
+func encodeRels(byID map[string]rels.Relationship) []byte {
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for _, id := range ids {
+		rel := byID[id]
+		b.WriteString(`<Relationship Id="`)
+		xml.EscapeText(&b, []byte(id))
+		b.WriteString(`" Type="`)
+		xml.EscapeText(&b, []byte(rel.Type))
+		b.WriteString(`" Target="`)
+		xml.EscapeText(&b, []byte(rel.Target))
+		b.WriteString(`"`)
+		if rel.TargetMode != "" {
+			b.WriteString(` TargetMode="`)
+			xml.EscapeText(&b, []byte(rel.TargetMode))
+			b.WriteString(`"`)
+		}
+		b.WriteString(`/>`)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.Bytes()
+}
+
+type contentTypes struct {
+	defaults  map[string]string
+	overrides map[string]string
+}
+
+func parseContentTypes(data []byte) (contentTypes, error) {
+	out := contentTypes{defaults: map[string]string{}, overrides: map[string]string{}}
+	if len(data) == 0 {
+		return out, nil
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return contentTypes{}, fmt.Errorf("parse content types: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		var key, contentType string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "Extension", "PartName":
+				key = strings.ToLower(attr.Value)
+			case "ContentType":
+				contentType = attr.Value
+			}
+		}
+		switch start.Name.Local {
+		case "Default":
+			out.defaults[key] = contentType
+		case "Override":
+			out.overrides[key] = contentType
+		}
+	}
+}
+
+// declareImportedParts registers content types for the copied parts: source
+// overrides are carried over under the new names, anything else relies on an
+// extension default, added when the target lacks one.
+func declareImportedParts(stage overlaystage.Overlay, srcTypes contentTypes, parts []copiedPart) error {
+	data, err := stage.Get(contentTypesPart)
+	if errors.Is(err, ooxmlpkg.ErrPartNotFound) {
+		data = []byte(emptyContentTypes)
+	} else if err != nil {
+		return fmt.Errorf("read %q: %w", contentTypesPart, err)
+	}
+	target, err := parseContentTypes(data)
+	if err != nil {
+		return err
+	}
+
+	var added strings.Builder
+	for _, part := range parts {
+		override, ok := srcTypes.overrides[strings.ToLower("/"+part.srcPath)]
+		if !ok {
+			override, ok = relContentTypes[part.relType]
+		}
+		if !ok && strings.HasPrefix(part.newPath, "ppt/charts/") && path.Ext(part.newPath) == ".xml" {
+			override, ok = chartContentType, true
+		}
+		if ok {
+			fmt.Fprintf(&added, `<Override PartName="/%s" ContentType="%s"/>`, part.newPath, override)
+			continue
+		}
+
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(part.newPath), "."))
+		if _, declared := target.defaults[ext]; declared {
+			continue
+		}
+		contentType, ok := srcTypes.defaults[ext]
+		if !ok {
+			contentType, ok = defaultContentTypes[ext]
+		}
+		if !ok {
+			return fmt.Errorf("no content type for imported part %q", part.newPath)
+		}
+		target.defaults[ext] = contentType
+		fmt.Fprintf(&added, `<Default Extension="%s" ContentType="%s"/>`, ext, contentType)
+	}
+	if added.Len() == 0 {
+		return nil
+	}
+
+	updated, err := insertBeforeClosing(data, "</Types>", added.String())
+	if err != nil {
+		return fmt.Errorf("declare imported parts in %q: %w", contentTypesPart, err)
+	}
+	return stage.Set(contentTypesPart, updated)
+}
+
+func addSlideChartRelationship(stage overlaystage.Overlay, relsPath, slidePath, chartPath string) (string, error) {
+	data, err := stage.Get(relsPath)
+	if errors.Is(err, ooxmlpkg.ErrPartNotFound) {
+		data = []byte(emptyPackageRels)
+	} else if err != nil {
+		return "", fmt.Errorf("read %q: %w", relsPath, err)
+	}
+	editor, err := rels.Load(data)
+	if err != nil {
+		return "", fmt.Errorf("add chart relationship to %q: %w", relsPath, err)
+	}
+
+	id := editor.AddRelationship(chartRelType, relativeTarget(slidePath, chartPath), "")
+	return id, stage.Set(relsPath, editor.Serialize())
+}
+
+// parseSourceFrame finds the graphicFrame holding the chart with relID.
+// Elements are matched by local name so any prefix binding works.
+func parseSourceFrame(data []byte, relID string) (sourceFrame, bool, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	var frame sourceFrame
+	matched := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return sourceFrame{}, false, nil
+		}
+		if err != nil {
+			return sourceFrame{}, false, fmt.Errorf("parse slide xml: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if tok.Name.Local == "graphicFrame" {
+					depth = 1
+					frame = sourceFrame{Geometry: defaultFrameGeometry}
+					matched = false
+				}
+				continue
+			}
+			depth++
+			switch tok.Name.Local {
+			case "cNvPr":
+				frame.Name = attrValue(tok.Attr, "name")
+				frame.Descr = attrValue(tok.Attr, "descr")
+			case "off":
+				frame.Geometry.X = attrValue(tok.Attr, "x")
+				frame.Geometry.Y = attrValue(tok.Attr, "y")
+			case "ext":
+				if cx := attrValue(tok.Attr, "cx"); cx != "" {
+					frame.Geometry.CX = cx
+					frame.Geometry.CY = attrValue(tok.Attr, "cy")
+				}
+			case "chart":
+				matched = attrValue(tok.Attr, "id") == relID
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 && matched {
+				return frame, true, nil
+			}
+		}
+	}
+}
+
+// insertChartFrame appends a graphicFrame for relID to the slide's spTree.
+// The frame reuses whatever prefixes the slide binds to the namespaces it
+// needs and declares the rest itself.
+func insertChartFrame(slideXML []byte, relID string, frame sourceFrame) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(slideXML))
+	var scopes []map[string]string
+	maxID := 0
+	treeStart, treeEnd := int64(-1), int64(-1)
+	treeDepth := 0
+	var bindings map[string]string
+	selfClosing := false
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse slide xml: %w", err)
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			scope := make(map[string]string)
+			for _, attr := range tok.Attr {
+				if attr.Name.Space == "xmlns" {
+					scope[attr.Value] = attr.Name.Local
+				} else if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+					scope[attr.Value] = ""
+				}
+			}
+			scopes = append(scopes, scope)
+			if tok.Name.Local == "cNvPr" {
+				if id, err := strconv.Atoi(attrValue(tok.Attr, "id")); err == nil && id > maxID {
+					maxID = id
+				}
+			}
+			if treeStart < 0 && tok.Name.Local == "spTree" && tok.Name.Space == nsPresentationML {
+				treeStart = offset
+				treeDepth = len(scopes)
+				bindings = mergeScopes(scopes)
+			}
+		case xml.EndElement:
+			if treeEnd < 0 && treeStart >= 0 && len(scopes) == treeDepth {
+				treeEnd = offset
+				selfClosing = decoder.InputOffset() == offset
+			}
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+	if treeStart < 0 || treeEnd < 0 {
+		return nil, fmt.Errorf("slide has no spTree")
+	}
+
+	element := buildChartFrame(bindings, relID, maxID+1, frame)
+	out := make([]byte, 0, len(slideXML)+len(element)+32)
+	if selfClosing {
+		// <p:spTree/>: reopen the tag so the frame has a parent to live in.
+		closeAt := bytes.LastIndex(slideXML[:treeEnd], []byte("/>"))
+		if closeAt < 0 {
+			return nil, fmt.Errorf("malformed spTree tag")
+		}
+		name := elementName(slideXML[treeStart:])
+		out = append(out, slideXML[:closeAt]...)
+		out = append(out, '>')
+		out = append(out, element...)
+		out = append(out, "</"+name+">"...)
+		out = append(out, slideXML[closeAt+2:]...)
+		return out, nil
+	}
+	out = append(out, slideXML[:treeEnd]...)
+	out = append(out, element...)
+	out = append(out, slideXML[treeEnd:]...)
+	return out, nil
+}
+
+func mergeScopes(scopes []map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, scope := range scopes {
+		for ns, prefix := range scope {
+			merged[ns] = prefix
+		}
+	}
+	return merged
+}
+
+func buildChartFrame(bindings map[string]string, relID string, id int, frame sourceFrame) string {
+	fallback := []struct{ ns, prefix string }{
+		{nsPresentationML, "p"},
+		{nsDrawingML, "a"},
+		{nsChart, "c"},
+		{nsRelationships, "r"},
+	}
+	prefixes := make(map[string]string, len(fallback))
+	var decls strings.Builder
+	for _, f := range fallback {
+		prefix, ok := bindings[f.ns]
+		// Attributes never pick up the default namespace, so r needs a
+		// real prefix.
+		if !ok || (prefix == "" && f.ns == nsRelationships) {
+			prefix = f.prefix
+			fmt.Fprintf(&decls, ` xmlns:%s="%s"`, prefix, f.ns)
+		}
+		prefixes[f.ns] = prefix
+	}
+	name := func(ns, local string) string {
+		if prefixes[ns] == "" {
+			return local
+		}
+		return prefixes[ns] + ":" + local
+	}
+
+	p := func(local string) string { return name(nsPresentationML, local) }
+	a := func(local string) string { return name(nsDrawingML, local) }
+	frameName := frame.Name
+	if frameName == "" {
+		frameName = "Chart " + strconv.Itoa(id)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<%s%s>`, p("graphicFrame"), decls.String())
+	fmt.Fprintf(&b, `<%s><%s id="%d" name="%s"`, p("nvGraphicFramePr"), p("cNvPr"), id, escapeAttr(frameName))
+	if frame.Descr != "" {
+		fmt.Fprintf(&b, ` descr="%s"`, escapeAttr(frame.Descr))
+	}
+	fmt.Fprintf(&b, `/><%s/><%s/></%s>`, p("cNvGraphicFramePr"), p("nvPr"), p("nvGraphicFramePr"))
+	fmt.Fprintf(&b, `<%s><%s x="%s" y="%s"/><%s cx="%s" cy="%s"/></%s>`,
+		p("xfrm"), a("off"), escapeAttr(frame.Geometry.X), escapeAttr(frame.Geometry.Y),
+		a("ext"), escapeAttr(frame.Geometry.CX), escapeAttr(frame.Geometry.CY), p("xfrm"))
+	fmt.Fprintf(&b, `<%s><%s uri="%s"><%s %s:id="%s"/></%s></%s>`,
+		a("graphic"), a("graphicData"), nsChart, name(nsChart, "chart"), prefixes[nsRelationships], escapeAttr(relID), a("graphicData"), a("graphic"))
+	fmt.Fprintf(&b, `</%s>`, p("graphicFrame"))
+	return b.String()
+}
+
+// elementName returns the qualified name of the tag at the start of data.
+func elementName(data []byte) string {
+	end := bytes.IndexAny(data, " \t\r\n/>")
+	if end < 0 {
+		return ""
+	}
+	return string(data[1:end])
+}
+
+func attrValue(attrs []xml.Attr, local string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func escapeAttr(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
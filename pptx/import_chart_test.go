@@ -0,0 +1,234 @@
+package pptx
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"why-pptx/internal/rels"
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+// importTargetSlide binds PresentationML and DrawingML to unusual prefixes so
+// the inserted frame has to follow the target's bindings.
+const importTargetSlide = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<pml:sld xmlns:pml="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:dml="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:rel="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <pml:cSld>
+    <pml:spTree>
+      <pml:nvGrpSpPr><pml:cNvPr id="1" name=""/><pml:cNvGrpSpPr/><pml:nvPr/></pml:nvGrpSpPr>
+      <pml:grpSpPr/>
+      <pml:sp><pml:nvSpPr><pml:cNvPr id="4" name="Title 1"/><pml:cNvSpPr/><pml:nvPr/></pml:nvSpPr><pml:spPr/></pml:sp>
+    </pml:spTree>
+  </pml:cSld>
+</pml:sld>`
+
+func writeImportTarget(t *testing.T, dir string) string {
+	t.Helper()
+
+	parts := map[string][]byte{
+		"[Content_Types].xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Override PartName="/ppt/slides/slide1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slide+xml"/>
+</Types>`),
+		"ppt/slides/slide1.xml": []byte(importTargetSlide),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slideLayout" Target="../slideLayouts/slideLayout1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide" Target="../notesSlides/notesSlide1.xml"/>
+</Relationships>`),
+		"ppt/slideLayouts/slideLayout1.xml": []byte("<sldLayout/>"),
+		"ppt/notesSlides/notesSlide1.xml":   []byte("<notes/>"),
+	}
+	path := filepath.Join(dir, "target.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestImportChartIntoMinimalDeck(t *testing.T) {
+	exercisesFeature(t, "chart.import")
+
+	dir := t.TempDir()
+	src, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile source: %v", err)
+	}
+	doc, err := OpenFile(writeImportTarget(t, dir))
+	if err != nil {
+		t.Fatalf("OpenFile target: %v", err)
+	}
+
+	chartPath, err := doc.ImportChart(src, "ppt/charts/chart1.xml", "ppt/slides/slide1.xml")
+	if err != nil {
+		t.Fatalf("ImportChart: %v", err)
+	}
+	if chartPath != "ppt/charts/chart1.xml" {
+		t.Fatalf("unexpected chart path %q", chartPath)
+	}
+
+	data := map[string][]string{
+		"categories": {"North", "South"},
+		"values:0":   {"7", "9"},
+	}
+	if err := doc.ApplyChartDataByPath(chartPath, data); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	output := filepath.Join(dir, "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	cats, vals := extractChartCacheValues(t, readZipEntry(t, output, chartPath))
+	if strings.Join(cats, ",") != "North,South" || strings.Join(vals, ",") != "7,9" {
+		t.Fatalf("unexpected caches: cats=%v vals=%v", cats, vals)
+	}
+	workbook := readEmbeddedWorkbook(t, output, "ppt/embeddings/embeddedWorkbook1.xlsx")
+	cells, err := pptxassert.ExtractWorkbookCellSnapshot(workbook, "Sheet1", []string{"A2", "B3"})
+	if err != nil {
+		t.Fatalf("ExtractWorkbookCellSnapshot: %v", err)
+	}
+	if cells["A2"] != "North" || cells["B3"] != "9" {
+		t.Fatalf("unexpected workbook cells: %v", cells)
+	}
+
+	slideRels, err := rels.Parse(bytes.NewReader(readZipEntry(t, output, "ppt/slides/_rels/slide1.xml.rels")))
+	if err != nil {
+		t.Fatalf("parse slide rels: %v", err)
+	}
+	rel, ok := slideRels.ByID["rId3"]
+	if !ok || rel.Target != "../charts/chart1.xml" || len(slideRels.ByID) != 3 {
+		t.Fatalf("expected chart relationship rId3, got %#v", slideRels.ByID)
+	}
+
+	slide := string(readZipEntry(t, output, "ppt/slides/slide1.xml"))
+	for _, want := range []string{
+		`<pml:graphicFrame xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">`,
+		`<pml:cNvPr id="5" name="Chart 5"/>`,
+		`<dml:off x="0" y="0"/><dml:ext cx="6096000" cy="4064000"/>`,
+		`<c:chart rel:id="rId3"/>`,
+	} {
+		if !strings.Contains(slide, want) {
+			t.Fatalf("slide is missing %s:\n%s", want, slide)
+		}
+	}
+	if !strings.HasSuffix(strings.TrimSpace(strings.Split(slide, "</pml:spTree>")[0]), "</pml:graphicFrame>") {
+		t.Fatalf("expected frame at the end of spTree:\n%s", slide)
+	}
+
+	contentTypes := string(readZipEntry(t, output, "[Content_Types].xml"))
+	if !strings.Contains(contentTypes, `<Override PartName="/ppt/charts/chart1.xml" ContentType="application/vnd.openxmlformats-officedocument.drawingml.chart+xml"/>`) ||
+		!strings.Contains(contentTypes, `<Default Extension="xlsx"`) {
+		t.Fatalf("imported parts not declared:\n%s", contentTypes)
+	}
+
+	reopened, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile output: %v", err)
+	}
+	charts, err := reopened.ListCharts()
+	if err != nil {
+		t.Fatalf("ListCharts: %v", err)
+	}
+	if len(charts) != 1 || charts[0].WorkbookPath != "ppt/embeddings/embeddedWorkbook1.xlsx" || charts[0].Title != "Chart 5" {
+		t.Fatalf("unexpected charts after import: %#v", charts)
+	}
+}
+
+func TestImportChartFreshNamesAndGeometry(t *testing.T) {
+	dir := t.TempDir()
+	slide := `<p:sld xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:cSld><p:spTree>` +
+		`<p:graphicFrame><p:nvGraphicFramePr><p:cNvPr id="2" name="Revenue" descr="Quarterly revenue"/><p:cNvGraphicFramePr/><p:nvPr/></p:nvGraphicFramePr>` +
+		`<p:xfrm><a:off x="838200" y="1825625"/><a:ext cx="10515600" cy="4351338"/></p:xfrm>` +
+		`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" r:id="rId7"/></a:graphicData></a:graphic></p:graphicFrame>` +
+		`</p:spTree></p:cSld></p:sld>`
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte(slide),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": chartWithCaches("Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3", []string{"A", "B"}, []string{"1", "2"}),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildWorkbookWithValues(t, "A", "B", 1, 2),
+	}
+	path := filepath.Join(dir, "deck.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	chartPath, err := doc.ImportChart(doc, "ppt/charts/chart1.xml", "ppt/slides/slide1.xml")
+	if err != nil {
+		t.Fatalf("ImportChart: %v", err)
+	}
+	if chartPath != "ppt/charts/chart2.xml" {
+		t.Fatalf("expected fresh chart name, got %q", chartPath)
+	}
+
+	output := filepath.Join(dir, "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	chartRels := string(readZipEntry(t, output, "ppt/charts/_rels/chart2.xml.rels"))
+	if !strings.Contains(chartRels, `Target="../embeddings/embeddedWorkbook2.xlsx"`) {
+		t.Fatalf("chart rels not rewritten:\n%s", chartRels)
+	}
+	if !bytes.Equal(readZipEntry(t, output, "ppt/embeddings/embeddedWorkbook2.xlsx"), parts["ppt/embeddings/embeddedWorkbook1.xlsx"]) {
+		t.Fatalf("expected workbook to be copied byte for byte")
+	}
+
+	slideXML := string(readZipEntry(t, output, "ppt/slides/slide1.xml"))
+	for _, want := range []string{
+		`<p:cNvPr id="3" name="Revenue" descr="Quarterly revenue"/>`,
+		`<a:off x="838200" y="1825625"/><a:ext cx="10515600" cy="4351338"/>`,
+		`<c:chart r:id="rId1"/>`,
+	} {
+		if !strings.Contains(slideXML, want) {
+			t.Fatalf("slide is missing %s:\n%s", want, slideXML)
+		}
+	}
+
+	reopened, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile output: %v", err)
+	}
+	deps, err := reopened.GetChartDependencies()
+	if err != nil {
+		t.Fatalf("GetChartDependencies: %v", err)
+	}
+	if len(deps) != 2 {
+		t.Fatalf("expected both charts after import, got %#v", deps)
+	}
+	for _, dep := range deps {
+		if dep.ChartPath == "ppt/charts/chart2.xml" && dep.WorkbookPath != "ppt/embeddings/embeddedWorkbook2.xlsx" {
+			t.Fatalf("imported chart reads the wrong workbook: %#v", dep)
+		}
+	}
+}
+
+func TestImportChartRejectsLinkedWorkbook(t *testing.T) {
+	src, err := OpenFile(fixturePath("linked_workbook_chart.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile source: %v", err)
+	}
+	doc, err := OpenFile(writeImportTarget(t, t.TempDir()))
+	if err != nil {
+		t.Fatalf("OpenFile target: %v", err)
+	}
+	if _, err := doc.ImportChart(src, "ppt/charts/chart1.xml", "ppt/slides/slide1.xml"); err == nil {
+		t.Fatalf("expected linked-workbook chart import to fail")
+	}
+	if _, err := doc.ImportChart(src, "ppt/charts/chart9.xml", "ppt/slides/slide1.xml"); err == nil {
+		t.Fatalf("expected unknown chart import to fail")
+	}
+}
@@ -0,0 +1,422 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"why-pptx/internal/ooxmlpkg"
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/postflight"
+	"why-pptx/internal/rels"
+)
+
+const (
+	changeManifestPart          = "docProps/whypptx-manifest.json"
+	changeManifestContentType   = "application/json"
+	changeManifestRelType       = "urn:why-pptx:relationships:change-manifest"
+	changeManifestSchemaVersion = 1
+
+	contentTypesPart = "[Content_Types].xml"
+	packageRelsPart  = "_rels/.rels"
+)
+
+// ChangeManifest is the change log kept inside a package when
+// Options.Save.WriteChangeManifest is enabled. Each SaveFile appends one run.
+type ChangeManifest struct {
+	SchemaVersion int           `json:"schemaVersion"`
+	Runs          []ManifestRun `json:"runs"`
+}
+
+// ManifestRun records the changes committed between two saves.
+type ManifestRun struct {
+	Library string    `json:"library"`
+	Version string    `json:"version"`
+	SavedAt time.Time `json:"savedAt"`
+	// Features are the capability flags of the library that wrote the run,
+	// as reported by Features.
+	Features map[string]bool `json:"features,omitempty"`
+	// AlertCodes lists, sorted and deduplicated, the alert codes raised
+	// during the run.
+	AlertCodes []string         `json:"alertCodes,omitempty"`
+	Changes    []ManifestChange `json:"changes"`
+}
+
+// ManifestChange records one committed write.
+type ManifestChange struct {
+	// Operation is the API that made the change: applyChartData,
+	// setWorkbookCells, setWorkbookRange, syncChartCaches,
+	// repairChartCaches, or importChart.
+	Operation    string `json:"operation"`
+	ChartPath    string `json:"chartPath,omitempty"`
+	WorkbookPath string `json:"workbookPath,omitempty"`
+	// Ranges are the workbook ranges (or cells, for setWorkbookCells) the
+	// change wrote or read back into chart caches.
+	Ranges []string `json:"ranges,omitempty"`
+	// ValueCount is the number of cells or cache points written.
+	ValueCount int       `json:"valueCount,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// manifestState tracks the change manifest of one Document. Changes noted
+// inside a chart stage are held until the stage commits, so discarded or
+// timed-out stages leave no record.
+type manifestState struct {
+	loaded bool
+	// base is the manifest found in the package when first needed.
+	base *ChangeManifest
+	// runs are the runs appended by this Document's successful saves.
+	runs      []ManifestRun
+	pending   []ManifestChange
+	staged    []ManifestChange
+	staging   bool
+	alertMark int
+	// cleared holds the codes of alerts ClearAlerts dropped since the last
+	// save.
+	cleared []string
+}
+
+func (m *manifestState) record(change ManifestChange) {
+	change.At = time.Now().UTC()
+	m.pending = append(m.pending, change)
+}
+
+func (m *manifestState) stage(change ManifestChange) {
+	if !m.staging {
+		m.record(change)
+		return
+	}
+	m.staged = append(m.staged, change)
+}
+
+func (m *manifestState) beginStage() {
+	m.staging = true
+	m.staged = nil
+}
+
+func (m *manifestState) commitStage() {
+	staged := m.staged
+	m.discardStage()
+	for _, change := range staged {
+		m.record(change)
+	}
+}
+
+func (m *manifestState) discardStage() {
+	m.staging = false
+	m.staged = nil
+}
+
+func (m *manifestState) saved(run ManifestRun, alertCount int) {
+	m.runs = append(m.runs, run)
+	m.pending = nil
+	m.alertMark = alertCount
+	m.cleared = nil
+}
+
+// clearAlerts keeps the codes of the alerts raised since the last save
+// before ClearAlerts drops them.
+func (m *manifestState) clearAlerts(alerts []Alert) {
+	m.cleared = append(m.cleared, alertCodes(alerts[m.alertMark:])...)
+	m.alertMark = 0
+}
+
+// alertCodes returns the codes of the alerts raised since the last save,
+// cleared ones included.
+func (m *manifestState) alertCodes(alerts []Alert) []string {
+	codes := alertCodes(alerts[m.alertMark:])
+	if len(m.cleared) == 0 {
+		return codes
+	}
+	merged := make([]Alert, 0, len(codes)+len(m.cleared))
+	for _, code := range append(codes, m.cleared...) {
+		merged = append(merged, Alert{Code: code})
+	}
+	return alertCodes(merged)
+}
+
+func (m *manifestState) current() *ChangeManifest {
+	if m.base == nil && len(m.runs) == 0 {
+		return nil
+	}
+	out := &ChangeManifest{SchemaVersion: changeManifestSchemaVersion}
+	if m.base != nil {
+		out.Runs = append(out.Runs, m.base.Runs...)
+	}
+	out.Runs = append(out.Runs, m.runs...)
+	return out
+}
+
+func chartChange(operation string, dep ChartDependencies, ranges []string, valueCount int) ManifestChange {
+	return ManifestChange{
+		Operation:    operation,
+		ChartPath:    dep.ChartPath,
+		WorkbookPath: dep.WorkbookPath,
+		Ranges:       ranges,
+		ValueCount:   valueCount,
+	}
+}
+
+func workbookCellsChange(workbookPath string, updates []CellUpdate) ManifestChange {
+	cells := make([]string, 0, len(updates))
+	for _, update := range updates {
+		cells = append(cells, update.Sheet+"!"+update.Cell)
+	}
+	return ManifestChange{
+		Operation:    "setWorkbookCells",
+		WorkbookPath: workbookPath,
+		Ranges:       cells,
+		ValueCount:   len(updates),
+	}
+}
+
+func workbookRangeChange(workbookPath, sheet, startCell, endCell string, count int) ManifestChange {
+	return ManifestChange{
+		Operation:    "setWorkbookRange",
+		WorkbookPath: workbookPath,
+		Ranges:       []string{sheet + "!" + startCell + ":" + endCell},
+		ValueCount:   count,
+	}
+}
+
+func dependencyFormulas(dep ChartDependencies) []string {
+	formulas := make([]string, 0, len(dep.Ranges))
+	for _, r := range dep.Ranges {
+		if r.UnionIndex > 0 {
+			continue
+		}
+		formulas = append(formulas, r.Formula)
+	}
+	return formulas
+}
+
+// ChangeManifest returns the manifest stored in the package, including runs
+// appended by earlier SaveFile calls on this Document, or nil when there is
+// none. Changes not yet saved are not included.
+//
+// A manifest that cannot be read is an error in Strict. BestEffort reports
+// CHANGE_MANIFEST_INVALID and treats the package as having no manifest, so
+// the next save starts a new one.
+func (d *Document) ChangeManifest() (*ChangeManifest, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	if err := call.loadChangeManifest(); err != nil {
+		return nil, err
+	}
+	return d.manifest.current(), nil
+}
+
+func (d *docCall) loadChangeManifest() error {
+	if d.manifest.loaded {
+		return nil
+	}
+
+	data, err := d.pkg.ReadPart(changeManifestPart)
+	if errors.Is(err, ooxmlpkg.ErrPartNotFound) {
+		d.manifest.loaded = true
+		return nil
+	}
+	if err == nil {
+		var manifest ChangeManifest
+		err = json.Unmarshal(data, &manifest)
+		if err == nil && manifest.SchemaVersion != changeManifestSchemaVersion {
+			err = fmt.Errorf("unsupported schemaVersion %d", manifest.SchemaVersion)
+		}
+		if err == nil {
+			d.manifest.base = &manifest
+			d.manifest.loaded = true
+			return nil
+		}
+	}
+
+	err = fmt.Errorf("read change manifest %q: %w", changeManifestPart, err)
+	if d.mode() != BestEffort {
+		return err
+	}
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "CHANGE_MANIFEST_INVALID",
+		Message: "Change manifest could not be read; a new manifest is started",
+		Context: map[string]string{
+			"partPath": changeManifestPart,
+			"error":    err.Error(),
+		},
+	})
+	d.manifest.loaded = true
+	return nil
+}
+
+// writeChangeManifest stages the manifest with this run appended, together
+// with the content type override and package relationship that declare it.
+// The run is returned so SaveFile can keep it once the package is written.
+func (d *docCall) writeChangeManifest() (ManifestRun, error) {
+	if err := d.loadChangeManifest(); err != nil {
+		return ManifestRun{}, err
+	}
+	if err := d.ensureOverlay(); err != nil {
+		return ManifestRun{}, err
+	}
+
+	d.mu.Lock()
+	codes := d.manifest.alertCodes(d.alerts)
+	d.mu.Unlock()
+	run := ManifestRun{
+		Library:    "why-pptx",
+		Version:    Version(),
+		Features:   Features(),
+		SavedAt:    time.Now().UTC(),
+		AlertCodes: codes,
+		Changes:    append([]ManifestChange{}, d.manifest.pending...),
+	}
+	manifest := d.manifest.current()
+	if manifest == nil {
+		manifest = &ChangeManifest{SchemaVersion: changeManifestSchemaVersion}
+	}
+	manifest.Runs = append(manifest.Runs, run)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return ManifestRun{}, fmt.Errorf("encode change manifest: %w", err)
+	}
+
+	ctx := postflight.ValidateContext{
+		Mode:            postflight.ModeStrict,
+		AllowedNewParts: []string{changeManifestPart},
+	}
+	if d.mode() == BestEffort {
+		ctx.Mode = postflight.ModeBestEffort
+	}
+	for _, part := range []string{contentTypesPart, packageRelsPart} {
+		exists, err := d.overlay.Has(part)
+		if err != nil {
+			return ManifestRun{}, err
+		}
+		if !exists {
+			ctx.AllowedNewParts = append(ctx.AllowedNewParts, part)
+		}
+	}
+	err = d.runChartStage(ctx, func(stage overlaystage.Overlay) error {
+		if err := declareChangeManifest(stage); err != nil {
+			return err
+		}
+		return stage.Set(changeManifestPart, data)
+	})
+	if err != nil {
+		return ManifestRun{}, err
+	}
+	return run, nil
+}
+
+// declareChangeManifest registers the manifest part in [Content_Types].xml and
+// _rels/.rels. Either file is created when the package lacks it.
+func declareChangeManifest(stage overlaystage.Overlay) error {
+	declarations := []struct {
+		part    string
+		empty   string
+		declare func([]byte) ([]byte, bool, error)
+	}{
+		{contentTypesPart, emptyContentTypes, addManifestContentType},
+		{packageRelsPart, emptyPackageRels, addManifestRelationship},
+	}
+	for _, decl := range declarations {
+		data, err := stage.Get(decl.part)
+		if errors.Is(err, ooxmlpkg.ErrPartNotFound) {
+			data = []byte(decl.empty)
+		} else if err != nil {
+			return fmt.Errorf("read %q: %w", decl.part, err)
+		}
+
+		updated, changed, err := decl.declare(data)
+		if err != nil {
+			return fmt.Errorf("declare change manifest in %q: %w", decl.part, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := stage.Set(decl.part, updated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const emptyContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/></Types>`
+
+const emptyPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"></Relationships>`
+
+func addManifestContentType(data []byte) ([]byte, bool, error) {
+	partName := "/" + changeManifestPart
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Override" {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "PartName" && strings.EqualFold(attr.Value, partName) {
+				return data, false, nil
+			}
+		}
+	}
+
+	override := fmt.Sprintf(`<Override PartName="%s" ContentType="%s"/>`, partName, changeManifestContentType)
+	updated, err := insertBeforeClosing(data, "</Types>", override)
+	return updated, err == nil, err
+}
+
+func addManifestRelationship(data []byte) ([]byte, bool, error) {
+	editor, err := rels.Load(data)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, rel := range editor.Relationships() {
+		if rel.Type == changeManifestRelType && strings.TrimPrefix(rel.Target, "/") == changeManifestPart {
+			return data, false, nil
+		}
+	}
+
+	editor.AddRelationship(changeManifestRelType, changeManifestPart, "")
+	return editor.Serialize(), true, nil
+}
+
+func insertBeforeClosing(data []byte, closing, element string) ([]byte, error) {
+	idx := bytes.LastIndex(data, []byte(closing))
+	if idx < 0 {
+		return nil, fmt.Errorf("closing %s not found", closing)
+	}
+	out := make([]byte, 0, len(data)+len(element))
+	out = append(out, data[:idx]...)
+	out = append(out, element...)
+	out = append(out, data[idx:]...)
+	return out, nil
+}
+
+func alertCodes(alerts []Alert) []string {
+	seen := make(map[string]struct{}, len(alerts))
+	codes := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		if _, ok := seen[alert.Code]; ok {
+			continue
+		}
+		seen[alert.Code] = struct{}{}
+		codes = append(codes, alert.Code)
+	}
+	sort.Strings(codes)
+	return codes
+}
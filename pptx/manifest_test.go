@@ -0,0 +1,364 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"why-pptx/internal/rels"
+)
+
+func TestChangeManifestFreshWrite(t *testing.T) {
+	exercisesFeature(t, "manifest.write")
+
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "output.pptx")
+
+	doc, err := OpenFile(writeManifestDeck(t, dir), WithOptions(manifestOptions()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if manifest, err := doc.ChangeManifest(); err != nil || manifest != nil {
+		t.Fatalf("expected no manifest before the first save, got %#v, %v", manifest, err)
+	}
+	if err := doc.ApplyChartData(0, map[string][]string{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+	}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	manifest := readManifestPart(t, outputPath)
+	if manifest.SchemaVersion != 1 || len(manifest.Runs) != 1 {
+		t.Fatalf("unexpected manifest: %#v", manifest)
+	}
+	run := manifest.Runs[0]
+	if run.Library != "why-pptx" || run.Version != Version() || !reflect.DeepEqual(run.Features, Features()) || run.SavedAt.IsZero() {
+		t.Fatalf("unexpected run header: %#v", run)
+	}
+	if len(run.Changes) != 1 {
+		t.Fatalf("expected one apply change, got %#v", run.Changes)
+	}
+	apply := run.Changes[0]
+	if apply.Operation != "applyChartData" || apply.ChartPath != "ppt/charts/chart1.xml" ||
+		apply.WorkbookPath != "ppt/embeddings/embeddedWorkbook1.xlsx" || apply.ValueCount != 4 || apply.At.IsZero() {
+		t.Fatalf("unexpected apply change: %#v", apply)
+	}
+	if len(apply.Ranges) != 2 || apply.Ranges[0] != "Sheet1!$A$2:$A$3" || apply.Ranges[1] != "Sheet1!$B$2:$B$3" {
+		t.Fatalf("unexpected apply ranges: %#v", apply.Ranges)
+	}
+
+	assertManifestDeclared(t, outputPath)
+}
+
+func TestChangeManifestAppendsAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	firstPath := filepath.Join(dir, "first.pptx")
+	secondPath := filepath.Join(dir, "second.pptx")
+
+	doc, err := OpenFile(writeManifestDeck(t, dir), WithOptions(manifestOptions()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SetWorkbookCells([]CellUpdate{{
+		WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx",
+		Sheet:        "Sheet1",
+		Cell:         "B2",
+		Value:        Num(42),
+	}}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	if err := doc.SaveFile(firstPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	opts := manifestOptions()
+	opts.Mode = BestEffort
+	reopened, err := OpenFile(firstPath, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	stored, err := reopened.ChangeManifest()
+	if err != nil || stored == nil || len(stored.Runs) != 1 {
+		t.Fatalf("expected first run on reopen, got %#v, %v", stored, err)
+	}
+	first := stored.Runs[0].Changes
+	if len(first) != 1 || first[0].Operation != "setWorkbookCells" || len(first[0].Ranges) != 1 || first[0].Ranges[0] != "Sheet1!B2" {
+		t.Fatalf("unexpected first run changes: %#v", first)
+	}
+
+	if err := reopened.SetWorkbookCells([]CellUpdate{{Sheet: "Sheet1", Cell: "B2", Value: Num(1)}}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	if _, err := reopened.SyncChartCaches(); err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if err := reopened.SaveFile(secondPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	manifest := readManifestPart(t, secondPath)
+	if len(manifest.Runs) != 2 {
+		t.Fatalf("expected two runs, got %#v", manifest.Runs)
+	}
+	second := manifest.Runs[1]
+	if len(second.Changes) != 1 || second.Changes[0].Operation != "syncChartCaches" {
+		t.Fatalf("unexpected second run changes: %#v", second.Changes)
+	}
+	if len(second.AlertCodes) != 1 || second.AlertCodes[0] != "WORKBOOK_UPDATE_FAILED" {
+		t.Fatalf("expected the workbook alert to be recorded, got %#v", second.AlertCodes)
+	}
+	if current, err := reopened.ChangeManifest(); err != nil || len(current.Runs) != 2 {
+		t.Fatalf("expected ChangeManifest to include the saved run, got %#v, %v", current, err)
+	}
+
+	assertManifestDeclared(t, secondPath)
+	if count := bytes.Count(readZipEntry(t, secondPath, "[Content_Types].xml"), []byte(changeManifestPart)); count != 1 {
+		t.Fatalf("expected one content type override after two saves, got %d", count)
+	}
+	if count := bytes.Count(readZipEntry(t, secondPath, "_rels/.rels"), []byte(changeManifestRelType)); count != 1 {
+		t.Fatalf("expected one manifest relationship after two saves, got %d", count)
+	}
+}
+
+func TestChangeManifestCreatesPackageRels(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(manifestOptions()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	parsed, err := rels.Parse(bytes.NewReader(readZipEntry(t, outputPath, "_rels/.rels")))
+	if err != nil {
+		t.Fatalf("package rels malformed: %v", err)
+	}
+	rel, ok := parsed.Resolve("rId1")
+	if !ok || rel.Type != changeManifestRelType || rel.Target != changeManifestPart {
+		t.Fatalf("expected created rels to point at the manifest, got %#v", parsed.ByID)
+	}
+	if !bytes.Contains(readZipEntry(t, outputPath, "[Content_Types].xml"), []byte(`PartName="/`+changeManifestPart+`"`)) {
+		t.Fatalf("expected manifest content type override")
+	}
+}
+
+func TestChangeManifestDisabledByDefault(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "output.pptx")
+
+	doc, err := OpenFile(writeManifestDeck(t, dir))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	parts := zipEntryNames(t, outputPath)
+	if _, ok := parts[changeManifestPart]; ok {
+		t.Fatalf("expected no manifest part by default")
+	}
+}
+
+func TestChangeManifestInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := writeManifestDeckWith(t, dir, map[string][]byte{changeManifestPart: []byte("{not json")})
+
+	t.Run("Strict", func(t *testing.T) {
+		doc, err := OpenFile(path, WithOptions(manifestOptions()))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		if _, err := doc.ChangeManifest(); err == nil {
+			t.Fatalf("expected invalid manifest error")
+		}
+		if err := doc.SaveFile(filepath.Join(t.TempDir(), "output.pptx")); err == nil {
+			t.Fatalf("expected strict save to refuse overwriting an unreadable manifest")
+		}
+	})
+
+	t.Run("BestEffort", func(t *testing.T) {
+		opts := manifestOptions()
+		opts.Mode = BestEffort
+		doc, err := OpenFile(path, WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		outputPath := filepath.Join(t.TempDir(), "output.pptx")
+		if err := doc.SaveFile(outputPath); err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		if len(doc.AlertsByCode("CHANGE_MANIFEST_INVALID")) != 1 {
+			t.Fatalf("expected CHANGE_MANIFEST_INVALID alert, got %#v", doc.Alerts())
+		}
+		manifest := readManifestPart(t, outputPath)
+		if len(manifest.Runs) != 1 || len(manifest.Runs[0].AlertCodes) != 1 {
+			t.Fatalf("expected a fresh manifest recording the alert, got %#v", manifest)
+		}
+	})
+}
+
+func manifestOptions() Options {
+	opts := DefaultOptions()
+	opts.Save.WriteChangeManifest = true
+	return opts
+}
+
+func writeManifestDeck(t *testing.T, dir string) string {
+	t.Helper()
+	return writeManifestDeckWith(t, dir, nil)
+}
+
+// writeManifestDeckWith builds a one-chart deck with content types and package
+// relationships, plus any extra parts.
+func writeManifestDeckWith(t *testing.T, dir string, extra map[string][]byte) string {
+	t.Helper()
+
+	parts := map[string][]byte{
+		"[Content_Types].xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Override PartName="/ppt/presentation.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/>
+</Types>`),
+		"_rels/.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="ppt/presentation.xml"/>
+</Relationships>`),
+		"ppt/presentation.xml":  []byte("<presentation/>"),
+		"ppt/slides/slide1.xml": []byte("<slide/>"),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": chartWithCaches("Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3", []string{"Old1", "Old2"}, []string{"10", "20"}),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildWorkbookWithValues(t, "Old1", "Old2", 10, 20),
+	}
+	for name, data := range extra {
+		parts[name] = data
+	}
+
+	path := filepath.Join(dir, "manifest.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func readManifestPart(t *testing.T, path string) ChangeManifest {
+	t.Helper()
+
+	var manifest ChangeManifest
+	if err := json.Unmarshal(readZipEntry(t, path, changeManifestPart), &manifest); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	return manifest
+}
+
+// assertManifestDeclared checks the static compatibility of the manifest part:
+// both package files stay well-formed, the part has a content type override,
+// and a package relationship resolves to it.
+func assertManifestDeclared(t *testing.T, path string) {
+	t.Helper()
+
+	contentTypes := readZipEntry(t, path, "[Content_Types].xml")
+	var types struct {
+		Overrides []struct {
+			PartName    string `xml:"PartName,attr"`
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}
+	if err := xml.Unmarshal(contentTypes, &types); err != nil {
+		t.Fatalf("content types malformed: %v", err)
+	}
+	declared := false
+	for _, override := range types.Overrides {
+		if override.PartName == "/"+changeManifestPart && override.ContentType == "application/json" {
+			declared = true
+		}
+	}
+	if !declared || len(types.Overrides) != 2 {
+		t.Fatalf("expected manifest override next to the existing one, got %#v", types.Overrides)
+	}
+
+	parsed, err := rels.Parse(bytes.NewReader(readZipEntry(t, path, "_rels/.rels")))
+	if err != nil {
+		t.Fatalf("package rels malformed: %v", err)
+	}
+	if office, ok := parsed.Resolve("rId1"); !ok || office.Target != "ppt/presentation.xml" {
+		t.Fatalf("expected officeDocument relationship to be kept, got %#v", parsed.ByID)
+	}
+	parts := zipEntryNames(t, path)
+	found := false
+	for _, rel := range parsed.ByID {
+		if rel.Type != changeManifestRelType {
+			continue
+		}
+		if _, ok := parts[rels.ResolveTarget("", rel.Target)]; !ok {
+			t.Fatalf("manifest relationship target %q missing", rel.Target)
+		}
+		found = true
+	}
+	if !found {
+		t.Fatalf("expected manifest relationship, got %#v", parsed.ByID)
+	}
+}
+
+func zipEntryNames(t *testing.T, path string) map[string]struct{} {
+	t.Helper()
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	defer reader.Close()
+
+	names := make(map[string]struct{}, len(reader.File))
+	for _, file := range reader.File {
+		names[file.Name] = struct{}{}
+	}
+	return names
+}
+
+func TestChangeManifestKeepsClearedAlertCodes(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "output.pptx")
+
+	opts := manifestOptions()
+	opts.Mode = BestEffort
+	var handled []string
+	doc, err := OpenFile(writeManifestDeck(t, dir), WithOptions(opts), WithAlertHandler(func(alert Alert) {
+		handled = append(handled, alert.Code)
+	}))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.SetWorkbookCells([]CellUpdate{{Sheet: "Sheet1", Cell: "B2", Value: Num(1)}}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	if !reflect.DeepEqual(handled, []string{"WORKBOOK_UPDATE_FAILED"}) {
+		t.Fatalf("expected the handler to see the alert, got %v", handled)
+	}
+	doc.ClearAlerts()
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	run := readManifestPart(t, outputPath).Runs[0]
+	if !reflect.DeepEqual(run.AlertCodes, []string{"WORKBOOK_UPDATE_FAILED"}) {
+		t.Fatalf("expected the cleared alert in the run, got %#v", run.AlertCodes)
+	}
+}
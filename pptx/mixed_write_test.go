@@ -11,6 +11,8 @@ import (
 )
 
 func TestMixedApplyChartDataStrict(t *testing.T) {
+	exercisesFeature(t, "apply.mixed", "cachesync.mixed")
+
 	input := fixturePath("mix_write_bar_line_valid.pptx")
 	output := filepath.Join(t.TempDir(), "output.pptx")
 
@@ -371,7 +373,7 @@ func TestMixedPostflightInvalidCacheStrict(t *testing.T) {
 		Mode:             postflight.ModeStrict,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, badChart)
 	})
 	if err == nil {
@@ -404,7 +406,7 @@ func TestMixedPostflightSecondaryAxisInvalidCacheStrict(t *testing.T) {
 		Mode:             postflight.ModeStrict,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, badChart)
 	})
 	if err == nil {
@@ -437,7 +439,7 @@ func TestMixedPostflightSecondaryAxisInvalidGroupStrict(t *testing.T) {
 		Mode:             postflight.ModeStrict,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, badChart)
 	})
 	if err == nil {
@@ -0,0 +1,40 @@
+package pptx
+
+import "why-pptx/internal/chartxml"
+
+// chartHasMultiLevelCategories reports whether a series of the chart takes
+// its categories from a c:multiLvlStrRef. A chart that does not parse has
+// none.
+func (d *docCall) chartHasMultiLevelCategories(chartPath string, chartXML []byte) bool {
+	parsed, err := d.parsedChart(chartPath, chartXML)
+	if err != nil {
+		return false
+	}
+	return hasMultiLevelCategories(parsed)
+}
+
+func hasMultiLevelCategories(parsed *chartxml.ParsedChart) bool {
+	for _, formula := range parsed.Formulas {
+		if formula.Kind == chartxml.KindCategories && formula.MultiLevel {
+			return true
+		}
+	}
+	return false
+}
+
+// reportMultiLevelCachePreserved records CHART_CACHE_MULTILEVEL_PRESERVED
+// for a chart whose cache sync kept its multiLvlStrCache: a flat strCache in
+// its place would break the grouped axis, so the levels PowerPoint shows stay
+// as they were cached.
+func (d *docCall) reportMultiLevelCachePreserved(dep ChartDependencies) {
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "CHART_CACHE_MULTILEVEL_PRESERVED",
+		Message: "Multi-level category cache is kept as it is; cache sync does not rebuild it",
+		Context: map[string]string{
+			"slide":    dep.SlidePath,
+			"chart":    dep.ChartPath,
+			"workbook": dep.WorkbookPath,
+		},
+	})
+}
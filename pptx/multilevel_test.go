@@ -0,0 +1,107 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractMultiLevelCategories(t *testing.T) {
+	exercisesFeature(t, "ranges.multilevel")
+
+	doc, err := OpenFile(fixturePath("bar_multilevel_categories.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	wantLabels := []string{"H1", "H2", "H1", "H2"}
+	if !reflect.DeepEqual(data.Labels, wantLabels) {
+		t.Fatalf("expected the innermost level as labels, got %#v", data.Labels)
+	}
+	if want := [][]string{{"2024", "", "2025", ""}}; !reflect.DeepEqual(data.LabelGroups, want) {
+		t.Fatalf("unexpected label groups: %#v", data.LabelGroups)
+	}
+	if len(data.Series) != 1 || !reflect.DeepEqual(data.Series[0].Data, []string{"10", "20", "30", "40"}) {
+		t.Fatalf("unexpected series: %#v", data.Series)
+	}
+
+	var streamed []string
+	err = doc.ExtractChartDataStream("ppt/charts/chart1.xml", func(series int, kind ChartRangeKind, index int, value string) error {
+		if kind == RangeCategories {
+			streamed = append(streamed, value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExtractChartDataStream: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, wantLabels) {
+		t.Fatalf("streamed labels %#v differ", streamed)
+	}
+
+	flat, err := OpenFile(fixturePath("bar_categories_2d.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	flatData, err := flat.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if flatData.LabelGroups != nil {
+		t.Fatalf("expected no label groups for a strRef, got %#v", flatData.LabelGroups)
+	}
+}
+
+func TestSyncChartCachesKeepsMultiLevelCache(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_multilevel_categories.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || !results[0].Changed {
+		t.Fatalf("expected the values cache to sync, got %#v", results)
+	}
+	var preserved []Alert
+	for _, alert := range doc.Alerts() {
+		if alert.Code == "CHART_CACHE_MULTILEVEL_PRESERVED" {
+			preserved = append(preserved, alert)
+		}
+	}
+	if len(preserved) != 1 || preserved[0].Context["chart"] != "ppt/charts/chart1.xml" {
+		t.Fatalf("expected one CHART_CACHE_MULTILEVEL_PRESERVED alert, got %#v", doc.Alerts())
+	}
+
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	chartXML := readZipEntry(t, output, "ppt/charts/chart1.xml")
+	cats, nums := extractChartCacheValues(t, chartXML)
+	if len(cats) != 0 || !reflect.DeepEqual(nums, []string{"10", "20", "30", "40"}) {
+		t.Fatalf("expected only the values cache to change, got %v %v", cats, nums)
+	}
+	for _, want := range []string{"multiLvlStrCache", ">2024<", ">2025<"} {
+		if !strings.Contains(string(chartXML), want) {
+			t.Fatalf("expected the multi-level cache to be kept, missing %q", want)
+		}
+	}
+
+	reopened, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := reopened.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(data.LabelGroups, [][]string{{"2024", "", "2025", ""}}) {
+		t.Fatalf("unexpected label groups after the round trip: %#v", data.LabelGroups)
+	}
+}
@@ -0,0 +1,126 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// InvalidNumberError is returned in Strict mode for a ChartDataInput value
+// of a values range that does not read as a number. Position indexes the
+// value within its series.
+type InvalidNumberError struct {
+	SeriesIndex int
+	Position    int
+	Value       string
+}
+
+func (e *InvalidNumberError) Error() string {
+	return fmt.Sprintf("invalid numeric value %q for series %d at position %d", e.Value, e.SeriesIndex, e.Position)
+}
+
+// readInputNumber reads one ChartDataInput value of a values range under
+// opts. With CoerceNumbers, a value NumberFormat rejects is retried with its
+// spaces removed and a trailing '%' read as hundredths; coerced reports
+// that, and missing a value that names no number at all.
+func readInputNumber(value string, opts InputOptions) (number float64, missing, coerced bool, err error) {
+	number, err = parseNumber(value, opts.NumberFormat)
+	if err == nil || !opts.CoerceNumbers {
+		return number, false, false, err
+	}
+
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" || trimmed == "-" || strings.EqualFold(trimmed, "N/A") {
+		return 0, true, true, nil
+	}
+	compact := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, trimmed)
+	percent := strings.HasSuffix(compact, "%")
+	if percent {
+		compact = strings.TrimSuffix(compact, "%")
+	}
+	number, coerceErr := parseNumber(compact, opts.NumberFormat)
+	if coerceErr != nil {
+		return 0, false, false, err
+	}
+	if percent {
+		number /= 100
+	}
+	return number, false, true, nil
+}
+
+// numberCoercions lists, per series, the positions whose values
+// CoerceNumbers coerced.
+type numberCoercions map[int][]int
+
+func (c numberCoercions) add(seriesIndex, position int) {
+	c[seriesIndex] = append(c[seriesIndex], position)
+}
+
+// alerts returns one CHART_DATA_NUMBERS_COERCED alert per series, in series
+// order.
+func (c numberCoercions) alerts(chartIndex int) []Alert {
+	series := make([]int, 0, len(c))
+	for seriesIndex := range c {
+		series = append(series, seriesIndex)
+	}
+	sort.Ints(series)
+
+	alerts := make([]Alert, 0, len(series))
+	for _, seriesIndex := range series {
+		positions := make([]string, len(c[seriesIndex]))
+		for i, position := range c[seriesIndex] {
+			positions[i] = strconv.Itoa(position)
+		}
+		alerts = append(alerts, Alert{
+			Level:   "warn",
+			Code:    "CHART_DATA_NUMBERS_COERCED",
+			Message: "Coerced values that were not plain numbers",
+			Context: map[string]string{
+				"chartIndex":  strconv.Itoa(chartIndex),
+				"seriesIndex": strconv.Itoa(seriesIndex),
+				"positions":   strings.Join(positions, ","),
+			},
+		})
+	}
+	return alerts
+}
+
+func (d *docCall) reportNumberCoercions(chartIndex int, coerced numberCoercions) {
+	for _, alert := range coerced.alerts(chartIndex) {
+		d.addAlert(alert)
+	}
+}
+
+func invalidNumberAlert(chartIndex int, invalid *InvalidNumberError) Alert {
+	return Alert{
+		Level:   "warn",
+		Code:    "CHART_DATA_INVALID_NUMBER",
+		Message: "A value is not a number; chart skipped",
+		Context: map[string]string{
+			"chartIndex":  strconv.Itoa(chartIndex),
+			"seriesIndex": strconv.Itoa(invalid.SeriesIndex),
+			"position":    strconv.Itoa(invalid.Position),
+			"value":       invalid.Value,
+		},
+	}
+}
+
+// handleInvalidNumber skips the chart with a CHART_DATA_INVALID_NUMBER alert
+// in BestEffort mode when err is an InvalidNumberError, and returns err
+// otherwise.
+func (d *docCall) handleInvalidNumber(chartIndex int, err error) error {
+	var invalid *InvalidNumberError
+	if !errors.As(err, &invalid) || d.mode() != BestEffort {
+		return err
+	}
+	d.addAlert(invalidNumberAlert(chartIndex, invalid))
+	return nil
+}
@@ -0,0 +1,149 @@
+package pptx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadInputNumberCoerce(t *testing.T) {
+	cases := []struct {
+		value   string
+		format  NumberFormat
+		want    float64
+		missing bool
+		ok      bool
+	}{
+		{"12", NumberFormatCanonical, 12, false, true},
+		{"1 000", NumberFormatCanonical, 1000, false, true},
+		{"1 000.5", NumberFormatCanonical, 1000.5, false, true},
+		{"12%", NumberFormatCanonical, 0.12, false, true},
+		{" 7.5 % ", NumberFormatCanonical, 0.075, false, true},
+		{"12,5%", NumberFormatDecimalComma, 0.125, false, true},
+		{"N/A", NumberFormatCanonical, 0, true, true},
+		{"n/a", NumberFormatCanonical, 0, true, true},
+		{"-", NumberFormatCanonical, 0, true, true},
+		{"", NumberFormatCanonical, 0, true, true},
+		{"abc", NumberFormatCanonical, 0, false, false},
+		{"%", NumberFormatCanonical, 0, false, false},
+		{"12%%", NumberFormatCanonical, 0, false, false},
+	}
+	for _, tc := range cases {
+		got, missing, _, err := readInputNumber(tc.value, InputOptions{NumberFormat: tc.format, CoerceNumbers: true})
+		if (err == nil) != tc.ok {
+			t.Fatalf("%q: expected ok=%v, got err %v", tc.value, tc.ok, err)
+		}
+		if tc.ok && (got != tc.want || missing != tc.missing) {
+			t.Fatalf("%q: expected %v (missing %v), got %v (missing %v)", tc.value, tc.want, tc.missing, got, missing)
+		}
+	}
+
+	if _, _, _, err := readInputNumber("12%", InputOptions{}); err == nil {
+		t.Fatalf("expected %q to be rejected without CoerceNumbers", "12%")
+	}
+}
+
+func TestApplyChartDataCoerceNumbers(t *testing.T) {
+	exercisesFeature(t, "input.coerce-numbers")
+
+	const workbook = "ppt/embeddings/embeddedWorkbook1.xlsx"
+	data := ChartDataInput{
+		"categories": {"A", "B"},
+		"values:0":   {"12%", "N/A"},
+	}
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data)
+	var invalid *InvalidNumberError
+	if !errors.As(err, &invalid) || invalid.SeriesIndex != 0 || invalid.Position != 0 || invalid.Value != "12%" {
+		t.Fatalf("expected an InvalidNumberError for position 0, got %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Input.CoerceNumbers = true
+	doc, err = OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	plan, err := doc.PlanChanges(PlanRequest{Data: data})
+	if err != nil {
+		t.Fatalf("PlanChanges: %v", err)
+	}
+	if len(plan.Charts) != 1 || plan.Charts[0].Action != "apply" {
+		t.Fatalf("expected the chart to plan as apply, got %#v", plan.Charts)
+	}
+	var newValues []string
+	for _, change := range plan.Charts[0].Changes {
+		if change.Role == PlanRoleValues {
+			newValues = append(newValues, change.NewValue)
+		}
+	}
+	if !reflect.DeepEqual(newValues, []string{"0.12", ""}) {
+		t.Fatalf("expected coerced planned values, got %#v", plan.Charts[0].Changes)
+	}
+	if len(plan.Alerts) != 1 || plan.Alerts[0].Code != "CHART_DATA_NUMBERS_COERCED" || plan.Alerts[0].Context["positions"] != "0,1" {
+		t.Fatalf("expected one coercion alert in the plan, got %#v", plan.Alerts)
+	}
+
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	cells, err := doc.GetWorkbookCells(workbook, "Sheet1", []string{"B2", "B3"})
+	if err != nil {
+		t.Fatalf("GetWorkbookCells: %v", err)
+	}
+	if !reflect.DeepEqual(cells, map[string]string{"B2": "0.12", "B3": ""}) {
+		t.Fatalf("expected a coerced and a blank cell, got %#v", cells)
+	}
+	var coerced []Alert
+	for _, alert := range doc.Alerts() {
+		if alert.Code == "CHART_DATA_NUMBERS_COERCED" {
+			coerced = append(coerced, alert)
+		}
+	}
+	want := map[string]string{"chartIndex": "0", "seriesIndex": "0", "positions": "0,1"}
+	if len(coerced) != 1 || !reflect.DeepEqual(coerced[0].Context, want) {
+		t.Fatalf("expected one summarized coercion alert, got %#v", coerced)
+	}
+}
+
+func TestApplyChartDataInvalidNumberBestEffort(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Mode = BestEffort
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data := ChartDataInput{
+		"categories": {"A", "B"},
+		"values:0":   {"1", "1 000"},
+	}
+
+	plan, err := doc.PlanChanges(PlanRequest{Data: data})
+	if err != nil {
+		t.Fatalf("PlanChanges: %v", err)
+	}
+	if len(plan.Charts) != 1 || plan.Charts[0].Action != "skip" || plan.Charts[0].ReasonCode != "CHART_DATA_INVALID_NUMBER" {
+		t.Fatalf("expected the chart to plan as skipped, got %#v", plan.Charts)
+	}
+
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	want := map[string]string{"chartIndex": "0", "seriesIndex": "0", "position": "1", "value": "1 000"}
+	found := false
+	for _, alert := range doc.Alerts() {
+		if alert.Code == "CHART_DATA_INVALID_NUMBER" {
+			found = reflect.DeepEqual(alert.Context, want)
+		}
+	}
+	if !found {
+		t.Fatalf("expected CHART_DATA_INVALID_NUMBER with %v, got %#v", want, doc.Alerts())
+	}
+	if len(doc.TouchedParts()) != 0 {
+		t.Fatalf("expected the chart to be skipped, got %v", doc.TouchedParts())
+	}
+}
@@ -0,0 +1,114 @@
+package pptx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat is how ChartDataInput values are read as numbers. Workbook
+// cells and chart caches always store them canonically, with a '.' decimal
+// point and no grouping.
+type NumberFormat int
+
+const (
+	// NumberFormatCanonical accepts what strconv.ParseFloat does: "1234.56",
+	// "1.5e3".
+	NumberFormatCanonical NumberFormat = iota
+	// NumberFormatDecimalPoint reads "1,234.56": '.' is the decimal point,
+	// and ',', a space, or a (narrow) no-break space groups thousands.
+	NumberFormatDecimalPoint
+	// NumberFormatDecimalComma reads "1.234,56", the European format: ','
+	// is the decimal point, and '.', a space, or a (narrow) no-break space
+	// groups thousands.
+	NumberFormatDecimalComma
+)
+
+// parseNumber reads value under format. Grouped digits must come in threes,
+// so "1,234" is a thousand and more in NumberFormatDecimalPoint and a bit
+// over one in NumberFormatDecimalComma, while "1,23,4" is rejected in both.
+// An exponent ("1,5e3") is allowed after the decimal part.
+func parseNumber(value string, format NumberFormat) (float64, error) {
+	value = strings.TrimSpace(value)
+	var decimal, group byte
+	switch format {
+	case NumberFormatDecimalPoint:
+		decimal, group = '.', ','
+	case NumberFormatDecimalComma:
+		decimal, group = ',', '.'
+	default:
+		return strconv.ParseFloat(value, 64)
+	}
+	invalid := fmt.Errorf("parse number %q: invalid syntax", value)
+
+	mantissa, exponent := value, ""
+	if i := strings.IndexAny(value, "eE"); i >= 0 {
+		mantissa, exponent = value[:i], value[i:]
+	}
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '+' || mantissa[0] == '-') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(mantissa, string(decimal))
+	if integer == "" && fraction == "" {
+		return 0, invalid
+	}
+	integer, ok := ungroupDigits(integer, group)
+	if !ok || !allDigits(fraction) {
+		return 0, invalid
+	}
+
+	canonical := sign + integer
+	if hasFraction {
+		canonical += "." + fraction
+	}
+	if exponent != "" {
+		digits := exponent[1:]
+		if digits != "" && (digits[0] == '+' || digits[0] == '-') {
+			digits = digits[1:]
+		}
+		if digits == "" || !allDigits(digits) {
+			return 0, invalid
+		}
+		canonical += exponent
+	}
+	number, err := strconv.ParseFloat(canonical, 64)
+	if err != nil {
+		return 0, invalid
+	}
+	return number, nil
+}
+
+// ungroupDigits strips the thousands separators of the integer part of a
+// number. The groups after the first must all have three digits, and one
+// number uses one separator.
+func ungroupDigits(integer string, group byte) (string, bool) {
+	separator := ""
+	for _, candidate := range []string{string(group), " ", "\u00a0", "\u202f"} {
+		if strings.Contains(integer, candidate) {
+			if separator != "" {
+				return "", false
+			}
+			separator = candidate
+		}
+	}
+	if separator == "" {
+		return integer, allDigits(integer)
+	}
+	groups := strings.Split(integer, separator)
+	for i, digits := range groups {
+		if !allDigits(digits) || digits == "" || (i > 0 && len(digits) != 3) || (i == 0 && len(digits) > 3) {
+			return "", false
+		}
+	}
+	return strings.Join(groups, ""), true
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package pptx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	cases := []struct {
+		value  string
+		format NumberFormat
+		want   float64
+		ok     bool
+	}{
+		{"1234.56", NumberFormatCanonical, 1234.56, true},
+		{"1.5e3", NumberFormatCanonical, 1500, true},
+		{"1,234.56", NumberFormatCanonical, 0, false},
+
+		{"1,234.56", NumberFormatDecimalPoint, 1234.56, true},
+		{"-1,234,567", NumberFormatDecimalPoint, -1234567, true},
+		{"1 234.5", NumberFormatDecimalPoint, 1234.5, true},
+		{"1,234", NumberFormatDecimalPoint, 1234, true},
+		{".5", NumberFormatDecimalPoint, 0.5, true},
+		{"1.5E-3", NumberFormatDecimalPoint, 0.0015, true},
+		{"1,234.5e2", NumberFormatDecimalPoint, 123450, true},
+		{"1,23,4", NumberFormatDecimalPoint, 0, false},
+		{"1234,567.8", NumberFormatDecimalPoint, 0, false},
+		{"1,234 567", NumberFormatDecimalPoint, 0, false},
+		{"1.234,56", NumberFormatDecimalPoint, 0, false},
+
+		{"1.234,56", NumberFormatDecimalComma, 1234.56, true},
+		{"1 234,56", NumberFormatDecimalComma, 1234.56, true},
+		{"1 234,56", NumberFormatDecimalComma, 1234.56, true},
+		{"1,234", NumberFormatDecimalComma, 1.234, true},
+		{"1.234", NumberFormatDecimalComma, 1234, true},
+		{"+0,5", NumberFormatDecimalComma, 0.5, true},
+		{"1,5e3", NumberFormatDecimalComma, 1500, true},
+		{"-2,5E+2", NumberFormatDecimalComma, -250, true},
+		{"1.5", NumberFormatDecimalComma, 0, false},
+		{"1,5e", NumberFormatDecimalComma, 0, false},
+		{"1,2,3", NumberFormatDecimalComma, 0, false},
+		{"", NumberFormatDecimalComma, 0, false},
+		{"abc", NumberFormatDecimalComma, 0, false},
+	}
+	for _, tc := range cases {
+		got, err := parseNumber(tc.value, tc.format)
+		if (err == nil) != tc.ok || (tc.ok && got != tc.want) {
+			t.Errorf("parseNumber(%q, %d) = %v, %v; want %v, ok %v", tc.value, tc.format, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestApplyChartDataLocalizedNumbers(t *testing.T) {
+	exercisesFeature(t, "input.number-format")
+
+	const workbook = "ppt/embeddings/embeddedWorkbook1.xlsx"
+	data := ChartDataInput{
+		"categories": {"A", "B"},
+		"values:0":   {"1.234,56", "1,5e3"},
+	}
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data); err == nil {
+		t.Fatalf("expected the canonical format to reject %q", data["values:0"][0])
+	}
+
+	opts := DefaultOptions()
+	opts.Input.NumberFormat = NumberFormatDecimalComma
+	doc, err = OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	plan, err := doc.PlanChanges(PlanRequest{Data: data})
+	if err != nil {
+		t.Fatalf("PlanChanges: %v", err)
+	}
+	if len(plan.Charts) != 1 || plan.Charts[0].Action != "apply" {
+		t.Fatalf("expected the chart to plan as apply, got %#v", plan.Charts)
+	}
+	var newValues []string
+	for _, change := range plan.Charts[0].Changes {
+		if change.Role == PlanRoleValues {
+			newValues = append(newValues, change.NewValue)
+		}
+	}
+	if !reflect.DeepEqual(newValues, []string{"1234.56", "1500"}) {
+		t.Fatalf("expected canonical planned values, got %#v", plan.Charts[0].Changes)
+	}
+
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	cells, err := doc.GetWorkbookCells(workbook, "Sheet1", []string{"B2", "B3"})
+	if err != nil {
+		t.Fatalf("GetWorkbookCells: %v", err)
+	}
+	if !reflect.DeepEqual(cells, map[string]string{"B2": "1234.56", "B3": "1500"}) {
+		t.Fatalf("expected canonical cells, got %#v", cells)
+	}
+	chartXML, err := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ReadPart: %v", err)
+	}
+	if !bytes.Contains(chartXML, []byte(">1234.56</")) || !bytes.Contains(chartXML, []byte(">1500</")) {
+		t.Fatalf("expected canonical cache values, got %s", chartXML)
+	}
+}
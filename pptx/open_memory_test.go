@@ -0,0 +1,157 @@
+package pptx
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+func TestOpenAndSaveInMemoryMatchesFiles(t *testing.T) {
+	input := fixturePath("bar_simple_embedded.pptx")
+	data, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	chartData := map[string][]string{"categories": {"A", "B"}, "values:0": {"1", "2"}}
+
+	fromFile, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := fromFile.ApplyChartData(0, chartData); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := fromFile.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	want, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	opens := map[string]func() (*Document, error){
+		"Open":       func() (*Document, error) { return Open(data) },
+		"OpenReader": func() (*Document, error) { return OpenReader(bytes.NewReader(data), int64(len(data))) },
+	}
+	for name, open := range opens {
+		t.Run(name, func(t *testing.T) {
+			doc, err := open()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if err := doc.ApplyChartData(0, chartData); err != nil {
+				t.Fatalf("ApplyChartData: %v", err)
+			}
+			got, err := doc.Save()
+			if err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("Save output differs from SaveFile output")
+			}
+			var buf bytes.Buffer
+			if err := doc.SaveTo(&buf); err != nil {
+				t.Fatalf("SaveTo: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Fatalf("SaveTo output differs from SaveFile output")
+			}
+			if len(doc.Alerts()) != 0 {
+				t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+			}
+		})
+	}
+}
+
+func TestSaveReportsOutputSizeWithoutPath(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Save.MaxOutputBytes = 1
+	data, err := os.ReadFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	doc, err := Open(data, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	out, err := doc.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	alerts := doc.AlertsByCode("SAVE_OUTPUT_SIZE_EXCEEDED")
+	if len(alerts) != 1 {
+		t.Fatalf("expected SAVE_OUTPUT_SIZE_EXCEEDED, got %#v", doc.Alerts())
+	}
+	if _, ok := alerts[0].Context["path"]; ok || alerts[0].Context["outputBytes"] != strconv.Itoa(len(out)) {
+		t.Fatalf("unexpected context: %#v", alerts[0].Context)
+	}
+}
+
+func TestOpenRejectsInvalidData(t *testing.T) {
+	if _, err := Open([]byte("not a pptx")); err == nil {
+		t.Fatalf("expected Open to fail")
+	}
+}
+
+// failAfterWriter accepts limit bytes and then fails every write.
+type failAfterWriter struct {
+	limit int
+}
+
+func (w *failAfterWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errors.New("connection reset")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestSaveToRetriesAfterFailedWrite(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Save.WriteChangeManifest = true
+	input := fixturePath("bar_simple_embedded.pptx")
+	doc, err := OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, map[string][]string{"categories": {"A", "B"}, "values:0": {"1", "2"}}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+
+	if err := doc.SaveTo(&failAfterWriter{limit: 512}); err == nil {
+		t.Fatalf("expected SaveTo to fail")
+	}
+	var buf bytes.Buffer
+	if err := doc.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo after a failed write: %v", err)
+	}
+	// The failed save recorded no run; the retry recorded one.
+	manifest, err := doc.ChangeManifest()
+	if err != nil {
+		t.Fatalf("ChangeManifest: %v", err)
+	}
+	if manifest == nil || len(manifest.Runs) != 1 {
+		t.Fatalf("expected one manifest run, got %#v", manifest)
+	}
+
+	streamed := filepath.Join(t.TempDir(), "streamed.pptx")
+	if err := os.WriteFile(streamed, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	saved := filepath.Join(t.TempDir(), "saved.pptx")
+	if err := doc.SaveFile(saved); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	pptxassert.AssertSameEntrySet(t, saved, streamed)
+	if _, err := Open(buf.Bytes()); err != nil {
+		t.Fatalf("the streamed deck must reopen: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package pptx
+
+import (
+	"strconv"
+
+	"why-pptx/internal/chartdiscover"
+	"why-pptx/internal/ooxmlpkg"
+)
+
+// Defaults of Options.Limits, generous enough for any deck PowerPoint
+// writes. Hostile inputs call for much smaller values.
+const (
+	DefaultMaxPartSize          = 256 << 20
+	DefaultMaxTotalUncompressed = 2 << 30
+	DefaultMaxChartCount        = 10000
+)
+
+// PartTooLargeError is the error of a read refused by Options.Limits.
+// errors.Is matches it against ErrPartTooLarge.
+type PartTooLargeError = ooxmlpkg.PartTooLargeError
+
+func (d *Document) packageLimits() ooxmlpkg.Limits {
+	return ooxmlpkg.Limits{
+		MaxPartSize:  d.opts.Limits.MaxPartSize,
+		MaxTotalSize: d.opts.Limits.MaxTotalUncompressed,
+	}
+}
+
+// workbookBudget applies Options.Limits to the parts of one opening of the
+// embedded workbook at workbookPath, so its own zip is bounded as the
+// package is.
+func (d *docCall) workbookBudget(workbookPath string) *ooxmlpkg.Budget {
+	limits := d.packageLimits()
+	if limits.MaxPartSize <= 0 && limits.MaxTotalSize <= 0 {
+		return nil
+	}
+	return ooxmlpkg.NewBudget(limits, func(err *ooxmlpkg.PartTooLargeError) {
+		d.reportPartTooLarge(workbookPath, err)
+	})
+}
+
+// reportPartTooLarge records a PACKAGE_PART_TOO_LARGE alert in BestEffort
+// mode, once per part. workbook is the embedded workbook holding the part,
+// or "" for a part of the presentation package. The read fails either way.
+func (d *docCall) reportPartTooLarge(workbook string, err *ooxmlpkg.PartTooLargeError) {
+	if d.mode() != BestEffort {
+		return
+	}
+	key := workbook + "!" + err.Part
+	d.mu.Lock()
+	if d.partsTooLarge == nil {
+		d.partsTooLarge = make(map[string]bool)
+	}
+	seen := d.partsTooLarge[key]
+	d.partsTooLarge[key] = true
+	d.mu.Unlock()
+	if seen {
+		return
+	}
+
+	limit := "maxPartSize"
+	if err.Total {
+		limit = "maxTotalUncompressed"
+	}
+	ctx := map[string]string{
+		"part":  err.Part,
+		"size":  strconv.FormatInt(err.Size, 10),
+		"limit": limit,
+		"max":   strconv.FormatInt(err.Limit, 10),
+	}
+	if workbook != "" {
+		ctx["workbook"] = workbook
+	}
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "PACKAGE_PART_TOO_LARGE",
+		Message: "Part exceeds Options.Limits and was not read",
+		Context: ctx,
+	})
+}
+
+// discoverEmbeddedCharts runs chart discovery in the configured order. It
+// stops after Options.Limits.MaxChartCount charts with a
+// CHART_COUNT_LIMIT_REACHED warning, in both modes.
+func (d *Document) discoverEmbeddedCharts() ([]chartdiscover.EmbeddedChart, []chartdiscover.SkippedChart, error) {
+	embedded, skipped, truncated, err := chartdiscover.DiscoverEmbeddedChartsLimit(d.pkg, d.discoveryOrder(), d.opts.Limits.MaxChartCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if truncated {
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "CHART_COUNT_LIMIT_REACHED",
+			Message: "Chart discovery stopped at Options.Limits.MaxChartCount; later charts are ignored",
+			Context: map[string]string{
+				"maxChartCount": strconv.Itoa(d.opts.Limits.MaxChartCount),
+			},
+		})
+	}
+	return embedded, skipped, nil
+}
+
+// discoverChartRefs is discoverEmbeddedCharts for every chart reference,
+// embedded or not. It leaves the warning to discoverEmbeddedCharts.
+func (d *Document) discoverChartRefs() ([]chartdiscover.ChartRef, error) {
+	refs, _, err := chartdiscover.DiscoverChartRefsLimit(d.pkg, d.discoveryOrder(), d.opts.Limits.MaxChartCount)
+	return refs, err
+}
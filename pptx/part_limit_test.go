@@ -0,0 +1,168 @@
+package pptx
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMaxPartSizeRejectsLargeWorkbook(t *testing.T) {
+	opts := DefaultOptions()
+	// chart1.xml is 588 bytes, embeddedWorkbook1.xlsx 1289.
+	opts.Limits.MaxPartSize = 1024
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) != 1 || charts[0].ChartType != "bar" {
+		t.Fatalf("expected chart metadata to stay readable, got %#v, %v", charts, err)
+	}
+	if _, err := doc.ExtractChartData(0); !errors.Is(err, ErrPartTooLarge) {
+		t.Fatalf("expected ErrPartTooLarge, got %v", err)
+	}
+}
+
+func TestLimitsDefaults(t *testing.T) {
+	limits := DefaultOptions().Limits
+	if limits.MaxPartSize != DefaultMaxPartSize || limits.MaxTotalUncompressed != DefaultMaxTotalUncompressed || limits.MaxChartCount != DefaultMaxChartCount {
+		t.Fatalf("unexpected default limits: %+v", limits)
+	}
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.ExtractChartData(0); err != nil {
+		t.Fatalf("ExtractChartData: %v", err)
+	}
+}
+
+// writeWorkbookBomb writes bar_simple_embedded.pptx with a worksheet padded
+// to a megabyte of whitespace, which deflates to a few kilobytes.
+func writeWorkbookBomb(t *testing.T) string {
+	t.Helper()
+	fixture := fixturePath("bar_simple_embedded.pptx")
+	const workbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+	workbook := corpusZipEntries(t, readEmbeddedWorkbook(t, fixture, workbookPath))
+	sheet := workbook["xl/worksheets/sheet1.xml"]
+	end := bytes.LastIndex(sheet, []byte("</worksheet>"))
+	padded := append([]byte(nil), sheet[:end]...)
+	padded = append(padded, bytes.Repeat([]byte(" "), 1<<20)...)
+	workbook["xl/worksheets/sheet1.xml"] = append(padded, sheet[end:]...)
+
+	entries := corpusZipEntries(t, readCorpusFile(t, fixture))
+	entries[workbookPath] = writeZipBytes(t, workbook)
+	if len(entries[workbookPath]) > 16<<10 {
+		t.Fatalf("expected the workbook to deflate, got %d bytes", len(entries[workbookPath]))
+	}
+	path := filepath.Join(t.TempDir(), "bomb.pptx")
+	if err := writeZipFile(path, entries); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestMaxPartSizeLimitsEmbeddedWorkbookParts(t *testing.T) {
+	path := writeWorkbookBomb(t)
+	opts := DefaultOptions()
+	opts.Limits.MaxPartSize = 64 << 10
+
+	doc, err := OpenFile(path, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	_, err = doc.ExtractChartData(0)
+	var tooLarge *PartTooLargeError
+	if !errors.As(err, &tooLarge) || !errors.Is(err, ErrPartTooLarge) || tooLarge.Part != "xl/worksheets/sheet1.xml" || tooLarge.Limit != 64<<10 {
+		t.Fatalf("expected the worksheet to be refused, got %v", err)
+	}
+	if len(doc.AlertsByCode("PACKAGE_PART_TOO_LARGE")) != 0 {
+		t.Fatalf("expected no alert in Strict mode, got %#v", doc.Alerts())
+	}
+
+	opts.Mode = BestEffort
+	doc, err = OpenFile(path, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := doc.ExtractAllCharts(); err != nil {
+			t.Fatalf("ExtractAllCharts: %v", err)
+		}
+	}
+	alerts := doc.AlertsByCode("PACKAGE_PART_TOO_LARGE")
+	if len(alerts) != 1 {
+		t.Fatalf("expected one alert for the part, got %#v", doc.Alerts())
+	}
+	want := map[string]string{
+		"part":     "xl/worksheets/sheet1.xml",
+		"workbook": "ppt/embeddings/embeddedWorkbook1.xlsx",
+		"size":     alerts[0].Context["size"],
+		"limit":    "maxPartSize",
+		"max":      "65536",
+	}
+	if !reflect.DeepEqual(alerts[0].Context, want) || alerts[0].Context["size"] == "" {
+		t.Fatalf("unexpected alert context: %#v", alerts[0].Context)
+	}
+
+	doc.ClearAlerts()
+	if _, err := doc.ExtractAllCharts(); err != nil {
+		t.Fatalf("ExtractAllCharts: %v", err)
+	}
+	if len(doc.AlertsByCode("PACKAGE_PART_TOO_LARGE")) != 1 {
+		t.Fatalf("expected the alert again after ClearAlerts, got %#v", doc.Alerts())
+	}
+}
+
+func TestMaxTotalUncompressedBoundsPartsRead(t *testing.T) {
+	opts := DefaultOptions()
+	// Listing reads 1156 bytes of rels and chart XML; the workbook adds 1289.
+	opts.Limits.MaxTotalUncompressed = 2000
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if charts, err := doc.ListCharts(); err != nil || len(charts) != 1 {
+			t.Fatalf("expected rereads to count once, got %#v, %v", charts, err)
+		}
+	}
+	_, err = doc.ExtractChartData(0)
+	var tooLarge *PartTooLargeError
+	if !errors.As(err, &tooLarge) || !tooLarge.Total || tooLarge.Part != "ppt/embeddings/embeddedWorkbook1.xlsx" {
+		t.Fatalf("expected the workbook to exceed the total, got %v", err)
+	}
+}
+
+func TestMaxChartCountStopsDiscovery(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Limits.MaxChartCount = 1
+	doc, err := OpenFile(fixturePath("shared_workbook_two_charts.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) != 1 || charts[0].ChartPath != "ppt/charts/chart1.xml" {
+		t.Fatalf("expected only the first chart, got %#v, %v", charts, err)
+	}
+	alerts := doc.AlertsByCode("CHART_COUNT_LIMIT_REACHED")
+	if len(alerts) != 1 || alerts[0].Context["maxChartCount"] != "1" {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart2.xml", map[string][]string{"values:0": {"1", "2"}}); err == nil {
+		t.Fatalf("expected the second chart to be out of reach")
+	}
+
+	opts.Limits.MaxChartCount = 2
+	doc, err = OpenFile(fixturePath("shared_workbook_two_charts.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if charts, err := doc.ListCharts(); err != nil || len(charts) != 2 || len(doc.AlertsByCode("CHART_COUNT_LIMIT_REACHED")) != 0 {
+		t.Fatalf("expected both charts without an alert, got %#v, %v, %#v", charts, err, doc.Alerts())
+	}
+}
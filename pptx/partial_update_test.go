@@ -0,0 +1,153 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+func TestApplyChartDataPartialUpdate(t *testing.T) {
+	exercisesFeature(t, "apply.partial")
+
+	const workbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+	input := fixturePath("doughnut_two_rings_embedded.pptx")
+	data := ChartDataInput{"values:1": {"40", "50", "60"}}
+
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.PlanChanges(PlanRequest{Data: data}); err != nil {
+		t.Fatalf("PlanChanges: %v", err)
+	}
+	if err := doc.ApplyChartData(0, data); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	sheet := readSheetFromXLSX(t, readEmbeddedWorkbook(t, output, workbookPath), "xl/worksheets/sheet1.xml")
+	for ref, want := range map[string]string{"A2": "North", "B2": "10", "B4": "30", "C2": "40", "C4": "60"} {
+		if _, val, ok := readCellFromSheet(sheet, ref); !ok || val != want {
+			t.Fatalf("cell %s: expected %q, got %q (ok=%v)", ref, want, val, ok)
+		}
+	}
+	// The fixture's caches are stale; only those of the supplied series
+	// are synced.
+	cats, vals := extractChartCacheValues(t, readZipEntry(t, output, "ppt/charts/chart1.xml"))
+	wantCats := []string{"Old A", "Old1", "Old2", "Old3", "2024", "North", "South", "West"}
+	if !reflect.DeepEqual(cats, wantCats) || !reflect.DeepEqual(vals, []string{"1", "2", "3", "40", "50", "60"}) {
+		t.Fatalf("unexpected caches: %#v %#v", cats, vals)
+	}
+
+	doc, err = OpenFile(fixturePath("mix_write_bar_line_valid.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile mixed: %v", err)
+	}
+	if err := doc.ApplyChartData(0, ChartDataInput{"values:1": {"33", "44"}}); err != nil {
+		t.Fatalf("ApplyChartData mixed: %v", err)
+	}
+	output = filepath.Join(t.TempDir(), "mixed.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile mixed: %v", err)
+	}
+	workbook, err := pptxassert.ReadEntry(output, workbookPath)
+	if err != nil {
+		t.Fatalf("ReadEntry workbook: %v", err)
+	}
+	original, err := pptxassert.ReadEntry(fixturePath("mix_write_bar_line_valid.pptx"), workbookPath)
+	if err != nil {
+		t.Fatalf("ReadEntry original workbook: %v", err)
+	}
+	refs := []string{"A2", "A3", "B2", "B3", "C2", "C3"}
+	cells, err := pptxassert.ExtractWorkbookCellSnapshot(workbook, "Sheet1", refs)
+	if err != nil {
+		t.Fatalf("ExtractWorkbookCellSnapshot: %v", err)
+	}
+	before, err := pptxassert.ExtractWorkbookCellSnapshot(original, "Sheet1", refs)
+	if err != nil {
+		t.Fatalf("ExtractWorkbookCellSnapshot original: %v", err)
+	}
+	if cells["C2"] != "33" || cells["C3"] != "44" {
+		t.Fatalf("unexpected line series cells: %v", cells)
+	}
+	for _, ref := range []string{"A2", "A3", "B2", "B3"} {
+		if cells[ref] != before[ref] {
+			t.Fatalf("cell %s must be untouched: %q, was %q", ref, cells[ref], before[ref])
+		}
+	}
+}
+
+func TestApplyChartDataPartialUpdateBestEffort(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Mode = BestEffort
+	input := fixturePath("doughnut_two_rings_embedded.pptx")
+
+	doc, err := OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	// Only the supplied series is checked against the categories.
+	mismatch := ChartDataInput{"categories": {"A", "B", "C"}, "values:1": {"1", "2"}}
+	if err := doc.ApplyChartData(0, mismatch); err != nil {
+		t.Fatalf("expected the mismatch to be skipped, got %v", err)
+	}
+	alerts := doc.AlertsByCode("CHART_DATA_LENGTH_MISMATCH")
+	if len(alerts) != 1 || alerts[0].Context["seriesIndex"] != "1" {
+		t.Fatalf("expected CHART_DATA_LENGTH_MISMATCH for series 1, got %#v", doc.Alerts())
+	}
+
+	if err := doc.ApplyChartData(0, ChartDataInput{"categories": {"A", "B", "C"}, "values:0": {"7", "8", "9"}}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	if len(doc.Alerts()) != 1 {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	// Categories are shared, so every series' categories cache follows
+	// them; the values cache of the series left out keeps its points.
+	cats, vals := extractChartCacheValues(t, readZipEntry(t, output, "ppt/charts/chart1.xml"))
+	wantCats := []string{"2023", "A", "B", "C", "Old B", "A", "B", "C"}
+	if !reflect.DeepEqual(cats, wantCats) || !reflect.DeepEqual(vals, []string{"7", "8", "9", "4", "5", "6"}) {
+		t.Fatalf("unexpected caches: %#v %#v", cats, vals)
+	}
+}
+
+func TestApplyChartDataRequireAllSeries(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Chart.RequireAllSeries = true
+	cases := map[string]struct {
+		data ChartDataInput
+		want string
+	}{
+		"series left out": {
+			data: ChartDataInput{"categories": {"A", "B", "C"}, "values:1": {"1", "2", "3"}},
+			want: "values data missing for series 0",
+		},
+		"categories left out": {
+			data: ChartDataInput{"values:0": {"1", "2", "3"}, "values:1": {"4", "5", "6"}},
+			want: "categories data is required",
+		},
+	}
+	for name, tc := range cases {
+		doc, err := OpenFile(fixturePath("doughnut_two_rings_embedded.pptx"), WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		_, planErr := doc.PlanChanges(PlanRequest{Data: tc.data})
+		applyErr := doc.ApplyChartData(0, tc.data)
+		for _, err := range []error{planErr, applyErr} {
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("%s: expected %q, got %v", name, tc.want, err)
+			}
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 )
 
 func TestPieApplyChartDataStrict(t *testing.T) {
+	exercisesFeature(t, "apply.pie", "cachesync.pie")
+
 	input := fixturePath("pie_edit_valid.pptx")
 	output := filepath.Join(t.TempDir(), "output.pptx")
 
@@ -162,7 +164,7 @@ func TestPiePostflightInvalidCacheStrict(t *testing.T) {
 		Mode:             postflight.ModeStrict,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, badChart)
 	})
 	if err == nil {
@@ -1,13 +1,11 @@
 package pptx
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"why-pptx/internal/chartdiscover"
-	"why-pptx/internal/chartxml"
 )
 
 type Range = ChartRange
@@ -26,38 +24,53 @@ type Plan struct {
 }
 
 type PlannedChart struct {
-	Index        int     `json:"index"`
-	SlidePath    string  `json:"slidePath"`
-	ChartPath    string  `json:"chartPath"`
-	WorkbookPath string  `json:"workbookPath"`
-	ChartType    string  `json:"chartType"`
-	Title        string  `json:"title,omitempty"`
-	AltText      string  `json:"altText,omitempty"`
-	Action       string  `json:"action"`
-	ReasonCode   string  `json:"reasonCode,omitempty"`
-	Dependencies []Range `json:"dependencies,omitempty"`
+	Index     int    `json:"index"`
+	SlidePath string `json:"slidePath"`
+	// Source tells a chart on a slide from one on a layout, master, or
+	// notes slide, which no slide path selects.
+	Source       ChartSource `json:"source"`
+	ChartPath    string      `json:"chartPath"`
+	WorkbookPath string      `json:"workbookPath"`
+	ChartType    string      `json:"chartType"`
+	Title        string      `json:"title,omitempty"`
+	AltText      string      `json:"altText,omitempty"`
+	Action       string      `json:"action"`
+	ReasonCode   string      `json:"reasonCode,omitempty"`
+	Dependencies []Range     `json:"dependencies,omitempty"`
+	// Fingerprint and CellCount come from ChartDependencies; comparing the
+	// fingerprints of two plans shows which charts changed structure.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	CellCount   int    `json:"cellCount,omitempty"`
+	// AxisGroups and HasSecondaryAxis are those of ChartInfo.
+	AxisGroups       []AxisInfo `json:"axisGroups,omitempty"`
+	HasSecondaryAxis bool       `json:"hasSecondaryAxis,omitempty"`
+	// Changes lists the cells PlanRequest.Data would change, current value
+	// against proposed, for a chart planned to "apply". Cells already
+	// holding their new value are left out.
+	Changes []PlannedCellChange `json:"changes,omitempty"`
 }
 
 func (d *Document) Plan() (Plan, error) {
 	return d.PlanChanges(PlanRequest{})
 }
 
-func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
+func (d *Document) PlanChanges(req PlanRequest, opts ...CallOption) (Plan, error) {
 	if d == nil || d.pkg == nil {
 		return Plan{}, fmt.Errorf("document not initialized")
 	}
+	call := d.newCall(opts)
 
 	cacheSync := d.opts.Chart.CacheSync
 	if req.CacheSync != nil {
 		cacheSync = *req.CacheSync
 	}
 
-	refs, err := chartdiscover.DiscoverChartRefs(d.pkg)
+	refs, err := d.discoverChartRefs()
 	if err != nil {
 		return Plan{}, err
 	}
 
-	embedded, skipped, err := chartdiscover.DiscoverEmbeddedCharts(d.pkg)
+	embedded, skipped, err := d.discoverEmbeddedCharts()
 	if err != nil {
 		return Plan{}, err
 	}
@@ -76,7 +89,7 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 	var alerts []Alert
 
 	for i, ref := range refs {
-		info, infoAlerts := d.planChartInfo(i, ref, embeddedByPath[ref.ChartPath])
+		info, infoAlerts := call.planChartInfo(i, ref, embeddedByPath[ref.ChartPath])
 		allInfos = append(allInfos, info)
 		infoByPath[ref.ChartPath] = info
 		if len(infoAlerts) > 0 {
@@ -84,7 +97,7 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 		}
 	}
 
-	selected, targetAlerts, err := selectPlanTargets(req.TargetCharts, allInfos, d.opts.Mode)
+	selected, targetAlerts, err := selectPlanTargets(req.TargetCharts, allInfos, call.mode(), d.planSlideTarget)
 	if err != nil {
 		plan := Plan{Charts: []PlannedChart{}, Alerts: append(alerts, targetAlerts...)}
 		return plan, err
@@ -103,26 +116,24 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 
 		info := infoByPath[ref.ChartPath]
 		chart := PlannedChart{
-			Index:     i,
-			SlidePath: ref.SlidePath,
-			ChartPath: ref.ChartPath,
-			ChartType: info.ChartType,
-			Title:     info.Title,
-			AltText:   info.AltText,
-			Action:    "apply",
+			Index:            i,
+			SlidePath:        ref.SlidePath,
+			Source:           info.Source,
+			ChartPath:        ref.ChartPath,
+			ChartType:        info.ChartType,
+			Title:            info.Title,
+			AltText:          info.AltText,
+			Action:           "apply",
+			AxisGroups:       info.AxisGroups,
+			HasSecondaryAxis: info.HasSecondaryAxis,
 		}
 
 		if skip, ok := skippedByPath[ref.ChartPath]; ok {
-			action, code, ctx := planSkipReason(skip)
-			chart.Action = action
-			chart.ReasonCode = code
-			if code != "" {
-				alerts = append(alerts, Alert{
-					Level:   "warn",
-					Code:    code,
-					Message: planMessageForCode(code),
-					Context: ctx,
-				})
+			reason := lookupSkipReason(skip)
+			chart.Action = reason.action
+			chart.ReasonCode = reason.code
+			if alert, ok := reason.alert(skip); ok {
+				alerts = append(alerts, alert)
 			}
 			plan.Charts = append(plan.Charts, chart)
 			continue
@@ -135,7 +146,7 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 			alerts = append(alerts, Alert{
 				Level:   "warn",
 				Code:    "CHART_WORKBOOK_NOT_FOUND",
-				Message: planMessageForCode("CHART_WORKBOOK_NOT_FOUND"),
+				Message: skipReasons[chartdiscover.ReasonWorkbookNotFound].message,
 				Context: map[string]string{
 					"slide": ref.SlidePath,
 					"chart": ref.ChartPath,
@@ -147,26 +158,46 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 
 		chart.WorkbookPath = embeddedItem.WorkbookPath
 
-		deps, err := d.extractChartDependencies(EmbeddedChart{
-			SlidePath:    embeddedItem.SlidePath,
-			ChartPath:    embeddedItem.ChartPath,
-			WorkbookPath: embeddedItem.WorkbookPath,
+		var deps ChartDependencies
+		err := recoverChart("plan", embeddedItem.ChartPath, func() error {
+			var err error
+			deps, err = call.extractChartDependencies(EmbeddedChart{
+				SlidePath:    embeddedItem.SlidePath,
+				ChartPath:    embeddedItem.ChartPath,
+				WorkbookPath: embeddedItem.WorkbookPath,
+			})
+			return err
 		})
+		var panicErr *ChartPanicError
+		if errors.As(err, &panicErr) {
+			d.logChartPanic(panicErr)
+			chart.Action = "skip"
+			chart.ReasonCode = "CHART_INTERNAL_PANIC"
+			alerts = append(alerts, chartPanicAlert(panicErr, embeddedItem.SlidePath, embeddedItem.WorkbookPath))
+			plan.Charts = append(plan.Charts, chart)
+			if call.mode() == Strict {
+				plan.Alerts = alerts
+				return plan, panicErr
+			}
+			continue
+		}
 		if err != nil {
+			context := map[string]string{
+				"slide":    embeddedItem.SlidePath,
+				"chart":    embeddedItem.ChartPath,
+				"workbook": embeddedItem.WorkbookPath,
+				"error":    err.Error(),
+			}
+			code := dependencyFailureCode(err, context)
 			chart.Action = "skip"
-			chart.ReasonCode = "CHART_DEPENDENCIES_PARSE_FAILED"
+			chart.ReasonCode = code
 			alerts = append(alerts, Alert{
 				Level:   "warn",
-				Code:    "CHART_DEPENDENCIES_PARSE_FAILED",
-				Message: planMessageForCode("CHART_DEPENDENCIES_PARSE_FAILED"),
-				Context: map[string]string{
-					"slide":    embeddedItem.SlidePath,
-					"chart":    embeddedItem.ChartPath,
-					"workbook": embeddedItem.WorkbookPath,
-					"error":    err.Error(),
-				},
+				Code:    code,
+				Message: planMessageForCode(code),
+				Context: context,
 			})
-			if d.opts.Mode == Strict && planErr == nil {
+			if call.mode() == Strict && planErr == nil {
 				planErr = err
 			}
 			plan.Charts = append(plan.Charts, chart)
@@ -175,6 +206,8 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 
 		chart.ChartType = deps.ChartType
 		chart.Dependencies = deps.Ranges
+		chart.Fingerprint = deps.Fingerprint
+		chart.CellCount = deps.CellCount
 
 		if err := validatePlanRanges(chart.Dependencies); err != nil {
 			chart.Action = "skip"
@@ -190,14 +223,14 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 					"error":    err.Error(),
 				},
 			})
-			if d.opts.Mode == Strict && planErr == nil {
+			if call.mode() == Strict && planErr == nil {
 				planErr = err
 			}
 			plan.Charts = append(plan.Charts, chart)
 			continue
 		}
 
-		if deps.ChartType != "bar" && deps.ChartType != "line" && cacheSync {
+		if !planApplyTypes[deps.ChartType] || cacheSync && !planCacheSyncTypes[deps.ChartType] {
 			chart.Action = "unsupported"
 			chart.ReasonCode = "CHART_TYPE_UNSUPPORTED"
 			alerts = append(alerts, Alert{
@@ -210,7 +243,7 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 					"chartType": deps.ChartType,
 				},
 			})
-			if d.opts.Mode == Strict && planErr == nil {
+			if call.mode() == Strict && planErr == nil {
 				planErr = fmt.Errorf("unsupported chart type %q", deps.ChartType)
 			}
 			plan.Charts = append(plan.Charts, chart)
@@ -218,7 +251,7 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 		}
 
 		if len(req.Data) > 0 {
-			action, reason, dataAlerts, dataErr := validatePlanData(req.Data, chart, d.opts.Mode)
+			action, reason, dataAlerts, dataErr := validatePlanData(req.Data, chart, call.mode(), d.opts.Chart, d.opts.Input)
 			if len(dataAlerts) > 0 {
 				alerts = append(alerts, dataAlerts...)
 			}
@@ -237,6 +270,12 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 				plan.Charts = append(plan.Charts, chart)
 				continue
 			}
+			changes, changeAlerts, changeErr := call.planCellChanges(chart, req.Data)
+			alerts = append(alerts, changeAlerts...)
+			if changeErr != nil && planErr == nil {
+				planErr = changeErr
+			}
+			chart.Changes = changes
 		}
 
 		plan.Charts = append(plan.Charts, chart)
@@ -248,10 +287,12 @@ func (d *Document) PlanChanges(req PlanRequest) (Plan, error) {
 	return plan, planErr
 }
 
-func (d *Document) planChartInfo(index int, ref chartdiscover.ChartRef, embedded chartdiscover.EmbeddedChart) (ChartInfo, []Alert) {
+func (d *docCall) planChartInfo(index int, ref chartdiscover.ChartRef, embedded chartdiscover.EmbeddedChart) (ChartInfo, []Alert) {
 	info := ChartInfo{
 		Index:        index,
 		SlidePath:    ref.SlidePath,
+		Source:       ChartSource(ref.Source),
+		SlideIndex:   ref.SlideIndex,
 		ChartPath:    ref.ChartPath,
 		WorkbookPath: embedded.WorkbookPath,
 		ChartType:    "unknown",
@@ -277,7 +318,7 @@ func (d *Document) planChartInfo(index int, ref chartdiscover.ChartRef, embedded
 		}}
 	}
 
-	parsed, err := chartxml.ParseInfo(bytes.NewReader(data))
+	parsed, err := d.chartInfo(ref.ChartPath, data)
 	if err != nil {
 		if titleFromSlide != "" {
 			info.Title = titleFromSlide
@@ -297,6 +338,8 @@ func (d *Document) planChartInfo(index int, ref chartdiscover.ChartRef, embedded
 	info.ChartType = parsed.ChartType
 	info.SeriesCount = parsed.SeriesCount
 	info.Title = parsed.Title
+	info.AxisGroups, info.HasSecondaryAxis = chartAxisInfo(parsed)
+	info.DataLabels, info.SeriesDataLabels = chartDataLabels(parsed)
 	if info.Title == "" && titleFromSlide != "" {
 		info.Title = titleFromSlide
 	}
@@ -304,7 +347,10 @@ func (d *Document) planChartInfo(index int, ref chartdiscover.ChartRef, embedded
 	return info, nil
 }
 
-func selectPlanTargets(targets []string, infos []ChartInfo, mode ErrorMode) (map[string]struct{}, []Alert, error) {
+// selectPlanTargets resolves PlanRequest.TargetCharts: chart paths, chart
+// names, and slides, which select every chart on them. slideTarget reports
+// whether a target names a slide.
+func selectPlanTargets(targets []string, infos []ChartInfo, mode ErrorMode, slideTarget func(string) (string, bool, error)) (map[string]struct{}, []Alert, error) {
 	if len(targets) == 0 {
 		return nil, nil, nil
 	}
@@ -322,10 +368,21 @@ func selectPlanTargets(targets []string, infos []ChartInfo, mode ErrorMode) (map
 			selected[info.ChartPath] = struct{}{}
 			continue
 		}
+		if slide, ok, err := slideTarget(target); ok {
+			if err != nil {
+				return nil, alerts, err
+			}
+			for _, info := range infos {
+				if info.SlidePath == slide {
+					selected[info.ChartPath] = struct{}{}
+				}
+			}
+			continue
+		}
 
 		matches := matchChartsByName(infos, target)
 		if len(matches) == 0 {
-			return nil, alerts, fmt.Errorf("chart not found")
+			return nil, alerts, ErrChartNotFound
 		}
 		if len(matches) > 1 {
 			if mode == BestEffort {
@@ -348,49 +405,48 @@ func selectPlanTargets(targets []string, infos []ChartInfo, mode ErrorMode) (map
 	return selected, alerts, nil
 }
 
-func planSkipReason(skip chartdiscover.SkippedChart) (string, string, map[string]string) {
-	switch skip.Reason {
-	case chartdiscover.ReasonLinked:
-		return "linked", "CHART_LINKED_WORKBOOK", map[string]string{
-			"slide":  skip.SlidePath,
-			"chart":  skip.ChartPath,
-			"target": skip.Target,
-		}
-	case chartdiscover.ReasonRelsMissing:
-		return "skip", "CHART_RELS_MISSING", map[string]string{
-			"slide":     skip.SlidePath,
-			"chart":     skip.ChartPath,
-			"rels_path": skip.RelsPath,
-		}
-	case chartdiscover.ReasonWorkbookNotFound:
-		return "skip", "CHART_WORKBOOK_NOT_FOUND", map[string]string{
-			"slide": skip.SlidePath,
-			"chart": skip.ChartPath,
-		}
-	case chartdiscover.ReasonUnsupported:
-		return "skip", "CHART_WORKBOOK_UNSUPPORTED_TARGET", map[string]string{
-			"slide":  skip.SlidePath,
-			"chart":  skip.ChartPath,
-			"target": skip.Target,
-		}
-	default:
-		return "skip", "", map[string]string{
-			"slide": skip.SlidePath,
-			"chart": skip.ChartPath,
-		}
-	}
+// planApplyTypes lists the chart types ApplyChartData writes. Others, such
+// as scatter and radar (read-only) or bubble and surface (detected only), are
+// planned as unsupported.
+var planApplyTypes = map[string]bool{
+	"bar":      true,
+	"line":     true,
+	"pie":      true,
+	"doughnut": true,
+	"area":     true,
+	"stock":    true,
+	"mixed":    true,
+}
+
+// planCacheSyncTypes lists the chart types whose caches SyncChartCaches
+// rewrites one plot at a time; with cache sync on, other types are planned as
+// unsupported.
+var planCacheSyncTypes = map[string]bool{
+	"bar":      true,
+	"line":     true,
+	"pie":      true,
+	"doughnut": true,
+	"area":     true,
+	"stock":    true,
 }
 
 func validatePlanRanges(ranges []Range) error {
 	for _, r := range ranges {
-		if _, err := expandRangeCells(r.StartCell, r.EndCell); err != nil {
+		if _, err := r.Cells(); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func validatePlanData(data ChartDataInput, chart PlannedChart, mode ErrorMode) (string, string, []Alert, error) {
+func validatePlanData(data ChartDataInput, chart PlannedChart, mode ErrorMode, chartOpts ChartOptions, inputOpts InputOptions) (string, string, []Alert, error) {
+	seriesKeys := newSeriesKeys(chart.Dependencies)
+	valueKeys, keysErr := seriesKeys.resolve(data, chartOpts.RequireAllSeries)
+	if keysErr == nil {
+		if _, err := seriesKeys.nameKeys(data, valueKeys); err != nil {
+			return "", "", nil, err
+		}
+	}
 	categories, hasCategories := data["categories"]
 	if hasCategories {
 		categoriesLen := len(categories)
@@ -398,8 +454,7 @@ func validatePlanData(data ChartDataInput, chart PlannedChart, mode ErrorMode) (
 			if r.Kind != RangeValues {
 				continue
 			}
-			key := fmt.Sprintf("values:%d", r.SeriesIndex)
-			values, ok := data[key]
+			values, ok := data[valueKeys[r.SeriesIndex]]
 			if !ok {
 				continue
 			}
@@ -417,18 +472,36 @@ func validatePlanData(data ChartDataInput, chart PlannedChart, mode ErrorMode) (
 						},
 					}}, nil
 				}
-				return "", "", nil, fmt.Errorf("categories length %d does not match values length %d for series %d", categoriesLen, len(values), r.SeriesIndex)
+				return "", "", nil, fmt.Errorf("categories length %d does not match values length %d for series %d%s", categoriesLen, len(values), r.SeriesIndex, seriesKeys.hint())
 			}
 		}
 	}
 
-	for _, r := range chart.Dependencies {
+	ranges := chart.Dependencies
+	if chartOpts.AllowResize && keysErr == nil {
+		resize, err := resizeChartRanges(ranges, data, valueKeys)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if resize != nil {
+			ranges = resize.ranges
+		}
+	}
+
+	coerced := make(numberCoercions)
+	for _, r := range ranges {
+		if r.UnionIndex > 0 {
+			continue
+		}
 		switch r.Kind {
 		case RangeCategories:
 			if !hasCategories {
-				return "", "", nil, fmt.Errorf("categories data is required")
+				if chartOpts.RequireAllSeries {
+					return "", "", nil, fmt.Errorf("categories data is required")
+				}
+				continue
 			}
-			cells, err := expandRangeCells(r.StartCell, r.EndCell)
+			cells, err := formulaCells(ranges, r)
 			if err != nil {
 				return "", "", nil, err
 			}
@@ -436,51 +509,64 @@ func validatePlanData(data ChartDataInput, chart PlannedChart, mode ErrorMode) (
 				return "", "", nil, fmt.Errorf("categories length mismatch: expected %d got %d", len(cells), len(categories))
 			}
 		case RangeValues:
-			key := fmt.Sprintf("values:%d", r.SeriesIndex)
-			values, ok := data[key]
+			if keysErr != nil {
+				return "", "", nil, keysErr
+			}
+			values, ok := data[valueKeys[r.SeriesIndex]]
 			if !ok {
-				return "", "", nil, fmt.Errorf("values data missing for series %d", r.SeriesIndex)
+				continue
 			}
-			cells, err := expandRangeCells(r.StartCell, r.EndCell)
+			cells, err := formulaCells(ranges, r)
 			if err != nil {
 				return "", "", nil, err
 			}
 			if len(values) != len(cells) {
-				return "", "", nil, fmt.Errorf("values length mismatch for series %d: expected %d got %d", r.SeriesIndex, len(cells), len(values))
+				return "", "", nil, fmt.Errorf("values length mismatch for series %d: expected %d got %d%s", r.SeriesIndex, len(cells), len(values), seriesKeys.hint())
 			}
-			for _, value := range values {
-				if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
-					return "", "", nil, fmt.Errorf("invalid numeric value %q for series %d", value, r.SeriesIndex)
+			for i, value := range values {
+				if chartOpts.AllowExpressions {
+					if _, ok := parseValueExpression(value); ok {
+						continue
+					}
+				}
+				_, _, wasCoerced, err := readInputNumber(value, inputOpts)
+				if err != nil {
+					invalid := &InvalidNumberError{SeriesIndex: r.SeriesIndex, Position: i, Value: value}
+					if mode == BestEffort {
+						return "skip", "CHART_DATA_INVALID_NUMBER", []Alert{invalidNumberAlert(chart.Index, invalid)}, nil
+					}
+					return "", "", nil, invalid
+				}
+				if wasCoerced {
+					coerced.add(r.SeriesIndex, i)
 				}
 			}
 		}
 	}
 
-	return "", "", nil, nil
+	return "", "", coerced.alerts(chart.Index), nil
 }
 
 func planMessageForCode(code string) string {
 	switch code {
-	case "CHART_LINKED_WORKBOOK":
-		return "Chart uses linked workbook and is skipped"
-	case "CHART_RELS_MISSING":
-		return "Chart relationships file is missing; chart is skipped"
-	case "CHART_WORKBOOK_NOT_FOUND":
-		return "No workbook relationship found for chart; chart is skipped"
-	case "CHART_WORKBOOK_UNSUPPORTED_TARGET":
-		return "Chart workbook target is unsupported; chart is skipped"
 	case "CHART_DEPENDENCIES_PARSE_FAILED":
 		return "Failed to extract chart dependencies; chart is skipped"
+	case "CHART_NAMED_RANGE_UNSUPPORTED":
+		return "Series formula is a defined name, which is unsupported; chart is skipped"
 	case "CHART_CACHE_SYNC_FAILED":
 		return "Failed to sync chart caches; chart is skipped"
 	case "CHART_DATA_LENGTH_MISMATCH":
 		return "Categories and values length mismatch; chart skipped"
+	case "CHART_DATA_INVALID_NUMBER":
+		return "A value is not a number; chart skipped"
 	case "CHART_NAME_AMBIGUOUS":
 		return "Chart name is ambiguous; no chart selected"
 	case "CHART_INFO_PARSE_FAILED":
 		return "Failed to parse chart info; chart metadata is partial"
 	case "CHART_TYPE_UNSUPPORTED":
 		return "Chart type is unsupported; chart is skipped"
+	case "PLAN_CELL_UNREADABLE":
+		return "Current workbook values could not be read; oldValue left empty"
 	default:
 		return "Plan detected an issue"
 	}
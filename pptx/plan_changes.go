@@ -0,0 +1,228 @@
+package pptx
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/xlsxembed"
+)
+
+// PlannedCellChange is one workbook cell that ApplyChartData would change,
+// with its current and proposed values. The JSON field names are stable.
+type PlannedCellChange struct {
+	WorkbookPath string `json:"workbook"`
+	Sheet        string `json:"sheet"`
+	Cell         string `json:"cell"`
+	// OldValue is the cell as GetWorkbookCells reads it; "" for a blank
+	// cell, or one that could not be read (see PLAN_CELL_UNREADABLE).
+	OldValue string `json:"oldValue"`
+	// NewValue is the input value; a number read under a localized
+	// Options.Input.NumberFormat is shown as it will be stored.
+	NewValue string `json:"newValue"`
+	// Role is PlanRoleCategories, PlanRoleValues, or PlanRoleSeriesName.
+	Role string `json:"role"`
+	// SeriesIndex is the series written, -1 for categories.
+	SeriesIndex int `json:"seriesIndex"`
+}
+
+// Roles of a PlannedCellChange.
+const (
+	PlanRoleCategories = "categories"
+	PlanRoleValues     = "values"
+	PlanRoleSeriesName = "seriesName"
+)
+
+// planCellChanges lists the cells data would write in chart that differ
+// from the workbook, in write order: categories and values by range, then
+// series names. A value cell whose number is unchanged ("7" and "7.0") is
+// left out. With Chart.AllowResize the resized ranges are used, so appended
+// points show up with an empty OldValue. A sheet that cannot be read gives
+// its cells an empty OldValue and a PLAN_CELL_UNREADABLE alert, or the
+// error in Strict mode.
+func (d *docCall) planCellChanges(chart PlannedChart, data ChartDataInput) ([]PlannedCellChange, []Alert, error) {
+	seriesKeys := newSeriesKeys(chart.Dependencies)
+	valueKeys, err := seriesKeys.resolve(data, d.opts.Chart.RequireAllSeries)
+	if err != nil {
+		return nil, nil, nil
+	}
+	nameKeys, err := seriesKeys.nameKeys(data, valueKeys)
+	if err != nil {
+		return nil, nil, nil
+	}
+	ranges := chart.Dependencies
+	if d.opts.Chart.AllowResize {
+		resize, err := resizeChartRanges(ranges, data, valueKeys)
+		if err != nil {
+			return nil, nil, nil
+		}
+		if resize != nil {
+			ranges = resize.ranges
+		}
+	}
+
+	var changes []PlannedCellChange
+	seen := make(map[string]bool)
+	add := func(sheet, cell, value, role string, seriesIndex int) {
+		key := sheet + "!" + cell
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		changes = append(changes, PlannedCellChange{
+			WorkbookPath: chart.WorkbookPath,
+			Sheet:        sheet,
+			Cell:         cell,
+			NewValue:     value,
+			Role:         role,
+			SeriesIndex:  seriesIndex,
+		})
+	}
+	for _, r := range ranges {
+		if r.UnionIndex > 0 {
+			continue
+		}
+		var values []string
+		role, seriesIndex := PlanRoleValues, r.SeriesIndex
+		switch r.Kind {
+		case RangeCategories:
+			values = data["categories"]
+			role, seriesIndex = PlanRoleCategories, -1
+		case RangeValues:
+			values = data[valueKeys[r.SeriesIndex]]
+		default:
+			continue
+		}
+		if values == nil {
+			continue
+		}
+		cells, err := formulaCells(ranges, r)
+		if err != nil || len(cells) != len(values) {
+			continue
+		}
+		for i, cell := range cells {
+			value := values[i]
+			if role == PlanRoleValues && (d.opts.Input.NumberFormat != NumberFormatCanonical || d.opts.Input.CoerceNumbers) {
+				if number, missing, _, err := readInputNumber(value, d.opts.Input); err == nil {
+					value = strconv.FormatFloat(number, 'f', -1, 64)
+					if missing && d.opts.Workbook.MissingNumericPolicy != MissingNumericZero {
+						value = ""
+					}
+				}
+			}
+			add(cell.sheet, cell.cell, value, role, seriesIndex)
+		}
+	}
+	names := seriesNameRanges(ranges)
+	series := make([]int, 0, len(nameKeys))
+	for seriesIndex := range nameKeys {
+		series = append(series, seriesIndex)
+	}
+	sort.Ints(series)
+	for _, seriesIndex := range series {
+		r := names[seriesIndex]
+		if r == nil {
+			continue
+		}
+		cells, err := expandRangeCells(r.StartCell, r.EndCell)
+		if err != nil || len(cells) != 1 {
+			continue
+		}
+		add(r.Sheet, cells[0], data[nameKeys[seriesIndex]][0], PlanRoleSeriesName, seriesIndex)
+	}
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
+
+	alerts, err := d.readPlannedOldValues(chart, changes)
+	if err != nil {
+		return nil, alerts, err
+	}
+	out := changes[:0]
+	for _, change := range changes {
+		if !plannedValueUnchanged(change) {
+			out = append(out, change)
+		}
+	}
+	return out, alerts, nil
+}
+
+// readPlannedOldValues fills OldValue from the workbook, one read per sheet.
+func (d *docCall) readPlannedOldValues(chart PlannedChart, changes []PlannedCellChange) ([]Alert, error) {
+	var sheets []string
+	bySheet := make(map[string][]int)
+	for i, change := range changes {
+		if _, ok := bySheet[change.Sheet]; !ok {
+			sheets = append(sheets, change.Sheet)
+		}
+		bySheet[change.Sheet] = append(bySheet[change.Sheet], i)
+	}
+
+	var alerts []Alert
+	unreadable := func(sheet string, err error) error {
+		alerts = append(alerts, Alert{
+			Level:   "warn",
+			Code:    "PLAN_CELL_UNREADABLE",
+			Message: planMessageForCode("PLAN_CELL_UNREADABLE"),
+			Context: map[string]string{
+				"slide":    chart.SlidePath,
+				"chart":    chart.ChartPath,
+				"workbook": chart.WorkbookPath,
+				"sheet":    sheet,
+				"cells":    strconv.Itoa(len(bySheet[sheet])),
+				"error":    err.Error(),
+			},
+		})
+		if d.mode() != BestEffort {
+			return err
+		}
+		return nil
+	}
+
+	wbBytes, err := d.pkg.ReadPart(chart.WorkbookPath)
+	var wb *xlsxembed.Workbook
+	if err == nil {
+		wb, err = d.openEmbeddedWorkbook(chart.WorkbookPath, wbBytes)
+	}
+	if err != nil {
+		for _, sheet := range sheets {
+			if err := unreadable(sheet, err); err != nil {
+				return alerts, err
+			}
+		}
+		return alerts, nil
+	}
+
+	for _, sheet := range sheets {
+		indexes := bySheet[sheet]
+		ranges := make([]xlsxembed.Range, len(indexes))
+		for i, index := range indexes {
+			ranges[i] = xlsxembed.Range{Sheet: sheet, StartCell: changes[index].Cell, EndCell: changes[index].Cell}
+		}
+		values, err := wb.GetRanges(ranges, xlsxembed.MissingNumericEmpty)
+		if err != nil {
+			if err := unreadable(sheet, err); err != nil {
+				return alerts, err
+			}
+			continue
+		}
+		for i, index := range indexes {
+			if len(values[i]) > 0 {
+				changes[index].OldValue = values[i][0]
+			}
+		}
+	}
+	return alerts, nil
+}
+
+func plannedValueUnchanged(change PlannedCellChange) bool {
+	if change.OldValue == change.NewValue {
+		return true
+	}
+	if change.Role != PlanRoleValues {
+		return false
+	}
+	oldNumber, oldErr := strconv.ParseFloat(strings.TrimSpace(change.OldValue), 64)
+	newNumber, newErr := strconv.ParseFloat(strings.TrimSpace(change.NewValue), 64)
+	return oldErr == nil && newErr == nil && oldNumber == newNumber
+}
@@ -1,6 +1,12 @@
 package pptx
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
 
 func TestPlanLinkedWorkbookSkip(t *testing.T) {
 	doc, err := OpenFile(fixturePath("linked_workbook_chart.pptx"))
@@ -70,3 +76,113 @@ func TestPlanOrderStable(t *testing.T) {
 		t.Fatalf("unexpected chart order: %#v", plan.Charts)
 	}
 }
+
+func TestPlanChangesDiffsCurrentValues(t *testing.T) {
+	exercisesFeature(t, "plan.changes")
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	plan, err := doc.PlanChanges(PlanRequest{Data: ChartDataInput{
+		"categories": {"Old1", "New"},
+		"values:0":   {"10.0", "99"},
+	}})
+	if err != nil {
+		t.Fatalf("PlanChanges: %v", err)
+	}
+	const workbook = "ppt/embeddings/embeddedWorkbook1.xlsx"
+	want := []PlannedCellChange{
+		{WorkbookPath: workbook, Sheet: "Sheet1", Cell: "A3", OldValue: "Old2", NewValue: "New", Role: PlanRoleCategories, SeriesIndex: -1},
+		{WorkbookPath: workbook, Sheet: "Sheet1", Cell: "B3", OldValue: "20", NewValue: "99", Role: PlanRoleValues, SeriesIndex: 0},
+	}
+	if len(plan.Charts) != 1 || !reflect.DeepEqual(plan.Charts[0].Changes, want) {
+		t.Fatalf("unexpected changes: %#v", plan.Charts)
+	}
+
+	plan, err = doc.Plan()
+	if err != nil || plan.Charts[0].Changes != nil {
+		t.Fatalf("expected no changes without data, got %#v %v", plan.Charts, err)
+	}
+}
+
+func TestPlanChangesUnreadableSheet(t *testing.T) {
+	entries := corpusZipEntries(t, readCorpusFile(t, fixturePath("bar_simple_embedded.pptx")))
+	entries["ppt/charts/chart1.xml"] = bytes.ReplaceAll(entries["ppt/charts/chart1.xml"], []byte("Sheet1!"), []byte("Gone!"))
+	inputPath := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(inputPath, entries); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	req := PlanRequest{Data: ChartDataInput{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"1", "2"},
+	}}
+
+	doc, err := OpenFile(inputPath, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	plan, err := doc.PlanChanges(req)
+	if err != nil {
+		t.Fatalf("PlanChanges: %v", err)
+	}
+	changes := plan.Charts[0].Changes
+	if len(changes) != 4 || changes[0].OldValue != "" || changes[0].Sheet != "Gone" {
+		t.Fatalf("expected every cell with an empty old value, got %#v", changes)
+	}
+	if len(plan.Alerts) != 1 || plan.Alerts[0].Code != "PLAN_CELL_UNREADABLE" || plan.Alerts[0].Context["sheet"] != "Gone" {
+		t.Fatalf("expected a PLAN_CELL_UNREADABLE alert, got %#v", plan.Alerts)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("Plan should not mutate document alerts")
+	}
+
+	doc, err = OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.PlanChanges(req); err == nil {
+		t.Fatalf("expected Strict mode to fail on an unreadable sheet")
+	}
+}
+
+// TestPlanJSONFieldNames freezes the plan JSON that external tooling reads.
+func TestPlanJSONFieldNames(t *testing.T) {
+	plan := Plan{Charts: []PlannedChart{{
+		Index:        1,
+		SlidePath:    "ppt/slides/slide1.xml",
+		Source:       ChartSource("slide"),
+		ChartPath:    "ppt/charts/chart1.xml",
+		WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx",
+		ChartType:    "bar",
+		Title:        "Sales",
+		AltText:      "Sales chart",
+		Action:       "apply",
+		ReasonCode:   "CODE",
+		Dependencies: []Range{{Kind: RangeValues, Sheet: "Sheet1", StartCell: "B2", EndCell: "B3", Formula: "Sheet1!$B$2:$B$3"}},
+		Fingerprint:  "v1:00",
+		CellCount:    2,
+		Changes: []PlannedCellChange{{
+			WorkbookPath: "ppt/embeddings/embeddedWorkbook1.xlsx",
+			Sheet:        "Sheet1",
+			Cell:         "B2",
+			OldValue:     "10",
+			NewValue:     "11",
+			Role:         PlanRoleValues,
+			SeriesIndex:  0,
+		}},
+	}}}
+	got, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"charts":[{"index":1,"slidePath":"ppt/slides/slide1.xml","source":"slide","chartPath":"ppt/charts/chart1.xml",` +
+		`"workbookPath":"ppt/embeddings/embeddedWorkbook1.xlsx","chartType":"bar","title":"Sales","altText":"Sales chart",` +
+		`"action":"apply","reasonCode":"CODE","dependencies":[{"Kind":"values","SeriesIndex":0,"OriginalIndex":0,"UnionIndex":0,` +
+		`"Sheet":"Sheet1","StartCell":"B2","EndCell":"B3","Formula":"Sheet1!$B$2:$B$3"}],"fingerprint":"v1:00","cellCount":2,` +
+		`"changes":[{"workbook":"ppt/embeddings/embeddedWorkbook1.xlsx","sheet":"Sheet1","cell":"B2","oldValue":"10","newValue":"11",` +
+		`"role":"values","seriesIndex":0}]}]}`
+	if string(got) != want {
+		t.Fatalf("plan JSON changed:\n got %s\nwant %s", got, want)
+	}
+}
@@ -35,7 +35,7 @@ func TestPostflightBestEffortDiscard(t *testing.T) {
 		ChartPath: chartPath,
 		Mode:      postflight.ModeBestEffort,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, []byte("<c:chartSpace><broken"))
 	})
 	if err == nil {
@@ -81,7 +81,7 @@ func TestPostflightStrictAbort(t *testing.T) {
 		ChartPath: chartPath,
 		Mode:      postflight.ModeStrict,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, []byte("<c:chartSpace><broken"))
 	})
 	if err == nil {
@@ -162,7 +162,7 @@ func TestPostflightBestEffortDiscardChartCache(t *testing.T) {
 		Mode:             postflight.ModeBestEffort,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, invalid)
 	})
 	if err == nil {
@@ -244,7 +244,7 @@ func TestPostflightStrictChartCacheAbort(t *testing.T) {
 		Mode:             postflight.ModeStrict,
 		CacheSyncEnabled: true,
 	}
-	err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+	err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 		return stage.Set(chartPath, invalid)
 	})
 	if err == nil {
@@ -292,7 +292,7 @@ func TestPostflightInlineStrViolation(t *testing.T) {
 			} else {
 				ctx.Mode = postflight.ModeStrict
 			}
-			err = doc.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+			err = doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
 				return stage.Set(workbookPath, workbook)
 			})
 			if err == nil {
@@ -0,0 +1,112 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractRadarChart(t *testing.T) {
+	exercisesFeature(t, "extract.radar")
+
+	doc, err := OpenFile(fixturePath("radar_two_series_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) != 1 || charts[0].ChartType != "radar" || charts[0].SeriesCount != 2 {
+		t.Fatalf("unexpected ListCharts: %#v %v", charts, err)
+	}
+
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if data.Type != "radar" || !reflect.DeepEqual(data.Labels, []string{"Cat1", "Cat2", "Cat3"}) || len(data.Series) != 2 {
+		t.Fatalf("unexpected chart: %#v", data)
+	}
+	if !reflect.DeepEqual(data.Series[1].Data, []string{"4", "5", "6"}) {
+		t.Fatalf("unexpected second series: %#v", data.Series[1])
+	}
+
+	payload, err := ChartJSExporter{}.Export(data)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if payload.Data["type"] != "radar" || len(payload.Data["datasets"].([]map[string]any)) != 2 {
+		t.Fatalf("unexpected payload: %#v", payload.Data)
+	}
+	if _, ok := payload.Data["scales"]; ok {
+		t.Fatalf("radar payloads have no x/y scales: %#v", payload.Data)
+	}
+	if _, err := (CSVExporter{}).Export(data); err != nil {
+		t.Fatalf("CSV Export: %v", err)
+	}
+}
+
+func TestRadarChartWritesUnsupported(t *testing.T) {
+	doc, err := OpenFile(fixturePath("radar_two_series_embedded.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, map[string][]string{"categories": {"A", "B", "C"}, "values:0": {"1", "2", "3"}}); err == nil {
+		t.Fatalf("expected the radar chart to be skipped for writes")
+	}
+	alerts := doc.AlertsByCode("CHART_TYPE_UNSUPPORTED")
+	if len(alerts) != 1 || alerts[0].Context["chartType"] != "radar" {
+		t.Fatalf("expected CHART_TYPE_UNSUPPORTED for radar, got %#v", doc.Alerts())
+	}
+	plan, err := doc.Plan()
+	if err != nil || plan.Charts[0].Action != "unsupported" || plan.Charts[0].ChartType != "radar" {
+		t.Fatalf("expected the radar chart to plan as unsupported: %#v %v", plan.Charts, err)
+	}
+}
+
+func TestBubbleChartDetectedUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bubble.pptx")
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte("<slide/>"),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": []byte(`<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart><c:plotArea><c:bubbleChart><c:ser><c:idx val="0"/><c:order val="0"/>
+  <c:xVal><c:numRef><c:f>Sheet1!$A$2:$A$3</c:f></c:numRef></c:xVal>
+  <c:yVal><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f></c:numRef></c:yVal>
+  <c:bubbleSize><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f></c:numRef></c:bubbleSize>
+</c:ser></c:bubbleChart></c:plotArea></c:chart></c:chartSpace>`),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/book1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/book1.xlsx": buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20),
+	}
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(path, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) != 1 || charts[0].ChartType != "bubble" {
+		t.Fatalf("unexpected ListCharts: %#v %v", charts, err)
+	}
+	extracted, err := doc.ExtractAllCharts()
+	if err != nil || len(extracted) != 0 {
+		t.Fatalf("expected the bubble chart to be skipped, got %#v %v", extracted, err)
+	}
+	alerts := doc.AlertsByCode("CHART_TYPE_UNSUPPORTED")
+	if len(alerts) != 1 || alerts[0].Context["chartType"] != "bubble" {
+		t.Fatalf("expected CHART_TYPE_UNSUPPORTED for bubble, got %#v", doc.Alerts())
+	}
+
+	plan, err := doc.Plan()
+	if err != nil || len(plan.Charts) != 1 || plan.Charts[0].Action != "unsupported" || plan.Charts[0].ReasonCode != "CHART_TYPE_UNSUPPORTED" {
+		t.Fatalf("unexpected plan: %#v %v", plan.Charts, err)
+	}
+	if len(plan.Alerts) != 1 || plan.Alerts[0].Context["chartType"] != "bubble" {
+		t.Fatalf("expected the plan alert to name the bubble type: %#v", plan.Alerts)
+	}
+}
@@ -0,0 +1,142 @@
+package pptx
+
+import (
+	"fmt"
+	"strings"
+
+	"why-pptx/internal/xlref"
+	"why-pptx/internal/xlsxembed"
+)
+
+// isGridRange reports whether startCell:endCell spans more than one row and
+// more than one column.
+func isGridRange(startCell, endCell string) bool {
+	bounds, err := xlref.RangeRef{StartCell: startCell, EndCell: endCell}.Bounds()
+	return err == nil && bounds.StartCol != bounds.EndCol && bounds.StartRow != bounds.EndRow
+}
+
+// collapseCategoryGrid turns a rectangular categories range, which holds
+// multi-level labels, into one label per point. Points run down the rows
+// unless the grid is wider than it is tall, as it is for series in rows. The
+// cells of a point are joined outer level first with a space, skipping blank
+// cells, so a grouped label reads "2024 H1" and the next one just "H2".
+func collapseCategoryGrid(grid xlsxembed.Grid) []string {
+	points, levels := grid.Rows, grid.Cols
+	at := grid.At
+	if grid.Cols > grid.Rows {
+		points, levels = grid.Cols, grid.Rows
+		at = func(point, level int) string { return grid.At(level, point) }
+	}
+
+	out := make([]string, points)
+	parts := make([]string, 0, levels)
+	for point := 0; point < points; point++ {
+		parts = parts[:0]
+		for level := 0; level < levels; level++ {
+			if value := strings.TrimSpace(at(point, level)); value != "" {
+				parts = append(parts, value)
+			}
+		}
+		out[point] = strings.Join(parts, " ")
+	}
+	return out
+}
+
+// splitCategoryGrid turns the rectangular range of a multi-level categories
+// formula into its levels, oriented as collapseCategoryGrid orients it. inner
+// holds the innermost level, one label per point; outer holds the other
+// levels, outermost first, with the cells as read, so a group's label sits at
+// its first point and the points that continue it are "".
+func splitCategoryGrid(grid xlsxembed.Grid) (inner []string, outer [][]string) {
+	points, levels := grid.Rows, grid.Cols
+	at := grid.At
+	if grid.Cols > grid.Rows {
+		points, levels = grid.Cols, grid.Rows
+		at = func(point, level int) string { return grid.At(level, point) }
+	}
+
+	all := make([][]string, levels)
+	for level := range all {
+		all[level] = make([]string, points)
+		for point := 0; point < points; point++ {
+			all[level][point] = at(point, level)
+		}
+	}
+	if levels == 0 {
+		return []string{}, nil
+	}
+	if levels > 1 {
+		outer = all[:levels-1]
+	}
+	return all[levels-1], outer
+}
+
+// readCategoryLevels reads the segments of a multi-level categories formula
+// and splits each with splitCategoryGrid, appending the points of later
+// segments of a union. Every segment must have as many levels as the first.
+func readCategoryLevels(wb *xlsxembed.Workbook, segments []ChartRange) ([]string, [][]string, error) {
+	var inner []string
+	var outer [][]string
+	for i, segment := range segments {
+		_ = wb.LoadSheetIndex(segment.Sheet)
+		grid, err := wb.GetRangeValues2D(segment.Sheet, segment.StartCell, segment.EndCell, xlsxembed.MissingNumericEmpty)
+		if err != nil {
+			return nil, nil, err
+		}
+		points, levels := splitCategoryGrid(grid)
+		if i == 0 {
+			inner, outer = points, levels
+			continue
+		}
+		if len(levels) != len(outer) {
+			return nil, nil, fmt.Errorf("union segment %s!%s:%s has %d category levels, want %d", segment.Sheet, segment.StartCell, segment.EndCell, len(levels)+1, len(outer)+1)
+		}
+		inner = append(inner, points...)
+		for level := range outer {
+			outer[level] = append(outer[level], levels[level]...)
+		}
+	}
+	return inner, outer, nil
+}
+
+// readChartRangeValues reads one chart range for extraction or cache sync. A
+// rectangular categories range is read with GetRangeValues2D and collapsed
+// by collapseCategoryGrid; every other range must be 1D. The sheet is
+// indexed on its first read, so the other ranges of a chart on it do not
+// scan it again. A sheet that cannot be indexed is read unindexed, which
+// reports the error.
+func readChartRangeValues(wb *xlsxembed.Workbook, categories bool, sheet, startCell, endCell string, policy xlsxembed.MissingNumericPolicy) ([]string, error) {
+	_ = wb.LoadSheetIndex(sheet)
+	if categories && isGridRange(startCell, endCell) {
+		grid, err := wb.GetRangeValues2D(sheet, startCell, endCell, policy)
+		if err != nil {
+			return nil, err
+		}
+		return collapseCategoryGrid(grid), nil
+	}
+	return wb.GetRangeValues(sheet, startCell, endCell, policy)
+}
+
+// validateExtractRanges is validatePlanRanges for the read paths, which also
+// accept rectangular categories ranges.
+func validateExtractRanges(ranges []Range) error {
+	for _, r := range ranges {
+		if r.Kind == RangeCategories && r.Orientation() == OrientationGrid {
+			continue
+		}
+		if _, err := r.Cells(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formulaHasGrid reports whether any segment of r's formula is rectangular.
+func formulaHasGrid(ranges []ChartRange, r ChartRange) bool {
+	for _, segment := range formulaSegments(ranges, r) {
+		if isGridRange(segment.StartCell, segment.EndCell) {
+			return true
+		}
+	}
+	return false
+}
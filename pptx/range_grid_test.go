@@ -0,0 +1,76 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"why-pptx/internal/xlsxembed"
+)
+
+func TestExtractCollapsesRectangularCategories(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_categories_2d.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	want := []string{"2024 H1", "H2", "2025 H1", "H2"}
+	if !reflect.DeepEqual(data.Labels, want) {
+		t.Fatalf("unexpected labels: %#v", data.Labels)
+	}
+	if len(data.Series) != 1 || !reflect.DeepEqual(data.Series[0].Data, []string{"10", "20", "30", "40"}) {
+		t.Fatalf("unexpected series: %#v", data.Series)
+	}
+
+	var streamed []string
+	err = doc.ExtractChartDataStream("ppt/charts/chart1.xml", func(series int, kind ChartRangeKind, index int, value string) error {
+		if kind == RangeCategories {
+			streamed = append(streamed, value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExtractChartDataStream: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, want) {
+		t.Fatalf("streamed labels %#v differ", streamed)
+	}
+}
+
+func TestSyncChartCachesCollapsesRectangularCategories(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_categories_2d.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	// A write cannot split a label into its levels, so Plan still skips it.
+	plan, err := doc.Plan()
+	if err == nil || len(plan.Charts) != 1 || plan.Charts[0].Action != "skip" {
+		t.Fatalf("expected the chart to be skipped for writes, got %#v (%v)", plan.Charts, err)
+	}
+
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || !results[0].Changed {
+		t.Fatalf("expected a sync, got %#v", results)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	cats, nums := extractChartCacheValues(t, readZipEntry(t, output, "ppt/charts/chart1.xml"))
+	if !reflect.DeepEqual(cats, []string{"2024 H1", "H2", "2025 H1", "H2"}) || len(nums) != 4 {
+		t.Fatalf("unexpected caches: %v %v", cats, nums)
+	}
+}
+
+func TestCollapseCategoryGridSeriesInRows(t *testing.T) {
+	grid := xlsxembed.Grid{Rows: 2, Cols: 3, Values: []string{"North", "", " South ", "Q1", "Q2", ""}}
+	if got := collapseCategoryGrid(grid); !reflect.DeepEqual(got, []string{"North Q1", "Q2", "South"}) {
+		t.Fatalf("unexpected labels: %#v", got)
+	}
+}
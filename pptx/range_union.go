@@ -0,0 +1,38 @@
+package pptx
+
+// formulaCell is one workbook cell behind a chart formula.
+type formulaCell struct {
+	sheet string
+	cell  string
+}
+
+// formulaSegments returns the ranges of the formula r belongs to, in
+// UnionIndex order. ranges may be nil, in which case r stands alone.
+func formulaSegments(ranges []ChartRange, r ChartRange) []ChartRange {
+	var segments []ChartRange
+	for _, candidate := range ranges {
+		if candidate.Kind == r.Kind && candidate.SeriesIndex == r.SeriesIndex {
+			segments = append(segments, candidate)
+		}
+	}
+	if len(segments) == 0 {
+		return []ChartRange{r}
+	}
+	return segments
+}
+
+// formulaCells expands every segment of r's formula and concatenates the
+// cells, so a union formula counts and fills as one range.
+func formulaCells(ranges []ChartRange, r ChartRange) ([]formulaCell, error) {
+	var out []formulaCell
+	for _, segment := range formulaSegments(ranges, r) {
+		cells, err := expandRangeCells(segment.StartCell, segment.EndCell)
+		if err != nil {
+			return nil, err
+		}
+		for _, cell := range cells {
+			out = append(out, formulaCell{sheet: segment.Sheet, cell: cell})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,111 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnionRangesExtractInOrder(t *testing.T) {
+	exercisesFeature(t, "ranges.union")
+
+	doc, err := OpenFile(fixturePath("bar_values_union.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	deps, err := doc.GetChartDependencies()
+	if err != nil {
+		t.Fatalf("GetChartDependencies: %v", err)
+	}
+	var values []ChartRange
+	for _, r := range deps[0].Ranges {
+		if r.Kind == RangeValues {
+			values = append(values, r)
+		}
+	}
+	if len(values) != 2 || values[0].UnionIndex != 0 || values[1].UnionIndex != 1 ||
+		values[1].StartCell != "B5" || values[1].Formula != "(Sheet1!$B$2:$B$3,Sheet1!$B$5:$B$6)" {
+		t.Fatalf("unexpected values ranges: %+v", values)
+	}
+	if deps[0].CellCount != 8 {
+		t.Fatalf("expected 8 cells, got %d", deps[0].CellCount)
+	}
+
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(data.Labels, []string{"North", "South", "East", "West"}) {
+		t.Fatalf("unexpected labels: %#v", data.Labels)
+	}
+	if len(data.Series) != 1 || !reflect.DeepEqual(data.Series[0].Data, []string{"10", "20", "40", "50"}) {
+		t.Fatalf("unexpected series: %#v", data.Series)
+	}
+
+	streamed := map[int]string{}
+	err = doc.ExtractChartDataStream("ppt/charts/chart1.xml", func(series int, kind ChartRangeKind, index int, value string) error {
+		if kind == RangeValues {
+			streamed[index] = value
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExtractChartDataStream: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, map[int]string{0: "10", 1: "20", 2: "40", 3: "50"}) {
+		t.Fatalf("unexpected streamed values: %#v", streamed)
+	}
+}
+
+func TestUnionRangesApplyAndSync(t *testing.T) {
+	inputPath := fixturePath("bar_values_union.pptx")
+	doc, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	err = doc.ApplyChartData(0, map[string][]string{
+		"categories": {"N", "S", "E"},
+		"values:0":   {"1", "2", "3"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "categories length mismatch: expected 4 got 3") {
+		t.Fatalf("expected a length error against the union total, got %v", err)
+	}
+
+	if err := doc.ApplyChartData(0, map[string][]string{
+		"categories": {"N", "S", "E", "W"},
+		"values:0":   {"1", "2", "3", "4"},
+	}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	sheet := readSheetFromXLSX(t, readEmbeddedWorkbook(t, output, "ppt/embeddings/embeddedWorkbook1.xlsx"), "xl/worksheets/sheet1.xml")
+	for cell, want := range map[string]string{"A3": "S", "B3": "2", "A4": "Subtotal", "B4": "30", "A5": "E", "B6": "4"} {
+		if _, val, ok := readCellFromSheet(sheet, cell); !ok || val != want {
+			t.Fatalf("unexpected %s: %q (ok=%v)", cell, val, ok)
+		}
+	}
+
+	chart := readZipEntry(t, output, "ppt/charts/chart1.xml")
+	cats, nums := extractChartCacheValues(t, chart)
+	if !reflect.DeepEqual(cats, []string{"N", "S", "E", "W"}) || !reflect.DeepEqual(nums, []string{"1", "2", "3", "4"}) {
+		t.Fatalf("caches not synced across the union: %v %v", cats, nums)
+	}
+	if strings.Count(string(chart), `val="4"`) != 2 {
+		t.Fatalf("expected ptCount 4 on both caches:\n%s", chart)
+	}
+
+	_, err = doc.PlanChanges(PlanRequest{Data: ChartDataInput{
+		"categories": {"N", "S", "E", "W", "X"},
+		"values:0":   {"1", "2", "3", "4", "5"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "categories length mismatch: expected 4 got 5") {
+		t.Fatalf("expected Plan to check lengths against the union total, got %v", err)
+	}
+}
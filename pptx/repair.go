@@ -0,0 +1,281 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"why-pptx/internal/chartcache"
+	"why-pptx/internal/chartdiscover"
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/postflight"
+)
+
+// ChartCacheRepair describes one series cache rebuilt by RepairChartCaches.
+type ChartCacheRepair struct {
+	SeriesIndex int
+	Kind        ChartRangeKind
+	// OldPtCount is the ptCount of the replaced cache, or -1 when it was
+	// missing or unreadable.
+	OldPtCount int
+	OldPoints  int
+	NewPoints  int
+	// IdxRenumbered reports that the replaced pt idx values were not 0..n-1.
+	IdxRenumbered bool
+	ValuesChanged bool
+	// Added reports that the reference had no cache and one was created.
+	Added bool
+	// FormatCode is the numCache formatCode carried over, if any.
+	FormatCode string
+}
+
+// ChartRepairReport lists the caches RepairChartCaches rebuilt for one chart.
+type ChartRepairReport struct {
+	ChartPath    string
+	SlidePath    string
+	WorkbookPath string
+	Caches       []ChartCacheRepair
+	// Changed reports whether any rebuilt cache differs from the one it
+	// replaced.
+	Changed bool
+}
+
+// RepairChartCaches rebuilds the caches of the given charts, or of every
+// embedded chart when no path is given, from their workbook values. Unlike
+// SyncChartCaches it never trusts the existing points: each strCache/numCache
+// is replaced outright, so ptCount and idx corruption written by other tools
+// is fixed. It runs even when Options.Chart.CacheSync is disabled.
+//
+// BestEffort skips charts that cannot be repaired, with the usual alert codes.
+// Strict still repairs but returns the first failure as an error.
+func (d *Document) RepairChartCaches(chartPaths ...string) ([]ChartRepairReport, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+
+	targets, err := call.repairTargets(chartPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]ChartRepairReport, 0, len(targets))
+	for _, chart := range targets {
+		dep, ok, err := call.chartDependencies(chart)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if err := call.validateWritableChart(dep); err != nil {
+			if call.mode() == BestEffort {
+				continue
+			}
+			return nil, err
+		}
+
+		var report *ChartRepairReport
+		err = recoverChart("repairChartCaches", dep.ChartPath, func() error {
+			var err error
+			report, err = call.repairChartCache(dep)
+			return err
+		})
+		var panicErr *ChartPanicError
+		if errors.As(err, &panicErr) {
+			if err := call.handleChartPanic(panicErr, dep.SlidePath, dep.WorkbookPath); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			if postflight.IsPostflightError(err) {
+				return nil, err
+			}
+			if err := call.handleChartCacheError(dep, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if report != nil {
+			reports = append(reports, *report)
+		}
+	}
+	return reports, nil
+}
+
+// repairTargets resolves chartPaths against the discovered charts, in the
+// requested order. Ineligible charts are reported like extraction does.
+func (d *docCall) repairTargets(chartPaths []string) ([]EmbeddedChart, error) {
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]EmbeddedChart, len(embedded))
+	all := make([]EmbeddedChart, 0, len(embedded))
+	for _, item := range embedded {
+		chart := embeddedChartFrom(item)
+		byPath[chart.ChartPath] = chart
+		all = append(all, chart)
+	}
+	skippedByPath := make(map[string]chartdiscover.SkippedChart, len(skipped))
+	for _, skip := range skipped {
+		skippedByPath[skip.ChartPath] = skip
+	}
+
+	if len(chartPaths) == 0 {
+		for _, skip := range skipped {
+			if err := d.handleTargetSkip(skip, "cache repair"); err != nil {
+				return nil, err
+			}
+		}
+		return all, nil
+	}
+
+	targets := make([]EmbeddedChart, 0, len(chartPaths))
+	seen := make(map[string]struct{}, len(chartPaths))
+	for _, chartPath := range chartPaths {
+		if _, ok := seen[chartPath]; ok {
+			continue
+		}
+		seen[chartPath] = struct{}{}
+
+		if chart, ok := byPath[chartPath]; ok {
+			targets = append(targets, chart)
+			continue
+		}
+		if skip, ok := skippedByPath[chartPath]; ok {
+			if err := d.handleTargetSkip(skip, "cache repair"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, fmt.Errorf("chart %q not found", chartPath)
+	}
+	return targets, nil
+}
+
+// handleTargetSkip reports an ineligible chart named by a caller; verb names
+// the operation in the error.
+func (d *docCall) handleTargetSkip(skip chartdiscover.SkippedChart, verb string) error {
+	err := d.handleExtractError(skipExtractIssue(skip, verb))
+	if d.mode() == BestEffort {
+		return nil
+	}
+	return err
+}
+
+// repairChartCache rebuilds one chart's caches in a staged overlay. The report
+// is nil when a BestEffort timeout abandoned the chart.
+func (d *docCall) repairChartCache(dep ChartDependencies) (*ChartRepairReport, error) {
+	ctx := d.validateContext(dep)
+	ctx.CacheSyncEnabled = true
+
+	rewrite := func(chartXML []byte, deps chartcache.Dependencies, provider chartcache.ValueProvider) ([]byte, []chartcache.CacheRepair, error) {
+		return chartcache.RepairCaches(chartXML, deps, provider, d.cancel)
+	}
+
+	// Guarding here rather than relying on withChartStage lets a BestEffort
+	// timeout be told apart from a committed repair.
+	var repairs []chartRepairRecord
+	err := d.guardChart("repair", dep.SlidePath, dep.ChartPath, dep.WorkbookPath, func() error {
+		return d.withChartStage(ctx, func(stage overlaystage.Overlay) error {
+			var err error
+			if dep.ChartType == "mixed" {
+				repairs, err = d.rewriteMixedChartCacheInOverlay(stage, dep, rewrite)
+			} else {
+				repairs, err = d.rewriteChartCacheInOverlay(stage, dep, rewrite)
+			}
+			if err != nil {
+				return err
+			}
+			if !recordsChanged(repairs) {
+				return nil
+			}
+			points := 0
+			for _, record := range repairs {
+				points += record.cache.NewPoints
+			}
+			d.manifest.stage(chartChange("repairChartCaches", dep, dependencyFormulas(dep), points))
+			return nil
+		})
+	})
+	if errors.Is(err, ErrChartProcessingTimeout) && d.mode() == BestEffort {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seriesIndex, err := d.repairSeriesIndexer(dep)
+	if err != nil {
+		return nil, err
+	}
+	report := &ChartRepairReport{
+		ChartPath:    dep.ChartPath,
+		SlidePath:    dep.SlidePath,
+		WorkbookPath: dep.WorkbookPath,
+		Caches:       make([]ChartCacheRepair, 0, len(repairs)),
+	}
+	for _, record := range repairs {
+		cache := record.cache
+		report.Caches = append(report.Caches, ChartCacheRepair{
+			SeriesIndex:   seriesIndex(record.plotType, cache.SeriesIndex),
+			Kind:          ChartRangeKind(cache.Kind),
+			OldPtCount:    cache.OldPtCount,
+			OldPoints:     cache.OldPoints,
+			NewPoints:     cache.NewPoints,
+			IdxRenumbered: cache.IdxRenumbered,
+			ValuesChanged: cache.ValuesChanged,
+			Added:         cache.Added,
+			FormatCode:    cache.FormatCode,
+		})
+		if cache.Changed() {
+			report.Changed = true
+		}
+	}
+	sort.SliceStable(report.Caches, func(i, j int) bool {
+		return report.Caches[i].SeriesIndex < report.Caches[j].SeriesIndex
+	})
+	return report, nil
+}
+
+type chartRepairRecord struct {
+	plotType string
+	cache    chartcache.CacheRepair
+}
+
+func recordsChanged(records []chartRepairRecord) bool {
+	for _, record := range records {
+		if record.cache.Changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// repairSeriesIndexer maps the plot-local series index reported by
+// chartcache back to the chart-wide index. Only mixed charts differ.
+func (d *docCall) repairSeriesIndexer(dep ChartDependencies) (func(plotType string, plotIndex int) int, error) {
+	if dep.ChartType != "mixed" {
+		return func(_ string, plotIndex int) int { return plotIndex }, nil
+	}
+	mixedDeps, _, err := d.mixedWriteDependencies(dep)
+	if err != nil {
+		return nil, err
+	}
+	byPlot := make(map[string]map[int]int)
+	for _, series := range mixedDeps.Series {
+		if byPlot[series.PlotType] == nil {
+			byPlot[series.PlotType] = make(map[int]int)
+		}
+		byPlot[series.PlotType][series.PlotIndex] = series.SeriesIndex
+	}
+	return func(plotType string, plotIndex int) int {
+		if index, ok := byPlot[plotType][plotIndex]; ok {
+			return index
+		}
+		return plotIndex
+	}, nil
+}
@@ -0,0 +1,325 @@
+package pptx
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/postflight"
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+// corruptPtCountChart mirrors the postflight ptCount-mismatch fixture: the
+// value cache declares two points but holds one.
+const corruptPtCountChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Cat1</c:v></c:pt><c:pt idx="1"><c:v>Cat2</c:v></c:pt></c:strCache></c:strRef></c:cat>
+          <c:val>
+            <c:numRef>
+              <c:f>Sheet1!$B$2:$B$3</c:f>
+              <c:numCache>
+                <c:formatCode>0.00</c:formatCode>
+                <c:ptCount val="2"/>
+                <c:pt idx="0"><c:v>1</c:v></c:pt>
+              </c:numCache>
+            </c:numRef>
+          </c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+// corruptIdxGapChart mirrors the postflight idx-gap fixture.
+const corruptIdxGapChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Cat1</c:v></c:pt><c:pt idx="1"><c:v>Cat2</c:v></c:pt></c:strCache></c:strRef></c:cat>
+          <c:val>
+            <c:numRef>
+              <c:f>Sheet1!$B$2:$B$3</c:f>
+              <c:numCache>
+                <c:ptCount val="2"/>
+                <c:pt idx="0"><c:v>10</c:v></c:pt>
+                <c:pt idx="2"><c:v>20</c:v></c:pt>
+              </c:numCache>
+            </c:numRef>
+          </c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+func TestRepairChartCachesPtCountMismatch(t *testing.T) {
+	exercisesFeature(t, "cache.repair")
+
+	path := writeRepairDeck(t, t.TempDir(), corruptPtCountChart, nil)
+	assertChartFailsPostflight(t, path, "ppt/charts/chart1.xml")
+
+	opts := DefaultOptions()
+	opts.Chart.CacheSync = false
+	doc, err := OpenFile(path, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	reports, err := doc.RepairChartCaches("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("RepairChartCaches: %v", err)
+	}
+	if len(reports) != 1 || !reports[0].Changed || len(reports[0].Caches) != 2 {
+		t.Fatalf("unexpected reports: %#v", reports)
+	}
+	cat, val := reports[0].Caches[0], reports[0].Caches[1]
+	if cat.Kind != RangeCategories || val.Kind != RangeValues {
+		t.Fatalf("unexpected cache kinds: %#v", reports[0].Caches)
+	}
+	if cat.ValuesChanged || cat.IdxRenumbered || cat.OldPtCount != 2 {
+		t.Fatalf("expected category cache to be rebuilt unchanged, got %#v", cat)
+	}
+	if val.OldPtCount != 2 || val.OldPoints != 1 || val.NewPoints != 2 || !val.ValuesChanged || val.FormatCode != "0.00" {
+		t.Fatalf("unexpected value cache repair: %#v", val)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	chartXML := readZipEntry(t, outputPath, "ppt/charts/chart1.xml")
+	cats, vals := extractChartCacheValues(t, chartXML)
+	if strings.Join(cats, ",") != "Cat1,Cat2" || strings.Join(vals, ",") != "10,20" {
+		t.Fatalf("unexpected repaired caches: cats=%v vals=%v", cats, vals)
+	}
+	if !bytes.Contains(chartXML, []byte(">0.00</formatCode>")) {
+		t.Fatalf("expected formatCode to be preserved:\n%s", chartXML)
+	}
+	assertChartPassesPostflight(t, outputPath, "ppt/charts/chart1.xml")
+}
+
+func TestRepairChartCachesIdxGap(t *testing.T) {
+	path := writeRepairDeck(t, t.TempDir(), corruptIdxGapChart, nil)
+	assertChartFailsPostflight(t, path, "ppt/charts/chart1.xml")
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	reports, err := doc.RepairChartCaches()
+	if err != nil {
+		t.Fatalf("RepairChartCaches: %v", err)
+	}
+	if len(reports) != 1 || len(reports[0].Caches) != 2 {
+		t.Fatalf("unexpected reports: %#v", reports)
+	}
+	val := reports[0].Caches[1]
+	if !val.IdxRenumbered || val.ValuesChanged || val.OldPoints != 2 || val.NewPoints != 2 {
+		t.Fatalf("unexpected value cache repair: %#v", val)
+	}
+	if !reports[0].Changed {
+		t.Fatalf("expected idx renumbering to count as a change")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	assertChartPassesPostflight(t, outputPath, "ppt/charts/chart1.xml")
+}
+
+func TestRepairChartCachesUnreadableWorkbook(t *testing.T) {
+	broken := []byte("not a zip")
+
+	t.Run("BestEffort", func(t *testing.T) {
+		path := writeRepairDeck(t, t.TempDir(), corruptIdxGapChart, broken)
+		doc, err := OpenFile(path, WithErrorMode(BestEffort))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		reports, err := doc.RepairChartCaches()
+		if err != nil {
+			t.Fatalf("RepairChartCaches: %v", err)
+		}
+		if len(reports) != 1 || reports[0].ChartPath != "ppt/charts/chart1.xml" {
+			t.Fatalf("expected only chart1 to be repaired, got %#v", reports)
+		}
+		alerts := doc.AlertsByCode("CHART_CACHE_SYNC_FAILED")
+		if len(alerts) != 1 || alerts[0].Context["chart"] != "ppt/charts/chart2.xml" {
+			t.Fatalf("expected cache sync failure for chart2, got %#v", doc.Alerts())
+		}
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		path := writeRepairDeck(t, t.TempDir(), corruptIdxGapChart, broken)
+		doc, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		if _, err := doc.RepairChartCaches(); err == nil {
+			t.Fatalf("expected strict repair to fail on unreadable workbook")
+		}
+	})
+}
+
+func TestRepairChartCachesUnknownChart(t *testing.T) {
+	path := writeRepairDeck(t, t.TempDir(), corruptIdxGapChart, nil)
+	doc, err := OpenFile(path, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.RepairChartCaches("ppt/charts/chart9.xml"); err == nil {
+		t.Fatalf("expected error for unknown chart")
+	}
+}
+
+// hiddenLegendChart has two series over the same range, the second of them
+// with a stale value cache and its legend entry deleted.
+const hiddenLegendChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:idx val="0"/><c:order val="0"/>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Cat1</c:v></c:pt><c:pt idx="1"><c:v>Cat2</c:v></c:pt></c:strCache></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:ptCount val="2"/><c:pt idx="0"><c:v>10</c:v></c:pt><c:pt idx="1"><c:v>20</c:v></c:pt></c:numCache></c:numRef></c:val>
+        </c:ser>
+        <c:ser>
+          <c:idx val="1"/><c:order val="1"/>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Cat1</c:v></c:pt><c:pt idx="1"><c:v>Cat2</c:v></c:pt></c:strCache></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$3</c:f><c:numCache><c:ptCount val="2"/><c:pt idx="0"><c:v>10</c:v></c:pt><c:pt idx="2"><c:v>20</c:v></c:pt></c:numCache></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+    <c:legend>
+      <c:legendPos val="r"/>
+      <c:legendEntry><c:idx val="1"/><c:delete val="1"/></c:legendEntry>
+    </c:legend>
+  </c:chart>
+</c:chartSpace>`
+
+func TestRepairChartCachesKeepsHiddenLegendEntries(t *testing.T) {
+	path := writeRepairDeck(t, t.TempDir(), hiddenLegendChart, nil)
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil {
+		t.Fatalf("ListCharts: %v", err)
+	}
+	if len(charts) != 1 || !reflect.DeepEqual(charts[0].HiddenLegendEntries, map[int]bool{1: true}) {
+		t.Fatalf("unexpected hidden legend entries: %#v", charts)
+	}
+	reports, err := doc.RepairChartCaches()
+	if err != nil {
+		t.Fatalf("RepairChartCaches: %v", err)
+	}
+	if len(reports) != 1 || !reports[0].Changed {
+		t.Fatalf("expected the stale cache to be repaired, got %#v", reports)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	snap, err := pptxassert.ExtractChartCacheSnapshot(readZipEntry(t, outputPath, "ppt/charts/chart1.xml"))
+	if err != nil {
+		t.Fatalf("ExtractChartCacheSnapshot: %v", err)
+	}
+	if !reflect.DeepEqual(snap.HiddenLegendEntries, []int{1}) {
+		t.Fatalf("repair changed the hidden legend entries: %v", snap.HiddenLegendEntries)
+	}
+	pptxassert.AssertCacheMatchesExpected(t, snap, pptxassert.ExpectedCache{Series: []pptxassert.ExpectedCacheSeries{
+		{Kind: "strCache", SeriesIndex: 0, Values: []string{"Cat1", "Cat2"}},
+		{Kind: "numCache", SeriesIndex: 0, Values: []string{"10", "20"}},
+		{Kind: "strCache", SeriesIndex: 1, Values: []string{"Cat1", "Cat2"}},
+		{Kind: "numCache", SeriesIndex: 1, Values: []string{"10", "20"}},
+	}})
+
+	reopened, err := OpenFile(outputPath)
+	if err != nil {
+		t.Fatalf("OpenFile output: %v", err)
+	}
+	charts, err = reopened.ListCharts()
+	if err != nil {
+		t.Fatalf("ListCharts output: %v", err)
+	}
+	if !reflect.DeepEqual(charts[0].HiddenLegendEntries, map[int]bool{1: true}) {
+		t.Fatalf("unexpected hidden legend entries after repair: %#v", charts[0].HiddenLegendEntries)
+	}
+}
+
+// writeRepairDeck builds a deck with chart1 holding chart1XML. When
+// secondWorkbook is set, a chart2 backed by those workbook bytes is added.
+func writeRepairDeck(t *testing.T, dir, chart1XML string, secondWorkbook []byte) string {
+	t.Helper()
+
+	slideRels := `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>`
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte("<slide/>"),
+		"ppt/charts/chart1.xml": []byte(chart1XML),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20),
+	}
+	if secondWorkbook != nil {
+		slideRels += `
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart2.xml"/>`
+		parts["ppt/charts/chart2.xml"] = []byte(corruptIdxGapChart)
+		parts["ppt/charts/_rels/chart2.xml.rels"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook2.xlsx"/>
+</Relationships>`)
+		parts["ppt/embeddings/embeddedWorkbook2.xlsx"] = secondWorkbook
+	}
+	parts["ppt/slides/_rels/slide1.xml.rels"] = []byte(slideRels + "\n</Relationships>")
+
+	path := filepath.Join(dir, "repair.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func validateChartAsStaged(t *testing.T, path, chartPath string) error {
+	t.Helper()
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data := readZipEntry(t, path, chartPath)
+	ctx := postflight.ValidateContext{ChartPath: chartPath, Mode: postflight.ModeStrict, CacheSyncEnabled: true}
+	return doc.newCall(nil).withChartStage(ctx, func(stage overlaystage.Overlay) error {
+		return stage.Set(chartPath, data)
+	})
+}
+
+func assertChartPassesPostflight(t *testing.T, path, chartPath string) {
+	t.Helper()
+	if err := validateChartAsStaged(t, path, chartPath); err != nil {
+		t.Fatalf("expected %s to pass postflight, got %v", chartPath, err)
+	}
+}
+
+func assertChartFailsPostflight(t *testing.T, path, chartPath string) {
+	t.Helper()
+	if err := validateChartAsStaged(t, path, chartPath); !postflight.IsPostflightError(err) {
+		t.Fatalf("expected %s to fail postflight, got %v", chartPath, err)
+	}
+}
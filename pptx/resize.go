@@ -0,0 +1,167 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/chartxml"
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/xlref"
+)
+
+// chartResize is a change of a chart's categories and values ranges to a
+// new number of points, planned by resizeChartRanges for
+// Chart.AllowResize.
+type chartResize struct {
+	// ranges are the chart's ranges with the categories and values
+	// formulas rewritten to the new extent.
+	ranges []ChartRange
+	// formulas maps each old categories or values formula to its new text.
+	formulas map[string]string
+	// cleared lists the cells a shrunk range no longer covers.
+	cleared []formulaCell
+}
+
+// resizeChartRanges plans the resize of ranges to the lengths in data. It
+// returns nil when every categories and values range already has as many
+// cells as data has points. All of them must take the same new length, and
+// only plain single row or column formulas can be resized: union formulas,
+// rectangular categories, and charts with data label ranges are refused, and
+// so is a partial update that leaves out the categories or a series.
+func resizeChartRanges(ranges []ChartRange, data map[string][]string, valueKeys map[int]string) (*chartResize, error) {
+	points := -1
+	if categories, ok := data["categories"]; ok {
+		points = len(categories)
+	}
+	changed := false
+	across := false
+	for _, r := range ranges {
+		if r.Kind != RangeCategories && r.Kind != RangeValues {
+			continue
+		}
+		length := points
+		if r.Kind == RangeValues {
+			values, ok := data[valueKeys[r.SeriesIndex]]
+			if !ok {
+				continue
+			}
+			length = len(values)
+		}
+		if length < 0 {
+			continue
+		}
+		if points < 0 {
+			points = length
+		}
+		if length != points {
+			return nil, fmt.Errorf("resize needs one length for every range: series %d has %d values, expected %d", r.SeriesIndex, length, points)
+		}
+		cells, err := formulaCells(ranges, r)
+		if err != nil {
+			return nil, err
+		}
+		if len(cells) != points {
+			changed = true
+		}
+		if r.StartCell != r.EndCell {
+			if startCol, _, _, err := xlref.SplitCellRef(r.StartCell); err == nil {
+				if endCol, _, _, err := xlref.SplitCellRef(r.EndCell); err == nil {
+					across = startCol != endCol
+				}
+			}
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	if points < 1 {
+		return nil, fmt.Errorf("resize needs at least one point")
+	}
+
+	resize := &chartResize{ranges: make([]ChartRange, len(ranges)), formulas: make(map[string]string)}
+	copy(resize.ranges, ranges)
+	for i, r := range ranges {
+		switch r.Kind {
+		case RangeDataLabels:
+			return nil, fmt.Errorf("resize is not supported for charts with data label ranges (series %d)", r.SeriesIndex)
+		case RangeCategories:
+			if _, ok := data["categories"]; !ok {
+				return nil, fmt.Errorf("resize needs the categories")
+			}
+		case RangeValues:
+			if _, ok := data[valueKeys[r.SeriesIndex]]; !ok {
+				return nil, fmt.Errorf("resize needs values for every series: series %d is missing", r.SeriesIndex)
+			}
+		default:
+			continue
+		}
+		if r.UnionIndex > 0 || len(formulaSegments(ranges, r)) > 1 {
+			return nil, fmt.Errorf("resize is not supported for union formula %q", r.Formula)
+		}
+
+		formula, err := xlref.ResizeA1Range(r.Formula, points, across)
+		if err != nil {
+			return nil, fmt.Errorf("resize %s formula %q: %w", r.Kind, r.Formula, err)
+		}
+		ref, err := xlref.ParseA1Range(formula)
+		if err != nil {
+			return nil, fmt.Errorf("resize %s formula %q: %w", r.Kind, r.Formula, err)
+		}
+		if _, seen := resize.formulas[r.Formula]; !seen {
+			cells, err := formulaCells(nil, r)
+			if err != nil {
+				return nil, err
+			}
+			if len(cells) > points {
+				resize.cleared = append(resize.cleared, cells[points:]...)
+			}
+			resize.formulas[r.Formula] = formula
+		}
+		resize.ranges[i].StartCell = ref.StartCell
+		resize.ranges[i].EndCell = ref.EndCell
+		resize.ranges[i].Formula = formula
+	}
+	return resize, nil
+}
+
+// resizeChartInOverlay clears the cells resize gives up and rewrites the
+// chart's formulas. The cells it grows into are written by the caller.
+func (d *docCall) resizeChartInOverlay(overlay overlaystage.Overlay, dep ChartDependencies, resize *chartResize) error {
+	if len(resize.cleared) > 0 {
+		data, err := overlay.Get(dep.WorkbookPath)
+		if err != nil {
+			return fmt.Errorf("read workbook %q: %w", dep.WorkbookPath, err)
+		}
+		wb, err := d.openEmbeddedWorkbook(dep.WorkbookPath, data)
+		if err != nil {
+			return fmt.Errorf("open workbook %q: %w", dep.WorkbookPath, err)
+		}
+		for _, cell := range resize.cleared {
+			if err := wb.ClearCell(cell.sheet, cell.cell); err != nil {
+				return fmt.Errorf("clear %s!%s: %w", cell.sheet, cell.cell, err)
+			}
+		}
+		updated, err := d.saveWorkbook(wb)
+		if err != nil {
+			return fmt.Errorf("save workbook %q: %w", dep.WorkbookPath, err)
+		}
+		if err := d.checkWorkbookSize(dep.WorkbookPath, updated); err != nil {
+			return err
+		}
+		if err := overlay.Set(dep.WorkbookPath, updated); err != nil {
+			return fmt.Errorf("write workbook %q: %w", dep.WorkbookPath, err)
+		}
+	}
+
+	chartXML, err := overlay.Get(dep.ChartPath)
+	if err != nil {
+		return fmt.Errorf("read chart %q: %w", dep.ChartPath, err)
+	}
+	updated, err := chartxml.RewriteSeriesFormulas(chartXML, resize.formulas)
+	if err != nil {
+		return fmt.Errorf("rewrite chart %q: %w", dep.ChartPath, err)
+	}
+	if err := overlay.Set(dep.ChartPath, updated); err != nil {
+		return fmt.Errorf("write chart %q: %w", dep.ChartPath, err)
+	}
+	return nil
+}
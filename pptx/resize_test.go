@@ -0,0 +1,166 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// chartFormulaPattern matches c:f elements whether or not a cache sync
+// re-encoded them without the prefix.
+var chartFormulaPattern = regexp.MustCompile(`<(?:c:)?f(?: [^>]*)?>([^<]*)</(?:c:)?f>`)
+
+func chartFormulas(t *testing.T, chartXML []byte) []string {
+	t.Helper()
+	var out []string
+	for _, match := range chartFormulaPattern.FindAllSubmatch(chartXML, -1) {
+		out = append(out, string(match[1]))
+	}
+	return out
+}
+
+func resizeOptions() Options {
+	opts := DefaultOptions()
+	opts.Chart.AllowResize = true
+	return opts
+}
+
+func TestApplyChartDataResizeGrows(t *testing.T) {
+	exercisesFeature(t, "apply.resize")
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(resizeOptions()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data := map[string][]string{
+		"categories": {"Jan", "Feb", "Mar", "Apr"},
+		"values:0":   {"1", "2", "3", "4"},
+	}
+	if err := doc.ApplyChartData(0, data); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+
+	chartXML := readZipEntry(t, output, "ppt/charts/chart1.xml")
+	if got := chartFormulas(t, chartXML); !reflect.DeepEqual(got, []string{"Sheet1!$A$2:$A$5", "Sheet1!$B$2:$B$5"}) {
+		t.Fatalf("unexpected formulas: %#v", got)
+	}
+	cats, vals := extractChartCacheValues(t, chartXML)
+	if !reflect.DeepEqual(cats, data["categories"]) || !reflect.DeepEqual(vals, data["values:0"]) {
+		t.Fatalf("caches not regenerated: %#v %#v", cats, vals)
+	}
+	if counts := regexp.MustCompile(`ptCount[^>]* val="(\d+)"`).FindAllSubmatch(chartXML, -1); len(counts) != 2 || string(counts[0][1]) != "4" || string(counts[1][1]) != "4" {
+		t.Fatalf("expected ptCount 4 in both caches:\n%s", chartXML)
+	}
+
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	extracted, err := saved.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(extracted.Labels, data["categories"]) || !reflect.DeepEqual(extracted.Series[0].Data, data["values:0"]) {
+		t.Fatalf("round trip mismatch: %#v", extracted)
+	}
+}
+
+func TestApplyChartDataResizeShrinks(t *testing.T) {
+	doc, err := OpenFile(fixturePath("line_multi_series_embedded.pptx"), WithOptions(resizeOptions()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data := map[string][]string{
+		"categories": {"H1", "H2"},
+		"values:0":   {"10", "20"},
+		"values:1":   {"30", "40"},
+	}
+	if err := doc.ApplyChartData(0, data); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	chartXML := readZipEntry(t, output, "ppt/charts/chart1.xml")
+	want := []string{"Sheet1!$A$2:$A$3", "Sheet1!$B$2:$B$3", "Sheet1!$A$2:$A$3", "Sheet1!$C$2:$C$3"}
+	if got := chartFormulas(t, chartXML); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected formulas: %#v", got)
+	}
+	if _, vals := extractChartCacheValues(t, chartXML); !reflect.DeepEqual(vals, []string{"10", "20", "30", "40"}) {
+		t.Fatalf("caches not regenerated: %#v", vals)
+	}
+
+	sheet := readSheetFromXLSX(t, readEmbeddedWorkbook(t, output, "ppt/embeddings/embeddedWorkbook1.xlsx"), "xl/worksheets/sheet1.xml")
+	for _, ref := range []string{"A4", "B4", "C4"} {
+		if cellType, value, ok := readCellFromSheet(sheet, ref); ok && (cellType != "" || value != "") {
+			t.Fatalf("expected %s to be cleared, got %q %q", ref, cellType, value)
+		}
+	}
+}
+
+func TestApplyChartDataResizeRequiresOption(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.ApplyChartData(0, map[string][]string{
+		"categories": {"Jan", "Feb", "Mar"},
+		"values:0":   {"1", "2", "3"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "length mismatch") {
+		t.Fatalf("expected a length mismatch without AllowResize, got %v", err)
+	}
+}
+
+func TestApplyChartDataResizeRefusesUnionFormulas(t *testing.T) {
+	input := fixturePath("bar_values_union.pptx")
+	doc, err := OpenFile(input, WithOptions(resizeOptions()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	deps, err := doc.GetChartDependencies()
+	if err != nil {
+		t.Fatalf("GetChartDependencies: %v", err)
+	}
+	cells, err := formulaCells(deps[0].Ranges, deps[0].Ranges[len(deps[0].Ranges)-1])
+	if err != nil {
+		t.Fatalf("formulaCells: %v", err)
+	}
+	data := map[string][]string{"categories": make([]string, len(cells)+1), "values:0": make([]string, len(cells)+1)}
+	for i := range data["values:0"] {
+		data["categories"][i] = "C"
+		data["values:0"][i] = "1"
+	}
+	err = doc.ApplyChartData(0, data)
+	if err == nil || !strings.Contains(err.Error(), "union formula") {
+		t.Fatalf("expected union formulas to be refused, got %v", err)
+	}
+}
+
+func TestPlanChangesAllowsResize(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(resizeOptions()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	plan, err := doc.PlanChanges(PlanRequest{Data: ChartDataInput{
+		"categories": {"Jan", "Feb", "Mar"},
+		"values:0":   {"1", "2", "3"},
+	}})
+	if err != nil {
+		t.Fatalf("PlanChanges: %v", err)
+	}
+	if len(plan.Charts) != 1 || plan.Charts[0].Action == "skip" {
+		t.Fatalf("unexpected plan: %#v", plan)
+	}
+}
@@ -0,0 +1,120 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeterministicOutputSavesIdenticalBytes(t *testing.T) {
+	exercisesFeature(t, "save.deterministic")
+
+	opts := DefaultOptions()
+	opts.Output = OutputOptions{CompressionLevel: CompressionBest, Deterministic: true}
+
+	save := func() [sha256.Size]byte {
+		doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{
+			"categories": {"NewA", "NewB"},
+			"values:0":   {"100", "200"},
+		}); err != nil {
+			t.Fatalf("ApplyChartDataByPath: %v", err)
+		}
+		for _, name := range []string{"customXml/item9.xml", "customXml/item1.xml", "customXml/item5.xml"} {
+			doc.pkg.WritePart(name, []byte("<root/>"))
+		}
+
+		output := filepath.Join(t.TempDir(), "output.pptx")
+		if err := doc.SaveFile(output); err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("zip.NewReader: %v", err)
+		}
+		var added []string
+		for _, file := range reader.File {
+			if filepath.Dir(file.Name) == "customXml" {
+				added = append(added, file.Name)
+			}
+		}
+		if len(added) != 3 || added[0] != "customXml/item1.xml" || added[2] != "customXml/item9.xml" {
+			t.Fatalf("expected new parts in name order, got %v", added)
+		}
+
+		workbook := readEmbeddedWorkbook(t, output, rangeWorkbookPath)
+		wbReader, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+		if err != nil {
+			t.Fatalf("open embedded workbook: %v", err)
+		}
+		for _, file := range wbReader.File {
+			if file.Name == "xl/worksheets/sheet1.xml" && (file.ModifiedDate != dosEpoch || file.ModifiedTime != 0 || file.Flags&^0x800 != 0) {
+				t.Fatalf("rewritten sheet header not fixed: date=%d time=%d flags=%#x", file.ModifiedDate, file.ModifiedTime, file.Flags)
+			}
+		}
+		return sha256.Sum256(data)
+	}
+
+	if first, second := save(), save(); first != second {
+		t.Fatalf("deterministic saves differ: %x != %x", first, second)
+	}
+}
+
+// dosEpoch is 1980-01-01 as an MS-DOS date.
+const dosEpoch = 1<<5 | 1
+
+func TestOutputCompressionLevel(t *testing.T) {
+	chartSize := func(level CompressionLevel) (uint16, uint64) {
+		opts := DefaultOptions()
+		opts.Output.CompressionLevel = level
+		doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{
+			"categories": {"NewA", "NewB"},
+			"values:0":   {"100", "200"},
+		}); err != nil {
+			t.Fatalf("ApplyChartDataByPath: %v", err)
+		}
+		output := filepath.Join(t.TempDir(), "output.pptx")
+		if err := doc.SaveFile(output); err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		reader, err := zip.OpenReader(output)
+		if err != nil {
+			t.Fatalf("zip.OpenReader: %v", err)
+		}
+		defer reader.Close()
+		for _, file := range reader.File {
+			if file.Name == "ppt/charts/chart1.xml" {
+				return file.Method, file.CompressedSize64
+			}
+		}
+		t.Fatalf("chart part missing from output")
+		return 0, 0
+	}
+
+	storeMethod, storeSize := chartSize(CompressionStore)
+	if storeMethod != zip.Store {
+		t.Fatalf("expected CompressionStore to store the chart, got method %d", storeMethod)
+	}
+	defaultMethod, defaultSize := chartSize(CompressionDefault)
+	if defaultMethod != zip.Deflate || defaultSize >= storeSize {
+		t.Fatalf("expected the default level to deflate the chart: method %d, %d >= %d bytes", defaultMethod, defaultSize, storeSize)
+	}
+	if _, fastSize := chartSize(CompressionFast); fastSize >= storeSize {
+		t.Fatalf("expected CompressionFast to deflate the chart: %d >= %d bytes", fastSize, storeSize)
+	}
+}
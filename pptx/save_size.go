@@ -0,0 +1,64 @@
+package pptx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/ooxmlpkg"
+)
+
+// outputSizeReportParts is how many of the largest parts
+// SAVE_OUTPUT_SIZE_EXCEEDED lists.
+const outputSizeReportParts = 10
+
+// checkOutputSize reports SAVE_OUTPUT_SIZE_EXCEEDED when a save wrote more
+// than Options.Save.MaxOutputBytes. path is the file SaveFile wrote, or
+// empty for Save and SaveTo. The limit is soft: the output is kept in both
+// modes.
+func (d *Document) checkOutputSize(path string, size int64) {
+	limit := d.opts.Save.MaxOutputBytes
+	if limit <= 0 || size <= limit {
+		return
+	}
+	parts, err := d.pkg.ListPartsWithInfo()
+	if err != nil {
+		return
+	}
+	ctx := map[string]string{
+		"outputBytes":    strconv.FormatInt(size, 10),
+		"maxOutputBytes": strconv.FormatInt(limit, 10),
+		"largestParts":   formatLargestParts(parts, outputSizeReportParts),
+	}
+	if path != "" {
+		ctx["path"] = path
+	}
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "SAVE_OUTPUT_SIZE_EXCEEDED",
+		Message: "Saved package is larger than Options.Save.MaxOutputBytes; see largestParts",
+		Context: ctx,
+	})
+}
+
+// formatLargestParts lists the n largest parts by uncompressed size as
+// "name=size(+growth)" entries separated by ";", where growth is measured
+// against the part's size when the package was opened.
+func formatLargestParts(parts []ooxmlpkg.PartInfo, n int) string {
+	sorted := append([]ooxmlpkg.PartInfo(nil), parts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Size != sorted[j].Size {
+			return sorted[i].Size > sorted[j].Size
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	entries := make([]string, 0, len(sorted))
+	for _, part := range sorted {
+		entries = append(entries, fmt.Sprintf("%s=%d(%+d)", part.Name, part.Size, part.Size-part.InputSize))
+	}
+	return strings.Join(entries, ";")
+}
@@ -0,0 +1,144 @@
+package pptx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLongRangeDeck writes a deck with one bar series over points rows
+// whose caches are trimmed to a single point, as a deck that was saved with
+// small caches would be. Syncing it writes the full range into the chart
+// part.
+func writeLongRangeDeck(t *testing.T, points int) string {
+	t.Helper()
+
+	lastRow := points + 1
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>`)
+	for r := 2; r <= lastRow; r++ {
+		fmt.Fprintf(&sheet, `
+    <row r="%d"><c r="A%d" t="inlineStr"><is><t>P%d</t></is></c><c r="B%d"><v>%d</v></c></row>`, r, r, r-1, r, r*7)
+	}
+	sheet.WriteString(`
+  </sheetData>
+</worksheet>`)
+
+	workbook := baseXLSXParts(t)
+	workbook["xl/worksheets/sheet1.xml"] = []byte(sheet.String())
+	parts := map[string][]byte{
+		"ppt/slides/slide1.xml": []byte(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"></p:sld>`),
+		"ppt/slides/_rels/slide1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/chart1.xml"/>
+</Relationships>`),
+		"ppt/charts/chart1.xml": []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$%[1]d</c:f><c:strCache><c:ptCount val="1"/><c:pt idx="0"><c:v>P1</c:v></c:pt></c:strCache></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!$B$2:$B$%[1]d</c:f><c:numCache><c:ptCount val="1"/><c:pt idx="0"><c:v>14</c:v></c:pt></c:numCache></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`, lastRow)),
+		"ppt/charts/_rels/chart1.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/>
+</Relationships>`),
+		"ppt/embeddings/embeddedWorkbook1.xlsx": writeZipBytes(t, workbook),
+	}
+
+	path := filepath.Join(t.TempDir(), "long.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestSaveOutputSizeExceededReportsLargestParts(t *testing.T) {
+	input := writeLongRangeDeck(t, 5000)
+	stat, err := os.Stat(input)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Save.MaxOutputBytes = stat.Size()
+	doc, err := OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	unchanged := filepath.Join(t.TempDir(), "unchanged.pptx")
+	if err := doc.SaveFile(unchanged); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("an unchanged deck must stay within its own size: %#v", doc.Alerts())
+	}
+
+	if _, err := doc.SyncChartCaches(); err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if _, err := OpenFile(output); err != nil {
+		t.Fatalf("the oversized save must still be written: %v", err)
+	}
+
+	alerts := doc.AlertsByCode("SAVE_OUTPUT_SIZE_EXCEEDED")
+	if len(alerts) != 1 || alerts[0].Level != "warn" {
+		t.Fatalf("expected one SAVE_OUTPUT_SIZE_EXCEEDED warning, got %#v", alerts)
+	}
+	ctx := alerts[0].Context
+	if ctx["path"] != output || ctx["maxOutputBytes"] != fmt.Sprint(stat.Size()) {
+		t.Fatalf("unexpected context: %#v", ctx)
+	}
+	saved, err := os.Stat(output)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if ctx["outputBytes"] != fmt.Sprint(saved.Size()) {
+		t.Fatalf("outputBytes %q does not match the saved file (%d)", ctx["outputBytes"], saved.Size())
+	}
+
+	entries := strings.Split(ctx["largestParts"], ";")
+	if len(entries) != 5 {
+		t.Fatalf("expected every part of the deck to be listed, got %q", ctx["largestParts"])
+	}
+	// The synced chart outgrew the workbook, and only it changed size.
+	if !strings.HasPrefix(entries[0], "ppt/charts/chart1.xml=") || strings.Contains(entries[0], "(+0)") {
+		t.Fatalf("expected the grown chart first, got %q", entries[0])
+	}
+	for _, entry := range entries[1:] {
+		if !strings.HasSuffix(entry, "(+0)") {
+			t.Fatalf("unexpected growth in %q", entry)
+		}
+	}
+}
+
+func TestFormatLargestPartsKeepsTopN(t *testing.T) {
+	input := writeLongRangeDeck(t, 5000)
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	parts, err := doc.pkg.ListPartsWithInfo()
+	if err != nil {
+		t.Fatalf("ListPartsWithInfo: %v", err)
+	}
+	got := formatLargestParts(parts, 2)
+	if entries := strings.Split(got, ";"); len(entries) != 2 || !strings.HasPrefix(entries[0], "ppt/embeddings/embeddedWorkbook1.xlsx=") {
+		t.Fatalf("unexpected top parts: %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package pptx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// splitScatterDependencies is splitDependencies for scatter charts. The y
+// values of each series stand in for its values, and the x values of the
+// lowest series that has any stand in for the chart's categories. xRanges
+// holds the x values of every series.
+func splitScatterDependencies(ranges []Range) (*Range, map[int]Range, map[int]Range) {
+	var xRange *Range
+	values := make(map[int]Range)
+	xRanges := make(map[int]Range)
+
+	for i := range ranges {
+		r := ranges[i]
+		switch r.Kind {
+		case RangeXValues:
+			if _, ok := xRanges[r.SeriesIndex]; !ok {
+				xRanges[r.SeriesIndex] = r
+			}
+			if xRange == nil || r.SeriesIndex < xRange.SeriesIndex {
+				copy := r
+				xRange = &copy
+			}
+		case RangeYValues:
+			if _, ok := values[r.SeriesIndex]; !ok {
+				values[r.SeriesIndex] = r
+			}
+		}
+	}
+
+	return xRange, values, xRanges
+}
+
+// chartJSScatterPoints pairs the x and y values of a scatter series into
+// Chart.js {x, y} points. As in PowerPoint, x values that are not all
+// numbers are replaced by the point positions 1..n.
+func chartJSScatterPoints(s ExtractedSeries, xs []string, policy MissingNumericPolicy) ([]any, error) {
+	ys := s.Data
+	yValues, err := chartJSValues(s, policy)
+	if err != nil {
+		return nil, err
+	}
+	var xValues []any
+	if len(xs) == len(ys) && numericCells(xs) {
+		if xValues, err = chartJSValues(ExtractedSeries{Index: s.Index, Data: xs}, policy); err != nil {
+			return nil, err
+		}
+	} else {
+		xValues = make([]any, len(ys))
+		for i := range xValues {
+			xValues[i] = float64(i + 1)
+		}
+	}
+
+	points := make([]any, len(ys))
+	for i := range ys {
+		points[i] = map[string]any{"x": xValues[i], "y": yValues[i]}
+	}
+	return points, nil
+}
+
+// numericCells reports whether every non-blank value parses as a number.
+func numericCells(values []string) bool {
+	for _, raw := range values {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(trimmed, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
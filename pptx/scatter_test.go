@@ -0,0 +1,118 @@
+package pptx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractScatterChart(t *testing.T) {
+	exercisesFeature(t, "extract.scatter")
+
+	doc, err := OpenFile(fixturePath("scatter_xy.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	deps, err := doc.GetChartDependencies()
+	if err != nil {
+		t.Fatalf("GetChartDependencies: %v", err)
+	}
+	kinds := map[ChartRangeKind]int{}
+	for _, r := range deps[0].Ranges {
+		kinds[r.Kind]++
+	}
+	if !reflect.DeepEqual(kinds, map[ChartRangeKind]int{RangeSeriesName: 2, RangeXValues: 2, RangeYValues: 2}) {
+		t.Fatalf("unexpected range kinds: %#v", kinds)
+	}
+
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if data.Type != "scatter" || !reflect.DeepEqual(data.Labels, []string{"12", "18", "24", "31"}) {
+		t.Fatalf("unexpected chart: %q %#v", data.Type, data.Labels)
+	}
+	if len(data.Series) != 2 {
+		t.Fatalf("expected 2 series, got %#v", data.Series)
+	}
+	sales, returns := data.Series[0], data.Series[1]
+	if sales.Name != "Sales" || !reflect.DeepEqual(sales.Data, []string{"130", "170", "240", "310"}) || sales.XValues != nil {
+		t.Fatalf("unexpected first series: %#v", sales)
+	}
+	// The second series has x values of its own.
+	if returns.Name != "Returns" || !reflect.DeepEqual(returns.XValues, []string{"14", "19", "26", "30"}) {
+		t.Fatalf("unexpected second series: %#v", returns)
+	}
+	if sales.Axis != "" || returns.Axis != "" {
+		t.Fatalf("the x value axis must not read as a secondary axis: %#v", data.Series)
+	}
+
+	payload, err := ChartJSExporter{}.Export(data)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if payload.Data["type"] != "scatter" {
+		t.Fatalf("unexpected payload: %#v", payload.Data)
+	}
+	if _, ok := payload.Data["labels"]; ok {
+		t.Fatalf("scatter payloads carry their x values in the points: %#v", payload.Data)
+	}
+	datasets := payload.Data["datasets"].([]map[string]any)
+	want := []any{
+		map[string]any{"x": float64(14), "y": float64(4)},
+		map[string]any{"x": float64(19), "y": float64(6)},
+		map[string]any{"x": float64(26), "y": float64(5)},
+		map[string]any{"x": float64(30), "y": float64(9)},
+	}
+	if len(datasets) != 2 || !reflect.DeepEqual(datasets[1]["data"], want) {
+		t.Fatalf("unexpected datasets: %#v", datasets)
+	}
+	first := datasets[0]["data"].([]any)
+	if !reflect.DeepEqual(first[0], map[string]any{"x": float64(12), "y": float64(130)}) {
+		t.Fatalf("unexpected first point: %#v", first[0])
+	}
+}
+
+func TestChartJSScatterTextXValuesUsePositions(t *testing.T) {
+	points, err := chartJSScatterPoints(ExtractedSeries{Data: []string{"3", ""}}, []string{"North", "South"}, MissingNumericEmpty)
+	if err != nil {
+		t.Fatalf("chartJSScatterPoints: %v", err)
+	}
+	want := []any{
+		map[string]any{"x": float64(1), "y": float64(3)},
+		map[string]any{"x": float64(2), "y": nil},
+	}
+	if !reflect.DeepEqual(points, want) {
+		t.Fatalf("unexpected points: %#v", points)
+	}
+}
+
+func TestScatterChartWritesUnsupported(t *testing.T) {
+	data := map[string][]string{"categories": {"1", "2", "3", "4"}, "values:0": {"1", "2", "3", "4"}}
+
+	doc, err := OpenFile(fixturePath("scatter_xy.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, data); err == nil {
+		t.Fatalf("expected Strict to reject the scatter chart")
+	}
+
+	doc, err = OpenFile(fixturePath("scatter_xy.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, data); err == nil {
+		t.Fatalf("expected BestEffort to report the skipped chart")
+	}
+	alerts := doc.Alerts()
+	if len(alerts) != 1 || alerts[0].Code != "CHART_TYPE_UNSUPPORTED" || alerts[0].Context["chartType"] != "scatter" {
+		t.Fatalf("expected CHART_TYPE_UNSUPPORTED, got %#v", alerts)
+	}
+	plan, err := doc.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if plan.Charts[0].Action != "unsupported" {
+		t.Fatalf("expected the scatter chart to be unsupported for writes: %#v", plan.Charts[0])
+	}
+}
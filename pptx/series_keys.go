@@ -0,0 +1,167 @@
+package pptx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// seriesKeys maps the values ranges of a chart to the "values:N" keys of a
+// ChartDataInput. A caller may number series by position, matching
+// ExtractedSeries.Index, or by the chart's c:idx, matching
+// ExtractedSeries.OriginalIndex; the two only differ in charts where series
+// were deleted.
+type seriesKeys struct {
+	// position and original map a SeriesIndex to its number in each scheme.
+	position map[int]int
+	original map[int]int
+	order    []int
+}
+
+func newSeriesKeys(ranges []ChartRange) seriesKeys {
+	keys := seriesKeys{position: map[int]int{}, original: map[int]int{}}
+	for _, r := range ranges {
+		if r.Kind != RangeValues {
+			continue
+		}
+		if _, ok := keys.original[r.SeriesIndex]; ok {
+			continue
+		}
+		keys.original[r.SeriesIndex] = r.OriginalIndex
+		keys.order = append(keys.order, r.SeriesIndex)
+	}
+	sort.Ints(keys.order)
+	for i, seriesIndex := range keys.order {
+		keys.position[seriesIndex] = i
+	}
+	return keys
+}
+
+// sparse reports whether the two numbering schemes disagree.
+func (k seriesKeys) sparse() bool {
+	for _, seriesIndex := range k.order {
+		if k.position[seriesIndex] != k.original[seriesIndex] {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve picks the numbering data uses and returns the key of each values
+// range by SeriesIndex. Positional keys win when data has a full set of
+// both. In a sparse chart, values keys the chosen scheme does not use are
+// rejected, since they usually mean the two schemes were mixed up.
+//
+// Unless requireAll is set, data may leave series out: the scheme is the
+// one that uses every values key data has, positional first, and a values
+// key matching no series is rejected in any chart.
+func (k seriesKeys) resolve(data map[string][]string, requireAll bool) (map[int]string, error) {
+	byPosition := k.keys(k.position)
+	byOriginal := k.keys(k.original)
+	chosen := byPosition
+	if requireAll {
+		if !hasAllKeys(data, byPosition) && hasAllKeys(data, byOriginal) {
+			chosen = byOriginal
+		}
+		for _, seriesIndex := range k.order {
+			if _, ok := data[chosen[seriesIndex]]; !ok {
+				return nil, fmt.Errorf("values data missing for series %d (%s)", k.position[seriesIndex], k.describe())
+			}
+		}
+		if !k.sparse() {
+			return chosen, nil
+		}
+	} else if len(extraKeys(data, byPosition)) > 0 && len(extraKeys(data, byOriginal)) == 0 {
+		chosen = byOriginal
+	}
+	if extra := extraKeys(data, chosen); len(extra) > 0 {
+		return nil, fmt.Errorf("unexpected values keys %s (%s)", strings.Join(extra, ", "), k.describe())
+	}
+	return chosen, nil
+}
+
+// nameKeys returns the "name:N" key of each series data names, numbered in
+// the scheme the values keys resolved to. It rejects name keys that match
+// no series and names that are not exactly one string.
+func (k seriesKeys) nameKeys(data map[string][]string, valueKeys map[int]string) (map[int]string, error) {
+	out := make(map[int]string)
+	used := make(map[string]bool, len(valueKeys))
+	for seriesIndex, valueKey := range valueKeys {
+		key := "name:" + strings.TrimPrefix(valueKey, "values:")
+		used[key] = true
+		names, ok := data[key]
+		if !ok {
+			continue
+		}
+		if len(names) != 1 {
+			return nil, fmt.Errorf("%s takes exactly one series name, got %d", key, len(names))
+		}
+		out[seriesIndex] = key
+	}
+	var extra []string
+	for key := range data {
+		if strings.HasPrefix(key, "name:") && !used[key] {
+			extra = append(extra, key)
+		}
+	}
+	if len(extra) > 0 {
+		sort.Strings(extra)
+		return nil, fmt.Errorf("unexpected name keys %s (name keys follow the values keys, %s)", strings.Join(extra, ", "), k.describe())
+	}
+	return out, nil
+}
+
+// extraKeys returns the sorted values keys of data that keys does not use.
+func extraKeys(data map[string][]string, keys map[int]string) []string {
+	used := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		used[key] = true
+	}
+	var extra []string
+	for key := range data {
+		if strings.HasPrefix(key, "values:") && !used[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	return extra
+}
+
+func (k seriesKeys) keys(numbers map[int]int) map[int]string {
+	out := make(map[int]string, len(numbers))
+	for seriesIndex, n := range numbers {
+		out[seriesIndex] = "values:" + strconv.Itoa(n)
+	}
+	return out
+}
+
+// describe explains both numbering schemes for error messages.
+func (k seriesKeys) describe() string {
+	if !k.sparse() {
+		return fmt.Sprintf("expected values:0 to values:%d", len(k.order)-1)
+	}
+	original := make([]string, len(k.order))
+	for i, seriesIndex := range k.order {
+		original[i] = "values:" + strconv.Itoa(k.original[seriesIndex])
+	}
+	return fmt.Sprintf("series are keyed by position, values:0 to values:%d, or by chart c:idx, %s", len(k.order)-1, strings.Join(original, ", "))
+}
+
+// hint is appended to length errors in sparse charts, where a length
+// mismatch often means data was keyed in the other scheme.
+func (k seriesKeys) hint() string {
+	if !k.sparse() {
+		return ""
+	}
+	return " (" + k.describe() + ")"
+}
+
+func hasAllKeys(data map[string][]string, keys map[int]string) bool {
+	for _, key := range keys {
+		if _, ok := data[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
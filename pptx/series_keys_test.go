@@ -0,0 +1,206 @@
+package pptx
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const idxGapFixture = "line_series_idx_gap.pptx"
+
+func TestExtractNumbersSeriesByPosition(t *testing.T) {
+	exercisesFeature(t, "extract.line")
+
+	doc, err := OpenFile(fixturePath(idxGapFixture))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if len(data.Series) != 3 {
+		t.Fatalf("expected 3 series, got %#v", data.Series)
+	}
+	wantOriginal := []int{0, 2, 5}
+	wantNames := []string{"North", "South", "West"}
+	for i, series := range data.Series {
+		if series.Index != i || series.OriginalIndex != wantOriginal[i] || series.Name != wantNames[i] {
+			t.Fatalf("series %d: unexpected %+v", i, series)
+		}
+	}
+}
+
+func TestPlanDependenciesCarryBothSeriesNumbers(t *testing.T) {
+	doc, err := OpenFile(fixturePath(idxGapFixture))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for name, data := range map[string]ChartDataInput{
+		"position":  gapChartData("values:0", "values:1", "values:2"),
+		"chart idx": gapChartData("values:0", "values:2", "values:5"),
+	} {
+		plan, err := doc.PlanChanges(PlanRequest{Data: data})
+		if err != nil {
+			t.Fatalf("%s: PlanChanges: %v", name, err)
+		}
+		if len(plan.Charts) != 1 || plan.Charts[0].Action != "apply" {
+			t.Fatalf("%s: unexpected plan: %#v", name, plan)
+		}
+		var position, original []int
+		for _, r := range plan.Charts[0].Dependencies {
+			if r.Kind == RangeValues {
+				position = append(position, r.SeriesIndex)
+				original = append(original, r.OriginalIndex)
+			}
+		}
+		if !reflect.DeepEqual(position, []int{0, 1, 2}) || !reflect.DeepEqual(original, []int{0, 2, 5}) {
+			t.Fatalf("%s: unexpected series numbers %v and %v", name, position, original)
+		}
+	}
+}
+
+func TestApplyChartDataAcceptsBothSeriesNumberings(t *testing.T) {
+	exercisesFeature(t, "apply.line")
+
+	for name, keys := range map[string][]string{
+		"position":  {"values:0", "values:1", "values:2"},
+		"chart idx": {"values:0", "values:2", "values:5"},
+	} {
+		doc, err := OpenFile(fixturePath(idxGapFixture))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", gapChartData(keys...)); err != nil {
+			t.Fatalf("%s: ApplyChartDataByPath: %v", name, err)
+		}
+		data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+		if err != nil {
+			t.Fatalf("%s: ExtractChartDataByPath: %v", name, err)
+		}
+		for i, series := range data.Series {
+			if want := gapSeriesValues(i); !reflect.DeepEqual(series.Data, want) {
+				t.Fatalf("%s: series %d got %v, want %v", name, i, series.Data, want)
+			}
+		}
+	}
+}
+
+func TestApplyChartDataExplainsSeriesNumberings(t *testing.T) {
+	cases := map[string]struct {
+		data ChartDataInput
+		want string
+	}{
+		"neither scheme": {
+			data: gapChartData("values:0", "values:1", "values:5"),
+			want: "unexpected values keys values:5",
+		},
+		"schemes mixed": {
+			data: gapChartData("values:0", "values:1", "values:2", "values:5"),
+			want: "unexpected values keys values:5",
+		},
+		"wrong length": {
+			data: ChartDataInput{
+				"categories": {"Q1", "Q2", "Q3"},
+				"values:0":   {"1", "2"},
+				"values:1":   {"1", "2"},
+				"values:2":   {"1", "2"},
+			},
+			want: "categories length 3 does not match values length 2 for series 0",
+		},
+	}
+	for name, tc := range cases {
+		doc, err := OpenFile(fixturePath(idxGapFixture))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		_, planErr := doc.PlanChanges(PlanRequest{Data: tc.data})
+		applyErr := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", tc.data)
+		for _, err := range []error{planErr, applyErr} {
+			if err == nil || !strings.Contains(err.Error(), tc.want) ||
+				!strings.Contains(err.Error(), "values:0 to values:2, or by chart c:idx, values:0, values:2, values:5") {
+				t.Fatalf("%s: unexpected error %v", name, err)
+			}
+		}
+	}
+}
+
+func gapSeriesValues(series int) []string {
+	return []string{
+		strings.Repeat("1", series+1),
+		strings.Repeat("2", series+1),
+		strings.Repeat("3", series+1),
+	}
+}
+
+func gapChartData(keys ...string) ChartDataInput {
+	data := ChartDataInput{"categories": {"Q1", "Q2", "Q3"}}
+	for i, key := range keys {
+		data[key] = gapSeriesValues(i)
+	}
+	return data
+}
+
+func TestSeriesIndexFollowsChartOrder(t *testing.T) {
+	exercisesFeature(t, "series.order")
+
+	// The last c:ser, West in column D, has c:order 1.
+	doc, err := OpenFile(fixturePath("line_series_order_shuffled.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	wantNames := []string{"North", "West", "South"}
+	wantOriginal := []int{0, 3, 2}
+	for i, series := range data.Series {
+		if series.Index != i || series.Name != wantNames[i] || series.OriginalIndex != wantOriginal[i] {
+			t.Fatalf("series %d: unexpected %+v", i, series)
+		}
+	}
+
+	plan, err := doc.PlanChanges(PlanRequest{Data: gapChartData("values:0", "values:1", "values:2")})
+	if err != nil || len(plan.Charts) != 1 {
+		t.Fatalf("PlanChanges: %#v %v", plan, err)
+	}
+	columns := map[int]string{}
+	for _, r := range plan.Charts[0].Dependencies {
+		if r.Kind == RangeValues {
+			columns[r.SeriesIndex] = r.StartCell
+		}
+	}
+	if !reflect.DeepEqual(columns, map[int]string{0: "B2", 1: "D2", 2: "C2"}) {
+		t.Fatalf("unexpected values ranges: %#v", columns)
+	}
+
+	for name, keys := range map[string][]string{
+		"position":  {"values:0", "values:1", "values:2"},
+		"chart idx": {"values:0", "values:3", "values:2"},
+	} {
+		doc, err := OpenFile(fixturePath("line_series_order_shuffled.pptx"))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", gapChartData(keys...)); err != nil {
+			t.Fatalf("%s: ApplyChartDataByPath: %v", name, err)
+		}
+		cells, err := doc.GetWorkbookCells(plan.Charts[0].WorkbookPath, "Sheet1", []string{"B2", "C2", "D2"})
+		if err != nil {
+			t.Fatalf("%s: GetWorkbookCells: %v", name, err)
+		}
+		if cells["B2"] != "1" || cells["D2"] != "11" || cells["C2"] != "111" {
+			t.Fatalf("%s: unexpected cells %#v", name, cells)
+		}
+
+		// Each c:ser cache holds its own column, West's after South's.
+		chartXML, _ := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+		south := bytes.Index(chartXML, []byte(">South</"))
+		west := bytes.Index(chartXML, []byte(">West</"))
+		if south < 0 || west < south || !bytes.Contains(chartXML[south:west], []byte(">111</")) || !bytes.Contains(chartXML[west:], []byte(">11</")) {
+			t.Fatalf("%s: caches not synced by c:order:\n%s", name, chartXML)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ErrSeriesNameNotBound is wrapped by the ApplyChartData error for a
+// "name:N" key whose series takes its name from literal text (c:tx/c:v) or
+// has none, so there is no workbook cell to write it to.
+var ErrSeriesNameNotBound = errors.New("series name is not bound to a workbook cell")
+
+// seriesNameUpdates returns the cell writes of the "name:N" keys of data.
+// names holds the name range of each series by SeriesIndex, nil for a series
+// whose name is not a cell reference. The series written are marked in
+// supplied, so their caches, the c:tx strCache included, are synced.
+func (d *docCall) seriesNameUpdates(dep ChartDependencies, data map[string][]string, keys map[int]string, names map[int]*ChartRange, supplied map[int]bool) ([]CellUpdate, []string, error) {
+	series := make([]int, 0, len(keys))
+	for seriesIndex := range keys {
+		series = append(series, seriesIndex)
+	}
+	sort.Ints(series)
+
+	var updates []CellUpdate
+	var written []string
+	for _, seriesIndex := range series {
+		key := keys[seriesIndex]
+		r := names[seriesIndex]
+		if r == nil {
+			if err := d.handleSeriesNameNotBound(dep, seriesIndex, key); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		cells, err := expandRangeCells(r.StartCell, r.EndCell)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(cells) != 1 {
+			return nil, nil, fmt.Errorf("%s: series name range %s covers %d cells, expected 1", key, r.Formula, len(cells))
+		}
+		updates = append(updates, CellUpdate{
+			WorkbookPath: dep.WorkbookPath,
+			Sheet:        r.Sheet,
+			Cell:         cells[0],
+			Value:        Str(data[key][0]),
+		})
+		written = append(written, r.Formula)
+		if supplied != nil {
+			supplied[seriesIndex] = true
+		}
+	}
+	return updates, written, nil
+}
+
+// seriesNameRanges maps each series of ranges to its name range.
+func seriesNameRanges(ranges []ChartRange) map[int]*ChartRange {
+	names := make(map[int]*ChartRange)
+	for i := range ranges {
+		if ranges[i].Kind == RangeSeriesName && ranges[i].UnionIndex == 0 {
+			names[ranges[i].SeriesIndex] = &ranges[i]
+		}
+	}
+	return names
+}
+
+func (d *docCall) handleSeriesNameNotBound(dep ChartDependencies, seriesIndex int, key string) error {
+	err := fmt.Errorf("%w: %s for series %d of chart %q", ErrSeriesNameNotBound, key, seriesIndex, dep.ChartPath)
+	if d.mode() != BestEffort {
+		return err
+	}
+
+	d.addAlert(Alert{
+		Level:   "warn",
+		Code:    "CHART_SERIES_NAME_NOT_BOUND",
+		Message: "Series name is literal text, not a workbook cell; name not written",
+		Context: map[string]string{
+			"slide":       dep.SlidePath,
+			"chart":       dep.ChartPath,
+			"workbook":    dep.WorkbookPath,
+			"seriesIndex": strconv.Itoa(seriesIndex),
+			"key":         key,
+		},
+	})
+	return nil
+}
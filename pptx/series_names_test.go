@@ -0,0 +1,115 @@
+package pptx
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyChartDataWritesSeriesName(t *testing.T) {
+	exercisesFeature(t, "apply.series-names")
+
+	doc, err := OpenFile(fixturePath("line_series_idx_gap.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) != 1 {
+		t.Fatalf("ListCharts: %v %v", charts, err)
+	}
+
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{"name:0": {"East", "West"}}); err == nil || !strings.Contains(err.Error(), "exactly one") {
+		t.Fatalf("expected a two-value name to fail, got %v", err)
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{"name:9": {"East"}}); err == nil || !strings.Contains(err.Error(), "unexpected name keys name:9") {
+		t.Fatalf("expected an unknown name key to fail, got %v", err)
+	}
+
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{"name:1": {"West"}}); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	cells, err := doc.GetWorkbookCells(charts[0].WorkbookPath, "Sheet1", []string{"B1", "C1"})
+	if err != nil {
+		t.Fatalf("GetWorkbookCells: %v", err)
+	}
+	if cells["B1"] != "North" || cells["C1"] != "West" {
+		t.Fatalf("unexpected name cells: %#v", cells)
+	}
+	chartXML, _ := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if !bytes.Contains(chartXML, []byte(">West</")) || bytes.Contains(chartXML, []byte(">South</")) {
+		t.Fatalf("expected the c:tx strCache to be synced:\n%s", chartXML)
+	}
+	extracted, err := doc.ExtractChartData(0)
+	if err != nil {
+		t.Fatalf("ExtractChartData: %v", err)
+	}
+	if extracted.Series[1].Name != "West" {
+		t.Fatalf("expected series 1 to be named West, got %#v", extracted.Series)
+	}
+}
+
+func TestApplyChartDataSeriesNameNotBound(t *testing.T) {
+	data := map[string][]string{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+		"name:0":     {"Revenue"},
+	}
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data); !errors.Is(err, ErrSeriesNameNotBound) {
+		t.Fatalf("expected ErrSeriesNameNotBound, got %v", err)
+	}
+
+	doc, err = OpenFile(fixturePath("bar_simple_embedded.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	alerts := doc.AlertsByCode("CHART_SERIES_NAME_NOT_BOUND")
+	if len(alerts) != 1 || alerts[0].Context["key"] != "name:0" || alerts[0].Context["seriesIndex"] != "0" {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+	extracted, err := doc.ExtractChartData(0)
+	if err != nil || strings.Join(extracted.Labels, ",") != "NewA,NewB" {
+		t.Fatalf("expected the values to be written anyway, got %v %v", extracted.Labels, err)
+	}
+}
+
+func TestApplyMixedChartDataWritesSeriesName(t *testing.T) {
+	entries := corpusZipEntries(t, readCorpusFile(t, fixturePath("mix_write_secondary_axis_valid.pptx")))
+	chart := entries["ppt/charts/chart1.xml"]
+	line := bytes.Index(chart, []byte("<c:lineChart>"))
+	named := append([]byte(nil), chart[:line]...)
+	named = append(named, bytes.Replace(chart[line:], []byte("<c:ser><c:cat>"), []byte(`<c:ser><c:tx><c:strRef><c:f>Sheet1!$C$1</c:f><c:strCache><c:ptCount val="1"/><c:pt idx="0"><c:v>Old</c:v></c:pt></c:strCache></c:strRef></c:tx><c:cat>`), 1)...)
+	entries["ppt/charts/chart1.xml"] = named
+	inputPath := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(inputPath, entries); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{
+		"values:1": {"31", "41"},
+		"name:1":   {"Target"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	chartXML, _ := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if !bytes.Contains(chartXML, []byte(">Target</")) || bytes.Contains(chartXML, []byte(">Old</")) {
+		t.Fatalf("expected the line series name cache to be synced:\n%s", chartXML)
+	}
+
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{"name:0": {"Bars"}}); !errors.Is(err, ErrSeriesNameNotBound) {
+		t.Fatalf("expected the unnamed bar series to fail, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractResolvesSharedStrings(t *testing.T) {
+	exercisesFeature(t, "workbook.sharedstrings-read")
+
+	doc, err := OpenFile(fixturePath("xlsx_sharedstrings_rich.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	// Rich-text runs are concatenated and the phonetic run of "West" dropped.
+	if !reflect.DeepEqual(data.Labels, []string{"North", "South East", "West"}) {
+		t.Fatalf("unexpected labels: %#v", data.Labels)
+	}
+	if len(data.Series) != 1 || data.Series[0].Name != "Revenue" || !reflect.DeepEqual(data.Series[0].Data, []string{"10", "20", "30"}) {
+		t.Fatalf("unexpected series: %#v", data.Series)
+	}
+
+	charts, err := doc.ExtractAllCharts()
+	if err != nil {
+		t.Fatalf("ExtractAllCharts: %v", err)
+	}
+	if len(charts) != 1 || !reflect.DeepEqual(charts[0].Labels, data.Labels) {
+		t.Fatalf("unexpected charts: %#v", charts)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+}
+
+func TestSyncChartCachesResolvesSharedStrings(t *testing.T) {
+	doc, err := OpenFile(fixturePath("xlsx_sharedstrings_rich.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	results, err := doc.SyncChartCaches()
+	if err != nil {
+		t.Fatalf("SyncChartCaches: %v", err)
+	}
+	if len(results) != 1 || !results[0].Changed {
+		t.Fatalf("expected the stale caches to be rewritten, got %#v", results)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	chartXML, err := doc.pkg.ReadPart("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ReadPart: %v", err)
+	}
+	// The series name cache comes first, then the categories.
+	cats, _ := extractChartCacheValues(t, chartXML)
+	if !reflect.DeepEqual(cats, append([]string{"Revenue"}, data.Labels...)) {
+		t.Fatalf("strCache not synced from the shared strings: %#v", cats)
+	}
+}
+
+func TestApplyChartDataConvertSharedStrings(t *testing.T) {
+	exercisesFeature(t, "workbook.sharedstrings-convert")
+
+	const workbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+	input := fixturePath("xlsx_sharedstrings_rich.pptx")
+	data := map[string][]string{
+		"categories": {"Q1", "Q2", "Q3"},
+		"values:0":   {"11", "12", "13"},
+	}
+
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, data); err == nil {
+		t.Fatalf("expected the shared string workbook to be refused without ConvertSharedStrings")
+	}
+	if len(doc.AlertsByCode("POSTFLIGHT_XLSX_SHAREDSTRINGS_DETECTED")) != 1 {
+		t.Fatalf("expected POSTFLIGHT_XLSX_SHAREDSTRINGS_DETECTED, got %#v", doc.Alerts())
+	}
+
+	opts := DefaultOptions()
+	opts.Workbook.ConvertSharedStrings = true
+	doc, err = OpenFile(input, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, data); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+
+	workbook := readEmbeddedWorkbook(t, output, workbookPath)
+	sheet := readSheetFromXLSX(t, workbook, "xl/worksheets/sheet1.xml")
+	if cellType, value, ok := readCellFromSheet(sheet, "A3"); !ok || cellType != "inlineStr" || value != "Q2" {
+		t.Fatalf("written category: %q %q (ok=%v)", cellType, value, ok)
+	}
+	// The series name was not written and keeps its shared string, and so
+	// does the table.
+	if cellType, value, ok := readCellFromSheet(sheet, "B1"); !ok || cellType != "s" || value != "3" {
+		t.Fatalf("untouched series name: %q %q (ok=%v)", cellType, value, ok)
+	}
+	if table := readSheetFromXLSX(t, workbook, "xl/sharedStrings.xml"); len(table) == 0 {
+		t.Fatalf("sharedStrings.xml must be kept")
+	}
+
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	extracted, err := saved.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(extracted.Labels, data["categories"]) || extracted.Series[0].Name != "Revenue" {
+		t.Fatalf("round trip mismatch: %#v", extracted)
+	}
+}
@@ -0,0 +1,99 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/chartdiscover"
+)
+
+// skipReason describes how a chart that chartdiscover skipped is reported:
+// the alert code and message, the PlannedChart action, and the context keys
+// added after slide and chart. DiscoverEmbeddedCharts, extraction, cache
+// repair and Plan all read skipReasons, so they cannot drift apart.
+type skipReason struct {
+	code    string
+	message string
+	action  string
+	context func(skip chartdiscover.SkippedChart, ctx map[string]string)
+}
+
+var skipReasons = map[string]skipReason{
+	chartdiscover.ReasonLinked: {
+		code:    "CHART_LINKED_WORKBOOK",
+		message: "Chart uses linked workbook and is skipped",
+		action:  "linked",
+		context: func(skip chartdiscover.SkippedChart, ctx map[string]string) {
+			ctx["target"] = skip.Target
+		},
+	},
+	chartdiscover.ReasonRelsMissing: {
+		code:    "CHART_RELS_MISSING",
+		message: "Chart relationships file is missing; chart is skipped",
+		action:  "skip",
+		context: func(skip chartdiscover.SkippedChart, ctx map[string]string) {
+			ctx["relsPath"] = skip.RelsPath
+		},
+	},
+	chartdiscover.ReasonWorkbookNotFound: {
+		code:    "CHART_WORKBOOK_NOT_FOUND",
+		message: "No workbook relationship found for chart; chart is skipped",
+		action:  "skip",
+	},
+	chartdiscover.ReasonUnsupported: {
+		code:    "CHART_WORKBOOK_UNSUPPORTED_TARGET",
+		message: "Chart workbook target is unsupported; chart is skipped",
+		action:  "skip",
+		context: func(skip chartdiscover.SkippedChart, ctx map[string]string) {
+			ctx["target"] = skip.Target
+		},
+	},
+}
+
+// lookupSkipReason returns the mapping for skip.Reason. An unknown reason is
+// a plain skip without an alert code.
+func lookupSkipReason(skip chartdiscover.SkippedChart) skipReason {
+	if reason, ok := skipReasons[skip.Reason]; ok {
+		return reason
+	}
+	return skipReason{action: "skip"}
+}
+
+func (r skipReason) contextFor(skip chartdiscover.SkippedChart) map[string]string {
+	ctx := map[string]string{
+		"slide": skip.SlidePath,
+		"chart": skip.ChartPath,
+	}
+	if r.context != nil {
+		r.context(skip, ctx)
+	}
+	return ctx
+}
+
+// alert returns the warning for skip, or false when the reason has no code.
+func (r skipReason) alert(skip chartdiscover.SkippedChart) (Alert, bool) {
+	if r.code == "" {
+		return Alert{}, false
+	}
+	return Alert{
+		Level:   "warn",
+		Code:    r.code,
+		Message: r.message,
+		Context: r.contextFor(skip),
+	}, true
+}
+
+// skipExtractIssue is the extraction failure reported for skip; verb names
+// the operation the chart is not eligible for.
+func skipExtractIssue(skip chartdiscover.SkippedChart, verb string) extractIssue {
+	reason := lookupSkipReason(skip)
+	message := reason.message
+	if message == "" {
+		message = extractMessageForCode(reason.code)
+	}
+	return extractIssue{
+		code:    reason.code,
+		message: message,
+		err:     fmt.Errorf("chart %q is not eligible for %s", skip.ChartPath, verb),
+		context: reason.contextFor(skip),
+	}
+}
@@ -0,0 +1,78 @@
+package pptx
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSkipReasonsCoverEveryDiscoverReason(t *testing.T) {
+	reasons := discoverReasonConstants(t)
+	if len(reasons) == 0 {
+		t.Fatalf("no Reason constants found in chartdiscover")
+	}
+	codes, err := readAlertCodes(filepath.Join("..", "ALERTS.md"))
+	if err != nil {
+		t.Fatalf("read ALERTS.md: %v", err)
+	}
+
+	for name, value := range reasons {
+		reason, ok := skipReasons[value]
+		if !ok {
+			t.Fatalf("chartdiscover.%s (%q) has no skipReasons entry", name, value)
+		}
+		if reason.code == "" || reason.message == "" || reason.action == "" {
+			t.Fatalf("chartdiscover.%s has an incomplete mapping: %+v", name, reason)
+		}
+		if _, ok := codes[reason.code]; !ok {
+			t.Fatalf("%s is not documented in ALERTS.md", reason.code)
+		}
+	}
+	if len(skipReasons) != len(reasons) {
+		t.Fatalf("skipReasons has %d entries for %d chartdiscover reasons", len(skipReasons), len(reasons))
+	}
+}
+
+// discoverReasonConstants reads the Reason* string constants declared by
+// internal/chartdiscover, keyed by constant name.
+func discoverReasonConstants(t *testing.T) map[string]string {
+	t.Helper()
+	dir := filepath.Join("..", "internal", "chartdiscover")
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, 0)
+	if err != nil {
+		t.Fatalf("parse chartdiscover: %v", err)
+	}
+	reasons := map[string]string{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					value := spec.(*ast.ValueSpec)
+					for i, name := range value.Names {
+						if !strings.HasPrefix(name.Name, "Reason") || i >= len(value.Values) {
+							continue
+						}
+						lit, ok := value.Values[i].(*ast.BasicLit)
+						if !ok || lit.Kind != token.STRING {
+							t.Fatalf("chartdiscover.%s is not a string literal", name.Name)
+						}
+						unquoted, err := strconv.Unquote(lit.Value)
+						if err != nil {
+							t.Fatalf("unquote %s: %v", name.Name, err)
+						}
+						reasons[name.Name] = unquoted
+					}
+				}
+			}
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,144 @@
+package pptx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/chartdiscover"
+)
+
+// ErrSlideNotFound is returned when a slide path or slide number names no
+// slide of the presentation.
+var ErrSlideNotFound = errors.New("slide not found")
+
+// slideTargetPrefix marks a PlanRequest.TargetCharts entry that names a
+// slide by its 1-based number, as in "slide:7".
+const slideTargetPrefix = "slide:"
+
+// SlidePaths lists the slide parts in presentation order, the order of
+// p:sldIdLst in ppt/presentation.xml, so the seventh path is slide 7 as
+// PowerPoint numbers it whatever the part is called. Without a slide list
+// the slide parts are ordered by the number in their name.
+func (d *Document) SlidePaths() ([]string, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+
+	return chartdiscover.SlideOrder(d.pkg)
+}
+
+// resolveSlide normalizes slidePath and checks that it is a slide of the
+// presentation.
+func (d *Document) resolveSlide(slidePath string) (string, error) {
+	slidePath = normalizeChartPath(slidePath)
+	slides, err := d.SlidePaths()
+	if err != nil {
+		return "", err
+	}
+	for _, slide := range slides {
+		if slide == slidePath {
+			return slide, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrSlideNotFound, slidePath)
+}
+
+// slideByNumber returns the path of the 1-based slide number.
+func (d *Document) slideByNumber(number int) (string, error) {
+	slides, err := d.SlidePaths()
+	if err != nil {
+		return "", err
+	}
+	if number < 1 || number > len(slides) {
+		return "", fmt.Errorf("%w: slide %d of %d", ErrSlideNotFound, number, len(slides))
+	}
+	return slides[number-1], nil
+}
+
+// ChartsOnSlide is DiscoverEmbeddedCharts for one slide: the charts with an
+// embedded workbook on slidePath, in discovery order. Ineligible charts on
+// the slide are alerted the same way. A path that names no slide fails with
+// ErrSlideNotFound; a slide without charts returns an empty slice.
+func (d *Document) ChartsOnSlide(slidePath string) ([]EmbeddedChart, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	slidePath, err := d.resolveSlide(slidePath)
+	if err != nil {
+		return nil, err
+	}
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return nil, err
+	}
+	for _, skip := range skipped {
+		if skip.SlidePath != slidePath {
+			continue
+		}
+		if alert, ok := lookupSkipReason(skip).alert(skip); ok {
+			d.addAlert(alert)
+		}
+	}
+
+	out := make([]EmbeddedChart, 0)
+	for _, item := range embedded {
+		if item.SlidePath == slidePath {
+			out = append(out, embeddedChartFrom(item))
+		}
+	}
+	return out, nil
+}
+
+// ExtractChartsOnSlide is ExtractAllCharts for the charts on slidePath,
+// with the same handling of ineligible and failing charts. A path that names
+// no slide fails with ErrSlideNotFound.
+func (d *Document) ExtractChartsOnSlide(slidePath string) ([]ExtractedChartData, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	slidePath, err := d.resolveSlide(slidePath)
+	if err != nil {
+		return nil, err
+	}
+
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return nil, err
+	}
+	onSlide := make([]chartdiscover.EmbeddedChart, 0)
+	for _, item := range embedded {
+		if item.SlidePath == slidePath {
+			onSlide = append(onSlide, item)
+		}
+	}
+	skippedOnSlide := make([]chartdiscover.SkippedChart, 0)
+	for _, skip := range skipped {
+		if skip.SlidePath == slidePath {
+			skippedOnSlide = append(skippedOnSlide, skip)
+		}
+	}
+	return call.extractCharts(context.Background(), onSlide, skippedOnSlide)
+}
+
+// planSlideTarget reports whether a PlanRequest target names a slide, by
+// path or as "slide:N", and returns the slide path.
+func (d *Document) planSlideTarget(target string) (string, bool, error) {
+	if number, ok := strings.CutPrefix(target, slideTargetPrefix); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil {
+			return "", true, fmt.Errorf("invalid slide target %q: %w", target, err)
+		}
+		slide, err := d.slideByNumber(n)
+		return slide, true, err
+	}
+	if !isSlidePart(normalizeChartPath(target)) {
+		return "", false, nil
+	}
+	slide, err := d.resolveSlide(target)
+	return slide, true, err
+}
@@ -0,0 +1,213 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeSlideOrderDeck writes a deck whose slide1, slide2, and slide10 are
+// presented in that order, with a chart on slide2 and two on slide10.
+func writeSlideOrderDeck(t *testing.T, withSlideList bool) string {
+	t.Helper()
+
+	parts := map[string][]byte{}
+	chartRels := func(workbook string) []byte {
+		return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/` + workbook + `"/>
+</Relationships>`)
+	}
+	slideCharts := map[string][]string{"slide1": nil, "slide2": {"chart1"}, "slide10": {"chart2", "chart3"}}
+	for slide, charts := range slideCharts {
+		parts["ppt/slides/"+slide+".xml"] = []byte("<slide/>")
+		slideRels := `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`
+		for i, chart := range charts {
+			slideRels += fmt.Sprintf(`
+  <Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="../charts/%s.xml"/>`, i+1, chart)
+			parts["ppt/charts/"+chart+".xml"] = []byte(corruptPtCountChart)
+			parts["ppt/charts/_rels/"+chart+".xml.rels"] = chartRels(chart + ".xlsx")
+			parts["ppt/embeddings/"+chart+".xlsx"] = buildWorkbookWithValues(t, "Cat1", "Cat2", 10, 20)
+		}
+		parts["ppt/slides/_rels/"+slide+".xml.rels"] = []byte(slideRels + "\n</Relationships>")
+	}
+	if withSlideList {
+		parts["ppt/presentation.xml"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <p:sldIdLst><p:sldId id="256" r:id="rId7"/><p:sldId id="257" r:id="rId5"/><p:sldId id="258" r:id="rId6"/></p:sldIdLst>
+</p:presentation>`)
+		parts["ppt/_rels/presentation.xml.rels"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId5" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide10.xml"/>
+  <Relationship Id="rId6" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide2.xml"/>
+  <Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide1.xml"/>
+</Relationships>`)
+	}
+
+	path := filepath.Join(t.TempDir(), "slides.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	return path
+}
+
+func TestSlidePathsOrder(t *testing.T) {
+	// The slide list puts slide10 second; without one the part numbers
+	// decide, never the lexicographic order of the names.
+	for _, tc := range []struct {
+		withSlideList bool
+		want          []string
+	}{
+		{withSlideList: true, want: []string{"ppt/slides/slide1.xml", "ppt/slides/slide10.xml", "ppt/slides/slide2.xml"}},
+		{withSlideList: false, want: []string{"ppt/slides/slide1.xml", "ppt/slides/slide2.xml", "ppt/slides/slide10.xml"}},
+	} {
+		doc, err := OpenFile(writeSlideOrderDeck(t, tc.withSlideList))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		slides, err := doc.SlidePaths()
+		if err != nil || !reflect.DeepEqual(slides, tc.want) {
+			t.Fatalf("SlidePaths (slide list %v) = %v, %v; want %v", tc.withSlideList, slides, err, tc.want)
+		}
+	}
+}
+
+func TestChartsOnSlide(t *testing.T) {
+	doc, err := OpenFile(writeSlideOrderDeck(t, true))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	charts, err := doc.ChartsOnSlide("/ppt/slides/slide10.xml")
+	if err != nil {
+		t.Fatalf("ChartsOnSlide: %v", err)
+	}
+	if len(charts) != 2 || charts[0].ChartPath != "ppt/charts/chart2.xml" || charts[1].ChartPath != "ppt/charts/chart3.xml" {
+		t.Fatalf("unexpected charts: %#v", charts)
+	}
+	if charts, err := doc.ChartsOnSlide("ppt/slides/slide1.xml"); err != nil || len(charts) != 0 {
+		t.Fatalf("expected no charts on slide1, got %#v %v", charts, err)
+	}
+	if _, err := doc.ChartsOnSlide("ppt/slides/slide3.xml"); !errors.Is(err, ErrSlideNotFound) {
+		t.Fatalf("expected ErrSlideNotFound, got %v", err)
+	}
+
+	extracted, err := doc.ExtractChartsOnSlide("ppt/slides/slide2.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartsOnSlide: %v", err)
+	}
+	if len(extracted) != 1 || extracted[0].Meta.ChartPath != "ppt/charts/chart1.xml" || !reflect.DeepEqual(extracted[0].Labels, []string{"Cat1", "Cat2"}) {
+		t.Fatalf("unexpected extraction: %#v", extracted)
+	}
+}
+
+func TestPlanChangesTargetsSlides(t *testing.T) {
+	doc, err := OpenFile(writeSlideOrderDeck(t, true))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	planned := func(targets ...string) []string {
+		t.Helper()
+		plan, err := doc.PlanChanges(PlanRequest{TargetCharts: targets})
+		if err != nil {
+			t.Fatalf("PlanChanges(%v): %v", targets, err)
+		}
+		paths := make([]string, 0, len(plan.Charts))
+		for _, chart := range plan.Charts {
+			paths = append(paths, chart.ChartPath)
+		}
+		return paths
+	}
+
+	if got := planned("ppt/slides/slide10.xml"); !reflect.DeepEqual(got, []string{"ppt/charts/chart2.xml", "ppt/charts/chart3.xml"}) {
+		t.Fatalf("slide path target planned %v", got)
+	}
+	// Slide 3 in presentation order is slide2.xml.
+	if got := planned("slide:3"); !reflect.DeepEqual(got, []string{"ppt/charts/chart1.xml"}) {
+		t.Fatalf("slide number target planned %v", got)
+	}
+	if got := planned("slide:1"); len(got) != 0 {
+		t.Fatalf("a slide without charts must plan nothing, got %v", got)
+	}
+	if _, err := doc.PlanChanges(PlanRequest{TargetCharts: []string{"slide:4"}}); !errors.Is(err, ErrSlideNotFound) {
+		t.Fatalf("expected ErrSlideNotFound, got %v", err)
+	}
+}
+
+func TestDiscoveryFollowsPresentationOrder(t *testing.T) {
+	const shapes = `<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:cSld><p:spTree>
+  <p:graphicFrame><a:graphic><a:graphicData><c:chart r:id="rId2"/></a:graphicData></a:graphic></p:graphicFrame>
+  <p:graphicFrame><a:graphic><a:graphicData><c:chart r:id="rId1"/></a:graphicData></a:graphic></p:graphicFrame>
+</p:spTree></p:cSld></p:sld>`
+
+	for _, tc := range []struct {
+		name          string
+		partNameOrder bool
+		wantCharts    []string
+		wantIndexes   []int
+	}{
+		// slide10 is shown second and names chart3 (rId2) first.
+		{name: "presentation", wantCharts: []string{"chart3", "chart2", "chart1"}, wantIndexes: []int{2, 2, 3}},
+		{name: "part name", partNameOrder: true, wantCharts: []string{"chart2", "chart3", "chart1"}, wantIndexes: []int{2, 2, 3}},
+	} {
+		opts := DefaultOptions()
+		opts.Discovery.PartNameOrder = tc.partNameOrder
+		doc, err := OpenFile(writeSlideOrderDeck(t, true), WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		doc.pkg.WritePart("ppt/slides/slide10.xml", []byte(shapes))
+
+		embedded, err := doc.DiscoverEmbeddedCharts()
+		if err != nil {
+			t.Fatalf("%s: DiscoverEmbeddedCharts: %v", tc.name, err)
+		}
+		infos, err := doc.ListCharts()
+		if err != nil {
+			t.Fatalf("%s: ListCharts: %v", tc.name, err)
+		}
+		if len(embedded) != len(tc.wantCharts) || len(infos) != len(tc.wantCharts) {
+			t.Fatalf("%s: unexpected charts: %#v %#v", tc.name, embedded, infos)
+		}
+		for i, want := range tc.wantCharts {
+			wantPath := "ppt/charts/" + want + ".xml"
+			if embedded[i].ChartPath != wantPath || embedded[i].SlideIndex != tc.wantIndexes[i] {
+				t.Fatalf("%s: chart %d = %#v, want %s on slide %d", tc.name, i, embedded[i], wantPath, tc.wantIndexes[i])
+			}
+			if infos[i].ChartPath != wantPath || infos[i].SlideIndex != tc.wantIndexes[i] || infos[i].Index != i {
+				t.Fatalf("%s: ChartInfo %d = %#v", tc.name, i, infos[i])
+			}
+		}
+	}
+}
+
+func TestDiscoveryWithoutSlideListOrdersByNumber(t *testing.T) {
+	for _, tc := range []struct {
+		partNameOrder bool
+		want          []string
+	}{
+		{want: []string{"ppt/slides/slide2.xml", "ppt/slides/slide10.xml", "ppt/slides/slide10.xml"}},
+		{partNameOrder: true, want: []string{"ppt/slides/slide10.xml", "ppt/slides/slide10.xml", "ppt/slides/slide2.xml"}},
+	} {
+		opts := DefaultOptions()
+		opts.Discovery.PartNameOrder = tc.partNameOrder
+		doc, err := OpenFile(writeSlideOrderDeck(t, false), WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		embedded, err := doc.DiscoverEmbeddedCharts()
+		if err != nil {
+			t.Fatalf("DiscoverEmbeddedCharts: %v", err)
+		}
+		slides := make([]string, len(embedded))
+		for i, chart := range embedded {
+			slides[i] = chart.SlidePath
+		}
+		if !reflect.DeepEqual(slides, tc.want) {
+			t.Fatalf("part name order %v: slides %v, want %v", tc.partNameOrder, slides, tc.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package pptx
+
+// stockLegNames returns the legs a stock chart with count series draws, in
+// series order. PowerPoint's stock layouts without volume plot three series
+// as high-low-close and four as open-high-low-close; other counts have no
+// fixed legs and return nil.
+func stockLegNames(count int) []string {
+	switch count {
+	case 3:
+		return []string{"High", "Low", "Close"}
+	case 4:
+		return []string{"Open", "High", "Low", "Close"}
+	}
+	return nil
+}
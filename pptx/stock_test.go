@@ -0,0 +1,131 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+func TestExtractStockChartNamesLegs(t *testing.T) {
+	exercisesFeature(t, "extract.stock")
+
+	doc, err := OpenFile(fixturePath("stock_hlc.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	data, err := doc.ExtractChartDataByPath("ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if data.Type != "stock" || data.TypeDetails != "high-low-close" {
+		t.Fatalf("unexpected type: %q (%q)", data.Type, data.TypeDetails)
+	}
+	if !reflect.DeepEqual(data.Labels, []string{"Mon", "Tue", "Wed"}) {
+		t.Fatalf("unexpected labels: %#v", data.Labels)
+	}
+	// The first series has a tx; the others are named after their leg.
+	want := []struct {
+		name string
+		data []string
+	}{
+		{"Daily High", []string{"12.5", "13.2", "12.8"}},
+		{"Low", []string{"10.1", "11.0", "10.7"}},
+		{"Close", []string{"11.8", "12.9", "11.2"}},
+	}
+	if len(data.Series) != len(want) {
+		t.Fatalf("expected %d series, got %#v", len(want), data.Series)
+	}
+	for i, w := range want {
+		if data.Series[i].Name != w.name || !reflect.DeepEqual(data.Series[i].Data, w.data) {
+			t.Fatalf("series %d: %#v", i, data.Series[i])
+		}
+	}
+
+	payload, err := ChartJSExporter{}.Export(data)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if payload.Data["type"] != "line" || payload.Data["typeDetails"] != "stock:high-low-close" {
+		t.Fatalf("unexpected payload: %#v", payload.Data)
+	}
+	datasets := payload.Data["datasets"].([]map[string]any)
+	if len(datasets) != 3 || datasets[1]["label"] != "Low" || datasets[2]["label"] != "Close" {
+		t.Fatalf("expected one line dataset per leg, got %#v", datasets)
+	}
+	if _, ok := datasets[0]["fill"]; ok {
+		t.Fatalf("stock legs must not be filled: %#v", datasets[0])
+	}
+}
+
+func TestStockChartApplyAndSync(t *testing.T) {
+	exercisesFeature(t, "apply.stock", "cachesync.stock")
+
+	const chartPath = "ppt/charts/chart1.xml"
+	input := fixturePath("stock_hlc.pptx")
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	plan, err := doc.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plan.Charts) != 1 || plan.Charts[0].Action != "apply" {
+		t.Fatalf("expected the stock chart to be planned for apply: %#v", plan.Charts)
+	}
+
+	data := map[string][]string{
+		"categories": {"Thu", "Fri", "Sat"},
+		"values:0":   {"20", "21", "22"},
+		"values:1":   {"15", "16", "17"},
+		"values:2":   {"18", "19", "20"},
+	}
+	if err := doc.ApplyChartDataByPath(chartPath, data); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("unexpected alerts: %#v", doc.Alerts())
+	}
+
+	chartXML, err := pptxassert.ReadEntry(output, chartPath)
+	if err != nil {
+		t.Fatalf("ReadEntry chart: %v", err)
+	}
+	snap, err := pptxassert.ExtractChartCacheSnapshot(chartXML)
+	if err != nil {
+		t.Fatalf("ExtractChartCacheSnapshot: %v", err)
+	}
+	nums := map[int][]string{}
+	for _, series := range snap.Series {
+		if series.Kind != "numCache" {
+			continue
+		}
+		for _, pt := range series.Points {
+			nums[series.SeriesIndex] = append(nums[series.SeriesIndex], pt.Value)
+		}
+	}
+	if !reflect.DeepEqual(nums, map[int][]string{0: data["values:0"], 1: data["values:1"], 2: data["values:2"]}) {
+		t.Fatalf("numCaches not synced: %#v", nums)
+	}
+
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	extracted, err := saved.ExtractChartDataByPath(chartPath)
+	if err != nil {
+		t.Fatalf("ExtractChartDataByPath: %v", err)
+	}
+	if !reflect.DeepEqual(extracted.Labels, data["categories"]) || !reflect.DeepEqual(extracted.Series[2].Data, data["values:2"]) {
+		t.Fatalf("round trip mismatch: %#v", extracted)
+	}
+	if extracted.Series[0].Name != "Daily High" || extracted.Series[1].Name != "Low" {
+		t.Fatalf("series names changed: %#v", extracted.Series)
+	}
+}
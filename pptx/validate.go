@@ -0,0 +1,145 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/overlaystage"
+	"why-pptx/internal/postflight"
+)
+
+// ValidateOptions relaxes Validate for decks this package did not write.
+type ValidateOptions struct {
+	// AllowSharedStrings skips the sharedStrings.xml and t="s" cell checks.
+	// Workbooks saved by Excel or PowerPoint use shared strings; the checks
+	// hold the workbooks this package writes to inline strings.
+	AllowSharedStrings bool
+	// SkipChartCaches skips the chart cache invariants (ptCount, pt idx,
+	// numeric values, and the mixed chart axis structure).
+	SkipChartCaches bool
+}
+
+// ValidationIssue is one failed check of Validate. Code is a POSTFLIGHT_*
+// code, and Context holds the keys ALERTS.md lists for it.
+type ValidationIssue struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Context map[string]string `json:"context"`
+	// Err is what the check found, such as the XML syntax error.
+	Err error `json:"-"`
+}
+
+// ValidationError is the error of a Strict Validate that found an issue.
+type ValidationError struct {
+	Issue ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Issue.Code, e.Issue.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Issue.Err
+}
+
+// Validate runs the postflight checks of a write on every chart part and
+// embedded workbook of the package, touched or not, with any unsaved edits:
+// well-formed XML, relationship targets, shared strings, and chart caches.
+// It changes nothing and records no alerts, so it suits decks other tooling
+// post-processed.
+//
+// BestEffort returns every issue; a check stops at its first finding in a
+// part. Strict returns a *ValidationError carrying the first issue. A deck
+// that passes returns an empty list.
+func (d *Document) Validate(opts ValidateOptions) ([]ValidationIssue, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	overlay := d.overlay
+	if overlay == nil {
+		packageOverlay, err := overlaystage.NewPackageOverlay(d.pkg)
+		if err != nil {
+			return nil, err
+		}
+		overlay = packageOverlay
+	}
+	refs, err := d.discoverChartRefs()
+	if err != nil {
+		return nil, err
+	}
+	embedded, _, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return nil, err
+	}
+	workbooks := make(map[string]string, len(embedded))
+	for _, chart := range embedded {
+		workbooks[chart.ChartPath] = chart.WorkbookPath
+	}
+
+	// Each failed check emits its alert just before returning its error, so
+	// the two line up.
+	var emitted []ValidationIssue
+	validator := postflight.NewPostflightValidator(&postflight.Document{
+		Overlay: overlay,
+		EmitAlert: func(code, message string, ctx map[string]string) {
+			emitted = append(emitted, ValidationIssue{Code: code, Message: message, Context: ctx})
+		},
+	})
+	stage := overlaystage.NewStagingOverlay(overlay)
+	defer stage.Discard()
+
+	ctx := postflight.ValidateContext{
+		Mode:                 postflight.ModeStrict,
+		CacheSyncEnabled:     !opts.SkipChartCaches,
+		MissingNumericPolicy: int(d.opts.Workbook.MissingNumericPolicy),
+		Cancel:               call.cancel,
+	}
+	if call.mode() == BestEffort {
+		ctx.Mode = postflight.ModeBestEffort
+	}
+	if opts.AllowSharedStrings {
+		// An empty cell list lets sharedStrings.xml stay and no cell fail.
+		ctx.SharedStringCells = map[string]map[string]struct{}{}
+	}
+
+	issues := []ValidationIssue{}
+	collect := func(failures []error, err error) error {
+		if err != nil {
+			return err
+		}
+		for i, failure := range failures {
+			issue := emitted[len(emitted)-len(failures)+i]
+			issue.Err = failure
+			if call.mode() != BestEffort {
+				return &ValidationError{Issue: issue}
+			}
+			issues = append(issues, issue)
+		}
+		return nil
+	}
+
+	seenCharts := make(map[string]bool)
+	seenWorkbooks := make(map[string]bool)
+	for _, ref := range refs {
+		if seenCharts[ref.ChartPath] {
+			continue
+		}
+		seenCharts[ref.ChartPath] = true
+		chartCtx := ctx
+		chartCtx.ChartPath = ref.ChartPath
+		chartCtx.SlidePath = ref.SlidePath
+		chartCtx.WorkbookPath = workbooks[ref.ChartPath]
+		if err := collect(validator.ValidateChart(chartCtx, stage)); err != nil {
+			return nil, err
+		}
+
+		if chartCtx.WorkbookPath == "" || seenWorkbooks[chartCtx.WorkbookPath] {
+			continue
+		}
+		seenWorkbooks[chartCtx.WorkbookPath] = true
+		if err := collect(validator.ValidateWorkbook(chartCtx, stage)); err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,81 @@
+package pptx
+
+import (
+	"fmt"
+)
+
+// ValidationResult is the verdict of ValidateChartData.
+type ValidationResult struct {
+	// Valid reports that the data would apply: the write, cache sync, and
+	// postflight checks all passed and the stage would have committed.
+	Valid bool `json:"valid"`
+	// Err is the error ApplyChartDataByPath would have returned, if any;
+	// Error is its message.
+	Err   error  `json:"-"`
+	Error string `json:"error,omitempty"`
+	// Alerts are the alerts the apply would have raised, postflight alerts
+	// included. They are not added to the document.
+	Alerts []Alert `json:"alerts,omitempty"`
+}
+
+// dryRun collects what a ValidateChartData run would have done.
+type dryRun struct {
+	alerts []Alert
+	// staged is set once a stage passes postflight, where a real apply
+	// would commit it.
+	staged bool
+}
+
+// ValidateChartData runs ApplyChartDataByPath for data against chartPath
+// without changing the document. The write, cache sync, and postflight
+// validation run in a staging overlay that is discarded whatever the
+// outcome, so unlike PlanChanges it catches every issue the apply would.
+// Alerts go to the result instead of Alerts or the WithAlertHandler
+// handler, and neither the change manifest nor CacheSyncResults record the
+// run. Alerts that reads running alongside raise still go to the document.
+// The error is for a chart path that names no chart; a failing apply is
+// reported in the result.
+func (d *Document) ValidateChartData(chartPath string, data ChartDataInput) (ValidationResult, error) {
+	if d == nil || d.pkg == nil {
+		return ValidationResult{}, fmt.Errorf("document not initialized")
+	}
+	chartPath = normalizeChartPath(chartPath)
+	if chartPath == "" {
+		return ValidationResult{}, fmt.Errorf("chart path is required")
+	}
+	call := d.newCall(nil)
+	index, err := call.chartIndexByPath(chartPath)
+	if err != nil {
+		return ValidationResult{}, err
+	}
+
+	run := &dryRun{}
+	call.dryRun = run
+	result := ValidationResult{}
+	if err := call.applyChartData(index, data); err != nil {
+		result.Err = err
+		result.Error = err.Error()
+	}
+	result.Valid = result.Err == nil && run.staged
+	result.Alerts = run.alerts
+	return result, nil
+}
+
+// chartIndexByPath returns the ApplyChartData index of chartPath, or the
+// ChartPathError ApplyChartDataByPath would return.
+func (d *docCall) chartIndexByPath(chartPath string) (int, error) {
+	charts, err := d.listCharts()
+	if err != nil {
+		return 0, err
+	}
+	for _, chart := range charts {
+		if chart.ChartPath == chartPath {
+			return chart.Index, nil
+		}
+	}
+	embedded, skipped, err := d.discoverEmbeddedCharts()
+	if err != nil {
+		return 0, err
+	}
+	return 0, d.chartPathError(chartPath, embedded, skipped)
+}
@@ -0,0 +1,168 @@
+package pptx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateChartDataLeavesDocumentUntouched(t *testing.T) {
+	exercisesFeature(t, "apply.validate")
+
+	var handled []Alert
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(manifestOptions()), WithAlertHandler(func(alert Alert) {
+		handled = append(handled, alert)
+	}))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) == 0 {
+		t.Fatalf("ListCharts: %v %v", charts, err)
+	}
+	chartPath := charts[0].ChartPath
+	chartBefore, _ := doc.pkg.ReadPart(chartPath)
+	workbookBefore, _ := doc.pkg.ReadPart(rangeWorkbookPath)
+
+	result, err := doc.ValidateChartData(chartPath, ChartDataInput{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+	})
+	if err != nil {
+		t.Fatalf("ValidateChartData: %v", err)
+	}
+	if !result.Valid || result.Err != nil || result.Error != "" {
+		t.Fatalf("expected a valid result, got %#v", result)
+	}
+
+	if after, _ := doc.pkg.ReadPart(chartPath); !bytes.Equal(after, chartBefore) {
+		t.Fatalf("chart xml changed by validation")
+	}
+	if after, _ := doc.pkg.ReadPart(rangeWorkbookPath); !bytes.Equal(after, workbookBefore) {
+		t.Fatalf("workbook changed by validation")
+	}
+	if len(doc.CacheSyncResults()) != 0 {
+		t.Fatalf("validation must not record cache syncs: %#v", doc.CacheSyncResults())
+	}
+	if len(doc.Alerts()) != 0 || len(handled) != 0 {
+		t.Fatalf("validation must not raise document alerts: %#v %#v", doc.Alerts(), handled)
+	}
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	if manifest, err := doc.ChangeManifest(); err != nil || manifest == nil || len(manifest.Runs) != 1 || len(manifest.Runs[0].Changes) != 0 {
+		t.Fatalf("validation must not add manifest changes: %#v %v", manifest, err)
+	}
+	if got := readZipEntry(t, outputPath, chartPath); !bytes.Equal(got, chartBefore) {
+		t.Fatalf("saved chart xml changed by validation")
+	}
+
+	if err := doc.ApplyChartDataByPath(chartPath, map[string][]string{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "200"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByPath after validation: %v", err)
+	}
+	if after, _ := doc.pkg.ReadPart(rangeWorkbookPath); bytes.Equal(after, workbookBefore) {
+		t.Fatalf("expected the real apply to write the workbook")
+	}
+}
+
+func TestValidateChartDataReportsFailures(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	charts, err := doc.ListCharts()
+	if err != nil || len(charts) == 0 {
+		t.Fatalf("ListCharts: %v %v", charts, err)
+	}
+	chartPath := charts[0].ChartPath
+
+	result, err := doc.ValidateChartData(chartPath, ChartDataInput{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100", "many"},
+	})
+	if err != nil {
+		t.Fatalf("ValidateChartData: %v", err)
+	}
+	if result.Valid || result.Err == nil || result.Error == "" {
+		t.Fatalf("expected a non-numeric value to fail, got %#v", result)
+	}
+
+	var pathErr *ChartPathError
+	if _, err := doc.ValidateChartData("ppt/charts/chart99.xml", ChartDataInput{}); !errors.As(err, &pathErr) {
+		t.Fatalf("expected ChartPathError, got %v", err)
+	}
+
+	doc, err = OpenFile(fixturePath("bar_simple_embedded.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	result, err = doc.ValidateChartData(chartPath, ChartDataInput{
+		"categories": {"NewA", "NewB"},
+		"values:0":   {"100"},
+	})
+	if err != nil {
+		t.Fatalf("ValidateChartData: %v", err)
+	}
+	if result.Valid || len(result.Alerts) == 0 {
+		t.Fatalf("expected BestEffort to report the skipped write, got %#v", result)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("validation alerts must stay out of the document: %#v", doc.Alerts())
+	}
+}
+
+func TestValidateChartDataConcurrentReads(t *testing.T) {
+	doc, err := OpenFile(unreadableSheetDeck(t), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	const chartPath = "ppt/charts/chart1.xml"
+	data := ChartDataInput{"categories": {"A", "B"}, "values:0": {"1", "2"}}
+	alone, err := doc.ValidateChartData(chartPath, data)
+	if err != nil || alone.Valid {
+		t.Fatalf("expected validation to fail on the unreadable sheet, got %#v, %v", alone, err)
+	}
+
+	// Validation stages a write, so only reads run alongside it.
+	const extractions = 8
+	done := make(chan error)
+	go func() {
+		for i := 0; i < extractions; i++ {
+			result, err := doc.ValidateChartData(chartPath, data)
+			if err != nil || len(result.Alerts) != len(alone.Alerts) {
+				done <- fmt.Errorf("validation %d: expected %d alerts of its own, got %#v, %v", i, len(alone.Alerts), result.Alerts, err)
+				return
+			}
+		}
+		done <- nil
+	}()
+	for i := 0; i < extractions; i++ {
+		go func(i int) {
+			if _, err := doc.ExtractAllCharts(); err != nil {
+				done <- fmt.Errorf("extraction %d: %v", i, err)
+				return
+			}
+			done <- nil
+		}(i)
+	}
+	for i := 0; i < extractions+1; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+	alerts := doc.Alerts()
+	if len(alerts) != extractions {
+		t.Fatalf("expected one alert per extraction, got %#v", alerts)
+	}
+	for _, alert := range alerts {
+		if alert.Code != "EXTRACT_SHEET_NOT_FOUND" {
+			t.Fatalf("expected only extraction alerts on the document, got %#v", alerts)
+		}
+	}
+}
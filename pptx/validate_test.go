@@ -0,0 +1,87 @@
+package pptx
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateCleanDeck(t *testing.T) {
+	exercisesFeature(t, "postflight.validate")
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	issues, err := doc.Validate(ValidateOptions{})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", issues)
+	}
+}
+
+func TestValidateSharedStrings(t *testing.T) {
+	doc, err := OpenFile(fixturePath("xlsx_sharedStrings_present.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	issues, err := doc.Validate(ValidateOptions{})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(issues) != 2 || issues[0].Code != "POSTFLIGHT_XLSX_SHAREDSTRINGS_DETECTED" || issues[1].Code != "POSTFLIGHT_XLSX_CELL_TYPE_MISMATCH" {
+		t.Fatalf("unexpected issues %+v", issues)
+	}
+	if issues[1].Context["sheetPath"] == "" || issues[1].Context["stage"] != "postflight" {
+		t.Fatalf("unexpected context %v", issues[1].Context)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("Validate should record no alerts, got %+v", doc.Alerts())
+	}
+
+	issues, err = doc.Validate(ValidateOptions{AllowSharedStrings: true})
+	if err != nil || len(issues) != 0 {
+		t.Fatalf("expected shared strings to be allowed, got %+v, %v", issues, err)
+	}
+
+	strict, err := OpenFile(fixturePath("xlsx_sharedStrings_present.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile strict: %v", err)
+	}
+	_, err = strict.Validate(ValidateOptions{})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Issue.Code != "POSTFLIGHT_XLSX_SHAREDSTRINGS_DETECTED" {
+		t.Fatalf("expected the first issue as a ValidationError, got %v", err)
+	}
+}
+
+func TestValidateReportsEveryPart(t *testing.T) {
+	parts := corpusZipEntries(t, readCorpusFile(t, fixturePath("bar_simple_embedded.pptx")))
+	parts["ppt/charts/chart1.xml"] = append(parts["ppt/charts/chart1.xml"], "<c:broken"...)
+	parts["ppt/charts/_rels/chart1.xml.rels"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/embeddedWorkbook1.xlsx"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="../media/image1.png"/></Relationships>`)
+	path := filepath.Join(t.TempDir(), "broken.pptx")
+	if err := writeZipFile(path, parts); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	doc, err := OpenFile(path, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	issues, err := doc.Validate(ValidateOptions{})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(issues) != 2 || issues[0].Code != "POSTFLIGHT_XML_MALFORMED" || issues[1].Code != "POSTFLIGHT_REL_TARGET_MISSING" {
+		t.Fatalf("unexpected issues %+v", issues)
+	}
+	if issues[0].Context["chartPath"] != "ppt/charts/chart1.xml" || issues[1].Context["target"] != "ppt/media/image1.png" {
+		t.Fatalf("unexpected context %v %v", issues[0].Context, issues[1].Context)
+	}
+	if issues[0].Err == nil {
+		t.Fatalf("expected the check error on the issue")
+	}
+}
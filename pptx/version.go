@@ -0,0 +1,196 @@
+package pptx
+
+import "runtime/debug"
+
+const (
+	modulePath     = "why-pptx"
+	defaultVersion = "v2.0.0"
+)
+
+// version is set at link time:
+//
+//	go build -ldflags "-X why-pptx/pptx.version=v2.1.0"
+var version string
+
+// Version returns the library version: the value linked in through -ldflags
+// if there is one, else the module version from the binary's build info,
+// else the version of this source tree.
+func Version() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v := moduleVersion(info); v != "" {
+			return v
+		}
+	}
+	return defaultVersion
+}
+
+func moduleVersion(info *debug.BuildInfo) string {
+	module := &info.Main
+	if module.Path != modulePath {
+		module = nil
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				module = dep
+				break
+			}
+		}
+	}
+	if module == nil {
+		return ""
+	}
+	if module.Replace != nil && module.Replace.Version != "" {
+		return module.Replace.Version
+	}
+	if module.Version == "(devel)" {
+		return ""
+	}
+	return module.Version
+}
+
+// features are the capability flags reported by Features. A flag is added
+// with the feature it describes and flipped when a build gains or drops it;
+// keys are never reused for something else. False entries name capabilities
+// callers commonly ask about that this build does not have.
+var features = map[string]bool{
+	// ExtractChartDataByPath and ExtractAllCharts.
+	"extract.bar":      true,
+	"extract.line":     true,
+	"extract.pie":      true,
+	"extract.doughnut": true,
+	"extract.area":     true,
+	"extract.mixed":    true,
+	"extract.stock":    true,
+	"extract.scatter":  true,
+	"extract.radar":    true,
+	// ExtractedSeries.FormatCode and ExtractedChartData.LabelsFormatCode.
+	"extract.format-codes": true,
+	// ExtractChartDataStream.
+	"extract.stream": true,
+	// ExtractAllChartsParallel.
+	"extract.parallel": true,
+	// Options.Extract.UseCacheFallback and ExtractMeta.Source.
+	"extract.cache-fallback": true,
+	// Options.Extract.ConvertDates: ISO-8601 labels for date categories.
+	"extract.dates": true,
+	// Cached results of formula cells, and Options.Workbook.RejectFormulaCells.
+	"extract.formula-cells": true,
+
+	// ApplyChartData and ApplyChartDataByPath.
+	"apply.bar":      true,
+	"apply.line":     true,
+	"apply.pie":      true,
+	"apply.doughnut": true,
+	"apply.area":     true,
+	"apply.mixed":    true,
+	"apply.stock":    true,
+	// Options.Chart.AllowExpressions.
+	"apply.expressions": true,
+	// Options.Chart.AllowResize.
+	"apply.resize": true,
+	// Partial ApplyChartData input; Options.Chart.RequireAllSeries.
+	"apply.partial": true,
+	// Document.ValidateChartData.
+	"apply.validate": true,
+	// Document.ApplyChartDataByIndex.
+	"apply.by-index": true,
+	// Case- and whitespace-insensitive ApplyChartDataByName, shape name
+	// fallback, and the OnSlide scope.
+	"apply.name-match": true,
+	// "name:N" keys in ChartDataInput, mixed charts included.
+	"apply.series-names": true,
+	// Options.Input.NumberFormat: localized ChartDataInput numbers.
+	"input.number-format": true,
+	// Options.Input.CoerceNumbers: "12%", "1 000", and "N/A" in
+	// ChartDataInput values.
+	"input.coerce-numbers": true,
+	// Series numbered by c:order rather than c:ser position in extract,
+	// apply, and cache sync.
+	"series.order": true,
+	// PlannedChart.Changes: PlanChanges diffs PlanRequest.Data against the
+	// workbook.
+	"plan.changes": true,
+	// Union series formulas (ChartRange.UnionIndex) in extraction, apply,
+	// and cache sync, except for mixed charts.
+	"ranges.union": true,
+	// Data label ranges (RangeDataLabels and ExtractedSeries.LabelTexts) in
+	// extraction and cache sync, except for mixed charts.
+	"ranges.datalabels": true,
+	// Whole-column and whole-row series formulas ("Sheet1!$A:$A"), cut to
+	// the sheet's used range or the cache's ptCount.
+	"ranges.whole": true,
+	// Multi-level categories (c:multiLvlStrRef): ExtractedChartData.LabelGroups,
+	// and cache sync keeping the multiLvlStrCache.
+	"ranges.multilevel": true,
+
+	// Options.Chart.CacheSync and SyncChartCaches, including pie and area
+	// caches.
+	"cachesync.bar":      true,
+	"cachesync.line":     true,
+	"cachesync.pie":      true,
+	"cachesync.doughnut": true,
+	"cachesync.area":     true,
+	"cachesync.mixed":    true,
+	"cachesync.stock":    true,
+	// SyncChartCachesFor and SyncChartCachesForWorkbook.
+	"cachesync.selective": true,
+	// RepairChartCaches.
+	"cache.repair": true,
+	// Document.Validate: the postflight checks on a whole package.
+	"postflight.validate": true,
+
+	// ExportChartByPathFormat and the default exporter registry.
+	"export.chartjs":  true,
+	"export.csv":      true,
+	"export.vegalite": true,
+	// ChartJSExporter Palette, DisableAreaFill, Tension, Stacked, and
+	// Scales.
+	"export.chartjs-options": true,
+	// Document.RegisterExporter and RegisterDefaultExporter.
+	"export.register": true,
+
+	// ImportChart and ConvertChartType.
+	"chart.import":          true,
+	"chart.convert.barline": true,
+	// SetValueAxisNumberFormat.
+	"chart.axis-numfmt": true,
+	// SetChartTitle.
+	"chart.title": true,
+	// SetChartDataLabels, and DataLabels on ChartInfo and extracted data.
+	"chart.data-labels": true,
+	// ChartInfo.AxisGroups and HasSecondaryAxis, also on PlannedChart.
+	"chart.axis-info": true,
+	// Document.VerifyChartCaches.
+	"chart.verify-caches": true,
+
+	// Embedded workbooks: SetWorkbookCells writes inline strings, and reads
+	// resolve t="s" cells through sharedStrings.xml.
+	"workbook.write":              true,
+	"workbook.sharedstrings-read": true,
+	// ApplyChartData with Workbook.ConvertSharedStrings.
+	"workbook.sharedstrings-convert": true,
+	// WorkbookSheets panes and print areas.
+	"workbook.sheet-layout": true,
+	// SetWorkbookRange.
+	"workbook.range-write": true,
+	// ListWorkbookSheets and GetWorkbookCells.
+	"workbook.read": true,
+
+	// Options.Save.WriteChangeManifest.
+	"manifest.write": true,
+	// Options.Output compression levels and deterministic saves.
+	"save.deterministic": true,
+}
+
+// Features returns the capability flags of this build, keyed by names such
+// as "extract.pie" or "apply.area", so a caller can decide at runtime whether
+// a document can be handled here. The map is a copy.
+func Features() map[string]bool {
+	out := make(map[string]bool, len(features))
+	for name, enabled := range features {
+		out[name] = enabled
+	}
+	return out
+}
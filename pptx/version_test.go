@@ -0,0 +1,152 @@
+package pptx
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"testing"
+
+	"why-pptx/internal/testutil/pptxassert"
+)
+
+var (
+	exercisedMu sync.Mutex
+	exercised   = map[string]bool{}
+)
+
+// exercisesFeature records that the calling test covers the capability
+// behind a Features flag, or for a false flag, that it is indeed missing.
+func exercisesFeature(t *testing.T, names ...string) {
+	t.Helper()
+	exercisedMu.Lock()
+	defer exercisedMu.Unlock()
+	for _, name := range names {
+		if _, ok := features[name]; !ok {
+			t.Fatalf("unknown feature flag %q", name)
+		}
+		exercised[name] = true
+	}
+}
+
+// TestMain fails a full run of the package tests when a Features flag has
+// no test exercising it, so a flag cannot outlive its feature unnoticed.
+// Runs narrowed with -run skip the check.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+	if code == 0 && flag.Lookup("test.run").Value.String() == "" {
+		var missing []string
+		for name := range features {
+			if !exercised[name] {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			fmt.Fprintf(os.Stderr, "feature flags without a test calling exercisesFeature: %v\n", missing)
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+func TestFeaturesReturnsCopy(t *testing.T) {
+	got := Features()
+	if !reflect.DeepEqual(got, features) {
+		t.Fatalf("Features() differs from the flag table")
+	}
+	got["extract.bar"] = false
+	if !Features()["extract.bar"] {
+		t.Fatalf("mutating the result changed the flags")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	old := version
+	defer func() { version = old }()
+
+	version = "v9.9.9-test"
+	if got := Version(); got != "v9.9.9-test" {
+		t.Fatalf("linked version ignored, got %q", got)
+	}
+	version = ""
+	if got := Version(); got == "" {
+		t.Fatalf("expected a default version")
+	}
+}
+
+func TestModuleVersion(t *testing.T) {
+	cases := map[string]struct {
+		info debug.BuildInfo
+		want string
+	}{
+		"main module devel": {
+			info: debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "(devel)"}},
+		},
+		"dependency": {
+			info: debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/service", Version: "(devel)"},
+				Deps: []*debug.Module{{Path: modulePath, Version: "v2.1.0"}},
+			},
+			want: "v2.1.0",
+		},
+		"replaced dependency": {
+			info: debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/service"},
+				Deps: []*debug.Module{{Path: modulePath, Version: "v2.1.0", Replace: &debug.Module{Path: "../why-pptx", Version: "v2.1.1"}}},
+			},
+			want: "v2.1.1",
+		},
+		"not linked": {
+			info: debug.BuildInfo{Main: debug.Module{Path: "example.com/service"}},
+		},
+	}
+	for name, tc := range cases {
+		if got := moduleVersion(&tc.info); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+func TestApplyChartDataLineChart(t *testing.T) {
+	exercisesFeature(t, "apply.line", "cachesync.line")
+
+	input := fixturePath("line_multi_series_embedded.pptx")
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	doc, err := OpenFile(input)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{
+		"categories": {"Q1", "Q2", "Q3"},
+		"values:0":   {"7", "8", "9"},
+		"values:1":   {"10", "11", "12"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	chartXML, err := pptxassert.ReadEntry(output, "ppt/charts/chart1.xml")
+	if err != nil {
+		t.Fatalf("ReadEntry chart: %v", err)
+	}
+	snap, err := pptxassert.ExtractChartCacheSnapshot(chartXML)
+	if err != nil {
+		t.Fatalf("ExtractChartCacheSnapshot: %v", err)
+	}
+	pptxassert.AssertCacheMatchesExpected(t, snap, pptxassert.ExpectedCache{
+		Series: []pptxassert.ExpectedCacheSeries{
+			{Kind: "strCache", SeriesIndex: 0, Values: []string{"Q1", "Q2", "Q3"}},
+			{Kind: "numCache", SeriesIndex: 0, Values: []string{"7", "8", "9"}},
+			{Kind: "strCache", SeriesIndex: 1, Values: []string{"Q1", "Q2", "Q3"}},
+			{Kind: "numCache", SeriesIndex: 1, Values: []string{"10", "11", "12"}},
+		},
+	})
+}
@@ -0,0 +1,71 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+
+	"why-pptx/internal/xlref"
+	"why-pptx/internal/xlsxembed"
+)
+
+// wholeRangeResolver narrows the whole-column and whole-row references of
+// one chart's formulas, opening the chart's workbook at most once for all
+// of them.
+type wholeRangeResolver struct {
+	d            *docCall
+	workbookPath string
+	opened       bool
+	wb           *xlsxembed.Workbook
+}
+
+// narrow cuts a whole-column or whole-row ref to the rows or columns its
+// sheet uses. When the workbook cannot be read or the sheet holds no values,
+// it covers the ptCount points of the formula's cache instead, from row 1
+// or column A. Other refs are returned as they are.
+func (w *wholeRangeResolver) narrow(ref xlref.RangeRef, ptCount int) (xlref.RangeRef, error) {
+	if ref.Whole == xlref.NotWhole {
+		return ref, nil
+	}
+	if wb := w.workbook(); wb != nil {
+		if used, ok, err := wb.UsedRange(ref.Sheet); err == nil && ok {
+			return ref.Narrow(used), nil
+		}
+	}
+	if ptCount > 0 {
+		return ref.Narrow(xlref.Bounds{StartCol: 1, EndCol: ptCount, StartRow: 1, EndRow: ptCount}), nil
+	}
+	kind := "column"
+	if ref.Whole == xlref.WholeRows {
+		kind = "row"
+	}
+	return xlref.RangeRef{}, fmt.Errorf("whole-%s reference on sheet %q: no used cells or cached points to size it by", kind, ref.Sheet)
+}
+
+func (w *wholeRangeResolver) workbook() *xlsxembed.Workbook {
+	if w.opened {
+		return w.wb
+	}
+	w.opened = true
+	if w.workbookPath == "" {
+		return nil
+	}
+	data, err := w.d.pkg.ReadPart(w.workbookPath)
+	if err != nil {
+		return nil
+	}
+	w.wb, _ = w.d.openEmbeddedWorkbook(w.workbookPath, data)
+	return w.wb
+}
+
+// dependencyFailureCode is the alert code for a chart whose dependencies
+// could not be read: CHART_NAMED_RANGE_UNSUPPORTED, with the name added to
+// context, when a series formula is a defined name, and
+// CHART_DEPENDENCIES_PARSE_FAILED otherwise.
+func dependencyFailureCode(err error, context map[string]string) string {
+	var named *xlref.NamedRangeError
+	if errors.As(err, &named) {
+		context["name"] = named.Name
+		return "CHART_NAMED_RANGE_UNSUPPORTED"
+	}
+	return "CHART_DEPENDENCIES_PARSE_FAILED"
+}
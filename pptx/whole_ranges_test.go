@@ -0,0 +1,103 @@
+package pptx
+
+import (
+	"reflect"
+	"testing"
+)
+
+const wholeColumnChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A:$A</c:f><c:strCache><c:ptCount val="2"/><c:pt idx="0"><c:v>Cat1</c:v></c:pt><c:pt idx="1"><c:v>Cat2</c:v></c:pt></c:strCache></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!B:$B</c:f><c:numCache><c:ptCount val="2"/><c:pt idx="0"><c:v>10</c:v></c:pt><c:pt idx="1"><c:v>20</c:v></c:pt></c:numCache></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+const namedRangeChart = `<?xml version="1.0" encoding="UTF-8"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart">
+  <c:chart>
+    <c:plotArea>
+      <c:barChart>
+        <c:ser>
+          <c:cat><c:strRef><c:f>Sheet1!$A$2:$A$3</c:f></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>Sheet1!Revenue</c:f></c:numRef></c:val>
+        </c:ser>
+      </c:barChart>
+    </c:plotArea>
+  </c:chart>
+</c:chartSpace>`
+
+func TestGetChartDependenciesWholeColumns(t *testing.T) {
+	exercisesFeature(t, "ranges.whole")
+
+	doc, err := OpenFile(writeRepairDeck(t, t.TempDir(), wholeColumnChart, nil))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	deps, err := doc.GetChartDependencies()
+	if err != nil {
+		t.Fatalf("GetChartDependencies: %v", err)
+	}
+	if len(deps) != 1 || len(deps[0].Ranges) != 2 {
+		t.Fatalf("expected one chart with two ranges, got %#v", deps)
+	}
+	var got [][3]string
+	for _, r := range deps[0].Ranges {
+		got = append(got, [3]string{r.StartCell, r.EndCell, r.Formula})
+	}
+	want := [][3]string{{"A2", "A3", "Sheet1!$A:$A"}, {"B2", "B3", "Sheet1!B:$B"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected ranges narrowed to the used rows, got %v", got)
+	}
+
+	data := ChartDataInput{"categories": {"North", "South"}, "values:0": {"7", "8"}}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", data); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	cells, err := doc.GetWorkbookCells("ppt/embeddings/embeddedWorkbook1.xlsx", "Sheet1", []string{"A2", "A3", "B2", "B3"})
+	if err != nil {
+		t.Fatalf("GetWorkbookCells: %v", err)
+	}
+	if !reflect.DeepEqual(cells, map[string]string{"A2": "North", "A3": "South", "B2": "7", "B3": "8"}) {
+		t.Fatalf("unexpected workbook cells: %#v", cells)
+	}
+}
+
+func TestGetChartDependenciesNamedRange(t *testing.T) {
+	path := writeRepairDeck(t, t.TempDir(), namedRangeChart, nil)
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.GetChartDependencies(); err == nil {
+		t.Fatalf("expected a named range to fail in Strict mode")
+	}
+
+	doc, err = OpenFile(path, WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	deps, err := doc.GetChartDependencies()
+	if err != nil {
+		t.Fatalf("GetChartDependencies: %v", err)
+	}
+	if len(deps) != 0 {
+		t.Fatalf("expected the chart to be skipped, got %#v", deps)
+	}
+	found := false
+	for _, alert := range doc.Alerts() {
+		if alert.Code == "CHART_NAMED_RANGE_UNSUPPORTED" {
+			found = alert.Context["name"] == "Sheet1!Revenue"
+		}
+	}
+	if !found {
+		t.Fatalf("expected CHART_NAMED_RANGE_UNSUPPORTED naming the range, got %#v", doc.Alerts())
+	}
+}
@@ -0,0 +1,56 @@
+package pptx
+
+import (
+	"crypto/sha256"
+
+	"why-pptx/internal/xlsxembed"
+)
+
+// workbookCache shares the embedded workbooks opened by one SyncChartCaches
+// call across its charts, so a workbook backing many charts is unzipped and
+// its sheet map read once. As with chartModels, an entry is reused only
+// while the workbook part's bytes hash to the same sum: a workbook rewritten
+// earlier in the call, through the package or a staged overlay, is opened
+// again without the write paths having to invalidate anything.
+type workbookCache struct {
+	byPath map[string]*cachedWorkbook
+}
+
+type cachedWorkbook struct {
+	sum [sha256.Size]byte
+	wb  *xlsxembed.Workbook
+}
+
+// beginWorkbookCache shares the call's workbook opens until the returned
+// func is called. Beginning again while a cache is in effect keeps the
+// outer one.
+func (d *docCall) beginWorkbookCache() func() {
+	if d.workbooks != nil {
+		return func() {}
+	}
+	d.workbooks = &workbookCache{byPath: make(map[string]*cachedWorkbook)}
+	return func() { d.workbooks = nil }
+}
+
+// openSharedWorkbook is openEmbeddedWorkbook through the workbook cache of
+// the call, if any. data is the current content of workbookPath.
+// The workbook may be handed to later charts, so callers only read it.
+func (d *docCall) openSharedWorkbook(workbookPath string, data []byte) (*xlsxembed.Workbook, error) {
+	cache := d.workbooks
+	var sum [sha256.Size]byte
+	if cache != nil {
+		sum = sha256.Sum256(data)
+		if entry, ok := cache.byPath[workbookPath]; ok && entry.sum == sum {
+			return entry.wb, nil
+		}
+	}
+	wb, err := d.openEmbeddedWorkbook(workbookPath, data)
+	if err != nil {
+		return nil, err
+	}
+	d.addStats(Stats{WorkbookOpens: 1})
+	if cache != nil {
+		cache.byPath[workbookPath] = &cachedWorkbook{sum: sum, wb: wb}
+	}
+	return wb, nil
+}
@@ -0,0 +1,119 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+
+	"why-pptx/internal/ooxmlpkg"
+	"why-pptx/internal/xlref"
+	"why-pptx/internal/xlsxembed"
+)
+
+// ListWorkbookSheets lists the sheet names of an embedded workbook in
+// workbook order. Like every read, it sees cell writes made since the
+// document was opened.
+func (d *Document) ListWorkbookSheets(workbookPath string) ([]string, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	workbookPath = normalizeChartPath(workbookPath)
+	wb, err := call.readWorkbook(workbookPath, "")
+	if err != nil {
+		return nil, err
+	}
+	names, err := wb.SheetNames()
+	if err != nil {
+		return nil, call.handleWorkbookReadError("EXTRACT_CELL_PARSE_ERROR", workbookPath, "", fmt.Errorf("workbook %q: %w", workbookPath, err))
+	}
+	return names, nil
+}
+
+// GetWorkbookCells reads cells of one sheet as text, keyed by normalized
+// reference ("b2" reads as "B2"). Shared strings are resolved, and cells
+// the sheet does not have read as "". Pending SetWorkbookCells and
+// ApplyChartData writes are visible. An unknown workbook, sheet, or cell
+// reference fails in both modes; under BestEffort it is also reported as
+// EXTRACT_WORKBOOK_NOT_FOUND, EXTRACT_SHEET_NOT_FOUND,
+// EXTRACT_UNSUPPORTED_CELL_TYPE, or EXTRACT_CELL_PARSE_ERROR.
+func (d *Document) GetWorkbookCells(workbookPath, sheet string, cells []string) (map[string]string, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	workbookPath = normalizeChartPath(workbookPath)
+	wb, err := call.readWorkbook(workbookPath, sheet)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := wb.SheetPath(sheet); !ok {
+		return nil, call.handleWorkbookReadError("EXTRACT_SHEET_NOT_FOUND", workbookPath, sheet, fmt.Errorf("sheet %q not found in workbook %q", sheet, workbookPath))
+	}
+
+	refs := make([]string, 0, len(cells))
+	ranges := make([]xlsxembed.Range, 0, len(cells))
+	seen := make(map[string]struct{}, len(cells))
+	for _, cell := range cells {
+		ref, err := xlref.NormalizeCellRef(cell)
+		if err != nil {
+			return nil, call.handleWorkbookReadError("EXTRACT_CELL_PARSE_ERROR", workbookPath, sheet, fmt.Errorf("cell %q: %w", cell, err))
+		}
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+		refs = append(refs, ref)
+		ranges = append(ranges, xlsxembed.Range{Sheet: sheet, StartCell: ref, EndCell: ref})
+	}
+
+	out := make(map[string]string, len(refs))
+	if len(refs) == 0 {
+		return out, nil
+	}
+	values, err := wb.GetRanges(ranges, xlsxembed.MissingNumericEmpty)
+	if err != nil {
+		return nil, call.handleWorkbookReadError(workbookReadCode(err), workbookPath, sheet, fmt.Errorf("workbook %q: %w", workbookPath, err))
+	}
+	for i, ref := range refs {
+		if len(values[i]) > 0 {
+			out[ref] = values[i][0]
+		} else {
+			out[ref] = ""
+		}
+	}
+	return out, nil
+}
+
+// readWorkbook opens an embedded workbook through the package overlay.
+func (d *docCall) readWorkbook(workbookPath, sheet string) (*xlsxembed.Workbook, error) {
+	if workbookPath == "" {
+		return nil, fmt.Errorf("workbook path is required")
+	}
+	data, err := d.pkg.ReadPart(workbookPath)
+	if err != nil {
+		code := "EXTRACT_CELL_PARSE_ERROR"
+		if errors.Is(err, ooxmlpkg.ErrPartNotFound) {
+			code = "EXTRACT_WORKBOOK_NOT_FOUND"
+		}
+		return nil, d.handleWorkbookReadError(code, workbookPath, sheet, fmt.Errorf("read workbook %q: %w", workbookPath, err))
+	}
+	wb, err := d.openEmbeddedWorkbook(workbookPath, data)
+	if err != nil {
+		return nil, d.handleWorkbookReadError("EXTRACT_CELL_PARSE_ERROR", workbookPath, sheet, fmt.Errorf("open workbook %q: %w", workbookPath, err))
+	}
+	return wb, nil
+}
+
+func (d *docCall) handleWorkbookReadError(code, workbookPath, sheet string, err error) error {
+	context := map[string]string{"workbook": workbookPath, "error": err.Error()}
+	if sheet != "" {
+		context["sheet"] = sheet
+	}
+	addCellTypeContext(context, err)
+	return d.handleExtractError(extractIssue{
+		code:    code,
+		message: extractMessageForCode(code),
+		err:     err,
+		context: context,
+	})
+}
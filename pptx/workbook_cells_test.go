@@ -0,0 +1,83 @@
+package pptx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"why-pptx/internal/ooxmlpkg"
+)
+
+func TestListWorkbookSheetsAndGetWorkbookCells(t *testing.T) {
+	exercisesFeature(t, "workbook.read")
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	sheets, err := doc.ListWorkbookSheets("/" + rangeWorkbookPath)
+	if err != nil || !reflect.DeepEqual(sheets, []string{"Sheet1"}) {
+		t.Fatalf("ListWorkbookSheets = %v, %v", sheets, err)
+	}
+
+	before, err := doc.GetWorkbookCells(rangeWorkbookPath, "Sheet1", []string{"a2", "B2", "B2", "Z99"})
+	if err != nil {
+		t.Fatalf("GetWorkbookCells: %v", err)
+	}
+	if !reflect.DeepEqual(before, map[string]string{"A2": "Old1", "B2": "10", "Z99": ""}) {
+		t.Fatalf("unexpected cells before the update: %#v", before)
+	}
+
+	if err := doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: rangeWorkbookPath, Sheet: "Sheet1", Cell: "B2", Value: Num(42)},
+		{WorkbookPath: rangeWorkbookPath, Sheet: "Sheet1", Cell: "Z99", Value: Str("note")},
+	}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	after, err := doc.GetWorkbookCells(rangeWorkbookPath, "Sheet1", []string{"A2", "B2", "Z99"})
+	if err != nil {
+		t.Fatalf("GetWorkbookCells: %v", err)
+	}
+	if !reflect.DeepEqual(after, map[string]string{"A2": "Old1", "B2": "42", "Z99": "note"}) {
+		t.Fatalf("expected the pending writes to be visible, got %#v", after)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("expected no alerts, got %#v", doc.Alerts())
+	}
+}
+
+func TestGetWorkbookCellsErrors(t *testing.T) {
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.ListWorkbookSheets("ppt/embeddings/missing.xlsx"); !errors.Is(err, ooxmlpkg.ErrPartNotFound) {
+		t.Fatalf("expected ErrPartNotFound in Strict, got %v", err)
+	}
+	if len(doc.Alerts()) != 0 {
+		t.Fatalf("Strict must not alert: %#v", doc.Alerts())
+	}
+
+	doc, err = OpenFile(fixturePath("bar_simple_embedded.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for _, tc := range []struct {
+		workbook string
+		sheet    string
+		cells    []string
+		code     string
+	}{
+		{workbook: "ppt/embeddings/missing.xlsx", sheet: "Sheet1", cells: []string{"A1"}, code: "EXTRACT_WORKBOOK_NOT_FOUND"},
+		{workbook: rangeWorkbookPath, sheet: "Missing", cells: []string{"A1"}, code: "EXTRACT_SHEET_NOT_FOUND"},
+		{workbook: rangeWorkbookPath, sheet: "Sheet1", cells: []string{"1A"}, code: "EXTRACT_CELL_PARSE_ERROR"},
+	} {
+		if _, err := doc.GetWorkbookCells(tc.workbook, tc.sheet, tc.cells); err == nil {
+			t.Fatalf("%s: expected an error", tc.code)
+		}
+		alerts := doc.AlertsByCode(tc.code)
+		if len(alerts) != 1 || alerts[0].Context["workbook"] != tc.workbook || alerts[0].Context["sheet"] != tc.sheet {
+			t.Fatalf("expected one %s alert, got %#v", tc.code, doc.Alerts())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package pptx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"why-pptx/internal/xlref"
+	"why-pptx/internal/xlsxembed"
+)
+
+// ErrCellOutOfBounds is wrapped by the error for a write to a row or column
+// past the worksheet grid (row 1048576, column XFD).
+var ErrCellOutOfBounds = xlsxembed.ErrCellOutOfBounds
+
+// ErrWorkbookWriteLimit is wrapped by the error for a write exceeding
+// Options.Workbook.MaxRowsPerWrite or Options.Workbook.MaxWorkbookBytes.
+var ErrWorkbookWriteLimit = errors.New("workbook write limit exceeded")
+
+// checkWorkbookWrite runs the guards that need only the updates and the
+// workbook as it is, before any cell is written, and then the frozen header
+// warning. Updates the write loop will reject anyway (bad refs, unknown
+// sheets) are left to it.
+func (d *docCall) checkWorkbookWrite(workbookPath string, wb *xlsxembed.Workbook, updates []CellUpdate) error {
+	limit := d.opts.Workbook.MaxRowsPerWrite
+	rowsBySheet := make(map[string]map[int]struct{})
+	for _, update := range updates {
+		col, row, normalized, err := xlref.SplitCellRef(update.Cell)
+		if err != nil {
+			continue
+		}
+		if !xlref.InGrid(col, row) {
+			return fmt.Errorf("update workbook %q sheet %q: %w: %s", workbookPath, update.Sheet, ErrCellOutOfBounds, normalized)
+		}
+		if limit <= 0 {
+			continue
+		}
+		rows := rowsBySheet[update.Sheet]
+		if rows == nil {
+			rows = make(map[int]struct{})
+			rowsBySheet[update.Sheet] = rows
+		}
+		rows[row] = struct{}{}
+	}
+
+	sheets := make([]string, 0, len(rowsBySheet))
+	for sheet, rows := range rowsBySheet {
+		if len(rows) > limit {
+			sheets = append(sheets, sheet)
+		}
+	}
+	sort.Strings(sheets)
+	for _, sheet := range sheets {
+		added, err := wb.NewRows(sheet, rowsBySheet[sheet])
+		if err != nil {
+			continue
+		}
+		if added > limit {
+			return fmt.Errorf("%w: write adds %d rows to sheet %q in %q, over Workbook.MaxRowsPerWrite (%d)", ErrWorkbookWriteLimit, added, sheet, workbookPath, limit)
+		}
+	}
+	d.checkFrozenHeader(workbookPath, wb, updates)
+	return nil
+}
+
+// checkWorkbookSize rejects a rewritten workbook over
+// Options.Workbook.MaxWorkbookBytes. Callers check before writing the part,
+// so the original stays in place.
+func (d *Document) checkWorkbookSize(workbookPath string, data []byte) error {
+	limit := d.opts.Workbook.MaxWorkbookBytes
+	if limit <= 0 || int64(len(data)) <= limit {
+		return nil
+	}
+	return fmt.Errorf("%w: workbook %q would be %d bytes, over Workbook.MaxWorkbookBytes (%d)", ErrWorkbookWriteLimit, workbookPath, len(data), limit)
+}
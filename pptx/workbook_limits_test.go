@@ -0,0 +1,149 @@
+package pptx
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const limitsWorkbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+
+func openLimitsDeck(t *testing.T, mode ErrorMode, configure func(*WorkbookOptions)) *Document {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(path, map[string][]byte{limitsWorkbookPath: buildTestXLSX(t)}); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+	opts := DefaultOptions()
+	opts.Mode = mode
+	configure(&opts.Workbook)
+	doc, err := OpenFile(path, WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	return doc
+}
+
+func TestSetWorkbookCellsRejectsRowExplosion(t *testing.T) {
+	// 20 columns by 100k rows: 2M cells. Writing them one by one would take
+	// far longer than the test runs, so a pass means the write was rejected
+	// up front.
+	value := Num(1)
+	updates := make([]CellUpdate, 0, 2000000)
+	for row := 1; row <= 100000; row++ {
+		suffix := strconv.Itoa(row)
+		for col := 0; col < 20; col++ {
+			updates = append(updates, CellUpdate{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: string(rune('A'+col)) + suffix, Value: value})
+		}
+	}
+
+	for _, mode := range []ErrorMode{Strict, BestEffort} {
+		doc := openLimitsDeck(t, mode, func(o *WorkbookOptions) { o.MaxRowsPerWrite = 10000 })
+		original, err := doc.pkg.ReadPart(limitsWorkbookPath)
+		if err != nil {
+			t.Fatalf("ReadPart: %v", err)
+		}
+
+		err = doc.SetWorkbookCells(updates)
+		if !errors.Is(err, ErrWorkbookWriteLimit) {
+			t.Fatalf("mode %v: expected ErrWorkbookWriteLimit, got %v", mode, err)
+		}
+		current, err := doc.pkg.ReadPart(limitsWorkbookPath)
+		if err != nil {
+			t.Fatalf("ReadPart: %v", err)
+		}
+		if !bytes.Equal(current, original) {
+			t.Fatalf("mode %v: workbook changed by a rejected write", mode)
+		}
+		if len(doc.Alerts()) != 0 {
+			t.Fatalf("mode %v: limit errors must not become alerts: %#v", mode, doc.Alerts())
+		}
+	}
+}
+
+func TestSetWorkbookCellsRowLimitCountsOnlyNewRows(t *testing.T) {
+	doc := openLimitsDeck(t, Strict, func(o *WorkbookOptions) { o.MaxRowsPerWrite = 1 })
+	// buildTestXLSX has row 1; rewriting it adds nothing.
+	updates := []CellUpdate{
+		{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "A1", Value: Num(1)},
+		{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "B1", Value: Num(2)},
+		{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "A2", Value: Num(3)},
+	}
+	if err := doc.SetWorkbookCells(updates); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	updates = append(updates, CellUpdate{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "A3", Value: Num(4)},
+		CellUpdate{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "A4", Value: Num(5)})
+	if err := doc.SetWorkbookCells(updates); !errors.Is(err, ErrWorkbookWriteLimit) {
+		t.Fatalf("expected two new rows to exceed the limit, got %v", err)
+	}
+}
+
+func TestSetWorkbookCellsRejectsOversizedWorkbook(t *testing.T) {
+	for _, mode := range []ErrorMode{Strict, BestEffort} {
+		doc := openLimitsDeck(t, mode, func(o *WorkbookOptions) {})
+		original, err := doc.pkg.ReadPart(limitsWorkbookPath)
+		if err != nil {
+			t.Fatalf("ReadPart: %v", err)
+		}
+		doc.opts.Workbook.MaxWorkbookBytes = int64(len(original)) + 64
+
+		// Random letters do not deflate away, so a few cells outgrow the
+		// limit.
+		rng := rand.New(rand.NewSource(1))
+		var updates []CellUpdate
+		for row := 2; row <= 5; row++ {
+			text := make([]byte, 200)
+			for i := range text {
+				text[i] = byte('a' + rng.Intn(26))
+			}
+			updates = append(updates, CellUpdate{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "A" + strconv.Itoa(row), Value: Str(string(text))})
+		}
+		if err := doc.SetWorkbookCells(updates); !errors.Is(err, ErrWorkbookWriteLimit) {
+			t.Fatalf("mode %v: expected ErrWorkbookWriteLimit, got %v", mode, err)
+		}
+		current, err := doc.pkg.ReadPart(limitsWorkbookPath)
+		if err != nil {
+			t.Fatalf("ReadPart: %v", err)
+		}
+		if !bytes.Equal(current, original) {
+			t.Fatalf("mode %v: oversized write was not rolled back", mode)
+		}
+	}
+}
+
+func TestSetWorkbookCellsRejectsCellsPastGrid(t *testing.T) {
+	for _, cell := range []string{"A1048577", "XFE1"} {
+		doc := openLimitsDeck(t, BestEffort, func(o *WorkbookOptions) {})
+		err := doc.SetWorkbookCells([]CellUpdate{{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: cell, Value: Num(1)}})
+		if !errors.Is(err, ErrCellOutOfBounds) {
+			t.Fatalf("%s: expected ErrCellOutOfBounds, got %v", cell, err)
+		}
+	}
+
+	doc := openLimitsDeck(t, Strict, func(o *WorkbookOptions) {})
+	if err := doc.SetWorkbookCells([]CellUpdate{{WorkbookPath: limitsWorkbookPath, Sheet: "Sheet1", Cell: "XFD1048576", Value: Num(1)}}); err != nil {
+		t.Fatalf("last grid cell must be writable: %v", err)
+	}
+}
+
+func TestApplyChartDataRollsBackOversizedWorkbook(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Workbook.MaxWorkbookBytes = 100
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	err = doc.ApplyChartData(0, map[string][]string{
+		"categories": {"A", "B"},
+		"values:0":   {"1", "2"},
+	})
+	if !errors.Is(err, ErrWorkbookWriteLimit) {
+		t.Fatalf("expected ErrWorkbookWriteLimit, got %v", err)
+	}
+	assertExpressionResult(t, doc, []string{"10", "20"})
+}
@@ -0,0 +1,121 @@
+package pptx
+
+import (
+	"fmt"
+
+	"why-pptx/internal/xlref"
+	"why-pptx/internal/xlsxembed"
+)
+
+// RangeDirection is the way SetWorkbookRange fills cells from its start
+// cell.
+type RangeDirection int
+
+const (
+	// RangeDown fills a column, one row after another.
+	RangeDown RangeDirection = iota
+	// RangeRight fills a row, one column after another.
+	RangeRight
+)
+
+// SetWorkbookRange writes values to consecutive cells of one sheet from
+// startCell, down a column or along a row, and returns the normalized last
+// cell written, the end of the range a chart formula would name. It is
+// SetWorkbookCells for a run of cells with a single rewrite of the sheet:
+// missing rows are created in order, other cells and row attributes are
+// kept, and the same value checks and workbook limits apply. In BestEffort
+// a failed write is reported as WORKBOOK_UPDATE_FAILED and returns "" and
+// nil; the workbook limits still fail.
+func (d *Document) SetWorkbookRange(workbookPath, sheet, startCell string, values []CellValue, direction RangeDirection) (string, error) {
+	if d == nil || d.pkg == nil {
+		return "", fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	first := CellUpdate{WorkbookPath: workbookPath, Sheet: sheet, Cell: startCell}
+	if workbookPath == "" {
+		return "", call.handleWorkbookUpdateError(first, fmt.Errorf("workbook path is required"))
+	}
+	if sheet == "" {
+		return "", call.handleWorkbookUpdateError(first, fmt.Errorf("sheet name is required"))
+	}
+	updates, err := rangeUpdates(first, values, direction)
+	if err != nil {
+		return "", call.handleWorkbookUpdateError(first, err)
+	}
+
+	data, err := d.pkg.ReadPart(workbookPath)
+	if err != nil {
+		return "", call.handleWorkbookUpdateError(first, fmt.Errorf("read workbook %q: %w", workbookPath, err))
+	}
+	wb, err := call.openEmbeddedWorkbook(workbookPath, data)
+	if err != nil {
+		return "", call.handleWorkbookUpdateError(first, fmt.Errorf("open workbook %q: %w", workbookPath, err))
+	}
+	if err := call.checkWorkbookWrite(workbookPath, wb, updates); err != nil {
+		return "", err
+	}
+
+	cells := make([]xlsxembed.CellValue, len(updates))
+	for i, update := range updates {
+		if err := validateCellValue(update.Value); err != nil {
+			return "", call.handleWorkbookUpdateError(update, fmt.Errorf("update workbook %q: %w", workbookPath, err))
+		}
+		if err := call.checkCellText(update); err != nil {
+			return "", err
+		}
+		cells[i] = xlsxembed.CellValue{Number: update.Value.Number, String: update.Value.String}
+	}
+
+	xlDirection := xlsxembed.RangeDown
+	if direction == RangeRight {
+		xlDirection = xlsxembed.RangeRight
+	}
+	end, err := wb.SetRangeValues(sheet, updates[0].Cell, cells, xlDirection)
+	if err != nil {
+		return "", call.handleWorkbookUpdateError(first, fmt.Errorf("update workbook %q: %w", workbookPath, err))
+	}
+	newBytes, err := d.saveWorkbook(wb)
+	if err != nil {
+		return "", call.handleWorkbookUpdateError(first, fmt.Errorf("save workbook %q: %w", workbookPath, err))
+	}
+	if err := d.checkWorkbookSize(workbookPath, newBytes); err != nil {
+		return "", err
+	}
+
+	d.pkg.WritePart(workbookPath, newBytes)
+	d.manifest.record(workbookRangeChange(workbookPath, sheet, updates[0].Cell, end, len(updates)))
+	return end, nil
+}
+
+// rangeUpdates expands a SetWorkbookRange call into one update per cell,
+// from the normalized start cell.
+func rangeUpdates(first CellUpdate, values []CellValue, direction RangeDirection) ([]CellUpdate, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("values are required")
+	}
+	if direction != RangeDown && direction != RangeRight {
+		return nil, fmt.Errorf("unknown range direction %d", direction)
+	}
+	col, row, _, err := xlref.SplitCellRef(first.Cell)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cell %q: %w", first.Cell, err)
+	}
+	startCol := xlref.ColumnIndex(col)
+
+	updates := make([]CellUpdate, len(values))
+	for i, value := range values {
+		cellCol, cellRow := col, row
+		if direction == RangeDown {
+			cellRow = row + i
+		} else {
+			cellCol = xlref.ColumnName(startCol + i)
+		}
+		updates[i] = CellUpdate{
+			WorkbookPath: first.WorkbookPath,
+			Sheet:        first.Sheet,
+			Cell:         fmt.Sprintf("%s%d", cellCol, cellRow),
+			Value:        value,
+		}
+	}
+	return updates, nil
+}
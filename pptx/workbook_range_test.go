@@ -0,0 +1,188 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const rangeWorkbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+
+func rangeValues(n int) []CellValue {
+	values := make([]CellValue, n)
+	for i := range values {
+		values[i] = Num(float64(i + 1))
+	}
+	return values
+}
+
+func TestSetWorkbookRangeMatchesSetWorkbookCells(t *testing.T) {
+	exercisesFeature(t, "workbook.range-write")
+
+	values := rangeValues(200)
+	dir := t.TempDir()
+
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(manifestOptions()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	end, err := doc.SetWorkbookRange(rangeWorkbookPath, "Sheet1", "d2", values, RangeDown)
+	if err != nil {
+		t.Fatalf("SetWorkbookRange: %v", err)
+	}
+	if end != "D201" {
+		t.Fatalf("expected end cell D201, got %q", end)
+	}
+	ranged := filepath.Join(dir, "range.pptx")
+	if err := doc.SaveFile(ranged); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	cells, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	updates := make([]CellUpdate, len(values))
+	for i, value := range values {
+		updates[i] = CellUpdate{WorkbookPath: rangeWorkbookPath, Sheet: "Sheet1", Cell: "D" + strconv.Itoa(i+2), Value: value}
+	}
+	if err := cells.SetWorkbookCells(updates); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	perCell := filepath.Join(dir, "cells.pptx")
+	if err := cells.SaveFile(perCell); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	got := readSheetFromXLSX(t, readEmbeddedWorkbook(t, ranged, rangeWorkbookPath), "xl/worksheets/sheet1.xml")
+	want := readSheetFromXLSX(t, readEmbeddedWorkbook(t, perCell, rangeWorkbookPath), "xl/worksheets/sheet1.xml")
+	// The per-cell path re-encodes the sheet once per cell, so compare the
+	// cells rather than the bytes.
+	if !reflect.DeepEqual(sheetCells(t, got), sheetCells(t, want)) {
+		t.Fatalf("range write differs from the per-cell write:\n%v\nwant\n%v", sheetCells(t, got), sheetCells(t, want))
+	}
+	if len(got) > len(want) {
+		t.Fatalf("expected the single rewrite to be no larger: %d > %d bytes", len(got), len(want))
+	}
+	if _, value, ok := readCellFromSheet(got, "D201"); !ok || value != "200" {
+		t.Fatalf("D201 = %q (ok=%v)", value, ok)
+	}
+
+	manifest, err := doc.ChangeManifest()
+	if err != nil || manifest == nil || len(manifest.Runs) != 1 {
+		t.Fatalf("ChangeManifest: %#v %v", manifest, err)
+	}
+	change := manifest.Runs[0].Changes[0]
+	if change.Operation != "setWorkbookRange" || !reflect.DeepEqual(change.Ranges, []string{"Sheet1!D2:D201"}) || change.ValueCount != 200 {
+		t.Fatalf("unexpected manifest change: %#v", change)
+	}
+}
+
+func TestSetWorkbookRangeChecks(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Workbook.MaxRowsPerWrite = 10
+	doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"), WithOptions(opts))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := doc.SetWorkbookRange(rangeWorkbookPath, "Sheet1", "D2", rangeValues(50), RangeDown); !errors.Is(err, ErrWorkbookWriteLimit) {
+		t.Fatalf("expected ErrWorkbookWriteLimit, got %v", err)
+	}
+	if _, err := doc.SetWorkbookRange(rangeWorkbookPath, "Sheet1", "XFC1", rangeValues(3), RangeRight); !errors.Is(err, ErrCellOutOfBounds) {
+		t.Fatalf("expected ErrCellOutOfBounds, got %v", err)
+	}
+
+	doc, err = OpenFile(fixturePath("bar_simple_embedded.pptx"), WithErrorMode(BestEffort))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	before, err := doc.pkg.ReadPart(rangeWorkbookPath)
+	if err != nil {
+		t.Fatalf("ReadPart: %v", err)
+	}
+	end, err := doc.SetWorkbookRange(rangeWorkbookPath, "Missing", "A1", rangeValues(3), RangeRight)
+	if err != nil || end != "" {
+		t.Fatalf("expected BestEffort to skip the write, got %q %v", end, err)
+	}
+	if len(doc.AlertsByCode("WORKBOOK_UPDATE_FAILED")) != 1 {
+		t.Fatalf("expected WORKBOOK_UPDATE_FAILED, got %#v", doc.Alerts())
+	}
+	if after, _ := doc.pkg.ReadPart(rangeWorkbookPath); !bytes.Equal(after, before) {
+		t.Fatalf("a failed range write must leave the workbook as it was")
+	}
+}
+
+const rangeBenchmarkCells = 10000
+
+func BenchmarkSetWorkbookRange(b *testing.B) {
+	values := rangeValues(rangeBenchmarkCells)
+	for i := 0; i < b.N; i++ {
+		doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+		if err != nil {
+			b.Fatalf("OpenFile: %v", err)
+		}
+		if _, err := doc.SetWorkbookRange(rangeWorkbookPath, "Sheet1", "D2", values, RangeDown); err != nil {
+			b.Fatalf("SetWorkbookRange: %v", err)
+		}
+	}
+}
+
+// BenchmarkSetWorkbookCellsPerCell is the per-cell baseline for
+// BenchmarkSetWorkbookRange. Every cell rewrites the sheet, so the cost grows
+// with the square of the cell count; run it with -benchtime 1x and a
+// -timeout well above the default.
+func BenchmarkSetWorkbookCellsPerCell(b *testing.B) {
+	values := rangeValues(rangeBenchmarkCells)
+	updates := make([]CellUpdate, len(values))
+	for i, value := range values {
+		updates[i] = CellUpdate{WorkbookPath: rangeWorkbookPath, Sheet: "Sheet1", Cell: "D" + strconv.Itoa(i+2), Value: value}
+	}
+	for i := 0; i < b.N; i++ {
+		doc, err := OpenFile(fixturePath("bar_simple_embedded.pptx"))
+		if err != nil {
+			b.Fatalf("OpenFile: %v", err)
+		}
+		if err := doc.SetWorkbookCells(updates); err != nil {
+			b.Fatalf("SetWorkbookCells: %v", err)
+		}
+	}
+}
+
+// sheetCells lists the cells of a worksheet in document order as
+// "ref=value", the value being the cell's text content.
+func sheetCells(t *testing.T, sheet []byte) []string {
+	t.Helper()
+	decoder := xml.NewDecoder(bytes.NewReader(sheet))
+	var cells []string
+	ref, text := "", ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return cells
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if tok.Name.Local == "c" {
+				ref, text = "", ""
+				for _, attr := range tok.Attr {
+					if attr.Name.Local == "r" {
+						ref = attr.Value
+					}
+				}
+			}
+		case xml.CharData:
+			if ref != "" {
+				text += string(tok)
+			}
+		case xml.EndElement:
+			if tok.Name.Local == "c" {
+				cells = append(cells, ref+"="+text)
+				ref = ""
+			}
+		}
+	}
+}
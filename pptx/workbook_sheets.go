@@ -0,0 +1,128 @@
+package pptx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"why-pptx/internal/xlref"
+	"why-pptx/internal/xlsxembed"
+)
+
+// WorkbookSheetInfo describes the view and print settings of one sheet of an
+// embedded workbook, for template hygiene checks that flag data sheets
+// without a frozen header row or a print area.
+type WorkbookSheetInfo struct {
+	WorkbookPath string `json:"workbookPath"`
+	Sheet        string `json:"sheet"`
+	// FrozenRows and FrozenColumns count the rows and columns kept in view
+	// by a frozen pane; both are 0 for a sheet without one.
+	FrozenRows    int `json:"frozenRows"`
+	FrozenColumns int `json:"frozenColumns"`
+	// PaneState is the state of the first sheetView's pane ("frozen",
+	// "frozenSplit", or "split"), empty when there is no pane.
+	PaneState   string `json:"paneState,omitempty"`
+	TopLeftCell string `json:"topLeftCell,omitempty"`
+	// PrintArea is the sheet's _xlnm.Print_Area, such as
+	// "Sheet1!$A$1:$D$20", empty when none is defined.
+	PrintArea string `json:"printArea,omitempty"`
+}
+
+// WorkbookSheets lists the sheets of an embedded workbook in workbook order
+// with their pane and print area. Cell writes keep both as they are.
+func (d *Document) WorkbookSheets(workbookPath string) ([]WorkbookSheetInfo, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+	data, err := d.pkg.ReadPart(workbookPath)
+	if err != nil {
+		return nil, fmt.Errorf("read workbook %q: %w", workbookPath, err)
+	}
+	wb, err := call.openEmbeddedWorkbook(workbookPath, data)
+	if err != nil {
+		return nil, fmt.Errorf("open workbook %q: %w", workbookPath, err)
+	}
+
+	names, err := wb.SheetNames()
+	if err != nil {
+		return nil, fmt.Errorf("workbook %q: %w", workbookPath, err)
+	}
+	out := make([]WorkbookSheetInfo, 0, len(names))
+	for _, name := range names {
+		layout, err := wb.SheetLayout(name)
+		if err != nil {
+			return nil, fmt.Errorf("workbook %q: %w", workbookPath, err)
+		}
+		info := WorkbookSheetInfo{WorkbookPath: workbookPath, Sheet: name, PrintArea: layout.PrintArea}
+		if layout.Pane != nil {
+			info.FrozenRows = layout.Pane.FrozenRows()
+			info.FrozenColumns = layout.Pane.FrozenColumns()
+			info.PaneState = layout.Pane.State
+			info.TopLeftCell = layout.Pane.TopLeftCell
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// checkFrozenHeader warns about writes that create rows inside a sheet's
+// frozen header, which usually means the caller's row numbers are off by
+// one. Writes to header rows that already exist are ordinary edits.
+func (d *docCall) checkFrozenHeader(workbookPath string, wb *xlsxembed.Workbook, updates []CellUpdate) {
+	rowsBySheet := make(map[string]map[int]struct{})
+	for _, update := range updates {
+		_, row, _, err := xlref.SplitCellRef(update.Cell)
+		if err != nil {
+			continue
+		}
+		rows := rowsBySheet[update.Sheet]
+		if rows == nil {
+			rows = make(map[int]struct{})
+			rowsBySheet[update.Sheet] = rows
+		}
+		rows[row] = struct{}{}
+	}
+
+	sheets := make([]string, 0, len(rowsBySheet))
+	for sheet := range rowsBySheet {
+		sheets = append(sheets, sheet)
+	}
+	sort.Strings(sheets)
+	for _, sheet := range sheets {
+		layout, err := wb.SheetLayout(sheet)
+		if err != nil || layout.Pane == nil {
+			continue
+		}
+		frozen := layout.Pane.FrozenRows()
+		header := make(map[int]struct{})
+		for row := range rowsBySheet[sheet] {
+			if row <= frozen {
+				header[row] = struct{}{}
+			}
+		}
+		if len(header) == 0 {
+			continue
+		}
+		added, err := wb.NewRowNumbers(sheet, header)
+		if err != nil || len(added) == 0 {
+			continue
+		}
+		rows := make([]string, len(added))
+		for i, row := range added {
+			rows[i] = strconv.Itoa(row)
+		}
+		d.addAlert(Alert{
+			Level:   "warn",
+			Code:    "WORKBOOK_WRITE_IN_FROZEN_HEADER",
+			Message: "Write appends rows inside the sheet's frozen header",
+			Context: map[string]string{
+				"workbook":   workbookPath,
+				"sheet":      sheet,
+				"rows":       strings.Join(rows, ","),
+				"frozenRows": strconv.Itoa(frozen),
+			},
+		})
+	}
+}
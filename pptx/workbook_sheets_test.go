@@ -0,0 +1,140 @@
+package pptx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const frozenWorkbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+
+func frozenFixtureSheets() []WorkbookSheetInfo {
+	return []WorkbookSheetInfo{
+		{WorkbookPath: frozenWorkbookPath, Sheet: "Data", FrozenRows: 1, PaneState: "frozen", TopLeftCell: "A2", PrintArea: "Data!$A$1:$B$3"},
+		{WorkbookPath: frozenWorkbookPath, Sheet: "Scratch"},
+	}
+}
+
+func TestWorkbookSheetsReportsPaneAndPrintArea(t *testing.T) {
+	exercisesFeature(t, "workbook.sheet-layout")
+
+	doc, err := OpenFile(fixturePath("workbook_frozen_print_area.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	sheets, err := doc.WorkbookSheets(frozenWorkbookPath)
+	if err != nil {
+		t.Fatalf("WorkbookSheets: %v", err)
+	}
+	if !reflect.DeepEqual(sheets, frozenFixtureSheets()) {
+		t.Fatalf("unexpected sheets: %+v", sheets)
+	}
+}
+
+func TestWorkbookWritesKeepPaneAndPrintArea(t *testing.T) {
+	exercisesFeature(t, "workbook.sheet-layout")
+
+	doc, err := OpenFile(fixturePath("workbook_frozen_print_area.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartDataByPath("ppt/charts/chart1.xml", map[string][]string{
+		"categories": {"Q1", "Q2"},
+		"values:0":   {"30", "40"},
+	}); err != nil {
+		t.Fatalf("ApplyChartDataByPath: %v", err)
+	}
+	if err := doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: frozenWorkbookPath, Sheet: "Data", Cell: "A4", Value: Str("Q3")},
+		{WorkbookPath: frozenWorkbookPath, Sheet: "Data", Cell: "B4", Value: Num(50)},
+		{WorkbookPath: frozenWorkbookPath, Sheet: "Data", Cell: "B1", Value: Str("Revenue (USD)")},
+	}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	if alerts := doc.AlertsByCode("WORKBOOK_WRITE_IN_FROZEN_HEADER"); len(alerts) != 0 {
+		t.Fatalf("writes below the header must not warn: %#v", alerts)
+	}
+
+	output := filepath.Join(t.TempDir(), "output.pptx")
+	if err := doc.SaveFile(output); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	saved, err := OpenFile(output)
+	if err != nil {
+		t.Fatalf("OpenFile saved: %v", err)
+	}
+	sheets, err := saved.WorkbookSheets(frozenWorkbookPath)
+	if err != nil {
+		t.Fatalf("WorkbookSheets: %v", err)
+	}
+	if !reflect.DeepEqual(sheets, frozenFixtureSheets()) {
+		t.Fatalf("writes changed the sheet layout: %+v", sheets)
+	}
+}
+
+func TestWorkbookWriteInFrozenHeaderWarns(t *testing.T) {
+	// Two frozen rows, a title in row 1 and an empty spacer row 2.
+	sheet := `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetViews><sheetView workbookViewId="0"><pane ySplit="2" topLeftCell="A3" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>
+  <sheetData>
+    <row r="1"><c r="A1" t="inlineStr"><is><t>Revenue</t></is></c></row>
+    <row r="3"><c r="A3"><v>10</v></c></row>
+  </sheetData>
+</worksheet>`
+	workbook := writeZipBytes(t, map[string][]byte{
+		"[Content_Types].xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="xml" ContentType="application/xml"/>
+</Types>`),
+		"xl/workbook.xml": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Sheet1" sheetId="1" r:id="rId1"/>
+  </sheets>
+</workbook>`),
+		"xl/_rels/workbook.xml.rels": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`),
+		"xl/worksheets/sheet1.xml": []byte(sheet),
+	})
+	path := filepath.Join(t.TempDir(), "input.pptx")
+	if err := writeZipFile(path, map[string][]byte{frozenWorkbookPath: workbook}); err != nil {
+		t.Fatalf("writeZipFile: %v", err)
+	}
+
+	for _, mode := range []ErrorMode{Strict, BestEffort} {
+		opts := DefaultOptions()
+		opts.Mode = mode
+		doc, err := OpenFile(path, WithOptions(opts))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+
+		if err := doc.SetWorkbookCells([]CellUpdate{
+			{WorkbookPath: frozenWorkbookPath, Sheet: "Sheet1", Cell: "A1", Value: Str("Revenue 2026")},
+			{WorkbookPath: frozenWorkbookPath, Sheet: "Sheet1", Cell: "A4", Value: Num(20)},
+		}); err != nil {
+			t.Fatalf("SetWorkbookCells: %v", err)
+		}
+		if alerts := doc.AlertsByCode("WORKBOOK_WRITE_IN_FROZEN_HEADER"); len(alerts) != 0 {
+			t.Fatalf("existing header rows and rows below must not warn: %#v", alerts)
+		}
+
+		if err := doc.SetWorkbookCells([]CellUpdate{
+			{WorkbookPath: frozenWorkbookPath, Sheet: "Sheet1", Cell: "A2", Value: Num(15)},
+			{WorkbookPath: frozenWorkbookPath, Sheet: "Sheet1", Cell: "B2", Value: Num(16)},
+		}); err != nil {
+			t.Fatalf("SetWorkbookCells: %v", err)
+		}
+		alerts := doc.AlertsByCode("WORKBOOK_WRITE_IN_FROZEN_HEADER")
+		if len(alerts) != 1 || alerts[0].Level != "warn" {
+			t.Fatalf("mode %v: expected one warning, got %#v", mode, doc.Alerts())
+		}
+		want := map[string]string{"workbook": frozenWorkbookPath, "sheet": "Sheet1", "rows": "2", "frozenRows": "2"}
+		if !reflect.DeepEqual(alerts[0].Context, want) {
+			t.Fatalf("unexpected context: %#v", alerts[0].Context)
+		}
+	}
+}
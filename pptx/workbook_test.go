@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"testing"
 
@@ -14,6 +15,8 @@ import (
 )
 
 func TestSetWorkbookCellsIntegration(t *testing.T) {
+	exercisesFeature(t, "workbook.write")
+
 	dir := t.TempDir()
 	inputPath := filepath.Join(dir, "input.pptx")
 	outputPath := filepath.Join(dir, "output.pptx")
@@ -65,6 +68,119 @@ func TestSetWorkbookCellsIntegration(t *testing.T) {
 	}
 }
 
+func TestWorkbookSheetExtrasSurviveWrites(t *testing.T) {
+	inputPath := fixturePath("workbook_sheet_extras.pptx")
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	const workbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+
+	doc, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, map[string][]string{
+		"categories": {"Q1", "Q2"},
+		"values:0":   {"15", "25"},
+	}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	if err := doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: workbookPath, Sheet: "Sheet1", Cell: "A4", Value: Str("Q3")},
+		{WorkbookPath: workbookPath, Sheet: "Sheet1", Cell: "B4", Value: Num(35)},
+	}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	before := readEmbeddedWorkbook(t, inputPath, workbookPath)
+	after := readEmbeddedWorkbook(t, outputPath, workbookPath)
+	for _, part := range []string{"xl/worksheets/_rels/sheet1.xml.rels", "xl/comments1.xml", "xl/drawings/vmlDrawing1.vml"} {
+		if !bytes.Equal(readSheetFromXLSX(t, before, part), readSheetFromXLSX(t, after, part)) {
+			t.Fatalf("expected %s to be unchanged", part)
+		}
+	}
+
+	original := readSheetFromXLSX(t, before, "xl/worksheets/sheet1.xml")
+	sheet := readSheetFromXLSX(t, after, "xl/worksheets/sheet1.xml")
+	suffix := original[bytes.Index(original, []byte("</sheetData>"))+len("</sheetData>"):]
+	if !bytes.HasSuffix(sheet, suffix) {
+		t.Fatalf("worksheet children after sheetData changed:\n%s", sheet)
+	}
+	for _, want := range []string{`<legacyDrawing r:id="rId1"/>`, `<hyperlink ref="B1" r:id="rId2" display="Source"/>`, `sqref="A2:A10"`} {
+		if !bytes.Contains(sheet, []byte(want)) {
+			t.Fatalf("expected %s in worksheet:\n%s", want, sheet)
+		}
+	}
+	if typ, val, ok := readCellFromSheet(sheet, "B4"); !ok || typ != "" || val != "35" {
+		t.Fatalf("unexpected appended B4: type=%q val=%q ok=%v", typ, val, ok)
+	}
+	if typ, val, ok := readCellFromSheet(sheet, "B2"); !ok || typ != "" || val != "15" {
+		t.Fatalf("unexpected B2: type=%q val=%q ok=%v", typ, val, ok)
+	}
+}
+
+func TestWorkbookWritesPlaceNewRowsByNumber(t *testing.T) {
+	exercisesFeature(t, "workbook.write")
+
+	inputPath := fixturePath("workbook_rows_out_of_order.pptx")
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	const workbookPath = "ppt/embeddings/embeddedWorkbook1.xlsx"
+
+	doc, err := OpenFile(inputPath)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if err := doc.ApplyChartData(0, map[string][]string{
+		"categories": {"Q1", "Q2"},
+		"values:0":   {"15", "25"},
+	}); err != nil {
+		t.Fatalf("ApplyChartData: %v", err)
+	}
+	if err := doc.SetWorkbookCells([]CellUpdate{
+		{WorkbookPath: workbookPath, Sheet: "Sheet1", Cell: "A4", Value: Str("Q3")},
+		{WorkbookPath: workbookPath, Sheet: "Sheet1", Cell: "B4", Value: Num(35)},
+		{WorkbookPath: workbookPath, Sheet: "Sheet1", Cell: "B1", Value: Str("Revenue")},
+		{WorkbookPath: workbookPath, Sheet: "Sheet1", Cell: "A11", Value: Str("Checked 2026-10")},
+	}); err != nil {
+		t.Fatalf("SetWorkbookCells: %v", err)
+	}
+	if err := doc.SaveFile(outputPath); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	sheet := readSheetFromXLSX(t, readEmbeddedWorkbook(t, outputPath, workbookPath), "xl/worksheets/sheet1.xml")
+	var rows []string
+	decoder := xml.NewDecoder(bytes.NewReader(sheet))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("parse worksheet: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "row" {
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "r" {
+					rows = append(rows, attr.Value)
+				}
+			}
+		}
+	}
+	// Row 10 stays first as in the input; 11 follows it, 1 precedes the
+	// lowest existing row, and 4 follows 3.
+	if want := []string{"10", "11", "1", "2", "3", "4"}; !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows %v, want %v", rows, want)
+	}
+
+	for ref, want := range map[string]string{"A10": "Source: regional survey", "B2": "15", "B3": "25", "B4": "35", "B1": "Revenue"} {
+		if _, val, ok := readCellFromSheet(sheet, ref); !ok || val != want {
+			t.Fatalf("cell %s: got %q (%v), want %q", ref, val, ok, want)
+		}
+	}
+}
+
 func buildTestXLSX(t *testing.T) []byte {
 	t.Helper()
 
@@ -128,7 +244,7 @@ func writeZipFile(path string, parts map[string][]byte) error {
 	return nil
 }
 
-func writeZipBytes(t *testing.T, parts map[string][]byte) []byte {
+func writeZipBytes(t testing.TB, parts map[string][]byte) []byte {
 	t.Helper()
 
 	var buf bytes.Buffer
@@ -0,0 +1,296 @@
+package pptx
+
+import (
+	"fmt"
+	"sort"
+
+	"why-pptx/internal/xlref"
+)
+
+// WorkbookUsage groups the charts backed by one embedded workbook with the
+// cells they read.
+type WorkbookUsage struct {
+	WorkbookPath string               `json:"workbookPath"`
+	Charts       []WorkbookChartRef   `json:"charts"`
+	Sheets       []WorkbookSheetUsage `json:"sheets"`
+	// Overlaps lists every pair of charts reading shared cells.
+	Overlaps      []RangeOverlap `json:"overlaps"`
+	DistinctCells int            `json:"distinctCells"`
+	SheetCount    int            `json:"sheetCount"`
+}
+
+type WorkbookChartRef struct {
+	ChartPath string `json:"chartPath"`
+	SlidePath string `json:"slidePath"`
+	Title     string `json:"title,omitempty"`
+}
+
+// WorkbookSheetUsage is the union of the ranges read from one sheet, merged
+// into non-overlapping A1 ranges without a sheet prefix.
+type WorkbookSheetUsage struct {
+	Sheet         string   `json:"sheet"`
+	Ranges        []string `json:"ranges"`
+	DistinctCells int      `json:"distinctCells"`
+}
+
+// RangeOverlap reports cells read by two different charts.
+type RangeOverlap struct {
+	Sheet     string    `json:"sheet"`
+	Cells     string    `json:"cells"`
+	CellCount int       `json:"cellCount"`
+	First     RangeRead `json:"first"`
+	Second    RangeRead `json:"second"`
+}
+
+// RangeRead is one chart range taking part in an overlap.
+type RangeRead struct {
+	ChartPath   string         `json:"chartPath"`
+	Kind        ChartRangeKind `json:"kind"`
+	SeriesIndex int            `json:"seriesIndex"`
+	Formula     string         `json:"formula"`
+}
+
+type rangeUse struct {
+	chartPath string
+	chartPos  int
+	r         ChartRange
+	bounds    xlref.Bounds
+}
+
+// WorkbookUsage reports, per embedded workbook, which charts read it and
+// which cells they read. Workbooks are ordered by path and charts by their
+// discovery order, so the result is stable across runs. Charts whose
+// dependencies cannot be read are listed without ranges in BestEffort.
+func (d *Document) WorkbookUsage() ([]WorkbookUsage, error) {
+	if d == nil || d.pkg == nil {
+		return nil, fmt.Errorf("document not initialized")
+	}
+	call := d.newCall(nil)
+
+	charts, err := d.ListCharts()
+	if err != nil {
+		return nil, err
+	}
+
+	byWorkbook := make(map[string]*WorkbookUsage)
+	usesByWorkbook := make(map[string][]rangeUse)
+	for _, chart := range charts {
+		usage, ok := byWorkbook[chart.WorkbookPath]
+		if !ok {
+			usage = &WorkbookUsage{WorkbookPath: chart.WorkbookPath}
+			byWorkbook[chart.WorkbookPath] = usage
+		}
+		chartPos := len(usage.Charts)
+		usage.Charts = append(usage.Charts, WorkbookChartRef{
+			ChartPath: chart.ChartPath,
+			SlidePath: chart.SlidePath,
+			Title:     chart.Title,
+		})
+
+		embedded := EmbeddedChart{
+			SlidePath:    chart.SlidePath,
+			ChartPath:    chart.ChartPath,
+			WorkbookPath: chart.WorkbookPath,
+		}
+		dep, ok, err := call.chartDependencies(embedded)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		uses, err := chartRangeUses(dep, chartPos)
+		if err != nil {
+			if err := call.handleChartInfoError(embedded, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		usesByWorkbook[chart.WorkbookPath] = append(usesByWorkbook[chart.WorkbookPath], uses...)
+	}
+
+	paths := make([]string, 0, len(byWorkbook))
+	for workbookPath := range byWorkbook {
+		paths = append(paths, workbookPath)
+	}
+	sort.Strings(paths)
+
+	out := make([]WorkbookUsage, 0, len(paths))
+	for _, workbookPath := range paths {
+		usage := byWorkbook[workbookPath]
+		usage.Sheets, usage.DistinctCells = sheetUsage(usesByWorkbook[workbookPath])
+		usage.SheetCount = len(usage.Sheets)
+		usage.Overlaps = rangeOverlaps(usesByWorkbook[workbookPath])
+		out = append(out, *usage)
+	}
+	return out, nil
+}
+
+// chartRangeUses resolves a chart's ranges to cell bounds, dropping repeats
+// of the same area (series commonly share one categories range).
+func chartRangeUses(dep ChartDependencies, chartPos int) ([]rangeUse, error) {
+	uses := make([]rangeUse, 0, len(dep.Ranges))
+	seen := make(map[string]struct{}, len(dep.Ranges))
+	for _, r := range dep.Ranges {
+		bounds, err := xlref.RangeRef{Sheet: r.Sheet, StartCell: r.StartCell, EndCell: r.EndCell}.Bounds()
+		if err != nil {
+			return nil, fmt.Errorf("range %q in %s: %w", r.Formula, dep.ChartPath, err)
+		}
+		key := r.Sheet + "!" + bounds.String()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		uses = append(uses, rangeUse{chartPath: dep.ChartPath, chartPos: chartPos, r: r, bounds: bounds})
+	}
+	return uses, nil
+}
+
+func sheetUsage(uses []rangeUse) ([]WorkbookSheetUsage, int) {
+	areasBySheet := make(map[string][]xlref.Bounds)
+	for _, use := range uses {
+		areasBySheet[use.r.Sheet] = append(areasBySheet[use.r.Sheet], use.bounds)
+	}
+
+	sheets := make([]string, 0, len(areasBySheet))
+	for sheet := range areasBySheet {
+		sheets = append(sheets, sheet)
+	}
+	sort.Strings(sheets)
+
+	out := make([]WorkbookSheetUsage, 0, len(sheets))
+	total := 0
+	for _, sheet := range sheets {
+		union, cells := unionBounds(areasBySheet[sheet])
+		ranges := make([]string, 0, len(union))
+		for _, b := range union {
+			ranges = append(ranges, b.String())
+		}
+		out = append(out, WorkbookSheetUsage{Sheet: sheet, Ranges: ranges, DistinctCells: cells})
+		total += cells
+	}
+	return out, total
+}
+
+// unionBounds merges areas into disjoint bounds and counts their cells. The
+// columns are cut into strips at every area edge; within a strip the covered
+// rows are plain intervals, and neighbouring strips with the same rows are
+// joined again.
+func unionBounds(areas []xlref.Bounds) ([]xlref.Bounds, int) {
+	edgeSet := make(map[int]struct{}, len(areas)*2)
+	for _, area := range areas {
+		edgeSet[area.StartCol] = struct{}{}
+		edgeSet[area.EndCol+1] = struct{}{}
+	}
+	edges := make([]int, 0, len(edgeSet))
+	for edge := range edgeSet {
+		edges = append(edges, edge)
+	}
+	sort.Ints(edges)
+
+	var strips []xlref.Bounds
+	for i := 0; i+1 < len(edges); i++ {
+		startCol, endCol := edges[i], edges[i+1]-1
+		var rows [][2]int
+		for _, area := range areas {
+			if area.StartCol <= startCol && area.EndCol >= endCol {
+				rows = append(rows, [2]int{area.StartRow, area.EndRow})
+			}
+		}
+		for _, interval := range mergeIntervals(rows) {
+			strips = append(strips, xlref.Bounds{StartCol: startCol, EndCol: endCol, StartRow: interval[0], EndRow: interval[1]})
+		}
+	}
+
+	// Join a strip into an earlier one spanning the same rows when their
+	// columns touch. Strips arrive ordered by column, so one pass suffices.
+	var merged []xlref.Bounds
+	for _, strip := range strips {
+		joined := false
+		for i := range merged {
+			if merged[i].StartRow == strip.StartRow && merged[i].EndRow == strip.EndRow && merged[i].EndCol+1 == strip.StartCol {
+				merged[i].EndCol = strip.EndCol
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			merged = append(merged, strip)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].StartCol != merged[j].StartCol {
+			return merged[i].StartCol < merged[j].StartCol
+		}
+		return merged[i].StartRow < merged[j].StartRow
+	})
+
+	cells := 0
+	for _, b := range merged {
+		cells += b.Cells()
+	}
+	return merged, cells
+}
+
+func mergeIntervals(intervals [][2]int) [][2]int {
+	if len(intervals) == 0 {
+		return nil
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i][0] < intervals[j][0] })
+	out := [][2]int{intervals[0]}
+	for _, interval := range intervals[1:] {
+		last := &out[len(out)-1]
+		if interval[0] <= last[1]+1 {
+			last[1] = max(last[1], interval[1])
+			continue
+		}
+		out = append(out, interval)
+	}
+	return out
+}
+
+func rangeOverlaps(uses []rangeUse) []RangeOverlap {
+	overlaps := make([]RangeOverlap, 0)
+	for i := range uses {
+		for j := i + 1; j < len(uses); j++ {
+			a, b := uses[i], uses[j]
+			if a.chartPos == b.chartPos || a.r.Sheet != b.r.Sheet {
+				continue
+			}
+			shared, ok := a.bounds.Intersect(b.bounds)
+			if !ok {
+				continue
+			}
+			if b.chartPos < a.chartPos {
+				a, b = b, a
+			}
+			overlaps = append(overlaps, RangeOverlap{
+				Sheet:     a.r.Sheet,
+				Cells:     shared.String(),
+				CellCount: shared.Cells(),
+				First:     rangeRead(a),
+				Second:    rangeRead(b),
+			})
+		}
+	}
+	sort.SliceStable(overlaps, func(i, j int) bool {
+		if overlaps[i].Sheet != overlaps[j].Sheet {
+			return overlaps[i].Sheet < overlaps[j].Sheet
+		}
+		if overlaps[i].First.ChartPath != overlaps[j].First.ChartPath {
+			return overlaps[i].First.ChartPath < overlaps[j].First.ChartPath
+		}
+		return overlaps[i].Second.ChartPath < overlaps[j].Second.ChartPath
+	})
+	return overlaps
+}
+
+func rangeRead(use rangeUse) RangeRead {
+	return RangeRead{
+		ChartPath:   use.chartPath,
+		Kind:        use.r.Kind,
+		SeriesIndex: use.r.SeriesIndex,
+		Formula:     use.r.Formula,
+	}
+}
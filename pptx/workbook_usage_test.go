@@ -0,0 +1,133 @@
+package pptx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"why-pptx/internal/xlref"
+)
+
+func TestWorkbookUsageSharedWorkbook(t *testing.T) {
+	doc, err := OpenFile(fixturePath("shared_workbook_two_charts.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	usage, err := doc.WorkbookUsage()
+	if err != nil {
+		t.Fatalf("WorkbookUsage: %v", err)
+	}
+	if len(usage) != 1 || usage[0].WorkbookPath != "ppt/embeddings/embeddedWorkbook1.xlsx" {
+		t.Fatalf("expected one shared workbook, got %#v", usage)
+	}
+
+	wb := usage[0]
+	if len(wb.Charts) != 2 || wb.Charts[0].ChartPath != "ppt/charts/chart1.xml" || wb.Charts[1].ChartPath != "ppt/charts/chart2.xml" {
+		t.Fatalf("unexpected charts: %#v", wb.Charts)
+	}
+	if wb.Charts[0].SlidePath != "ppt/slides/slide1.xml" {
+		t.Fatalf("unexpected slide: %#v", wb.Charts[0])
+	}
+	if wb.SheetCount != 1 || wb.DistinctCells != 8 {
+		t.Fatalf("unexpected totals: sheets=%d cells=%d", wb.SheetCount, wb.DistinctCells)
+	}
+	if got := strings.Join(wb.Sheets[0].Ranges, ","); got != "A2:B3,D2:E3" {
+		t.Fatalf("unexpected merged ranges: %s", got)
+	}
+	if len(wb.Overlaps) != 0 {
+		t.Fatalf("expected no overlaps, got %#v", wb.Overlaps)
+	}
+}
+
+func TestWorkbookUsageOverlappingCategories(t *testing.T) {
+	doc, err := OpenFile(fixturePath("shared_workbook_overlapping_categories.pptx"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+
+	usage, err := doc.WorkbookUsage()
+	if err != nil {
+		t.Fatalf("WorkbookUsage: %v", err)
+	}
+	if len(usage) != 1 {
+		t.Fatalf("expected one workbook, got %#v", usage)
+	}
+
+	wb := usage[0]
+	if wb.DistinctCells != 6 {
+		t.Fatalf("expected shared categories to be counted once, got %d cells", wb.DistinctCells)
+	}
+	if got := strings.Join(wb.Sheets[0].Ranges, ","); got != "A2:B3,E2:E3" {
+		t.Fatalf("unexpected merged ranges: %s", got)
+	}
+	if len(wb.Overlaps) != 1 {
+		t.Fatalf("expected one overlap, got %#v", wb.Overlaps)
+	}
+	overlap := wb.Overlaps[0]
+	if overlap.Sheet != "Sheet1" || overlap.Cells != "A2:A3" || overlap.CellCount != 2 {
+		t.Fatalf("unexpected overlap: %#v", overlap)
+	}
+	if overlap.First.ChartPath != "ppt/charts/chart1.xml" || overlap.Second.ChartPath != "ppt/charts/chart2.xml" {
+		t.Fatalf("unexpected overlap charts: %#v", overlap)
+	}
+	if overlap.First.Kind != RangeCategories || overlap.Second.Kind != RangeCategories {
+		t.Fatalf("expected categories on both sides: %#v", overlap)
+	}
+}
+
+func TestWorkbookUsageJSONStable(t *testing.T) {
+	marshal := func() string {
+		doc, err := OpenFile(fixturePath("shared_workbook_overlapping_categories.pptx"))
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		usage, err := doc.WorkbookUsage()
+		if err != nil {
+			t.Fatalf("WorkbookUsage: %v", err)
+		}
+		data, err := json.Marshal(usage)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		return string(data)
+	}
+
+	first := marshal()
+	for i := 0; i < 5; i++ {
+		if got := marshal(); got != first {
+			t.Fatalf("WorkbookUsage JSON changed between runs:\n%s\n%s", first, got)
+		}
+	}
+	if !strings.Contains(first, `"cells":"A2:A3"`) || !strings.Contains(first, `"distinctCells":6`) {
+		t.Fatalf("unexpected JSON: %s", first)
+	}
+}
+
+func TestUnionBoundsWholeColumn(t *testing.T) {
+	parse := func(start, end string) xlref.Bounds {
+		b, err := xlref.RangeRef{StartCell: start, EndCell: end}.Bounds()
+		if err != nil {
+			t.Fatalf("Bounds: %v", err)
+		}
+		return b
+	}
+	whole := xlref.Bounds{StartCol: 1, EndCol: 1, StartRow: 1, EndRow: xlref.MaxRows}
+
+	union, cells := unionBounds([]xlref.Bounds{
+		parse("A2", "A6"),
+		whole,
+		parse("B3", "D3"),
+		parse("C3", "C4"),
+	})
+	var got []string
+	for _, b := range union {
+		got = append(got, b.String())
+	}
+	if strings.Join(got, ",") != "A:A,B3,C3:C4,D3" {
+		t.Fatalf("unexpected union: %v", got)
+	}
+	if cells != xlref.MaxRows+4 {
+		t.Fatalf("expected %d cells, got %d", xlref.MaxRows+4, cells)
+	}
+}